@@ -22,6 +22,7 @@ import (
 	"crypto/sha256"
 	"crypto/tls"
 	"crypto/x509"
+	"encoding/base32"
 	"encoding/base64"
 	"encoding/hex"
 	"encoding/json"
@@ -35,6 +36,8 @@ import (
 	"strings"
 	"sync"
 	"time"
+
+	"golang.org/x/crypto/proxy"
 )
 
 // LetsEncryptURL is the Directory endpoint of Let's Encrypt CA.
@@ -68,6 +71,13 @@ type Client struct {
 	// instead of http.DefaultClient.
 	HTTPClient *http.Client
 
+	// Dialer optionally makes the connections of the HTTP client used when
+	// HTTPClient is nil, for example through an HTTP CONNECT or SOCKS5 proxy
+	// of package golang.org/x/crypto/proxy. The proxy settings of the
+	// environment are then ignored.
+	// Mutating this value after the first request has no effect.
+	Dialer proxy.ContextDialer
+
 	// DirectoryURL points to the CA directory endpoint.
 	// If empty, LetsEncryptURL is used.
 	// Mutating this value after a successful call of Client's Discover method
@@ -95,6 +105,9 @@ type Client struct {
 
 	noncesMu sync.Mutex
 	nonces   map[string]struct{} // nonces collected from previous responses
+
+	dialerClientOnce sync.Once
+	dialerClient     *http.Client // HTTP client using Dialer
 }
 
 // Discover performs ACME server discovery using c.DirectoryURL.
@@ -492,6 +505,30 @@ func (c *Client) DNS01ChallengeRecord(token string) (string, error) {
 	return base64.RawURLEncoding.EncodeToString(b[:]), nil
 }
 
+// DNSAccount01ChallengeRecord returns a DNS record value for a dns-account-01
+// challenge response, as defined in draft-ietf-acme-dns-account-label.
+// The value is the same as for dns-01, but the TXT record must be provisioned
+// under the name returned by DNSAccount01ChallengeDomain.
+//
+// The token argument is a Challenge.Token value.
+func (c *Client) DNSAccount01ChallengeRecord(token string) (string, error) {
+	return c.DNS01ChallengeRecord(token)
+}
+
+// DNSAccount01ChallengeDomain returns the name under which the TXT record for
+// a dns-account-01 challenge response must be provisioned.
+// The name has the form "_<label>._acme-challenge.<domain>", where the label is
+// derived from the account, so that several accounts, for example one per CDN,
+// can validate the same domain without conflicting records.
+//
+// The accountURI argument is an Account.URI value and domain is the name
+// being validated. A leading "*." of a wildcard domain is removed.
+func (c *Client) DNSAccount01ChallengeDomain(accountURI, domain string) string {
+	b := sha256.Sum256([]byte(accountURI))
+	label := strings.ToLower(base32.StdEncoding.EncodeToString(b[:10]))
+	return "_" + label + "._acme-challenge." + strings.TrimPrefix(domain, "*.")
+}
+
 // HTTP01ChallengeResponse returns the response for an http-01 challenge.
 // Servers should respond with the value to HTTP requests at the URL path
 // provided by HTTP01ChallengePath to validate the challenge and prove control
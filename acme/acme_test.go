@@ -1258,3 +1258,25 @@ func TestDNS01ChallengeRecord(t *testing.T) {
 		t.Errorf("val = %q; want %q", val, value)
 	}
 }
+
+func TestDNSAccount01Challenge(t *testing.T) {
+	// Example from draft-ietf-acme-dns-account-label.
+	const (
+		account = "https://example.com/acme/acct/ExampleAccount"
+		want    = "_ujmmovf2vn55tgye._acme-challenge.example.org"
+	)
+	client := &Client{Key: testKeyEC}
+	for _, domain := range []string{"example.org", "*.example.org"} {
+		if name := client.DNSAccount01ChallengeDomain(account, domain); name != want {
+			t.Errorf("DNSAccount01ChallengeDomain(%q) = %q; want %q", domain, name, want)
+		}
+	}
+
+	val, err := client.DNSAccount01ChallengeRecord("xxx")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if val != "8DERMexQ5VcdJ_prpPiA0mVdp7imgbCgjsG4SqqNMIo" {
+		t.Errorf("val = %q; want the dns-01 record value", val)
+	}
+}
@@ -19,6 +19,7 @@ import (
 	"crypto/tls"
 	"crypto/x509"
 	"crypto/x509/pkix"
+	"encoding/asn1"
 	"encoding/pem"
 	"errors"
 	"fmt"
@@ -157,6 +158,33 @@ type Manager struct {
 	// in the template's ExtraExtensions field as is.
 	ExtraExtensions []pkix.Extension
 
+	// NewKey optionally generates the private keys of new certificates for
+	// host, which allows choosing the size of RSA keys or the curve of ECDSA
+	// keys per host. It must return an *rsa.PrivateKey if rsaKey is true, and
+	// an *ecdsa.PrivateKey otherwise.
+	//
+	// If nil, 2048-bit RSA keys and ECDSA P-256 keys are generated.
+	NewKey func(host string, rsaKey bool) (crypto.Signer, error)
+
+	// CertificateRequest optionally customizes the requests for new
+	// certificates. It is called with a template holding host as the common
+	// name and ExtraExtensions, and can modify it, for instance to add
+	// subject alternative names, for which the Manager also completes the
+	// authorization flow, or extensions such as MustStaple. The template is
+	// then passed to crypto/x509.CreateCertificateRequest.
+	//
+	// A non-nil error aborts the request.
+	CertificateRequest func(host string, tmpl *x509.CertificateRequest) error
+
+	// ApproveIssuance optionally approves every certificate request, including
+	// renewals, before the Manager starts the authorization flow for it. It is
+	// called with the final, signed request, for instance to check it against
+	// an inventory or to enforce quotas.
+	//
+	// A non-nil error aborts the request. For a new certificate, it is
+	// returned to the caller of GetCertificate.
+	ApproveIssuance func(ctx context.Context, host string, csr *x509.CertificateRequest) error
+
 	clientMu sync.Mutex
 	client   *acme.Client // initialized by acmeClient method
 
@@ -184,6 +212,14 @@ type Manager struct {
 	certTokens map[string]*tls.Certificate
 }
 
+// MustStaple is the TLS Feature extension (RFC 7633) requiring OCSP stapling,
+// which can be added to certificate requests with Manager.ExtraExtensions or
+// Manager.CertificateRequest to prevent OCSP downgrade attacks.
+var MustStaple = pkix.Extension{
+	Id:    asn1.ObjectIdentifier{1, 3, 6, 1, 5, 5, 7, 1, 24},
+	Value: []byte{0x30, 0x03, 0x02, 0x01, 0x05}, // SEQUENCE { INTEGER 5 }, status_request
+}
+
 // certKey is the key by which certificates are tracked in state, renewal and cache.
 type certKey struct {
 	domain  string // without trailing dot
@@ -574,14 +610,33 @@ func (m *Manager) certState(ck certKey) (*certState, error) {
 		err error
 		key crypto.Signer
 	)
-	if ck.isRSA {
+	switch {
+	case m.NewKey != nil:
+		key, err = m.NewKey(ck.domain, ck.isRSA)
+	case ck.isRSA:
 		key, err = rsa.GenerateKey(rand.Reader, 2048)
-	} else {
+	default:
 		key, err = ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
 	}
 	if err != nil {
 		return nil, err
 	}
+	if m.NewKey != nil {
+		// A key of the wrong type would only be caught once the certificate
+		// is issued, after the whole authorization flow, and would then fail
+		// every time.
+		var ok bool
+		want := "*ecdsa.PrivateKey"
+		if ck.isRSA {
+			_, ok = key.(*rsa.PrivateKey)
+			want = "*rsa.PrivateKey"
+		} else {
+			_, ok = key.(*ecdsa.PrivateKey)
+		}
+		if !ok {
+			return nil, fmt.Errorf("acme/autocert: NewKey returned a %T, want %s", key, want)
+		}
+	}
 
 	state := &certState{
 		key:    key,
@@ -600,13 +655,31 @@ func (m *Manager) authorizedCert(ctx context.Context, key crypto.Signer, ck cert
 		return nil, nil, err
 	}
 
-	if err := m.verify(ctx, client, ck.domain); err != nil {
+	csr, err := m.certRequest(key, ck.domain)
+	if err != nil {
 		return nil, nil, err
 	}
-	csr, err := certRequest(key, ck.domain, m.ExtraExtensions)
+	req, err := x509.ParseCertificateRequest(csr)
 	if err != nil {
 		return nil, nil, err
 	}
+	if m.ApproveIssuance != nil {
+		if err := m.ApproveIssuance(ctx, ck.domain, req); err != nil {
+			return nil, nil, err
+		}
+	}
+
+	if err := m.verify(ctx, client, ck.domain); err != nil {
+		return nil, nil, err
+	}
+	for _, name := range req.DNSNames {
+		if name == ck.domain {
+			continue
+		}
+		if err := m.verify(ctx, client, name); err != nil {
+			return nil, nil, err
+		}
+	}
 	der, _, err = client.CreateCert(ctx, csr, 0, true)
 	if err != nil {
 		return nil, nil, err
@@ -963,12 +1036,18 @@ func (s *certState) tlscert() (*tls.Certificate, error) {
 	}, nil
 }
 
-// certRequest generates a CSR for the given common name cn and optional SANs.
-func certRequest(key crypto.Signer, cn string, ext []pkix.Extension, san ...string) ([]byte, error) {
+// certRequest generates a CSR for host, with m.ExtraExtensions, as customized
+// by m.CertificateRequest.
+func (m *Manager) certRequest(key crypto.Signer, host string) ([]byte, error) {
 	req := &x509.CertificateRequest{
-		Subject:         pkix.Name{CommonName: cn},
-		DNSNames:        san,
-		ExtraExtensions: ext,
+		Subject:         pkix.Name{CommonName: host},
+		// Copied, so that m.CertificateRequest can append to it.
+		ExtraExtensions: append([]pkix.Extension(nil), m.ExtraExtensions...),
+	}
+	if m.CertificateRequest != nil {
+		if err := m.CertificateRequest(host, req); err != nil {
+			return nil, err
+		}
 	}
 	return x509.CreateCertificateRequest(rand.Reader, req, key)
 }
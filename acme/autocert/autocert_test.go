@@ -227,6 +227,74 @@ func TestGetCertificate_ForceRSA(t *testing.T) {
 	}
 }
 
+func TestGetCertificate_NewKey(t *testing.T) {
+	var approved []string
+	man := &Manager{
+		Prompt: AcceptTOS,
+		Cache:  newMemCache(t),
+		NewKey: func(host string, rsaKey bool) (crypto.Signer, error) {
+			if host != exampleDomain || rsaKey {
+				t.Errorf("NewKey(%q, %v)", host, rsaKey)
+			}
+			return ecdsa.GenerateKey(elliptic.P384(), rand.Reader)
+		},
+		ApproveIssuance: func(ctx context.Context, host string, csr *x509.CertificateRequest) error {
+			if pub, ok := csr.PublicKey.(*ecdsa.PublicKey); !ok || pub.Curve != elliptic.P384() {
+				t.Errorf("CSR has public key %T", csr.PublicKey)
+			}
+			approved = append(approved, host)
+			return nil
+		},
+	}
+	defer man.stopRenew()
+	hello := clientHelloInfo(exampleDomain, true)
+	testGetCertificate(t, man, exampleDomain, hello)
+
+	if len(approved) != 1 || approved[0] != exampleDomain {
+		t.Errorf("ApproveIssuance called for %q; want [%q]", approved, exampleDomain)
+	}
+	cert, err := man.cacheGet(context.Background(), exampleCertKey)
+	if err != nil {
+		t.Fatalf("man.cacheGet: %v", err)
+	}
+	if key, ok := cert.PrivateKey.(*ecdsa.PrivateKey); !ok || key.Curve != elliptic.P384() {
+		t.Errorf("cert.PrivateKey is %T; want a P-384 *ecdsa.PrivateKey", cert.PrivateKey)
+	}
+}
+
+func TestGetCertificate_NewKeyWrongType(t *testing.T) {
+	man := &Manager{
+		Prompt: AcceptTOS,
+		NewKey: func(host string, rsaKey bool) (crypto.Signer, error) {
+			return ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+		},
+	}
+	defer man.stopRenew()
+	// The Manager must fail before contacting the CA, so no server is
+	// needed.
+	man.Client = &acme.Client{DirectoryURL: "http://invalid.example"}
+	if _, err := man.GetCertificate(clientHelloInfo(exampleDomain, false)); err == nil || !strings.Contains(err.Error(), "NewKey") {
+		t.Errorf("GetCertificate with an ECDSA key for an RSA certificate: %v", err)
+	}
+}
+
+func TestGetCertificate_notApproved(t *testing.T) {
+	man := &Manager{
+		Prompt: AcceptTOS,
+		ApproveIssuance: func(ctx context.Context, host string, csr *x509.CertificateRequest) error {
+			return fmt.Errorf("%s is not in the inventory", host)
+		},
+	}
+	defer man.stopRenew()
+	url, finish := startACMEServerStub(t, getCertificateFromManager(man, true), exampleDomain)
+	defer finish()
+	man.Client = &acme.Client{DirectoryURL: url}
+	_, err := man.GetCertificate(clientHelloInfo(exampleDomain, true))
+	if err == nil || !strings.Contains(err.Error(), "inventory") {
+		t.Errorf("GetCertificate: got error %v; want the one of ApproveIssuance", err)
+	}
+}
+
 func TestGetCertificate_nilPrompt(t *testing.T) {
 	man := &Manager{}
 	defer man.stopRenew()
@@ -1050,7 +1118,18 @@ func TestCertRequest(t *testing.T) {
 		Id:    asn1.ObjectIdentifier{1, 3, 6, 1, 5, 5, 7, 1},
 		Value: []byte("dummy"),
 	}
-	b, err := certRequest(key, "example.org", []pkix.Extension{ext}, "san.example.org")
+	// Spare capacity, which appending to the template must not use.
+	extra := make([]pkix.Extension, 1, 2)
+	extra[0] = ext
+	m := &Manager{
+		ExtraExtensions: extra,
+		CertificateRequest: func(host string, tmpl *x509.CertificateRequest) error {
+			tmpl.DNSNames = append(tmpl.DNSNames, "san."+host)
+			tmpl.ExtraExtensions = append(tmpl.ExtraExtensions, MustStaple)
+			return nil
+		},
+	}
+	b, err := m.certRequest(key, "example.org")
 	if err != nil {
 		t.Fatalf("certRequest: %v", err)
 	}
@@ -1058,15 +1137,28 @@ func TestCertRequest(t *testing.T) {
 	if err != nil {
 		t.Fatalf("ParseCertificateRequest: %v", err)
 	}
-	var found bool
-	for _, v := range r.Extensions {
-		if v.Id.Equal(ext.Id) {
-			found = true
-			break
+	for _, want := range []pkix.Extension{ext, MustStaple} {
+		var found bool
+		for _, v := range r.Extensions {
+			if v.Id.Equal(want.Id) {
+				found = true
+				break
+			}
+		}
+		if !found {
+			t.Errorf("want %v in Extensions: %v", want, r.Extensions)
 		}
 	}
-	if !found {
-		t.Errorf("want %v in Extensions: %v", ext, r.Extensions)
+	if r.Subject.CommonName != "example.org" || len(r.DNSNames) != 1 || r.DNSNames[0] != "san.example.org" {
+		t.Errorf("got CN %q and SANs %q", r.Subject.CommonName, r.DNSNames)
+	}
+	if len(m.ExtraExtensions) != 1 || extra[:2][1].Id != nil {
+		t.Errorf("CertificateRequest modified m.ExtraExtensions")
+	}
+
+	m.CertificateRequest = func(string, *x509.CertificateRequest) error { return fmt.Errorf("refused") }
+	if _, err := m.certRequest(key, "example.org"); err == nil {
+		t.Error("certRequest ignored the error of CertificateRequest")
 	}
 }
 
@@ -15,8 +15,9 @@ import (
 	"math/big"
 	"net/http"
 	"strconv"
-	"strings"
 	"time"
+
+	"golang.org/x/crypto/proxy"
 )
 
 // retryTimer encapsulates common logic for retrying unsuccessful requests.
@@ -235,17 +236,19 @@ func (c *Client) httpClient() *http.Client {
 	if c.HTTPClient != nil {
 		return c.HTTPClient
 	}
+	if c.Dialer != nil {
+		c.dialerClientOnce.Do(func() {
+			c.dialerClient = &http.Client{Transport: proxy.HTTPTransport(c.Dialer)}
+		})
+		return c.dialerClient
+	}
 	return http.DefaultClient
 }
 
-// isBadNonce reports whether err is an ACME "badnonce" error.
+// isBadNonce reports whether err is an ACME "badNonce" error.
 func isBadNonce(err error) bool {
-	// According to the spec badNonce is urn:ietf:params:acme:error:badNonce.
-	// However, ACME servers in the wild return their versions of the error.
-	// See https://tools.ietf.org/html/draft-ietf-acme-acme-02#section-5.4
-	// and https://github.com/letsencrypt/boulder/blob/0e07eacb/docs/acme-divergences.md#section-66.
 	ae, ok := err.(*Error)
-	return ok && strings.HasSuffix(strings.ToLower(ae.ProblemType), ":badnonce")
+	return ok && ae.Problem() == ProblemBadNonce
 }
 
 // isRetriable reports whether a request can be retried
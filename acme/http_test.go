@@ -8,10 +8,12 @@ import (
 	"context"
 	"fmt"
 	"io/ioutil"
+	"net"
 	"net/http"
 	"net/http/httptest"
 	"reflect"
 	"strings"
+	"sync"
 	"testing"
 	"time"
 )
@@ -45,6 +47,65 @@ func TestDefaultBackoff(t *testing.T) {
 	}
 }
 
+func TestErrorResponseSubproblems(t *testing.T) {
+	s := `{
+		"type": "urn:ietf:params:acme:error:malformed",
+		"detail": "Some of the identifiers requested were rejected",
+		"instance": "https://example.com/acme/error/1",
+		"subproblems": [
+			{
+				"type": "urn:ietf:params:acme:error:malformed",
+				"detail": "Invalid underscore in DNS name \"_example.org\"",
+				"identifier": {"type": "dns", "value": "_example.org"}
+			},
+			{
+				"type": "urn:ietf:params:acme:error:rejectedIdentifier",
+				"detail": "This CA will not issue for \"example.net\"",
+				"identifier": {"type": "dns", "value": "example.net"}
+			}
+		]
+	}`
+	res := &http.Response{
+		StatusCode: 403,
+		Status:     "403 Forbidden",
+		Body:       ioutil.NopCloser(strings.NewReader(s)),
+	}
+	v, ok := responseError(res).(*Error)
+	if !ok {
+		t.Fatalf("responseError returned %T; want *Error", v)
+	}
+	if v.StatusCode != 403 {
+		t.Errorf("v.StatusCode = %v; want 403", v.StatusCode)
+	}
+	if v.Problem() != ProblemMalformed {
+		t.Errorf("v.Problem() = %q; want %q", v.Problem(), ProblemMalformed)
+	}
+	if v.Instance != "https://example.com/acme/error/1" {
+		t.Errorf("v.Instance = %q", v.Instance)
+	}
+	want := []Subproblem{
+		{
+			ProblemType: "urn:ietf:params:acme:error:malformed",
+			Detail:      `Invalid underscore in DNS name "_example.org"`,
+			Identifier:  AuthzID{Type: "dns", Value: "_example.org"},
+		},
+		{
+			ProblemType: "urn:ietf:params:acme:error:rejectedIdentifier",
+			Detail:      `This CA will not issue for "example.net"`,
+			Identifier:  AuthzID{Type: "dns", Value: "example.net"},
+		},
+	}
+	if !reflect.DeepEqual(v.Subproblems, want) {
+		t.Errorf("v.Subproblems = %+v; want %+v", v.Subproblems, want)
+	}
+	if p := v.Subproblems[1].Problem(); p != ProblemRejectedIdentifier {
+		t.Errorf("v.Subproblems[1].Problem() = %q; want %q", p, ProblemRejectedIdentifier)
+	}
+	if msg := v.Error(); !strings.Contains(msg, "example.net: urn:ietf:params:acme:error:rejectedIdentifier") {
+		t.Errorf("v.Error() = %q; does not mention the subproblems", msg)
+	}
+}
+
 func TestErrorResponse(t *testing.T) {
 	s := `{
 		"status": 400,
@@ -156,3 +217,38 @@ func TestRetryBackoffArgs(t *testing.T) {
 		t.Errorf("nretry = %d; want 3", nretry)
 	}
 }
+
+type countingDialer struct {
+	mu    sync.Mutex
+	addrs []string
+}
+
+func (d *countingDialer) DialContext(ctx context.Context, network, addr string) (net.Conn, error) {
+	d.mu.Lock()
+	d.addrs = append(d.addrs, addr)
+	d.mu.Unlock()
+	var nd net.Dialer
+	return nd.DialContext(ctx, network, addr)
+}
+
+func TestDialer(t *testing.T) {
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		fmt.Fprint(w, `{"new-reg": "https://example.com/acme/new-reg"}`)
+	}))
+	defer ts.Close()
+
+	d := new(countingDialer)
+	client := &Client{Dialer: d, DirectoryURL: ts.URL}
+	dir, err := client.Discover(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if dir.RegURL != "https://example.com/acme/new-reg" {
+		t.Errorf("dir.RegURL = %q", dir.RegURL)
+	}
+	want := []string{strings.TrimPrefix(ts.URL, "http://")}
+	if !reflect.DeepEqual(d.addrs, want) {
+		t.Errorf("dialed %q; want %q", d.addrs, want)
+	}
+}
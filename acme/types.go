@@ -44,8 +44,78 @@ const (
 // ErrUnsupportedKey is returned when an unsupported key type is encountered.
 var ErrUnsupportedKey = errors.New("acme: unknown key type; only RSA and ECDSA are supported")
 
+// ACME problem types, as returned by Error.Problem and Subproblem.Problem.
+// They are the names of the problem types of RFC 8555, Section 6.7, whose
+// URNs start with "urn:ietf:params:acme:error:", or "urn:acme:error:" for
+// servers implementing earlier drafts.
+const (
+	ProblemAccountDoesNotExist     = "accountDoesNotExist"
+	ProblemAlreadyRevoked          = "alreadyRevoked"
+	ProblemBadCSR                  = "badCSR"
+	ProblemBadNonce                = "badNonce"
+	ProblemBadPublicKey            = "badPublicKey"
+	ProblemBadRevocationReason     = "badRevocationReason"
+	ProblemBadSignatureAlgorithm   = "badSignatureAlgorithm"
+	ProblemCAA                     = "caa"
+	ProblemCompound                = "compound"
+	ProblemConnection              = "connection"
+	ProblemDNS                     = "dns"
+	ProblemExternalAccountRequired = "externalAccountRequired"
+	ProblemIncorrectResponse       = "incorrectResponse"
+	ProblemInvalidContact          = "invalidContact"
+	ProblemMalformed               = "malformed"
+	ProblemOrderNotReady           = "orderNotReady"
+	ProblemRateLimited             = "rateLimited"
+	ProblemRejectedIdentifier      = "rejectedIdentifier"
+	ProblemServerInternal          = "serverInternal"
+	ProblemTLS                     = "tls"
+	ProblemUnauthorized            = "unauthorized"
+	ProblemUnsupportedContact      = "unsupportedContact"
+	ProblemUnsupportedIdentifier   = "unsupportedIdentifier"
+	ProblemUserActionRequired      = "userActionRequired"
+)
+
+// problemNames maps the lowercase names of the problem types to their
+// canonical spelling.
+var problemNames = make(map[string]string)
+
+func init() {
+	for _, name := range []string{
+		ProblemAccountDoesNotExist, ProblemAlreadyRevoked, ProblemBadCSR,
+		ProblemBadNonce, ProblemBadPublicKey, ProblemBadRevocationReason,
+		ProblemBadSignatureAlgorithm, ProblemCAA, ProblemCompound,
+		ProblemConnection, ProblemDNS, ProblemExternalAccountRequired,
+		ProblemIncorrectResponse, ProblemInvalidContact, ProblemMalformed,
+		ProblemOrderNotReady, ProblemRateLimited, ProblemRejectedIdentifier,
+		ProblemServerInternal, ProblemTLS, ProblemUnauthorized,
+		ProblemUnsupportedContact, ProblemUnsupportedIdentifier,
+		ProblemUserActionRequired,
+	} {
+		problemNames[strings.ToLower(name)] = name
+	}
+}
+
+// problemName returns the name of the problem type of the URN typ, or "" if
+// it is not a known ACME problem type.
+//
+// ACME servers in the wild use their own prefixes and capitalization, such
+// as urn:acme:error:badnonce, so only the part after the last colon is
+// compared, case-insensitively.
+// See https://github.com/letsencrypt/boulder/blob/0e07eacb/docs/acme-divergences.md#section-66.
+func problemName(typ string) string {
+	i := strings.LastIndex(typ, ":")
+	if i < 0 {
+		return ""
+	}
+	return problemNames[strings.ToLower(typ[i+1:])]
+}
+
 // Error is an ACME error, defined in Problem Details for HTTP APIs doc
 // http://tools.ietf.org/html/draft-ietf-appsawg-http-problem.
+//
+// Callers should not compare ProblemType or Detail to known strings, but
+// use Problem to tell apart the problem types, and RetryAfter to find out
+// when to retry.
 type Error struct {
 	// StatusCode is The HTTP status code generated by the origin server.
 	StatusCode int
@@ -54,13 +124,65 @@ type Error struct {
 	ProblemType string
 	// Detail is a human-readable explanation specific to this occurrence of the problem.
 	Detail string
+	// Instance is a URI reference that identifies the specific occurrence of
+	// the problem. It may be empty.
+	Instance string
 	// Header is the original server error response headers.
 	// It may be nil.
 	Header http.Header
+	// Subproblems are the problems with individual identifiers of the
+	// request, when it had several, as described in RFC 8555, Section 6.7.1.
+	// The server may report the first problem or a ProblemCompound in
+	// ProblemType.
+	Subproblems []Subproblem
 }
 
 func (e *Error) Error() string {
-	return fmt.Sprintf("%d %s: %s", e.StatusCode, e.ProblemType, e.Detail)
+	s := fmt.Sprintf("%d %s: %s", e.StatusCode, e.ProblemType, e.Detail)
+	for _, sub := range e.Subproblems {
+		s += fmt.Sprintf("; %s: %s: %s", sub.Identifier.Value, sub.ProblemType, sub.Detail)
+	}
+	return s
+}
+
+// Problem returns the name of the ACME problem type of e, one of the Problem
+// constants such as ProblemRateLimited, or "" if ProblemType is not an ACME
+// problem type.
+func (e *Error) Problem() string {
+	return problemName(e.ProblemType)
+}
+
+// RetryAfter returns how long the server asked the client to wait before
+// retrying, from the Retry-After header of its response, and reports whether
+// there was such a header. Servers send it with ProblemRateLimited and, for
+// instance, when they are overloaded.
+func (e *Error) RetryAfter() (time.Duration, bool) {
+	if e.Header == nil {
+		return 0, false
+	}
+	v := e.Header.Get("Retry-After")
+	if v == "" {
+		return 0, false
+	}
+	return retryAfter(v), true
+}
+
+// A Subproblem is the problem with one of the identifiers of a request,
+// reported as part of an Error.
+type Subproblem struct {
+	// ProblemType is a URI reference that identifies the problem type.
+	ProblemType string
+	// Detail is a human-readable explanation of the problem.
+	Detail string
+	// Identifier is the identifier the problem is about. It may be the zero
+	// value if the server did not report it.
+	Identifier AuthzID
+}
+
+// Problem returns the name of the ACME problem type of s, as Error.Problem
+// does.
+func (s *Subproblem) Problem() string {
+	return problemName(s.ProblemType)
 }
 
 // AuthorizationError indicates that an authorization for an identifier
@@ -93,18 +215,11 @@ func (a *AuthorizationError) Error() string {
 // https://tools.ietf.org/html/draft-ietf-acme-acme-05#section-5.6
 func RateLimit(err error) (time.Duration, bool) {
 	e, ok := err.(*Error)
-	if !ok {
+	if !ok || e.Problem() != ProblemRateLimited {
 		return 0, false
 	}
-	// Some CA implementations may return incorrect values.
-	// Use case-insensitive comparison.
-	if !strings.HasSuffix(strings.ToLower(e.ProblemType), ":ratelimited") {
-		return 0, false
-	}
-	if e.Header == nil {
-		return 0, true
-	}
-	return retryAfter(e.Header.Get("Retry-After")), true
+	d, _ := e.RetryAfter()
+	return d, true
 }
 
 // Account is a user account. It is associated with a private key.
@@ -168,7 +283,8 @@ type Directory struct {
 // Its Error field may be non-nil if the challenge is part of an Authorization
 // with StatusInvalid.
 type Challenge struct {
-	// Type is the challenge type, e.g. "http-01", "tls-sni-02", "dns-01".
+	// Type is the challenge type, e.g. "http-01", "tls-sni-02", "dns-01",
+	// "dns-account-01".
 	Type string
 
 	// URI is where a challenge response can be posted to.
@@ -280,20 +396,42 @@ func (c *wireChallenge) challenge() *Challenge {
 }
 
 // wireError is a subset of fields of the Problem Details object
-// as described in https://tools.ietf.org/html/rfc7807#section-3.1.
+// as described in https://tools.ietf.org/html/rfc7807#section-3.1,
+// with the subproblems of RFC 8555, Section 6.7.1.
 type wireError struct {
-	Status int
-	Type   string
-	Detail string
+	Status      int
+	Type        string
+	Detail      string
+	Instance    string
+	Subproblems []wireSubproblem
+}
+
+// wireSubproblem is ACME JSON subproblem representation.
+type wireSubproblem struct {
+	Type       string
+	Detail     string
+	Identifier struct {
+		Type  string
+		Value string
+	}
 }
 
 func (e *wireError) error(h http.Header) *Error {
-	return &Error{
+	err := &Error{
 		StatusCode:  e.Status,
 		ProblemType: e.Type,
 		Detail:      e.Detail,
+		Instance:    e.Instance,
 		Header:      h,
 	}
+	for _, sub := range e.Subproblems {
+		err.Subproblems = append(err.Subproblems, Subproblem{
+			ProblemType: sub.Type,
+			Detail:      sub.Detail,
+			Identifier:  AuthzID{Type: sub.Identifier.Type, Value: sub.Identifier.Value},
+		})
+	}
+	return err
 }
 
 // CertOption is an optional argument type for the TLSSNIxChallengeCert methods for
@@ -61,3 +61,58 @@ func TestRateLimit(t *testing.T) {
 		}
 	}
 }
+
+func TestErrorProblem(t *testing.T) {
+	tt := []struct {
+		typ, want string
+	}{
+		{"urn:ietf:params:acme:error:rateLimited", ProblemRateLimited},
+		{"urn:acme:error:rateLimited", ProblemRateLimited},
+		{"urn:acme:error:badnonce", ProblemBadNonce},
+		{"urn:ietf:params:acme:error:CAA", ProblemCAA},
+		{"urn:ietf:params:acme:error:unauthorized", ProblemUnauthorized},
+		{"urn:ietf:params:acme:error:nolimit", ""},
+		{"about:blank", ""},
+		{"rateLimited", ""},
+		{"", ""},
+	}
+	for _, test := range tt {
+		e := &Error{ProblemType: test.typ}
+		if got := e.Problem(); got != test.want {
+			t.Errorf("(&Error{ProblemType: %q}).Problem() = %q; want %q", test.typ, got, test.want)
+		}
+		s := &Subproblem{ProblemType: test.typ}
+		if got := s.Problem(); got != test.want {
+			t.Errorf("(&Subproblem{ProblemType: %q}).Problem() = %q; want %q", test.typ, got, test.want)
+		}
+	}
+}
+
+func TestErrorRetryAfter(t *testing.T) {
+	now := time.Date(2017, 04, 27, 10, 0, 0, 0, time.UTC)
+	f := timeNow
+	defer func() { timeNow = f }()
+	timeNow = func() time.Time { return now }
+
+	h120, hTime := http.Header{}, http.Header{}
+	h120.Set("Retry-After", "120")
+	hTime.Set("Retry-After", "Tue Apr 27 11:00:00 2017")
+
+	tt := []struct {
+		h   http.Header
+		res time.Duration
+		ok  bool
+	}{
+		{nil, 0, false},
+		{http.Header{}, 0, false},
+		{h120, 2 * time.Minute, true},
+		{hTime, time.Hour, true},
+	}
+	for i, test := range tt {
+		e := &Error{ProblemType: "urn:ietf:params:acme:error:serverInternal", Header: test.h}
+		res, ok := e.RetryAfter()
+		if res != test.res || ok != test.ok {
+			t.Errorf("%d: RetryAfter() = %v, %v; want %v, %v", i, res, ok, test.res, test.ok)
+		}
+	}
+}
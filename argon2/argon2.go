@@ -9,7 +9,9 @@
 // For a detailed specification of Argon2 see [1].
 //
 // If you aren't sure which function you need, use Argon2id (IDKey) and
-// the parameter recommendations for your scenario.
+// the parameter recommendations for your scenario. KeyWithSecret and
+// IDKeyWithSecret additionally accept the optional secret value and associated
+// data defined by the specification.
 //
 //
 // Argon2i
@@ -99,6 +101,25 @@ func IDKey(password, salt []byte, time, memory uint32, threads uint8, keyLen uin
 	return deriveKey(argon2id, password, salt, nil, nil, time, memory, threads, keyLen)
 }
 
+// KeyWithSecret is like Key, but also takes the two optional inputs of
+// Argon2, a secret value and associated data, either of which may be nil.
+//
+// The secret, or pepper, is mixed into the hash along with the password and
+// salt. Unlike the salt it is not stored with the hash, but kept apart, for
+// example in an HSM or in the configuration of the server, so that a leaked
+// password database cannot be attacked without it. The associated data binds
+// the derived key to a context, such as a user or key identifier, and need
+// not be secret.
+func KeyWithSecret(password, salt, secret, data []byte, time, memory uint32, threads uint8, keyLen uint32) []byte {
+	return deriveKey(argon2i, password, salt, secret, data, time, memory, threads, keyLen)
+}
+
+// IDKeyWithSecret is like IDKey, but also takes a secret value and associated
+// data, either of which may be nil, as described for KeyWithSecret.
+func IDKeyWithSecret(password, salt, secret, data []byte, time, memory uint32, threads uint8, keyLen uint32) []byte {
+	return deriveKey(argon2id, password, salt, secret, data, time, memory, threads, keyLen)
+}
+
 func deriveKey(mode int, password, salt, secret, data []byte, time, memory uint32, threads uint8, keyLen uint32) []byte {
 	if time < 1 {
 		panic("argon2: number of rounds too small")
@@ -77,6 +77,39 @@ func testArgon2id(t *testing.T) {
 	}
 }
 
+func TestKeyWithSecret(t *testing.T) {
+	// The tags of RFC 9106, Sections 5.2 and 5.3, which use a secret and
+	// associated data.
+	for _, test := range []struct {
+		name string
+		f    func(password, salt, secret, data []byte, time, memory uint32, threads uint8, keyLen uint32) []byte
+		want string
+	}{
+		{"KeyWithSecret", KeyWithSecret, "c814d9d1dc7f37aa13f0d77f2494bda1c8de6b016dd388d29952a4c4672b6ce8"},
+		{"IDKeyWithSecret", IDKeyWithSecret, "0d640df58d78766c08c037a34a8b53c9d01ef0452d75b65eb52520e96b01e659"},
+	} {
+		want, _ := hex.DecodeString(test.want)
+		hash := test.f(genKatPassword, genKatSalt, genKatSecret, genKatAAD, 3, 32, 4, 32)
+		if !bytes.Equal(hash, want) {
+			t.Errorf("%s: got %x, want %x", test.name, hash, want)
+		}
+
+		if hash := test.f(genKatPassword, genKatSalt, nil, nil, 3, 32, 4, 32); bytes.Equal(hash, want) {
+			t.Errorf("%s: secret and associated data are ignored", test.name)
+		}
+		if hash := test.f(genKatPassword, genKatSalt, genKatSecret, nil, 3, 32, 4, 32); bytes.Equal(hash, want) {
+			t.Errorf("%s: associated data is ignored", test.name)
+		}
+	}
+
+	if !bytes.Equal(KeyWithSecret(genKatPassword, genKatSalt, nil, nil, 1, 64, 2, 32), Key(genKatPassword, genKatSalt, 1, 64, 2, 32)) {
+		t.Errorf("KeyWithSecret without a secret differs from Key")
+	}
+	if !bytes.Equal(IDKeyWithSecret(genKatPassword, genKatSalt, nil, nil, 1, 64, 2, 32), IDKey(genKatPassword, genKatSalt, 1, 64, 2, 32)) {
+		t.Errorf("IDKeyWithSecret without a secret differs from IDKey")
+	}
+}
+
 func TestVectors(t *testing.T) {
 	password, salt := []byte("password"), []byte("somesalt")
 	for i, v := range testVectors {
@@ -14,6 +14,9 @@
 //
 // BLAKE2X is a construction to compute hash values larger than 64 bytes. It
 // can produce hash values between 0 and 4 GiB.
+//
+// TreeSum computes a tree hash of large inputs, which a TreeReader can verify
+// incrementally, chunk by chunk, as the data encoded by TreeEncode arrives.
 package blake2b
 
 import (
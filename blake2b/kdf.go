@@ -0,0 +1,55 @@
+// Copyright 2018 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package blake2b
+
+import "io"
+
+// KDFExtract is the extract step of the key derivation function of NewKDF. It
+// returns a Size-byte pseudorandom key computed from secret, the input keying
+// material, and salt, which is optional and at most Size bytes long: the
+// BLAKE2b-512 hash of secret, keyed with salt.
+func KDFExtract(secret, salt []byte) ([]byte, error) {
+	h, err := newDigest(Size, salt)
+	if err != nil {
+		return nil, err
+	}
+	h.Write(secret)
+	return h.Sum(nil), nil
+}
+
+// KDFExpand is the expand step of the key derivation function of NewKDF. It
+// returns a Reader of up to 256GiB of output keying material derived from
+// prk, a pseudorandom key of at most Size bytes such as the output of
+// KDFExtract, and info, which binds the output to its context: the output of
+// BLAKE2Xb with an unknown output length, keyed with prk, of info.
+//
+// As with HKDF, the first bytes of the output do not depend on how many are
+// read, so separate keys must be derived with separate info values rather
+// than by reading more output.
+func KDFExpand(prk, info []byte) (io.Reader, error) {
+	x, err := NewXOF(OutputLengthUnknown, prk)
+	if err != nil {
+		return nil, err
+	}
+	x.Write(info)
+	return x, nil
+}
+
+// NewKDF returns a Reader for a key derivation function that follows the
+// extract-then-expand design of HKDF (RFC 5869), instantiated with keyed
+// BLAKE2b as the extractor and BLAKE2Xb as the expander, as suggested by the
+// BLAKE2X specification. It is not compatible with HKDF used with BLAKE2b as
+// the hash of HMAC.
+//
+// secret is the input keying material, salt an optional value of at most Size
+// bytes, and info an optional context. The Reader behaves as that of
+// package golang.org/x/crypto/hkdf.
+func NewKDF(secret, salt, info []byte) (io.Reader, error) {
+	prk, err := KDFExtract(secret, salt)
+	if err != nil {
+		return nil, err
+	}
+	return KDFExpand(prk, info)
+}
@@ -0,0 +1,85 @@
+// Copyright 2018 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package blake2b
+
+import (
+	"bytes"
+	"encoding/hex"
+	"io"
+	"testing"
+)
+
+func TestKDFExtract(t *testing.T) {
+	secret := []byte("input keying material")
+	for _, test := range []struct {
+		salt []byte
+		want string
+	}{
+		{[]byte("salt"), "563a466404e567e1445a797ae0173e326d64cf0cfd99d42a840ce01bc128693aef34ca7892e15c885b22556066761ae5a8e60e3f4a49df234ef7dace5d89f386"},
+		{nil, "0d173659cd305a9f704a1688da1e77a2a5b4f26e53e21bdfe0b7bca7b339fd96467b8988267ba2ddaddc0728807982dac7277e01a88808572c2e2f4bad81b7b3"},
+	} {
+		prk, err := KDFExtract(secret, test.salt)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got := hex.EncodeToString(prk); got != test.want {
+			t.Errorf("KDFExtract(%q) = %s, want %s", test.salt, got, test.want)
+		}
+	}
+	if _, err := KDFExtract(secret, make([]byte, Size+1)); err == nil {
+		t.Error("KDFExtract accepted a long salt")
+	}
+}
+
+func TestKDF(t *testing.T) {
+	secret, salt := []byte("input keying material"), []byte("salt")
+	read := func(r io.Reader, n int) []byte {
+		out := make([]byte, n)
+		if _, err := io.ReadFull(r, out); err != nil {
+			t.Fatal(err)
+		}
+		return out
+	}
+
+	kdf, err := NewKDF(secret, salt, []byte("info"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	long := read(kdf, 1000)
+
+	prk, _ := KDFExtract(secret, salt)
+	expand, err := KDFExpand(prk, []byte("info"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	// Reading in pieces gives the same output, and short outputs are
+	// prefixes of long ones.
+	var pieces []byte
+	for _, n := range []int{1, 31, 64, 100, 804} {
+		pieces = append(pieces, read(expand, n)...)
+	}
+	if !bytes.Equal(pieces, long) {
+		t.Error("output read in pieces differs")
+	}
+	kdf, _ = NewKDF(secret, salt, []byte("info"))
+	if short := read(kdf, 32); !bytes.Equal(short, long[:32]) {
+		t.Error("short output is not a prefix of the long one")
+	}
+
+	for _, other := range [][3][]byte{
+		{secret, salt, []byte("other info")},
+		{secret, []byte("other salt"), []byte("info")},
+		{[]byte("other secret"), salt, []byte("info")},
+	} {
+		kdf, _ := NewKDF(other[0], other[1], other[2])
+		if bytes.Equal(read(kdf, 32), long[:32]) {
+			t.Errorf("NewKDF(%q, %q, %q) gave the same output", other[0], other[1], other[2])
+		}
+	}
+
+	if _, err := KDFExpand(make([]byte, Size+1), nil); err == nil {
+		t.Error("KDFExpand accepted a long key")
+	}
+}
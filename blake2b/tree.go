@@ -0,0 +1,285 @@
+// Copyright 2018 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package blake2b
+
+import (
+	"crypto/subtle"
+	"encoding/binary"
+	"errors"
+	"io"
+)
+
+// ChunkSize is the size, in bytes, of the leaves of the tree hash computed by
+// TreeSum. It is also the granularity at which a TreeReader verifies data.
+const ChunkSize = 4096
+
+// ErrTreeMismatch is returned by the Read method of a TreeReader when the
+// encoded data does not match the expected root hash.
+var ErrTreeMismatch = errors.New("blake2b: tree hash mismatch")
+
+// The tree hash splits the input into chunks of ChunkSize bytes, the last
+// one possibly shorter, and an empty input into a single empty chunk. The
+// chunks are the leaves of a binary tree, in which the left subtree of every
+// node holds the largest power of two of chunks that is less than the number
+// of chunks of the node. Every node is hashed with BLAKE2b-256 in tree mode:
+// leaves have a node depth of 0 and their chunk index as node offset, and
+// parents, the concatenation of the hashes of their children, have a node
+// depth of 1 and a node offset of 0. The root is instead marked with a node
+// depth of 255, and the length of the input is appended to it, so that the
+// root hash commits to the length. If a key is given, every node is keyed.
+//
+// The encoding produced by TreeEncode is the length of the input, as a
+// little-endian uint64, followed by the nodes in pre-order, that is, every
+// parent before its left and right subtrees.
+
+// TreeSum returns the root of the tree hash of data. A non-nil key turns the
+// hash into a MAC. The key must be between zero and 64 bytes long.
+func TreeSum(data, key []byte) ([Size256]byte, error) {
+	t, err := newTreeHasher(key)
+	if err != nil {
+		return [Size256]byte{}, err
+	}
+	return t.subtree(nil, data, 0, chunkCount(uint64(len(data))), true, uint64(len(data))), nil
+}
+
+// TreeEncode returns the encoding of data, which lets a TreeReader verify it
+// incrementally, and its root hash as computed by TreeSum.
+func TreeEncode(data, key []byte) (encoded []byte, root [Size256]byte, err error) {
+	t, err := newTreeHasher(key)
+	if err != nil {
+		return nil, root, err
+	}
+	length := uint64(len(data))
+	chunks := chunkCount(length)
+	encoded = make([]byte, 8, 8+len(data)+int(chunks-1)*2*Size256)
+	binary.LittleEndian.PutUint64(encoded, length)
+	root = t.subtree(&encoded, data, 0, chunks, true, length)
+	return encoded, root, nil
+}
+
+// chunkCount returns the number of leaves of the tree of an input of the
+// given length.
+func chunkCount(length uint64) uint64 {
+	if length == 0 {
+		return 1
+	}
+	return (length + ChunkSize - 1) / ChunkSize
+}
+
+// leftCount returns the number of chunks of the left subtree of a node with
+// the given number of chunks, which must be at least two.
+func leftCount(chunks uint64) uint64 {
+	n := uint64(1)
+	for 2*n < chunks {
+		n *= 2
+	}
+	return n
+}
+
+type treeHasher struct {
+	d   digest
+	cfg [Size]byte
+}
+
+func newTreeHasher(key []byte) (*treeHasher, error) {
+	if len(key) > Size {
+		return nil, errKeySize
+	}
+	t := &treeHasher{d: digest{size: Size256, keyLen: len(key)}}
+	copy(t.d.key[:], key)
+	t.cfg[0] = Size256
+	t.cfg[1] = byte(len(key))
+	t.cfg[2] = 2   // fanout
+	t.cfg[3] = 255 // maximal depth, unlimited
+	binary.LittleEndian.PutUint32(t.cfg[4:], ChunkSize)
+	t.cfg[17] = Size256 // inner hash size
+	return t, nil
+}
+
+// node hashes the concatenation of parts as the node with the given offset
+// and depth, or as the root of a tree of an input of the given length.
+func (t *treeHasher) node(offset uint64, depth byte, root bool, length uint64, parts ...[]byte) (sum [Size256]byte) {
+	binary.LittleEndian.PutUint64(t.cfg[8:], offset)
+	t.cfg[16] = depth
+	if root {
+		t.cfg[16] = 255
+	}
+	t.d.initConfig(&t.cfg)
+	if t.d.keyLen > 0 {
+		t.d.block = t.d.key
+		t.d.offset = BlockSize
+	}
+	for _, p := range parts {
+		t.d.Write(p)
+	}
+	if root {
+		var l [8]byte
+		binary.LittleEndian.PutUint64(l[:], length)
+		t.d.Write(l[:])
+	}
+	var hash [Size]byte
+	t.d.finalize(&hash)
+	copy(sum[:], hash[:])
+	return sum
+}
+
+func (t *treeHasher) chunk(index uint64, data []byte, root bool, length uint64) [Size256]byte {
+	return t.node(index, 0, root, length, data)
+}
+
+func (t *treeHasher) parent(children []byte, root bool, length uint64) [Size256]byte {
+	return t.node(0, 1, root, length, children)
+}
+
+// subtree returns the hash of the subtree of the given number of chunks
+// holding data, which starts at chunk start. If out is not nil, the encoding
+// of the subtree is appended to it.
+func (t *treeHasher) subtree(out *[]byte, data []byte, start, chunks uint64, root bool, length uint64) [Size256]byte {
+	if chunks == 1 {
+		if out != nil {
+			*out = append(*out, data...)
+		}
+		return t.chunk(start, data, root, length)
+	}
+	var parent int
+	if out != nil {
+		parent = len(*out)
+		*out = append(*out, make([]byte, 2*Size256)...)
+	}
+	left := leftCount(chunks)
+	var children [2 * Size256]byte
+	l := t.subtree(out, data[:left*ChunkSize], start, left, false, length)
+	r := t.subtree(out, data[left*ChunkSize:], start+left, chunks-left, false, length)
+	copy(children[:], l[:])
+	copy(children[Size256:], r[:])
+	if out != nil {
+		copy((*out)[parent:], children[:])
+	}
+	return t.parent(children[:], root, length)
+}
+
+// A TreeReader reads data encoded by TreeEncode and verifies it against a
+// known root hash as it arrives, without buffering more than a chunk. Data
+// is only returned once the chunk it belongs to and all the parents of that
+// chunk were verified, so that a consumer can process a large download
+// incrementally. If the encoding does not match the root hash, Read returns
+// ErrTreeMismatch, after which it fails permanently.
+type TreeReader struct {
+	r      io.Reader
+	t      *treeHasher
+	root   [Size256]byte
+	length uint64
+	stack  []pendingSubtree
+	buf    []byte
+	chunk  []byte
+	err    error
+	start  bool
+}
+
+// A pendingSubtree is a subtree whose hash is known, but whose encoding was
+// not read yet.
+type pendingSubtree struct {
+	hash          [Size256]byte
+	start, chunks uint64
+	root          bool
+}
+
+// NewTreeReader returns a TreeReader reading the encoding from r and
+// verifying it against root, which must have been computed with the same key.
+func NewTreeReader(r io.Reader, root [Size256]byte, key []byte) (*TreeReader, error) {
+	t, err := newTreeHasher(key)
+	if err != nil {
+		return nil, err
+	}
+	return &TreeReader{r: r, t: t, root: root, chunk: make([]byte, ChunkSize), start: true}, nil
+}
+
+// Length returns the length of the data. It reads the start of the encoding
+// if needed, and returns an error if that fails. The length is only
+// authenticated once the root of the tree was verified, which happens on the
+// first call to Read.
+func (tr *TreeReader) Length() (uint64, error) {
+	if tr.start {
+		tr.readLength()
+	}
+	return tr.length, tr.err
+}
+
+func (tr *TreeReader) readLength() {
+	tr.start = false
+	var l [8]byte
+	if _, err := io.ReadFull(tr.r, l[:]); err != nil {
+		tr.err = unexpectedEOF(err)
+		return
+	}
+	tr.length = binary.LittleEndian.Uint64(l[:])
+	tr.stack = append(tr.stack, pendingSubtree{hash: tr.root, start: 0, chunks: chunkCount(tr.length), root: true})
+}
+
+// Read reads verified data into p. It returns io.EOF once all the data was
+// read.
+func (tr *TreeReader) Read(p []byte) (int, error) {
+	if tr.start {
+		tr.readLength()
+	}
+	for len(tr.buf) == 0 {
+		if tr.err != nil {
+			return 0, tr.err
+		}
+		if len(tr.stack) == 0 {
+			return 0, io.EOF
+		}
+		tr.err = tr.next()
+	}
+	n := copy(p, tr.buf)
+	tr.buf = tr.buf[n:]
+	return n, nil
+}
+
+// next reads and verifies the next node of the encoding, and makes the data
+// of the chunk it read, if any, available in buf.
+func (tr *TreeReader) next() error {
+	s := tr.stack[len(tr.stack)-1]
+	tr.stack = tr.stack[:len(tr.stack)-1]
+
+	if s.chunks > 1 {
+		var children [2 * Size256]byte
+		if _, err := io.ReadFull(tr.r, children[:]); err != nil {
+			return unexpectedEOF(err)
+		}
+		if h := tr.t.parent(children[:], s.root, tr.length); subtle.ConstantTimeCompare(h[:], s.hash[:]) != 1 {
+			return ErrTreeMismatch
+		}
+		left := leftCount(s.chunks)
+		right := pendingSubtree{start: s.start + left, chunks: s.chunks - left}
+		copy(right.hash[:], children[Size256:])
+		tr.stack = append(tr.stack, right)
+		l := pendingSubtree{start: s.start, chunks: left}
+		copy(l.hash[:], children[:Size256])
+		tr.stack = append(tr.stack, l)
+		return nil
+	}
+
+	size := uint64(ChunkSize)
+	if rest := tr.length - s.start*ChunkSize; rest < size {
+		size = rest
+	}
+	chunk := tr.chunk[:size]
+	if _, err := io.ReadFull(tr.r, chunk); err != nil {
+		return unexpectedEOF(err)
+	}
+	if h := tr.t.chunk(s.start, chunk, s.root, tr.length); subtle.ConstantTimeCompare(h[:], s.hash[:]) != 1 {
+		return ErrTreeMismatch
+	}
+	tr.buf = chunk
+	return nil
+}
+
+func unexpectedEOF(err error) error {
+	if err == io.EOF {
+		return io.ErrUnexpectedEOF
+	}
+	return err
+}
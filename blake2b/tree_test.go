@@ -0,0 +1,121 @@
+// Copyright 2018 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package blake2b
+
+import (
+	"bytes"
+	"io"
+	"io/ioutil"
+	"testing"
+)
+
+var treeSizes = []int{0, 1, ChunkSize - 1, ChunkSize, ChunkSize + 1, 2 * ChunkSize, 3*ChunkSize + 7, 8 * ChunkSize, 9*ChunkSize + 1}
+
+func TestTreeRoundTrip(t *testing.T) {
+	for _, key := range [][]byte{nil, []byte("key")} {
+		for _, size := range treeSizes {
+			data := make([]byte, size)
+			generateSequence(data, uint32(size))
+
+			encoded, root, err := TreeEncode(data, key)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if sum, err := TreeSum(data, key); err != nil || sum != root {
+				t.Errorf("size %d: TreeSum = %x, %v, want %x", size, sum, err, root)
+			}
+			chunks := chunkCount(uint64(size))
+			if want := 8 + size + int(chunks-1)*2*Size256; len(encoded) != want {
+				t.Errorf("size %d: encoding is %d bytes, want %d", size, len(encoded), want)
+			}
+
+			tr, err := NewTreeReader(bytes.NewReader(encoded), root, key)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if l, err := tr.Length(); err != nil || l != uint64(size) {
+				t.Errorf("size %d: Length = %d, %v", size, l, err)
+			}
+			got, err := ioutil.ReadAll(tr)
+			if err != nil {
+				t.Errorf("size %d: %v", size, err)
+			} else if !bytes.Equal(got, data) {
+				t.Errorf("size %d: read different data", size)
+			}
+		}
+	}
+}
+
+func TestTreeDomainSeparation(t *testing.T) {
+	data := make([]byte, 2*ChunkSize)
+	generateSequence(data, 1)
+	root, _ := TreeSum(data, nil)
+	keyed, _ := TreeSum(data, []byte("key"))
+	short, _ := TreeSum(data[:ChunkSize], nil)
+	if root == keyed {
+		t.Error("keyed and unkeyed roots are equal")
+	}
+	if root == short {
+		t.Error("roots of different inputs are equal")
+	}
+	// The root of a single chunk differs from the hash of the chunk as a leaf
+	// of a larger tree.
+	th, _ := newTreeHasher(nil)
+	if short == th.chunk(0, data[:ChunkSize], false, 0) {
+		t.Error("root is not domain separated from leaves")
+	}
+	if _, err := TreeSum(data, make([]byte, Size+1)); err != errKeySize {
+		t.Errorf("TreeSum with a long key: got %v, want %v", err, errKeySize)
+	}
+}
+
+func TestTreeReaderCorruption(t *testing.T) {
+	const size = 5*ChunkSize + 100
+	data := make([]byte, size)
+	generateSequence(data, 2)
+	encoded, root, err := TreeEncode(data, []byte("key"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for _, i := range []int{0, 7, 8, 8 + 2*Size256, len(encoded) / 2, len(encoded) - 1} {
+		bad := append([]byte{}, encoded...)
+		bad[i] ^= 1
+		tr, err := NewTreeReader(bytes.NewReader(bad), root, []byte("key"))
+		if err != nil {
+			t.Fatal(err)
+		}
+		got, err := ioutil.ReadAll(tr)
+		if err != ErrTreeMismatch && err != io.ErrUnexpectedEOF {
+			t.Errorf("flipping byte %d: got error %v", i, err)
+		}
+		// Only verified data, which is a prefix of the input, was returned.
+		if !bytes.HasPrefix(data, got) || len(got) >= size {
+			t.Errorf("flipping byte %d: returned %d bytes of unverified data", i, len(got))
+		}
+	}
+
+	tr, _ := NewTreeReader(bytes.NewReader(encoded), root, []byte("wrong key"))
+	if _, err := ioutil.ReadAll(tr); err != ErrTreeMismatch {
+		t.Errorf("wrong key: got %v, want %v", err, ErrTreeMismatch)
+	}
+
+	tr, _ = NewTreeReader(bytes.NewReader(encoded[:len(encoded)-1]), root, []byte("key"))
+	got, err := ioutil.ReadAll(tr)
+	if err != io.ErrUnexpectedEOF {
+		t.Errorf("truncated encoding: got %v, want %v", err, io.ErrUnexpectedEOF)
+	}
+	if !bytes.Equal(got, data[:5*ChunkSize]) {
+		t.Errorf("truncated encoding: read %d bytes, want %d", len(got), 5*ChunkSize)
+	}
+}
+
+func BenchmarkTreeSum1M(b *testing.B) {
+	data := make([]byte, 1<<20)
+	b.SetBytes(int64(len(data)))
+	for i := 0; i < b.N; i++ {
+		TreeSum(data, nil)
+	}
+}
@@ -0,0 +1,164 @@
+// Copyright 2018 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+// Package blindindex computes blind indexes, which make encrypted fields
+// searchable for equality.
+//
+// A blind index of a value is a keyed MAC of it, truncated to a configurable
+// number of bits, stored next to the encrypted value. To find the rows whose
+// field equals some value, a query computes the index of the value and looks
+// it up, then decrypts the matching rows and discards the false positives.
+//
+// Truncation is what keeps blind indexes from leaking too much: a full MAC
+// reveals which rows hold equal values, while a short index makes unrelated
+// values collide, so that every index value is shared by several rows. The
+// functions FalsePositives and MaxBits help choosing the size of an index.
+//
+// Each Indexer is bound to a field name, so that the same value gets
+// unrelated indexes in different fields even under the same key. Values that
+// should match despite differences, such as email addresses in different
+// cases, must be normalized by the caller.
+package blindindex // import "golang.org/x/crypto/blindindex"
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/binary"
+	"errors"
+	"hash"
+	"math"
+
+	"golang.org/x/crypto/blake2b"
+)
+
+// An Algorithm identifies the MAC of an Indexer.
+type Algorithm uint8
+
+const (
+	HMAC_SHA256 Algorithm = 1 // import crypto/sha256
+	BLAKE2b_256 Algorithm = 2 // import golang.org/x/crypto/blake2b
+)
+
+// MinKeySize is the minimum size, in bytes, of keys.
+const MinKeySize = 16
+
+var (
+	errUnknownAlgorithm = errors.New("blindindex: unknown algorithm")
+	errKeySize          = errors.New("blindindex: invalid key size")
+	errBits             = errors.New("blindindex: size must be between 1 and 256 bits")
+)
+
+const domain = "golang.org/x/crypto/blindindex v1"
+
+// An Indexer computes the blind indexes of one field. It is safe for
+// concurrent use.
+type Indexer struct {
+	alg   Algorithm
+	key   []byte
+	field string
+	bits  int
+}
+
+// New returns an Indexer of the given size, in bits, for the named field.
+// The key must be at least MinKeySize bytes long, and at most 64 bytes long
+// for BLAKE2b_256. The same key can be used for several fields.
+func New(alg Algorithm, key []byte, field string, bits int) (*Indexer, error) {
+	switch alg {
+	case HMAC_SHA256:
+	case BLAKE2b_256:
+		if len(key) > blake2b.Size {
+			return nil, errKeySize
+		}
+	default:
+		return nil, errUnknownAlgorithm
+	}
+	if len(key) < MinKeySize {
+		return nil, errKeySize
+	}
+	if bits < 1 || bits > 256 {
+		return nil, errBits
+	}
+	return &Indexer{
+		alg:   alg,
+		key:   append([]byte(nil), key...),
+		field: field,
+		bits:  bits,
+	}, nil
+}
+
+// Bits returns the size of the indexes, in bits.
+func (x *Indexer) Bits() int {
+	return x.bits
+}
+
+func (x *Indexer) mac() hash.Hash {
+	if x.alg == BLAKE2b_256 {
+		h, _ := blake2b.New256(x.key)
+		return h
+	}
+	return hmac.New(sha256.New, x.key)
+}
+
+// Index returns the blind index of value. It is (Bits()+7)/8 bytes long,
+// and the unused low bits of its last byte are zero.
+func (x *Indexer) Index(value []byte) []byte {
+	h := x.mac()
+	var n [8]byte
+	h.Write([]byte(domain))
+	binary.BigEndian.PutUint64(n[:], uint64(len(x.field)))
+	h.Write(n[:])
+	h.Write([]byte(x.field))
+	h.Write(value)
+
+	size := (x.bits + 7) / 8
+	index := h.Sum(nil)[:size]
+	index[size-1] &= 0xff << uint(8*size-x.bits)
+	return index
+}
+
+// FalsePositives returns the expected number of other rows that share the
+// index of a given row, in a table of the given number of rows with distinct
+// values and indexes of the given size. It is both the expected number of
+// false positives of a query, and a measure of how well the index hides
+// which rows hold equal values.
+func FalsePositives(rows uint64, bits int) float64 {
+	if rows == 0 {
+		return 0
+	}
+	return float64(rows-1) / math.Exp2(float64(bits))
+}
+
+// MaxBits returns the largest index size for which FalsePositives(rows, bits)
+// is at least min, or 0 if no size is small enough. Smaller sizes give more
+// false positives and leak less.
+func MaxBits(rows uint64, min float64) int {
+	if min <= 0 {
+		return 256
+	}
+	if rows < 2 {
+		return 0
+	}
+	bits := math.Floor(math.Log2(float64(rows-1) / min))
+	if bits < 1 {
+		return 0
+	}
+	if bits > 256 {
+		return 256
+	}
+	return int(bits)
+}
+
+// A Rotation helps moving the indexes of a field to a new key or size. While
+// it is in progress, every write stores both indexes of the value, as
+// returned by Indexes, and queries look up the old index in the old column
+// or the new index in the new column. Once all rows were rewritten with both
+// indexes, the old column can be dropped and New used alone.
+type Rotation struct {
+	Old, New *Indexer
+}
+
+// Indexes returns the old and new indexes of value.
+func (r *Rotation) Indexes(value []byte) (old, new []byte) {
+	return r.Old.Index(value), r.New.Index(value)
+}
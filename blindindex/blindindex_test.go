@@ -0,0 +1,132 @@
+// Copyright 2018 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package blindindex
+
+import (
+	"bytes"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/binary"
+	"testing"
+)
+
+var testKey = []byte("0123456789abcdef0123456789abcdef")
+
+func TestIndex(t *testing.T) {
+	for _, alg := range []Algorithm{HMAC_SHA256, BLAKE2b_256} {
+		for _, bits := range []int{1, 7, 8, 12, 32, 255, 256} {
+			x, err := New(alg, testKey, "email", bits)
+			if err != nil {
+				t.Fatal(err)
+			}
+			a := x.Index([]byte("alice@example.com"))
+			if len(a) != (bits+7)/8 {
+				t.Fatalf("alg %d, %d bits: index is %d bytes long", alg, bits, len(a))
+			}
+			if unused := uint(8*len(a) - bits); a[len(a)-1]&(1<<unused-1) != 0 {
+				t.Errorf("alg %d, %d bits: unused bits set in %x", alg, bits, a)
+			}
+			if b := x.Index([]byte("alice@example.com")); !bytes.Equal(a, b) {
+				t.Errorf("alg %d, %d bits: index is not deterministic", alg, bits)
+			}
+		}
+	}
+}
+
+func TestIndexSeparation(t *testing.T) {
+	email, _ := New(HMAC_SHA256, testKey, "email", 256)
+	name, _ := New(HMAC_SHA256, testKey, "name", 256)
+	other, _ := New(HMAC_SHA256, []byte("fedcba9876543210"), "email", 256)
+	blake, _ := New(BLAKE2b_256, testKey, "email", 256)
+	v := []byte("alice")
+
+	indexes := [][]byte{email.Index(v), name.Index(v), other.Index(v), blake.Index(v), email.Index([]byte("bob"))}
+	for i := range indexes {
+		for j := i + 1; j < len(indexes); j++ {
+			if bytes.Equal(indexes[i], indexes[j]) {
+				t.Errorf("indexes %d and %d are equal", i, j)
+			}
+		}
+	}
+
+	// A field name cannot be shifted into the value.
+	em, _ := New(HMAC_SHA256, testKey, "em", 256)
+	if bytes.Equal(em.Index([]byte("ailalice")), email.Index([]byte("alice"))) {
+		t.Error("field name and value are ambiguous")
+	}
+}
+
+func TestIndexVector(t *testing.T) {
+	// The HMAC-SHA256 index is a truncation of the MAC of the documented
+	// encoding.
+	x, _ := New(HMAC_SHA256, testKey, "ssn", 20)
+	m := hmac.New(sha256.New, testKey)
+	m.Write([]byte("golang.org/x/crypto/blindindex v1"))
+	var n [8]byte
+	binary.BigEndian.PutUint64(n[:], 3)
+	m.Write(n[:])
+	m.Write([]byte("ssn"))
+	m.Write([]byte("123-45-6789"))
+	want := m.Sum(nil)[:3]
+	want[2] &= 0xf0
+	if got := x.Index([]byte("123-45-6789")); !bytes.Equal(got, want) {
+		t.Errorf("got %x, want %x", got, want)
+	}
+}
+
+func TestNewErrors(t *testing.T) {
+	for _, tt := range []struct {
+		alg  Algorithm
+		key  []byte
+		bits int
+	}{
+		{0, testKey, 16},
+		{HMAC_SHA256, testKey[:MinKeySize-1], 16},
+		{BLAKE2b_256, make([]byte, 65), 16},
+		{HMAC_SHA256, testKey, 0},
+		{HMAC_SHA256, testKey, 257},
+	} {
+		if _, err := New(tt.alg, tt.key, "f", tt.bits); err == nil {
+			t.Errorf("New(%d, %d-byte key, %d bits) succeeded", tt.alg, len(tt.key), tt.bits)
+		}
+	}
+}
+
+func TestSizing(t *testing.T) {
+	if got := FalsePositives(1<<20+1, 16); got != 16 {
+		t.Errorf("FalsePositives(2^20+1, 16) = %v, want 16", got)
+	}
+	for _, tt := range []struct {
+		rows uint64
+		min  float64
+		want int
+	}{
+		{1<<20 + 1, 16, 16},
+		{1 << 20, 16, 15},
+		{1000, 1000, 0},
+		{1, 1, 0},
+		{0, 1, 0},
+		{1000, 0, 256},
+	} {
+		bits := MaxBits(tt.rows, tt.min)
+		if bits != tt.want {
+			t.Errorf("MaxBits(%d, %v) = %d, want %d", tt.rows, tt.min, bits, tt.want)
+		}
+		if bits > 0 && tt.min > 0 && FalsePositives(tt.rows, bits) < tt.min {
+			t.Errorf("MaxBits(%d, %v) = %d gives too few false positives", tt.rows, tt.min, bits)
+		}
+	}
+}
+
+func TestRotation(t *testing.T) {
+	old, _ := New(HMAC_SHA256, testKey, "email", 16)
+	new, _ := New(BLAKE2b_256, []byte("a new key for rotation"), "email", 24)
+	r := &Rotation{Old: old, New: new}
+	v := []byte("alice@example.com")
+	o, n := r.Indexes(v)
+	if !bytes.Equal(o, old.Index(v)) || !bytes.Equal(n, new.Index(v)) {
+		t.Error("Rotation.Indexes does not match the Indexers")
+	}
+}
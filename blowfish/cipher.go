@@ -8,7 +8,13 @@ package blowfish // import "golang.org/x/crypto/blowfish"
 // The code is a port of Bruce Schneier's C implementation.
 // See https://www.schneier.com/blowfish.html.
 
-import "strconv"
+import (
+	"strconv"
+
+	"golang.org/x/crypto/policy"
+)
+
+var algorithm = policy.Register("blowfish", false)
 
 // The Blowfish block size in bytes.
 const BlockSize = 8
@@ -27,7 +33,11 @@ func (k KeySizeError) Error() string {
 
 // NewCipher creates and returns a Cipher.
 // The key argument should be the Blowfish key, from 1 to 56 bytes.
+// It returns a *policy.DisabledError if Blowfish is disabled.
 func NewCipher(key []byte) (*Cipher, error) {
+	if err := algorithm.Check(); err != nil {
+		return nil, err
+	}
 	var result Cipher
 	if k := len(key); k < 1 || k > 56 {
 		return nil, KeySizeError(k)
@@ -45,6 +55,9 @@ func NewSaltedCipher(key, salt []byte) (*Cipher, error) {
 	if len(salt) == 0 {
 		return NewCipher(key)
 	}
+	if err := algorithm.Check(); err != nil {
+		return nil, err
+	}
 	var result Cipher
 	if k := len(key); k < 1 {
 		return nil, KeySizeError(k)
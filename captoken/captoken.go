@@ -0,0 +1,352 @@
+// Copyright 2018 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+// Package captoken issues and verifies opaque capability tokens, such as API
+// keys, whose possession grants access to something.
+//
+// A token is made of an ID, which is not secret and identifies the token in
+// storage and in logs, and a random 256-bit secret. The holder of a token
+// gets its String form. The server only stores the token's ID and a digest,
+// a keyed MAC of the token, so that a copy of its storage does not contain
+// any usable token, nor allow checking guesses without the MAC key.
+//
+// The MAC keys are held by a Verifier, which checks presented tokens against
+// stored digests in constant time. A Verifier can hold several keys, each
+// with a key ID recorded in the digests it produced, so that keys can be
+// rotated: new digests use the primary key, while digests made with older
+// keys keep verifying until they are replaced, as reported by NeedsRehash.
+// A Verifier also keeps a revocation list of token IDs, and reports every
+// issuance, verification and revocation to an optional audit function.
+package captoken // import "golang.org/x/crypto/captoken"
+
+import (
+	"crypto/hmac"
+	cryptorand "crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/binary"
+	"errors"
+	"io"
+	"sort"
+	"strings"
+	"sync"
+
+	"golang.org/x/crypto/subtle"
+)
+
+const (
+	// IDSize is the size, in bytes, of token IDs.
+	IDSize = 12
+	// SecretSize is the size, in bytes, of the secret part of tokens.
+	SecretSize = 32
+	// MinKeySize is the minimum size, in bytes, of MAC keys.
+	MinKeySize = 32
+	// DigestSize is the size, in bytes, of digests.
+	DigestSize = 4 + sha256.Size
+)
+
+// prefix starts the String form of tokens, so that they can be recognized,
+// for example by secret scanners, and so that the format can change.
+const prefix = "ct1_"
+
+const domain = "golang.org/x/crypto/captoken v1"
+
+var (
+	// ErrInvalidToken is returned by Verify if the token does not match the
+	// digest.
+	ErrInvalidToken = errors.New("captoken: invalid token")
+	// ErrRevoked is returned by Verify for valid tokens that were revoked.
+	ErrRevoked = errors.New("captoken: token revoked")
+	// ErrUnknownKey is returned by Verify if the digest was made with a key
+	// the Verifier does not hold, for example one that was removed.
+	ErrUnknownKey = errors.New("captoken: digest made with an unknown key")
+
+	errMalformedToken  = errors.New("captoken: malformed token")
+	errMalformedID     = errors.New("captoken: malformed token ID")
+	errMalformedDigest = errors.New("captoken: malformed digest")
+	errKeySize         = errors.New("captoken: MAC key too short")
+	errDuplicateKey    = errors.New("captoken: key ID already in use")
+	errPrimaryKey      = errors.New("captoken: cannot remove the primary key")
+)
+
+// An ID identifies a token. It is not secret.
+type ID [IDSize]byte
+
+// String returns the unpadded base64url encoding of id.
+func (id ID) String() string {
+	return base64.RawURLEncoding.EncodeToString(id[:])
+}
+
+// ParseID parses an ID encoded by ID.String.
+func ParseID(s string) (ID, error) {
+	var id ID
+	b, err := base64.RawURLEncoding.DecodeString(s)
+	if err != nil || len(b) != IDSize {
+		return id, errMalformedID
+	}
+	copy(id[:], b)
+	return id, nil
+}
+
+// A Token is a capability token.
+type Token struct {
+	ID     ID
+	secret [SecretSize]byte
+}
+
+// NewToken returns a new token with a random ID and secret read from rand.
+// If rand is nil, crypto/rand.Reader is used.
+func NewToken(rand io.Reader) (*Token, error) {
+	if rand == nil {
+		rand = cryptorand.Reader
+	}
+	t := new(Token)
+	if _, err := io.ReadFull(rand, t.ID[:]); err != nil {
+		return nil, err
+	}
+	if _, err := io.ReadFull(rand, t.secret[:]); err != nil {
+		return nil, err
+	}
+	return t, nil
+}
+
+// String returns the form of t given to its holder: a fixed prefix followed
+// by the unpadded base64url encoding of the ID and the secret.
+func (t *Token) String() string {
+	b := make([]byte, 0, IDSize+SecretSize)
+	b = append(b, t.ID[:]...)
+	b = append(b, t.secret[:]...)
+	return prefix + base64.RawURLEncoding.EncodeToString(b)
+}
+
+// ParseToken parses a token encoded by Token.String. It takes time that
+// depends only on the length of s.
+func ParseToken(s string) (*Token, error) {
+	if !strings.HasPrefix(s, prefix) {
+		return nil, errMalformedToken
+	}
+	b, err := subtle.DecodeBase64URLString(s[len(prefix):])
+	if err != nil || len(b) != IDSize+SecretSize {
+		return nil, errMalformedToken
+	}
+	t := new(Token)
+	copy(t.ID[:], b)
+	copy(t.secret[:], b[IDSize:])
+	return t, nil
+}
+
+// An EventKind is the kind of an audit Event.
+type EventKind int
+
+const (
+	// Issued is reported by Issue for every new token.
+	Issued EventKind = iota + 1
+	// Verified is reported by Verify when a token is accepted.
+	Verified
+	// Rejected is reported by Verify when a token is rejected.
+	Rejected
+	// Revoked is reported by Revoke.
+	Revoked
+)
+
+// An Event is reported to the audit function of a Verifier. It never holds
+// the secret of a token.
+type Event struct {
+	Kind EventKind
+	ID   ID
+	// KeyID is the ID of the key of the digest that was made or checked, or
+	// zero for revocations.
+	KeyID uint32
+	// Err is the error returned by Verify for Rejected events.
+	Err error
+}
+
+// A Verifier computes and checks the digests of tokens with a set of MAC
+// keys, and holds a revocation list. It is safe for concurrent use.
+type Verifier struct {
+	mu      sync.RWMutex
+	primary uint32
+	keys    map[uint32][]byte
+	revoked map[ID]bool
+	audit   func(Event)
+}
+
+// NewVerifier returns a Verifier whose primary key is key, identified by
+// keyID. The key must be at least MinKeySize bytes long and be kept
+// separately from the stored digests. Key IDs are stored in digests, and
+// must not be reused for different keys.
+func NewVerifier(keyID uint32, key []byte) (*Verifier, error) {
+	if len(key) < MinKeySize {
+		return nil, errKeySize
+	}
+	return &Verifier{
+		primary: keyID,
+		keys:    map[uint32][]byte{keyID: append([]byte(nil), key...)},
+		revoked: make(map[ID]bool),
+	}, nil
+}
+
+// AddKey adds a key with which digests can be verified, but which is not
+// used for new digests until it is made primary with SetPrimaryKey.
+func (v *Verifier) AddKey(keyID uint32, key []byte) error {
+	if len(key) < MinKeySize {
+		return errKeySize
+	}
+	v.mu.Lock()
+	defer v.mu.Unlock()
+	if _, ok := v.keys[keyID]; ok {
+		return errDuplicateKey
+	}
+	v.keys[keyID] = append([]byte(nil), key...)
+	return nil
+}
+
+// SetPrimaryKey selects the key, previously added, used for new digests.
+func (v *Verifier) SetPrimaryKey(keyID uint32) error {
+	v.mu.Lock()
+	defer v.mu.Unlock()
+	if _, ok := v.keys[keyID]; !ok {
+		return ErrUnknownKey
+	}
+	v.primary = keyID
+	return nil
+}
+
+// RemoveKey removes a key that is not the primary one. Digests made with it
+// no longer verify, so it should only be removed once they were all replaced.
+func (v *Verifier) RemoveKey(keyID uint32) error {
+	v.mu.Lock()
+	defer v.mu.Unlock()
+	if keyID == v.primary {
+		return errPrimaryKey
+	}
+	delete(v.keys, keyID)
+	return nil
+}
+
+// SetAuditFunc sets a function that is called for every Event, or removes it
+// if f is nil. f may be called concurrently by several goroutines, and must
+// not call the methods of v.
+func (v *Verifier) SetAuditFunc(f func(Event)) {
+	v.mu.Lock()
+	defer v.mu.Unlock()
+	v.audit = f
+}
+
+// report must be called with v.mu held.
+func (v *Verifier) report(e Event) {
+	if v.audit != nil {
+		v.audit(e)
+	}
+}
+
+func mac(key []byte, keyID uint32, t *Token) []byte {
+	m := hmac.New(sha256.New, key)
+	var n [4]byte
+	binary.BigEndian.PutUint32(n[:], keyID)
+	m.Write([]byte(domain))
+	m.Write(n[:])
+	m.Write(t.ID[:])
+	m.Write(t.secret[:])
+	return m.Sum(nil)
+}
+
+// Issue returns a new token and its digest, made with the primary key. The
+// server stores the digest under the ID of the token, and hands the String
+// form of the token to its holder, without keeping it.
+func (v *Verifier) Issue() (*Token, []byte, error) {
+	t, err := NewToken(nil)
+	if err != nil {
+		return nil, nil, err
+	}
+	v.mu.RLock()
+	defer v.mu.RUnlock()
+	v.report(Event{Kind: Issued, ID: t.ID, KeyID: v.primary})
+	return t, v.digest(t), nil
+}
+
+// Digest returns the digest of t made with the primary key. It is used to
+// replace digests for which NeedsRehash reports true, once their token was
+// verified.
+func (v *Verifier) Digest(t *Token) []byte {
+	v.mu.RLock()
+	defer v.mu.RUnlock()
+	return v.digest(t)
+}
+
+func (v *Verifier) digest(t *Token) []byte {
+	d := make([]byte, 4, DigestSize)
+	binary.BigEndian.PutUint32(d, v.primary)
+	return append(d, mac(v.keys[v.primary], v.primary, t)...)
+}
+
+// Verify checks that t matches digest, in constant time, and that it was not
+// revoked. It returns nil if t is valid. The digest is the one stored under
+// t.ID.
+func (v *Verifier) Verify(t *Token, digest []byte) error {
+	v.mu.RLock()
+	defer v.mu.RUnlock()
+	keyID, err := v.verify(t, digest)
+	if err != nil {
+		v.report(Event{Kind: Rejected, ID: t.ID, KeyID: keyID, Err: err})
+		return err
+	}
+	v.report(Event{Kind: Verified, ID: t.ID, KeyID: keyID})
+	return nil
+}
+
+func (v *Verifier) verify(t *Token, digest []byte) (uint32, error) {
+	if len(digest) != DigestSize {
+		return 0, errMalformedDigest
+	}
+	keyID := binary.BigEndian.Uint32(digest)
+	key, ok := v.keys[keyID]
+	if !ok {
+		return keyID, ErrUnknownKey
+	}
+	if !hmac.Equal(mac(key, keyID, t), digest[4:]) {
+		return keyID, ErrInvalidToken
+	}
+	// Revocation is only checked for valid tokens, so that it is not
+	// revealed to those who do not hold the token.
+	if v.revoked[t.ID] {
+		return keyID, ErrRevoked
+	}
+	return keyID, nil
+}
+
+// NeedsRehash reports whether digest was not made with the primary key, and
+// should be replaced with the output of Digest the next time its token is
+// verified.
+func (v *Verifier) NeedsRehash(digest []byte) bool {
+	v.mu.RLock()
+	defer v.mu.RUnlock()
+	return len(digest) != DigestSize || binary.BigEndian.Uint32(digest) != v.primary
+}
+
+// Revoke adds id to the revocation list, so that Verify rejects its token
+// with ErrRevoked. Revoking a token is only needed while its digest may
+// still be stored, such as in backups or caches; otherwise, deleting the
+// digest is enough.
+func (v *Verifier) Revoke(id ID) {
+	v.mu.Lock()
+	defer v.mu.Unlock()
+	v.revoked[id] = true
+	v.report(Event{Kind: Revoked, ID: id})
+}
+
+// RevocationList returns the revoked IDs, sorted, so that they can be
+// persisted and restored with Revoke.
+func (v *Verifier) RevocationList() []ID {
+	v.mu.RLock()
+	defer v.mu.RUnlock()
+	ids := make([]ID, 0, len(v.revoked))
+	for id := range v.revoked {
+		ids = append(ids, id)
+	}
+	sort.Slice(ids, func(i, j int) bool {
+		return string(ids[i][:]) < string(ids[j][:])
+	})
+	return ids
+}
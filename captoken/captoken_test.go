@@ -0,0 +1,182 @@
+// Copyright 2018 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package captoken
+
+import (
+	"bytes"
+	"strings"
+	"sync"
+	"testing"
+)
+
+func testKey(b byte) []byte {
+	return bytes.Repeat([]byte{b}, MinKeySize)
+}
+
+func TestTokenString(t *testing.T) {
+	tok, err := NewToken(nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	s := tok.String()
+	if !strings.HasPrefix(s, "ct1_") {
+		t.Errorf("token %q does not have the expected prefix", s)
+	}
+	parsed, err := ParseToken(s)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if *parsed != *tok {
+		t.Errorf("ParseToken(%q) = %v, want %v", s, parsed, tok)
+	}
+	id, err := ParseID(tok.ID.String())
+	if err != nil || id != tok.ID {
+		t.Errorf("ParseID = %v, %v, want %v", id, err, tok.ID)
+	}
+
+	for _, s := range []string{"", s[4:], "ct2_" + s[4:], s[:len(s)-1], s + "A", s[:10] + "!" + s[11:]} {
+		if _, err := ParseToken(s); err == nil {
+			t.Errorf("ParseToken(%q) succeeded", s)
+		}
+	}
+}
+
+func TestVerify(t *testing.T) {
+	v, err := NewVerifier(1, testKey(1))
+	if err != nil {
+		t.Fatal(err)
+	}
+	tok, digest, err := v.Issue()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(digest) != DigestSize {
+		t.Fatalf("digest is %d bytes long, want %d", len(digest), DigestSize)
+	}
+	if err := v.Verify(tok, digest); err != nil {
+		t.Errorf("Verify rejected a fresh token: %v", err)
+	}
+	if bytes.Contains(digest, tok.secret[:]) {
+		t.Errorf("digest contains the token secret")
+	}
+
+	other, _ := NewToken(nil)
+	if err := v.Verify(other, digest); err != ErrInvalidToken {
+		t.Errorf("Verify of another token = %v, want ErrInvalidToken", err)
+	}
+	// The digest is bound to the ID of the token.
+	moved := *tok
+	moved.ID = other.ID
+	if err := v.Verify(&moved, digest); err != ErrInvalidToken {
+		t.Errorf("Verify of a token with another ID = %v, want ErrInvalidToken", err)
+	}
+	tampered := append([]byte(nil), digest...)
+	tampered[len(tampered)-1] ^= 1
+	if err := v.Verify(tok, tampered); err != ErrInvalidToken {
+		t.Errorf("Verify with a tampered digest = %v, want ErrInvalidToken", err)
+	}
+	if err := v.Verify(tok, digest[:10]); err == nil {
+		t.Errorf("Verify with a short digest succeeded")
+	}
+
+	// A different key does not verify the digest.
+	v2, _ := NewVerifier(1, testKey(2))
+	if err := v2.Verify(tok, digest); err != ErrInvalidToken {
+		t.Errorf("Verify with another key = %v, want ErrInvalidToken", err)
+	}
+
+	if _, err := NewVerifier(1, testKey(1)[:MinKeySize-1]); err == nil {
+		t.Errorf("short key accepted")
+	}
+}
+
+func TestRotation(t *testing.T) {
+	v, _ := NewVerifier(1, testKey(1))
+	tok, oldDigest, _ := v.Issue()
+	if v.NeedsRehash(oldDigest) {
+		t.Errorf("NeedsRehash is true for a digest made with the primary key")
+	}
+
+	if err := v.SetPrimaryKey(2); err != ErrUnknownKey {
+		t.Errorf("SetPrimaryKey of a missing key = %v, want ErrUnknownKey", err)
+	}
+	if err := v.AddKey(2, testKey(2)); err != nil {
+		t.Fatal(err)
+	}
+	if err := v.AddKey(2, testKey(3)); err == nil {
+		t.Errorf("AddKey reused a key ID")
+	}
+	if err := v.SetPrimaryKey(2); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := v.Verify(tok, oldDigest); err != nil {
+		t.Errorf("old digest rejected after rotation: %v", err)
+	}
+	if !v.NeedsRehash(oldDigest) {
+		t.Errorf("NeedsRehash is false for a digest made with an old key")
+	}
+	newDigest := v.Digest(tok)
+	if v.NeedsRehash(newDigest) {
+		t.Errorf("NeedsRehash is true for a new digest")
+	}
+	if err := v.Verify(tok, newDigest); err != nil {
+		t.Errorf("new digest rejected: %v", err)
+	}
+
+	if err := v.RemoveKey(2); err == nil {
+		t.Errorf("RemoveKey removed the primary key")
+	}
+	if err := v.RemoveKey(1); err != nil {
+		t.Fatal(err)
+	}
+	if err := v.Verify(tok, oldDigest); err != ErrUnknownKey {
+		t.Errorf("Verify with a removed key = %v, want ErrUnknownKey", err)
+	}
+}
+
+func TestRevocationAndAudit(t *testing.T) {
+	v, _ := NewVerifier(7, testKey(1))
+	var mu sync.Mutex
+	var events []Event
+	v.SetAuditFunc(func(e Event) {
+		mu.Lock()
+		events = append(events, e)
+		mu.Unlock()
+	})
+
+	tok, digest, _ := v.Issue()
+	other, _ := NewToken(nil)
+	v.Verify(tok, digest)
+	v.Verify(other, digest)
+	v.Revoke(tok.ID)
+	if err := v.Verify(tok, digest); err != ErrRevoked {
+		t.Errorf("Verify of a revoked token = %v, want ErrRevoked", err)
+	}
+	// Revocation is not revealed without the token.
+	if err := v.Verify(&Token{ID: tok.ID}, digest); err != ErrInvalidToken {
+		t.Errorf("Verify of a forged revoked token = %v, want ErrInvalidToken", err)
+	}
+	if list := v.RevocationList(); len(list) != 1 || list[0] != tok.ID {
+		t.Errorf("RevocationList = %v, want [%v]", list, tok.ID)
+	}
+
+	want := []Event{
+		{Kind: Issued, ID: tok.ID, KeyID: 7},
+		{Kind: Verified, ID: tok.ID, KeyID: 7},
+		{Kind: Rejected, ID: other.ID, KeyID: 7, Err: ErrInvalidToken},
+		{Kind: Revoked, ID: tok.ID},
+		{Kind: Rejected, ID: tok.ID, KeyID: 7, Err: ErrRevoked},
+		{Kind: Rejected, ID: tok.ID, KeyID: 7, Err: ErrInvalidToken},
+	}
+	if len(events) != len(want) {
+		t.Fatalf("got %d events, want %d: %v", len(events), len(want), events)
+	}
+	for i := range want {
+		if events[i] != want[i] {
+			t.Errorf("event %d = %+v, want %+v", i, events[i], want[i])
+		}
+	}
+}
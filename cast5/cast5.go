@@ -6,7 +6,13 @@
 // OpenPGP cipher.
 package cast5 // import "golang.org/x/crypto/cast5"
 
-import "errors"
+import (
+	"errors"
+
+	"golang.org/x/crypto/policy"
+)
+
+var algorithm = policy.Register("cast5", false)
 
 const BlockSize = 8
 const KeySize = 16
@@ -16,7 +22,11 @@ type Cipher struct {
 	rotate  [16]uint8
 }
 
+// NewCipher returns a *policy.DisabledError if CAST5 is disabled.
 func NewCipher(key []byte) (c *Cipher, err error) {
+	if err := algorithm.Check(); err != nil {
+		return nil, err
+	}
 	if len(key) != KeySize {
 		return nil, errors.New("CAST5: keys must be 16 bytes")
 	}
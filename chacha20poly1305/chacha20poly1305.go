@@ -9,6 +9,8 @@ import (
 	"crypto/cipher"
 	"encoding/binary"
 	"errors"
+
+	"golang.org/x/crypto/policy"
 )
 
 const (
@@ -18,12 +20,22 @@ const (
 	NonceSize = 12
 )
 
+var (
+	algorithm  = policy.Register("chacha20poly1305", false)
+	algorithmX = policy.Register("xchacha20poly1305", false)
+)
+
 type chacha20poly1305 struct {
 	key [8]uint32
 }
 
 // New returns a ChaCha20-Poly1305 AEAD that uses the given, 256-bit key.
+//
+// New returns a *policy.DisabledError if ChaCha20-Poly1305 is disabled.
 func New(key []byte) (cipher.AEAD, error) {
+	if err := algorithm.Check(); err != nil {
+		return nil, err
+	}
 	if len(key) != KeySize {
 		return nil, errors.New("chacha20poly1305: bad key length")
 	}
@@ -6,10 +6,13 @@ package chacha20poly1305
 
 import (
 	"bytes"
+	"crypto/cipher"
 	cr "crypto/rand"
 	"encoding/hex"
 	mr "math/rand"
 	"testing"
+
+	"golang.org/x/crypto/policy"
 )
 
 func TestVectors(t *testing.T) {
@@ -66,6 +69,19 @@ func TestVectors(t *testing.T) {
 	}
 }
 
+func TestPolicy(t *testing.T) {
+	policy.SetApprovedOnly(true)
+	defer policy.SetApprovedOnly(false)
+	key := make([]byte, KeySize)
+	for name, constructor := range map[string]func([]byte) (cipher.AEAD, error){"New": New, "NewX": NewX} {
+		if _, err := constructor(key); err == nil {
+			t.Errorf("%s succeeded in approved-only mode", name)
+		} else if _, ok := err.(*policy.DisabledError); !ok {
+			t.Errorf("%s returned %v, want a *policy.DisabledError", name, err)
+		}
+	}
+}
+
 func TestRandom(t *testing.T) {
 	// Some random tests to verify Open(Seal) == Plaintext
 	for i := 0; i < 256; i++ {
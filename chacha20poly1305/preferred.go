@@ -0,0 +1,79 @@
+// Copyright 2018 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package chacha20poly1305
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"errors"
+
+	"golang.org/x/sys/cpu"
+)
+
+// A Policy selects the AEAD returned by NewAEADPreferred.
+type Policy int
+
+const (
+	// PreferHardware selects AES-GCM if the CPU has instructions for both AES
+	// and GHASH, and ChaCha20-Poly1305 otherwise, as crypto/tls orders its
+	// cipher suites.
+	PreferHardware Policy = iota
+	// PreferChaCha20Poly1305 selects ChaCha20-Poly1305, whose software
+	// implementation is fast and constant time on every platform. If
+	// ChaCha20-Poly1305 is disabled by golang.org/x/crypto/policy, it
+	// silently selects AES-GCM instead, without returning an error.
+	PreferChaCha20Poly1305
+	// PreferAESGCM selects AES-GCM.
+	PreferAESGCM
+	// RequireApproved selects AES-GCM, the FIPS 140 approved choice, as
+	// every policy does when golang.org/x/crypto/policy is in approved-only
+	// mode or ChaCha20-Poly1305 is disabled.
+	RequireApproved
+)
+
+// hasAESGCMHardware reports whether AES-GCM is hardware accelerated, with the
+// checks made by crypto/tls.
+//
+// The cpu.ARM64 and cpu.S390X fields used here are missing from older
+// versions of golang.org/x/sys, such as those from 2018, which therefore do
+// not compile this package. The oldest version it was built with is
+// v0.0.0-20201101102859-da207088b7d1.
+var hasAESGCMHardware = cpu.X86.HasAES && cpu.X86.HasPCLMULQDQ ||
+	cpu.ARM64.HasAES && cpu.ARM64.HasPMULL ||
+	cpu.S390X.HasAES && cpu.S390X.HasAESCBC && cpu.S390X.HasAESCTR &&
+		(cpu.S390X.HasGHASH || cpu.S390X.HasAESGCM)
+
+// NewAEADPreferred returns either a ChaCha20-Poly1305 AEAD or an AES-256-GCM
+// AEAD that uses the given, 256-bit key, chosen according to p, the hardware
+// and the algorithm policy. Both take 12-byte nonces and add 16-byte tags, so
+// callers need not know which one they got.
+//
+// The two AEADs do not produce the same ciphertexts, and with PreferHardware
+// the choice depends on the machine, so data sealed by one process may not
+// open in another. NewAEADPreferred suits connections and other short-lived
+// state; stored data should use a fixed Policy, or record the one in use.
+func NewAEADPreferred(key []byte, p Policy) (cipher.AEAD, error) {
+	if len(key) != KeySize {
+		return nil, errors.New("chacha20poly1305: bad key length")
+	}
+	useChaCha := false
+	switch p {
+	case PreferHardware:
+		useChaCha = !hasAESGCMHardware
+	case PreferChaCha20Poly1305:
+		useChaCha = true
+	case PreferAESGCM, RequireApproved:
+	default:
+		return nil, errors.New("chacha20poly1305: unknown AEAD policy")
+	}
+	if useChaCha && algorithm.Check() == nil {
+		return New(key)
+	}
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, err
+	}
+	return cipher.NewGCM(block)
+}
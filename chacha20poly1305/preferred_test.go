@@ -0,0 +1,77 @@
+// Copyright 2018 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package chacha20poly1305
+
+import (
+	"bytes"
+	"testing"
+
+	"golang.org/x/crypto/policy"
+)
+
+func TestNewAEADPreferred(t *testing.T) {
+	defer func(hw bool) { hasAESGCMHardware = hw }(hasAESGCMHardware)
+	defer policy.SetApprovedOnly(false)
+	defer policy.Enable("chacha20poly1305")
+
+	key := make([]byte, KeySize)
+	for i := range key {
+		key[i] = byte(i)
+	}
+	tests := []struct {
+		desc         string
+		hw           bool
+		approvedOnly bool
+		disabled     bool
+		p            Policy
+		wantChaCha   bool
+	}{
+		{"hardware with AES", true, false, false, PreferHardware, false},
+		{"hardware without AES", false, false, false, PreferHardware, true},
+		{"ChaCha20-Poly1305", true, false, false, PreferChaCha20Poly1305, true},
+		{"AES-GCM", false, false, false, PreferAESGCM, false},
+		{"approved", false, false, false, RequireApproved, false},
+		{"approved-only mode", false, true, false, PreferChaCha20Poly1305, false},
+		{"approved-only mode without AES", false, true, false, PreferHardware, false},
+		{"ChaCha20-Poly1305 disabled", false, false, true, PreferChaCha20Poly1305, false},
+	}
+	for _, test := range tests {
+		hasAESGCMHardware = test.hw
+		policy.SetApprovedOnly(test.approvedOnly)
+		if test.disabled {
+			policy.Disable("chacha20poly1305")
+		} else {
+			policy.Enable("chacha20poly1305")
+		}
+
+		aead, err := NewAEADPreferred(key, test.p)
+		if err != nil {
+			t.Errorf("%s: %v", test.desc, err)
+			continue
+		}
+		if _, isChaCha := aead.(*chacha20poly1305); isChaCha != test.wantChaCha {
+			t.Errorf("%s: got ChaCha20-Poly1305 %v, want %v", test.desc, isChaCha, test.wantChaCha)
+		}
+		if aead.NonceSize() != NonceSize || aead.Overhead() != 16 {
+			t.Errorf("%s: nonce size %d, overhead %d", test.desc, aead.NonceSize(), aead.Overhead())
+		}
+
+		nonce := make([]byte, NonceSize)
+		plaintext := []byte("preferred AEAD")
+		ct := aead.Seal(nil, nonce, plaintext, nil)
+		if pt, err := aead.Open(nil, nonce, ct, nil); err != nil || !bytes.Equal(pt, plaintext) {
+			t.Errorf("%s: Open = %q, %v", test.desc, pt, err)
+		}
+	}
+
+	policy.SetApprovedOnly(false)
+	policy.Enable("chacha20poly1305")
+	if _, err := NewAEADPreferred(key[:16], PreferAESGCM); err == nil {
+		t.Errorf("short key accepted")
+	}
+	if _, err := NewAEADPreferred(key, Policy(42)); err == nil {
+		t.Errorf("unknown policy accepted")
+	}
+}
@@ -0,0 +1,82 @@
+// Copyright 2018 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package chacha20poly1305
+
+import (
+	"bytes"
+	cr "crypto/rand"
+	"encoding/hex"
+	mr "math/rand"
+	"testing"
+)
+
+func TestXChaCha20Poly1305Vector(t *testing.T) {
+	// This is the test vector from
+	// https://tools.ietf.org/html/draft-irtf-cfrg-xchacha-01#appendix-A.3.1.
+	plaintext := []byte("Ladies and Gentlemen of the class of '99: If I could offer you only one tip for the future, sunscreen would be it.")
+	ad, _ := hex.DecodeString("50515253c0c1c2c3c4c5c6c7")
+	key, _ := hex.DecodeString("808182838485868788898a8b8c8d8e8f909192939495969798999a9b9c9d9e9f")
+	nonce, _ := hex.DecodeString("404142434445464748494a4b4c4d4e4f5051525354555657")
+	const out = "bd6d179d3e83d43b9576579493c0e939572a1700252bfaccbed2902c21396cbb731c7f1b0b4aa6440bf3a82f4eda7e39ae64c6708c54c216cb96b72e1213b4522f8c9ba40db5d945b11b69b982c1bb9e3f3fac2bc369488f76b2383565d3fff921f9664c97637da9768812f615c68b13b52e" +
+		"c0875924c1c7987947deafd8780acf49"
+
+	aead, err := NewX(key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	ct := aead.Seal(nil, nonce, plaintext, ad)
+	if ctHex := hex.EncodeToString(ct); ctHex != out {
+		t.Errorf("got %s, want %s", ctHex, out)
+	}
+	plaintext2, err := aead.Open(nil, nonce, ct, ad)
+	if err != nil {
+		t.Fatal("Open failed")
+	}
+	if !bytes.Equal(plaintext, plaintext2) {
+		t.Errorf("plaintexts don't match: got %x vs %x", plaintext2, plaintext)
+	}
+}
+
+func TestXChaCha20Poly1305Random(t *testing.T) {
+	for i := 0; i < 64; i++ {
+		var nonce [NonceSizeX]byte
+		var key [KeySize]byte
+
+		ad := make([]byte, mr.Intn(128))
+		plaintext := make([]byte, mr.Intn(16384))
+		cr.Read(key[:])
+		cr.Read(nonce[:])
+		cr.Read(ad)
+		cr.Read(plaintext)
+
+		aead, err := NewX(key[:])
+		if err != nil {
+			t.Fatal(err)
+		}
+		if aead.NonceSize() != NonceSizeX {
+			t.Fatalf("NonceSize() = %d, want %d", aead.NonceSize(), NonceSizeX)
+		}
+
+		ct := aead.Seal(nil, nonce[:], plaintext, ad)
+		plaintext2, err := aead.Open(nil, nonce[:], ct, ad)
+		if err != nil {
+			t.Errorf("#%d: Open failed", i)
+			continue
+		}
+		if !bytes.Equal(plaintext, plaintext2) {
+			t.Errorf("#%d: plaintexts don't match: got %x vs %x", i, plaintext2, plaintext)
+			continue
+		}
+
+		// The extended part of the nonce must be authenticated too.
+		for _, idx := range []int{0, 15, 16, 23} {
+			nonce[idx] ^= 0x80
+			if _, err := aead.Open(nil, nonce[:], ct, ad); err == nil {
+				t.Errorf("#%d: Open was successful after altering nonce byte %d", i, idx)
+			}
+			nonce[idx] ^= 0x80
+		}
+	}
+}
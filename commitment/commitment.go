@@ -0,0 +1,200 @@
+// Copyright 2018 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+// Package commitment implements hash-based commitments to byte streams.
+//
+// A commitment lets a party publish a short value that binds it to some data
+// without revealing it, and later open the commitment by revealing the data
+// and the random opening. The commitment is a hash of a domain separation
+// string, the algorithm, a context label, the opening, zero padding up to the
+// block size of the hash, the data, and the length of the data. Every
+// variable-length field is either prefixed or followed by its length, so the
+// encoding is unambiguous: a commitment made under one label cannot be opened
+// under another one, and a commitment to some data cannot be opened to an
+// extension of it. The padding makes the data start on a block boundary, so
+// that the blocks of the hash that cover the data never also cover the
+// opening.
+//
+// Commitments are hiding as long as the opening stays secret, and binding as
+// long as the hash function is collision resistant.
+package commitment // import "golang.org/x/crypto/commitment"
+
+import (
+	cryptorand "crypto/rand"
+	"crypto/subtle"
+	"encoding/binary"
+	"errors"
+	"hash"
+	"io"
+
+	"golang.org/x/crypto/blake2b"
+	"golang.org/x/crypto/sha3"
+)
+
+// Size is the size, in bytes, of commitments and openings.
+const Size = 32
+
+// An Algorithm identifies the hash function of a commitment.
+type Algorithm uint8
+
+const (
+	SHA3_256    Algorithm = 1 // import golang.org/x/crypto/sha3
+	BLAKE2b_256 Algorithm = 2 // import golang.org/x/crypto/blake2b
+)
+
+func (a Algorithm) new() (hash.Hash, error) {
+	switch a {
+	case SHA3_256:
+		return sha3.New256(), nil
+	case BLAKE2b_256:
+		return blake2b.New256(nil)
+	}
+	return nil, errUnknownAlgorithm
+}
+
+// A Commitment binds its creator to some data.
+type Commitment [Size]byte
+
+// An Opening is the secret randomness of a commitment, which is revealed
+// with the data to open it.
+type Opening [Size]byte
+
+// ErrMismatch is returned by Verify when the data and opening do not match
+// the commitment.
+var ErrMismatch = errors.New("commitment: data does not match commitment")
+
+var errUnknownAlgorithm = errors.New("commitment: unknown algorithm")
+
+const domain = "golang.org/x/crypto/commitment v1"
+
+// writer hashes the data of a commitment, and counts its length.
+type writer struct {
+	h      hash.Hash
+	length uint64
+}
+
+func newWriter(alg Algorithm, label string, opening *Opening) (*writer, error) {
+	h, err := alg.new()
+	if err != nil {
+		return nil, err
+	}
+	var n [8]byte
+	h.Write([]byte(domain))
+	h.Write([]byte{byte(alg)})
+	binary.BigEndian.PutUint64(n[:], uint64(len(label)))
+	h.Write(n[:])
+	h.Write([]byte(label))
+	h.Write(opening[:])
+	prefix := len(domain) + 1 + len(n) + len(label) + len(opening)
+	if r := prefix % h.BlockSize(); r != 0 {
+		h.Write(make([]byte, h.BlockSize()-r))
+	}
+	return &writer{h: h}, nil
+}
+
+func (w *writer) Write(p []byte) (int, error) {
+	w.length += uint64(len(p))
+	return w.h.Write(p)
+}
+
+func (w *writer) sum() (c Commitment) {
+	var n [8]byte
+	binary.BigEndian.PutUint64(n[:], w.length)
+	w.h.Write(n[:])
+	copy(c[:], w.h.Sum(nil))
+	return c
+}
+
+// A Committer computes a commitment to the data written to it.
+type Committer struct {
+	w       *writer
+	opening Opening
+}
+
+// NewCommitter returns a Committer for data in the context identified by
+// label. If rand is nil, crypto/rand.Reader is used to generate the opening.
+func NewCommitter(alg Algorithm, rand io.Reader, label string) (*Committer, error) {
+	if rand == nil {
+		rand = cryptorand.Reader
+	}
+	c := new(Committer)
+	if _, err := io.ReadFull(rand, c.opening[:]); err != nil {
+		return nil, err
+	}
+	w, err := newWriter(alg, label, &c.opening)
+	if err != nil {
+		return nil, err
+	}
+	c.w = w
+	return c, nil
+}
+
+// Write adds p to the committed data. It never returns an error.
+func (c *Committer) Write(p []byte) (int, error) {
+	return c.w.Write(p)
+}
+
+// Commit returns the commitment to the data written so far and its opening.
+// The Committer must not be used afterwards.
+func (c *Committer) Commit() (Commitment, Opening) {
+	return c.w.sum(), c.opening
+}
+
+// A Verifier checks that the data written to it opens a commitment.
+type Verifier struct {
+	w *writer
+}
+
+// NewVerifier returns a Verifier for data committed to in the context
+// identified by label, with the given opening.
+func NewVerifier(alg Algorithm, label string, opening Opening) (*Verifier, error) {
+	w, err := newWriter(alg, label, &opening)
+	if err != nil {
+		return nil, err
+	}
+	return &Verifier{w: w}, nil
+}
+
+// Write adds p to the data being verified. It never returns an error.
+func (v *Verifier) Write(p []byte) (int, error) {
+	return v.w.Write(p)
+}
+
+// Verify returns nil if the data written so far opens c, and ErrMismatch
+// otherwise. The Verifier must not be used afterwards.
+func (v *Verifier) Verify(c Commitment) error {
+	sum := v.w.sum()
+	if subtle.ConstantTimeCompare(sum[:], c[:]) != 1 {
+		return ErrMismatch
+	}
+	return nil
+}
+
+// Commit reads r until EOF and returns a commitment to its contents in the
+// context identified by label, and the opening of the commitment. If rand is
+// nil, crypto/rand.Reader is used.
+func Commit(alg Algorithm, rand io.Reader, label string, r io.Reader) (Commitment, Opening, error) {
+	c, err := NewCommitter(alg, rand, label)
+	if err != nil {
+		return Commitment{}, Opening{}, err
+	}
+	if _, err := io.Copy(c, r); err != nil {
+		return Commitment{}, Opening{}, err
+	}
+	commitment, opening := c.Commit()
+	return commitment, opening, nil
+}
+
+// Verify reads r until EOF and returns nil if its contents open c in the
+// context identified by label, or ErrMismatch if they do not.
+func Verify(alg Algorithm, label string, c Commitment, opening Opening, r io.Reader) error {
+	v, err := NewVerifier(alg, label, opening)
+	if err != nil {
+		return err
+	}
+	if _, err := io.Copy(v, r); err != nil {
+		return err
+	}
+	return v.Verify(c)
+}
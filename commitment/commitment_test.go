@@ -0,0 +1,118 @@
+// Copyright 2018 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package commitment
+
+import (
+	"bytes"
+	"encoding/binary"
+	"strings"
+	"testing"
+
+	"golang.org/x/crypto/sha3"
+)
+
+func TestCommitVerify(t *testing.T) {
+	data := []byte("the quick brown fox")
+	for _, alg := range []Algorithm{SHA3_256, BLAKE2b_256} {
+		c, o, err := Commit(alg, nil, "test", bytes.NewReader(data))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if err := Verify(alg, "test", c, o, bytes.NewReader(data)); err != nil {
+			t.Errorf("alg %d: %v", alg, err)
+		}
+
+		for _, tt := range []struct {
+			name  string
+			alg   Algorithm
+			label string
+			data  []byte
+			o     Opening
+		}{
+			{"other label", alg, "other", data, o},
+			{"extended data", alg, "test", append(append([]byte{}, data...), 0), o},
+			{"truncated data", alg, "test", data[1:], o},
+			{"other opening", alg, "test", data, Opening{}},
+			{"other algorithm", 3 - alg, "test", data, o},
+		} {
+			if err := Verify(tt.alg, tt.label, c, tt.o, bytes.NewReader(tt.data)); err != ErrMismatch {
+				t.Errorf("alg %d, %s: got %v, want %v", alg, tt.name, err, ErrMismatch)
+			}
+		}
+	}
+}
+
+// TestUnambiguous checks that moving bytes between the label and the data
+// changes the commitment.
+func TestUnambiguous(t *testing.T) {
+	var o Opening
+	commit := func(label, data string) Commitment {
+		c, err := NewCommitter(BLAKE2b_256, bytes.NewReader(o[:]), label)
+		if err != nil {
+			t.Fatal(err)
+		}
+		c.Write([]byte(data))
+		commitment, _ := c.Commit()
+		return commitment
+	}
+	if commit("ab", "c") == commit("a", "bc") {
+		t.Error("label and data boundaries are ambiguous")
+	}
+}
+
+func TestStreaming(t *testing.T) {
+	c, err := NewCommitter(SHA3_256, nil, "stream")
+	if err != nil {
+		t.Fatal(err)
+	}
+	for i := 0; i < 100; i++ {
+		c.Write([]byte(strings.Repeat("x", i)))
+	}
+	commitment, opening := c.Commit()
+
+	v, err := NewVerifier(SHA3_256, "stream", opening)
+	if err != nil {
+		t.Fatal(err)
+	}
+	v.Write([]byte(strings.Repeat("x", 99*100/2)))
+	if err := v.Verify(commitment); err != nil {
+		t.Error(err)
+	}
+}
+
+func TestUnknownAlgorithm(t *testing.T) {
+	if _, _, err := Commit(0, nil, "", bytes.NewReader(nil)); err != errUnknownAlgorithm {
+		t.Errorf("got %v, want %v", err, errUnknownAlgorithm)
+	}
+}
+
+// TestEncoding checks the commitment against a hash of its encoding, built
+// by hand, with the prefix zero padded to the 136-byte block of SHA3-256.
+func TestEncoding(t *testing.T) {
+	opening := bytes.Repeat([]byte{7}, Size)
+	label, data := "test", []byte("the quick brown fox")
+	c, o, err := Commit(SHA3_256, bytes.NewReader(opening), label, bytes.NewReader(data))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(o[:], opening) {
+		t.Fatalf("opening = %x, want %x", o, opening)
+	}
+
+	var enc []byte
+	enc = append(enc, domain...)
+	enc = append(enc, byte(SHA3_256))
+	enc = append(enc, 0, 0, 0, 0, 0, 0, 0, byte(len(label)))
+	enc = append(enc, label...)
+	enc = append(enc, opening...)
+	enc = append(enc, make([]byte, 136-len(enc))...)
+	enc = append(enc, data...)
+	var n [8]byte
+	binary.BigEndian.PutUint64(n[:], uint64(len(data)))
+	enc = append(enc, n[:]...)
+	if want := Commitment(sha3.Sum256(enc)); c != want {
+		t.Errorf("commitment = %x, want %x", c, want)
+	}
+}
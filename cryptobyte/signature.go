@@ -0,0 +1,115 @@
+// Copyright 2018 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package cryptobyte
+
+import (
+	"crypto/elliptic"
+	"errors"
+	"math/big"
+
+	"golang.org/x/crypto/cryptobyte/asn1"
+)
+
+// This file contains methods for String and Builder that convert signatures
+// between the fixed-size encodings produced by signing APIs and the ASN.1
+// encodings used by X.509 and TLS.
+
+// ecdsaScalarSize returns the size, in bytes, of r and s in fixed-size
+// encodings of ECDSA signatures over curve.
+func ecdsaScalarSize(curve elliptic.Curve) int {
+	return (curve.Params().N.BitLen() + 7) / 8
+}
+
+// inECDSARange reports whether 0 < n < N, where N is the order of curve.
+func inECDSARange(n *big.Int, curve elliptic.Curve) bool {
+	return n.Sign() > 0 && n.Cmp(curve.Params().N) < 0
+}
+
+// AddASN1ECDSASignature appends the DER encoding of an ECDSA signature, the
+// Ecdsa-Sig-Value SEQUENCE of RFC 3279, Section 2.2.3, given its fixed-size
+// encoding raw: r followed by s, each big-endian and as long as the order of
+// curve, as used by IEEE P1363, JWS and PKCS #11. It is an error if raw does
+// not have that length, or if r or s is not between 1 and the order minus 1.
+func (b *Builder) AddASN1ECDSASignature(raw []byte, curve elliptic.Curve) {
+	if b.err != nil {
+		return
+	}
+	size := ecdsaScalarSize(curve)
+	if len(raw) != 2*size {
+		b.err = errors.New("cryptobyte: ECDSA signature has the wrong length for the curve")
+		return
+	}
+	r := new(big.Int).SetBytes(raw[:size])
+	s := new(big.Int).SetBytes(raw[size:])
+	if !inECDSARange(r, curve) || !inECDSARange(s, curve) {
+		b.err = errors.New("cryptobyte: ECDSA signature value out of range")
+		return
+	}
+	b.AddASN1(asn1.SEQUENCE, func(c *Builder) {
+		c.AddASN1BigInt(r)
+		c.AddASN1BigInt(s)
+	})
+}
+
+// ReadASN1ECDSASignature decodes a DER-encoded ECDSA signature, an
+// Ecdsa-Sig-Value SEQUENCE, into out, in the fixed-size encoding accepted by
+// AddASN1ECDSASignature, and advances. It rejects encodings that are not
+// strict DER, and values of r or s that are not between 1 and the order of
+// curve minus 1. It reports whether the read was successful.
+func (s *String) ReadASN1ECDSASignature(out *[]byte, curve elliptic.Curve) bool {
+	var seq String
+	r, sv := new(big.Int), new(big.Int)
+	if !s.ReadASN1(&seq, asn1.SEQUENCE) ||
+		!seq.readASN1BigInt(r) ||
+		!seq.readASN1BigInt(sv) ||
+		!seq.Empty() {
+		return false
+	}
+	if !inECDSARange(r, curve) || !inECDSARange(sv, curve) {
+		return false
+	}
+	size := ecdsaScalarSize(curve)
+	raw := make([]byte, 2*size)
+	rBytes, sBytes := r.Bytes(), sv.Bytes()
+	copy(raw[size-len(rBytes):size], rBytes)
+	copy(raw[2*size-len(sBytes):], sBytes)
+	*out = raw
+	return true
+}
+
+// isEdDSASignatureSize reports whether n is the size of Ed25519 or Ed448
+// signatures, as defined by RFC 8032.
+func isEdDSASignatureSize(n int) bool {
+	return n == 64 || n == 114
+}
+
+// AddASN1EdDSASignature appends an Ed25519 or Ed448 signature, as defined by
+// RFC 8032, as a DER-encoded BIT STRING, which is how X.509 certificates and
+// CRLs carry them, see RFC 8410, Section 6. EdDSA signatures have no ASN.1
+// structure of their own. It is an error if sig is neither 64 nor 114 bytes
+// long.
+func (b *Builder) AddASN1EdDSASignature(sig []byte) {
+	if b.err != nil {
+		return
+	}
+	if !isEdDSASignatureSize(len(sig)) {
+		b.err = errors.New("cryptobyte: EdDSA signature has the wrong length")
+		return
+	}
+	b.AddASN1BitString(sig)
+}
+
+// ReadASN1EdDSASignature decodes an Ed25519 or Ed448 signature from a
+// DER-encoded BIT STRING, as written by AddASN1EdDSASignature, into out and
+// advances. The BIT STRING must be a whole number of bytes, 64 or 114 of them.
+// It reports whether the read was successful.
+func (s *String) ReadASN1EdDSASignature(out *[]byte) bool {
+	var sig []byte
+	if !s.ReadASN1BitStringAsBytes(&sig) || !isEdDSASignatureSize(len(sig)) {
+		return false
+	}
+	*out = sig
+	return true
+}
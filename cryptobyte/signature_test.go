@@ -0,0 +1,138 @@
+// Copyright 2018 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package cryptobyte
+
+import (
+	"bytes"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	encoding_asn1 "encoding/asn1"
+	"math/big"
+	"testing"
+)
+
+func TestECDSASignature(t *testing.T) {
+	for _, curve := range []elliptic.Curve{elliptic.P224(), elliptic.P256(), elliptic.P384(), elliptic.P521()} {
+		name := curve.Params().Name
+		priv, err := ecdsa.GenerateKey(curve, rand.Reader)
+		if err != nil {
+			t.Fatal(err)
+		}
+		for i := 0; i < 8; i++ {
+			r, s, err := ecdsa.Sign(rand.Reader, priv, []byte("digest"))
+			if err != nil {
+				t.Fatal(err)
+			}
+			der, err := encoding_asn1.Marshal(struct{ R, S *big.Int }{r, s})
+			if err != nil {
+				t.Fatal(err)
+			}
+			size := ecdsaScalarSize(curve)
+			raw := make([]byte, 2*size)
+			copy(raw[size-len(r.Bytes()):size], r.Bytes())
+			copy(raw[2*size-len(s.Bytes()):], s.Bytes())
+
+			var got []byte
+			in := String(der)
+			if !in.ReadASN1ECDSASignature(&got, curve) || !in.Empty() {
+				t.Fatalf("%s: ReadASN1ECDSASignature failed on %x", name, der)
+			}
+			if !bytes.Equal(got, raw) {
+				t.Errorf("%s: ReadASN1ECDSASignature = %x, want %x", name, got, raw)
+			}
+
+			var b Builder
+			b.AddASN1ECDSASignature(raw, curve)
+			if out, err := b.Bytes(); err != nil || !bytes.Equal(out, der) {
+				t.Errorf("%s: AddASN1ECDSASignature = %x, %v, want %x", name, out, err, der)
+			}
+		}
+	}
+}
+
+func TestECDSASignatureInvalid(t *testing.T) {
+	curve := elliptic.P256()
+	n := curve.Params().N
+	marshal := func(r, s *big.Int) []byte {
+		var b Builder
+		b.AddASN1(0x30, func(c *Builder) {
+			c.AddASN1BigInt(r)
+			c.AddASN1BigInt(s)
+		})
+		return b.BytesOrPanic()
+	}
+	one := big.NewInt(1)
+	nMinusOne := new(big.Int).Sub(n, one)
+
+	var out []byte
+	valid := marshal(one, nMinusOne)
+	if in := String(valid); !in.ReadASN1ECDSASignature(&out, curve) {
+		t.Errorf("r = 1, s = N-1 rejected")
+	}
+	for name, der := range map[string][]byte{
+		"zero r":        marshal(new(big.Int), one),
+		"zero s":        marshal(one, new(big.Int)),
+		"negative r":    {0x30, 0x06, 0x02, 0x01, 0xff, 0x02, 0x01, 0x01},
+		"s equal to N":  marshal(one, n),
+		"r too large":   marshal(new(big.Int).Lsh(one, 300), one),
+		"non-minimal r": {0x30, 0x07, 0x02, 0x02, 0x00, 0x01, 0x02, 0x01, 0x01},
+		"trailing data": {0x30, 0x08, 0x02, 0x01, 0x01, 0x02, 0x01, 0x01, 0x05, 0x00},
+		"missing s":     {0x30, 0x03, 0x02, 0x01, 0x01},
+		"wrong tag":     {0x31, 0x06, 0x02, 0x01, 0x01, 0x02, 0x01, 0x01},
+	} {
+		in := String(der)
+		if in.ReadASN1ECDSASignature(&out, curve) {
+			t.Errorf("%s: %x accepted", name, der)
+		}
+	}
+
+	size := ecdsaScalarSize(curve)
+	for name, raw := range map[string][]byte{
+		"short":  make([]byte, 2*size-1),
+		"zero r": append(make([]byte, size), nMinusOne.Bytes()...),
+		"s is N": append(append(make([]byte, size-1), 1), n.Bytes()...),
+	} {
+		var b Builder
+		b.AddASN1ECDSASignature(raw, curve)
+		if _, err := b.Bytes(); err == nil {
+			t.Errorf("%s: AddASN1ECDSASignature accepted %x", name, raw)
+		}
+	}
+}
+
+func TestEdDSASignature(t *testing.T) {
+	for _, size := range []int{64, 114} {
+		sig := bytes.Repeat([]byte{0xa5}, size)
+		var b Builder
+		b.AddASN1EdDSASignature(sig)
+		der, err := b.Bytes()
+		if err != nil {
+			t.Fatal(err)
+		}
+		var got []byte
+		in := String(der)
+		if !in.ReadASN1EdDSASignature(&got) || !in.Empty() || !bytes.Equal(got, sig) {
+			t.Errorf("%d bytes: round trip = %x, want %x", size, got, sig)
+		}
+	}
+
+	var b Builder
+	b.AddASN1EdDSASignature(make([]byte, 63))
+	if _, err := b.Bytes(); err == nil {
+		t.Errorf("AddASN1EdDSASignature accepted a 63-byte signature")
+	}
+	var got []byte
+	for name, der := range map[string][]byte{
+		"short":        append([]byte{0x03, 64, 0}, make([]byte, 63)...),
+		"padding bits": append([]byte{0x03, 65, 1}, make([]byte, 64)...),
+		"octet string": append([]byte{0x04, 64}, make([]byte, 64)...),
+	} {
+		in := String(der)
+		if in.ReadASN1EdDSASignature(&got) {
+			t.Errorf("%s: %x accepted", name, der)
+		}
+	}
+}
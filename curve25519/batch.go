@@ -0,0 +1,79 @@
+// Copyright 2018 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package curve25519
+
+import (
+	"crypto/subtle"
+	"errors"
+	"runtime"
+	"strconv"
+	"sync"
+)
+
+// X25519Batch returns the X25519 function of RFC 7748 applied to each pair
+// of scalars[i] and points[i], the shared secrets of as many independent
+// Diffie-Hellman key exchanges, as ScalarMult would compute them.
+//
+// It is faster than separate calls, as the results are converted to x
+// coordinates with a single field inversion for the whole batch, and as
+// large batches are split across up to GOMAXPROCS goroutines. It is meant
+// for servers completing many handshakes at once.
+//
+// As recommended by RFC 7748, Section 6.1, an error is returned if any of
+// the shared secrets is all zeros, which happens when the peer sent a point
+// of small order. The other results are still valid. It panics if scalars
+// and points have different lengths.
+func X25519Batch(scalars, points [][32]byte) ([][32]byte, error) {
+	if len(scalars) != len(points) {
+		panic("curve25519: mismatched batch lengths")
+	}
+	out := make([][32]byte, len(scalars))
+	scalarMultBatch(out, scalars, points)
+
+	var zero [32]byte
+	for i := range out {
+		if subtle.ConstantTimeCompare(out[i][:], zero[:]) == 1 {
+			return out, errors.New("curve25519: low order point at index " + strconv.Itoa(i))
+		}
+	}
+	return out, nil
+}
+
+// minLaddersPerWorker is the smallest number of ladders worth starting a
+// goroutine for.
+const minLaddersPerWorker = 8
+
+// forEachParallel calls f(i) for each i in [0, n), splitting the calls
+// across goroutines when n is large enough. f must be safe to call
+// concurrently for different i.
+func forEachParallel(n int, f func(i int)) {
+	workers := runtime.GOMAXPROCS(0)
+	if max := n / minLaddersPerWorker; workers > max {
+		workers = max
+	}
+	if workers <= 1 {
+		for i := 0; i < n; i++ {
+			f(i)
+		}
+		return
+	}
+
+	var wg sync.WaitGroup
+	chunk := (n + workers - 1) / workers
+	for start := 0; start < n; start += chunk {
+		end := start + chunk
+		if end > n {
+			end = n
+		}
+		wg.Add(1)
+		go func(start, end int) {
+			defer wg.Done()
+			for i := start; i < end; i++ {
+				f(i)
+			}
+		}(start, end)
+	}
+	wg.Wait()
+}
@@ -9,6 +9,7 @@
 package curve25519
 
 import (
+	"crypto/subtle"
 	"encoding/binary"
 )
 
@@ -783,6 +784,67 @@ func feInvert(out, z *fieldElement) {
 }
 
 func scalarMult(out, in, base *[32]byte) {
+	var x, z fieldElement
+	ladder(&x, &z, in, base)
+	feInvert(&z, &z)
+	feMul(&x, &x, &z)
+	feToBytes(out, &x)
+}
+
+// scalarMultBatch sets out[i] = in[i]*bases[i], with a single field
+// inversion. If bases has a single element, it is used for every in[i].
+func scalarMultBatch(out, in, bases [][32]byte) {
+	if len(in) == 0 {
+		return
+	}
+	x := make([]fieldElement, len(in))
+	z := make([]fieldElement, len(in))
+	forEachParallel(len(in), func(i int) {
+		base := &bases[0]
+		if len(bases) > 1 {
+			base = &bases[i]
+		}
+		ladder(&x[i], &z[i], &in[i], base)
+	})
+
+	// A base of small order can give z = 0, for which scalarMult returns
+	// zero, but which would zero the whole product below. Such z are
+	// replaced by 1, and their x by 0, in constant time.
+	var zero [32]byte
+	for i := range z {
+		var zb [32]byte
+		feToBytes(&zb, &z[i])
+		mask := -int32(subtle.ConstantTimeCompare(zb[:], zero[:]))
+		for j := range z[i] {
+			z[i][j] &^= mask
+			x[i][j] &^= mask
+		}
+		z[i][0] |= mask & 1
+	}
+
+	// Montgomery's trick: acc[i] = z[0] * ... * z[i], then walk back from the
+	// inverse of the product.
+	acc := make([]fieldElement, len(in))
+	feCopy(&acc[0], &z[0])
+	for i := 1; i < len(in); i++ {
+		feMul(&acc[i], &acc[i-1], &z[i])
+	}
+	var inv, zInv fieldElement
+	feInvert(&inv, &acc[len(in)-1])
+	for i := len(in) - 1; i >= 0; i-- {
+		if i > 0 {
+			feMul(&zInv, &inv, &acc[i-1])
+			feMul(&inv, &inv, &z[i])
+		} else {
+			feCopy(&zInv, &inv)
+		}
+		feMul(&x[i], &x[i], &zInv)
+		feToBytes(&out[i], &x[i])
+	}
+}
+
+// ladder sets x/z to the x coordinate of in*base, with in clamped.
+func ladder(x, z *fieldElement, in, base *[32]byte) {
 	var e [32]byte
 
 	copy(e[:], in[:])
@@ -828,7 +890,6 @@ func scalarMult(out, in, base *[32]byte) {
 	feCSwap(&x2, &x3, swap)
 	feCSwap(&z2, &z3, swap)
 
-	feInvert(&z2, &z2)
-	feMul(&x2, &x2, &z2)
-	feToBytes(out, &x2)
+	feCopy(x, &x2)
+	feCopy(z, &z2)
 }
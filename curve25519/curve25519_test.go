@@ -37,3 +37,94 @@ func BenchmarkScalarBaseMult(b *testing.B) {
 		ScalarBaseMult(&out, &in)
 	}
 }
+
+func TestScalarBaseMultBatch(t *testing.T) {
+	in := make([][32]byte, 33)
+	for i := range in {
+		for j := range in[i] {
+			in[i][j] = byte(i*31 + j*7)
+		}
+	}
+	out := make([][32]byte, len(in))
+	ScalarBaseMultBatch(out, in)
+	for i := range in {
+		var want [32]byte
+		ScalarBaseMult(&want, &in[i])
+		if out[i] != want {
+			t.Errorf("scalar %d: got %x, want %x", i, out[i], want)
+		}
+	}
+	ScalarBaseMultBatch(nil, nil)
+}
+
+func BenchmarkScalarBaseMultBatch(b *testing.B) {
+	in := make([][32]byte, 1000)
+	out := make([][32]byte, len(in))
+	for i := range in {
+		in[i][0] = byte(i)
+		in[i][1] = byte(i >> 8)
+	}
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		ScalarBaseMultBatch(out, in)
+	}
+}
+
+func TestX25519Batch(t *testing.T) {
+	for _, n := range []int{0, 1, 7, 100} {
+		scalars := make([][32]byte, n)
+		points := make([][32]byte, n)
+		for i := 0; i < n; i++ {
+			for j := range scalars[i] {
+				scalars[i][j] = byte(i*31 + j*7)
+				points[i][j] = byte(i*17 + j*3 + 1)
+			}
+			// Use valid public keys as the points.
+			var s [32]byte
+			copy(s[:], points[i][:])
+			ScalarBaseMult(&points[i], &s)
+		}
+		out, err := X25519Batch(scalars, points)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(out) != n {
+			t.Fatalf("got %d results, want %d", len(out), n)
+		}
+		for i := range out {
+			var want [32]byte
+			ScalarMult(&want, &scalars[i], &points[i])
+			if out[i] != want {
+				t.Errorf("batch of %d, pair %d: got %x, want %x", n, i, out[i], want)
+			}
+		}
+	}
+
+	// The point of order 1, whose shared secrets are all zeros.
+	scalars := make([][32]byte, 3)
+	points := [][32]byte{basePoint, {1}, basePoint}
+	scalars[0][0], scalars[1][0], scalars[2][0] = 1, 2, 3
+	out, err := X25519Batch(scalars, points)
+	if err == nil {
+		t.Error("low order point accepted")
+	}
+	var want [32]byte
+	ScalarBaseMult(&want, &scalars[2])
+	if out[2] != want {
+		t.Errorf("result after a low order point: got %x, want %x", out[2], want)
+	}
+}
+
+func BenchmarkX25519Batch(b *testing.B) {
+	scalars := make([][32]byte, 1000)
+	points := make([][32]byte, len(scalars))
+	for i := range scalars {
+		scalars[i][0] = byte(i)
+		scalars[i][1] = byte(i >> 8)
+		points[i] = basePoint
+	}
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		X25519Batch(scalars, points)
+	}
+}
@@ -21,3 +21,16 @@ func ScalarMult(dst, in, base *[32]byte) {
 func ScalarBaseMult(dst, in *[32]byte) {
 	ScalarMult(dst, in, &basePoint)
 }
+
+// ScalarBaseMultBatch sets dst[i] to the product in[i]*base, as
+// ScalarBaseMult would, for each i. It is faster than separate calls for
+// large batches, as the projective results of the Montgomery ladders are
+// converted to x coordinates with a single field inversion for the whole
+// batch, and as the ladders are split across up to GOMAXPROCS goroutines. It
+// panics if dst and in have different lengths.
+func ScalarBaseMultBatch(dst, in [][32]byte) {
+	if len(dst) != len(in) {
+		panic("curve25519: mismatched batch lengths")
+	}
+	scalarMultBatch(dst, in, [][32]byte{basePoint})
+}
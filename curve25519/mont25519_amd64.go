@@ -6,6 +6,8 @@
 
 package curve25519
 
+import "crypto/subtle"
+
 // These functions are implemented in the .s files. The names of the functions
 // in the rest of the file are also taken from the SUPERCOP sources to help
 // people following along.
@@ -60,18 +62,75 @@ func mladder(xr, zr *[5]uint64, s *[32]byte) {
 }
 
 func scalarMult(out, in, base *[32]byte) {
+	var t, z [5]uint64
+	ladder(&t, &z, in, base)
+	invert(&z, &z)
+	mul(&t, &t, &z)
+	pack(out, &t)
+}
+
+// scalarMultBatch sets out[i] = in[i]*bases[i], with a single field
+// inversion. If bases has a single element, it is used for every in[i].
+func scalarMultBatch(out, in, bases [][32]byte) {
+	if len(in) == 0 {
+		return
+	}
+	t := make([][5]uint64, len(in))
+	z := make([][5]uint64, len(in))
+	forEachParallel(len(in), func(i int) {
+		base := &bases[0]
+		if len(bases) > 1 {
+			base = &bases[i]
+		}
+		ladder(&t[i], &z[i], &in[i], base)
+	})
+
+	// A base of small order can give z = 0, for which scalarMult returns
+	// zero, but which would zero the whole product below. Such z are
+	// replaced by 1, and their t by 0, in constant time.
+	var zero [32]byte
+	for i := range z {
+		var zb [32]byte
+		pack(&zb, &z[i])
+		mask := -uint64(subtle.ConstantTimeCompare(zb[:], zero[:]))
+		for j := range z[i] {
+			z[i][j] &^= mask
+			t[i][j] &^= mask
+		}
+		z[i][0] |= mask & 1
+	}
+
+	// Montgomery's trick: acc[i] = z[0] * ... * z[i], then walk back from the
+	// inverse of the product.
+	acc := make([][5]uint64, len(in))
+	acc[0] = z[0]
+	for i := 1; i < len(in); i++ {
+		mul(&acc[i], &acc[i-1], &z[i])
+	}
+	var inv, zInv [5]uint64
+	invert(&inv, &acc[len(in)-1])
+	for i := len(in) - 1; i >= 0; i-- {
+		if i > 0 {
+			mul(&zInv, &inv, &acc[i-1])
+			mul(&inv, &inv, &z[i])
+		} else {
+			zInv = inv
+		}
+		mul(&t[i], &t[i], &zInv)
+		pack(&out[i], &t[i])
+	}
+}
+
+// ladder sets t/z to the x coordinate of in*base, with in clamped.
+func ladder(t, z *[5]uint64, in, base *[32]byte) {
 	var e [32]byte
 	copy(e[:], (*in)[:])
 	e[0] &= 248
 	e[31] &= 127
 	e[31] |= 64
 
-	var t, z [5]uint64
-	unpack(&t, base)
-	mladder(&t, &z, &e)
-	invert(&z, &z)
-	mul(&t, &t, &z)
-	pack(out, &t)
+	unpack(t, base)
+	mladder(t, z, &e)
 }
 
 func setint(r *[5]uint64, v uint64) {
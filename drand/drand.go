@@ -0,0 +1,161 @@
+// Copyright 2018 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+// Package drand verifies randomness published by drand networks, such as the
+// League of Entropy. See https://drand.love.
+//
+// A drand network periodically produces a beacon: a BLS signature over
+// BLS12-381 by the network's distributed key on a message derived from the
+// round number and, in chained mode, the previous signature. The signature
+// lives in G₂ and the public key in G₁. The randomness of a round is the
+// SHA-256 hash of its signature.
+//
+// Verification is slow, on the order of tens of milliseconds per beacon, and
+// should not be used on untrusted input at high rates.
+package drand // import "golang.org/x/crypto/drand"
+
+import (
+	"crypto/sha256"
+	"encoding/binary"
+	"errors"
+	"math/big"
+	"time"
+
+	"golang.org/x/crypto/internal/bls12381"
+)
+
+// Scheme identifies how the messages signed by a drand network are formed.
+// The values match the scheme IDs used by drand itself.
+type Scheme string
+
+const (
+	// Chained is the scheme where each round signs the previous round's
+	// signature along with its round number.
+	Chained Scheme = "pedersen-bls-chained"
+	// Unchained is the scheme where each round signs only its round number,
+	// so that future rounds can be targeted before earlier ones are
+	// published.
+	Unchained Scheme = "pedersen-bls-unchained"
+)
+
+// domainSeparationTag is the hash-to-curve tag used by drand for signatures
+// in G₂.
+const domainSeparationTag = "BLS_SIG_BLS12381G2_XMD:SHA-256_SSWU_RO_NUL_"
+
+var (
+	errInvalidPublicKey = errors.New("drand: invalid public key")
+	errInvalidPeriod    = errors.New("drand: period must be positive")
+	errUnknownScheme    = errors.New("drand: unknown scheme")
+	errInvalidSignature = errors.New("drand: invalid signature")
+	errMissingPrevious  = errors.New("drand: chained beacon is missing its previous signature")
+	errInvalidRound     = errors.New("drand: round 0 has no randomness")
+)
+
+// negGenerator is the negation of the generator of G₁.
+var negGenerator = func() *bls12381.G1 {
+	g := new(bls12381.G1).ScalarBaseMult(big.NewInt(1))
+	return g.Neg(g)
+}()
+
+// Chain holds the public parameters of a drand network.
+type Chain struct {
+	publicKey *bls12381.G1
+	genesis   time.Time
+	period    time.Duration
+	scheme    Scheme
+}
+
+// NewChain returns a Chain for the network with the given 48-byte compressed
+// public key, genesis time, round period and scheme, as published in the
+// network's chain info.
+func NewChain(publicKey []byte, genesis time.Time, period time.Duration, scheme Scheme) (*Chain, error) {
+	if scheme != Chained && scheme != Unchained {
+		return nil, errUnknownScheme
+	}
+	if period <= 0 {
+		return nil, errInvalidPeriod
+	}
+	pub, ok := new(bls12381.G1).Unmarshal(publicKey)
+	if !ok {
+		return nil, errInvalidPublicKey
+	}
+	return &Chain{
+		publicKey: pub,
+		genesis:   genesis,
+		period:    period,
+		scheme:    scheme,
+	}, nil
+}
+
+// Beacon is the output of a drand network for one round.
+type Beacon struct {
+	Round     uint64
+	Signature []byte
+	// PreviousSignature is the signature of round Round-1. It is only used,
+	// and required, in the chained scheme.
+	PreviousSignature []byte
+}
+
+// Randomness returns the random value of b, which is only meaningful once b
+// has been verified.
+func (b *Beacon) Randomness() []byte {
+	h := sha256.Sum256(b.Signature)
+	return h[:]
+}
+
+// Message returns the message signed for the given round. previousSignature
+// is ignored by the unchained scheme.
+func (c *Chain) Message(round uint64, previousSignature []byte) []byte {
+	var roundBytes [8]byte
+	binary.BigEndian.PutUint64(roundBytes[:], round)
+
+	h := sha256.New()
+	if c.scheme == Chained {
+		h.Write(previousSignature)
+	}
+	h.Write(roundBytes[:])
+	return h.Sum(nil)
+}
+
+// Verify checks that b carries a valid signature by the network. It returns
+// nil if, and only if, the beacon is authentic.
+func (c *Chain) Verify(b *Beacon) error {
+	if b.Round == 0 {
+		return errInvalidRound
+	}
+	if c.scheme == Chained && len(b.PreviousSignature) == 0 {
+		return errMissingPrevious
+	}
+
+	sig, ok := new(bls12381.G2).Unmarshal(b.Signature)
+	if !ok {
+		return errInvalidSignature
+	}
+	msg := bls12381.HashToG2(c.Message(b.Round, b.PreviousSignature), []byte(domainSeparationTag))
+
+	// Check e(pk, H(m)) == e(g₁, sig) as e(pk, H(m)) · e(-g₁, sig) == 1.
+	if !bls12381.PairingCheck([]*bls12381.G1{c.publicKey, negGenerator}, []*bls12381.G2{msg, sig}) {
+		return errInvalidSignature
+	}
+	return nil
+}
+
+// TimeOfRound returns the time at which round is scheduled to be produced.
+// Round 1 is produced at the genesis time, and round 0 is considered to be
+// the genesis itself.
+func (c *Chain) TimeOfRound(round uint64) time.Time {
+	if round == 0 {
+		return c.genesis
+	}
+	return c.genesis.Add(time.Duration(round-1) * c.period)
+}
+
+// RoundAt returns the latest round scheduled to be produced at or before t, or
+// zero if t is before the genesis time.
+func (c *Chain) RoundAt(t time.Time) uint64 {
+	if t.Before(c.genesis) {
+		return 0
+	}
+	return uint64(t.Sub(c.genesis)/c.period) + 1
+}
@@ -0,0 +1,200 @@
+// Copyright 2018 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package drand
+
+import (
+	"encoding/hex"
+	"testing"
+	"time"
+)
+
+func fromHex(s string) []byte {
+	b, err := hex.DecodeString(s)
+	if err != nil {
+		panic(err)
+	}
+	return b
+}
+
+// The test vectors below were produced with an independent BLS12-381
+// implementation, using a fixed secret key.
+var testPublicKey = fromHex("af9c1a7dbad106add38b5406817a4ca3f93558c9d70a861d13b36ee0ef6ec6a912a049b0f55cb880bc184b612717d31f")
+
+var chainedBeacons = []Beacon{
+	{
+		Round:             1,
+		Signature:         fromHex("b95d5fc5dc027654a48114e72fc4b22a45adb17be94e87b6dcce38ffea68219f38bc5f5d45ab1991cd55b2b2a60878ee10edf5d7faf81fb4952c7fba49725201bf6b9aaecdd3e2867a7d982bce4ae627eff28c75e54a32c9f0fd8a1e8b4672d2"),
+		PreviousSignature: nil,
+	},
+	{
+		Round:             2,
+		Signature:         fromHex("89fd3ea1a5f985cae0aaf976209ef589534052a383be9eb15e08ed29549480e21408d37aaf68f2507a56c7d0e61fe2180ddbf7e4eeff1c48934e90ea54059f151683dfad98044838c8c75fb149653b3851d659f6a72ad6d9a6b54f5f367d07e5"),
+		PreviousSignature: fromHex("b95d5fc5dc027654a48114e72fc4b22a45adb17be94e87b6dcce38ffea68219f38bc5f5d45ab1991cd55b2b2a60878ee10edf5d7faf81fb4952c7fba49725201bf6b9aaecdd3e2867a7d982bce4ae627eff28c75e54a32c9f0fd8a1e8b4672d2"),
+	},
+	{
+		Round:             3,
+		Signature:         fromHex("8d3664fb457bb09cdeddd5f9442e23f98eb72998af1ff8de7cd3e273527ccc016d60516b08900783a742bd85880224570ae6d7229d2f1093523f71968ffa355fee8dda94f3d15784c53f8dda04cb83581e46397b74e8de28a08442f2425570d9"),
+		PreviousSignature: fromHex("89fd3ea1a5f985cae0aaf976209ef589534052a383be9eb15e08ed29549480e21408d37aaf68f2507a56c7d0e61fe2180ddbf7e4eeff1c48934e90ea54059f151683dfad98044838c8c75fb149653b3851d659f6a72ad6d9a6b54f5f367d07e5"),
+	},
+}
+
+var unchainedBeacons = []Beacon{
+	{
+		Round:     2,
+		Signature: fromHex("8132ee506e829cce826cc623de6b33f0339f1e5df036fc80de758b072cab731974e5f0c8fb8fc686111ff1357fb07220095ed7e16d4a9d9792b7db60f2b98563f4c6e055857747e55cd2417055b331a7923dcafe73a0b76e12f0d9919d859a9f"),
+	},
+	{
+		Round:     3,
+		Signature: fromHex("996046b8471448015eec99001a4f2efcdee746d5b9fbbeb85a063ca0e62bcc9fe3a7e0ec29c674547de650a268a7c28005d635b5e1858faaeac7b0adca4e219269efb38e6cab9345a555e084a01dd2fc215951da9c5282e401918514f9204fa1"),
+	},
+}
+
+// mainnetPublicKey and mainnetRound1 are the public key and first beacon of
+// the League of Entropy mainnet chain in the chained scheme, whose chain hash
+// is 8990e7a9aaed2ffed73dbd7092123d6f289930540d7651336225dc172e51b2ce, as
+// served by https://api.drand.sh/info and https://api.drand.sh/public/1. The
+// previous signature of round 1 is the genesis seed of the chain.
+//
+// The unchained mainnet chains of drand put their signatures in G₁, which
+// this package does not support, so there is no mainnet vector for the
+// unchained scheme.
+var mainnetPublicKey = fromHex("868f005eb8e6e4ca0a47c8a77ceaa5309a47978a7c71bc5cce96366b5d7a569937c529eeda66c7293784a9402801af31")
+
+var mainnetRound1 = Beacon{
+	Round:             1,
+	Signature:         fromHex("8d61d9100567de44682506aea1a7a6fa6e5491cd27a0a0ed349ef6910ac5ac20ff7bc3e09d7c046566c9f7f3c6f3b10104990e7cb424998203d8f7de586fb7fa5f60045417a432684f85093b06ca91c769f0e7ca19268375e659c2a2352b4655"),
+	PreviousSignature: fromHex("176f93498eac9ca337150b46d21dd58673ea4e3581185f869672e59fa4cb390a"),
+}
+
+func TestVerifyMainnet(t *testing.T) {
+	c, err := NewChain(mainnetPublicKey, time.Unix(1595431050, 0), 30*time.Second, Chained)
+	if err != nil {
+		t.Fatal(err)
+	}
+	b := mainnetRound1
+	if err := c.Verify(&b); err != nil {
+		t.Fatal(err)
+	}
+	if got, want := hex.EncodeToString(b.Randomness()), "101297f1ca7dc44ef6088d94ad5fb7ba03455dc33d53ddb412bbc4564ed986ec"; got != want {
+		t.Errorf("randomness %s, want %s", got, want)
+	}
+
+	b.Round = 2
+	if err := c.Verify(&b); err == nil {
+		t.Error("accepted mainnet beacon with the wrong round")
+	}
+}
+
+func TestVerifyChained(t *testing.T) {
+	c, err := NewChain(testPublicKey, time.Unix(1595431050, 0), 30*time.Second, Chained)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// The first round of these vectors signs an empty previous signature,
+	// where a real network uses its genesis seed, and Verify refuses it.
+	for _, b := range chainedBeacons[1:] {
+		b := b
+		if err := c.Verify(&b); err != nil {
+			t.Errorf("round %d: %v", b.Round, err)
+		}
+	}
+
+	b := chainedBeacons[2]
+	b.PreviousSignature = chainedBeacons[0].Signature
+	if err := c.Verify(&b); err == nil {
+		t.Error("accepted beacon with the wrong previous signature")
+	}
+
+	b = chainedBeacons[2]
+	b.Round = 4
+	if err := c.Verify(&b); err == nil {
+		t.Error("accepted beacon with the wrong round")
+	}
+
+	b = chainedBeacons[2]
+	b.PreviousSignature = nil
+	if err := c.Verify(&b); err == nil {
+		t.Error("accepted chained beacon without previous signature")
+	}
+}
+
+func TestVerifyUnchained(t *testing.T) {
+	c, err := NewChain(testPublicKey, time.Unix(1595431050, 0), 3*time.Second, Unchained)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for _, b := range unchainedBeacons {
+		b := b
+		if err := c.Verify(&b); err != nil {
+			t.Errorf("round %d: %v", b.Round, err)
+		}
+	}
+
+	// Round 1 is the same message in both schemes.
+	b := chainedBeacons[0]
+	if err := c.Verify(&b); err != nil {
+		t.Errorf("round 1: %v", err)
+	}
+
+	b = unchainedBeacons[0]
+	b.Signature = unchainedBeacons[1].Signature
+	if err := c.Verify(&b); err == nil {
+		t.Error("accepted signature for a different round")
+	}
+
+	b = unchainedBeacons[0]
+	b.Signature = append([]byte{}, b.Signature...)
+	b.Signature[10] ^= 1
+	if err := c.Verify(&b); err == nil {
+		t.Error("accepted corrupted signature")
+	}
+}
+
+func TestNewChain(t *testing.T) {
+	if _, err := NewChain(testPublicKey[1:], time.Unix(0, 0), time.Second, Chained); err == nil {
+		t.Error("accepted truncated public key")
+	}
+	if _, err := NewChain(testPublicKey, time.Unix(0, 0), 0, Chained); err == nil {
+		t.Error("accepted zero period")
+	}
+	if _, err := NewChain(testPublicKey, time.Unix(0, 0), time.Second, "bls-unchained-on-g1"); err == nil {
+		t.Error("accepted unsupported scheme")
+	}
+}
+
+func TestRounds(t *testing.T) {
+	genesis := time.Unix(1595431050, 0)
+	c, err := NewChain(testPublicKey, genesis, 30*time.Second, Chained)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		t     time.Time
+		round uint64
+	}{
+		{genesis.Add(-time.Second), 0},
+		{genesis, 1},
+		{genesis.Add(29 * time.Second), 1},
+		{genesis.Add(30 * time.Second), 2},
+		{genesis.Add(time.Hour), 121},
+	}
+	for _, tt := range tests {
+		if got := c.RoundAt(tt.t); got != tt.round {
+			t.Errorf("RoundAt(genesis%+v) = %d, want %d", tt.t.Sub(genesis), got, tt.round)
+		}
+	}
+
+	if got := c.TimeOfRound(0); !got.Equal(genesis) {
+		t.Errorf("TimeOfRound(0) = %v, want %v", got, genesis)
+	}
+	for _, round := range []uint64{1, 2, 121, 1000000} {
+		if got := c.RoundAt(c.TimeOfRound(round)); got != round {
+			t.Errorf("RoundAt(TimeOfRound(%d)) = %d", round, got)
+		}
+	}
+}
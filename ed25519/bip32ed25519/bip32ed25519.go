@@ -0,0 +1,381 @@
+// Copyright 2018 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+// Package bip32ed25519 implements BIP32-Ed25519, the hierarchical derivation
+// of Ed25519 keys by Khovratovich and Law, in the variant used by Cardano.
+//
+// Unlike SLIP-0010, implemented by package slip10, BIP32-Ed25519 supports
+// non-hardened derivation: the children of a PublicKey can be derived without
+// its private key, so that a watch-only wallet can generate the addresses of
+// a spending wallet. To allow it, keys are not RFC 8032 seeds but extended
+// private keys, a secret scalar and a nonce prefix, which sign as
+// ed25519.ExpandedPrivateKey does. The two schemes derive unrelated keys from
+// the same seed and path, and their keys cannot be used with each other.
+//
+// Derivation follows the "V2" scheme of the Cardano implementations, which
+// encodes child indexes in little-endian and carries the addition of the
+// derived scalar across all of its bytes. Master keys are generated from
+// BIP-39 entropy as in the Icarus scheme of Cardano's CIP-3.
+//
+// See https://input-output-hk.github.io/adrestia/static/Ed25519_BIP.pdf and
+// https://github.com/cardano-foundation/CIPs/blob/master/CIP-0003/Icarus.md.
+package bip32ed25519 // import "golang.org/x/crypto/ed25519/bip32ed25519"
+
+import (
+	"crypto/hmac"
+	"crypto/sha512"
+	"encoding/binary"
+	"errors"
+	"strconv"
+	"strings"
+
+	"golang.org/x/crypto/ed25519"
+	"golang.org/x/crypto/ed25519/internal/edwards25519"
+	"golang.org/x/crypto/pbkdf2"
+)
+
+const (
+	// HardenedOffset is the index of the first hardened child, which can
+	// only be derived from a PrivateKey.
+	HardenedOffset = 1 << 31
+
+	// PrivateKeySize is the size, in bytes, of the encoding of a PrivateKey:
+	// the secret scalar, the nonce prefix and the chain code.
+	PrivateKeySize = 96
+	// PublicKeySize is the size, in bytes, of the encoding of a PublicKey:
+	// the Ed25519 public key and the chain code.
+	PublicKeySize = 64
+)
+
+var (
+	// ErrHardenedPublic is returned when deriving a hardened child from a
+	// PublicKey.
+	ErrHardenedPublic = errors.New("bip32ed25519: hardened child of a public key")
+
+	errInvalidChild = errors.New("bip32ed25519: invalid child key")
+)
+
+// A DerivationPath is a sequence of child indexes, starting from the master
+// key. Hardened indexes include HardenedOffset.
+type DerivationPath []uint32
+
+// ParseDerivationPath parses a path such as m/1852'/1815'/0'/0/0. It must
+// start with m, the master key, followed by indexes in decimal, without a
+// sign or leading zeros, below 2^31, and ending with ', h or H if hardened.
+func ParseDerivationPath(s string) (DerivationPath, error) {
+	parts := strings.Split(s, "/")
+	if parts[0] != "m" {
+		return nil, errors.New("bip32ed25519: derivation path " + strconv.Quote(s) + " does not start with m")
+	}
+	path := make(DerivationPath, 0, len(parts)-1)
+	for _, part := range parts[1:] {
+		digits, hardened := part, false
+		if n := len(part) - 1; n >= 0 && (part[n] == '\'' || part[n] == 'h' || part[n] == 'H') {
+			digits, hardened = part[:n], true
+		}
+		i, err := strconv.ParseUint(digits, 10, 32)
+		if err != nil || i >= HardenedOffset || len(digits) > 1 && digits[0] == '0' {
+			return nil, errors.New("bip32ed25519: invalid derivation path component " + strconv.Quote(part))
+		}
+		if hardened {
+			i += HardenedOffset
+		}
+		path = append(path, uint32(i))
+	}
+	return path, nil
+}
+
+// String returns the path in the form parsed by ParseDerivationPath, with
+// hardened indexes marked by '.
+func (p DerivationPath) String() string {
+	b := []byte("m")
+	for _, i := range p {
+		b = append(b, '/')
+		b = strconv.AppendUint(b, uint64(i&^HardenedOffset), 10)
+		if i >= HardenedOffset {
+			b = append(b, '\'')
+		}
+	}
+	return string(b)
+}
+
+// A PrivateKey is an extended private key: a secret scalar, a nonce prefix,
+// and the chain code from which its children are derived. All are secret.
+type PrivateKey struct {
+	kL, kR    [32]byte // the scalar, little-endian, and the nonce prefix
+	chainCode [32]byte
+	public    [32]byte
+}
+
+// newPrivateKey returns the PrivateKey of kL, kR and chainCode, or an error
+// if kL is a multiple of the group order.
+func newPrivateKey(kL, kR, chainCode []byte) (*PrivateKey, error) {
+	k := new(PrivateKey)
+	copy(k.kL[:], kL)
+	copy(k.kR[:], kR)
+	copy(k.chainCode[:], chainCode)
+	expanded, err := k.expand()
+	if err != nil {
+		return nil, err
+	}
+	copy(k.public[:], expanded.Public().(ed25519.PublicKey))
+	expanded.Zero()
+	return k, nil
+}
+
+// expand returns the ExpandedPrivateKey of the scalar and the nonce prefix
+// of k.
+func (k *PrivateKey) expand() (*ed25519.ExpandedPrivateKey, error) {
+	b := make([]byte, 0, ed25519.ExpandedPrivateKeySize)
+	b = append(b, k.kL[:]...)
+	b = append(b, k.kR[:]...)
+	defer zero(b)
+	return ed25519.NewExpandedPrivateKey(b)
+}
+
+// NewMasterKey returns the master key of the Icarus scheme for the given
+// BIP-39 entropy, not the seed derived from the mnemonic, and the optional
+// password, which may be nil.
+func NewMasterKey(entropy, password []byte) *PrivateKey {
+	b := pbkdf2.Key(password, entropy, 4096, PrivateKeySize, sha512.New)
+	defer zero(b)
+	b[0] &= 0xf8
+	b[31] &= 0x1f
+	b[31] |= 0x40
+	k, err := newPrivateKey(b[:32], b[32:64], b[64:])
+	if err != nil {
+		// The scalar is between 2^254 and 2^255, so it is never zero modulo
+		// the group order.
+		panic("bip32ed25519: " + err.Error())
+	}
+	return k
+}
+
+// NewPrivateKey decodes a PrivateKey from its PrivateKeySize-byte encoding,
+// the secret scalar, the nonce prefix and the chain code, as returned by
+// Bytes. The lowest three bits of the scalar must be zero, as they are for
+// all keys of the scheme.
+func NewPrivateKey(b []byte) (*PrivateKey, error) {
+	if len(b) != PrivateKeySize {
+		return nil, errors.New("bip32ed25519: bad private key length: " + strconv.Itoa(len(b)))
+	}
+	if b[0]&7 != 0 {
+		return nil, errors.New("bip32ed25519: private key scalar is not a multiple of 8")
+	}
+	return newPrivateKey(b[:32], b[32:64], b[64:])
+}
+
+// Bytes returns the PrivateKeySize-byte encoding of k.
+func (k *PrivateKey) Bytes() []byte {
+	b := make([]byte, 0, PrivateKeySize)
+	b = append(b, k.kL[:]...)
+	b = append(b, k.kR[:]...)
+	return append(b, k.chainCode[:]...)
+}
+
+// ExpandedPrivateKey returns the Ed25519 private key of k, which signs
+// messages for k.Public().Key().
+func (k *PrivateKey) ExpandedPrivateKey() *ed25519.ExpandedPrivateKey {
+	expanded, err := k.expand()
+	if err != nil {
+		// The scalar was checked by newPrivateKey.
+		panic("bip32ed25519: " + err.Error())
+	}
+	return expanded
+}
+
+// Public returns the PublicKey of k, from which the non-hardened children of
+// k can be derived.
+func (k *PrivateKey) Public() *PublicKey {
+	return &PublicKey{key: k.public, chainCode: k.chainCode}
+}
+
+// Child returns the child of k with the given index, which is hardened if it
+// is at least HardenedOffset. It returns an error in the negligibly unlikely
+// case that the child scalar is a multiple of the group order.
+func (k *PrivateKey) Child(index uint32) (*PrivateKey, error) {
+	var z, c [64]byte
+	if index >= HardenedOffset {
+		k.hmac(&z, 0x00, index)
+		k.hmac(&c, 0x01, index)
+	} else {
+		hmacPublic(&z, &k.chainCode, &k.public, 0x02, index)
+		hmacPublic(&c, &k.chainCode, &k.public, 0x03, index)
+	}
+
+	var kL, kR [32]byte
+	addMul8(&kL, &k.kL, z[:28])
+	var carry uint16
+	for i := range kR {
+		carry += uint16(k.kR[i]) + uint16(z[32+i])
+		kR[i] = byte(carry)
+		carry >>= 8
+	}
+	child, err := newPrivateKey(kL[:], kR[:], c[32:])
+	z, c, kL, kR = [64]byte{}, [64]byte{}, [32]byte{}, [32]byte{}
+	if err != nil {
+		return nil, errInvalidChild
+	}
+	return child, nil
+}
+
+// hmac sets out to HMAC-SHA512(chainCode, prefix || kL || kR || index).
+func (k *PrivateKey) hmac(out *[64]byte, prefix byte, index uint32) {
+	mac := hmac.New(sha512.New, k.chainCode[:])
+	mac.Write([]byte{prefix})
+	mac.Write(k.kL[:])
+	mac.Write(k.kR[:])
+	mac.Write(le32(index))
+	mac.Sum(out[:0])
+}
+
+// Derive returns the descendant of k at path, relative to k.
+func (k *PrivateKey) Derive(path DerivationPath) (*PrivateKey, error) {
+	child := *k
+	for _, i := range path {
+		next, err := child.Child(i)
+		child.Zero()
+		if err != nil {
+			return nil, err
+		}
+		child = *next
+		next.Zero()
+	}
+	return &child, nil
+}
+
+// Zero overwrites k with zeroes, with the same limits as
+// ed25519.SecretSeed.Zero. k must not be used afterwards.
+func (k *PrivateKey) Zero() {
+	*k = PrivateKey{}
+}
+
+// A PublicKey is an extended public key: an Ed25519 public key and the chain
+// code from which the non-hardened children of its PrivateKey are derived.
+// The chain code is not secret, but together with any non-hardened
+// descendant private key, it reveals the PrivateKey.
+type PublicKey struct {
+	key       [32]byte
+	chainCode [32]byte
+}
+
+// NewPublicKey decodes a PublicKey from its PublicKeySize-byte encoding, the
+// Ed25519 public key followed by the chain code, as returned by Bytes.
+func NewPublicKey(b []byte) (*PublicKey, error) {
+	if len(b) != PublicKeySize {
+		return nil, errors.New("bip32ed25519: bad public key length: " + strconv.Itoa(len(b)))
+	}
+	k := new(PublicKey)
+	copy(k.key[:], b[:32])
+	copy(k.chainCode[:], b[32:])
+	var A edwards25519.ExtendedGroupElement
+	if err := A.FromCanonicalBytes(&k.key, false); err != nil {
+		return nil, errors.New("bip32ed25519: invalid public key")
+	}
+	return k, nil
+}
+
+// Bytes returns the PublicKeySize-byte encoding of k.
+func (k *PublicKey) Bytes() []byte {
+	return append(append(make([]byte, 0, PublicKeySize), k.key[:]...), k.chainCode[:]...)
+}
+
+// Key returns the Ed25519 public key of k.
+func (k *PublicKey) Key() ed25519.PublicKey {
+	return append(ed25519.PublicKey(nil), k.key[:]...)
+}
+
+// ChainCode returns a copy of the chain code of k.
+func (k *PublicKey) ChainCode() []byte {
+	return append([]byte(nil), k.chainCode[:]...)
+}
+
+// Child returns the public key of the child of k's PrivateKey with the given
+// index, which must be below HardenedOffset. It returns an error in the
+// negligibly unlikely case that the child key is the identity.
+func (k *PublicKey) Child(index uint32) (*PublicKey, error) {
+	if index >= HardenedOffset {
+		return nil, ErrHardenedPublic
+	}
+	var z, c [64]byte
+	hmacPublic(&z, &k.chainCode, &k.key, 0x02, index)
+	hmacPublic(&c, &k.chainCode, &k.key, 0x03, index)
+
+	var zL8, zero [32]byte
+	addMul8(&zL8, &zero, z[:28])
+	var A, Z, sum edwards25519.ExtendedGroupElement
+	if !A.FromBytes(&k.key) {
+		return nil, errors.New("bip32ed25519: invalid public key")
+	}
+	edwards25519.GeScalarMultBase(&Z, &zL8)
+	var cached edwards25519.CachedGroupElement
+	var r edwards25519.CompletedGroupElement
+	Z.ToCached(&cached)
+	edwards25519.GeAdd(&r, &A, &cached)
+	r.ToExtended(&sum)
+
+	child := new(PublicKey)
+	sum.ToBytes(&child.key)
+	copy(child.chainCode[:], c[32:])
+	if child.key == identity {
+		return nil, errInvalidChild
+	}
+	return child, nil
+}
+
+// Derive returns the public key of the descendant of k's PrivateKey at path,
+// relative to k. All indexes of path must be below HardenedOffset.
+func (k *PublicKey) Derive(path DerivationPath) (*PublicKey, error) {
+	child := k
+	for _, i := range path {
+		var err error
+		if child, err = child.Child(i); err != nil {
+			return nil, err
+		}
+	}
+	return child, nil
+}
+
+// identity is the encoding of the identity point.
+var identity = [32]byte{1}
+
+// hmacPublic sets out to HMAC-SHA512(chainCode, prefix || key || index).
+func hmacPublic(out *[64]byte, chainCode, key *[32]byte, prefix byte, index uint32) {
+	mac := hmac.New(sha512.New, chainCode[:])
+	mac.Write([]byte{prefix})
+	mac.Write(key[:])
+	mac.Write(le32(index))
+	mac.Sum(out[:0])
+}
+
+// addMul8 sets out = x + 8*zL mod 2^256, where x and out are 32-byte and zL
+// is 28-byte little-endian integers.
+func addMul8(out, x *[32]byte, zL []byte) {
+	var carry uint16
+	var prev byte
+	for i := range out {
+		var z byte
+		if i < len(zL) {
+			z = zL[i]
+		}
+		// The i-th byte of 8*zL is made of the low five bits of z[i] and
+		// the high three bits of z[i-1].
+		carry += uint16(x[i]) + uint16(z<<3|prev>>5)
+		out[i] = byte(carry)
+		carry >>= 8
+		prev = z
+	}
+}
+
+func le32(i uint32) []byte {
+	var b [4]byte
+	binary.LittleEndian.PutUint32(b[:], i)
+	return b[:]
+}
+
+func zero(b []byte) {
+	for i := range b {
+		b[i] = 0
+	}
+}
@@ -0,0 +1,217 @@
+// Copyright 2018 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package bip32ed25519
+
+import (
+	"encoding/hex"
+	"math/big"
+	"math/rand"
+	"reflect"
+	"testing"
+
+	"golang.org/x/crypto/ed25519"
+)
+
+// These vectors were computed with a standalone Python 3 implementation of
+// the BIP32-Ed25519 paper and of CIP-3, written for these tests and using only
+// the hashlib and hmac modules of the standard library, not with a Cardano
+// wallet. Its master keys match the published vectors in cip3Vectors.
+var vectors = []struct {
+	entropy, password string
+	path              string
+	private, public   string
+}{
+	{
+		"00000000000000000000000000000000", "", "m",
+		"60ce7dbec3616e9fc17e0c32578b3f380337b1b61a1f3cb9651aee30670e6f53970419a23a2e4e4082d12bf78faa8645dfc882cee2ae7179e2b07fe88098abb2072310084784c7308182dbbdb1449b2706586f1ff5cbf13d15e9b6e78c15f067",
+		"37fdfdbe9ac856469f8d83c66c57880246cd8bf7f852bf5b94336fe535c0efc8",
+	},
+	{
+		"00000000000000000000000000000000", "", "m/1852'/1815'/0'/0/0",
+		"105d2ef2192150655a926bca9cccf5e2f6e496efa9580508192e1f4a790e6f53de06529129511d1cacb0664bcf04853fdc0055a47cc6d2c6d20512702076065288848e8af62a27a57e982215741c9eac17e6e45cbfd6ea65a0e0dcc03bb777b2",
+		"7ea09a34aebb13c9841c71397b1cabfec5ddf950405293dee496cac2f437480a",
+	},
+	{
+		"000102030405060708090a0b0c0d0e0f101112131415161718191a1b1c1d1e1f", "foo", "m",
+		"70a19f9be914d79ebf3dd9395683ad94860ec032e91251b798fb9b3ce1c50959739cf74e92607f912b9dd592b9025adb2ef2e0a1ba21a529d17b3bc856c34b1d5a05b5a1165c8c19a79410b0735acae1abe4719492a472109fe5c1a21f665f99",
+		"de7e163cd33aad7b3cbd82165c3d0ab96d562bfe24c2a58d5d7863cdc39c8d18",
+	},
+	{
+		"000102030405060708090a0b0c0d0e0f101112131415161718191a1b1c1d1e1f", "foo", "m/1852'/1815'/0'/0/0",
+		"b02b65228536b70607388917f0b0542c0f3ba536d990c8c149aa26f9f2c50959f59045c4a649d29e4f50926ebcec9e6fbb4094def723b588b8c99d9598a74076900248fc35efd1214861841ebf93ed691b383dbabfe72f4a2231d7d5ef269fe5",
+		"1ac9da34826fc0b2f3fb77b48c3e84657953b41e850df467f5bb7c8055c8ef14",
+	},
+}
+
+func decodeHex(t *testing.T, s string) []byte {
+	b, err := hex.DecodeString(s)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return b
+}
+
+func TestVectors(t *testing.T) {
+	for _, v := range vectors {
+		master := NewMasterKey(decodeHex(t, v.entropy), []byte(v.password))
+		path, err := ParseDerivationPath(v.path)
+		if err != nil {
+			t.Fatal(err)
+		}
+		k, err := master.Derive(path)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got := hex.EncodeToString(k.Bytes()); got != v.private {
+			t.Errorf("%s: private key %s, want %s", v.path, got, v.private)
+		}
+		if got := hex.EncodeToString(k.Public().Key()); got != v.public {
+			t.Errorf("%s: public key %s, want %s", v.path, got, v.public)
+		}
+
+		k2, err := NewPrivateKey(k.Bytes())
+		if err != nil {
+			t.Fatal(err)
+		}
+		if *k2 != *k {
+			t.Errorf("%s: NewPrivateKey(Bytes()) differs", v.path)
+		}
+	}
+}
+
+// cip3Vectors are the Icarus master key test vectors published in
+// https://github.com/cardano-foundation/CIPs/blob/master/CIP-0003/Icarus.md.
+var cip3Vectors = []struct {
+	entropy, password string
+	master            string
+}{
+	{
+		"46e62370a138a182a498b8e2885bc032379ddf38", "",
+		"c065afd2832cd8b087c4d9ab7011f481ee1e0721e78ea5dd609f3ab3f156d245d176bd8fd4ec60b4731c3918a2a72a0226c0cd119ec35b47e4d55884667f552a23f7fdcd4a10c6cd2c7393ac61d877873e248f417634aa3d812af327ffe9d620",
+	},
+	{
+		"46e62370a138a182a498b8e2885bc032379ddf38", "foo",
+		"70531039904019351e1afb361cd1b312a4d0565d4ff9f8062d38acf4b15cce41d7b5738d9c893feea55512a3004acb0d222c35d3e3d5cde943a15a9824cbac59443cf67e589614076ba01e354b1a432e0e6db3b59e37fc56b5fb0222970a010e",
+	},
+}
+
+func TestCIP3Vectors(t *testing.T) {
+	for _, v := range cip3Vectors {
+		master := NewMasterKey(decodeHex(t, v.entropy), []byte(v.password))
+		if got := hex.EncodeToString(master.Bytes()); got != v.master {
+			t.Errorf("password %q: master key %s, want %s", v.password, got, v.master)
+		}
+	}
+}
+
+// TestPublicDerivation checks that the public keys of non-hardened children
+// are the same whether they are derived from the private or the public key.
+func TestPublicDerivation(t *testing.T) {
+	master := NewMasterKey(make([]byte, 16), nil)
+	account, err := master.Derive(DerivationPath{1852 + HardenedOffset, 1815 + HardenedOffset, HardenedOffset})
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, path := range []DerivationPath{{0, 0}, {0, 1}, {1, 0}, {0, HardenedOffset - 1}, {5, 4, 3, 2, 1}} {
+		priv, err := account.Derive(path)
+		if err != nil {
+			t.Fatal(err)
+		}
+		pub, err := account.Public().Derive(path)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if *pub != *priv.Public() {
+			t.Errorf("%v: public derivation differs from private derivation", path)
+		}
+
+		message := []byte("hello")
+		sig := priv.ExpandedPrivateKey().AppendSign(nil, message)
+		if !ed25519.Verify(pub.Key(), message, sig) {
+			t.Errorf("%v: signature does not verify", path)
+		}
+	}
+
+	if _, err := account.Public().Child(HardenedOffset); err != ErrHardenedPublic {
+		t.Errorf("hardened public child: got error %v, want ErrHardenedPublic", err)
+	}
+	if _, err := account.Public().Derive(DerivationPath{0, HardenedOffset}); err != ErrHardenedPublic {
+		t.Errorf("hardened public path: got error %v, want ErrHardenedPublic", err)
+	}
+}
+
+func TestAddMul8(t *testing.T) {
+	rng := rand.New(rand.NewSource(1))
+	mod := new(big.Int).Lsh(big.NewInt(1), 256)
+	for i := 0; i < 1000; i++ {
+		var x, out [32]byte
+		zL := make([]byte, 28)
+		rng.Read(x[:])
+		rng.Read(zL)
+		addMul8(&out, &x, zL)
+
+		want := new(big.Int).Lsh(new(big.Int).SetBytes(reverse(zL)), 3)
+		want.Add(want, new(big.Int).SetBytes(reverse(x[:]))).Mod(want, mod)
+		if got := new(big.Int).SetBytes(reverse(out[:])); got.Cmp(want) != 0 {
+			t.Fatalf("addMul8(%x, %x) = %x, want %x", x, zL, got, want)
+		}
+	}
+}
+
+func reverse(b []byte) []byte {
+	out := make([]byte, len(b))
+	for i := range b {
+		out[len(b)-1-i] = b[i]
+	}
+	return out
+}
+
+func TestNewKeys(t *testing.T) {
+	k := NewMasterKey(make([]byte, 16), nil)
+	b := k.Bytes()
+	b[0] |= 1
+	if _, err := NewPrivateKey(b); err == nil {
+		t.Error("scalar that is not a multiple of 8 accepted")
+	}
+	if _, err := NewPrivateKey(b[:64]); err == nil {
+		t.Error("short private key accepted")
+	}
+	if _, err := NewPrivateKey(make([]byte, PrivateKeySize)); err == nil {
+		t.Error("zero scalar accepted")
+	}
+
+	pub, err := NewPublicKey(k.Public().Bytes())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if *pub != *k.Public() {
+		t.Error("NewPublicKey(Bytes()) differs")
+	}
+	b = k.Public().Bytes()
+	b[31] ^= 0x7f
+	b[0] = 2
+	if _, err := NewPublicKey(b); err == nil {
+		t.Error("invalid point accepted")
+	}
+}
+
+func TestParseDerivationPath(t *testing.T) {
+	got, err := ParseDerivationPath("m/1852'/1815h/0H/0/12")
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := DerivationPath{1852 + HardenedOffset, 1815 + HardenedOffset, HardenedOffset, 0, 12}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("got %v, want %v", []uint32(got), []uint32(want))
+	}
+	if s := got.String(); s != "m/1852'/1815'/0'/0/12" {
+		t.Errorf("String() = %q", s)
+	}
+	for _, in := range []string{"", "m/", "m//0", "m/01", "m/-1", "m/2147483648", "m/0''", "n/0"} {
+		if _, err := ParseDerivationPath(in); err == nil {
+			t.Errorf("%q accepted", in)
+		}
+	}
+}
@@ -0,0 +1,49 @@
+// Copyright 2018 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package ed25519
+
+import (
+	"errors"
+	"strconv"
+)
+
+// SignWithContext signs message with privateKey using Ed25519ctx, as defined
+// in RFC 8032, Section 5.1. context must be between 1 and 255 bytes long, and
+// be passed to VerifyWithContext as well. It will panic if len(privateKey) is
+// not PrivateKeySize.
+//
+// The signature only verifies with the same context, so a key used by several
+// protocols, each with its own context, cannot have a signature made for one
+// accepted by another. Ed25519ctx signatures are never valid Ed25519 ones.
+func SignWithContext(privateKey PrivateKey, message []byte, context string) ([]byte, error) {
+	if l := len(privateKey); l != PrivateKeySize {
+		panic("ed25519: bad private key length: " + strconv.Itoa(l))
+	}
+	if context == "" {
+		return nil, errors.New("ed25519: Ed25519ctx context is empty")
+	}
+	dom, err := dom2(0, context)
+	if err != nil {
+		return nil, err
+	}
+	return privateKey.Expand().sign(message, dom, nil), nil
+}
+
+// VerifyWithContext reports whether sig is a valid Ed25519ctx signature of
+// message by publicKey with the given context. It will panic if
+// len(publicKey) is not PublicKeySize.
+func VerifyWithContext(publicKey PublicKey, message, sig []byte, context string) bool {
+	if l := len(publicKey); l != PublicKeySize {
+		panic("ed25519: bad public key length: " + strconv.Itoa(l))
+	}
+	if context == "" {
+		return false
+	}
+	dom, err := dom2(0, context)
+	if err != nil {
+		return false
+	}
+	return verify(publicKey, message, sig, dom)
+}
@@ -0,0 +1,108 @@
+// Copyright 2018 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package ed25519
+
+import (
+	"bytes"
+	"crypto"
+	"encoding/hex"
+	"strings"
+	"testing"
+)
+
+// ed25519ctxTests are the test vectors of RFC 8032, Section 7.2.
+var ed25519ctxTests = []struct {
+	seed, pub, msg, context, sig string
+}{
+	{
+		"0305334e381af78f141cb666f6199f57bc3495335a256a95bd2a55bf546663f6",
+		"dfc9425e4f968f7f0c29f0259cf5f9aed6851c2bb4ad8bfb860cfee0ab248292",
+		"f726936d19c800494e3fdaff20b276a8", "foo",
+		"55a4cc2f70a54e04288c5f4cd1e45a7bb520b36292911876cada7323198dd87a8b36950b95130022907a7fb7c4e9b2d5f6cca685a587b4b21f4b888e4e7edb0d",
+	},
+	{
+		"0305334e381af78f141cb666f6199f57bc3495335a256a95bd2a55bf546663f6",
+		"dfc9425e4f968f7f0c29f0259cf5f9aed6851c2bb4ad8bfb860cfee0ab248292",
+		"f726936d19c800494e3fdaff20b276a8", "bar",
+		"fc60d5872fc46b3aa69f8b5b4351d5808f92bcc044606db097abab6dbcb1aee3216c48e8b3b66431b5b186d1d28f8ee15a5ca2df6668346291c2043d4eb3e90d",
+	},
+	{
+		"0305334e381af78f141cb666f6199f57bc3495335a256a95bd2a55bf546663f6",
+		"dfc9425e4f968f7f0c29f0259cf5f9aed6851c2bb4ad8bfb860cfee0ab248292",
+		"508e9e6882b979fea900f62adceaca35", "foo",
+		"8b70c1cc8310e1de20ac53ce28ae6e7207f33c3295e03bb5c0732a1d20dc64908922a8b052cf99b7c4fe107a5abb5b2c4085ae75890d02df26269d8945f84b0b",
+	},
+	{
+		"ab9c2853ce297ddab85c993b3ae14bcad39b2c682beabc27d6d4eb20711d6560",
+		"0f1d1274943b91415889152e893d80e93275a1fc0b65fd71b4b0dda10ad7d772",
+		"f726936d19c800494e3fdaff20b276a8", "foo",
+		"21655b5f1aa965996b3f97b3c849eafba922a0a62992f73b3d1b73106a84ad85e9b86a7b6005ea868337ff2d20a7f5fbd4cd10b0be49a68da2b2e0dc0ad8960f",
+	},
+}
+
+func TestSignWithContext(t *testing.T) {
+	for i, test := range ed25519ctxTests {
+		seed, _ := hex.DecodeString(test.seed)
+		pub, _ := hex.DecodeString(test.pub)
+		msg, _ := hex.DecodeString(test.msg)
+		want, _ := hex.DecodeString(test.sig)
+		priv := NewKeyFromSeed(seed)
+		if !bytes.Equal(priv[32:], pub) {
+			t.Fatalf("#%d: public key = %x, want %x", i, priv[32:], pub)
+		}
+
+		sig, err := SignWithContext(priv, msg, test.context)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !bytes.Equal(sig, want) {
+			t.Errorf("#%d: SignWithContext = %x, want %x", i, sig, want)
+		}
+		opts := &Options{Context: test.context}
+		if sig, err := priv.Sign(nil, msg, opts); err != nil || !bytes.Equal(sig, want) {
+			t.Errorf("#%d: Sign with Options = %x, %v", i, sig, err)
+		}
+		if !VerifyWithContext(pub, msg, sig, test.context) {
+			t.Errorf("#%d: VerifyWithContext rejected the test vector", i)
+		}
+		if VerifyWithContext(pub, msg, sig, test.context+"!") {
+			t.Errorf("#%d: signature accepted with the wrong context", i)
+		}
+		if Verify(pub, msg, sig) {
+			t.Errorf("#%d: Ed25519ctx signature accepted by Verify", i)
+		}
+	}
+}
+
+func TestSignWithContextErrors(t *testing.T) {
+	_, priv, err := GenerateKey(nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	pub := priv.Public().(PublicKey)
+	msg := []byte("message")
+
+	if _, err := SignWithContext(priv, msg, ""); err == nil {
+		t.Errorf("empty context accepted")
+	}
+	if _, err := SignWithContext(priv, msg, strings.Repeat("x", 256)); err == nil {
+		t.Errorf("long context accepted")
+	}
+	if VerifyWithContext(pub, msg, Sign(priv, msg), "") {
+		t.Errorf("Ed25519 signature accepted with an empty context")
+	}
+
+	// Ed25519ctx and Ed25519ph signatures with the same context differ.
+	sig, err := priv.Sign(nil, msg, &Options{Context: "ctx"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if VerifyPrehashed(pub, msg, sig, "ctx") {
+		t.Errorf("Ed25519ctx signature accepted as Ed25519ph")
+	}
+	if sig, err := priv.Sign(nil, msg, &Options{Hash: crypto.Hash(0)}); err != nil || !Verify(pub, msg, sig) {
+		t.Errorf("Options without a context did not select Ed25519")
+	}
+}
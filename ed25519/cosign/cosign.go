@@ -0,0 +1,409 @@
+// Copyright 2018 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+// Package cosign implements two-party Ed25519 signing: two parties, each
+// holding a share of a private key, jointly make signatures that neither can
+// make alone, and that are ordinary Ed25519 signatures of their joint public
+// key, which any verifier accepts.
+//
+// The secret scalar a of the joint key is additively shared: party i holds
+// a_i, with a = a_1 + a_2 modulo the group order, and the public key is
+// A = A_1 + A_2, where A_i = [a_i]B is the public share of party i. The
+// shares are either generated jointly by KeyGen, so that a is never known to
+// anyone, or dealt from an existing key by Split.
+//
+// A signature is made in three rounds, in a Session at each party:
+//
+//  1. each party picks a random nonce r_i and sends a commitment to R_i = [r_i]B;
+//  2. once it received the commitment of its peer, each party reveals R_i;
+//  3. each party checks the peer's R_j against its commitment, computes
+//     R = R_1 + R_2 and k = SHA-512(R || A || M), and sends its partial
+//     signature s_i = r_i + k*a_i.
+//
+// Either party then checks the peer's partial signature and combines them
+// into the signature (R, s_1 + s_2). The commitments prevent a party from
+// choosing its nonce after seeing its peer's, which would let it forge
+// signatures when several sessions run concurrently.
+//
+// Messages must be exchanged over a channel that authenticates the peer.
+// Each Session must be used for a single signature: a party that made two
+// partial signatures with the same nonce reveals its share. The Session
+// methods enforce this by failing when called out of order or twice.
+package cosign // import "golang.org/x/crypto/ed25519/cosign"
+
+import (
+	cryptorand "crypto/rand"
+	"crypto/sha256"
+	"crypto/sha512"
+	"crypto/subtle"
+	"errors"
+	"io"
+
+	"golang.org/x/crypto/ed25519"
+	"golang.org/x/crypto/ed25519/internal/edwards25519"
+)
+
+const (
+	// CommitmentSize is the size, in bytes, of commitments.
+	CommitmentSize = sha256.Size
+	// PointSize is the size, in bytes, of public shares and revealed nonces.
+	PointSize = 32
+	// PartialSignatureSize is the size, in bytes, of partial signatures.
+	PartialSignatureSize = 32
+	// KeyShareSize is the size, in bytes, of the encoding of a KeyShare.
+	KeyShareSize = 64
+)
+
+var (
+	errPoint      = errors.New("cosign: invalid point")
+	errCommitment = errors.New("cosign: peer value does not match its commitment")
+	errOrder      = errors.New("cosign: round out of order or repeated")
+	errPartial    = errors.New("cosign: invalid partial signature")
+)
+
+// commit returns the commitment to point, bound to label and to context.
+func commit(label string, point *[32]byte, context ...[]byte) []byte {
+	h := sha256.New()
+	h.Write([]byte("golang.org/x/crypto/ed25519/cosign " + label))
+	h.Write(point[:])
+	for _, c := range context {
+		h.Write(c)
+	}
+	return h.Sum(nil)
+}
+
+// decodePoint decodes a point received from a peer, and rejects points of
+// small order, which a peer could use to cancel out or bias a contribution.
+func decodePoint(p *edwards25519.ExtendedGroupElement, b []byte) error {
+	if len(b) != PointSize {
+		return errPoint
+	}
+	var s [32]byte
+	copy(s[:], b)
+	if p.FromCanonicalBytes(&s, true) != nil {
+		return errPoint
+	}
+	return nil
+}
+
+func addPoints(a, b *edwards25519.ExtendedGroupElement) [32]byte {
+	var c edwards25519.CachedGroupElement
+	var r edwards25519.CompletedGroupElement
+	var sum edwards25519.ExtendedGroupElement
+	b.ToCached(&c)
+	edwards25519.GeAdd(&r, a, &c)
+	r.ToExtended(&sum)
+	var s [32]byte
+	sum.ToBytes(&s)
+	return s
+}
+
+// A KeyShare is the share of a joint key held by one party.
+type KeyShare struct {
+	secret    edwards25519.Scalar
+	public    [32]byte // [secret]B
+	peer      [32]byte // the public share of the peer
+	publicKey [32]byte // the joint public key
+}
+
+func newKeyShare(secret *edwards25519.Scalar, peer *edwards25519.ExtendedGroupElement) (*KeyShare, error) {
+	k := &KeyShare{secret: *secret}
+	b := secret.Bytes()
+	var A edwards25519.ExtendedGroupElement
+	edwards25519.GeScalarMultBase(&A, &b)
+	A.ToBytes(&k.public)
+	peer.ToBytes(&k.peer)
+	k.publicKey = addPoints(&A, peer)
+
+	var joint edwards25519.ExtendedGroupElement
+	if joint.FromCanonicalBytes(&k.publicKey, true) != nil {
+		return nil, errors.New("cosign: joint public key of small order")
+	}
+	return k, nil
+}
+
+// PublicKey returns the joint public key, which verifies the signatures made
+// with k and its peer.
+func (k *KeyShare) PublicKey() ed25519.PublicKey {
+	return append(ed25519.PublicKey(nil), k.publicKey[:]...)
+}
+
+// MarshalBinary returns the KeyShareSize-byte encoding of k: its secret
+// share followed by the public share of its peer. It must be stored as
+// securely as a private key.
+func (k *KeyShare) MarshalBinary() ([]byte, error) {
+	b := k.secret.Bytes()
+	return append(b[:], k.peer[:]...), nil
+}
+
+// UnmarshalKeyShare decodes a KeyShare encoded by MarshalBinary.
+func UnmarshalKeyShare(b []byte) (*KeyShare, error) {
+	if len(b) != KeyShareSize {
+		return nil, errors.New("cosign: bad key share length")
+	}
+	var secret edwards25519.Scalar
+	if _, err := secret.SetCanonicalBytes(b[:32]); err != nil || secret.IsZero() == 1 {
+		return nil, errors.New("cosign: invalid secret share")
+	}
+	var peer edwards25519.ExtendedGroupElement
+	if err := decodePoint(&peer, b[32:]); err != nil {
+		return nil, err
+	}
+	return newKeyShare(&secret, &peer)
+}
+
+// Split deals two shares of privateKey, for parties that already have an
+// Ed25519 key. The key must then be destroyed, and each share given to one
+// party. If rand is nil, crypto/rand.Reader is used.
+func Split(rand io.Reader, privateKey ed25519.PrivateKey) (*KeyShare, *KeyShare, error) {
+	expanded := privateKey.Expand()
+	defer expanded.Zero()
+	b := expanded.Bytes()
+	var a edwards25519.Scalar
+	a.SetBytesWithClamping(b[:32])
+	for i := range b {
+		b[i] = 0
+	}
+
+	a1, err := edwards25519.NewRandomScalar(rand)
+	if err != nil {
+		return nil, nil, err
+	}
+	var a2 edwards25519.Scalar
+	a2.Subtract(&a, a1)
+	if a2.IsZero() == 1 {
+		return nil, nil, errors.New("cosign: degenerate share")
+	}
+
+	var A1, A2 edwards25519.ExtendedGroupElement
+	a1Bytes, a2Bytes := a1.Bytes(), a2.Bytes()
+	edwards25519.GeScalarMultBase(&A1, &a1Bytes)
+	edwards25519.GeScalarMultBase(&A2, &a2Bytes)
+	k1, err := newKeyShare(a1, &A2)
+	if err != nil {
+		return nil, nil, err
+	}
+	k2, err := newKeyShare(&a2, &A1)
+	if err != nil {
+		return nil, nil, err
+	}
+	return k1, k2, nil
+}
+
+// KeyGen generates a share of a new joint key, in two rounds: each party
+// sends its Commitment, then its Reveal once it received the commitment of
+// its peer, and finally calls Finish with the revealed public share of its
+// peer. The commitments prevent a party from choosing its public share as a
+// function of its peer's, which would let it control the joint key.
+type KeyGen struct {
+	secret         *edwards25519.Scalar
+	public         [32]byte
+	peerCommitment []byte
+	done           bool
+}
+
+// NewKeyGen starts the generation of a joint key with a random share. If
+// rand is nil, crypto/rand.Reader is used.
+func NewKeyGen(rand io.Reader) (*KeyGen, error) {
+	secret, err := edwards25519.NewRandomScalar(rand)
+	if err != nil {
+		return nil, err
+	}
+	g := &KeyGen{secret: secret}
+	b := secret.Bytes()
+	var A edwards25519.ExtendedGroupElement
+	edwards25519.GeScalarMultBase(&A, &b)
+	A.ToBytes(&g.public)
+	return g, nil
+}
+
+// Commitment returns the first message of g, to be sent to the peer.
+func (g *KeyGen) Commitment() []byte {
+	return commit("keygen", &g.public)
+}
+
+// Reveal records the commitment of the peer, and returns the second message
+// of g, the public share, to be sent to the peer.
+func (g *KeyGen) Reveal(peerCommitment []byte) ([]byte, error) {
+	if g.peerCommitment != nil || g.done {
+		return nil, errOrder
+	}
+	if len(peerCommitment) != CommitmentSize {
+		return nil, errCommitment
+	}
+	g.peerCommitment = append([]byte(nil), peerCommitment...)
+	return append([]byte(nil), g.public[:]...), nil
+}
+
+// Finish checks the public share revealed by the peer against its
+// commitment, and returns the KeyShare of this party.
+func (g *KeyGen) Finish(peerPublic []byte) (*KeyShare, error) {
+	if g.peerCommitment == nil || g.done {
+		return nil, errOrder
+	}
+	var peer edwards25519.ExtendedGroupElement
+	if err := decodePoint(&peer, peerPublic); err != nil {
+		return nil, err
+	}
+	var p [32]byte
+	copy(p[:], peerPublic)
+	if subtle.ConstantTimeCompare(commit("keygen", &p), g.peerCommitment) != 1 {
+		return nil, errCommitment
+	}
+	if p == g.public {
+		return nil, errors.New("cosign: peer reflected our public share")
+	}
+	g.done = true
+	return newKeyShare(g.secret, &peer)
+}
+
+// A Session makes one signature with a KeyShare and its peer.
+type Session struct {
+	share   *KeyShare
+	message []byte
+
+	nonce          edwards25519.Scalar
+	R              [32]byte // [nonce]B
+	peerCommitment []byte
+	peerR          [32]byte
+	k              edwards25519.Scalar
+	jointR         [32]byte
+	partial        edwards25519.Scalar
+	state          int
+}
+
+// The states of a Session.
+const (
+	stateCommitted = iota
+	stateRevealed
+	stateSigned
+	stateDone
+)
+
+// NewSession starts the signing of message with k. The nonce is derived
+// from 32 bytes read from rand, hashed with the secret share and message.
+// If rand is nil, crypto/rand.Reader is used.
+//
+// Unlike with single-party Ed25519, the nonce must not be deterministic: a
+// peer that starts two sessions for the same message, and changes its own
+// nonce in the second, gets two partial signatures with the same r_i and
+// distinct k, and learns the share. Because the bytes from rand are hashed
+// with the secret share, a predictable rand does not reveal the share, but a
+// rand that repeats its output across sessions does. If reading from rand
+// fails, NewSession returns the error and no session is started.
+func (k *KeyShare) NewSession(rand io.Reader, message []byte) (*Session, error) {
+	if rand == nil {
+		rand = cryptorand.Reader
+	}
+	var noise [32]byte
+	if _, err := io.ReadFull(rand, noise[:]); err != nil {
+		return nil, err
+	}
+	s := &Session{share: k, message: append([]byte(nil), message...)}
+	secret := k.secret.Bytes()
+	h := sha512.New()
+	h.Write(secret[:])
+	h.Write(noise[:])
+	h.Write(k.publicKey[:])
+	h.Write(message)
+	if _, err := s.nonce.SetUniformBytes(h.Sum(nil)); err != nil {
+		panic("cosign: internal error: setting scalar failed")
+	}
+
+	b := s.nonce.Bytes()
+	var R edwards25519.ExtendedGroupElement
+	edwards25519.GeScalarMultBase(&R, &b)
+	R.ToBytes(&s.R)
+	return s, nil
+}
+
+// Commitment returns the first message of s, to be sent to the peer.
+func (s *Session) Commitment() []byte {
+	return commit("nonce", &s.R, s.share.public[:], s.share.publicKey[:], s.message)
+}
+
+// Reveal records the commitment of the peer, and returns the second message
+// of s, the nonce point, to be sent to the peer.
+func (s *Session) Reveal(peerCommitment []byte) ([]byte, error) {
+	if s.state != stateCommitted {
+		return nil, errOrder
+	}
+	if len(peerCommitment) != CommitmentSize {
+		return nil, errCommitment
+	}
+	s.peerCommitment = append([]byte(nil), peerCommitment...)
+	s.state = stateRevealed
+	return append([]byte(nil), s.R[:]...), nil
+}
+
+// Sign checks the nonce point revealed by the peer against its commitment,
+// and returns the partial signature of this party, to be sent to the peer.
+func (s *Session) Sign(peerNonce []byte) ([]byte, error) {
+	if s.state != stateRevealed {
+		return nil, errOrder
+	}
+	var peerR edwards25519.ExtendedGroupElement
+	if err := decodePoint(&peerR, peerNonce); err != nil {
+		return nil, err
+	}
+	copy(s.peerR[:], peerNonce)
+	want := commit("nonce", &s.peerR, s.share.peer[:], s.share.publicKey[:], s.message)
+	if subtle.ConstantTimeCompare(want, s.peerCommitment) != 1 {
+		return nil, errCommitment
+	}
+	// Past this point the nonce is used, whatever happens.
+	s.state = stateSigned
+
+	var R edwards25519.ExtendedGroupElement
+	R.FromBytes(&s.R)
+	s.jointR = addPoints(&R, &peerR)
+
+	h := sha512.New()
+	h.Write(s.jointR[:])
+	h.Write(s.share.publicKey[:])
+	h.Write(s.message)
+	if _, err := s.k.SetUniformBytes(h.Sum(nil)); err != nil {
+		panic("cosign: internal error: setting scalar failed")
+	}
+
+	s.partial.MultiplyAdd(&s.k, &s.share.secret, &s.nonce)
+	s.nonce = edwards25519.Scalar{}
+	b := s.partial.Bytes()
+	return b[:], nil
+}
+
+// Combine checks the partial signature of the peer, and returns the Ed25519
+// signature of the message by the joint public key.
+func (s *Session) Combine(peerPartial []byte) ([]byte, error) {
+	if s.state != stateSigned {
+		return nil, errOrder
+	}
+	var peer edwards25519.Scalar
+	if _, err := peer.SetCanonicalBytes(peerPartial); err != nil {
+		return nil, errPartial
+	}
+
+	// Check that [peer]B = R_j + [k]A_j, as [peer]B - [k]A_j.
+	var A edwards25519.ExtendedGroupElement
+	A.FromBytes(&s.share.peer)
+	edwards25519.GeNeg(&A, &A)
+	var check edwards25519.ProjectiveGroupElement
+	kBytes, peerBytes := s.k.Bytes(), peer.Bytes()
+	edwards25519.GeDoubleScalarMultVartime(&check, &kBytes, &A, &peerBytes)
+	var checkBytes [32]byte
+	check.ToBytes(&checkBytes)
+	if checkBytes != s.peerR {
+		return nil, errPartial
+	}
+
+	var sum edwards25519.Scalar
+	sum.Add(&s.partial, &peer)
+	sBytes := sum.Bytes()
+	sig := append(s.jointR[:], sBytes[:]...)
+	if !ed25519.Verify(s.share.publicKey[:], s.message, sig) {
+		return nil, errPartial
+	}
+	s.state = stateDone
+	return sig, nil
+}
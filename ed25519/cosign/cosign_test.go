@@ -0,0 +1,228 @@
+// Copyright 2018 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package cosign
+
+import (
+	"bytes"
+	"testing"
+
+	"golang.org/x/crypto/ed25519"
+)
+
+func keyGen(t *testing.T) (*KeyShare, *KeyShare) {
+	g1, err := NewKeyGen(nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	g2, err := NewKeyGen(nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	p1, err := g1.Reveal(g2.Commitment())
+	if err != nil {
+		t.Fatal(err)
+	}
+	p2, err := g2.Reveal(g1.Commitment())
+	if err != nil {
+		t.Fatal(err)
+	}
+	k1, err := g1.Finish(p2)
+	if err != nil {
+		t.Fatal(err)
+	}
+	k2, err := g2.Finish(p1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(k1.PublicKey(), k2.PublicKey()) {
+		t.Fatal("parties computed different public keys")
+	}
+	return k1, k2
+}
+
+// cosign runs the signing protocol between k1 and k2, and returns the
+// signatures combined by each party.
+func cosign(t *testing.T, k1, k2 *KeyShare, message []byte) ([]byte, []byte) {
+	s1, err := k1.NewSession(nil, message)
+	if err != nil {
+		t.Fatal(err)
+	}
+	s2, err := k2.NewSession(nil, message)
+	if err != nil {
+		t.Fatal(err)
+	}
+	R1, err := s1.Reveal(s2.Commitment())
+	if err != nil {
+		t.Fatal(err)
+	}
+	R2, err := s2.Reveal(s1.Commitment())
+	if err != nil {
+		t.Fatal(err)
+	}
+	p1, err := s1.Sign(R2)
+	if err != nil {
+		t.Fatal(err)
+	}
+	p2, err := s2.Sign(R1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	sig1, err := s1.Combine(p2)
+	if err != nil {
+		t.Fatal(err)
+	}
+	sig2, err := s2.Combine(p1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return sig1, sig2
+}
+
+func TestKeyGenAndSign(t *testing.T) {
+	k1, k2 := keyGen(t)
+	message := []byte("transfer 10 gophers")
+	sig1, sig2 := cosign(t, k1, k2, message)
+	if !bytes.Equal(sig1, sig2) {
+		t.Error("parties combined different signatures")
+	}
+	if !ed25519.Verify(k1.PublicKey(), message, sig1) {
+		t.Error("signature does not verify")
+	}
+	if err := ed25519.ValidatePublicKey(k1.PublicKey()); err != nil {
+		t.Errorf("joint public key is invalid: %v", err)
+	}
+}
+
+func TestSplit(t *testing.T) {
+	pub, priv, err := ed25519.GenerateKey(nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	k1, k2, err := Split(nil, priv)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(k1.PublicKey(), pub) || !bytes.Equal(k2.PublicKey(), pub) {
+		t.Fatal("shares do not have the public key of the split key")
+	}
+	message := []byte("hello")
+	sig, _ := cosign(t, k1, k2, message)
+	if !ed25519.Verify(pub, message, sig) {
+		t.Error("signature does not verify")
+	}
+}
+
+func TestKeyShareEncoding(t *testing.T) {
+	k1, k2 := keyGen(t)
+	b, err := k1.MarshalBinary()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(b) != KeyShareSize {
+		t.Fatalf("encoding is %d bytes, want %d", len(b), KeyShareSize)
+	}
+	k, err := UnmarshalKeyShare(b)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if *k != *k1 {
+		t.Error("decoded key share differs")
+	}
+	message := []byte("after a restart")
+	sig, _ := cosign(t, k, k2, message)
+	if !ed25519.Verify(k1.PublicKey(), message, sig) {
+		t.Error("signature does not verify")
+	}
+
+	if _, err := UnmarshalKeyShare(b[:63]); err == nil {
+		t.Error("short encoding accepted")
+	}
+	bad := append([]byte(nil), b...)
+	for i := 32; i < 64; i++ {
+		bad[i] = 0
+	}
+	if _, err := UnmarshalKeyShare(bad); err == nil {
+		t.Error("encoding with a small order peer share accepted")
+	}
+}
+
+func TestKeyGenCommitment(t *testing.T) {
+	g1, _ := NewKeyGen(nil)
+	g2, _ := NewKeyGen(nil)
+	g3, _ := NewKeyGen(nil)
+	if _, err := g1.Finish(make([]byte, 32)); err != errOrder {
+		t.Errorf("Finish before Reveal: got %v, want %v", err, errOrder)
+	}
+	if _, err := g1.Reveal(g2.Commitment()); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := g1.Reveal(g2.Commitment()); err != errOrder {
+		t.Errorf("second Reveal: got %v, want %v", err, errOrder)
+	}
+	// g2 changes its mind and reveals the share of g3.
+	p3, _ := g3.Reveal(g1.Commitment())
+	if _, err := g1.Finish(p3); err != errCommitment {
+		t.Errorf("Finish with a share that does not match the commitment: got %v, want %v", err, errCommitment)
+	}
+}
+
+func TestSessionRejectsCheating(t *testing.T) {
+	k1, k2 := keyGen(t)
+	message := []byte("message")
+
+	s1, _ := k1.NewSession(nil, message)
+	s2, _ := k2.NewSession(nil, message)
+	other, _ := k2.NewSession(nil, message)
+	if _, err := s1.Sign(make([]byte, 32)); err != errOrder {
+		t.Errorf("Sign before Reveal: got %v, want %v", err, errOrder)
+	}
+	if _, err := s1.Reveal(s2.Commitment()); err != nil {
+		t.Fatal(err)
+	}
+	R2, _ := s2.Reveal(s1.Commitment())
+
+	// The peer reveals a nonce other than the one it committed to.
+	Rother, _ := other.Reveal(s1.Commitment())
+	if _, err := s1.Sign(Rother); err != errCommitment {
+		t.Errorf("Sign with an uncommitted nonce: got %v, want %v", err, errCommitment)
+	}
+	// Reflecting our own nonce does not match the peer's commitment.
+	R1 := append([]byte(nil), s1.R[:]...)
+	if _, err := s1.Sign(R1); err != errCommitment {
+		t.Errorf("Sign with a reflected nonce: got %v, want %v", err, errCommitment)
+	}
+
+	if _, err := s1.Sign(R2); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := s1.Sign(R2); err != errOrder {
+		t.Errorf("second Sign: got %v, want %v", err, errOrder)
+	}
+	p2, err := s2.Sign(R1)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	bad := append([]byte(nil), p2...)
+	bad[0] ^= 1
+	if _, err := s1.Combine(bad); err != errPartial {
+		t.Errorf("Combine with a bad partial signature: got %v, want %v", err, errPartial)
+	}
+	if _, err := s1.Combine(p2); err != nil {
+		t.Errorf("Combine after a rejected partial signature: %v", err)
+	}
+}
+
+func TestSessionMessageBinding(t *testing.T) {
+	// Sessions for different messages cannot be mixed.
+	k1, k2 := keyGen(t)
+	s1, _ := k1.NewSession(nil, []byte("pay 1"))
+	s2, _ := k2.NewSession(nil, []byte("pay 1000"))
+	s1.Reveal(s2.Commitment())
+	R2, _ := s2.Reveal(s1.Commitment())
+	if _, err := s1.Sign(R2); err != errCommitment {
+		t.Errorf("got %v, want %v", err, errCommitment)
+	}
+}
@@ -20,9 +20,11 @@ import (
 	"crypto"
 	cryptorand "crypto/rand"
 	"crypto/sha512"
-	"errors"
+	"hash"
 	"io"
+	"runtime"
 	"strconv"
+	"sync"
 
 	"golang.org/x/crypto/ed25519/internal/edwards25519"
 )
@@ -38,6 +40,26 @@ const (
 	SeedSize = 32
 )
 
+// newHash returns the SHA-512 implementation used by this package. It can be
+// replaced with SetSHA512.
+var newHash = sha512.New
+
+// SetSHA512 replaces the SHA-512 implementation used for key generation,
+// signing and verification, for example with one backed by SHA-NI or AVX-512
+// instructions. newSHA512 must return a hash.Hash computing SHA-512, as
+// anything else will produce signatures that do not verify elsewhere. If
+// newSHA512 is nil, the implementation from crypto/sha512 is restored.
+//
+// SetSHA512 is not safe to call concurrently with any other function in this
+// package and is meant to be called once, during program initialization.
+func SetSHA512(newSHA512 func() hash.Hash) {
+	if newSHA512 == nil {
+		newSHA512 = sha512.New
+	}
+	newHash = newSHA512
+	scratchPool = newScratchPool()
+}
+
 // PublicKey is the type of Ed25519 public keys.
 type PublicKey []byte
 
@@ -60,17 +82,39 @@ func (priv PrivateKey) Seed() []byte {
 	return seed
 }
 
-// Sign signs the given message with priv.
-// Ed25519 performs two passes over messages to be signed and therefore cannot
-// handle pre-hashed messages. Thus opts.HashFunc() must return zero to
-// indicate the message hasn't been hashed. This can be achieved by passing
-// crypto.Hash(0) as the value for opts.
+// Sign signs the given message with priv. rand is ignored, as signatures are
+// deterministic, unless opts is an *Options with Hedged set.
+//
+// The variant of Ed25519 is selected by opts, as in crypto/ed25519:
+//
+//   - If opts.HashFunc() is zero, message is signed with Ed25519, as Sign
+//     does. Ed25519 performs two passes over messages to be signed and
+//     therefore cannot handle pre-hashed messages. This can be achieved by
+//     passing crypto.Hash(0) as the value for opts.
+//   - If opts.HashFunc() is crypto.SHA512, message must be the SHA-512 digest
+//     of the message, which is signed with Ed25519ph, as SignPrehashed does.
+//   - If opts is an *Options with a zero Hash and a non-empty Context, message
+//     is signed with Ed25519ctx, as SignWithContext does.
+//
+// The context of Ed25519ph and Ed25519ctx can only be set with an *Options.
+// Any other hash is rejected with an error.
+//
+// If opts is an *Options with Hedged set, the signature is hedged: the nonce
+// also depends on random bytes read from rand, or from crypto/rand.Reader if
+// rand is nil. Hedged signatures verify like deterministic ones.
 func (priv PrivateKey) Sign(rand io.Reader, message []byte, opts crypto.SignerOpts) (signature []byte, err error) {
-	if opts.HashFunc() != crypto.Hash(0) {
-		return nil, errors.New("ed25519: cannot sign hashed message")
+	dom, err := signerDomain(message, opts)
+	if err != nil {
+		return nil, err
 	}
-
-	return Sign(priv, message), nil
+	noise, err := signerNoise(rand, opts)
+	if err != nil {
+		return nil, err
+	}
+	if dom == nil && noise == nil {
+		return Sign(priv, message), nil
+	}
+	return priv.Expand().sign(message, dom, noise), nil
 }
 
 // GenerateKey generates a public/private key pair using entropy from rand.
@@ -101,14 +145,9 @@ func NewKeyFromSeed(seed []byte) PrivateKey {
 		panic("ed25519: bad seed length: " + strconv.Itoa(l))
 	}
 
-	digest := sha512.Sum512(seed)
-	digest[0] &= 248
-	digest[31] &= 127
-	digest[31] |= 64
-
 	var A edwards25519.ExtendedGroupElement
 	var hBytes [32]byte
-	copy(hBytes[:], digest[:])
+	expandSeed(&hBytes, seed)
 	edwards25519.GeScalarMultBase(&A, &hBytes)
 	var publicKeyBytes [32]byte
 	A.ToBytes(&publicKeyBytes)
@@ -120,62 +159,164 @@ func NewKeyFromSeed(seed []byte) PrivateKey {
 	return privateKey
 }
 
+// NewKeysFromSeeds calculates the private keys of many seeds, as
+// NewKeyFromSeed would. It is faster for large batches, as the public keys
+// are encoded with a single field inversion for the whole batch, and the
+// private keys share a single allocation. It will panic if the length of any
+// seed is not SeedSize.
+func NewKeysFromSeeds(seeds [][]byte) []PrivateKey {
+	points := make([]edwards25519.ExtendedGroupElement, len(seeds))
+	for i, seed := range seeds {
+		if l := len(seed); l != SeedSize {
+			panic("ed25519: bad seed length: " + strconv.Itoa(l))
+		}
+		var hBytes [32]byte
+		expandSeed(&hBytes, seed)
+		edwards25519.GeScalarMultBase(&points[i], &hBytes)
+	}
+	publicKeys := make([][32]byte, len(seeds))
+	edwards25519.GeBatchToBytes(publicKeys, points)
+
+	keys := make([]byte, len(seeds)*PrivateKeySize)
+	privateKeys := make([]PrivateKey, len(seeds))
+	for i, seed := range seeds {
+		privateKey := keys[i*PrivateKeySize : (i+1)*PrivateKeySize : (i+1)*PrivateKeySize]
+		copy(privateKey, seed)
+		copy(privateKey[32:], publicKeys[i][:])
+		privateKeys[i] = privateKey
+	}
+	return privateKeys
+}
+
+// expandSeed sets s to the clamped secret scalar derived from seed.
+func expandSeed(s *[32]byte, seed []byte) {
+	var digest [64]byte
+	h := newHash()
+	h.Write(seed)
+	h.Sum(digest[:0])
+	digest[0] &= 248
+	digest[31] &= 127
+	digest[31] |= 64
+	copy(s[:], digest[:])
+}
+
+// scratch holds a hash and the buffers passed to it. Buffers passed through
+// the hash.Hash interface escape to the heap, so Sign and Verify take them
+// from scratchPool to avoid allocating.
+type scratch struct {
+	h                                  hash.Hash
+	digest1, messageDigest, hramDigest [64]byte
+	encodedR                           [32]byte
+}
+
+var scratchPool = newScratchPool()
+
+func newScratchPool() *sync.Pool {
+	return &sync.Pool{
+		New: func() interface{} {
+			return &scratch{h: newHash()}
+		},
+	}
+}
+
+func getScratch() *scratch {
+	s := scratchPool.Get().(*scratch)
+	s.h.Reset()
+	return s
+}
+
 // Sign signs the message with privateKey and returns a signature. It will
 // panic if len(privateKey) is not PrivateKeySize.
 func Sign(privateKey PrivateKey, message []byte) []byte {
+	return AppendSign(make([]byte, 0, SignatureSize), privateKey, message)
+}
+
+// AppendSign signs the message with privateKey, appends the signature to dst
+// and returns the resulting slice. It will panic if len(privateKey) is not
+// PrivateKeySize.
+//
+// AppendSign does not allocate if dst has room for SignatureSize more bytes,
+// which makes it suitable for signing in latency-sensitive code.
+func AppendSign(dst []byte, privateKey PrivateKey, message []byte) []byte {
 	if l := len(privateKey); l != PrivateKeySize {
 		panic("ed25519: bad private key length: " + strconv.Itoa(l))
 	}
 
-	h := sha512.New()
-	h.Write(privateKey[:32])
-
-	var digest1, messageDigest, hramDigest [64]byte
-	var expandedSecretKey [32]byte
-	h.Sum(digest1[:0])
-	copy(expandedSecretKey[:], digest1[:])
-	expandedSecretKey[0] &= 248
-	expandedSecretKey[31] &= 63
-	expandedSecretKey[31] |= 64
+	sc := getScratch()
+	defer scratchPool.Put(sc)
+	sc.h.Write(privateKey[:32])
+	sc.h.Sum(sc.digest1[:0])
+	sc.digest1[0] &= 248
+	sc.digest1[31] &= 63
+	sc.digest1[31] |= 64
+
+	var a edwards25519.Scalar
+	if _, err := a.SetReducedBytes(sc.digest1[:32]); err != nil {
+		panic("ed25519: internal error: setting scalar failed")
+	}
+	return appendSign(dst, sc, &a, sc.digest1[32:], privateKey[32:], message, nil, nil)
+}
 
+// appendSign appends to dst the signature of message by the private key made
+// of the secret scalar a, reduced modulo l, and of the nonce prefix, whose
+// public key is publicKey. dom is the domain separation prefix of Ed25519ph,
+// or nil. noise is the randomness of a hedged signature, or nil for a
+// deterministic one.
+func appendSign(dst []byte, sc *scratch, a *edwards25519.Scalar, prefix, publicKey, message, dom, noise []byte) []byte {
+	h := sc.h
 	h.Reset()
-	h.Write(digest1[32:])
+	h.Write(dom)
+	if noise != nil {
+		h.Write([]byte(hedgedLabel))
+		h.Write(noise)
+	}
+	h.Write(prefix)
 	h.Write(message)
-	h.Sum(messageDigest[:0])
+	h.Sum(sc.messageDigest[:0])
 
-	var messageDigestReduced [32]byte
-	edwards25519.ScReduce(&messageDigestReduced, &messageDigest)
+	var r edwards25519.Scalar
+	if _, err := r.SetUniformBytes(sc.messageDigest[:]); err != nil {
+		panic("ed25519: internal error: setting scalar failed")
+	}
+	messageDigestReduced := r.Bytes()
 	var R edwards25519.ExtendedGroupElement
 	edwards25519.GeScalarMultBase(&R, &messageDigestReduced)
 
-	var encodedR [32]byte
-	R.ToBytes(&encodedR)
+	R.ToBytes(&sc.encodedR)
 
 	h.Reset()
-	h.Write(encodedR[:])
-	h.Write(privateKey[32:])
+	h.Write(dom)
+	h.Write(sc.encodedR[:])
+	h.Write(publicKey)
 	h.Write(message)
-	h.Sum(hramDigest[:0])
-	var hramDigestReduced [32]byte
-	edwards25519.ScReduce(&hramDigestReduced, &hramDigest)
+	h.Sum(sc.hramDigest[:0])
+	var k, S edwards25519.Scalar
+	if _, err := k.SetUniformBytes(sc.hramDigest[:]); err != nil {
+		panic("ed25519: internal error: setting scalar failed")
+	}
 
-	var s [32]byte
-	edwards25519.ScMulAdd(&s, &hramDigestReduced, &expandedSecretKey, &messageDigestReduced)
+	// S = r + k*a
+	S.MultiplyAdd(&k, a, &r)
+	s := S.Bytes()
 
-	signature := make([]byte, SignatureSize)
-	copy(signature[:], encodedR[:])
+	ret, signature := sliceForAppend(dst, SignatureSize)
+	copy(signature[:], sc.encodedR[:])
 	copy(signature[32:], s[:])
 
-	return signature
+	return ret
 }
 
 // Verify reports whether sig is a valid signature of message by publicKey. It
-// will panic if len(publicKey) is not PublicKeySize.
+// will panic if len(publicKey) is not PublicKeySize. Verify does not allocate.
 func Verify(publicKey PublicKey, message, sig []byte) bool {
 	if l := len(publicKey); l != PublicKeySize {
 		panic("ed25519: bad public key length: " + strconv.Itoa(l))
 	}
+	return verify(publicKey, message, sig, nil)
+}
 
+// verify is Verify with the domain separation prefix of Ed25519ph, or nil.
+func verify(publicKey PublicKey, message, sig, dom []byte) bool {
 	if len(sig) != SignatureSize || sig[63]&224 != 0 {
 		return false
 	}
@@ -189,29 +330,151 @@ func Verify(publicKey PublicKey, message, sig []byte) bool {
 	edwards25519.FeNeg(&A.X, &A.X)
 	edwards25519.FeNeg(&A.T, &A.T)
 
-	h := sha512.New()
+	var negA edwards25519.NAFTable
+	negA.FromPoint(&A)
+	return verifyTable(publicKey, &negA, message, sig, dom)
+}
+
+// verifyTable is verify with the NAFTable of the negation of the public key,
+// once the length of sig was checked.
+func verifyTable(publicKey []byte, negA *edwards25519.NAFTable, message, sig, dom []byte) bool {
+	sc := getScratch()
+	defer scratchPool.Put(sc)
+	h := sc.h
+	h.Write(dom)
 	h.Write(sig[:32])
 	h.Write(publicKey[:])
 	h.Write(message)
-	var digest [64]byte
-	h.Sum(digest[:0])
+	h.Sum(sc.hramDigest[:0])
 
 	var hReduced [32]byte
-	edwards25519.ScReduce(&hReduced, &digest)
-
-	var R edwards25519.ProjectiveGroupElement
-	var s [32]byte
-	copy(s[:], sig[32:])
+	edwards25519.ScReduce(&hReduced, &sc.hramDigest)
 
 	// https://tools.ietf.org/html/rfc8032#section-5.1.7 requires that s be in
 	// the range [0, order) in order to prevent signature malleability.
-	if !edwards25519.ScMinimal(&s) {
+	var S edwards25519.Scalar
+	if _, err := S.SetCanonicalBytes(sig[32:]); err != nil {
 		return false
 	}
+	s := S.Bytes()
 
-	edwards25519.GeDoubleScalarMultVartime(&R, &hReduced, &A, &s)
+	var R edwards25519.ProjectiveGroupElement
+
+	edwards25519.GeDoubleScalarMultTableVartime(&R, &hReduced, negA, &s)
 
 	var checkR [32]byte
 	R.ToBytes(&checkR)
 	return bytes.Equal(sig[:32], checkR[:])
 }
+
+// VerifyBatch reports whether every sigs[i] is a valid signature of msgs[i]
+// by pubs[i]. It will panic if the three slices do not have the same length,
+// or if the length of any public key is not PublicKeySize. An empty batch is
+// valid.
+//
+// VerifyBatch checks a random linear combination of the verification
+// equations with a single multiscalar multiplication, spread across
+// GOMAXPROCS goroutines. On a single CPU, a large batch takes less than half
+// the time of calling Verify for each of its signatures. If VerifyBatch
+// returns false, at least one signature is invalid, and callers that need to
+// know which must check them with Verify. The probability that it accepts a
+// batch containing an invalid signature is below 2^-128.
+//
+// Verify checks the cofactorless equation [S]B = R + [k]A, while VerifyBatch
+// checks the cofactored one, [8][S]B = [8]R + [8][k]A, as random linear
+// combinations require. Both are allowed by RFC 8032. Every batch whose
+// signatures Verify accepts is accepted by VerifyBatch, but VerifyBatch also
+// accepts signatures that only differ from a valid one by a point of small
+// order, which Verify rejects. Such signatures can only be produced by the
+// owner of a key, or for keys of small order, so they matter only to
+// applications that need all verifiers to agree on malicious signatures.
+func VerifyBatch(pubs []PublicKey, msgs [][]byte, sigs [][]byte) bool {
+	n := len(pubs)
+	if len(msgs) != n || len(sigs) != n {
+		panic("ed25519: mismatched batch lengths")
+	}
+	for _, publicKey := range pubs {
+		if l := len(publicKey); l != PublicKeySize {
+			panic("ed25519: bad public key length: " + strconv.Itoa(l))
+		}
+	}
+	if n == 0 {
+		return true
+	}
+
+	// The random coefficients z[i] are 128 bits long: a batch containing an
+	// invalid signature passes only if they satisfy a linear relation modulo
+	// l, which happens with probability at most 2^-128.
+	random := make([]byte, 16*n)
+	if _, err := io.ReadFull(cryptorand.Reader, random); err != nil {
+		panic("ed25519: failed to read random coefficients: " + err.Error())
+	}
+
+	sc := getScratch()
+	defer scratchPool.Put(sc)
+	h := sc.h
+
+	// The terms are z[i]*R[i] and z[i]*k[i]*A[i], and -sum(z[i]*S[i])*B last.
+	terms := make([]edwards25519.Term, 2*n+1)
+	var sumZS edwards25519.Scalar
+	for i := 0; i < n; i++ {
+		sig := sigs[i]
+		if len(sig) != SignatureSize || sig[63]&224 != 0 {
+			return false
+		}
+		var S edwards25519.Scalar
+		if _, err := S.SetCanonicalBytes(sig[32:]); err != nil {
+			return false
+		}
+		// Verify rejects non-canonical encodings of R, as they never match
+		// the encoding it computes, but accepts any encoding of A.
+		R, A := &terms[2*i].Point, &terms[2*i+1].Point
+		var encodedR, publicKeyBytes [32]byte
+		copy(encodedR[:], sig[:32])
+		copy(publicKeyBytes[:], pubs[i])
+		if R.FromCanonicalBytes(&encodedR, false) != nil || !A.FromBytes(&publicKeyBytes) {
+			return false
+		}
+
+		h.Reset()
+		h.Write(sig[:32])
+		h.Write(pubs[i])
+		h.Write(msgs[i])
+		h.Sum(sc.hramDigest[:0])
+		var k, z, zk edwards25519.Scalar
+		if _, err := k.SetUniformBytes(sc.hramDigest[:]); err != nil {
+			panic("ed25519: internal error: setting scalar failed")
+		}
+		var zBytes [32]byte
+		copy(zBytes[:], random[16*i:16*(i+1)])
+		if _, err := z.SetCanonicalBytes(zBytes[:]); err != nil {
+			panic("ed25519: internal error: setting scalar failed")
+		}
+
+		terms[2*i].Scalar = zBytes
+		terms[2*i+1].Scalar = zk.Multiply(&z, &k).Bytes()
+		sumZS.MultiplyAdd(&z, &S, &sumZS)
+	}
+	terms[2*n].Scalar = sumZS.Negate(&sumZS).Bytes()
+	terms[2*n].Point = *edwards25519.NewGeneratorPoint()
+
+	lc := edwards25519.LinearCombination{Shards: runtime.GOMAXPROCS(0)}
+	var sum edwards25519.ExtendedGroupElement
+	lc.ComputeVartime(&sum, terms)
+	return edwards25519.CofactorEqual(&sum, edwards25519.NewIdentityPoint())
+}
+
+// sliceForAppend takes a slice and a requested number of bytes. It returns a
+// slice with the contents of the given slice followed by that many bytes and a
+// second slice that aliases into it and contains only the extra bytes. If the
+// original slice has sufficient capacity then no allocation is performed.
+func sliceForAppend(in []byte, n int) (head, tail []byte) {
+	if total := len(in) + n; cap(in) >= total {
+		head = in[:total]
+	} else {
+		head = make([]byte, total)
+		copy(head, in)
+	}
+	tail = head[len(in):]
+	return
+}
@@ -10,7 +10,9 @@ import (
 	"compress/gzip"
 	"crypto"
 	"crypto/rand"
+	"crypto/sha512"
 	"encoding/hex"
+	"hash"
 	"os"
 	"strings"
 	"testing"
@@ -89,6 +91,83 @@ func TestCryptoSigner(t *testing.T) {
 	}
 }
 
+func TestAppendSign(t *testing.T) {
+	var zero zeroReader
+	public, private, _ := GenerateKey(zero)
+
+	message := []byte("test message")
+	want := Sign(private, message)
+
+	prefix := []byte("prefix")
+	for _, dst := range [][]byte{nil, prefix, append(make([]byte, 0, 100), prefix...)} {
+		out := AppendSign(dst, private, message)
+		if !bytes.Equal(out[:len(dst)], dst) {
+			t.Errorf("AppendSign changed the prefix to %x", out[:len(dst)])
+		}
+		if sig := out[len(dst):]; !bytes.Equal(sig, want) {
+			t.Errorf("AppendSign = %x, want %x", sig, want)
+		}
+		if !Verify(public, message, out[len(dst):]) {
+			t.Errorf("signature from AppendSign rejected")
+		}
+	}
+}
+
+func TestAllocations(t *testing.T) {
+	if raceEnabled {
+		t.Skip("skipping allocation test with the race detector")
+	}
+	var zero zeroReader
+	public, private, _ := GenerateKey(zero)
+	message := []byte("test message")
+	sig := make([]byte, 0, SignatureSize)
+
+	if allocs := testing.AllocsPerRun(100, func() {
+		sig = AppendSign(sig[:0], private, message)
+	}); allocs > 0 {
+		t.Errorf("AppendSign: expected zero allocations, got %0.1f", allocs)
+	}
+	if allocs := testing.AllocsPerRun(100, func() {
+		if !Verify(public, message, sig) {
+			t.Fatal("valid signature rejected")
+		}
+	}); allocs > 0 {
+		t.Errorf("Verify: expected zero allocations, got %0.1f", allocs)
+	}
+}
+
+type countingHash struct {
+	hash.Hash
+	writes *int
+}
+
+func (h countingHash) Write(p []byte) (int, error) {
+	*h.writes++
+	return h.Hash.Write(p)
+}
+
+func TestSetSHA512(t *testing.T) {
+	var writes int
+	SetSHA512(func() hash.Hash { return countingHash{sha512.New(), &writes} })
+	defer SetSHA512(nil)
+
+	var zero zeroReader
+	public, private, _ := GenerateKey(zero)
+	message := []byte("test message")
+	sig := Sign(private, message)
+	if !Verify(public, message, sig) {
+		t.Errorf("valid signature rejected")
+	}
+	if writes == 0 {
+		t.Errorf("injected SHA-512 implementation was not used")
+	}
+
+	SetSHA512(nil)
+	if sig2 := Sign(private, message); !bytes.Equal(sig, sig2) {
+		t.Errorf("signatures differ between SHA-512 implementations")
+	}
+}
+
 func TestGolden(t *testing.T) {
 	// sign.input.gz is a selection of test cases from
 	// https://ed25519.cr.yp.to/python/sign.input
@@ -183,6 +262,138 @@ func TestMalleability(t *testing.T) {
 	}
 }
 
+func TestNewKeysFromSeeds(t *testing.T) {
+	seeds := make([][]byte, 50)
+	for i := range seeds {
+		seeds[i] = make([]byte, SeedSize)
+		if _, err := rand.Read(seeds[i]); err != nil {
+			t.Fatal(err)
+		}
+	}
+	keys := NewKeysFromSeeds(seeds)
+	if len(keys) != len(seeds) {
+		t.Fatalf("got %d keys, want %d", len(keys), len(seeds))
+	}
+	for i, seed := range seeds {
+		if want := NewKeyFromSeed(seed); !bytes.Equal(keys[i], want) {
+			t.Errorf("key %d: got %x, want %x", i, keys[i], want)
+		}
+		if cap(keys[i]) != PrivateKeySize {
+			t.Errorf("key %d has capacity %d", i, cap(keys[i]))
+		}
+	}
+	if keys := NewKeysFromSeeds(nil); len(keys) != 0 {
+		t.Errorf("got %d keys for no seeds", len(keys))
+	}
+}
+
+func batchInput(tb testing.TB, n int) ([]PublicKey, [][]byte, [][]byte) {
+	pubs := make([]PublicKey, n)
+	msgs := make([][]byte, n)
+	sigs := make([][]byte, n)
+	for i := range pubs {
+		pub, priv, err := GenerateKey(nil)
+		if err != nil {
+			tb.Fatal(err)
+		}
+		pubs[i] = pub
+		msgs[i] = []byte(strings.Repeat("batch", i))
+		sigs[i] = Sign(priv, msgs[i])
+	}
+	return pubs, msgs, sigs
+}
+
+func TestVerifyBatch(t *testing.T) {
+	for _, n := range []int{0, 1, 2, 65, 200} {
+		pubs, msgs, sigs := batchInput(t, n)
+		if !VerifyBatch(pubs, msgs, sigs) {
+			t.Errorf("valid batch of %d signatures rejected", n)
+		}
+	}
+
+	pubs, msgs, sigs := batchInput(t, 10)
+	tests := []struct {
+		desc   string
+		tamper func(pubs []PublicKey, msgs, sigs [][]byte)
+	}{
+		{"wrong message", func(pubs []PublicKey, msgs, sigs [][]byte) { msgs[3] = []byte("forged") }},
+		{"swapped signatures", func(pubs []PublicKey, msgs, sigs [][]byte) { sigs[1], sigs[2] = sigs[2], sigs[1] }},
+		{"wrong key", func(pubs []PublicKey, msgs, sigs [][]byte) { pubs[9] = pubs[0] }},
+		{"flipped bit in R", func(pubs []PublicKey, msgs, sigs [][]byte) { sigs[5][0] ^= 1 }},
+		{"flipped bit in S", func(pubs []PublicKey, msgs, sigs [][]byte) { sigs[5][40] ^= 1 }},
+		{"short signature", func(pubs []PublicKey, msgs, sigs [][]byte) { sigs[0] = sigs[0][:63] }},
+		{"S with high bits", func(pubs []PublicKey, msgs, sigs [][]byte) { sigs[7][63] |= 224 }},
+	}
+	for _, test := range tests {
+		tPubs := append([]PublicKey(nil), pubs...)
+		tMsgs := append([][]byte(nil), msgs...)
+		tSigs := make([][]byte, len(sigs))
+		for i := range sigs {
+			tSigs[i] = append([]byte(nil), sigs[i]...)
+		}
+		test.tamper(tPubs, tMsgs, tSigs)
+		if VerifyBatch(tPubs, tMsgs, tSigs) {
+			t.Errorf("%s: invalid batch accepted", test.desc)
+		}
+	}
+
+	func() {
+		defer func() {
+			if recover() == nil {
+				t.Errorf("mismatched lengths did not panic")
+			}
+		}()
+		VerifyBatch(pubs, msgs[:9], sigs)
+	}()
+}
+
+func TestVerifyBatchCofactor(t *testing.T) {
+	// A signature whose R is offset by a point of order 8 satisfies the
+	// cofactored equation but not the cofactorless one.
+	seed := make([]byte, SeedSize)
+	if _, err := rand.Read(seed); err != nil {
+		t.Fatal(err)
+	}
+	pub := NewKeyFromSeed(seed).Public().(PublicKey)
+	var aBytes [32]byte
+	expandSeed(&aBytes, seed)
+	a, err := new(edwards25519.Scalar).SetBytesWithClamping(aBytes[:])
+	if err != nil {
+		t.Fatal(err)
+	}
+	r, err := edwards25519.NewRandomScalar(nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var R, torsion edwards25519.ExtendedGroupElement
+	var cached edwards25519.CachedGroupElement
+	var sum edwards25519.CompletedGroupElement
+	rBytes := r.Bytes()
+	edwards25519.GeScalarMultBase(&R, &rBytes)
+	torsion = edwards25519.SmallOrderPoints()[1]
+	torsion.ToCached(&cached)
+	edwards25519.GeAdd(&sum, &R, &cached)
+	sum.ToExtended(&R)
+
+	msg := []byte("small order component")
+	sig := make([]byte, SignatureSize)
+	var encodedR [32]byte
+	R.ToBytes(&encodedR)
+	copy(sig, encodedR[:])
+	kDigest := sha512.Sum512(append(append(encodedR[:], pub...), msg...))
+	k, _ := new(edwards25519.Scalar).SetUniformBytes(kDigest[:])
+	S := new(edwards25519.Scalar).MultiplyAdd(k, a, r).Bytes()
+	copy(sig[32:], S[:])
+
+	if Verify(pub, msg, sig) {
+		t.Errorf("Verify accepted a signature with a small-order component")
+	}
+	if !VerifyBatch([]PublicKey{pub}, [][]byte{msg}, [][]byte{sig}) {
+		t.Errorf("VerifyBatch rejected a signature valid under the cofactored equation")
+	}
+}
+
 func BenchmarkKeyGeneration(b *testing.B) {
 	var zero zeroReader
 	for i := 0; i < b.N; i++ {
@@ -192,6 +403,19 @@ func BenchmarkKeyGeneration(b *testing.B) {
 	}
 }
 
+func BenchmarkNewKeysFromSeeds(b *testing.B) {
+	seeds := make([][]byte, 1000)
+	for i := range seeds {
+		seeds[i] = make([]byte, SeedSize)
+		seeds[i][0] = byte(i)
+		seeds[i][1] = byte(i >> 8)
+	}
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		NewKeysFromSeeds(seeds)
+	}
+}
+
 func BenchmarkSigning(b *testing.B) {
 	var zero zeroReader
 	_, priv, err := GenerateKey(zero)
@@ -205,6 +429,21 @@ func BenchmarkSigning(b *testing.B) {
 	}
 }
 
+func BenchmarkAppendSign(b *testing.B) {
+	var zero zeroReader
+	_, priv, err := GenerateKey(zero)
+	if err != nil {
+		b.Fatal(err)
+	}
+	message := []byte("Hello, world!")
+	sig := make([]byte, 0, SignatureSize)
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		sig = AppendSign(sig[:0], priv, message)
+	}
+}
+
 func BenchmarkVerification(b *testing.B) {
 	var zero zeroReader
 	pub, priv, err := GenerateKey(zero)
@@ -218,3 +457,13 @@ func BenchmarkVerification(b *testing.B) {
 		Verify(pub, message, signature)
 	}
 }
+
+func BenchmarkVerifyBatch(b *testing.B) {
+	pubs, msgs, sigs := batchInput(b, 64)
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		if !VerifyBatch(pubs, msgs, sigs) {
+			b.Fatal("valid batch rejected")
+		}
+	}
+}
@@ -0,0 +1,131 @@
+// Copyright 2018 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package ed25519
+
+import (
+	"crypto"
+	"errors"
+	"io"
+	"strconv"
+
+	"golang.org/x/crypto/ed25519/internal/edwards25519"
+)
+
+// ExpandedPrivateKeySize is the size, in bytes, of the encoding of expanded
+// private keys.
+const ExpandedPrivateKeySize = 64
+
+// An ExpandedPrivateKey is an Ed25519 private key in expanded form: the secret
+// scalar and the nonce prefix, which RFC 8032, Section 5.1.5, derives from a
+// seed by hashing it. Its encoding is the 64-byte concatenation of the two,
+// the format in which some implementations store keys, and the only one in
+// which derived keys, such as blinded keys, exist.
+//
+// An expanded key cannot be turned back into a seed, and thus into a
+// PrivateKey. It is a distinct type, rather than a byte slice of the same
+// length as a PrivateKey, so that one is never mistaken for the other.
+// ExpandedPrivateKey implements crypto.Signer.
+//
+// Signing with an ExpandedPrivateKey skips the hashing of the seed and the
+// reduction of the secret scalar that Sign does for every signature, so
+// signers that make many signatures with the same key should expand it once
+// and keep the ExpandedPrivateKey.
+type ExpandedPrivateKey struct {
+	expanded  [64]byte
+	scalar    edwards25519.Scalar // expanded[:32] reduced modulo l
+	publicKey [PublicKeySize]byte
+}
+
+// NewExpandedPrivateKey decodes an expanded private key, the secret scalar
+// followed by the nonce prefix, and computes its public key. The scalar is a
+// 32-byte little-endian integer and need not be clamped, as derived keys are
+// not, but it must not be a multiple of the group order.
+func NewExpandedPrivateKey(b []byte) (*ExpandedPrivateKey, error) {
+	if len(b) != ExpandedPrivateKeySize {
+		return nil, errors.New("ed25519: bad expanded private key length")
+	}
+	var a edwards25519.Scalar
+	if _, err := a.SetReducedBytes(b[:32]); err != nil {
+		panic("ed25519: internal error: setting scalar failed")
+	}
+	if a.IsZero() == 1 {
+		return nil, errors.New("ed25519: expanded private key has a zero scalar")
+	}
+
+	k := &ExpandedPrivateKey{scalar: a}
+	copy(k.expanded[:], b)
+	var A edwards25519.ExtendedGroupElement
+	aBytes := a.Bytes()
+	edwards25519.GeScalarMultBase(&A, &aBytes)
+	A.ToBytes(&k.publicKey)
+	return k, nil
+}
+
+// Expand returns the expanded form of priv, whose signatures are identical to
+// those made with priv. It will panic if len(priv) is not PrivateKeySize.
+func (priv PrivateKey) Expand() *ExpandedPrivateKey {
+	if l := len(priv); l != PrivateKeySize {
+		panic("ed25519: bad private key length: " + strconv.Itoa(l))
+	}
+	k := new(ExpandedPrivateKey)
+	h := newHash()
+	h.Write(priv[:32])
+	h.Sum(k.expanded[:0])
+	k.expanded[0] &= 248
+	k.expanded[31] &= 127
+	k.expanded[31] |= 64
+	if _, err := k.scalar.SetReducedBytes(k.expanded[:32]); err != nil {
+		panic("ed25519: internal error: setting scalar failed")
+	}
+	copy(k.publicKey[:], priv[32:])
+	return k
+}
+
+// Bytes returns the 64-byte encoding of k, the secret scalar followed by the
+// nonce prefix.
+func (k *ExpandedPrivateKey) Bytes() []byte {
+	b := make([]byte, ExpandedPrivateKeySize)
+	copy(b, k.expanded[:])
+	return b
+}
+
+// Public returns the PublicKey corresponding to k.
+func (k *ExpandedPrivateKey) Public() crypto.PublicKey {
+	publicKey := make([]byte, PublicKeySize)
+	copy(publicKey, k.publicKey[:])
+	return PublicKey(publicKey)
+}
+
+// Sign signs the given message with k, interpreting opts as PrivateKey.Sign
+// does. rand is only used for hedged signatures.
+func (k *ExpandedPrivateKey) Sign(rand io.Reader, message []byte, opts crypto.SignerOpts) (signature []byte, err error) {
+	dom, err := signerDomain(message, opts)
+	if err != nil {
+		return nil, err
+	}
+	noise, err := signerNoise(rand, opts)
+	if err != nil {
+		return nil, err
+	}
+	return k.sign(message, dom, noise), nil
+}
+
+// AppendSign signs message with k using Ed25519, appends the signature to dst
+// and returns the resulting slice. Like the package-level AppendSign, it does
+// not allocate if dst has room for SignatureSize more bytes.
+func (k *ExpandedPrivateKey) AppendSign(dst, message []byte) []byte {
+	sc := getScratch()
+	defer scratchPool.Put(sc)
+	return appendSign(dst, sc, &k.scalar, k.expanded[32:], k.publicKey[:], message, nil, nil)
+}
+
+// sign signs message with k and the Ed25519ph or Ed25519ctx prefix dom,
+// or with Ed25519 if dom is nil. The signature is hedged with noise, unless it
+// is nil.
+func (k *ExpandedPrivateKey) sign(message, dom, noise []byte) []byte {
+	sc := getScratch()
+	defer scratchPool.Put(sc)
+	return appendSign(make([]byte, 0, SignatureSize), sc, &k.scalar, k.expanded[32:], k.publicKey[:], message, dom, noise)
+}
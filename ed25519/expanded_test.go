@@ -0,0 +1,138 @@
+// Copyright 2018 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package ed25519
+
+import (
+	"bytes"
+	"crypto"
+	"crypto/rand"
+	"crypto/sha512"
+	"encoding/hex"
+	"testing"
+)
+
+func TestExpandedPrivateKey(t *testing.T) {
+	// The first test vector of RFC 8032, Section 7.1.
+	seed, _ := hex.DecodeString("9d61b19deffd5a60ba844af492ec2cc44449c5697b326919703bac031cae7f60")
+	pub, _ := hex.DecodeString("d75a980182b10ab7d54bfed3c964073a0ee172f3daa62325af021a68f707511a")
+	sig, _ := hex.DecodeString("e5564300c360ac729086e2cc806e828a84877f1eb8e5d974d873e065224901555fb8821590a33bacc61e39701cf9b46bd25bf5f0595bbe24655141438e7a100b")
+
+	want := sha512.Sum512(seed)
+	want[0] &= 248
+	want[31] &= 127
+	want[31] |= 64
+	expanded := NewKeyFromSeed(seed).Expand()
+	encoded := expanded.Bytes()
+	if !bytes.Equal(encoded, want[:]) {
+		t.Errorf("Bytes() = %x, want %x", encoded, want)
+	}
+
+	k, err := NewExpandedPrivateKey(encoded)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := k.Public().(PublicKey); !bytes.Equal(got, pub) {
+		t.Errorf("Public() = %x, want %x", got, pub)
+	}
+	got, err := crypto.Signer(k).Sign(nil, nil, crypto.Hash(0))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(got, sig) {
+		t.Errorf("Sign() = %x, want %x", got, sig)
+	}
+	if _, err := k.Sign(nil, nil, crypto.SHA256); err == nil {
+		t.Errorf("signed a hashed message")
+	}
+
+	// Changes to the encoding do not affect the key.
+	encoded[32] ^= 1
+	if got, _ := k.Sign(nil, nil, crypto.Hash(0)); !bytes.Equal(got, sig) {
+		t.Errorf("key shares memory with its encoding")
+	}
+}
+
+func TestExpandedPrivateKeyUnclamped(t *testing.T) {
+	encoded := make([]byte, ExpandedPrivateKeySize)
+	if _, err := rand.Read(encoded); err != nil {
+		t.Fatal(err)
+	}
+	// The scalar of a derived key may have any bits set.
+	encoded[0] |= 7
+	encoded[31] |= 0x80
+	k, err := NewExpandedPrivateKey(encoded)
+	if err != nil {
+		t.Fatal(err)
+	}
+	message := []byte("signed with an unclamped scalar")
+	sig, err := k.Sign(nil, message, crypto.Hash(0))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !Verify(k.Public().(PublicKey), message, sig) {
+		t.Errorf("signature rejected")
+	}
+}
+
+func TestNewExpandedPrivateKeyErrors(t *testing.T) {
+	if _, err := NewExpandedPrivateKey(make([]byte, PrivateKeySize-1)); err == nil {
+		t.Errorf("short key accepted")
+	}
+	if _, err := NewExpandedPrivateKey(make([]byte, ExpandedPrivateKeySize)); err == nil {
+		t.Errorf("zero scalar accepted")
+	}
+	// The group order, 2^252 + 27742317777372353535851937790883648493, is
+	// also zero.
+	l, _ := hex.DecodeString("edd3f55c1a631258d69cf7a2def9de1400000000000000000000000000000010")
+	if _, err := NewExpandedPrivateKey(append(l, make([]byte, 32)...)); err == nil {
+		t.Errorf("scalar equal to the group order accepted")
+	}
+}
+
+func TestExpandedPrivateKeyAppendSign(t *testing.T) {
+	_, priv, _ := GenerateKey(nil)
+	k := priv.Expand()
+	message := []byte("log entry")
+	prefix := []byte("prefix")
+	got := k.AppendSign(prefix, message)
+	if want := AppendSign(prefix, priv, message); !bytes.Equal(got, want) {
+		t.Errorf("AppendSign = %x, want %x", got, want)
+	}
+
+	// A key decoded from its encoding signs identically.
+	decoded, err := NewExpandedPrivateKey(k.Bytes())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := decoded.AppendSign(nil, message); !bytes.Equal(got, Sign(priv, message)) {
+		t.Errorf("decoded key signature = %x, want %x", got, Sign(priv, message))
+	}
+
+	if raceEnabled {
+		t.Skip("skipping allocation test with the race detector")
+	}
+	sig := make([]byte, 0, SignatureSize)
+	if allocs := testing.AllocsPerRun(10, func() {
+		sig = k.AppendSign(sig[:0], message)
+	}); allocs > 0 {
+		t.Errorf("AppendSign allocated %v times", allocs)
+	}
+}
+
+func BenchmarkExpandedAppendSign(b *testing.B) {
+	var zero zeroReader
+	_, priv, err := GenerateKey(zero)
+	if err != nil {
+		b.Fatal(err)
+	}
+	k := priv.Expand()
+	message := []byte("Hello, world!")
+	sig := make([]byte, 0, SignatureSize)
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		sig = k.AppendSign(sig[:0], message)
+	}
+}
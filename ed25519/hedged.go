@@ -0,0 +1,35 @@
+// Copyright 2018 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package ed25519
+
+import (
+	"crypto"
+	cryptorand "crypto/rand"
+	"io"
+)
+
+// hedgedNoiseSize is the number of random bytes mixed into the nonces of
+// hedged signatures.
+const hedgedNoiseSize = 32
+
+// hedgedLabel precedes the random bytes in the nonce hash of hedged
+// signatures, so that it never takes the same input as a deterministic one.
+const hedgedLabel = "Ed25519 hedged nonce\x00"
+
+// signerNoise returns the random bytes read from rand for the nonce of a
+// hedged signature, if opts is an *Options selecting one, or nil otherwise.
+func signerNoise(rand io.Reader, opts crypto.SignerOpts) ([]byte, error) {
+	if o, ok := opts.(*Options); !ok || !o.Hedged {
+		return nil, nil
+	}
+	if rand == nil {
+		rand = cryptorand.Reader
+	}
+	noise := make([]byte, hedgedNoiseSize)
+	if _, err := io.ReadFull(rand, noise); err != nil {
+		return nil, err
+	}
+	return noise, nil
+}
@@ -0,0 +1,72 @@
+// Copyright 2018 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package ed25519
+
+import (
+	"bytes"
+	"crypto"
+	"crypto/sha512"
+	"errors"
+	"testing"
+)
+
+type errReader struct{}
+
+func (errReader) Read([]byte) (int, error) { return 0, errors.New("no randomness") }
+
+func TestHedgedSign(t *testing.T) {
+	pub, priv, _ := GenerateKey(nil)
+	message := []byte("test message")
+	digest := sha512.Sum512(message)
+	hedged := &Options{Hedged: true}
+
+	for _, signer := range []crypto.Signer{priv, priv.Expand()} {
+		sig1, err := signer.Sign(nil, message, hedged)
+		if err != nil {
+			t.Fatal(err)
+		}
+		sig2, err := signer.Sign(nil, message, hedged)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if bytes.Equal(sig1, sig2) || bytes.Equal(sig1, Sign(priv, message)) {
+			t.Errorf("%T: hedged signatures are deterministic", signer)
+		}
+		if !Verify(pub, message, sig1) || !Verify(pub, message, sig2) {
+			t.Errorf("%T: hedged signature rejected by Verify", signer)
+		}
+
+		// The same randomness gives the same signature.
+		zeros := bytes.NewReader(make([]byte, 2*hedgedNoiseSize))
+		sig1, _ = signer.Sign(zeros, message, hedged)
+		sig2, _ = signer.Sign(zeros, message, hedged)
+		if !bytes.Equal(sig1, sig2) {
+			t.Errorf("%T: hedged signatures with the same randomness differ", signer)
+		}
+
+		sig, err := signer.Sign(nil, message, &Options{Hedged: true, Context: "ctx"})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !VerifyWithContext(pub, message, sig, "ctx") {
+			t.Errorf("%T: hedged Ed25519ctx signature rejected", signer)
+		}
+		sig, err = signer.Sign(nil, digest[:], &Options{Hedged: true, Hash: crypto.SHA512})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !VerifyPrehashed(pub, digest[:], sig, "") {
+			t.Errorf("%T: hedged Ed25519ph signature rejected", signer)
+		}
+
+		if _, err := signer.Sign(errReader{}, message, hedged); err == nil {
+			t.Errorf("%T: hedged signature made without randomness", signer)
+		}
+		// rand is still ignored for deterministic signatures.
+		if _, err := signer.Sign(errReader{}, message, &Options{}); err != nil {
+			t.Errorf("%T: deterministic signature failed: %v", signer, err)
+		}
+	}
+}
@@ -0,0 +1,67 @@
+// Copyright 2018 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+// +build fiat
+
+package edwards25519
+
+// FeFromBytes sets dst to the field element encoded by src, ignoring its top
+// bit.
+func FeFromBytes(dst *FieldElement, src *[32]byte) { feFromBytesFiat(dst, src) }
+
+// FeToBytes sets s to the canonical encoding of h.
+func FeToBytes(s *[32]byte, h *FieldElement) { feToBytesFiat(s, h) }
+
+// FeAdd sets dst = a + b.
+func FeAdd(dst, a, b *FieldElement) { feAddFiat(dst, a, b) }
+
+// FeSub sets dst = a - b.
+func FeSub(dst, a, b *FieldElement) { feSubFiat(dst, a, b) }
+
+// FeNeg sets h = -f.
+func FeNeg(h, f *FieldElement) { feNegFiat(h, f) }
+
+// FeMul sets h = f * g.
+func FeMul(h, f, g *FieldElement) { feMulFiat(h, f, g) }
+
+// FeSquare sets h = f * f.
+func FeSquare(h, f *FieldElement) { feSquareFiat(h, f) }
+
+// FeSquare2 sets h = 2 * f * f.
+func FeSquare2(h, f *FieldElement) { feSquare2Fiat(h, f) }
+
+// FeMul121666 sets h = f * 121666.
+func FeMul121666(h, f *FieldElement) { feMul121666Fiat(h, f) }
+
+// The constants in const.go are in the ref10 representation.
+func init() {
+	for _, f := range []*FieldElement{&d, &d2, &SqrtM1, &A, &invSqrtAMinusD, &sqrtMinusAPlus2} {
+		feFromGeneric(f)
+	}
+	extendedFromGeneric(&basePoint)
+	for i := range smallOrderPoints {
+		extendedFromGeneric(&smallOrderPoints[i])
+	}
+	for i := range bi {
+		preComputedFromGeneric(&bi[i])
+	}
+	for i := range base {
+		for j := range base[i] {
+			preComputedFromGeneric(&base[i][j])
+		}
+	}
+}
+
+func extendedFromGeneric(p *ExtendedGroupElement) {
+	feFromGeneric(&p.X)
+	feFromGeneric(&p.Y)
+	feFromGeneric(&p.Z)
+	feFromGeneric(&p.T)
+}
+
+func preComputedFromGeneric(p *PreComputedGroupElement) {
+	feFromGeneric(&p.yPlusX)
+	feFromGeneric(&p.yMinusX)
+	feFromGeneric(&p.xy2d)
+}
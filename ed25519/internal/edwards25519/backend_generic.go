@@ -0,0 +1,35 @@
+// Copyright 2018 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+// +build !fiat
+
+package edwards25519
+
+// FeFromBytes sets dst to the field element encoded by src, ignoring its top
+// bit.
+func FeFromBytes(dst *FieldElement, src *[32]byte) { feFromBytesGeneric(dst, src) }
+
+// FeToBytes sets s to the canonical encoding of h.
+func FeToBytes(s *[32]byte, h *FieldElement) { feToBytesGeneric(s, h) }
+
+// FeAdd sets dst = a + b.
+func FeAdd(dst, a, b *FieldElement) { feAddGeneric(dst, a, b) }
+
+// FeSub sets dst = a - b.
+func FeSub(dst, a, b *FieldElement) { feSubGeneric(dst, a, b) }
+
+// FeNeg sets h = -f.
+func FeNeg(h, f *FieldElement) { feNegGeneric(h, f) }
+
+// FeMul sets h = f * g.
+func FeMul(h, f, g *FieldElement) { feMulGeneric(h, f, g) }
+
+// FeSquare sets h = f * f.
+func FeSquare(h, f *FieldElement) { feSquareGeneric(h, f) }
+
+// FeSquare2 sets h = 2 * f * f.
+func FeSquare2(h, f *FieldElement) { feSquare2Generic(h, f) }
+
+// FeMul121666 sets h = f * 121666.
+func FeMul121666(h, f *FieldElement) { feMul121666Generic(h, f) }
@@ -22,11 +22,93 @@ var SqrtM1 = FieldElement{
 	-32595792, -7943725, 9377950, 3500415, 12389472, -272473, -25146209, -2005654, 326686, 11406482,
 }
 
+// invSqrtAMinusD is 1/sqrt(a-d), where a = -1, used by the ristretto255
+// encoding.
+var invSqrtAMinusD = FieldElement{
+	6111466, 4156064, 39310137, 12243467, 41204824, 120896, 20826367, 26493656, 6093567, 31568420,
+}
+
+// sqrtMinusAPlus2 is the non-negative square root of -(A+2), used by the
+// birational map from curve25519 to edwards25519.
+var sqrtMinusAPlus2 = FieldElement{
+	54885894, 25242303, 55597453, 9067496, 51808079, 33312638, 25456129, 14121551, 54921728, 3972023,
+}
+
 // A is a constant in the Montgomery-form of curve25519.
 var A = FieldElement{
 	486662, 0, 0, 0, 0, 0, 0, 0, 0, 0,
 }
 
+// basePoint is the Ed25519 base point (x,4/5) with x positive.
+var basePoint = ExtendedGroupElement{
+	X: FieldElement{-14297830, -7645148, 16144683, -16471763, 27570974, -2696100, -26142465, 8378389, 20764389, 8758491},
+	Y: FieldElement{-26843541, -6710886, 13421773, -13421773, 26843546, 6710886, -13421773, 13421773, -26843546, -6710886},
+	Z: FieldElement{1, 0, 0, 0, 0, 0, 0, 0, 0, 0},
+	T: FieldElement{28827062, -6116119, -27349572, 244363, 8635006, 11264893, 19351346, 13413597, 16611511, -6414980},
+}
+
+// smallOrderPoints holds the eight points of the torsion subgroup, where
+// smallOrderPoints[i] is i times smallOrderPoints[1], a point of order 8. The
+// comments give the encoding of each point.
+var smallOrderPoints = [8]ExtendedGroupElement{
+	// 0100000000000000000000000000000000000000000000000000000000000000
+	{
+		X: FieldElement{0, 0, 0, 0, 0, 0, 0, 0, 0, 0},
+		Y: FieldElement{1, 0, 0, 0, 0, 0, 0, 0, 0, 0},
+		Z: FieldElement{1, 0, 0, 0, 0, 0, 0, 0, 0, 0},
+		T: FieldElement{0, 0, 0, 0, 0, 0, 0, 0, 0, 0},
+	},
+	// c7176a703d4dd84fba3c0b760d10670f2a2053fa2c39ccc64ec7fd7792ac037a
+	{
+		X: FieldElement{21352778, 5345713, 4660180, -8347857, 24143090, 14568123, 30185756, -12247770, -33528939, 8345319},
+		Y: FieldElement{6952922, 1265500, -6862341, 7057498, 4037696, 5447722, -31680899, 15325402, 19365852, -1569102},
+		Z: FieldElement{1, 0, 0, 0, 0, 0, 0, 0, 0, 0},
+		T: FieldElement{-25262188, -11972680, 11716002, -5869612, -18193162, 16297739, 20670665, -8559098, 3541543, -5011181},
+	},
+	// 0000000000000000000000000000000000000000000000000000000000000080
+	{
+		X: FieldElement{32595792, 7943725, -9377950, -3500415, -12389472, 272473, 25146209, 2005654, -326686, -11406482},
+		Y: FieldElement{0, 0, 0, 0, 0, 0, 0, 0, 0, 0},
+		Z: FieldElement{1, 0, 0, 0, 0, 0, 0, 0, 0, 0},
+		T: FieldElement{0, 0, 0, 0, 0, 0, 0, 0, 0, 0},
+	},
+	// 26e8958fc2b227b045c3f489f2ef98f0d5dfac05d3c63339b13802886d53fc05
+	{
+		X: FieldElement{21352778, 5345713, 4660180, -8347857, 24143090, 14568123, 30185756, -12247770, -33528939, 8345319},
+		Y: FieldElement{-6952922, -1265500, 6862341, -7057498, -4037696, -5447722, 31680899, -15325402, -19365852, 1569102},
+		Z: FieldElement{1, 0, 0, 0, 0, 0, 0, 0, 0, 0},
+		T: FieldElement{25262188, 11972680, -11716002, 5869612, 18193162, -16297739, -20670665, 8559098, -3541543, 5011181},
+	},
+	// ecffffffffffffffffffffffffffffffffffffffffffffffffffffffffffff7f
+	{
+		X: FieldElement{0, 0, 0, 0, 0, 0, 0, 0, 0, 0},
+		Y: FieldElement{-1, 0, 0, 0, 0, 0, 0, 0, 0, 0},
+		Z: FieldElement{1, 0, 0, 0, 0, 0, 0, 0, 0, 0},
+		T: FieldElement{0, 0, 0, 0, 0, 0, 0, 0, 0, 0},
+	},
+	// 26e8958fc2b227b045c3f489f2ef98f0d5dfac05d3c63339b13802886d53fc85
+	{
+		X: FieldElement{-21352778, -5345713, -4660180, 8347857, -24143090, -14568123, -30185756, 12247770, 33528939, -8345319},
+		Y: FieldElement{-6952922, -1265500, 6862341, -7057498, -4037696, -5447722, 31680899, -15325402, -19365852, 1569102},
+		Z: FieldElement{1, 0, 0, 0, 0, 0, 0, 0, 0, 0},
+		T: FieldElement{-25262188, -11972680, 11716002, -5869612, -18193162, 16297739, 20670665, -8559098, 3541543, -5011181},
+	},
+	// 0000000000000000000000000000000000000000000000000000000000000000
+	{
+		X: FieldElement{-32595792, -7943725, 9377950, 3500415, 12389472, -272473, -25146209, -2005654, 326686, 11406482},
+		Y: FieldElement{0, 0, 0, 0, 0, 0, 0, 0, 0, 0},
+		Z: FieldElement{1, 0, 0, 0, 0, 0, 0, 0, 0, 0},
+		T: FieldElement{0, 0, 0, 0, 0, 0, 0, 0, 0, 0},
+	},
+	// c7176a703d4dd84fba3c0b760d10670f2a2053fa2c39ccc64ec7fd7792ac03fa
+	{
+		X: FieldElement{-21352778, -5345713, -4660180, 8347857, -24143090, -14568123, -30185756, 12247770, 33528939, -8345319},
+		Y: FieldElement{6952922, 1265500, -6862341, 7057498, 4037696, 5447722, -31680899, 15325402, 19365852, -1569102},
+		Z: FieldElement{1, 0, 0, 0, 0, 0, 0, 0, 0, 0},
+		T: FieldElement{25262188, 11972680, -11716002, 5869612, 18193162, -16297739, -20670665, 8559098, -3541543, 5011181},
+	},
+}
+
 // bi contains precomputed multiples of the base-point. See the Ed25519 paper
 // for a discussion about how these values are used.
 var bi = [8]PreComputedGroupElement{
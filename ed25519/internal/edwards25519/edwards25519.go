@@ -4,7 +4,11 @@
 
 package edwards25519
 
-import "encoding/binary"
+import (
+	"crypto/subtle"
+	"encoding/binary"
+	"errors"
+)
 
 // This code is a port of the public domain, “ref10” implementation of ed25519
 // from SUPERCOP.
@@ -13,6 +17,10 @@ import "encoding/binary"
 // t, entries t[0]...t[9], represents the integer t[0]+2^26 t[1]+2^51 t[2]+2^77
 // t[3]+2^102 t[4]+...+2^230 t[9].  Bounds on each t[i] vary depending on
 // context.
+//
+// When built with the fiat build tag, the Fe functions are those of Fiat
+// Cryptography, and the entries are its unsigned, carried limbs instead, so
+// FieldElements should only be set with the Fe functions.
 type FieldElement [10]int32
 
 var zero FieldElement
@@ -26,7 +34,7 @@ func FeOne(fe *FieldElement) {
 	fe[0] = 1
 }
 
-func FeAdd(dst, a, b *FieldElement) {
+func feAddGeneric(dst, a, b *FieldElement) {
 	dst[0] = a[0] + b[0]
 	dst[1] = a[1] + b[1]
 	dst[2] = a[2] + b[2]
@@ -39,7 +47,7 @@ func FeAdd(dst, a, b *FieldElement) {
 	dst[9] = a[9] + b[9]
 }
 
-func FeSub(dst, a, b *FieldElement) {
+func feSubGeneric(dst, a, b *FieldElement) {
 	dst[0] = a[0] - b[0]
 	dst[1] = a[1] - b[1]
 	dst[2] = a[2] - b[2]
@@ -91,7 +99,7 @@ func load4(in []byte) int64 {
 	return r
 }
 
-func FeFromBytes(dst *FieldElement, src *[32]byte) {
+func feFromBytesGeneric(dst *FieldElement, src *[32]byte) {
 	h0 := load4(src[:])
 	h1 := load3(src[4:]) << 6
 	h2 := load3(src[7:]) << 5
@@ -106,7 +114,7 @@ func FeFromBytes(dst *FieldElement, src *[32]byte) {
 	FeCombine(dst, h0, h1, h2, h3, h4, h5, h6, h7, h8, h9)
 }
 
-// FeToBytes marshals h to s.
+// feToBytesGeneric marshals h to s.
 // Preconditions:
 //   |h| bounded by 1.1*2^25,1.1*2^24,1.1*2^25,1.1*2^24,etc.
 //
@@ -129,7 +137,7 @@ func FeFromBytes(dst *FieldElement, src *[32]byte) {
 //
 //   Have q+2^(-255)x = 2^(-255)(h + 19 2^(-25) h9 + 2^(-1))
 //   so floor(2^(-255)(h + 19 2^(-25) h9 + 2^(-1))) = q.
-func FeToBytes(s *[32]byte, h *FieldElement) {
+func feToBytesGeneric(s *[32]byte, h *FieldElement) {
 	var carry [10]int32
 
 	q := (19*h[9] + (1 << 24)) >> 25
@@ -237,14 +245,14 @@ func FeIsNonZero(f *FieldElement) int32 {
 	return int32(x & 1)
 }
 
-// FeNeg sets h = -f
+// feNegGeneric sets h = -f
 //
 // Preconditions:
 //    |f| bounded by 1.1*2^25,1.1*2^24,1.1*2^25,1.1*2^24,etc.
 //
 // Postconditions:
 //    |h| bounded by 1.1*2^25,1.1*2^24,1.1*2^25,1.1*2^24,etc.
-func FeNeg(h, f *FieldElement) {
+func feNegGeneric(h, f *FieldElement) {
 	h[0] = -f[0]
 	h[1] = -f[1]
 	h[2] = -f[2]
@@ -346,7 +354,7 @@ func FeCombine(h *FieldElement, h0, h1, h2, h3, h4, h5, h6, h7, h8, h9 int64) {
 	h[9] = int32(h9)
 }
 
-// FeMul calculates h = f * g
+// feMulGeneric calculates h = f * g
 // Can overlap h with f or g.
 //
 // Preconditions:
@@ -373,7 +381,7 @@ func FeCombine(h *FieldElement, h0, h1, h2, h3, h4, h5, h6, h7, h8, h9 int64) {
 // Can get away with 11 carries, but then data flow is much deeper.
 //
 // With tighter constraints on inputs, can squeeze carries into int32.
-func FeMul(h, f, g *FieldElement) {
+func feMulGeneric(h, f, g *FieldElement) {
 	f0 := int64(f[0])
 	f1 := int64(f[1])
 	f2 := int64(f[2])
@@ -465,19 +473,19 @@ func feSquare(f *FieldElement) (h0, h1, h2, h3, h4, h5, h6, h7, h8, h9 int64) {
 	return
 }
 
-// FeSquare calculates h = f*f. Can overlap h with f.
+// feSquareGeneric calculates h = f*f. Can overlap h with f.
 //
 // Preconditions:
 //    |f| bounded by 1.1*2^26,1.1*2^25,1.1*2^26,1.1*2^25,etc.
 //
 // Postconditions:
 //    |h| bounded by 1.1*2^25,1.1*2^24,1.1*2^25,1.1*2^24,etc.
-func FeSquare(h, f *FieldElement) {
+func feSquareGeneric(h, f *FieldElement) {
 	h0, h1, h2, h3, h4, h5, h6, h7, h8, h9 := feSquare(f)
 	FeCombine(h, h0, h1, h2, h3, h4, h5, h6, h7, h8, h9)
 }
 
-// FeSquare2 sets h = 2 * f * f
+// feSquare2Generic sets h = 2 * f * f
 //
 // Can overlap h with f.
 //
@@ -487,7 +495,7 @@ func FeSquare(h, f *FieldElement) {
 // Postconditions:
 //    |h| bounded by 1.01*2^25,1.01*2^24,1.01*2^25,1.01*2^24,etc.
 // See fe_mul.c for discussion of implementation strategy.
-func FeSquare2(h, f *FieldElement) {
+func feSquare2Generic(h, f *FieldElement) {
 	h0, h1, h2, h3, h4, h5, h6, h7, h8, h9 := feSquare(f)
 
 	h0 += h0
@@ -628,33 +636,54 @@ func fePow22523(out, z *FieldElement) {
 //   ExtendedGroupElement: (X:Y:Z:T) satisfying x=X/Z, y=Y/Z, XY=ZT
 //   CompletedGroupElement: ((X:Z),(Y:T)) satisfying x=X/Z, y=Y/T
 //   PreComputedGroupElement: (y+x,y-x,2dxy)
-
+//   CachedGroupElement: (Y+X,Y-X,Z,2dT)
+//
+// The costs documented on the operations below count field multiplications
+// (M), squarings (S) and inversions (I); additions and copies are ignored. A
+// typical addition chain keeps an accumulator as a CompletedGroupElement,
+// converts it to a ProjectiveGroupElement when only doubling follows, and to
+// an ExtendedGroupElement when an addition follows.
+
+// ProjectiveGroupElement is a point in projective coordinates, known as P2 in
+// ref10. It is the cheapest input for doubling.
 type ProjectiveGroupElement struct {
 	X, Y, Z FieldElement
 }
 
+// ExtendedGroupElement is a point in extended coordinates, known as P3 in
+// ref10. It is the general purpose representation and the left-hand input of
+// all additions.
 type ExtendedGroupElement struct {
 	X, Y, Z, T FieldElement
 }
 
+// CompletedGroupElement is the output of doublings and additions, known as
+// P1xP1 in ref10. It must be converted to another representation to be used
+// as an input.
 type CompletedGroupElement struct {
 	X, Y, Z, T FieldElement
 }
 
+// PreComputedGroupElement is an affine point prepared for mixed addition, as
+// used in the base point tables. See ExtendedGroupElement.ToPreComputed.
 type PreComputedGroupElement struct {
 	yPlusX, yMinusX, xy2d FieldElement
 }
 
+// CachedGroupElement is a point prepared for repeated use as the right-hand
+// input of GeAdd and GeSub. See ExtendedGroupElement.ToCached.
 type CachedGroupElement struct {
 	yPlusX, yMinusX, Z, T2d FieldElement
 }
 
+// Zero sets p to the identity element.
 func (p *ProjectiveGroupElement) Zero() {
 	FeZero(&p.X)
 	FeOne(&p.Y)
 	FeOne(&p.Z)
 }
 
+// Double sets r = 2*p. Cost: 4S.
 func (p *ProjectiveGroupElement) Double(r *CompletedGroupElement) {
 	var t0 FieldElement
 
@@ -669,6 +698,7 @@ func (p *ProjectiveGroupElement) Double(r *CompletedGroupElement) {
 	FeSub(&r.T, &r.T, &r.Z)
 }
 
+// ToBytes sets s to the canonical encoding of p. Cost: 1I + 2M.
 func (p *ProjectiveGroupElement) ToBytes(s *[32]byte) {
 	var recip, x, y FieldElement
 
@@ -679,6 +709,15 @@ func (p *ProjectiveGroupElement) ToBytes(s *[32]byte) {
 	s[31] ^= FeIsNegative(&x) << 7
 }
 
+// ToExtended converts p to extended form. Cost: 3M + 1S.
+func (p *ProjectiveGroupElement) ToExtended(r *ExtendedGroupElement) {
+	FeMul(&r.X, &p.X, &p.Z)
+	FeMul(&r.Y, &p.Y, &p.Z)
+	FeSquare(&r.Z, &p.Z)
+	FeMul(&r.T, &p.X, &p.Y)
+}
+
+// Zero sets p to the identity element.
 func (p *ExtendedGroupElement) Zero() {
 	FeZero(&p.X)
 	FeOne(&p.Y)
@@ -686,12 +725,105 @@ func (p *ExtendedGroupElement) Zero() {
 	FeZero(&p.T)
 }
 
+// NewIdentityPoint returns a new ExtendedGroupElement set to the identity
+// element of the group, (0, 1).
+func NewIdentityPoint() *ExtendedGroupElement {
+	p := new(ExtendedGroupElement)
+	p.Zero()
+	return p
+}
+
+// NewGeneratorPoint returns a new ExtendedGroupElement set to the Ed25519 base
+// point (x,4/5) with x positive, which generates the prime-order subgroup.
+func NewGeneratorPoint() *ExtendedGroupElement {
+	p := new(ExtendedGroupElement)
+	*p = basePoint
+	return p
+}
+
+// SmallOrderPoints returns the eight points of order dividing 8, which form
+// the torsion subgroup of the curve. The i-th point is i times a fixed point
+// of order 8, so it has order 8/gcd(i, 8): the first is the identity and the
+// fifth is (0, -1), of order 2.
+func SmallOrderPoints() [8]ExtendedGroupElement {
+	return smallOrderPoints
+}
+
+// CofactorEqual reports whether p and q are equal modulo the torsion
+// subgroup, that is, whether [8]p == [8]q. This is the equality used by
+// cofactored verification equations. It runs in constant time.
+func CofactorEqual(p, q *ExtendedGroupElement) bool {
+	var qCached CachedGroupElement
+	var r CompletedGroupElement
+	var t ProjectiveGroupElement
+
+	q.ToCached(&qCached)
+	GeSub(&r, p, &qCached)
+	for i := 0; i < 3; i++ {
+		r.ToProjective(&t)
+		t.Double(&r)
+	}
+	r.ToProjective(&t)
+
+	// The result is the identity iff X = 0 and Y = Z.
+	var yMinusZ FieldElement
+	FeSub(&yMinusZ, &t.Y, &t.Z)
+	return FeIsNonZero(&t.X)|FeIsNonZero(&yMinusZ) == 0
+}
+
+// orderBytes is the order of the prime-order subgroup, l, as a scalar for
+// GeScalarMultVartime.
+var orderBytes = [32]byte{
+	0xed, 0xd3, 0xf5, 0x5c, 0x1a, 0x63, 0x12, 0x58,
+	0xd6, 0x9c, 0xf7, 0xa2, 0xde, 0xf9, 0xde, 0x14,
+	0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00,
+	0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x10,
+}
+
+// IsTorsionFree reports whether p is in the prime-order subgroup, that is,
+// whether [l]p is the identity. Every point is the sum of a point of that
+// subgroup and of one of the eight SmallOrderPoints, and p is torsion free
+// if the latter is the identity. It is not constant time.
+func (p *ExtendedGroupElement) IsTorsionFree() bool {
+	var t ProjectiveGroupElement
+	GeScalarMultVartime(&t, &orderBytes, p, 5)
+
+	// The result is the identity iff X = 0 and Y = Z.
+	var yMinusZ FieldElement
+	FeSub(&yMinusZ, &t.Y, &t.Z)
+	return FeIsNonZero(&t.X)|FeIsNonZero(&yMinusZ) == 0
+}
+
+// Double sets r = 2*p. Cost: 4S.
 func (p *ExtendedGroupElement) Double(r *CompletedGroupElement) {
 	var q ProjectiveGroupElement
 	p.ToProjective(&q)
 	q.Double(r)
 }
 
+// GeMultByPow2 sets r = 2^k * p, for k >= 0. The intermediate points are kept
+// in projective form, so that each doubling only pays for the conversion it
+// needs. Cost: k*(4S + 3M) + 1M.
+func GeMultByPow2(r, p *ExtendedGroupElement, k int) {
+	if k < 0 {
+		panic("edwards25519: negative exponent in GeMultByPow2")
+	}
+	if k == 0 {
+		*r = *p
+		return
+	}
+	var t ProjectiveGroupElement
+	var c CompletedGroupElement
+	p.ToProjective(&t)
+	for i := 0; i < k-1; i++ {
+		t.Double(&c)
+		c.ToProjective(&t)
+	}
+	t.Double(&c)
+	c.ToExtended(r)
+}
+
+// ToCached converts p to cached form. Cost: 1M.
 func (p *ExtendedGroupElement) ToCached(r *CachedGroupElement) {
 	FeAdd(&r.yPlusX, &p.Y, &p.X)
 	FeSub(&r.yMinusX, &p.Y, &p.X)
@@ -699,12 +831,28 @@ func (p *ExtendedGroupElement) ToCached(r *CachedGroupElement) {
 	FeMul(&r.T2d, &p.T, &d2)
 }
 
+// ToPreComputed converts p to precomputed form, suitable for GeMixedAdd and
+// GeMixedSub. Cost: 1I + 4M.
+func (p *ExtendedGroupElement) ToPreComputed(r *PreComputedGroupElement) {
+	var recip, x, y FieldElement
+
+	FeInvert(&recip, &p.Z)
+	FeMul(&x, &p.X, &recip)
+	FeMul(&y, &p.Y, &recip)
+	FeAdd(&r.yPlusX, &y, &x)
+	FeSub(&r.yMinusX, &y, &x)
+	FeMul(&r.xy2d, &x, &y)
+	FeMul(&r.xy2d, &r.xy2d, &d2)
+}
+
+// ToProjective converts p to projective form by dropping T. Cost: free.
 func (p *ExtendedGroupElement) ToProjective(r *ProjectiveGroupElement) {
 	FeCopy(&r.X, &p.X)
 	FeCopy(&r.Y, &p.Y)
 	FeCopy(&r.Z, &p.Z)
 }
 
+// ToBytes sets s to the canonical encoding of p. Cost: 1I + 2M.
 func (p *ExtendedGroupElement) ToBytes(s *[32]byte) {
 	var recip, x, y FieldElement
 
@@ -715,6 +863,89 @@ func (p *ExtendedGroupElement) ToBytes(s *[32]byte) {
 	s[31] ^= FeIsNegative(&x) << 7
 }
 
+// batchInvert sets out[i] to the inverse of the Z coordinate of points[i],
+// using Montgomery's trick to perform a single field inversion. Cost: 1I +
+// 3(n-1)M.
+func batchInvert(out []FieldElement, points []ExtendedGroupElement) {
+	if len(points) == 0 {
+		return
+	}
+
+	// out[i] = Z_0 * ... * Z_i
+	FeCopy(&out[0], &points[0].Z)
+	for i := 1; i < len(points); i++ {
+		FeMul(&out[i], &out[i-1], &points[i].Z)
+	}
+
+	var inv, t FieldElement
+	FeInvert(&inv, &out[len(points)-1])
+	for i := len(points) - 1; i > 0; i-- {
+		FeMul(&t, &inv, &out[i-1])
+		FeMul(&inv, &inv, &points[i].Z)
+		FeCopy(&out[i], &t)
+	}
+	FeCopy(&out[0], &inv)
+}
+
+// GeBatchToAffine rescales each of points so that Z = 1, making X and Y the
+// affine coordinates, with a single field inversion for the whole batch.
+// Cost: 1I + (3(n-1) + 3n)M.
+func GeBatchToAffine(points []ExtendedGroupElement) {
+	zInv := make([]FieldElement, len(points))
+	batchInvert(zInv, points)
+	for i := range points {
+		p := &points[i]
+		FeMul(&p.X, &p.X, &zInv[i])
+		FeMul(&p.Y, &p.Y, &zInv[i])
+		FeMul(&p.T, &p.X, &p.Y)
+		FeOne(&p.Z)
+	}
+}
+
+// GeBatchToBytes sets out[i] to the encoding of points[i], as ToBytes would,
+// with a single field inversion for the whole batch. It panics if out and
+// points have different lengths. Cost: 1I + (3(n-1) + 2n)M.
+func GeBatchToBytes(out [][32]byte, points []ExtendedGroupElement) {
+	if len(out) != len(points) {
+		panic("edwards25519: mismatched batch lengths")
+	}
+
+	zInv := make([]FieldElement, len(points))
+	batchInvert(zInv, points)
+	var x, y FieldElement
+	for i := range points {
+		FeMul(&x, &points[i].X, &zInv[i])
+		FeMul(&y, &points[i].Y, &zInv[i])
+		FeToBytes(&out[i], &y)
+		out[i][31] ^= FeIsNegative(&x) << 7
+	}
+}
+
+// GeBatchEqual sets out[i] to 1 if a[i] and b[i] are the same point and to 0
+// otherwise. The coordinates are compared crosswise, X1*Z2 = X2*Z1 and
+// Y1*Z2 = Y2*Z1, so unlike comparing encodings it needs no inversion, and
+// it does not allocate. It runs in constant time, and panics if the slices
+// have different lengths. Cost: 4nM.
+func GeBatchEqual(out []int32, a, b []ExtendedGroupElement) {
+	if len(out) != len(a) || len(a) != len(b) {
+		panic("edwards25519: mismatched batch lengths")
+	}
+
+	var t1, t2, dx, dy FieldElement
+	for i := range a {
+		p, q := &a[i], &b[i]
+		FeMul(&t1, &p.X, &q.Z)
+		FeMul(&t2, &q.X, &p.Z)
+		FeSub(&dx, &t1, &t2)
+		FeMul(&t1, &p.Y, &q.Z)
+		FeMul(&t2, &q.Y, &p.Z)
+		FeSub(&dy, &t1, &t2)
+		out[i] = 1 ^ (FeIsNonZero(&dx) | FeIsNonZero(&dy))
+	}
+}
+
+// FromBytes sets p to the point encoded by s and reports whether s encodes a
+// point on the curve.
 func (p *ExtendedGroupElement) FromBytes(s *[32]byte) bool {
 	var u, v, v3, vxx, check FieldElement
 
@@ -761,12 +992,138 @@ func (p *ExtendedGroupElement) FromBytes(s *[32]byte) bool {
 	return true
 }
 
+// Errors returned by FromCanonicalBytes.
+var (
+	ErrNonCanonical = errors.New("edwards25519: non-canonical point encoding")
+	ErrNotOnCurve   = errors.New("edwards25519: encoding of a point not on the curve")
+	ErrLowOrder     = errors.New("edwards25519: point of small order")
+)
+
+// FromCanonicalBytes sets p to the point encoded by s, like FromBytes, but
+// returns an error instead of accepting an encoding that FromBytes would be
+// lenient with. It returns ErrNonCanonical if the y coordinate of s is not
+// fully reduced, or if its sign bit is set while x is zero, and
+// ErrNotOnCurve if s does not encode a point on the curve. If rejectLowOrder
+// is true, it also returns ErrLowOrder for the eight points of order dividing
+// 8, which contribute nothing to cofactored equations. The value of p is
+// unspecified when an error is returned.
+func (p *ExtendedGroupElement) FromCanonicalBytes(s *[32]byte, rejectLowOrder bool) error {
+	if canonical, _ := checkEncoding(s); canonical != 1 {
+		return ErrNonCanonical
+	}
+	if !p.FromBytes(s) {
+		return ErrNotOnCurve
+	}
+	if rejectLowOrder && CofactorEqual(p, NewIdentityPoint()) {
+		return ErrLowOrder
+	}
+	return nil
+}
+
+// ToAffineBytes sets s to the uncompressed encoding of p: the affine x
+// coordinate followed by the affine y coordinate, each as 32 little-endian
+// bytes in canonical form. Cost: 1I + 2M.
+func (p *ExtendedGroupElement) ToAffineBytes(s *[64]byte) {
+	var recip, x, y FieldElement
+	var xBytes, yBytes [32]byte
+
+	FeInvert(&recip, &p.Z)
+	FeMul(&x, &p.X, &recip)
+	FeMul(&y, &p.Y, &recip)
+	FeToBytes(&xBytes, &x)
+	FeToBytes(&yBytes, &y)
+	copy(s[:32], xBytes[:])
+	copy(s[32:], yBytes[:])
+}
+
+// FromAffineBytes sets p to the point with the uncompressed encoding s, as
+// produced by ToAffineBytes, and reports whether s is a canonical encoding of
+// a point on the curve. It runs in constant time.
+func (p *ExtendedGroupElement) FromAffineBytes(s *[64]byte) bool {
+	var xBytes, yBytes, check [32]byte
+	copy(xBytes[:], s[:32])
+	copy(yBytes[:], s[32:])
+
+	FeFromBytes(&p.X, &xBytes)
+	FeFromBytes(&p.Y, &yBytes)
+	FeOne(&p.Z)
+	FeMul(&p.T, &p.X, &p.Y)
+
+	// Each coordinate must be fully reduced, with the top bit clear.
+	FeToBytes(&check, &p.X)
+	canonical := subtle.ConstantTimeCompare(check[:], xBytes[:])
+	FeToBytes(&check, &p.Y)
+	canonical &= subtle.ConstantTimeCompare(check[:], yBytes[:])
+
+	// -x^2 + y^2 = 1 + dx^2y^2
+	var x2, y2, lhs, rhs FieldElement
+	FeSquare(&x2, &p.X)
+	FeSquare(&y2, &p.Y)
+	FeSub(&lhs, &y2, &x2)
+	FeMul(&rhs, &x2, &y2)
+	FeMul(&rhs, &rhs, &d)
+	FeAdd(&rhs, &rhs, &p.Z)
+	FeSub(&lhs, &lhs, &rhs)
+	onCurve := 1 - FeIsNonZero(&lhs)
+
+	return canonical&int(onCurve) == 1
+}
+
+// GeNeg sets r = -p.
+func GeNeg(r, p *ExtendedGroupElement) {
+	FeNeg(&r.X, &p.X)
+	FeCopy(&r.Y, &p.Y)
+	FeCopy(&r.Z, &p.Z)
+	FeNeg(&r.T, &p.T)
+}
+
+// GeNegateBytes sets out to the encoding of -P, where s is the canonical
+// encoding of a point P, without decompressing it. It reports whether s is
+// canonical: its y coordinate must be fully reduced, and the sign bit must
+// be clear when x is zero, that is when y is 1 or -1. Whether s encodes a
+// point on the curve is not checked. For every point P, decoding s, applying
+// GeNeg and then ToBytes produces the same out. It runs in constant time.
+//
+// Negating a point only flips the sign of x, so this is the sign bit of s,
+// except for the two points with x = 0, which are their own negation.
+func GeNegateBytes(out, s *[32]byte) bool {
+	canonical, xIsZero := checkEncoding(s)
+	*out = *s
+	out[31] ^= byte(1-xIsZero) << 7
+	return canonical == 1
+}
+
+// checkEncoding returns 1 as canonical if s is the canonical encoding of a
+// point, assuming it is on the curve, and 1 as xIsZero if the x coordinate of
+// that point is zero. It runs in constant time.
+func checkEncoding(s *[32]byte) (canonical, xIsZero int) {
+	var y, t, one FieldElement
+	var yBytes [32]byte
+	FeFromBytes(&y, s)
+	FeToBytes(&yBytes, &y)
+	sign := s[31] >> 7
+
+	masked := *s
+	masked[31] &= 0x7f
+	yCanonical := subtle.ConstantTimeCompare(yBytes[:], masked[:])
+
+	// x = 0 if and only if y^2 = 1.
+	FeOne(&one)
+	FeSquare(&t, &y)
+	FeSub(&t, &t, &one)
+	xIsZero = 1 - int(FeIsNonZero(&t))
+
+	return yCanonical &^ (xIsZero & int(sign)), xIsZero
+}
+
+// ToProjective converts p to projective form. Cost: 3M.
 func (p *CompletedGroupElement) ToProjective(r *ProjectiveGroupElement) {
 	FeMul(&r.X, &p.X, &p.T)
 	FeMul(&r.Y, &p.Y, &p.Z)
 	FeMul(&r.Z, &p.Z, &p.T)
 }
 
+// ToExtended converts p to extended form. Cost: 4M.
 func (p *CompletedGroupElement) ToExtended(r *ExtendedGroupElement) {
 	FeMul(&r.X, &p.X, &p.T)
 	FeMul(&r.Y, &p.Y, &p.Z)
@@ -774,13 +1131,15 @@ func (p *CompletedGroupElement) ToExtended(r *ExtendedGroupElement) {
 	FeMul(&r.T, &p.X, &p.Y)
 }
 
+// Zero sets p to the identity element.
 func (p *PreComputedGroupElement) Zero() {
 	FeOne(&p.yPlusX)
 	FeOne(&p.yMinusX)
 	FeZero(&p.xy2d)
 }
 
-func geAdd(r *CompletedGroupElement, p *ExtendedGroupElement, q *CachedGroupElement) {
+// GeAdd sets r = p + q. Cost: 4M.
+func GeAdd(r *CompletedGroupElement, p *ExtendedGroupElement, q *CachedGroupElement) {
 	var t0 FieldElement
 
 	FeAdd(&r.X, &p.Y, &p.X)
@@ -796,7 +1155,8 @@ func geAdd(r *CompletedGroupElement, p *ExtendedGroupElement, q *CachedGroupElem
 	FeSub(&r.T, &t0, &r.T)
 }
 
-func geSub(r *CompletedGroupElement, p *ExtendedGroupElement, q *CachedGroupElement) {
+// GeSub sets r = p - q. Cost: 4M.
+func GeSub(r *CompletedGroupElement, p *ExtendedGroupElement, q *CachedGroupElement) {
 	var t0 FieldElement
 
 	FeAdd(&r.X, &p.Y, &p.X)
@@ -812,7 +1172,8 @@ func geSub(r *CompletedGroupElement, p *ExtendedGroupElement, q *CachedGroupElem
 	FeAdd(&r.T, &t0, &r.T)
 }
 
-func geMixedAdd(r *CompletedGroupElement, p *ExtendedGroupElement, q *PreComputedGroupElement) {
+// GeMixedAdd sets r = p + q. Cost: 3M.
+func GeMixedAdd(r *CompletedGroupElement, p *ExtendedGroupElement, q *PreComputedGroupElement) {
 	var t0 FieldElement
 
 	FeAdd(&r.X, &p.Y, &p.X)
@@ -827,7 +1188,8 @@ func geMixedAdd(r *CompletedGroupElement, p *ExtendedGroupElement, q *PreCompute
 	FeSub(&r.T, &t0, &r.T)
 }
 
-func geMixedSub(r *CompletedGroupElement, p *ExtendedGroupElement, q *PreComputedGroupElement) {
+// GeMixedSub sets r = p - q. Cost: 3M.
+func GeMixedSub(r *CompletedGroupElement, p *ExtendedGroupElement, q *PreComputedGroupElement) {
 	var t0 FieldElement
 
 	FeAdd(&r.X, &p.Y, &p.X)
@@ -872,30 +1234,46 @@ func slide(r *[256]int8, a *[32]byte) {
 	}
 }
 
+// A NAFTable holds the odd multiples A, 3A, 5A, ..., 15A of a point A, as
+// used by GeDoubleScalarMultVartime. Computing it once for a point that is
+// used repeatedly saves seven additions per multiplication.
+type NAFTable [8]CachedGroupElement
+
+// FromPoint sets t to the table of A.
+func (t *NAFTable) FromPoint(A *ExtendedGroupElement) {
+	var c CompletedGroupElement
+	var u, A2 ExtendedGroupElement
+	A.ToCached(&t[0])
+	A.Double(&c)
+	c.ToExtended(&A2)
+	for i := 0; i < 7; i++ {
+		GeAdd(&c, &A2, &t[i])
+		c.ToExtended(&u)
+		u.ToCached(&t[i+1])
+	}
+}
+
 // GeDoubleScalarMultVartime sets r = a*A + b*B
 // where a = a[0]+256*a[1]+...+256^31 a[31].
 // and b = b[0]+256*b[1]+...+256^31 b[31].
 // B is the Ed25519 base point (x,4/5) with x positive.
 func GeDoubleScalarMultVartime(r *ProjectiveGroupElement, a *[32]byte, A *ExtendedGroupElement, b *[32]byte) {
+	var Ai NAFTable
+	Ai.FromPoint(A)
+	GeDoubleScalarMultTableVartime(r, a, &Ai, b)
+}
+
+// GeDoubleScalarMultTableVartime is like GeDoubleScalarMultVartime, with the
+// NAFTable of A.
+func GeDoubleScalarMultTableVartime(r *ProjectiveGroupElement, a *[32]byte, Ai *NAFTable, b *[32]byte) {
 	var aSlide, bSlide [256]int8
-	var Ai [8]CachedGroupElement // A,3A,5A,7A,9A,11A,13A,15A
 	var t CompletedGroupElement
-	var u, A2 ExtendedGroupElement
+	var u ExtendedGroupElement
 	var i int
 
 	slide(&aSlide, a)
 	slide(&bSlide, b)
 
-	A.ToCached(&Ai[0])
-	A.Double(&t)
-	t.ToExtended(&A2)
-
-	for i := 0; i < 7; i++ {
-		geAdd(&t, &A2, &Ai[i])
-		t.ToExtended(&u)
-		u.ToCached(&Ai[i+1])
-	}
-
 	r.Zero()
 
 	for i = 255; i >= 0; i-- {
@@ -909,18 +1287,113 @@ func GeDoubleScalarMultVartime(r *ProjectiveGroupElement, a *[32]byte, A *Extend
 
 		if aSlide[i] > 0 {
 			t.ToExtended(&u)
-			geAdd(&t, &u, &Ai[aSlide[i]/2])
+			GeAdd(&t, &u, &Ai[aSlide[i]/2])
 		} else if aSlide[i] < 0 {
 			t.ToExtended(&u)
-			geSub(&t, &u, &Ai[(-aSlide[i])/2])
+			GeSub(&t, &u, &Ai[(-aSlide[i])/2])
 		}
 
 		if bSlide[i] > 0 {
 			t.ToExtended(&u)
-			geMixedAdd(&t, &u, &bi[bSlide[i]/2])
+			GeMixedAdd(&t, &u, &bi[bSlide[i]/2])
 		} else if bSlide[i] < 0 {
 			t.ToExtended(&u)
-			geMixedSub(&t, &u, &bi[(-bSlide[i])/2])
+			GeMixedSub(&t, &u, &bi[(-bSlide[i])/2])
+		}
+
+		t.ToProjective(r)
+	}
+}
+
+// NonAdjacentForm sets r to the width-w non-adjacent form of a, where
+// a = a[0]+256*a[1]+...+256^31 a[31] and a[31] <= 127. Every non-zero digit
+// r[i] is odd and less than 2^(w-1) in absolute value, and is followed by at
+// least w-1 zero digits, so that a = r[0]+2*r[1]+...+2^255 r[255]. w must be
+// between 2 and 8. It is not constant time.
+func NonAdjacentForm(r *[256]int8, a *[32]byte, w uint) {
+	if w < 2 || w > 8 {
+		panic("edwards25519: invalid NAF width")
+	}
+	if a[31] > 127 {
+		panic("edwards25519: scalar too large for NAF")
+	}
+
+	var x [5]uint64
+	for i := 0; i < 4; i++ {
+		x[i] = binary.LittleEndian.Uint64(a[8*i:])
+	}
+
+	width := uint64(1) << w
+	windowMask := width - 1
+
+	*r = [256]int8{}
+	var carry uint64
+	for pos := uint(0); pos < 256; {
+		idx, bit := pos/64, pos%64
+		bits := x[idx] >> bit
+		if bit > 64-w {
+			bits |= x[idx+1] << (64 - bit)
+		}
+		window := carry + bits&windowMask
+
+		if window&1 == 0 {
+			// A carry of one and a window of width-1 sum to width, which
+			// is even and moves on with the carry still pending.
+			pos++
+			continue
+		}
+
+		if window < width/2 {
+			carry = 0
+			r[pos] = int8(window)
+		} else {
+			carry = 1
+			r[pos] = int8(int64(window) - int64(width))
+		}
+		pos += w
+	}
+}
+
+// GeScalarMultVartime sets r = a*A where a = a[0]+256*a[1]+...+256^31 a[31]
+// and a[31] <= 127, using the width-w non-adjacent form of a. A table of
+// 2^(w-2) multiples of A is computed first, so larger values of w trade
+// memory and setup time for fewer additions. w must be between 2 and 8. It
+// is not constant time and must only be used with public scalars.
+func GeScalarMultVartime(r *ProjectiveGroupElement, a *[32]byte, A *ExtendedGroupElement, w uint) {
+	var naf [256]int8
+	NonAdjacentForm(&naf, a, w)
+
+	// Ai holds A, 3A, 5A, ..., (2^(w-1)-1)A.
+	Ai := make([]CachedGroupElement, 1<<(w-2))
+	var t CompletedGroupElement
+	var u, A2 ExtendedGroupElement
+	A.ToCached(&Ai[0])
+	A.Double(&t)
+	t.ToExtended(&A2)
+	for i := 0; i < len(Ai)-1; i++ {
+		GeAdd(&t, &A2, &Ai[i])
+		t.ToExtended(&u)
+		u.ToCached(&Ai[i+1])
+	}
+
+	r.Zero()
+
+	i := 255
+	for ; i >= 0; i-- {
+		if naf[i] != 0 {
+			break
+		}
+	}
+
+	for ; i >= 0; i-- {
+		r.Double(&t)
+
+		if naf[i] > 0 {
+			t.ToExtended(&u)
+			GeAdd(&t, &u, &Ai[naf[i]/2])
+		} else if naf[i] < 0 {
+			t.ToExtended(&u)
+			GeSub(&t, &u, &Ai[(-int(naf[i]))/2])
 		}
 
 		t.ToProjective(r)
@@ -946,14 +1419,20 @@ func PreComputedGroupElementCMove(t, u *PreComputedGroupElement, b int32) {
 	FeCMove(&t.xy2d, &u.xy2d, b)
 }
 
-func selectPoint(t *PreComputedGroupElement, pos int32, b int32) {
+// SelectPoint sets t = b*P, where row holds 1*P to 8*P, as the rows of a
+// FixedBaseTable do. Its running time and memory accesses do not depend on b,
+// so it can be used to build other constant-time algorithms.
+//
+// Preconditions:
+//   -8 <= b <= 8
+func SelectPoint(t *PreComputedGroupElement, row *[8]PreComputedGroupElement, b int32) {
 	var minusT PreComputedGroupElement
 	bNegative := negative(b)
 	bAbs := b - (((-bNegative) & b) << 1)
 
 	t.Zero()
 	for i := int32(0); i < 8; i++ {
-		PreComputedGroupElementCMove(t, &base[pos][i], equal(bAbs, i+1))
+		PreComputedGroupElementCMove(t, &row[i], equal(bAbs, i+1))
 	}
 	FeCopy(&minusT.yPlusX, &t.yMinusX)
 	FeCopy(&minusT.yMinusX, &t.yPlusX)
@@ -961,6 +1440,51 @@ func selectPoint(t *PreComputedGroupElement, pos int32, b int32) {
 	PreComputedGroupElementCMove(t, &minusT, bNegative)
 }
 
+// GeScalarMult computes h = a*p in constant time, where
+//   a = a[0]+256*a[1]+...+256^31 a[31]
+// It builds a table of 1*p to 8*p on each call, so multiplying the same point
+// many times is faster with NewFixedBaseTable.
+//
+// Preconditions:
+//   a[31] <= 127
+func GeScalarMult(h *ExtendedGroupElement, a *[32]byte, p *ExtendedGroupElement) {
+	var points [8]ExtendedGroupElement
+	var r CompletedGroupElement
+	var cached CachedGroupElement
+
+	points[0] = *p
+	p.ToCached(&cached)
+	for j := 1; j < 8; j++ {
+		GeAdd(&r, &points[j-1], &cached)
+		r.ToExtended(&points[j])
+	}
+
+	var zInv [8]FieldElement
+	var row [8]PreComputedGroupElement
+	batchInvert(zInv[:], points[:])
+	for j := range points {
+		var x, y FieldElement
+		FeMul(&x, &points[j].X, &zInv[j])
+		FeMul(&y, &points[j].Y, &zInv[j])
+		FeAdd(&row[j].yPlusX, &y, &x)
+		FeSub(&row[j].yMinusX, &y, &x)
+		FeMul(&row[j].xy2d, &x, &y)
+		FeMul(&row[j].xy2d, &row[j].xy2d, &d2)
+	}
+
+	var e [64]int8
+	SignedRadix16(&e, a)
+
+	var t PreComputedGroupElement
+	h.Zero()
+	for i := 63; i >= 0; i-- {
+		GeMultByPow2(h, h, 4)
+		SelectPoint(&t, &row, int32(e[i]))
+		GeMixedAdd(&r, h, &t)
+		r.ToExtended(h)
+	}
+}
+
 // GeScalarMultBase computes h = a*B, where
 //   a = a[0]+256*a[1]+...+256^31 a[31]
 //   B is the Ed25519 base point (x,4/5) with x positive.
@@ -968,7 +1492,18 @@ func selectPoint(t *PreComputedGroupElement, pos int32, b int32) {
 // Preconditions:
 //   a[31] <= 127
 func GeScalarMultBase(h *ExtendedGroupElement, a *[32]byte) {
-	var e [64]int8
+	geScalarMultTable(h, a, &base)
+}
+
+// SignedRadix16 sets e to the signed radix-16 recoding of a, in constant
+// time, where a = a[0]+256*a[1]+...+256^31 a[31] and a[31] <= 127. Every digit
+// e[i] is between -8 and 8, so that a = e[0]+16*e[1]+...+16^63 e[63]. This is
+// the recoding used with SelectPoint by GeScalarMultBase and
+// FixedBaseTable.ScalarMult.
+func SignedRadix16(e *[64]int8, a *[32]byte) {
+	if a[31] > 127 {
+		panic("edwards25519: scalar too large for signed radix-16 recoding")
+	}
 
 	for i, v := range a {
 		e[2*i] = int8(v & 15)
@@ -985,13 +1520,20 @@ func GeScalarMultBase(h *ExtendedGroupElement, a *[32]byte) {
 	}
 	e[63] += carry
 	// each e[i] is between -8 and 8.
+}
+
+// geScalarMultTable computes h = a*P in constant time, where table[i][j] is
+// (j+1)*256^i*P, as in base.
+func geScalarMultTable(h *ExtendedGroupElement, a *[32]byte, table *[32][8]PreComputedGroupElement) {
+	var e [64]int8
+	SignedRadix16(&e, a)
 
 	h.Zero()
 	var t PreComputedGroupElement
 	var r CompletedGroupElement
 	for i := int32(1); i < 64; i += 2 {
-		selectPoint(&t, i/2, int32(e[i]))
-		geMixedAdd(&r, h, &t)
+		SelectPoint(&t, &table[i/2], int32(e[i]))
+		GeMixedAdd(&r, h, &t)
 		r.ToExtended(h)
 	}
 
@@ -1007,8 +1549,8 @@ func GeScalarMultBase(h *ExtendedGroupElement, a *[32]byte) {
 	r.ToExtended(h)
 
 	for i := int32(0); i < 64; i += 2 {
-		selectPoint(&t, i/2, int32(e[i]))
-		geMixedAdd(&r, h, &t)
+		SelectPoint(&t, &table[i/2], int32(e[i]))
+		GeMixedAdd(&r, h, &t)
 		r.ToExtended(h)
 	}
 }
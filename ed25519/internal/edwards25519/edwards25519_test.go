@@ -0,0 +1,624 @@
+// Copyright 2018 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package edwards25519
+
+import (
+	"bytes"
+	"encoding/hex"
+	"math/big"
+	"math/rand"
+	"testing"
+)
+
+func decodeHex(t *testing.T, s string) []byte {
+	b, err := hex.DecodeString(s)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return b
+}
+
+func TestGeneratorAndIdentity(t *testing.T) {
+	var s [32]byte
+
+	NewIdentityPoint().ToBytes(&s)
+	if want := decodeHex(t, "0100000000000000000000000000000000000000000000000000000000000000"); !bytes.Equal(s[:], want) {
+		t.Errorf("identity encodes to %x, want %x", s, want)
+	}
+
+	NewGeneratorPoint().ToBytes(&s)
+	if want := decodeHex(t, "5866666666666666666666666666666666666666666666666666666666666666"); !bytes.Equal(s[:], want) {
+		t.Errorf("generator encodes to %x, want %x", s, want)
+	}
+
+	var one [32]byte
+	one[0] = 1
+	var B ExtendedGroupElement
+	GeScalarMultBase(&B, &one)
+	var s2 [32]byte
+	B.ToBytes(&s2)
+	if s != s2 {
+		t.Errorf("generator does not match 1*B: %x vs %x", s, s2)
+	}
+
+	// The returned points must not alias package state.
+	g := NewGeneratorPoint()
+	g.Zero()
+	NewGeneratorPoint().ToBytes(&s2)
+	if s != s2 {
+		t.Errorf("modifying a returned generator changed later ones")
+	}
+}
+
+func scalarBaseMultBytes(n byte) [32]byte {
+	var a, s [32]byte
+	a[0] = n
+	var p ExtendedGroupElement
+	GeScalarMultBase(&p, &a)
+	p.ToBytes(&s)
+	return s
+}
+
+func TestRepresentations(t *testing.T) {
+	B := NewGeneratorPoint()
+
+	var bCached CachedGroupElement
+	B.ToCached(&bCached)
+	var bPre PreComputedGroupElement
+	B.ToPreComputed(&bPre)
+
+	// 2B via doubling.
+	var c CompletedGroupElement
+	var p2 ProjectiveGroupElement
+	var p3 ExtendedGroupElement
+	B.Double(&c)
+	c.ToExtended(&p3)
+
+	// 3B via cached addition and 4B via mixed addition.
+	GeAdd(&c, &p3, &bCached)
+	c.ToExtended(&p3)
+	var s [32]byte
+	p3.ToBytes(&s)
+	if want := scalarBaseMultBytes(3); s != want {
+		t.Errorf("2B + B = %x, want %x", s, want)
+	}
+	GeMixedAdd(&c, &p3, &bPre)
+	c.ToProjective(&p2)
+	p2.ToBytes(&s)
+	if want := scalarBaseMultBytes(4); s != want {
+		t.Errorf("3B + B = %x, want %x", s, want)
+	}
+
+	// 5B from the projective form converted back to extended.
+	p2.ToExtended(&p3)
+	GeAdd(&c, &p3, &bCached)
+	c.ToExtended(&p3)
+	p3.ToBytes(&s)
+	if want := scalarBaseMultBytes(5); s != want {
+		t.Errorf("4B + B = %x, want %x", s, want)
+	}
+
+	// 8B via a doubling of the projective form, then back down to 6B.
+	p2.Double(&c)
+	c.ToExtended(&p3)
+	GeSub(&c, &p3, &bCached)
+	c.ToExtended(&p3)
+	GeMixedSub(&c, &p3, &bPre)
+	c.ToExtended(&p3)
+	p3.ToBytes(&s)
+	if want := scalarBaseMultBytes(6); s != want {
+		t.Errorf("8B - B - B = %x, want %x", s, want)
+	}
+}
+
+func TestBatchToBytes(t *testing.T) {
+	points := make([]ExtendedGroupElement, 17)
+	want := make([][32]byte, len(points))
+	var a [32]byte
+	for i := range points {
+		a[0] = byte(3*i + 1)
+		GeScalarMultBase(&points[i], &a)
+		points[i].ToBytes(&want[i])
+	}
+
+	got := make([][32]byte, len(points))
+	GeBatchToBytes(got, points)
+	for i := range got {
+		if got[i] != want[i] {
+			t.Errorf("point %d: got %x, want %x", i, got[i], want[i])
+		}
+	}
+
+	GeBatchToAffine(points)
+	var one FieldElement
+	FeOne(&one)
+	for i := range points {
+		var s [32]byte
+		points[i].ToBytes(&s)
+		if s != want[i] {
+			t.Errorf("affine point %d: got %x, want %x", i, s, want[i])
+		}
+		if points[i].Z != one {
+			t.Errorf("affine point %d: Z is not one", i)
+		}
+	}
+
+	GeBatchToBytes(nil, nil)
+}
+
+func TestSmallOrderPoints(t *testing.T) {
+	// The encodings of the torsion points, in the order of SmallOrderPoints.
+	want := []string{
+		"0100000000000000000000000000000000000000000000000000000000000000",
+		"c7176a703d4dd84fba3c0b760d10670f2a2053fa2c39ccc64ec7fd7792ac037a",
+		"0000000000000000000000000000000000000000000000000000000000000080",
+		"26e8958fc2b227b045c3f489f2ef98f0d5dfac05d3c63339b13802886d53fc05",
+		"ecffffffffffffffffffffffffffffffffffffffffffffffffffffffffffff7f",
+		"26e8958fc2b227b045c3f489f2ef98f0d5dfac05d3c63339b13802886d53fc85",
+		"0000000000000000000000000000000000000000000000000000000000000000",
+		"c7176a703d4dd84fba3c0b760d10670f2a2053fa2c39ccc64ec7fd7792ac03fa",
+	}
+
+	points := SmallOrderPoints()
+	var c CachedGroupElement
+	points[1].ToCached(&c)
+	for i := range points {
+		var s [32]byte
+		points[i].ToBytes(&s)
+		if got := hex.EncodeToString(s[:]); got != want[i] {
+			t.Errorf("point %d encodes to %s, want %s", i, got, want[i])
+		}
+
+		// Each point is the previous one plus points[1].
+		var r CompletedGroupElement
+		var next ExtendedGroupElement
+		GeAdd(&r, &points[i], &c)
+		r.ToExtended(&next)
+		next.ToBytes(&s)
+		if got := hex.EncodeToString(s[:]); got != want[(i+1)%8] {
+			t.Errorf("point %d + point 1 encodes to %s, want %s", i, got, want[(i+1)%8])
+		}
+
+		if !CofactorEqual(&points[i], NewIdentityPoint()) {
+			t.Errorf("point %d is not cofactor-equal to the identity", i)
+		}
+	}
+}
+
+func TestCofactorEqual(t *testing.T) {
+	var a ExtendedGroupElement
+	s := [32]byte{7}
+	GeScalarMultBase(&a, &s)
+
+	points := SmallOrderPoints()
+	for i := range points {
+		var c CachedGroupElement
+		var r CompletedGroupElement
+		var b ExtendedGroupElement
+		points[i].ToCached(&c)
+		GeAdd(&r, &a, &c)
+		r.ToExtended(&b)
+		if !CofactorEqual(&a, &b) || !CofactorEqual(&b, &a) {
+			t.Errorf("7B + T%d is not cofactor-equal to 7B", i)
+		}
+	}
+
+	var b ExtendedGroupElement
+	s[0] = 8
+	GeScalarMultBase(&b, &s)
+	if CofactorEqual(&a, &b) {
+		t.Error("7B is cofactor-equal to 8B")
+	}
+	if CofactorEqual(&a, NewIdentityPoint()) {
+		t.Error("7B is cofactor-equal to the identity")
+	}
+}
+
+func TestIsTorsionFree(t *testing.T) {
+	var a ExtendedGroupElement
+	s := [32]byte{7}
+	GeScalarMultBase(&a, &s)
+	if !a.IsTorsionFree() {
+		t.Error("7B is not torsion free")
+	}
+	if !NewIdentityPoint().IsTorsionFree() {
+		t.Error("the identity is not torsion free")
+	}
+
+	points := SmallOrderPoints()
+	for i := 1; i < len(points); i++ {
+		if points[i].IsTorsionFree() {
+			t.Errorf("T%d is torsion free", i)
+		}
+		var c CachedGroupElement
+		var r CompletedGroupElement
+		var b ExtendedGroupElement
+		points[i].ToCached(&c)
+		GeAdd(&r, &a, &c)
+		r.ToExtended(&b)
+		if b.IsTorsionFree() {
+			t.Errorf("7B + T%d is torsion free", i)
+		}
+	}
+}
+
+func TestAffineBytes(t *testing.T) {
+	for _, n := range []byte{0, 1, 2, 77} {
+		var a [32]byte
+		a[0] = n
+		var p, q ExtendedGroupElement
+		GeScalarMultBase(&p, &a)
+
+		var affine [64]byte
+		p.ToAffineBytes(&affine)
+		if !q.FromAffineBytes(&affine) {
+			t.Fatalf("%d*B: FromAffineBytes rejected %x", n, affine)
+		}
+		var s1, s2 [32]byte
+		p.ToBytes(&s1)
+		q.ToBytes(&s2)
+		if s1 != s2 {
+			t.Errorf("%d*B does not round-trip through the affine encoding", n)
+		}
+		// The y coordinate is the compressed encoding without the sign bit.
+		s1[31] &= 0x7f
+		if !bytes.Equal(affine[32:], s1[:]) {
+			t.Errorf("%d*B: affine y is %x, want %x", n, affine[32:], s1)
+		}
+	}
+
+	// The base point, from RFC 8032, section 5.1.
+	var base [64]byte
+	copy(base[:], decodeHex(t, "1ad5258f602d56c9b2a7259560c72c695cdcd6fd31e2a4c0fe536ecdd3366921"+
+		"5866666666666666666666666666666666666666666666666666666666666666"))
+	var b ExtendedGroupElement
+	if !b.FromAffineBytes(&base) {
+		t.Fatal("FromAffineBytes rejected the base point")
+	}
+	var got, want [32]byte
+	b.ToBytes(&got)
+	NewGeneratorPoint().ToBytes(&want)
+	if got != want {
+		t.Errorf("decoded base point encodes to %x, want %x", got, want)
+	}
+
+	bad := base
+	bad[0] ^= 1
+	if b.FromAffineBytes(&bad) {
+		t.Error("FromAffineBytes accepted a point not on the curve")
+	}
+	// (0, 1) with y encoded as p + 1.
+	bad = [64]byte{}
+	copy(bad[32:], decodeHex(t, "eeffffffffffffffffffffffffffffffffffffffffffffffffffffffffffff7f"))
+	if b.FromAffineBytes(&bad) {
+		t.Error("FromAffineBytes accepted a non-canonical coordinate")
+	}
+	bad = [64]byte{}
+	bad[32] = 1
+	if !b.FromAffineBytes(&bad) {
+		t.Error("FromAffineBytes rejected the identity")
+	}
+	bad[63] |= 0x80
+	if b.FromAffineBytes(&bad) {
+		t.Error("FromAffineBytes accepted a coordinate with the top bit set")
+	}
+}
+
+func TestNonAdjacentForm(t *testing.T) {
+	rng := rand.New(rand.NewSource(1))
+	for i := 0; i < 100; i++ {
+		var a [32]byte
+		rng.Read(a[:])
+		a[31] &= 127
+		if i == 0 {
+			// Many carries.
+			for j := range a {
+				a[j] = 0xff
+			}
+			a[31] = 127
+		}
+		for w := uint(2); w <= 8; w++ {
+			var naf [256]int8
+			NonAdjacentForm(&naf, &a, w)
+
+			sum := new(big.Int)
+			last := -int(w)
+			for j := 255; j >= 0; j-- {
+				sum.Lsh(sum, 1)
+				sum.Add(sum, big.NewInt(int64(naf[j])))
+			}
+			for j, d := range naf {
+				if d == 0 {
+					continue
+				}
+				if d%2 == 0 || int(d) >= 1<<(w-1) || int(d) <= -(1<<(w-1)) {
+					t.Fatalf("w=%d: invalid digit %d at %d", w, d, j)
+				}
+				if j-last < int(w) {
+					t.Fatalf("w=%d: digits at %d and %d are too close", w, last, j)
+				}
+				last = j
+			}
+
+			want := new(big.Int).SetBytes(reverse(a[:]))
+			if sum.Cmp(want) != 0 {
+				t.Fatalf("w=%d: NAF of %x sums to %x", w, want, sum)
+			}
+		}
+	}
+}
+
+func TestSignedRadix16(t *testing.T) {
+	rng := rand.New(rand.NewSource(2))
+	for i := 0; i < 100; i++ {
+		var a [32]byte
+		rng.Read(a[:])
+		a[31] &= 127
+		switch i {
+		case 0:
+			// Every digit rounds up.
+			for j := range a {
+				a[j] = 0x88
+			}
+			a[31] = 0x78
+		case 1:
+			// Many carries.
+			for j := range a {
+				a[j] = 0xff
+			}
+			a[31] = 127
+		}
+
+		var e [64]int8
+		SignedRadix16(&e, &a)
+		sum := new(big.Int)
+		for j := 63; j >= 0; j-- {
+			if e[j] < -8 || e[j] > 8 {
+				t.Fatalf("invalid digit %d at %d", e[j], j)
+			}
+			sum.Lsh(sum, 4)
+			sum.Add(sum, big.NewInt(int64(e[j])))
+		}
+		want := new(big.Int).SetBytes(reverse(a[:]))
+		if sum.Cmp(want) != 0 {
+			t.Fatalf("signed radix-16 recoding of %x sums to %x", want, sum)
+		}
+	}
+}
+
+func reverse(b []byte) []byte {
+	out := make([]byte, len(b))
+	for i := range b {
+		out[len(b)-1-i] = b[i]
+	}
+	return out
+}
+
+func TestGeScalarMultVartime(t *testing.T) {
+	rng := rand.New(rand.NewSource(1))
+	for i := 0; i < 10; i++ {
+		var a [32]byte
+		rng.Read(a[:])
+		a[31] &= 127
+
+		var want ExtendedGroupElement
+		GeScalarMultBase(&want, &a)
+		var wantBytes [32]byte
+		want.ToBytes(&wantBytes)
+
+		for w := uint(2); w <= 8; w++ {
+			var r ProjectiveGroupElement
+			GeScalarMultVartime(&r, &a, NewGeneratorPoint(), w)
+			var got [32]byte
+			r.ToBytes(&got)
+			if got != wantBytes {
+				t.Errorf("w=%d: %x*B = %x, want %x", w, a, got, wantBytes)
+			}
+		}
+	}
+}
+
+func TestGeScalarMult(t *testing.T) {
+	rng := rand.New(rand.NewSource(1))
+	// Multiply a point other than B, so that the table is not the same.
+	var p ExtendedGroupElement
+	GeScalarMultBase(&p, &[32]byte{7})
+	for i := 0; i < 20; i++ {
+		var a [32]byte
+		rng.Read(a[:])
+		a[31] &= 127
+		if i == 0 {
+			a = [32]byte{}
+		}
+
+		var want ProjectiveGroupElement
+		GeScalarMultVartime(&want, &a, &p, 5)
+		var wantBytes [32]byte
+		want.ToBytes(&wantBytes)
+
+		var got ExtendedGroupElement
+		GeScalarMult(&got, &a, &p)
+		var gotBytes [32]byte
+		got.ToBytes(&gotBytes)
+		if gotBytes != wantBytes {
+			t.Errorf("%x*P = %x, want %x", a, gotBytes, wantBytes)
+		}
+	}
+}
+
+func TestGeNegateBytes(t *testing.T) {
+	check := func(p *ExtendedGroupElement) {
+		var s, want, got [32]byte
+		var neg ExtendedGroupElement
+		p.ToBytes(&s)
+		GeNeg(&neg, p)
+		neg.ToBytes(&want)
+		if !GeNegateBytes(&got, &s) {
+			t.Errorf("GeNegateBytes rejected %x", s)
+		}
+		if got != want {
+			t.Errorf("GeNegateBytes(%x) = %x, want %x", s, got, want)
+		}
+	}
+
+	rng := rand.New(rand.NewSource(1))
+	for i := 0; i < 20; i++ {
+		var a [32]byte
+		rng.Read(a[:])
+		a[31] &= 127
+		var p ExtendedGroupElement
+		GeScalarMultBase(&p, &a)
+		check(&p)
+	}
+	points := SmallOrderPoints()
+	for i := range points {
+		check(&points[i])
+	}
+
+	var out [32]byte
+	for _, s := range []string{
+		// y = p
+		"edffffffffffffffffffffffffffffffffffffffffffffffffffffffffffff7f",
+		// y = p + 1, with the sign bit set
+		"eeffffffffffffffffffffffffffffffffffffffffffffffffffffffffffffff",
+		// (-0, 1) and (-0, -1)
+		"0100000000000000000000000000000000000000000000000000000000000080",
+		"ecffffffffffffffffffffffffffffffffffffffffffffffffffffffffffffff",
+	} {
+		var in [32]byte
+		copy(in[:], decodeHex(t, s))
+		if GeNegateBytes(&out, &in) {
+			t.Errorf("GeNegateBytes accepted non-canonical %s", s)
+		}
+	}
+}
+
+func TestGeMultByPow2(t *testing.T) {
+	rng := rand.New(rand.NewSource(1))
+	var a [32]byte
+	rng.Read(a[:])
+	a[31] &= 127
+	var p ExtendedGroupElement
+	GeScalarMultBase(&p, &a)
+
+	// Compare against doubling through the extended representation.
+	var want ExtendedGroupElement
+	var c CompletedGroupElement
+	want = p
+	for k := 0; k < 20; k++ {
+		var r ExtendedGroupElement
+		GeMultByPow2(&r, &p, k)
+		var got, wantBytes [32]byte
+		r.ToBytes(&got)
+		want.ToBytes(&wantBytes)
+		if got != wantBytes {
+			t.Errorf("2^%d*P = %x, want %x", k, got, wantBytes)
+		}
+		want.Double(&c)
+		c.ToExtended(&want)
+	}
+
+	// r and p may alias.
+	var s, wantBytes [32]byte
+	r := p
+	GeMultByPow2(&r, &r, 5)
+	GeMultByPow2(&want, &p, 5)
+	r.ToBytes(&s)
+	want.ToBytes(&wantBytes)
+	if s != wantBytes {
+		t.Errorf("aliased 2^5*P = %x, want %x", s, wantBytes)
+	}
+
+	// Multiplying by the cofactor clears the torsion component.
+	var identity [32]byte
+	NewIdentityPoint().ToBytes(&identity)
+	points := SmallOrderPoints()
+	for i := range points {
+		GeMultByPow2(&r, &points[i], 3)
+		r.ToBytes(&s)
+		if s != identity {
+			t.Errorf("8*T[%d] = %x, want the identity", i, s)
+		}
+	}
+}
+
+func TestFromCanonicalBytes(t *testing.T) {
+	var s [32]byte
+	var p ExtendedGroupElement
+	NewGeneratorPoint().ToBytes(&s)
+	if err := p.FromCanonicalBytes(&s, true); err != nil {
+		t.Errorf("base point rejected: %v", err)
+	}
+
+	points := SmallOrderPoints()
+	for i := range points {
+		points[i].ToBytes(&s)
+		if err := p.FromCanonicalBytes(&s, false); err != nil {
+			t.Errorf("small order point %d rejected: %v", i, err)
+		}
+		if err := p.FromCanonicalBytes(&s, true); err != ErrLowOrder {
+			t.Errorf("small order point %d: got %v, want %v", i, err, ErrLowOrder)
+		}
+	}
+
+	for _, tt := range []struct {
+		s   string
+		err error
+	}{
+		// y = p + 1, which FromBytes decodes as the identity
+		{"eeffffffffffffffffffffffffffffffffffffffffffffffffffffffffffff7f", ErrNonCanonical},
+		// (-0, 1)
+		{"0100000000000000000000000000000000000000000000000000000000000080", ErrNonCanonical},
+		// y = 2
+		{"0200000000000000000000000000000000000000000000000000000000000000", ErrNotOnCurve},
+	} {
+		copy(s[:], decodeHex(t, tt.s))
+		if err := p.FromCanonicalBytes(&s, false); err != tt.err {
+			t.Errorf("FromCanonicalBytes(%s) = %v, want %v", tt.s, err, tt.err)
+		}
+	}
+}
+
+func TestGeBatchEqual(t *testing.T) {
+	rng := rand.New(rand.NewSource(1))
+	const n = 8
+	a := make([]ExtendedGroupElement, n)
+	b := make([]ExtendedGroupElement, n)
+	want := make([]int32, n)
+	for i := range a {
+		var k [32]byte
+		rng.Read(k[:])
+		k[31] &= 127
+		GeScalarMultBase(&a[i], &k)
+
+		// b[i] is either the same point with other coordinates, through a
+		// round trip via completed form, or a different one.
+		if i%2 == 0 {
+			var r CompletedGroupElement
+			var c CachedGroupElement
+			a[i].Double(&r)
+			r.ToExtended(&b[i])
+			a[i].ToCached(&c)
+			GeSub(&r, &b[i], &c)
+			r.ToExtended(&b[i])
+			want[i] = 1
+		} else {
+			k[0] ^= 1
+			GeScalarMultBase(&b[i], &k)
+		}
+	}
+
+	got := make([]int32, n)
+	GeBatchEqual(got, a, b)
+	for i := range got {
+		if got[i] != want[i] {
+			t.Errorf("pair %d: got %d, want %d", i, got[i], want[i])
+		}
+	}
+
+	if allocs := testing.AllocsPerRun(10, func() { GeBatchEqual(got, a, b) }); allocs != 0 {
+		t.Errorf("GeBatchEqual allocates %v times", allocs)
+	}
+}
@@ -0,0 +1,121 @@
+// Copyright 2018 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package edwards25519
+
+// The field arithmetic in fiat_curve25519.go is translated by hand from the
+// C code of Fiat Cryptography, which is proven correct by construction. It
+// uses the same ten limbs of 26 and 25 bits as the ref10 code, but they are
+// unsigned and carried after every operation, while those of ref10 can be
+// negative and are only carried by multiplications. The functions below implement the Fe API on top of it,
+// on FieldElements that hold the tight limbs of Fiat Cryptography, and are
+// used instead of the ref10 ones when building with the fiat build tag.
+//
+// The limbs of ref10 are not valid Fiat Cryptography limbs, so FieldElements
+// must not be mixed between the two, except through feFromGeneric. Both are
+// always compiled, so that they can be tested against each other.
+
+func feLoadFiat(out *fiat25519TightFieldElement, f *FieldElement) {
+	for i := range f {
+		out[i] = uint32(f[i])
+	}
+}
+
+func feStoreFiat(h *FieldElement, in *fiat25519TightFieldElement) {
+	for i := range in {
+		h[i] = int32(in[i])
+	}
+}
+
+// feCarryStoreFiat sets h to the carried value of the loose element in, so
+// that all FieldElements are tight, as the inputs of Add and Sub must be.
+func feCarryStoreFiat(h *FieldElement, in *fiat25519LooseFieldElement) {
+	var t fiat25519TightFieldElement
+	fiat25519Carry(&t, in)
+	feStoreFiat(h, &t)
+}
+
+func feFromBytesFiat(dst *FieldElement, src *[32]byte) {
+	// Like the ref10 code, ignore the top bit, which is outside of the
+	// input bounds of fiat25519FromBytes.
+	b := *src
+	b[31] &= 0x7f
+	var t fiat25519TightFieldElement
+	fiat25519FromBytes(&t, &b)
+	feStoreFiat(dst, &t)
+}
+
+func feToBytesFiat(s *[32]byte, h *FieldElement) {
+	var t fiat25519TightFieldElement
+	feLoadFiat(&t, h)
+	fiat25519ToBytes(s, &t)
+}
+
+func feAddFiat(dst, a, b *FieldElement) {
+	var x, y fiat25519TightFieldElement
+	var z fiat25519LooseFieldElement
+	feLoadFiat(&x, a)
+	feLoadFiat(&y, b)
+	fiat25519Add(&z, &x, &y)
+	feCarryStoreFiat(dst, &z)
+}
+
+func feSubFiat(dst, a, b *FieldElement) {
+	var x, y fiat25519TightFieldElement
+	var z fiat25519LooseFieldElement
+	feLoadFiat(&x, a)
+	feLoadFiat(&y, b)
+	fiat25519Sub(&z, &x, &y)
+	feCarryStoreFiat(dst, &z)
+}
+
+func feNegFiat(h, f *FieldElement) {
+	var x fiat25519TightFieldElement
+	var z fiat25519LooseFieldElement
+	feLoadFiat(&x, f)
+	fiat25519Opp(&z, &x)
+	feCarryStoreFiat(h, &z)
+}
+
+// Tight elements are within the loose bounds, so they are passed as loose
+// elements to the functions that accept them.
+
+func feMulFiat(h, f, g *FieldElement) {
+	var x, y, z fiat25519TightFieldElement
+	feLoadFiat(&x, f)
+	feLoadFiat(&y, g)
+	fiat25519CarryMul(&z, (*fiat25519LooseFieldElement)(&x), (*fiat25519LooseFieldElement)(&y))
+	feStoreFiat(h, &z)
+}
+
+func feSquareFiat(h, f *FieldElement) {
+	var x, z fiat25519TightFieldElement
+	feLoadFiat(&x, f)
+	fiat25519CarrySquare(&z, (*fiat25519LooseFieldElement)(&x))
+	feStoreFiat(h, &z)
+}
+
+func feSquare2Fiat(h, f *FieldElement) {
+	var x, sq fiat25519TightFieldElement
+	var z fiat25519LooseFieldElement
+	feLoadFiat(&x, f)
+	fiat25519CarrySquare(&sq, (*fiat25519LooseFieldElement)(&x))
+	fiat25519Add(&z, &sq, &sq)
+	feCarryStoreFiat(h, &z)
+}
+
+func feMul121666Fiat(h, f *FieldElement) {
+	var x, z fiat25519TightFieldElement
+	feLoadFiat(&x, f)
+	fiat25519CarryScmul121666(&z, (*fiat25519LooseFieldElement)(&x))
+	feStoreFiat(h, &z)
+}
+
+// feFromGeneric converts f from the ref10 limbs to the Fiat Cryptography
+// limbs, through its encoding.
+func feFromGeneric(f *FieldElement) {
+	var b [32]byte
+	feToBytesGeneric(&b, f)
+	feFromBytesFiat(f, &b)
+}
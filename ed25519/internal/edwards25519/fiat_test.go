@@ -0,0 +1,144 @@
+// Copyright 2018 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package edwards25519
+
+import (
+	"math/big"
+	"math/rand"
+	"testing"
+)
+
+// fiatOps holds the operations of both backends, so that the Fiat
+// Cryptography ones are tested whichever the build uses.
+var fiatOps = []struct {
+	name          string
+	generic, fiat func(h, f, g *FieldElement)
+}{
+	{"Add", feAddGeneric, feAddFiat},
+	{"Sub", feSubGeneric, feSubFiat},
+	{"Mul", feMulGeneric, feMulFiat},
+	{"Neg", func(h, f, _ *FieldElement) { feNegGeneric(h, f) }, func(h, f, _ *FieldElement) { feNegFiat(h, f) }},
+	{"Square", func(h, f, _ *FieldElement) { feSquareGeneric(h, f) }, func(h, f, _ *FieldElement) { feSquareFiat(h, f) }},
+	{"Square2", func(h, f, _ *FieldElement) { feSquare2Generic(h, f) }, func(h, f, _ *FieldElement) { feSquare2Fiat(h, f) }},
+	{"Mul121666", func(h, f, _ *FieldElement) { feMul121666Generic(h, f) }, func(h, f, _ *FieldElement) { feMul121666Fiat(h, f) }},
+}
+
+// randomFieldEncoding returns random bytes, some of which are non-canonical
+// encodings or have the top bit set.
+func randomFieldEncoding(rng *rand.Rand) [32]byte {
+	var b [32]byte
+	switch rng.Intn(4) {
+	case 0:
+		// p + k, or 2^255 - 1 for k = 18.
+		b[0] = 0xed + byte(rng.Intn(19))
+		for i := 1; i < 31; i++ {
+			b[i] = 0xff
+		}
+		b[31] = 0x7f
+	case 1:
+		b[0] = byte(rng.Intn(3))
+	default:
+		rng.Read(b[:])
+	}
+	if rng.Intn(2) == 0 {
+		b[31] |= 0x80
+	}
+	return b
+}
+
+func TestFiatFromToBytes(t *testing.T) {
+	rng := rand.New(rand.NewSource(1))
+	for i := 0; i < 1000; i++ {
+		in := randomFieldEncoding(rng)
+		var f, g FieldElement
+		var want, got [32]byte
+		feFromBytesGeneric(&f, &in)
+		feToBytesGeneric(&want, &f)
+		feFromBytesFiat(&g, &in)
+		feToBytesFiat(&got, &g)
+		if got != want {
+			t.Fatalf("%x: got %x, want %x", in, got, want)
+		}
+	}
+}
+
+func TestFiatOps(t *testing.T) {
+	rng := rand.New(rand.NewSource(1))
+	for _, op := range fiatOps {
+		for i := 0; i < 1000; i++ {
+			a, b := randomFieldEncoding(rng), randomFieldEncoding(rng)
+			var fg, gg, hg, ff, gf, hf FieldElement
+			feFromBytesGeneric(&fg, &a)
+			feFromBytesGeneric(&gg, &b)
+			feFromBytesFiat(&ff, &a)
+			feFromBytesFiat(&gf, &b)
+			op.generic(&hg, &fg, &gg)
+			op.fiat(&hf, &ff, &gf)
+
+			var want, got [32]byte
+			feToBytesGeneric(&want, &hg)
+			feToBytesFiat(&got, &hf)
+			if got != want {
+				t.Fatalf("%s(%x, %x): got %x, want %x", op.name, a, b, got, want)
+			}
+		}
+	}
+}
+
+// TestFiatChain applies long random sequences of operations, so that the
+// outputs of each operation are fed to all others.
+func TestFiatChain(t *testing.T) {
+	rng := rand.New(rand.NewSource(2))
+	var generic, fiat [4]FieldElement
+	for i := range generic {
+		b := randomFieldEncoding(rng)
+		feFromBytesGeneric(&generic[i], &b)
+		feFromBytesFiat(&fiat[i], &b)
+	}
+	for i := 0; i < 10000; i++ {
+		op := fiatOps[rng.Intn(len(fiatOps))]
+		h, f, g := rng.Intn(4), rng.Intn(4), rng.Intn(4)
+		op.generic(&generic[h], &generic[f], &generic[g])
+		op.fiat(&fiat[h], &fiat[f], &fiat[g])
+
+		var want, got [32]byte
+		feToBytesGeneric(&want, &generic[h])
+		feToBytesFiat(&got, &fiat[h])
+		if got != want {
+			t.Fatalf("step %d, %s: got %x, want %x", i, op.name, got, want)
+		}
+	}
+}
+
+// TestFieldConstants checks the constants in the representation of the
+// backend in use, which for Fiat Cryptography are converted by init.
+func TestFieldConstants(t *testing.T) {
+	p := new(big.Int).Sub(new(big.Int).Lsh(big.NewInt(1), 255), big.NewInt(19))
+	bigD := new(big.Int).ModInverse(big.NewInt(121666), p)
+	bigD.Mul(bigD, big.NewInt(-121665)).Mod(bigD, p)
+	sqrtM1 := new(big.Int).Rsh(new(big.Int).Sub(p, big.NewInt(1)), 2)
+	sqrtM1.Exp(big.NewInt(2), sqrtM1, p)
+	invSqrtAMinusDBig, _ := new(big.Int).SetString("54469307008909316920995813868745141605393597292927456921205312896311721017578", 10)
+	sqrtMinusAPlus2Big, _ := new(big.Int).SetString("6853475219497561581579357271197624642482790079785650197046958215289687604742", 10)
+
+	for _, tt := range []struct {
+		name string
+		f    *FieldElement
+		want *big.Int
+	}{
+		{"d", &d, bigD},
+		{"d2", &d2, new(big.Int).Mod(new(big.Int).Lsh(bigD, 1), p)},
+		{"SqrtM1", &SqrtM1, sqrtM1},
+		{"A", &A, big.NewInt(486662)},
+		{"invSqrtAMinusD", &invSqrtAMinusD, invSqrtAMinusDBig},
+		{"sqrtMinusAPlus2", &sqrtMinusAPlus2, sqrtMinusAPlus2Big},
+	} {
+		var b [32]byte
+		FeToBytes(&b, tt.f)
+		if got := new(big.Int).SetBytes(reverse(b[:])); got.Cmp(tt.want) != 0 {
+			t.Errorf("%s = %v, want %v", tt.name, got, tt.want)
+		}
+	}
+}
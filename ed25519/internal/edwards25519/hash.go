@@ -0,0 +1,150 @@
+// Copyright 2018 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package edwards25519
+
+import (
+	"crypto/sha512"
+
+	"golang.org/x/crypto/hashtofield"
+)
+
+// hashToScalarLength is the number of uniform bytes reduced into a scalar by
+// HashToScalar, L = ceil((ceil(log2(l)) + k) / 8) for k = 128 in RFC 9380,
+// Section 5. Reducing 384 bits modulo the 253-bit l leaves a bias of about
+// 2^-131.
+const hashToScalarLength = 48
+
+// HashToScalar hashes msg to a scalar with the domain separation tag dst, as
+// hash_to_field does for the scalar field in RFC 9380, Section 5.2: it
+// expands msg to 48 bytes with expand_message_xmd and SHA-512, and reduces
+// them, as a big-endian integer, modulo l. The result is indistinguishable
+// from a uniformly random scalar, so it is suitable for OPRF and VRF inputs
+// and for Schnorr challenges.
+//
+// dst should be unique to the protocol and its use of HashToScalar, and must
+// not be empty. Tags longer than 255 bytes are first hashed, as Section 5.3.3
+// specifies. It runs in time that depends only on the lengths of msg and dst.
+func HashToScalar(msg, dst []byte) *Scalar {
+	uniform, err := hashtofield.ExpandMessageXMD(sha512.New, msg, dst, hashToScalarLength)
+	if err != nil {
+		panic("edwards25519: " + err.Error())
+	}
+	var wide [64]byte
+	for i, v := range uniform {
+		wide[len(uniform)-1-i] = v
+	}
+	s := new(Scalar)
+	ScReduce(&s.s, &wide)
+	return s
+}
+
+// hashToFieldLength is the number of uniform bytes reduced into a field
+// element by EncodeToCurve, computed as for hashToScalarLength.
+const hashToFieldLength = 48
+
+// EncodeToCurve hashes msg to a point of the prime-order subgroup with the
+// domain separation tag dst, as the edwards25519_XMD:SHA-512_ELL2_NU_ suite
+// of RFC 9380 does: it hashes msg to a field element with hash_to_field,
+// maps it to the curve with Elligator 2 and clears the cofactor. The result
+// is not a uniformly random point, so it is only suitable for protocols that
+// specify this nonuniform encoding, such as the ELL2 suite of ECVRF.
+//
+// dst should be unique to the protocol and its use of EncodeToCurve, and must
+// not be empty. It runs in time that depends only on the lengths of msg and
+// dst.
+func EncodeToCurve(p *ExtendedGroupElement, msg, dst []byte) {
+	b, err := hashtofield.ExpandMessageXMD(sha512.New, msg, dst, hashToFieldLength)
+	if err != nil {
+		panic("edwards25519: " + err.Error())
+	}
+	var uniform [hashToFieldLength]byte
+	copy(uniform[:], b)
+	var u FieldElement
+	feFromHashBytes(&u, &uniform)
+	var q ExtendedGroupElement
+	mapToCurveElligator2(&q, &u)
+	GeMultByPow2(p, &q, 3)
+}
+
+// feFromHashBytes sets dst to b, a 48-byte big-endian integer, modulo p.
+func feFromHashBytes(dst *FieldElement, b *[hashToFieldLength]byte) {
+	// b = hi*2^256 + top*2^255 + lo, with 2^256 = 38 and 2^255 = 19 mod p.
+	var lo, hi [32]byte
+	for i := range lo {
+		lo[i] = b[len(b)-1-i]
+	}
+	for i := 0; i < len(b)-32; i++ {
+		hi[i] = b[len(b)-33-i]
+	}
+	top := int32(lo[31] >> 7)
+	lo[31] &= 127
+
+	var l, h, c FieldElement
+	FeFromBytes(&l, &lo)
+	FeFromBytes(&h, &hi)
+	c[0] = 38
+	FeMul(&h, &h, &c)
+	c[0] = 19 * top
+	FeAdd(&h, &h, &c)
+	FeAdd(dst, &l, &h)
+}
+
+// mapToCurveElligator2 sets p to the image of u by the map_to_curve function
+// of RFC 9380 for edwards25519: Elligator 2 onto curve25519, with Z = 2,
+// followed by the birational map to edwards25519. It runs in constant time.
+func mapToCurveElligator2(p *ExtendedGroupElement, u *FieldElement) {
+	var one, negA, t FieldElement
+	FeOne(&one)
+	FeNeg(&negA, &A)
+
+	// x1 = -A / (1 + 2u^2), or -A if the denominator is zero, which it
+	// never is, since -1/2 is not a square.
+	var x1, x2, gx1, gx2 FieldElement
+	FeSquare2(&t, u)
+	FeAdd(&t, &t, &one)
+	FeInvert(&t, &t)
+	FeMul(&x1, &negA, &t)
+	FeCMove(&x1, &negA, 1^FeIsNonZero(&x1))
+	// x2 = -x1 - A
+	FeSub(&x2, &negA, &x1)
+	// g(x) = x^3 + Ax^2 + x = x((x + A)x + 1)
+	for _, g := range []struct{ gx, x *FieldElement }{{&gx1, &x1}, {&gx2, &x2}} {
+		FeAdd(&t, g.x, &A)
+		FeMul(&t, &t, g.x)
+		FeAdd(&t, &t, &one)
+		FeMul(g.gx, &t, g.x)
+	}
+
+	// If g(x1) is a square, (x1, y) with y negative, otherwise (x2, y) with y
+	// non-negative. The roots returned by feSqrtRatio are non-negative.
+	var y1, y2, s, y FieldElement
+	isSquare := feSqrtRatio(&y1, &gx1, &one)
+	feSqrtRatio(&y2, &gx2, &one)
+	FeNeg(&y1, &y1)
+	FeCopy(&s, &x2)
+	FeCMove(&s, &x1, isSquare)
+	FeCopy(&y, &y2)
+	FeCMove(&y, &y1, isSquare)
+
+	// (x, y) = (sqrt(-(A+2)) s/t, (s-1)/(s+1)), or the identity if either
+	// denominator is zero.
+	var xn, xd, yn, yd FieldElement
+	FeMul(&xn, &sqrtMinusAPlus2, &s)
+	FeCopy(&xd, &y)
+	FeSub(&yn, &s, &one)
+	FeAdd(&yd, &s, &one)
+	var inv FieldElement
+	FeMul(&inv, &xd, &yd)
+	FeInvert(&inv, &inv)
+	isIdentity := 1 ^ FeIsNonZero(&inv)
+
+	FeMul(&p.X, &xn, &yd)
+	FeMul(&p.X, &p.X, &inv)
+	FeMul(&p.Y, &yn, &xd)
+	FeMul(&p.Y, &p.Y, &inv)
+	FeCMove(&p.Y, &one, isIdentity)
+	FeOne(&p.Z)
+	FeMul(&p.T, &p.X, &p.Y)
+}
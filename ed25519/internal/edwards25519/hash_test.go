@@ -0,0 +1,110 @@
+// Copyright 2018 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package edwards25519
+
+import (
+	"crypto/sha512"
+	"encoding/hex"
+	"math/big"
+	"strings"
+	"testing"
+
+	"golang.org/x/crypto/hashtofield"
+)
+
+func TestHashToScalar(t *testing.T) {
+	msg, dst := []byte("abc"), []byte("QUUX-V01-CS02-with-expander-SHA512-256")
+	s := HashToScalar(msg, dst)
+
+	// hash_to_field interprets the uniform bytes as a big-endian integer.
+	uniform, err := hashtofield.ExpandMessageXMD(sha512.New, msg, dst, 48)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := new(big.Int).SetBytes(uniform)
+	want.Mod(want, bigL)
+	if got := s.BigInt(); got.Cmp(want) != 0 {
+		t.Errorf("HashToScalar = %v, want %v", got, want)
+	}
+
+	if HashToScalar(msg, []byte("another DST")).Equal(s) == 1 {
+		t.Errorf("HashToScalar ignores the domain separation tag")
+	}
+	if HashToScalar([]byte("abd"), dst).Equal(s) == 1 {
+		t.Errorf("HashToScalar ignores the message")
+	}
+
+	// Tags longer than 255 bytes are replaced by their hash.
+	longDST := []byte(strings.Repeat("QUUX-V01-CS02-with-expander-SHA512-256", 8))
+	h := sha512.New()
+	h.Write([]byte("H2C-OVERSIZE-DST-"))
+	h.Write(longDST)
+	if HashToScalar(msg, longDST).Equal(HashToScalar(msg, h.Sum(nil))) != 1 {
+		t.Errorf("long DST was not hashed")
+	}
+}
+
+// encodeToCurveTests are from RFC 9380, Appendix J.5.2, with the points
+// encoded as in RFC 8032.
+var encodeToCurveTests = []struct {
+	msg   string
+	point string
+}{
+	{"", "9b0f7f682dabce2190b14e21a175f39eb6a6b29fff2a9f5e72d5a4044d312e22"},
+	{"abc", "42fa27c8f5a1ae0aa38bb59d5938e5145622ba5dedd11d11736fa2f9502d7367"},
+	{"abcdef0123456789", "fb861a8e0a5a954a5c6836d379f1b07775134a6adaca0939e7dd1add246c8aaf"},
+	{"q128_" + strings.Repeat("q", 128), "5034607af591cadcb883b05846079a27c2b46c29f474078b12baebf56efff6aa"},
+	{"a512_" + strings.Repeat("a", 512), "371a8945427accbf317cc92c1607d3cd62325fb34134d391f28fb19ed3c390ac"},
+}
+
+func TestEncodeToCurve(t *testing.T) {
+	dst := []byte("QUUX-V01-CS02-with-edwards25519_XMD:SHA-512_ELL2_NU_")
+	for _, test := range encodeToCurveTests {
+		var p ExtendedGroupElement
+		EncodeToCurve(&p, []byte(test.msg), dst)
+		var s [32]byte
+		p.ToBytes(&s)
+		if got := hex.EncodeToString(s[:]); got != test.point {
+			t.Errorf("encode_to_curve(%.8q) = %s, want %s", test.msg, got, test.point)
+		}
+		if !p.IsTorsionFree() {
+			t.Errorf("encode_to_curve(%.8q) is not in the prime-order subgroup", test.msg)
+		}
+	}
+}
+
+func TestFeFromHashBytes(t *testing.T) {
+	p, _ := new(big.Int).SetString("57896044618658097711785492504343953926634992332820282019728792003956564819949", 10)
+	for _, b := range [][hashToFieldLength]byte{
+		{},
+		{47: 1},
+		{0: 0xff, 1: 0xff, 2: 0xff, 3: 0xff, 4: 0xff, 5: 0xff, 6: 0xff, 7: 0xff, 8: 0xff, 9: 0xff, 10: 0xff, 11: 0xff, 12: 0xff, 13: 0xff, 14: 0xff, 15: 0xff, 16: 0x80},
+		sha512Prefix48("edwards25519"),
+		allOnes48(),
+	} {
+		var f FieldElement
+		feFromHashBytes(&f, &b)
+		var s [32]byte
+		FeToBytes(&s, &f)
+		want := new(big.Int).SetBytes(b[:])
+		want.Mod(want, p)
+		if got := new(big.Int).SetBytes(reverse(s[:])); got.Cmp(want) != 0 {
+			t.Errorf("%x mod p = %v, want %v", b, got, want)
+		}
+	}
+}
+
+func allOnes48() (b [hashToFieldLength]byte) {
+	for i := range b {
+		b[i] = 0xff
+	}
+	return b
+}
+
+func sha512Prefix48(s string) (b [hashToFieldLength]byte) {
+	h := sha512.Sum512([]byte(s))
+	copy(b[:], h[:])
+	return b
+}
@@ -0,0 +1,105 @@
+// Copyright 2018 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package edwards25519
+
+import "sync"
+
+// A Term is one scalar and point pair of a linear combination. The scalar is
+// s[0]+256*s[1]+...+256^31*s[31] and must have s[31] <= 127.
+type Term struct {
+	Scalar [32]byte
+	Point  ExtendedGroupElement
+}
+
+// minShardSize is the smallest number of terms worth handing to a goroutine.
+// Below it, the cost of the final additions and of scheduling outweighs the
+// work saved.
+const minShardSize = 64
+
+// LinearCombination computes sums of scalar multiples of points, optionally
+// splitting the terms into shards computed by separate goroutines, whose
+// partial sums are then added together. Each shard uses the bucket method of
+// Pippenger.
+//
+// A LinearCombination keeps its scratch space between calls. It must not be
+// used concurrently. The zero value is ready to use.
+type LinearCombination struct {
+	// Shards is the maximum number of goroutines across which the terms are
+	// split. Values less than 2 mean all the work is done by the calling
+	// goroutine. Fewer shards are used if there are too few terms.
+	Shards int
+
+	scalars [][32]byte
+	points  []ExtendedGroupElement
+	pp      []Pippenger
+	partial []ExtendedGroupElement
+}
+
+// ComputeVartime sets r = terms[0].Scalar*terms[0].Point + ... +
+// terms[n-1].Scalar*terms[n-1].Point. It is not constant time and must only
+// be used with public scalars.
+func (lc *LinearCombination) ComputeVartime(r *ExtendedGroupElement, terms []Term) {
+	n := len(terms)
+	if cap(lc.scalars) < n {
+		lc.scalars = make([][32]byte, n)
+		lc.points = make([]ExtendedGroupElement, n)
+	}
+	lc.scalars, lc.points = lc.scalars[:n], lc.points[:n]
+	for i := range terms {
+		// Check the scalars here, as a panic in a shard could not be
+		// recovered by the caller.
+		if terms[i].Scalar[31] > 127 {
+			panic("edwards25519: scalar too large for multiscalar multiplication")
+		}
+		lc.scalars[i] = terms[i].Scalar
+		lc.points[i] = terms[i].Point
+	}
+
+	shards := lc.Shards
+	if max := n / minShardSize; shards > max {
+		shards = max
+	}
+	if shards < 2 {
+		if len(lc.pp) < 1 {
+			lc.pp = make([]Pippenger, 1)
+		}
+		lc.pp[0].MultiScalarMultVartime(r, lc.scalars, lc.points)
+		return
+	}
+
+	if len(lc.pp) < shards {
+		lc.pp = append(lc.pp, make([]Pippenger, shards-len(lc.pp))...)
+	}
+	if len(lc.partial) < shards {
+		lc.partial = make([]ExtendedGroupElement, shards)
+	}
+	var wg sync.WaitGroup
+	for s := 0; s < shards; s++ {
+		lo, hi := s*n/shards, (s+1)*n/shards
+		wg.Add(1)
+		go func(s, lo, hi int) {
+			defer wg.Done()
+			lc.pp[s].MultiScalarMultVartime(&lc.partial[s], lc.scalars[lo:hi], lc.points[lo:hi])
+		}(s, lo, hi)
+	}
+	wg.Wait()
+
+	var t CompletedGroupElement
+	var cached CachedGroupElement
+	*r = lc.partial[0]
+	for s := 1; s < shards; s++ {
+		lc.partial[s].ToCached(&cached)
+		GeAdd(&t, r, &cached)
+		t.ToExtended(r)
+	}
+}
+
+// GeLinearCombinationVartime sets r to the sum of the terms using a new
+// LinearCombination with at most shards goroutines. See
+// LinearCombination.ComputeVartime.
+func GeLinearCombinationVartime(r *ExtendedGroupElement, terms []Term, shards int) {
+	lc := LinearCombination{Shards: shards}
+	lc.ComputeVartime(r, terms)
+}
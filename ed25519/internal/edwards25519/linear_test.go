@@ -0,0 +1,60 @@
+// Copyright 2018 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package edwards25519
+
+import (
+	"math/rand"
+	"strconv"
+	"testing"
+)
+
+func TestLinearCombination(t *testing.T) {
+	rng := rand.New(rand.NewSource(1))
+	var lc LinearCombination
+	for _, n := range []int{0, 1, 63, 64, 200, 513} {
+		scalars, points := randomMultiScalarMultInput(rng, n)
+		terms := make([]Term, n)
+		for i := range terms {
+			terms[i] = Term{scalars[i], points[i]}
+		}
+		want := naiveMultiScalarMult(scalars, points)
+		for _, shards := range []int{0, 1, 2, 3, 8} {
+			lc.Shards = shards
+			var r ExtendedGroupElement
+			lc.ComputeVartime(&r, terms)
+			var got [32]byte
+			r.ToBytes(&got)
+			if got != want {
+				t.Errorf("n=%d, shards=%d: got %x, want %x", n, shards, got, want)
+			}
+		}
+
+		var r ExtendedGroupElement
+		GeLinearCombinationVartime(&r, terms, 4)
+		var got [32]byte
+		r.ToBytes(&got)
+		if got != want {
+			t.Errorf("GeLinearCombinationVartime n=%d: got %x, want %x", n, got, want)
+		}
+	}
+}
+
+func BenchmarkLinearCombination(b *testing.B) {
+	rng := rand.New(rand.NewSource(1))
+	scalars, points := randomMultiScalarMultInput(rng, 4096)
+	terms := make([]Term, len(points))
+	for i := range terms {
+		terms[i] = Term{scalars[i], points[i]}
+	}
+	for _, shards := range []int{1, 4} {
+		lc := LinearCombination{Shards: shards}
+		b.Run(strconv.Itoa(shards)+"-shards", func(b *testing.B) {
+			var r ExtendedGroupElement
+			for i := 0; i < b.N; i++ {
+				lc.ComputeVartime(&r, terms)
+			}
+		})
+	}
+}
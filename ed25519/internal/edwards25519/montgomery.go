@@ -0,0 +1,67 @@
+// Copyright 2018 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package edwards25519
+
+// Curve25519, the Montgomery curve y^2 = x^3 + 486662x^2 + x, is birationally
+// equivalent to edwards25519 and shares its field. Its points are handled
+// here in x-only projective coordinates (X:Z), with x = X/Z, as in X25519.
+
+// Replace (f,g) with (g,f) if b == 1;
+// replace (f,g) with (f,g) if b == 0.
+//
+// Preconditions: b in {0,1}.
+func FeCSwap(f, g *FieldElement, b int32) {
+	b = -b
+	for i := range f {
+		t := b & (f[i] ^ g[i])
+		f[i] ^= t
+		g[i] ^= t
+	}
+}
+
+// feMul121666Generic calculates h = f * 121666, where 121666 = (486662 + 2)/4
+// is the constant of the Montgomery doubling formula. Can overlap h with f.
+//
+// Preconditions:
+//    |f| bounded by 1.1*2^26,1.1*2^25,1.1*2^26,1.1*2^25,etc.
+//
+// Postconditions:
+//    |h| bounded by 1.1*2^25,1.1*2^24,1.1*2^25,1.1*2^24,etc.
+func feMul121666Generic(h, f *FieldElement) {
+	FeCombine(h,
+		int64(f[0])*121666, int64(f[1])*121666, int64(f[2])*121666,
+		int64(f[3])*121666, int64(f[4])*121666, int64(f[5])*121666,
+		int64(f[6])*121666, int64(f[7])*121666, int64(f[8])*121666,
+		int64(f[9])*121666)
+}
+
+// MontgomeryLadderStep performs one step of the Montgomery ladder of RFC 7748,
+// Section 5: given P = (x2:z2), Q = (x3:z3) and the affine x coordinate x1 of
+// Q - P, it sets (x2:z2) = 2*P and (x3:z3) = P + Q, so that the difference of
+// the outputs is still x1. It runs in constant time, and swapping the inputs
+// conditionally with FeCSwap before and after each step gives a
+// constant-time scalar multiplication. Cost: 5M + 4S + 1*121666.
+func MontgomeryLadderStep(x1, x2, z2, x3, z3 *FieldElement) {
+	var tmp0, tmp1 FieldElement
+
+	FeSub(&tmp0, x3, z3)
+	FeSub(&tmp1, x2, z2)
+	FeAdd(x2, x2, z2)
+	FeAdd(z2, x3, z3)
+	FeMul(z3, &tmp0, x2)
+	FeMul(z2, z2, &tmp1)
+	FeSquare(&tmp0, &tmp1)
+	FeSquare(&tmp1, x2)
+	FeAdd(x3, z3, z2)
+	FeSub(z2, z3, z2)
+	FeMul(x2, &tmp1, &tmp0)
+	FeSub(&tmp1, &tmp1, &tmp0)
+	FeSquare(z2, z2)
+	FeMul121666(z3, &tmp1)
+	FeSquare(x3, x3)
+	FeAdd(&tmp0, &tmp0, z3)
+	FeMul(z3, x1, z2)
+	FeMul(z2, &tmp1, &tmp0)
+}
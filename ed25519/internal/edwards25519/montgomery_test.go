@@ -0,0 +1,62 @@
+// Copyright 2018 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package edwards25519
+
+import (
+	"math/rand"
+	"testing"
+
+	"golang.org/x/crypto/curve25519"
+)
+
+// x25519 implements X25519 with MontgomeryLadderStep, following RFC 7748.
+func x25519(out, scalar, point *[32]byte) {
+	var e [32]byte
+	copy(e[:], scalar[:])
+	e[0] &= 248
+	e[31] &= 127
+	e[31] |= 64
+
+	var u [32]byte
+	copy(u[:], point[:])
+	u[31] &= 127
+
+	var x1, x2, z2, x3, z3 FieldElement
+	FeFromBytes(&x1, &u)
+	FeOne(&x2)
+	FeCopy(&x3, &x1)
+	FeOne(&z3)
+
+	swap := int32(0)
+	for pos := 254; pos >= 0; pos-- {
+		b := int32(e[pos/8]>>uint(pos&7)) & 1
+		swap ^= b
+		FeCSwap(&x2, &x3, swap)
+		FeCSwap(&z2, &z3, swap)
+		swap = b
+		MontgomeryLadderStep(&x1, &x2, &z2, &x3, &z3)
+	}
+	FeCSwap(&x2, &x3, swap)
+	FeCSwap(&z2, &z3, swap)
+
+	FeInvert(&z2, &z2)
+	FeMul(&x2, &x2, &z2)
+	FeToBytes(out, &x2)
+}
+
+func TestMontgomeryLadderStep(t *testing.T) {
+	rng := rand.New(rand.NewSource(1))
+	var point = [32]byte{9}
+	for i := 0; i < 20; i++ {
+		var scalar, got, want [32]byte
+		rng.Read(scalar[:])
+		x25519(&got, &scalar, &point)
+		curve25519.ScalarMult(&want, &scalar, &point)
+		if got != want {
+			t.Fatalf("X25519(%x, %x) = %x, want %x", scalar, point, got, want)
+		}
+		point = got
+	}
+}
@@ -0,0 +1,231 @@
+// Copyright 2018 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package edwards25519
+
+import "encoding/binary"
+
+// A MontgomeryScalar is an integer modulo l, like a Scalar, kept in the
+// Montgomery domain: x is stored as x * 2^256 mod l in four 64-bit limbs. Its
+// multiplication is two to three times faster than that of Scalar, but
+// converting to and from bytes, or to and from a Scalar, costs one. It suits
+// long computations, such as those of threshold signing coordinators, that
+// decode their inputs once and encode only their results. The zero value is
+// the scalar 0.
+//
+// The methods set the receiver to the result and return it, so that the
+// receiver can also be an operand. All of them run in constant time.
+type MontgomeryScalar struct {
+	// limbs is little-endian and always less than l.
+	limbs [4]uint64
+}
+
+var (
+	// montR2 is 2^512 mod l, which montMul turns into 2^256 mod l, the
+	// Montgomery form of its other operand.
+	montR2 = [4]uint64{0xa40611e3449c0f01, 0xd00e1ba768859347, 0xceec73d217f5be65, 0x0399411b7c309a3d}
+	// montR3 is 2^768 mod l, used to reduce the upper half of wide inputs.
+	montR3 = [4]uint64{0x2a9e49687b83a2db, 0x278324e6aef7f3ec, 0x8065dc6c04ec5b65, 0x0e530b773599cec7}
+	// montOne is 1 in the Montgomery domain, used to leave it.
+	montOne = [4]uint64{1}
+)
+
+// montInv is -l^-1 mod 2^64.
+const montInv = 0xd2b51da312547e1b
+
+// Add sets m = x + y mod l and returns m.
+func (m *MontgomeryScalar) Add(x, y *MontgomeryScalar) *MontgomeryScalar {
+	montAdd(&m.limbs, &x.limbs, &y.limbs)
+	return m
+}
+
+// Subtract sets m = x - y mod l and returns m.
+func (m *MontgomeryScalar) Subtract(x, y *MontgomeryScalar) *MontgomeryScalar {
+	montSub(&m.limbs, &x.limbs, &y.limbs)
+	return m
+}
+
+// Negate sets m = -x mod l and returns m.
+func (m *MontgomeryScalar) Negate(x *MontgomeryScalar) *MontgomeryScalar {
+	var zero [4]uint64
+	montSub(&m.limbs, &zero, &x.limbs)
+	return m
+}
+
+// Multiply sets m = x * y mod l and returns m.
+func (m *MontgomeryScalar) Multiply(x, y *MontgomeryScalar) *MontgomeryScalar {
+	montMul(&m.limbs, &x.limbs, &y.limbs)
+	return m
+}
+
+// MultiplyAdd sets m = x * y + z mod l and returns m.
+func (m *MontgomeryScalar) MultiplyAdd(x, y, z *MontgomeryScalar) *MontgomeryScalar {
+	var t [4]uint64
+	montMul(&t, &x.limbs, &y.limbs)
+	montAdd(&m.limbs, &t, &z.limbs)
+	return m
+}
+
+// Equal returns 1 if m and t are equal, and 0 otherwise, in constant time.
+func (m *MontgomeryScalar) Equal(t *MontgomeryScalar) int {
+	var diff uint64
+	for i := range m.limbs {
+		diff |= m.limbs[i] ^ t.limbs[i]
+	}
+	return montIsZero(diff)
+}
+
+// IsZero returns 1 if m is zero, and 0 otherwise, in constant time.
+func (m *MontgomeryScalar) IsZero() int {
+	return montIsZero(m.limbs[0] | m.limbs[1] | m.limbs[2] | m.limbs[3])
+}
+
+// SetScalar sets m = x, converting it to the Montgomery domain, and returns m.
+func (m *MontgomeryScalar) SetScalar(x *Scalar) *MontgomeryScalar {
+	var t [4]uint64
+	for i := range t {
+		t[i] = binary.LittleEndian.Uint64(x.s[i*8:])
+	}
+	montMul(&m.limbs, &t, &montR2)
+	return m
+}
+
+// SetMontgomery sets s = m, converting it out of the Montgomery domain, and
+// returns s.
+func (s *Scalar) SetMontgomery(m *MontgomeryScalar) *Scalar {
+	var t [4]uint64
+	montMul(&t, &m.limbs, &montOne)
+	for i := range t {
+		binary.LittleEndian.PutUint64(s.s[i*8:], t[i])
+	}
+	return s
+}
+
+// SetCanonicalBytes sets m to the scalar encoded by b, as Scalar's
+// SetCanonicalBytes does, and returns m. If b is not a canonical encoding, m
+// is unchanged and an error is returned.
+func (m *MontgomeryScalar) SetCanonicalBytes(b []byte) (*MontgomeryScalar, error) {
+	var s Scalar
+	if _, err := s.SetCanonicalBytes(b); err != nil {
+		return nil, err
+	}
+	return m.SetScalar(&s), nil
+}
+
+// SetUniformBytes sets m to the 64-byte little-endian integer b reduced
+// modulo l, as Scalar's SetUniformBytes does, and returns m. If b is not 64
+// bytes long, m is unchanged and an error is returned.
+func (m *MontgomeryScalar) SetUniformBytes(b []byte) (*MontgomeryScalar, error) {
+	if len(b) != 64 {
+		return nil, errUniformLength
+	}
+	// b = lo + hi * 2^256, so b * 2^256 = lo * 2^256 + hi * 2^512 mod l, and
+	// montMul divides by 2^256 the products with 2^512 and 2^768.
+	var lo, hi, t [4]uint64
+	for i := range lo {
+		lo[i] = binary.LittleEndian.Uint64(b[i*8:])
+		hi[i] = binary.LittleEndian.Uint64(b[32+i*8:])
+	}
+	montMul(&lo, &lo, &montR2)
+	montMul(&t, &hi, &montR3)
+	montAdd(&m.limbs, &lo, &t)
+	return m, nil
+}
+
+// Bytes returns the canonical 32-byte little-endian encoding of m.
+func (m *MontgomeryScalar) Bytes() [32]byte {
+	var s Scalar
+	return s.SetMontgomery(m).Bytes()
+}
+
+// montIsZero returns 1 if x is zero, and 0 otherwise, in constant time.
+func montIsZero(x uint64) int {
+	// x | -x has its top bit set if and only if x is not zero.
+	return int(1 ^ (x|-x)>>63)
+}
+
+// montSubOrder sets out = x - l if x >= l, and out = x otherwise, where x is
+// the five-limb value x[0] + ... + carry * 2^256, which must be less than
+// 2 * l.
+func montSubOrder(out *[4]uint64, x *[4]uint64, carry uint64) {
+	var t [4]uint64
+	var b uint64
+	t[0], b = sub64(x[0], order[0], 0)
+	t[1], b = sub64(x[1], order[1], b)
+	t[2], b = sub64(x[2], order[2], b)
+	t[3], b = sub64(x[3], order[3], b)
+	_, b = sub64(carry, 0, b)
+	// If the subtraction borrowed, x was already less than l.
+	mask := -b
+	for i := range out {
+		out[i] = x[i]&mask | t[i]&^mask
+	}
+}
+
+// montAdd sets out = x + y mod l. x and y must be less than l.
+func montAdd(out, x, y *[4]uint64) {
+	var t [4]uint64
+	var c uint64
+	t[0], c = add64(x[0], y[0], 0)
+	t[1], c = add64(x[1], y[1], c)
+	t[2], c = add64(x[2], y[2], c)
+	t[3], c = add64(x[3], y[3], c)
+	montSubOrder(out, &t, c)
+}
+
+// montSub sets out = x - y mod l. x and y must be less than l.
+func montSub(out, x, y *[4]uint64) {
+	var t [4]uint64
+	var b uint64
+	t[0], b = sub64(x[0], y[0], 0)
+	t[1], b = sub64(x[1], y[1], b)
+	t[2], b = sub64(x[2], y[2], b)
+	t[3], b = sub64(x[3], y[3], b)
+	// If the subtraction borrowed, add l back.
+	mask := -b
+	var c uint64
+	out[0], c = add64(t[0], order[0]&mask, 0)
+	out[1], c = add64(t[1], order[1]&mask, c)
+	out[2], c = add64(t[2], order[2]&mask, c)
+	out[3], _ = add64(t[3], order[3]&mask, c)
+}
+
+// montMul sets out = x * y / 2^256 mod l, using word-by-word Montgomery
+// reduction. x * y must be less than l * 2^256, which holds if either is less
+// than l.
+func montMul(out, x, y *[4]uint64) {
+	var t [4]uint64
+	var t4, t5 uint64
+	for i := 0; i < 4; i++ {
+		// t += x * y[i]
+		var c uint64
+		for j := 0; j < 4; j++ {
+			c, t[j] = mulAddAdd64(x[j], y[i], t[j], c)
+		}
+		t4, c = add64(t4, c, 0)
+		t5 = c
+
+		// t = (t + k * l) / 2^64, where k makes the division exact.
+		k := t[0] * montInv
+		c, _ = mulAddAdd64(k, order[0], t[0], 0)
+		for j := 1; j < 4; j++ {
+			c, t[j-1] = mulAddAdd64(k, order[j], t[j], c)
+		}
+		t[3], c = add64(t4, c, 0)
+		t4 = t5 + c
+	}
+	montSubOrder(out, &t, t4)
+}
+
+// mulAddAdd64 returns x * y + z + w as a 128-bit value hi * 2^64 + lo, which
+// cannot overflow.
+func mulAddAdd64(x, y, z, w uint64) (hi, lo uint64) {
+	var c uint64
+	hi, lo = mul64(x, y)
+	lo, c = add64(lo, z, 0)
+	hi += c
+	lo, c = add64(lo, w, 0)
+	hi += c
+	return
+}
@@ -0,0 +1,24 @@
+// Copyright 2018 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+// +build go1.12
+
+package edwards25519
+
+import "math/bits"
+
+// add64, sub64 and mul64 are bits.Add64, bits.Sub64 and bits.Mul64, which
+// the compiler turns into single instructions on most platforms.
+
+func add64(x, y, carry uint64) (sum, carryOut uint64) {
+	return bits.Add64(x, y, carry)
+}
+
+func sub64(x, y, borrow uint64) (diff, borrowOut uint64) {
+	return bits.Sub64(x, y, borrow)
+}
+
+func mul64(x, y uint64) (hi, lo uint64) {
+	return bits.Mul64(x, y)
+}
@@ -0,0 +1,117 @@
+// Copyright 2018 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package edwards25519
+
+import (
+	"math/rand"
+	"testing"
+)
+
+func toMontgomery(s *Scalar) *MontgomeryScalar {
+	return new(MontgomeryScalar).SetScalar(s)
+}
+
+func fromMontgomery(m *MontgomeryScalar) *Scalar {
+	return new(Scalar).SetMontgomery(m)
+}
+
+func TestMontgomeryScalarArithmetic(t *testing.T) {
+	rng := rand.New(rand.NewSource(1))
+	edge := []*Scalar{{}, {scOne}, {scMinusOne}}
+	for i := 0; i < 200; i++ {
+		x, y, z := randomScalar(rng), randomScalar(rng), randomScalar(rng)
+		if i < len(edge)*len(edge) {
+			x, y = edge[i%len(edge)], edge[i/len(edge)]
+		}
+		mx, my, mz := toMontgomery(x), toMontgomery(y), toMontgomery(z)
+
+		if fromMontgomery(mx).Equal(x) != 1 {
+			t.Fatalf("%x did not round-trip", x.Bytes())
+		}
+		check := func(name string, got *MontgomeryScalar, want *Scalar) {
+			t.Helper()
+			if fromMontgomery(got).Equal(want) != 1 {
+				t.Errorf("%s(%x, %x): got %x, want %x", name, x.Bytes(), y.Bytes(), got.Bytes(), want.Bytes())
+			}
+		}
+		check("Add", new(MontgomeryScalar).Add(mx, my), new(Scalar).Add(x, y))
+		check("Subtract", new(MontgomeryScalar).Subtract(mx, my), new(Scalar).Subtract(x, y))
+		check("Multiply", new(MontgomeryScalar).Multiply(mx, my), new(Scalar).Multiply(x, y))
+		check("MultiplyAdd", new(MontgomeryScalar).MultiplyAdd(mx, my, mz), new(Scalar).MultiplyAdd(x, y, z))
+		check("Negate", new(MontgomeryScalar).Negate(mx), new(Scalar).Negate(x))
+
+		// The receiver can be an operand.
+		m := *mx
+		check("Multiply in place", m.Multiply(&m, &m), new(Scalar).Multiply(x, x))
+
+		if got, want := mx.Equal(my), x.Equal(y); got != want {
+			t.Errorf("Equal(%x, %x) = %d, want %d", x.Bytes(), y.Bytes(), got, want)
+		}
+		if got, want := mx.IsZero(), x.IsZero(); got != want {
+			t.Errorf("IsZero(%x) = %d, want %d", x.Bytes(), got, want)
+		}
+	}
+
+	var zero MontgomeryScalar
+	if zero.Bytes() != scZero {
+		t.Errorf("the zero value is not 0")
+	}
+}
+
+func TestMontgomeryScalarBytes(t *testing.T) {
+	rng := rand.New(rand.NewSource(2))
+	var wide [64]byte
+	for i := 0; i < 100; i++ {
+		rng.Read(wide[:])
+		if i == 0 {
+			for j := range wide {
+				wide[j] = 0xff
+			}
+		}
+		m, err := new(MontgomeryScalar).SetUniformBytes(wide[:])
+		if err != nil {
+			t.Fatal(err)
+		}
+		s, _ := new(Scalar).SetUniformBytes(wide[:])
+		if m.Bytes() != s.Bytes() {
+			t.Fatalf("SetUniformBytes(%x) = %x, want %x", wide, m.Bytes(), s.Bytes())
+		}
+
+		b := s.Bytes()
+		m2, err := new(MontgomeryScalar).SetCanonicalBytes(b[:])
+		if err != nil || m2.Equal(m) != 1 {
+			t.Fatalf("SetCanonicalBytes(%x) = %x, %v", b, m2.Bytes(), err)
+		}
+	}
+
+	m := toMontgomery(&Scalar{scOne})
+	l := scMinusOne
+	l[0]++
+	if _, err := m.SetCanonicalBytes(l[:]); err == nil {
+		t.Errorf("l accepted")
+	}
+	if _, err := m.SetUniformBytes(wide[:32]); err == nil {
+		t.Errorf("short input accepted")
+	}
+	if m.Bytes() != scOne {
+		t.Errorf("failed decoding modified the receiver")
+	}
+}
+
+func BenchmarkScalarMultiply(b *testing.B) {
+	rng := rand.New(rand.NewSource(1))
+	x, y := randomScalar(rng), randomScalar(rng)
+	for i := 0; i < b.N; i++ {
+		x.Multiply(x, y)
+	}
+}
+
+func BenchmarkMontgomeryScalarMultiply(b *testing.B) {
+	rng := rand.New(rand.NewSource(1))
+	x, y := toMontgomery(randomScalar(rng)), toMontgomery(randomScalar(rng))
+	for i := 0; i < b.N; i++ {
+		x.Multiply(x, y)
+	}
+}
@@ -0,0 +1,205 @@
+// Copyright 2018 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package edwards25519
+
+import (
+	"math/bits"
+	"sync"
+)
+
+// Pippenger computes multiscalar multiplications with the bucket method of
+// Pippenger, which is the fastest method for large numbers of points.
+//
+// The scalars are recoded in signed radix 2^c, and for every window the
+// points are sorted into 2^(c-1) buckets according to their digit. Each
+// window then costs about n + 2^c additions. The window size c is chosen
+// from the number of points.
+//
+// A Pippenger keeps its scratch space between calls, so that repeated
+// multiplications of similar sizes do not allocate. It must not be used
+// concurrently. The zero value is ready to use.
+type Pippenger struct {
+	// Parallelism is the number of goroutines across which the windows
+	// are distributed. Values less than 2 mean all the work is done by the
+	// calling goroutine.
+	Parallelism int
+
+	digits  []int32
+	cached  []CachedGroupElement
+	sums    []ExtendedGroupElement
+	buckets [][]ExtendedGroupElement
+}
+
+// pippengerWindow returns the window size for n points. Larger windows mean
+// fewer windows but more buckets per window, and the total is minimized
+// with roughly 2^c = n/8.
+func pippengerWindow(n int) uint {
+	if n < 32 {
+		return 2
+	}
+	c := uint(bits.Len(uint(n))) - 3
+	if c > 16 {
+		c = 16
+	}
+	return c
+}
+
+// MultiScalarMultVartime sets r = scalars[0]*points[0] + ... +
+// scalars[n-1]*points[n-1], where each scalar s is s[0]+256*s[1]+...+256^31
+// s[31] and must have s[31] <= 127. It is not constant time and must only be
+// used with public scalars. It panics if scalars and points have different
+// lengths.
+func (pp *Pippenger) MultiScalarMultVartime(r *ExtendedGroupElement, scalars [][32]byte, points []ExtendedGroupElement) {
+	if len(scalars) != len(points) {
+		panic("edwards25519: mismatched number of scalars and points")
+	}
+	n := len(points)
+	if n == 0 {
+		r.Zero()
+		return
+	}
+	c := pippengerWindow(n)
+	// One more window than needed for 255 bits holds the final carry.
+	windows := int((255+c-1)/c) + 1
+
+	// Recode the scalars into signed digits in [-2^(c-1), 2^(c-1)].
+	if cap(pp.digits) < n*windows {
+		pp.digits = make([]int32, n*windows)
+	}
+	pp.digits = pp.digits[:n*windows]
+	for i := range scalars {
+		if scalars[i][31] > 127 {
+			panic("edwards25519: scalar too large for multiscalar multiplication")
+		}
+		recodeSigned(pp.digits[i*windows:(i+1)*windows], &scalars[i], c)
+	}
+
+	if cap(pp.cached) < n {
+		pp.cached = make([]CachedGroupElement, n)
+	}
+	pp.cached = pp.cached[:n]
+	for i := range points {
+		points[i].ToCached(&pp.cached[i])
+	}
+
+	if cap(pp.sums) < windows {
+		pp.sums = make([]ExtendedGroupElement, windows)
+	}
+	pp.sums = pp.sums[:windows]
+
+	workers := pp.Parallelism
+	if workers < 1 {
+		workers = 1
+	}
+	if workers > windows {
+		workers = windows
+	}
+	for len(pp.buckets) < workers {
+		pp.buckets = append(pp.buckets, nil)
+	}
+	for w := 0; w < workers; w++ {
+		if cap(pp.buckets[w]) < 1<<(c-1) {
+			pp.buckets[w] = make([]ExtendedGroupElement, 1<<(c-1))
+		}
+		pp.buckets[w] = pp.buckets[w][:1<<(c-1)]
+	}
+
+	if workers == 1 {
+		for j := 0; j < windows; j++ {
+			pp.window(&pp.sums[j], j, windows, pp.buckets[0])
+		}
+	} else {
+		var wg sync.WaitGroup
+		for w := 0; w < workers; w++ {
+			wg.Add(1)
+			go func(w int) {
+				defer wg.Done()
+				for j := w; j < windows; j += workers {
+					pp.window(&pp.sums[j], j, windows, pp.buckets[w])
+				}
+			}(w)
+		}
+		wg.Wait()
+	}
+
+	// r = sum of 2^(c*j) sums[j], by Horner's rule from the top window.
+	var t CompletedGroupElement
+	var cached CachedGroupElement
+	*r = pp.sums[windows-1]
+	for j := windows - 2; j >= 0; j-- {
+		GeMultByPow2(r, r, int(c))
+		pp.sums[j].ToCached(&cached)
+		GeAdd(&t, r, &cached)
+		t.ToExtended(r)
+	}
+}
+
+// window sets out to the sum of the digits of window j times the points,
+// using buckets as scratch space.
+func (pp *Pippenger) window(out *ExtendedGroupElement, j, windows int, buckets []ExtendedGroupElement) {
+	var t CompletedGroupElement
+	for k := range buckets {
+		buckets[k].Zero()
+	}
+
+	// Bucket k holds the points whose digit is ±(k+1).
+	for i := range pp.cached {
+		d := pp.digits[i*windows+j]
+		if d > 0 {
+			GeAdd(&t, &buckets[d-1], &pp.cached[i])
+			t.ToExtended(&buckets[d-1])
+		} else if d < 0 {
+			GeSub(&t, &buckets[-d-1], &pp.cached[i])
+			t.ToExtended(&buckets[-d-1])
+		}
+	}
+
+	// sum_k (k+1) buckets[k], as the sum of the running sums from the top.
+	var running ExtendedGroupElement
+	var cached CachedGroupElement
+	running.Zero()
+	out.Zero()
+	for k := len(buckets) - 1; k >= 0; k-- {
+		buckets[k].ToCached(&cached)
+		GeAdd(&t, &running, &cached)
+		t.ToExtended(&running)
+		running.ToCached(&cached)
+		GeAdd(&t, out, &cached)
+		t.ToExtended(out)
+	}
+}
+
+// recodeSigned sets digits to the signed radix 2^c representation of a, with
+// digits in [-2^(c-1), 2^(c-1)]. There must be enough digits for the final
+// carry.
+func recodeSigned(digits []int32, a *[32]byte, c uint) {
+	var carry int32
+	for j := range digits {
+		var window int32
+		// Gather bits [c*j, c*j+c) of a, at most 16 of them.
+		for b := uint(0); b < c; b++ {
+			bit := c*uint(j) + b
+			if bit >= 256 {
+				break
+			}
+			window |= int32(a[bit/8]>>(bit%8)&1) << b
+		}
+		window += carry
+		carry = 0
+		if window > 1<<(c-1) {
+			window -= 1 << c
+			carry = 1
+		}
+		digits[j] = window
+	}
+}
+
+// GeMultiScalarMultVartime sets r = scalars[0]*points[0] + ... +
+// scalars[n-1]*points[n-1] using a new Pippenger. See
+// Pippenger.MultiScalarMultVartime.
+func GeMultiScalarMultVartime(r *ExtendedGroupElement, scalars [][32]byte, points []ExtendedGroupElement) {
+	var pp Pippenger
+	pp.MultiScalarMultVartime(r, scalars, points)
+}
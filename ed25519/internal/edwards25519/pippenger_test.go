@@ -0,0 +1,117 @@
+// Copyright 2018 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package edwards25519
+
+import (
+	"math/big"
+	"math/rand"
+	"testing"
+)
+
+// naiveMultiScalarMult computes the sum of scalars[i]*points[i] one term at
+// a time.
+func naiveMultiScalarMult(scalars [][32]byte, points []ExtendedGroupElement) [32]byte {
+	var sum, term ExtendedGroupElement
+	var p ProjectiveGroupElement
+	var t CompletedGroupElement
+	var c CachedGroupElement
+	var s [32]byte
+	sum.Zero()
+	for i := range points {
+		GeScalarMultVartime(&p, &scalars[i], &points[i], 5)
+		p.ToBytes(&s)
+		term.FromBytes(&s)
+		term.ToCached(&c)
+		GeAdd(&t, &sum, &c)
+		t.ToExtended(&sum)
+	}
+	sum.ToBytes(&s)
+	return s
+}
+
+func randomMultiScalarMultInput(rng *rand.Rand, n int) ([][32]byte, []ExtendedGroupElement) {
+	scalars := make([][32]byte, n)
+	points := make([]ExtendedGroupElement, n)
+	for i := range points {
+		var a [32]byte
+		rng.Read(a[:])
+		a[31] &= 127
+		GeScalarMultBase(&points[i], &a)
+		rng.Read(scalars[i][:])
+		scalars[i][31] &= 127
+	}
+	if n > 0 {
+		// The largest allowed scalar exercises the final carry.
+		for j := range scalars[0] {
+			scalars[0][j] = 0xff
+		}
+		scalars[0][31] = 0x7f
+	}
+	return scalars, points
+}
+
+func TestPippenger(t *testing.T) {
+	rng := rand.New(rand.NewSource(1))
+	var pp Pippenger
+	for _, n := range []int{0, 1, 2, 31, 32, 100, 70, 300} {
+		for _, par := range []int{0, 3} {
+			pp.Parallelism = par
+			scalars, points := randomMultiScalarMultInput(rng, n)
+
+			var r ExtendedGroupElement
+			pp.MultiScalarMultVartime(&r, scalars, points)
+			var got [32]byte
+			r.ToBytes(&got)
+			if want := naiveMultiScalarMult(scalars, points); got != want {
+				t.Errorf("n=%d, parallelism=%d: got %x, want %x", n, par, got, want)
+			}
+		}
+	}
+
+	scalars, points := randomMultiScalarMultInput(rng, 10)
+	var r ExtendedGroupElement
+	GeMultiScalarMultVartime(&r, scalars, points)
+	var got [32]byte
+	r.ToBytes(&got)
+	if want := naiveMultiScalarMult(scalars, points); got != want {
+		t.Errorf("GeMultiScalarMultVartime: got %x, want %x", got, want)
+	}
+}
+
+func TestRecodeSigned(t *testing.T) {
+	rng := rand.New(rand.NewSource(1))
+	for c := uint(2); c <= 16; c++ {
+		var a [32]byte
+		rng.Read(a[:])
+		a[31] &= 127
+		windows := int((255+c-1)/c) + 1
+		digits := make([]int32, windows)
+		recodeSigned(digits, &a, c)
+
+		sum := new(big.Int)
+		for j := len(digits) - 1; j >= 0; j-- {
+			d := digits[j]
+			if d > 1<<(c-1) || d < -(1<<(c-1)) {
+				t.Fatalf("c=%d: digit %d out of range", c, d)
+			}
+			sum.Lsh(sum, c)
+			sum.Add(sum, big.NewInt(int64(d)))
+		}
+		if want := new(big.Int).SetBytes(reverse(a[:])); sum.Cmp(want) != 0 {
+			t.Errorf("c=%d: digits sum to %x, want %x", c, sum, want)
+		}
+	}
+}
+
+func BenchmarkPippenger(b *testing.B) {
+	rng := rand.New(rand.NewSource(1))
+	scalars, points := randomMultiScalarMultInput(rng, 1024)
+	var pp Pippenger
+	var r ExtendedGroupElement
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		pp.MultiScalarMultVartime(&r, scalars, points)
+	}
+}
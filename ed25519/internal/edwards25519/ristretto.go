@@ -0,0 +1,161 @@
+// Copyright 2018 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package edwards25519
+
+// The ristretto255 group is the prime-order quotient of the curve by its
+// torsion subgroup. Its elements are represented by ExtendedGroupElements,
+// any of the points of a coset standing for the element, and are encoded and
+// compared with the functions below, which follow
+// https://datatracker.ietf.org/doc/draft-irtf-cfrg-ristretto255-decaf448/.
+// Points must only be combined with the group operations of the curve, and
+// never encoded or compared with the Edwards functions, which would tell
+// apart the points of a coset.
+
+// feEqual01 returns 1 if a == b and 0 otherwise, in constant time.
+func feEqual01(a, b *FieldElement) int32 {
+	var t FieldElement
+	FeSub(&t, a, b)
+	return 1 ^ FeIsNonZero(&t)
+}
+
+// feAbs sets h = |f|, the non-negative one of f and -f.
+func feAbs(h, f *FieldElement) {
+	var neg FieldElement
+	FeNeg(&neg, f)
+	FeCopy(h, f)
+	FeCMove(h, &neg, int32(FeIsNegative(f)))
+}
+
+// feSqrtRatio sets r to the non-negative square root of u/v, if there is
+// one, and returns 1, or sets r to the non-negative square root of
+// SqrtM1*u/v and returns 0. If u is zero, r is zero and it returns 1, and if
+// v is zero and u is not, r is zero and it returns 0.
+func feSqrtRatio(r, u, v *FieldElement) int32 {
+	var v3, v7, t, check, negU, negUI FieldElement
+
+	FeSquare(&v3, v)
+	FeMul(&v3, &v3, v) // v^3
+	FeSquare(&v7, &v3)
+	FeMul(&v7, &v7, v) // v^7
+	FeMul(&t, u, &v7)
+	fePow22523(&t, &t) // (uv^7)^((q-5)/8)
+	FeMul(&t, &t, &v3)
+	FeMul(&t, &t, u) // uv^3(uv^7)^((q-5)/8)
+
+	FeSquare(&check, &t)
+	FeMul(&check, &check, v) // vr^2
+	FeNeg(&negU, u)
+	FeMul(&negUI, &negU, &SqrtM1)
+	correctSign := feEqual01(&check, u)
+	flippedSign := feEqual01(&check, &negU)
+	flippedSignI := feEqual01(&check, &negUI)
+
+	var rPrime FieldElement
+	FeMul(&rPrime, &t, &SqrtM1)
+	FeCMove(&t, &rPrime, flippedSign|flippedSignI)
+	feAbs(r, &t)
+	return correctSign | flippedSign
+}
+
+// ToRistrettoBytes sets s to the ristretto255 encoding of the element
+// represented by p. All the points of a coset have the same encoding. It runs
+// in constant time.
+func (p *ExtendedGroupElement) ToRistrettoBytes(s *[32]byte) {
+	var u1, u2, t, one, invSqrt FieldElement
+
+	FeAdd(&u1, &p.Z, &p.Y)
+	FeSub(&t, &p.Z, &p.Y)
+	FeMul(&u1, &u1, &t) // (Z+Y)(Z-Y)
+	FeMul(&u2, &p.X, &p.Y)
+
+	FeSquare(&t, &u2)
+	FeMul(&t, &t, &u1)
+	FeOne(&one)
+	feSqrtRatio(&invSqrt, &one, &t)
+
+	var den1, den2, zInv FieldElement
+	FeMul(&den1, &invSqrt, &u1)
+	FeMul(&den2, &invSqrt, &u2)
+	FeMul(&zInv, &den1, &den2)
+	FeMul(&zInv, &zInv, &p.T)
+
+	var x, y, ix, iy, enchanted FieldElement
+	FeMul(&ix, &p.X, &SqrtM1)
+	FeMul(&iy, &p.Y, &SqrtM1)
+	FeMul(&enchanted, &den1, &invSqrtAMinusD)
+	FeMul(&t, &p.T, &zInv)
+	rotate := int32(FeIsNegative(&t))
+	FeCopy(&x, &p.X)
+	FeCopy(&y, &p.Y)
+	FeCMove(&x, &iy, rotate)
+	FeCMove(&y, &ix, rotate)
+	FeCMove(&den2, &enchanted, rotate)
+
+	var negY FieldElement
+	FeMul(&t, &x, &zInv)
+	FeNeg(&negY, &y)
+	FeCMove(&y, &negY, int32(FeIsNegative(&t)))
+
+	FeSub(&t, &p.Z, &y)
+	FeMul(&t, &t, &den2)
+	feAbs(&t, &t)
+	FeToBytes(s, &t)
+}
+
+// FromRistrettoBytes sets p to a point representing the ristretto255 element
+// encoded by s, and reports whether s is a valid encoding. Only canonical
+// encodings are valid, so that each element has a single encoding. The value
+// of p is unspecified when it returns false.
+func (p *ExtendedGroupElement) FromRistrettoBytes(s *[32]byte) bool {
+	var sf FieldElement
+	var check [32]byte
+	FeFromBytes(&sf, s)
+	FeToBytes(&check, &sf)
+	// The encoding must be reduced, below 2^255, and non-negative.
+	if check != *s || FeIsNegative(&sf) == 1 {
+		return false
+	}
+
+	var ss, u1, u2, u2Sqr, v, t, one FieldElement
+	FeOne(&one)
+	FeSquare(&ss, &sf)
+	FeSub(&u1, &one, &ss)
+	FeAdd(&u2, &one, &ss)
+	FeSquare(&u2Sqr, &u2)
+	FeSquare(&v, &u1)
+	FeMul(&v, &v, &d)
+	FeNeg(&v, &v)
+	FeSub(&v, &v, &u2Sqr) // -(d*u1^2) - u2^2
+
+	var invSqrt FieldElement
+	FeMul(&t, &v, &u2Sqr)
+	wasSquare := feSqrtRatio(&invSqrt, &one, &t)
+
+	var denX, denY FieldElement
+	FeMul(&denX, &invSqrt, &u2)
+	FeMul(&denY, &invSqrt, &denX)
+	FeMul(&denY, &denY, &v)
+
+	FeAdd(&t, &sf, &sf)
+	FeMul(&t, &t, &denX)
+	feAbs(&p.X, &t)
+	FeMul(&p.Y, &u1, &denY)
+	FeOne(&p.Z)
+	FeMul(&p.T, &p.X, &p.Y)
+
+	return wasSquare == 1 && FeIsNegative(&p.T) == 0 && FeIsNonZero(&p.Y) == 1
+}
+
+// RistrettoEqual reports whether p and q represent the same ristretto255
+// element. It runs in constant time.
+func RistrettoEqual(p, q *ExtendedGroupElement) bool {
+	var a, b FieldElement
+	FeMul(&a, &p.X, &q.Y)
+	FeMul(&b, &p.Y, &q.X)
+	eq := feEqual01(&a, &b)
+	FeMul(&a, &p.Y, &q.Y)
+	FeMul(&b, &p.X, &q.X)
+	return eq|feEqual01(&a, &b) == 1
+}
@@ -0,0 +1,107 @@
+// Copyright 2018 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package edwards25519
+
+import (
+	"encoding/hex"
+	"testing"
+)
+
+// ristrettoMultiples are the encodings of 0*B to 5*B, from the test vectors
+// of the ristretto255 specification.
+var ristrettoMultiples = []string{
+	"0000000000000000000000000000000000000000000000000000000000000000",
+	"e2f2ae0a6abc4e71a884a961c500515f58e30b6aa582dd8db6a65945e08d2d76",
+	"6a493210f7499cd17fecb510ae0cea23a110e8d5b901f8acadd3095c73a3b919",
+	"94741f5d5d52755ece4f23f044ee27d5d1ea1e2bd196b462166b16152a9d0259",
+	"da80862773358b466ffadfe0b3293ab3d9fd53c5ea6c955358f568322daf6a57",
+	"e882b131016b52c1d3337080187cf768423efccbb517bb495ab812c4160ff44e",
+}
+
+func TestRistrettoMultiples(t *testing.T) {
+	for n, want := range ristrettoMultiples {
+		var p ExtendedGroupElement
+		GeScalarMultBase(&p, &[32]byte{byte(n)})
+		var s [32]byte
+		p.ToRistrettoBytes(&s)
+		if got := hex.EncodeToString(s[:]); got != want {
+			t.Errorf("%d*B = %s, want %s", n, got, want)
+		}
+
+		var q ExtendedGroupElement
+		if !q.FromRistrettoBytes(&s) {
+			t.Errorf("%d*B: decoding failed", n)
+			continue
+		}
+		if !RistrettoEqual(&p, &q) {
+			t.Errorf("%d*B: decoded element differs", n)
+		}
+		var s2 [32]byte
+		q.ToRistrettoBytes(&s2)
+		if s2 != s {
+			t.Errorf("%d*B: re-encoding is %x, want %x", n, s2, s)
+		}
+	}
+}
+
+func TestRistrettoTorsion(t *testing.T) {
+	// Adding a point of order 4 or 2 gives another point of the same coset,
+	// which must have the same encoding and compare equal.
+	var p ExtendedGroupElement
+	GeScalarMultBase(&p, &[32]byte{3})
+	var want [32]byte
+	p.ToRistrettoBytes(&want)
+
+	torsion := SmallOrderPoints()
+	for i := 0; i < 8; i += 2 {
+		var c CachedGroupElement
+		var r CompletedGroupElement
+		var q ExtendedGroupElement
+		torsion[i].ToCached(&c)
+		GeAdd(&r, &p, &c)
+		r.ToExtended(&q)
+
+		var got [32]byte
+		q.ToRistrettoBytes(&got)
+		if got != want {
+			t.Errorf("3*B + T[%d] encodes to %x, want %x", i, got, want)
+		}
+		if !RistrettoEqual(&p, &q) {
+			t.Errorf("3*B + T[%d] differs from 3*B", i)
+		}
+	}
+
+	var q ExtendedGroupElement
+	GeScalarMultBase(&q, &[32]byte{4})
+	if RistrettoEqual(&p, &q) {
+		t.Errorf("3*B equals 4*B")
+	}
+}
+
+func TestRistrettoBadEncodings(t *testing.T) {
+	for _, tt := range []struct {
+		name, enc string
+	}{
+		// Non-canonical field encodings.
+		{"non-canonical", "00ffffffffffffffffffffffffffffffffffffffffffffffffffffffffffffff"},
+		{"non-canonical", "ffffffffffffffffffffffffffffffffffffffffffffffffffffffffffffff7f"},
+		{"non-canonical", "f3ffffffffffffffffffffffffffffffffffffffffffffffffffffffffffff7f"},
+		{"non-canonical", "edffffffffffffffffffffffffffffffffffffffffffffffffffffffffffff7f"},
+		// Negative field elements.
+		{"negative", "0100000000000000000000000000000000000000000000000000000000000000"},
+		{"negative", "01ffffffffffffffffffffffffffffffffffffffffffffffffffffffffffff7f"},
+		// Non-negative field elements that do not decode.
+		{"not square", "f4b165224a58b791df6af1d8303e61cdc4bb86c3d1c427103c344c41c4f5170f"},
+		{"not square", "8c2e0718822ce47ca8c74107e66cb0e4b2b3f4d58d82ca6386d2c96e3b87c04d"},
+		{"not square", "84c924c3597164c4a6058a00581a22b22de50472433d2e44fed8b6b81a3fa266"},
+	} {
+		var s [32]byte
+		copy(s[:], decodeHex(t, tt.enc))
+		var p ExtendedGroupElement
+		if p.FromRistrettoBytes(&s) {
+			t.Errorf("%s encoding %s was accepted", tt.name, tt.enc)
+		}
+	}
+}
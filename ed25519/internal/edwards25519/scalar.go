@@ -0,0 +1,293 @@
+// Copyright 2018 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package edwards25519
+
+import (
+	cryptorand "crypto/rand"
+	"crypto/subtle"
+	"encoding/binary"
+	"errors"
+	"io"
+	"math/big"
+)
+
+// A Scalar is an integer modulo the order of the prime-order subgroup,
+//   l = 2^252 + 27742317777372353535851937790883648493.
+// It is kept in canonical form, that is, as the 32-byte little-endian
+// encoding of an integer less than l. The zero value is the scalar 0.
+//
+// The arithmetic methods set the receiver to the result and return it, so
+// that the receiver can also be an operand. All of them run in constant time.
+// Long computations can be carried out faster with MontgomeryScalar.
+//
+// Bytes are turned into a Scalar in one of four ways, which give different
+// scalars for the same input and must match what the protocol specifies:
+// SetCanonicalBytes rejects encodings of integers not less than l,
+// SetReducedBytes reduces any 32-byte integer modulo l, SetUniformBytes
+// reduces a 64-byte integer, such as a hash, modulo l, and
+// SetBytesWithClamping clamps 32 bytes as X25519 and Ed25519 keys are.
+type Scalar struct {
+	s [32]byte
+}
+
+var (
+	scZero     [32]byte
+	scOne      = [32]byte{1}
+	scMinusOne = [32]byte{
+		0xec, 0xd3, 0xf5, 0x5c, 0x1a, 0x63, 0x12, 0x58,
+		0xd6, 0x9c, 0xf7, 0xa2, 0xde, 0xf9, 0xde, 0x14,
+		0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00,
+		0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x10,
+	}
+)
+
+var (
+	errNonCanonicalScalar = errors.New("edwards25519: non-canonical scalar encoding")
+	errReducedLength      = errors.New("edwards25519: SetReducedBytes input is not 32 bytes long")
+	errUniformLength      = errors.New("edwards25519: SetUniformBytes input is not 64 bytes long")
+	errClampingLength     = errors.New("edwards25519: SetBytesWithClamping input is not 32 bytes long")
+	errDivideByZero       = errors.New("edwards25519: division by zero")
+	errRandomScalar       = errors.New("edwards25519: random source returned only zero scalars")
+)
+
+// Add sets s = x + y mod l and returns s.
+func (s *Scalar) Add(x, y *Scalar) *Scalar {
+	// x*1 + y
+	ScMulAdd(&s.s, &x.s, &scOne, &y.s)
+	return s
+}
+
+// Subtract sets s = x - y mod l and returns s.
+func (s *Scalar) Subtract(x, y *Scalar) *Scalar {
+	// -1*y + x
+	ScMulAdd(&s.s, &scMinusOne, &y.s, &x.s)
+	return s
+}
+
+// Multiply sets s = x * y mod l and returns s.
+func (s *Scalar) Multiply(x, y *Scalar) *Scalar {
+	ScMulAdd(&s.s, &x.s, &y.s, &scZero)
+	return s
+}
+
+// MultiplyAdd sets s = x * y + z mod l and returns s.
+func (s *Scalar) MultiplyAdd(x, y, z *Scalar) *Scalar {
+	ScMulAdd(&s.s, &x.s, &y.s, &z.s)
+	return s
+}
+
+// Negate sets s = -x mod l and returns s.
+func (s *Scalar) Negate(x *Scalar) *Scalar {
+	ScMulAdd(&s.s, &scMinusOne, &x.s, &scZero)
+	return s
+}
+
+// Equal returns 1 if s and t are equal, and 0 otherwise, in constant time.
+// Protocols that compare secret or attacker-chosen scalars, such as shares,
+// challenges or responses, must use it rather than comparing encodings with
+// bytes.Equal, whose running time reveals the length of the common prefix.
+func (s *Scalar) Equal(t *Scalar) int {
+	return subtle.ConstantTimeCompare(s.s[:], t.s[:])
+}
+
+// IsZero returns 1 if s is zero, and 0 otherwise, in constant time.
+func (s *Scalar) IsZero() int {
+	return subtle.ConstantTimeCompare(s.s[:], scZero[:])
+}
+
+// SetUint64 sets s = x and returns s. Every uint64 is less than l, so no
+// reduction is needed.
+func (s *Scalar) SetUint64(x uint64) *Scalar {
+	s.s = [32]byte{}
+	binary.LittleEndian.PutUint64(s.s[:], x)
+	return s
+}
+
+// bigOrder is l as a big.Int.
+var bigOrder = new(big.Int).SetBytes([]byte{
+	0x10, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00,
+	0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00,
+	0x14, 0xde, 0xf9, 0xde, 0xa2, 0xf7, 0x9c, 0xd6,
+	0x58, 0x12, 0x63, 0x1a, 0x5c, 0xf5, 0xd3, 0xed,
+})
+
+// SetBigInt sets s = x mod l and returns s. x may be negative.
+//
+// It is not constant time, as math/big is not, and must not be used with
+// secret values. It is meant for tests and for cross-checking against other
+// implementations.
+func (s *Scalar) SetBigInt(x *big.Int) *Scalar {
+	r := new(big.Int).Mod(x, bigOrder)
+	b := r.Bytes()
+	s.s = [32]byte{}
+	for i, v := range b {
+		s.s[len(b)-1-i] = v
+	}
+	return s
+}
+
+// BigInt returns the integer in [0, l) that s represents.
+//
+// It is not constant time, as math/big is not, and must not be used with
+// secret values. It is meant for tests and for cross-checking against other
+// implementations.
+func (s *Scalar) BigInt() *big.Int {
+	var b [32]byte
+	for i, v := range s.s {
+		b[31-i] = v
+	}
+	return new(big.Int).SetBytes(b[:])
+}
+
+// DivideVartime sets s = x / y mod l, that is x times the inverse of y, and
+// returns s. If y is zero, s is unchanged and an error is returned.
+//
+// It is not constant time, and must only be used with public values, such as
+// the indexes from which threshold schemes compute Lagrange coefficients.
+func (s *Scalar) DivideVartime(x, y *Scalar) (*Scalar, error) {
+	if y.IsZero() == 1 {
+		return nil, errDivideByZero
+	}
+	var inv Scalar
+	inv.SetBigInt(new(big.Int).ModInverse(y.BigInt(), bigOrder))
+	return s.Multiply(x, &inv), nil
+}
+
+// SetCanonicalBytes sets s to the scalar encoded by b, which must be the
+// 32-byte little-endian encoding of an integer less than l, and returns s. If
+// b is not such an encoding, s is unchanged and an error is returned.
+func (s *Scalar) SetCanonicalBytes(b []byte) (*Scalar, error) {
+	if len(b) != 32 {
+		return nil, errNonCanonicalScalar
+	}
+	var t [32]byte
+	copy(t[:], b)
+	if scIsCanonical(&t) != 1 {
+		return nil, errNonCanonicalScalar
+	}
+	s.s = t
+	return s, nil
+}
+
+// SetReducedBytes sets s to the 32-byte little-endian integer b reduced
+// modulo l, and returns s. Unlike SetCanonicalBytes, it accepts every value
+// of b, including the non-canonical S of signatures made by implementations
+// that do not reduce it. It must not be used to hash to a scalar, as the
+// result is far from uniform: use SetUniformBytes instead. If b is not 32
+// bytes long, s is unchanged and an error is returned.
+func (s *Scalar) SetReducedBytes(b []byte) (*Scalar, error) {
+	if len(b) != 32 {
+		return nil, errReducedLength
+	}
+	var wide [64]byte
+	copy(wide[:], b)
+	ScReduce(&s.s, &wide)
+	return s, nil
+}
+
+// SetUniformBytes sets s to the 64-byte little-endian integer b reduced
+// modulo l, and returns s. If b is uniformly random, so is s, with a bias of
+// about 2^-259. This is how RFC 8032 derives scalars from SHA-512 digests,
+// and how VRF and OPRF specifications hash to scalars. If b is not 64 bytes
+// long, s is unchanged and an error is returned.
+func (s *Scalar) SetUniformBytes(b []byte) (*Scalar, error) {
+	if len(b) != 64 {
+		return nil, errUniformLength
+	}
+	var wide [64]byte
+	copy(wide[:], b)
+	ScReduce(&s.s, &wide)
+	return s, nil
+}
+
+// SetBytesWithClamping clamps the 32-byte value b as X25519 and Ed25519 do,
+// clearing its three lowest bits and its highest bit and setting its second
+// highest bit, and sets s to the result reduced modulo l, and returns s. If b
+// is not 32 bytes long, s is unchanged and an error is returned.
+//
+// This is how Ed25519 derives its secret scalar from the hash of a seed, see
+// RFC 8032, Section 5.1.5, and how X25519 interprets private keys, see RFC
+// 7748, Section 5. Multiplying the base point, or any point of the
+// prime-order subgroup, by s gives the same result as by the clamped value,
+// so s can convert between Ed25519 and X25519 keys. Other points, such as
+// the peer public keys of X25519, must be multiplied by the unreduced
+// clamped value instead, whose cofactor clearing the reduction undoes.
+func (s *Scalar) SetBytesWithClamping(b []byte) (*Scalar, error) {
+	if len(b) != 32 {
+		return nil, errClampingLength
+	}
+	var wide [64]byte
+	copy(wide[:], b)
+	wide[0] &= 248
+	wide[31] &= 127
+	wide[31] |= 64
+	ScReduce(&s.s, &wide)
+	return s, nil
+}
+
+// randomScalarAttempts is the number of times NewRandomScalar reads from
+// rand before giving up.
+const randomScalarAttempts = 3
+
+// NewRandomScalar returns a uniformly random non-zero scalar, reduced from 64
+// bytes read from rand so that its bias is negligible. If rand is nil,
+// crypto/rand.Reader is used. It is the way to generate secret scalars, such
+// as nonces and private keys, for which any bias can be fatal.
+func NewRandomScalar(rand io.Reader) (*Scalar, error) {
+	if rand == nil {
+		rand = cryptorand.Reader
+	}
+	var wide [64]byte
+	s := new(Scalar)
+	for i := 0; i < randomScalarAttempts; i++ {
+		if _, err := io.ReadFull(rand, wide[:]); err != nil {
+			return nil, err
+		}
+		ScReduce(&s.s, &wide)
+		if s.IsZero() == 0 {
+			return s, nil
+		}
+	}
+	// Zero only comes up with probability 2^-252, so several in a row mean
+	// that rand is broken, as it would be if it only returned zeroes.
+	return nil, errRandomScalar
+}
+
+// Bytes returns the canonical 32-byte little-endian encoding of s, which can
+// be used as a scalar by the other functions of this package.
+func (s *Scalar) Bytes() [32]byte {
+	return s.s
+}
+
+// SignedRadix16 returns the signed radix-16 recoding of s, as computed by
+// SignedRadix16, in constant time. Since s is less than l, the precondition
+// of SignedRadix16 always holds.
+func (s *Scalar) SignedRadix16() [64]int8 {
+	var e [64]int8
+	SignedRadix16(&e, &s.s)
+	return e
+}
+
+// NonAdjacentForm returns the width-w non-adjacent form of s, as computed by
+// NonAdjacentForm. w must be between 2 and 8. It is not constant time.
+func (s *Scalar) NonAdjacentForm(w uint) [256]int8 {
+	var naf [256]int8
+	NonAdjacentForm(&naf, &s.s, w)
+	return naf
+}
+
+// scIsCanonical returns 1 if s is less than l, and 0 otherwise, in constant
+// time. Unlike ScMinimal, its running time does not depend on s.
+func scIsCanonical(s *[32]byte) int {
+	// s < l if and only if computing s - l borrows.
+	var borrow uint64
+	for i := 0; i < 4; i++ {
+		x := binary.LittleEndian.Uint64(s[i*8:])
+		y := order[i]
+		diff := x - y - borrow
+		borrow = ((^x & y) | (^(x ^ y) & diff)) >> 63
+	}
+	return int(borrow)
+}
@@ -0,0 +1,433 @@
+// Copyright 2018 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package edwards25519
+
+import (
+	"bytes"
+	"crypto/sha512"
+	"encoding/hex"
+	"math/big"
+	"math/rand"
+	"testing"
+
+	"golang.org/x/crypto/curve25519"
+)
+
+var bigL, _ = new(big.Int).SetString("7237005577332262213973186563042994240857116359379907606001950938285454250989", 10)
+
+func scalarToBig(s *Scalar) *big.Int {
+	b := s.Bytes()
+	for i, j := 0, len(b)-1; i < j; i, j = i+1, j-1 {
+		b[i], b[j] = b[j], b[i]
+	}
+	return new(big.Int).SetBytes(b[:])
+}
+
+func randomScalar(rng *rand.Rand) *Scalar {
+	var wide [64]byte
+	rng.Read(wide[:])
+	s, err := new(Scalar).SetUniformBytes(wide[:])
+	if err != nil {
+		panic(err)
+	}
+	return s
+}
+
+func TestScalarArithmetic(t *testing.T) {
+	rng := rand.New(rand.NewSource(1))
+	for i := 0; i < 100; i++ {
+		x, y, z := randomScalar(rng), randomScalar(rng), randomScalar(rng)
+		bx, by, bz := scalarToBig(x), scalarToBig(y), scalarToBig(z)
+		if bx.Cmp(bigL) >= 0 {
+			t.Fatalf("SetUniformBytes returned a non-canonical scalar")
+		}
+
+		check := func(name string, got *Scalar, want *big.Int) {
+			t.Helper()
+			want.Mod(want, bigL)
+			if scalarToBig(got).Cmp(want) != 0 {
+				t.Errorf("%s: got %v, want %v", name, scalarToBig(got), want)
+			}
+		}
+		check("Add", new(Scalar).Add(x, y), new(big.Int).Add(bx, by))
+		check("Subtract", new(Scalar).Subtract(x, y), new(big.Int).Sub(bx, by))
+		check("Multiply", new(Scalar).Multiply(x, y), new(big.Int).Mul(bx, by))
+		check("MultiplyAdd", new(Scalar).MultiplyAdd(x, y, z), new(big.Int).Add(new(big.Int).Mul(bx, by), bz))
+		check("Negate", new(Scalar).Negate(x), new(big.Int).Neg(bx))
+
+		// The receiver can be an operand.
+		s := *x
+		check("Add in place", s.Add(&s, &s), new(big.Int).Lsh(bx, 1))
+	}
+
+	var zero Scalar
+	if new(Scalar).Negate(&zero).Equal(&zero) != 1 {
+		t.Errorf("-0 != 0")
+	}
+}
+
+func TestScalarEqual(t *testing.T) {
+	one, minusOne := &Scalar{scOne}, &Scalar{scMinusOne}
+	if one.Equal(one) != 1 || one.Equal(minusOne) != 0 {
+		t.Error("Equal is wrong for 1 and -1")
+	}
+	if x := new(Scalar).Add(one, minusOne); x.Equal(new(Scalar)) != 1 || x.IsZero() != 1 {
+		t.Error("1 + -1 is not zero")
+	}
+	if one.IsZero() != 0 || minusOne.IsZero() != 0 {
+		t.Error("IsZero is 1 for non-zero scalars")
+	}
+	// Scalars that only differ in their last byte.
+	x, y := &Scalar{[32]byte{1}}, &Scalar{}
+	y.s[0], y.s[31] = 1, 1
+	if x.Equal(y) != 0 || y.Equal(x) != 0 {
+		t.Error("Equal ignores the last byte")
+	}
+}
+
+func TestScalarSetUint64(t *testing.T) {
+	for _, x := range []uint64{0, 1, 8, 1<<32 + 7, 1<<64 - 1} {
+		s := &Scalar{scMinusOne}
+		s.SetUint64(x)
+		got := new(big.Int).SetBytes(reverse(s.s[:]))
+		if want := new(big.Int).SetUint64(x); got.Cmp(want) != 0 {
+			t.Errorf("SetUint64(%d) = %v", x, got)
+		}
+	}
+	// Small constants combine as integers.
+	var two, four Scalar
+	if new(Scalar).Multiply(two.SetUint64(2), four.SetUint64(4)).Equal(new(Scalar).SetUint64(8)) != 1 {
+		t.Error("2 * 4 != 8")
+	}
+}
+
+func TestScalarBigInt(t *testing.T) {
+	if bigOrder.Cmp(bigL) != 0 {
+		t.Fatalf("bigOrder = %v, want %v", bigOrder, bigL)
+	}
+	rng := rand.New(rand.NewSource(5))
+	for i := 0; i < 100; i++ {
+		x := randomScalar(rng)
+		b := x.BigInt()
+		if b.Cmp(scalarToBig(x)) != 0 {
+			t.Fatalf("BigInt() = %v, want %v", b, scalarToBig(x))
+		}
+		if new(Scalar).SetBigInt(b).Equal(x) != 1 {
+			t.Fatalf("SetBigInt(BigInt()) != x for %v", b)
+		}
+	}
+	for _, tt := range []struct {
+		x    *big.Int
+		want *Scalar
+	}{
+		{big.NewInt(-1), &Scalar{scMinusOne}},
+		{new(big.Int).Set(bigL), &Scalar{}},
+		{new(big.Int).Add(bigL, big.NewInt(1)), &Scalar{scOne}},
+		{new(big.Int).Lsh(bigL, 300), &Scalar{}},
+	} {
+		if new(Scalar).SetBigInt(tt.x).Equal(tt.want) != 1 {
+			t.Errorf("SetBigInt(%v) is wrong", tt.x)
+		}
+	}
+}
+
+func TestScalarDivideVartime(t *testing.T) {
+	rng := rand.New(rand.NewSource(6))
+	for i := 0; i < 100; i++ {
+		x, y := randomScalar(rng), randomScalar(rng)
+		q, err := new(Scalar).DivideVartime(x, y)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if new(Scalar).Multiply(q, y).Equal(x) != 1 {
+			t.Fatalf("(x / y) * y != x")
+		}
+	}
+
+	// The Lagrange coefficient at 0 of index 1 among {1, 2, 3} is
+	// 2/(2-1) * 3/(3-1) = 3.
+	var one, two, three, d1, d2 Scalar
+	one.SetUint64(1)
+	two.SetUint64(2)
+	three.SetUint64(3)
+	c1, _ := new(Scalar).DivideVartime(&two, d1.Subtract(&two, &one))
+	c2, _ := new(Scalar).DivideVartime(&three, d2.Subtract(&three, &one))
+	if new(Scalar).Multiply(c1, c2).Equal(&three) != 1 {
+		t.Error("Lagrange coefficient is not 3")
+	}
+
+	s := &Scalar{scOne}
+	if _, err := s.DivideVartime(&one, new(Scalar)); err == nil {
+		t.Error("division by zero succeeded")
+	}
+	if s.Equal(&one) != 1 {
+		t.Error("failed division modified the receiver")
+	}
+}
+
+func TestScalarRecodings(t *testing.T) {
+	// -1 has the largest top byte of any scalar.
+	x := &Scalar{scMinusOne}
+	want := new(big.Int).Sub(bigL, big.NewInt(1))
+
+	e := x.SignedRadix16()
+	sum := new(big.Int)
+	for j := 63; j >= 0; j-- {
+		sum.Lsh(sum, 4)
+		sum.Add(sum, big.NewInt(int64(e[j])))
+	}
+	if sum.Cmp(want) != 0 {
+		t.Errorf("SignedRadix16 of -1 sums to %x", sum)
+	}
+
+	naf := x.NonAdjacentForm(5)
+	sum.SetInt64(0)
+	for j := 255; j >= 0; j-- {
+		sum.Lsh(sum, 1)
+		sum.Add(sum, big.NewInt(int64(naf[j])))
+	}
+	if sum.Cmp(want) != 0 {
+		t.Errorf("NonAdjacentForm of -1 sums to %x", sum)
+	}
+}
+
+func TestScalarSetCanonicalBytes(t *testing.T) {
+	var s Scalar
+	minusOne := scMinusOne
+	if _, err := s.SetCanonicalBytes(minusOne[:]); err != nil {
+		t.Errorf("l-1 rejected: %v", err)
+	}
+	l := minusOne
+	l[0]++
+	if _, err := s.SetCanonicalBytes(l[:]); err == nil {
+		t.Errorf("l accepted")
+	}
+	var max [32]byte
+	for i := range max {
+		max[i] = 0xff
+	}
+	if _, err := s.SetCanonicalBytes(max[:]); err == nil {
+		t.Errorf("2^256-1 accepted")
+	}
+	if _, err := s.SetCanonicalBytes(max[:31]); err == nil {
+		t.Errorf("short encoding accepted")
+	}
+	if s.Equal(&Scalar{scMinusOne}) != 1 {
+		t.Errorf("failed SetCanonicalBytes modified the receiver")
+	}
+	// Adding l to a canonical scalar makes it non-canonical, as it would for
+	// a malleated signature.
+	one, lPlusOne := scOne, l
+	lPlusOne[0]++
+	if _, err := s.SetCanonicalBytes(lPlusOne[:]); err != errNonCanonicalScalar {
+		t.Errorf("l+1: got %v, want %v", err, errNonCanonicalScalar)
+	}
+	if _, err := s.SetCanonicalBytes(one[:]); err != nil || s.Equal(&Scalar{scOne}) != 1 {
+		t.Errorf("1 decoded to %x, %v", s.Bytes(), err)
+	}
+
+	rng := rand.New(rand.NewSource(1))
+	for i := 0; i < 100; i++ {
+		var b [32]byte
+		rng.Read(b[:])
+		b[31] &= 0x1f
+		if got, want := scIsCanonical(&b) == 1, ScMinimal(&b); got != want {
+			t.Errorf("scIsCanonical(%x) = %v, ScMinimal = %v", b, got, want)
+		}
+	}
+}
+
+func TestScalarSetUniformBytes(t *testing.T) {
+	// The nonce of the first test vector of RFC 8032, section 7.1, is the
+	// SHA-512 digest of the second half of the expanded key and the message.
+	seed, _ := hex.DecodeString("9d61b19deffd5a60ba844af492ec2cc44449c5697b326919703bac031cae7f60")
+	sig, _ := hex.DecodeString("e5564300c360ac729086e2cc806e828a84877f1eb8e5d974d873e065224901555fb8821590a33bacc61e39701cf9b46bd25bf5f0595bbe24655141438e7a100b")
+	expanded := sha512.Sum512(seed)
+	nonce := sha512.Sum512(expanded[32:])
+
+	r, err := new(Scalar).SetUniformBytes(nonce[:])
+	if err != nil {
+		t.Fatal(err)
+	}
+	var R ExtendedGroupElement
+	var encodedR [32]byte
+	rBytes := r.Bytes()
+	GeScalarMultBase(&R, &rBytes)
+	R.ToBytes(&encodedR)
+	if !bytes.Equal(encodedR[:], sig[:32]) {
+		t.Errorf("R = %x, want %x", encodedR, sig[:32])
+	}
+
+	// S = r + k*a, where k is the digest of R, the public key and the
+	// message, and a is the clamped secret scalar.
+	pub, _ := hex.DecodeString("d75a980182b10ab7d54bfed3c964073a0ee172f3daa62325af021a68f707511a")
+	kDigest := sha512.Sum512(append(sig[:32:32], pub...))
+	k, _ := new(Scalar).SetUniformBytes(kDigest[:])
+	var clamped [64]byte
+	copy(clamped[:], expanded[:32])
+	clamped[0] &= 248
+	clamped[31] &= 63
+	clamped[31] |= 64
+	a, _ := new(Scalar).SetUniformBytes(clamped[:])
+	if S := new(Scalar).MultiplyAdd(k, a, r).Bytes(); !bytes.Equal(S[:], sig[32:]) {
+		t.Errorf("S = %x, want %x", S, sig[32:])
+	}
+
+	// l + 1, padded to 64 bytes, reduces to 1.
+	var wide [64]byte
+	copy(wide[:], scMinusOne[:])
+	wide[0] += 2
+	if s, _ := new(Scalar).SetUniformBytes(wide[:]); s.Equal(&Scalar{scOne}) != 1 {
+		t.Errorf("l+1 reduced to %x", s.Bytes())
+	}
+
+	s := new(Scalar)
+	if _, err := s.SetUniformBytes(wide[:32]); err == nil {
+		t.Errorf("short input accepted")
+	}
+	if s.Equal(new(Scalar)) != 1 {
+		t.Errorf("failed SetUniformBytes modified the receiver")
+	}
+}
+
+func TestScalarSetReducedBytes(t *testing.T) {
+	rng := rand.New(rand.NewSource(1))
+	inputs := [][]byte{make([]byte, 32), bytes.Repeat([]byte{0xff}, 32)}
+	for i := 0; i < 100; i++ {
+		b := make([]byte, 32)
+		rng.Read(b)
+		inputs = append(inputs, b)
+	}
+	for _, b := range inputs {
+		s, err := new(Scalar).SetReducedBytes(b)
+		if err != nil {
+			t.Fatal(err)
+		}
+		le := make([]byte, 32)
+		for i, v := range b {
+			le[31-i] = v
+		}
+		want := new(big.Int).SetBytes(le)
+		want.Mod(want, bigL)
+		if got := scalarToBig(s); got.Cmp(want) != 0 {
+			t.Errorf("SetReducedBytes(%x) = %v, want %v", b, got, want)
+		}
+
+		// The other constructors interpret the same bytes differently.
+		if c, err := new(Scalar).SetCanonicalBytes(b); err == nil && c.Equal(s) != 1 {
+			t.Errorf("SetCanonicalBytes(%x) differs from SetReducedBytes", b)
+		} else if err != nil && want.Cmp(new(big.Int).SetBytes(le)) == 0 {
+			t.Errorf("SetCanonicalBytes(%x) rejected a canonical scalar", b)
+		}
+		clamped, _ := new(Scalar).SetBytesWithClamping(b)
+		if clamped.Equal(s) == 1 {
+			t.Errorf("SetBytesWithClamping(%x) equals SetReducedBytes", b)
+		}
+	}
+
+	s := new(Scalar)
+	if _, err := s.SetReducedBytes(make([]byte, 64)); err == nil {
+		t.Errorf("long input accepted")
+	}
+	if s.Equal(new(Scalar)) != 1 {
+		t.Errorf("failed SetReducedBytes modified the receiver")
+	}
+}
+
+func TestScalarSetBytesWithClamping(t *testing.T) {
+	// The public key of the first test vector of RFC 8032, section 7.1, is
+	// the base point multiplied by the clamped first half of the SHA-512
+	// digest of the seed.
+	seed, _ := hex.DecodeString("9d61b19deffd5a60ba844af492ec2cc44449c5697b326919703bac031cae7f60")
+	pub, _ := hex.DecodeString("d75a980182b10ab7d54bfed3c964073a0ee172f3daa62325af021a68f707511a")
+	expanded := sha512.Sum512(seed)
+	in := append([]byte(nil), expanded[:32]...)
+
+	a, err := new(Scalar).SetBytesWithClamping(in)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(in, expanded[:32]) {
+		t.Errorf("SetBytesWithClamping modified its input")
+	}
+	var A ExtendedGroupElement
+	var encodedA [32]byte
+	aBytes := a.Bytes()
+	GeScalarMultBase(&A, &aBytes)
+	A.ToBytes(&encodedA)
+	if !bytes.Equal(encodedA[:], pub) {
+		t.Errorf("A = %x, want %x", encodedA, pub)
+	}
+
+	// a is the clamped integer reduced modulo l.
+	var clamped [32]byte
+	for i, v := range in {
+		clamped[31-i] = v
+	}
+	clamped[31] &= 248
+	clamped[0] &= 127
+	clamped[0] |= 64
+	want := new(big.Int).SetBytes(clamped[:])
+	want.Mod(want, bigL)
+	if got := a.BigInt(); got.Cmp(want) != 0 {
+		t.Errorf("a = %v, want %v", got, want)
+	}
+
+	// X25519 of the same private key is the Montgomery u-coordinate of A,
+	// (1 + y) / (1 - y), or (Z + Y) / (Z - Y) in projective coordinates.
+	var num, den FieldElement
+	var u, x25519Pub, x25519Priv [32]byte
+	FeAdd(&num, &A.Z, &A.Y)
+	FeSub(&den, &A.Z, &A.Y)
+	FeInvert(&den, &den)
+	FeMul(&num, &num, &den)
+	FeToBytes(&u, &num)
+	copy(x25519Priv[:], in)
+	curve25519.ScalarBaseMult(&x25519Pub, &x25519Priv)
+	if u != x25519Pub {
+		t.Errorf("u = %x, want %x", u, x25519Pub)
+	}
+
+	s := new(Scalar)
+	if _, err := s.SetBytesWithClamping(expanded[:]); err == nil {
+		t.Errorf("long input accepted")
+	}
+	if s.Equal(new(Scalar)) != 1 {
+		t.Errorf("failed SetBytesWithClamping modified the receiver")
+	}
+}
+
+func TestNewRandomScalar(t *testing.T) {
+	s, err := NewRandomScalar(nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if s.Equal(&Scalar{}) == 1 {
+		t.Error("NewRandomScalar returned zero")
+	}
+
+	// Inputs that reduce to zero are skipped.
+	var wide [64]byte
+	copy(wide[:], scMinusOne[:])
+	wide[0]++
+	var next [64]byte
+	next[0] = 42
+	input := append(append(make([]byte, 64), wide[:]...), next[:]...)
+	s, err = NewRandomScalar(bytes.NewReader(input))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := (Scalar{[32]byte{42}}); s.Equal(&want) != 1 {
+		t.Errorf("got %x, want 42", s.Bytes())
+	}
+
+	if _, err := NewRandomScalar(bytes.NewReader(make([]byte, 63))); err == nil {
+		t.Error("short read not reported")
+	}
+	// A source of zeroes does not make it loop forever.
+	if _, err := NewRandomScalar(bytes.NewReader(make([]byte, 64*randomScalarAttempts+1))); err != errRandomScalar {
+		t.Errorf("source of zeroes: got %v, want errRandomScalar", err)
+	}
+}
@@ -0,0 +1,187 @@
+// Copyright 2018 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package edwards25519
+
+import (
+	"crypto/sha256"
+	"crypto/subtle"
+	"errors"
+)
+
+// A FixedBaseTable holds the precomputed multiples of a point that make
+// constant-time multiplications of that point as fast as those of the base
+// point. The table for the base point is compiled in and used by
+// GeScalarMultBase; tables for other generators, such as the second
+// generator of Pedersen commitments, are built by NewFixedBaseTable.
+//
+// Building a table costs about as much as a hundred multiplications, so
+// programs that start often can build their tables once, store them with
+// MarshalBinary, and load them with UnmarshalBinary at startup.
+type FixedBaseTable struct {
+	// table[i][j] is (j+1)*256^i*P.
+	table [32][8]PreComputedGroupElement
+}
+
+// NewFixedBaseTable returns the table for p.
+func NewFixedBaseTable(p *ExtendedGroupElement) *FixedBaseTable {
+	var points [32 * 8]ExtendedGroupElement
+	var t CompletedGroupElement
+	var cached CachedGroupElement
+
+	row := *p
+	for i := 0; i < 32; i++ {
+		points[8*i] = row
+		row.ToCached(&cached)
+		for j := 1; j < 8; j++ {
+			GeAdd(&t, &points[8*i+j-1], &cached)
+			t.ToExtended(&points[8*i+j])
+		}
+		GeMultByPow2(&row, &row, 8)
+	}
+
+	var zInv [len(points)]FieldElement
+	batchInvert(zInv[:], points[:])
+	tbl := new(FixedBaseTable)
+	for i := range points {
+		var x, y FieldElement
+		r := &tbl.table[i/8][i%8]
+		FeMul(&x, &points[i].X, &zInv[i])
+		FeMul(&y, &points[i].Y, &zInv[i])
+		FeAdd(&r.yPlusX, &y, &x)
+		FeSub(&r.yMinusX, &y, &x)
+		FeMul(&r.xy2d, &x, &y)
+		FeMul(&r.xy2d, &r.xy2d, &d2)
+	}
+	return tbl
+}
+
+// ScalarMult computes h = a*P in constant time, where P is the point of the
+// table and
+//   a = a[0]+256*a[1]+...+256^31 a[31]
+//
+// Preconditions:
+//   a[31] <= 127
+func (tbl *FixedBaseTable) ScalarMult(h *ExtendedGroupElement, a *[32]byte) {
+	geScalarMultTable(h, a, &tbl.table)
+}
+
+// Row returns the i-th row of the table, which holds 1*256^i*P to 8*256^i*P,
+// for use with SelectPoint.
+func (tbl *FixedBaseTable) Row(i int) *[8]PreComputedGroupElement {
+	return &tbl.table[i]
+}
+
+// The serialized form of a FixedBaseTable is a header, the canonical
+// encodings of the yPlusX, yMinusX and xy2d coordinates of every entry, and
+// the SHA-256 hash of everything before it.
+const (
+	tableHeader     = "ed25519 fixed-base table v1\n"
+	tableEntrySize  = 3 * 32
+	tableChecksumAt = len(tableHeader) + 32*8*tableEntrySize
+	tableSize       = tableChecksumAt + sha256.Size
+)
+
+var errBadTable = errors.New("edwards25519: invalid fixed-base table")
+
+// MarshalBinary implements encoding.BinaryMarshaler. It never returns an
+// error.
+func (tbl *FixedBaseTable) MarshalBinary() ([]byte, error) {
+	out := make([]byte, len(tableHeader), tableSize)
+	copy(out, tableHeader)
+	var s [32]byte
+	for i := range tbl.table {
+		for j := range tbl.table[i] {
+			e := &tbl.table[i][j]
+			FeToBytes(&s, &e.yPlusX)
+			out = append(out, s[:]...)
+			FeToBytes(&s, &e.yMinusX)
+			out = append(out, s[:]...)
+			FeToBytes(&s, &e.xy2d)
+			out = append(out, s[:]...)
+		}
+	}
+	sum := sha256.Sum256(out)
+	return append(out, sum[:]...), nil
+}
+
+// UnmarshalBinary implements encoding.BinaryUnmarshaler. It checks that data
+// is intact, that every encoding is canonical, and that every entry is a
+// point of the curve. It cannot check that the table was built from a given
+// point without rebuilding it, so data must come from a trusted source.
+func (tbl *FixedBaseTable) UnmarshalBinary(data []byte) error {
+	if len(data) != tableSize || string(data[:len(tableHeader)]) != tableHeader {
+		return errBadTable
+	}
+	sum := sha256.Sum256(data[:tableChecksumAt])
+	if subtle.ConstantTimeCompare(sum[:], data[tableChecksumAt:]) != 1 {
+		return errBadTable
+	}
+
+	var t FixedBaseTable
+	data = data[len(tableHeader):]
+	for i := range t.table {
+		for j := range t.table[i] {
+			e := &t.table[i][j]
+			if !feFromCanonicalBytes(&e.yPlusX, data[0:32]) ||
+				!feFromCanonicalBytes(&e.yMinusX, data[32:64]) ||
+				!feFromCanonicalBytes(&e.xy2d, data[64:96]) ||
+				!e.onCurve() {
+				return errBadTable
+			}
+			data = data[tableEntrySize:]
+		}
+	}
+	*tbl = t
+	return nil
+}
+
+// feFromCanonicalBytes sets dst to the element encoded in src, and reports
+// whether src is the canonical encoding of dst.
+func feFromCanonicalBytes(dst *FieldElement, src []byte) bool {
+	var in, out [32]byte
+	copy(in[:], src)
+	FeFromBytes(dst, &in)
+	FeToBytes(&out, dst)
+	return in == out
+}
+
+// onCurve reports whether p represents a point of the curve, with a
+// consistent xy2d coordinate.
+func (p *PreComputedGroupElement) onCurve() bool {
+	// With a = y+x and b = y-x, we have a*b = y^2 - x^2 and a^2 - b^2 = 4*x*y,
+	// so the curve equation -x^2 + y^2 = 1 + d*x^2*y^2 becomes
+	//   16*a*b - d*(a^2 - b^2)^2 = 16
+	// and xy2d = 2*d*x*y becomes 2*xy2d = d*(a^2 - b^2). Only products are
+	// taken of decoded elements, whose limbs may be too large for sums.
+	var two, sixteen FieldElement
+	FeOne(&two)
+	FeAdd(&two, &two, &two)
+	FeSquare(&sixteen, &two)
+	FeSquare(&sixteen, &sixteen)
+
+	var aa, bb, ab, t, lhs, rhs FieldElement
+	FeSquare(&aa, &p.yPlusX)
+	FeSquare(&bb, &p.yMinusX)
+	FeMul(&ab, &p.yPlusX, &p.yMinusX)
+	FeSub(&t, &aa, &bb)
+
+	FeMul(&lhs, &ab, &sixteen)
+	FeSquare(&rhs, &t)
+	FeMul(&rhs, &rhs, &d)
+	FeSub(&lhs, &lhs, &rhs)
+	onCurve := feEqual(&lhs, &sixteen)
+
+	FeMul(&lhs, &p.xy2d, &two)
+	FeMul(&rhs, &t, &d)
+	return onCurve && feEqual(&lhs, &rhs)
+}
+
+// feEqual reports whether a and b represent the same element.
+func feEqual(a, b *FieldElement) bool {
+	var sa, sb [32]byte
+	FeToBytes(&sa, a)
+	FeToBytes(&sb, b)
+	return sa == sb
+}
@@ -0,0 +1,167 @@
+// Copyright 2018 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package edwards25519
+
+import (
+	"crypto/sha256"
+	"math/rand"
+	"testing"
+)
+
+func TestFixedBaseTable(t *testing.T) {
+	// The table of the base point is the compiled-in one.
+	tbl := NewFixedBaseTable(NewGeneratorPoint())
+	for i := range base {
+		for j := range base[i] {
+			if !preComputedEqual(&tbl.table[i][j], &base[i][j]) {
+				t.Fatalf("entry [%d][%d] differs from the compiled-in table", i, j)
+			}
+		}
+	}
+
+	rng := rand.New(rand.NewSource(1))
+	var k [32]byte
+	rng.Read(k[:])
+	k[31] &= 127
+	var p ExtendedGroupElement
+	GeScalarMultBase(&p, &k)
+	tbl = NewFixedBaseTable(&p)
+	for i := 0; i < 10; i++ {
+		var a [32]byte
+		rng.Read(a[:])
+		a[31] &= 127
+
+		var got ExtendedGroupElement
+		var want ProjectiveGroupElement
+		tbl.ScalarMult(&got, &a)
+		GeScalarMultVartime(&want, &a, &p, 5)
+		var gotBytes, wantBytes [32]byte
+		got.ToBytes(&gotBytes)
+		want.ToBytes(&wantBytes)
+		if gotBytes != wantBytes {
+			t.Errorf("%x*P = %x, want %x", a, gotBytes, wantBytes)
+		}
+	}
+}
+
+func preComputedEqual(p, q *PreComputedGroupElement) bool {
+	var a, b [32]byte
+	for _, pair := range [][2]*FieldElement{{&p.yPlusX, &q.yPlusX}, {&p.yMinusX, &q.yMinusX}, {&p.xy2d, &q.xy2d}} {
+		FeToBytes(&a, pair[0])
+		FeToBytes(&b, pair[1])
+		if a != b {
+			return false
+		}
+	}
+	return true
+}
+
+func TestFixedBaseTableMarshal(t *testing.T) {
+	var k = [32]byte{1, 2, 3}
+	var p ExtendedGroupElement
+	GeScalarMultBase(&p, &k)
+	tbl := NewFixedBaseTable(&p)
+	data, err := tbl.MarshalBinary()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(data) != tableSize {
+		t.Fatalf("encoded table is %d bytes, want %d", len(data), tableSize)
+	}
+
+	var loaded FixedBaseTable
+	if err := loaded.UnmarshalBinary(data); err != nil {
+		t.Fatal(err)
+	}
+	for i := range tbl.table {
+		for j := range tbl.table[i] {
+			if !preComputedEqual(&loaded.table[i][j], &tbl.table[i][j]) {
+				t.Fatalf("entry [%d][%d] does not round-trip", i, j)
+			}
+		}
+	}
+
+	// Any corruption is caught by the checksum.
+	for _, i := range []int{0, len(tableHeader), len(tableHeader) + 1000, tableSize - 1} {
+		bad := append([]byte{}, data...)
+		bad[i] ^= 1
+		if err := loaded.UnmarshalBinary(bad); err == nil {
+			t.Errorf("corruption at byte %d not detected", i)
+		}
+	}
+	if err := loaded.UnmarshalBinary(data[:len(data)-1]); err == nil {
+		t.Errorf("truncated table accepted")
+	}
+
+	// An entry that is not a point of the curve is rejected even with a
+	// valid checksum.
+	bad := append([]byte{}, data[:tableChecksumAt]...)
+	bad[len(tableHeader)+5*tableEntrySize+64] ^= 1
+	sum := sha256.Sum256(bad)
+	bad = append(bad, sum[:]...)
+	if err := loaded.UnmarshalBinary(bad); err == nil {
+		t.Errorf("table with an invalid entry accepted")
+	}
+}
+
+func TestSelectPoint(t *testing.T) {
+	var k = [32]byte{4, 5, 6}
+	var p ExtendedGroupElement
+	GeScalarMultBase(&p, &k)
+	row := NewFixedBaseTable(&p).Row(0)
+
+	var identity [32]byte
+	identity[0] = 1
+	for b := int32(-8); b <= 8; b++ {
+		// Adding |b|*P to b*P gives 2*b*P if b > 0 and the identity
+		// otherwise.
+		var sel PreComputedGroupElement
+		SelectPoint(&sel, row, b)
+		var abs [32]byte
+		if b < 0 {
+			abs[0] = byte(-b)
+		} else {
+			abs[0] = byte(b)
+		}
+		var h ExtendedGroupElement
+		var r CompletedGroupElement
+		h.Zero()
+		if abs[0] != 0 {
+			GeMixedAdd(&r, &h, &row[abs[0]-1])
+			r.ToExtended(&h)
+		}
+		GeMixedAdd(&r, &h, &sel)
+		r.ToExtended(&h)
+
+		want := identity
+		if b > 0 {
+			var wantPoint ProjectiveGroupElement
+			twice := [32]byte{byte(2 * b)}
+			GeScalarMultVartime(&wantPoint, &twice, &p, 5)
+			wantPoint.ToBytes(&want)
+		}
+		var got [32]byte
+		h.ToBytes(&got)
+		if got != want {
+			t.Errorf("SelectPoint(%d) is not %d*P", b, b)
+		}
+	}
+}
+
+func BenchmarkNewFixedBaseTable(b *testing.B) {
+	for i := 0; i < b.N; i++ {
+		NewFixedBaseTable(NewGeneratorPoint())
+	}
+}
+
+func BenchmarkUnmarshalFixedBaseTable(b *testing.B) {
+	data, _ := NewFixedBaseTable(NewGeneratorPoint()).MarshalBinary()
+	var tbl FixedBaseTable
+	for i := 0; i < b.N; i++ {
+		if err := tbl.UnmarshalBinary(data); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
@@ -0,0 +1,114 @@
+// Copyright 2018 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package ed25519
+
+import (
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strconv"
+)
+
+// jwk holds the members of a JSON Web Key, as defined by RFC 7517, that are
+// used by Ed25519 keys, per RFC 8037, Section 2. Other members are ignored.
+type jwk struct {
+	Kty string `json:"kty"`
+	Crv string `json:"crv"`
+	X   string `json:"x"`
+	D   string `json:"d,omitempty"`
+}
+
+var (
+	errJWKType   = errors.New("ed25519: JWK is not an OKP key on the Ed25519 curve")
+	errJWKX      = errors.New("ed25519: JWK has a malformed x member")
+	errJWKD      = errors.New("ed25519: JWK has a malformed d member")
+	errJWKNoD    = errors.New("ed25519: JWK is not a private key")
+	errJWKPublic = errors.New("ed25519: JWK public key does not match its private key")
+)
+
+// MarshalPublicJWK returns the JSON Web Key of publicKey, as defined by RFC
+// 8037, Section 2: {"crv":"Ed25519","kty":"OKP","x":...}. Its members are
+// sorted and it has no whitespace, so it is also the input of the thumbprint.
+// It will panic if len(publicKey) is not PublicKeySize.
+func MarshalPublicJWK(publicKey PublicKey) []byte {
+	if l := len(publicKey); l != PublicKeySize {
+		panic("ed25519: bad public key length: " + strconv.Itoa(l))
+	}
+	// Member order is important.
+	// See https://tools.ietf.org/html/rfc7638#section-3.3 for details.
+	return []byte(fmt.Sprintf(`{"crv":"Ed25519","kty":"OKP","x":"%s"}`,
+		base64.RawURLEncoding.EncodeToString(publicKey)))
+}
+
+// MarshalPrivateJWK returns the JSON Web Key of privateKey, which is the
+// public key JWK with an additional d member holding the seed. It will panic
+// if len(privateKey) is not PrivateKeySize.
+func MarshalPrivateJWK(privateKey PrivateKey) []byte {
+	if l := len(privateKey); l != PrivateKeySize {
+		panic("ed25519: bad private key length: " + strconv.Itoa(l))
+	}
+	return []byte(fmt.Sprintf(`{"crv":"Ed25519","d":"%s","kty":"OKP","x":"%s"}`,
+		base64.RawURLEncoding.EncodeToString(privateKey[:SeedSize]),
+		base64.RawURLEncoding.EncodeToString(privateKey[SeedSize:])))
+}
+
+// jwkEncoding rejects non-zero padding bits, so that each key has a single
+// encoding.
+var jwkEncoding = base64.RawURLEncoding.Strict()
+
+func parseJWK(data []byte) (*jwk, PublicKey, error) {
+	var k jwk
+	if err := json.Unmarshal(data, &k); err != nil {
+		return nil, nil, err
+	}
+	if k.Kty != "OKP" || k.Crv != "Ed25519" {
+		return nil, nil, errJWKType
+	}
+	x, err := jwkEncoding.DecodeString(k.X)
+	if err != nil || len(x) != PublicKeySize {
+		return nil, nil, errJWKX
+	}
+	return &k, PublicKey(x), nil
+}
+
+// ParsePublicJWK parses the JSON Web Key of an Ed25519 public key. If data is
+// the JWK of a private key, its public key is returned.
+func ParsePublicJWK(data []byte) (PublicKey, error) {
+	_, publicKey, err := parseJWK(data)
+	return publicKey, err
+}
+
+// ParsePrivateJWK parses the JSON Web Key of an Ed25519 private key, and
+// checks that its public key matches its seed.
+func ParsePrivateJWK(data []byte) (PrivateKey, error) {
+	k, publicKey, err := parseJWK(data)
+	if err != nil {
+		return nil, err
+	}
+	if k.D == "" {
+		return nil, errJWKNoD
+	}
+	seed, err := jwkEncoding.DecodeString(k.D)
+	if err != nil || len(seed) != SeedSize {
+		return nil, errJWKD
+	}
+	privateKey := NewKeyFromSeed(seed)
+	if subtle.ConstantTimeCompare(privateKey[SeedSize:], publicKey) != 1 {
+		return nil, errJWKPublic
+	}
+	return privateKey, nil
+}
+
+// JWKThumbprint returns the JWK thumbprint of publicKey, as defined by RFC
+// 7638: the unpadded base64url encoding of the SHA-256 digest of
+// MarshalPublicJWK(publicKey). It will panic if len(publicKey) is not
+// PublicKeySize.
+func JWKThumbprint(publicKey PublicKey) string {
+	b := sha256.Sum256(MarshalPublicJWK(publicKey))
+	return base64.RawURLEncoding.EncodeToString(b[:])
+}
@@ -0,0 +1,102 @@
+// Copyright 2018 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package ed25519
+
+import (
+	"bytes"
+	"encoding/hex"
+	"testing"
+)
+
+// The key of RFC 8037, Appendix A.1 and A.2.
+const (
+	rfc8037PrivateJWK = `{"kty":"OKP","crv":"Ed25519",
+   "d":"nWGxne_9WmC6hEr0kuwsxERJxWl7MmkZcDusAxyuf2A",
+   "x":"11qYAYKxCrfVS_7TyWQHOg7hcvPapiMlrwIaaPcHURo"}`
+	rfc8037PublicJWK = `{"kty":"OKP","crv":"Ed25519",
+   "x":"11qYAYKxCrfVS_7TyWQHOg7hcvPapiMlrwIaaPcHURo"}`
+)
+
+func TestJWK(t *testing.T) {
+	priv, err := ParsePrivateJWK([]byte(rfc8037PrivateJWK))
+	if err != nil {
+		t.Fatal(err)
+	}
+	pub, err := ParsePublicJWK([]byte(rfc8037PublicJWK))
+	if err != nil {
+		t.Fatal(err)
+	}
+	// The key is also the first test vector of RFC 8032, Section 7.1.
+	seed, _ := hex.DecodeString("9d61b19deffd5a60ba844af492ec2cc44449c5697b326919703bac031cae7f60")
+	if want := NewKeyFromSeed(seed); !bytes.Equal(priv, want) {
+		t.Errorf("ParsePrivateJWK = %x, want %x", priv, want)
+	}
+	if !bytes.Equal(pub, priv.Public().(PublicKey)) {
+		t.Errorf("ParsePublicJWK = %x, want %x", pub, priv.Public())
+	}
+	if got, err := ParsePublicJWK([]byte(rfc8037PrivateJWK)); err != nil || !bytes.Equal(got, pub) {
+		t.Errorf("ParsePublicJWK(private JWK) = %x, %v, want %x", got, err, pub)
+	}
+
+	wantPub := `{"crv":"Ed25519","kty":"OKP","x":"11qYAYKxCrfVS_7TyWQHOg7hcvPapiMlrwIaaPcHURo"}`
+	if got := string(MarshalPublicJWK(pub)); got != wantPub {
+		t.Errorf("MarshalPublicJWK = %s, want %s", got, wantPub)
+	}
+	wantPriv := `{"crv":"Ed25519","d":"nWGxne_9WmC6hEr0kuwsxERJxWl7MmkZcDusAxyuf2A","kty":"OKP","x":"11qYAYKxCrfVS_7TyWQHOg7hcvPapiMlrwIaaPcHURo"}`
+	if got := string(MarshalPrivateJWK(priv)); got != wantPriv {
+		t.Errorf("MarshalPrivateJWK = %s, want %s", got, wantPriv)
+	}
+
+	// RFC 8037, Appendix A.3.
+	if got, want := JWKThumbprint(pub), "kPrK_qmxVWaYVA9wwBF6Iuo3vVzz7TxHCTwXBygrS4k"; got != want {
+		t.Errorf("JWKThumbprint = %s, want %s", got, want)
+	}
+}
+
+func TestParseJWKErrors(t *testing.T) {
+	for _, s := range []string{
+		``,
+		`[]`,
+		`{"kty":"EC","crv":"Ed25519","x":"11qYAYKxCrfVS_7TyWQHOg7hcvPapiMlrwIaaPcHURo"}`,
+		`{"kty":"OKP","crv":"X25519","x":"11qYAYKxCrfVS_7TyWQHOg7hcvPapiMlrwIaaPcHURo"}`,
+		`{"kty":"OKP","crv":"Ed25519"}`,
+		`{"kty":"OKP","crv":"Ed25519","x":"11qYAYKxCrfVS_7TyWQHOg7hcvPapiMlrwIaaPcHUR"}`,
+		`{"kty":"OKP","crv":"Ed25519","x":"11qYAYKxCrfVS_7TyWQHOg7hcvPapiMlrwIaaPcHURo="}`,
+		`{"kty":"OKP","crv":"Ed25519","x":"11qYAYKxCrfVS/7TyWQHOg7hcvPapiMlrwIaaPcHURo"}`,
+		// Non-zero padding bits.
+		`{"kty":"OKP","crv":"Ed25519","x":"11qYAYKxCrfVS_7TyWQHOg7hcvPapiMlrwIaaPcHURp"}`,
+	} {
+		if _, err := ParsePublicJWK([]byte(s)); err == nil {
+			t.Errorf("ParsePublicJWK(%s) succeeded", s)
+		}
+		if _, err := ParsePrivateJWK([]byte(s)); err == nil {
+			t.Errorf("ParsePrivateJWK(%s) succeeded", s)
+		}
+	}
+	for _, s := range []string{
+		rfc8037PublicJWK,
+		`{"kty":"OKP","crv":"Ed25519","d":"nWGxne_9WmC6hEr0kuwsxERJxWl7MmkZcDusAxyuf2",
+		  "x":"11qYAYKxCrfVS_7TyWQHOg7hcvPapiMlrwIaaPcHURo"}`,
+		// The seed of the RFC key, with the public key of another.
+		`{"kty":"OKP","crv":"Ed25519","d":"nWGxne_9WmC6hEr0kuwsxERJxWl7MmkZcDusAxyuf2A",
+		  "x":"21qYAYKxCrfVS_7TyWQHOg7hcvPapiMlrwIaaPcHURo"}`,
+	} {
+		if _, err := ParsePrivateJWK([]byte(s)); err == nil {
+			t.Errorf("ParsePrivateJWK(%s) succeeded", s)
+		}
+	}
+}
+
+func TestJWKRoundTrip(t *testing.T) {
+	pub, priv, _ := GenerateKey(nil)
+	gotPriv, err := ParsePrivateJWK(MarshalPrivateJWK(priv))
+	if err != nil || !bytes.Equal(gotPriv, priv) {
+		t.Errorf("private JWK round trip = %x, %v, want %x", gotPriv, err, priv)
+	}
+	gotPub, err := ParsePublicJWK(MarshalPublicJWK(pub))
+	if err != nil || !bytes.Equal(gotPub, pub) {
+		t.Errorf("public JWK round trip = %x, %v, want %x", gotPub, err, pub)
+	}
+}
@@ -0,0 +1,189 @@
+// Copyright 2018 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package ed25519
+
+import (
+	"bytes"
+	encoding_asn1 "encoding/asn1"
+	"encoding/pem"
+	"errors"
+
+	"golang.org/x/crypto/cryptobyte"
+	"golang.org/x/crypto/cryptobyte/asn1"
+)
+
+// oidEd25519 is the algorithm identifier of Ed25519 keys, RFC 8410,
+// Section 3.
+var oidEd25519 = encoding_asn1.ObjectIdentifier{1, 3, 101, 112}
+
+// The PEM block types of the PKCS #8 and PKIX encodings, as used by OpenSSL.
+const (
+	pemPrivateKeyType = "PRIVATE KEY"
+	pemPublicKeyType  = "PUBLIC KEY"
+)
+
+var (
+	errNotEd25519   = errors.New("ed25519: key is not an Ed25519 key")
+	errMalformedKey = errors.New("ed25519: malformed key encoding")
+)
+
+// addAlgorithm adds the AlgorithmIdentifier of Ed25519, whose parameters
+// are absent, to b.
+func addAlgorithm(b *cryptobyte.Builder) {
+	b.AddASN1(asn1.SEQUENCE, func(b *cryptobyte.Builder) {
+		b.AddASN1ObjectIdentifier(oidEd25519)
+	})
+}
+
+// readAlgorithm reads an AlgorithmIdentifier from s and checks that it is
+// the one of Ed25519.
+func readAlgorithm(s *cryptobyte.String) error {
+	var algo cryptobyte.String
+	var oid encoding_asn1.ObjectIdentifier
+	if !s.ReadASN1(&algo, asn1.SEQUENCE) || !algo.ReadASN1ObjectIdentifier(&oid) {
+		return errMalformedKey
+	}
+	if !oid.Equal(oidEd25519) {
+		return errNotEd25519
+	}
+	if !algo.Empty() {
+		return errMalformedKey
+	}
+	return nil
+}
+
+// MarshalPKCS8PrivateKey returns the PKCS #8 encoding of priv, in the form of
+// RFC 8410, Section 7, which holds the seed of the key, as OpenSSL does.
+func MarshalPKCS8PrivateKey(priv PrivateKey) ([]byte, error) {
+	if len(priv) != PrivateKeySize {
+		return nil, errors.New("ed25519: bad private key length")
+	}
+	var b cryptobyte.Builder
+	b.AddASN1(asn1.SEQUENCE, func(b *cryptobyte.Builder) {
+		b.AddASN1Int64(0) // version
+		addAlgorithm(b)
+		b.AddASN1(asn1.OCTET_STRING, func(b *cryptobyte.Builder) {
+			b.AddASN1OctetString(priv.Seed())
+		})
+	})
+	return b.Bytes()
+}
+
+// ParsePKCS8PrivateKey parses an Ed25519 private key in PKCS #8 form, as
+// defined in RFC 8410, Section 7. Both the version 1 and version 2
+// (RFC 5958) structures are accepted; if the latter holds the public key, it
+// must match the private key. Attributes are ignored.
+func ParsePKCS8PrivateKey(der []byte) (PrivateKey, error) {
+	var (
+		s, info, key cryptobyte.String
+		version      int64
+		seed, pub    []byte
+		hasPub       bool
+		pubBitString cryptobyte.String
+		attributeTag = asn1.Tag(0).ContextSpecific().Constructed()
+		publicKeyTag = asn1.Tag(1).ContextSpecific()
+	)
+	s = cryptobyte.String(der)
+	if !s.ReadASN1(&info, asn1.SEQUENCE) || !s.Empty() ||
+		!info.ReadASN1Integer(&version) || (version != 0 && version != 1) {
+		return nil, errMalformedKey
+	}
+	if err := readAlgorithm(&info); err != nil {
+		return nil, err
+	}
+	if !info.ReadASN1(&key, asn1.OCTET_STRING) ||
+		!key.ReadASN1Bytes(&seed, asn1.OCTET_STRING) || !key.Empty() ||
+		len(seed) != SeedSize {
+		return nil, errMalformedKey
+	}
+	if !info.SkipOptionalASN1(attributeTag) ||
+		!info.ReadOptionalASN1(&pubBitString, &hasPub, publicKeyTag) || !info.Empty() {
+		return nil, errMalformedKey
+	}
+	priv := NewKeyFromSeed(seed)
+	if hasPub {
+		if version != 1 || len(pubBitString) == 0 || pubBitString[0] != 0 {
+			return nil, errMalformedKey
+		}
+		pub = pubBitString[1:]
+		if !bytes.Equal(pub, priv[32:]) {
+			return nil, errors.New("ed25519: PKCS #8 public key does not match the private key")
+		}
+	}
+	return priv, nil
+}
+
+// MarshalPKIXPublicKey returns the SubjectPublicKeyInfo encoding of pub, as
+// defined in RFC 8410, Section 4.
+func MarshalPKIXPublicKey(pub PublicKey) ([]byte, error) {
+	if len(pub) != PublicKeySize {
+		return nil, errors.New("ed25519: bad public key length")
+	}
+	var b cryptobyte.Builder
+	b.AddASN1(asn1.SEQUENCE, func(b *cryptobyte.Builder) {
+		addAlgorithm(b)
+		b.AddASN1BitString(pub)
+	})
+	return b.Bytes()
+}
+
+// ParsePKIXPublicKey parses an Ed25519 public key in SubjectPublicKeyInfo
+// form, as defined in RFC 8410, Section 4. It returns an error if the key is
+// of another type.
+func ParsePKIXPublicKey(der []byte) (PublicKey, error) {
+	var s, info cryptobyte.String
+	var pub []byte
+	s = cryptobyte.String(der)
+	if !s.ReadASN1(&info, asn1.SEQUENCE) || !s.Empty() {
+		return nil, errMalformedKey
+	}
+	if err := readAlgorithm(&info); err != nil {
+		return nil, err
+	}
+	if !info.ReadASN1BitStringAsBytes(&pub) || !info.Empty() || len(pub) != PublicKeySize {
+		return nil, errMalformedKey
+	}
+	return PublicKey(pub), nil
+}
+
+// MarshalPrivateKeyPEM returns the PKCS #8 encoding of priv in a "PRIVATE
+// KEY" PEM block, the format of OpenSSL.
+func MarshalPrivateKeyPEM(priv PrivateKey) ([]byte, error) {
+	der, err := MarshalPKCS8PrivateKey(priv)
+	if err != nil {
+		return nil, err
+	}
+	return pem.EncodeToMemory(&pem.Block{Type: pemPrivateKeyType, Bytes: der}), nil
+}
+
+// ParsePrivateKeyPEM parses the first PEM block of data, which must be a
+// "PRIVATE KEY" block holding a PKCS #8 Ed25519 private key.
+func ParsePrivateKeyPEM(data []byte) (PrivateKey, error) {
+	block, _ := pem.Decode(data)
+	if block == nil || block.Type != pemPrivateKeyType {
+		return nil, errors.New("ed25519: no PRIVATE KEY PEM block found")
+	}
+	return ParsePKCS8PrivateKey(block.Bytes)
+}
+
+// MarshalPublicKeyPEM returns the SubjectPublicKeyInfo encoding of pub in a
+// "PUBLIC KEY" PEM block, the format of OpenSSL.
+func MarshalPublicKeyPEM(pub PublicKey) ([]byte, error) {
+	der, err := MarshalPKIXPublicKey(pub)
+	if err != nil {
+		return nil, err
+	}
+	return pem.EncodeToMemory(&pem.Block{Type: pemPublicKeyType, Bytes: der}), nil
+}
+
+// ParsePublicKeyPEM parses the first PEM block of data, which must be a
+// "PUBLIC KEY" block holding an Ed25519 SubjectPublicKeyInfo.
+func ParsePublicKeyPEM(data []byte) (PublicKey, error) {
+	block, _ := pem.Decode(data)
+	if block == nil || block.Type != pemPublicKeyType {
+		return nil, errors.New("ed25519: no PUBLIC KEY PEM block found")
+	}
+	return ParsePKIXPublicKey(block.Bytes)
+}
@@ -0,0 +1,58 @@
+// Copyright 2018 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package ed25519
+
+import (
+	"errors"
+
+	"golang.org/x/crypto/ed25519/internal/edwards25519"
+)
+
+// A PrecomputedPublicKey is a public key prepared for verifying many
+// signatures. Verify decompresses the public key and computes a table of its
+// multiples for every signature; a PrecomputedPublicKey does it once, which
+// makes its Verify method faster for services that check many signatures
+// from a few keys, such as update servers or certificate mirrors.
+//
+// A PrecomputedPublicKey is safe for concurrent use.
+type PrecomputedPublicKey struct {
+	publicKey [PublicKeySize]byte
+	// negA is the table of odd multiples of the negation of the public key
+	// point, -A, as used by the verification equation.
+	negA edwards25519.NAFTable
+}
+
+// NewPrecomputedPublicKey decompresses publicKey and precomputes the values
+// its signatures are verified with. It returns an error if publicKey is not a
+// valid encoding, in which case Verify would reject all its signatures.
+func NewPrecomputedPublicKey(publicKey PublicKey) (*PrecomputedPublicKey, error) {
+	if len(publicKey) != PublicKeySize {
+		return nil, errors.New("ed25519: bad public key length")
+	}
+	k := new(PrecomputedPublicKey)
+	copy(k.publicKey[:], publicKey)
+	var A edwards25519.ExtendedGroupElement
+	if !A.FromBytes(&k.publicKey) {
+		return nil, errors.New("ed25519: invalid public key encoding")
+	}
+	edwards25519.FeNeg(&A.X, &A.X)
+	edwards25519.FeNeg(&A.T, &A.T)
+	k.negA.FromPoint(&A)
+	return k, nil
+}
+
+// PublicKey returns the public key k was made from.
+func (k *PrecomputedPublicKey) PublicKey() PublicKey {
+	return append(PublicKey(nil), k.publicKey[:]...)
+}
+
+// Verify reports whether sig is a valid signature of message by k, with the
+// same result as the package-level Verify. It does not allocate.
+func (k *PrecomputedPublicKey) Verify(message, sig []byte) bool {
+	if len(sig) != SignatureSize || sig[63]&224 != 0 {
+		return false
+	}
+	return verifyTable(k.publicKey[:], &k.negA, message, sig, nil)
+}
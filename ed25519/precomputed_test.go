@@ -0,0 +1,94 @@
+// Copyright 2018 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package ed25519
+
+import (
+	"bytes"
+	"crypto/rand"
+	"testing"
+)
+
+func TestPrecomputedPublicKey(t *testing.T) {
+	for i := 0; i < 20; i++ {
+		pub, priv, _ := GenerateKey(nil)
+		k, err := NewPrecomputedPublicKey(pub)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !bytes.Equal(k.PublicKey(), pub) {
+			t.Errorf("PublicKey() = %x, want %x", k.PublicKey(), pub)
+		}
+
+		message := make([]byte, i)
+		rand.Read(message)
+		sig := Sign(priv, message)
+		if !k.Verify(message, sig) {
+			t.Errorf("valid signature rejected")
+		}
+		for _, bad := range [][]byte{
+			sig[:SignatureSize-1],
+			append([]byte{sig[0] ^ 1}, sig[1:]...),
+			append(append([]byte(nil), sig[:63]...), sig[63]^1),
+		} {
+			if k.Verify(message, bad) != Verify(pub, message, bad) {
+				t.Errorf("Verify disagrees with the package-level Verify on %x", bad)
+			}
+		}
+		if k.Verify(append(message, 0), sig) {
+			t.Errorf("signature of another message accepted")
+		}
+		other, _, _ := GenerateKey(nil)
+		ko, _ := NewPrecomputedPublicKey(other)
+		if ko.Verify(message, sig) {
+			t.Errorf("signature accepted for another key")
+		}
+	}
+
+	if _, err := NewPrecomputedPublicKey(make([]byte, PublicKeySize-1)); err == nil {
+		t.Error("short public key accepted")
+	}
+	// y = 2 is not the y coordinate of a point.
+	invalid := make([]byte, PublicKeySize)
+	invalid[0] = 2
+	if _, err := NewPrecomputedPublicKey(invalid); err == nil {
+		t.Error("invalid public key accepted")
+	}
+}
+
+func TestPrecomputedAllocations(t *testing.T) {
+	if raceEnabled {
+		t.Skip("skipping allocation test with the race detector")
+	}
+	var zero zeroReader
+	pub, priv, _ := GenerateKey(zero)
+	k, err := NewPrecomputedPublicKey(pub)
+	if err != nil {
+		t.Fatal(err)
+	}
+	message := []byte("test message")
+	sig := Sign(priv, message)
+	if allocs := testing.AllocsPerRun(10, func() { k.Verify(message, sig) }); allocs > 0 {
+		t.Errorf("Verify allocated %v times", allocs)
+	}
+}
+
+func BenchmarkPrecomputedVerification(b *testing.B) {
+	var zero zeroReader
+	pub, priv, err := GenerateKey(zero)
+	if err != nil {
+		b.Fatal(err)
+	}
+	k, err := NewPrecomputedPublicKey(pub)
+	if err != nil {
+		b.Fatal(err)
+	}
+	message := []byte("Hello, world!")
+	signature := Sign(priv, message)
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		k.Verify(message, signature)
+	}
+}
@@ -0,0 +1,135 @@
+// Copyright 2018 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package ed25519
+
+import (
+	"crypto"
+	"crypto/sha512"
+	"errors"
+	"strconv"
+)
+
+// Options can be passed to PrivateKey.Sign and ExpandedPrivateKey.Sign to
+// sign with Ed25519ph or Ed25519ctx, or to hedge signatures, and to
+// VerifyWithOptions to also select the checks made by verification.
+type Options struct {
+	// Hash is crypto.SHA512 for Ed25519ph, with a pre-hashed message, or zero
+	// for Ed25519 and Ed25519ctx.
+	Hash crypto.Hash
+
+	// Context is the context string, at most 255 bytes long. If Hash is zero,
+	// a non-empty Context selects Ed25519ctx rather than Ed25519.
+	Context string
+
+	// Hedged mixes random bytes into the nonce of the signature, in addition
+	// to the private key and the message, so that signatures are no longer
+	// deterministic. It protects against fault attacks, in which an error
+	// injected while signing, for example with a voltage glitch, makes two
+	// signatures share a nonce but not a challenge, revealing the private key.
+	// If the random bytes are predictable, a hedged signature is as secure as
+	// a deterministic one. Verification is unaffected.
+	Hedged bool
+
+	// The remaining fields are only used by VerifyWithOptions. Their zero
+	// values make it as strict as Verify.
+
+	// AllowNonCanonicalS accepts signatures whose S is not reduced modulo
+	// the group order, as long as its top three bits are clear, as the
+	// original ref10 implementation did. Such signatures are malleable.
+	AllowNonCanonicalS bool
+
+	// Encodings selects the encodings of R and of the public key that are
+	// accepted.
+	Encodings PointEncodings
+
+	// RejectSmallOrderKeys rejects public keys of small order, for which a
+	// signature can be valid for many messages. They cannot be produced by
+	// GenerateKey.
+	RejectSmallOrderKeys bool
+
+	// Cofactored selects the cofactored verification equation,
+	// [8][S]B = [8]R + [8][k]A, instead of the cofactorless one,
+	// [S]B = R + [k]A. VerifyBatch uses the cofactored equation.
+	Cofactored bool
+}
+
+// HashFunc returns o.Hash.
+func (o *Options) HashFunc() crypto.Hash { return o.Hash }
+
+// SignPrehashed signs digest, the SHA-512 digest of a message, with
+// privateKey using Ed25519ph, as defined in RFC 8032, Section 5.1. context is
+// an optional context string, at most 255 bytes long, that must be passed to
+// VerifyPrehashed as well. It will panic if len(privateKey) is not
+// PrivateKeySize.
+//
+// Unlike Ed25519, Ed25519ph reads the message only once, so a large message
+// can be streamed through a hash.Hash returned by crypto/sha512.New, whose
+// Sum(nil) is then the digest. Ed25519ph signatures do not verify as Ed25519
+// signatures of the message, nor of the digest.
+func SignPrehashed(privateKey PrivateKey, digest []byte, context string) ([]byte, error) {
+	if l := len(privateKey); l != PrivateKeySize {
+		panic("ed25519: bad private key length: " + strconv.Itoa(l))
+	}
+	if len(digest) != sha512.Size {
+		return nil, errors.New("ed25519: bad Ed25519ph digest length")
+	}
+	dom, err := dom2(1, context)
+	if err != nil {
+		return nil, err
+	}
+	return privateKey.Expand().sign(digest, dom, nil), nil
+}
+
+// VerifyPrehashed reports whether sig is a valid Ed25519ph signature of
+// digest, the SHA-512 digest of a message, by publicKey with the given
+// context. It will panic if len(publicKey) is not PublicKeySize.
+func VerifyPrehashed(publicKey PublicKey, digest, sig []byte, context string) bool {
+	if l := len(publicKey); l != PublicKeySize {
+		panic("ed25519: bad public key length: " + strconv.Itoa(l))
+	}
+	if len(digest) != sha512.Size {
+		return false
+	}
+	dom, err := dom2(1, context)
+	if err != nil {
+		return false
+	}
+	return verify(publicKey, digest, sig, dom)
+}
+
+// dom2 returns the prefix of RFC 8032, Section 5.1, that separates the hashes
+// of Ed25519ph, for which phflag is 1, and of Ed25519ctx, for which it is 0,
+// from those of Ed25519 and from each other.
+func dom2(phflag byte, context string) ([]byte, error) {
+	if len(context) > 255 {
+		return nil, errors.New("ed25519: context too long")
+	}
+	dom := []byte("SigEd25519 no Ed25519 collisions")
+	dom = append(dom, phflag, byte(len(context)))
+	return append(dom, context...), nil
+}
+
+// signerDomain returns the prefix selected by the opts passed to a
+// crypto.Signer Sign method for message, or nil for Ed25519.
+func signerDomain(message []byte, opts crypto.SignerOpts) ([]byte, error) {
+	var context string
+	if o, ok := opts.(*Options); ok {
+		context = o.Context
+	}
+	switch opts.HashFunc() {
+	case crypto.Hash(0):
+		if context == "" {
+			return nil, nil
+		}
+		return dom2(0, context)
+	case crypto.SHA512:
+		if len(message) != sha512.Size {
+			return nil, errors.New("ed25519: bad Ed25519ph digest length")
+		}
+		return dom2(1, context)
+	default:
+		return nil, errors.New("ed25519: expected opts.HashFunc() zero (unhashed message, for standard Ed25519) or SHA-512 (for Ed25519ph)")
+	}
+}
@@ -0,0 +1,156 @@
+// Copyright 2018 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package ed25519
+
+import (
+	"bytes"
+	"crypto"
+	"crypto/rand"
+	"crypto/sha512"
+	"encoding/hex"
+	"strings"
+	"testing"
+)
+
+func TestSignPrehashed(t *testing.T) {
+	// The test vector of RFC 8032, Section 7.3.
+	seed, _ := hex.DecodeString("833fe62409237b9d62ec77587520911e9a759cec1d19755b7da901b96dca3d42")
+	pub, _ := hex.DecodeString("ec172b93ad5e563bf4932c70e1245034c35467ef2efd4d64ebf819683467e2bf")
+	want, _ := hex.DecodeString("98a70222f0b8121aa9d30f813d683f809e462b469c7ff87639499bb94e6dae4131f85042463c2a355a2003d062adf5aaa10b8c61e636062aaad11c2a26083406")
+	priv := NewKeyFromSeed(seed)
+	if !bytes.Equal(priv[32:], pub) {
+		t.Fatalf("public key = %x, want %x", priv[32:], pub)
+	}
+
+	h := sha512.New()
+	h.Write([]byte("abc"))
+	digest := h.Sum(nil)
+	sig, err := SignPrehashed(priv, digest, "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(sig, want) {
+		t.Errorf("SignPrehashed = %x, want %x", sig, want)
+	}
+	if !VerifyPrehashed(pub, digest, sig, "") {
+		t.Errorf("VerifyPrehashed rejected the test vector")
+	}
+
+	for _, signer := range []crypto.Signer{priv, priv.Expand()} {
+		sig, err := signer.Sign(nil, digest, crypto.SHA512)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !bytes.Equal(sig, want) {
+			t.Errorf("%T.Sign = %x, want %x", signer, sig, want)
+		}
+	}
+
+	// Ed25519ph and Ed25519 signatures are not interchangeable.
+	if Verify(pub, []byte("abc"), sig) || Verify(pub, digest, sig) {
+		t.Errorf("Ed25519ph signature accepted by Verify")
+	}
+	if VerifyPrehashed(pub, digest, Sign(priv, digest), "") {
+		t.Errorf("Ed25519 signature accepted by VerifyPrehashed")
+	}
+	if VerifyPrehashed(pub, digest, sig, "context") {
+		t.Errorf("signature accepted with the wrong context")
+	}
+	if VerifyPrehashed(pub, digest[:32], sig, "") {
+		t.Errorf("short digest accepted")
+	}
+}
+
+func TestSignPrehashedContext(t *testing.T) {
+	_, priv, err := GenerateKey(nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	pub := priv.Public().(PublicKey)
+	digest := sha512.Sum512([]byte("message"))
+
+	sig, err := SignPrehashed(priv, digest[:], "context")
+	if err != nil {
+		t.Fatal(err)
+	}
+	opts := &Options{Hash: crypto.SHA512, Context: "context"}
+	sig2, err := priv.Sign(nil, digest[:], opts)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(sig, sig2) {
+		t.Errorf("Sign with Options = %x, want %x", sig2, sig)
+	}
+	if !VerifyPrehashed(pub, digest[:], sig, "context") {
+		t.Errorf("signature with context rejected")
+	}
+	if VerifyPrehashed(pub, digest[:], sig, "") {
+		t.Errorf("signature with context accepted without it")
+	}
+
+	long := strings.Repeat("x", 256)
+	if _, err := SignPrehashed(priv, digest[:], long); err == nil {
+		t.Errorf("long context accepted")
+	}
+	if _, err := SignPrehashed(priv, digest[:63], ""); err == nil {
+		t.Errorf("short digest accepted")
+	}
+	if _, err := priv.Sign(nil, digest[:], &Options{Hash: crypto.SHA512, Context: long}); err == nil {
+		t.Errorf("long context accepted by Sign")
+	}
+	if _, err := priv.Sign(nil, digest[:32], crypto.SHA512); err == nil {
+		t.Errorf("short digest accepted by Sign")
+	}
+	if _, err := priv.Sign(nil, digest[:32], crypto.SHA256); err == nil {
+		t.Errorf("SHA-256 digest accepted by Sign")
+	}
+}
+
+func TestSignerOpts(t *testing.T) {
+	_, priv, _ := GenerateKey(rand.Reader)
+	message := []byte("message")
+	digest := sha512.Sum512(message)
+	ph, _ := SignPrehashed(priv, digest[:], "")
+	phContext, _ := SignPrehashed(priv, digest[:], "context")
+	ctx, _ := SignWithContext(priv, message, "context")
+
+	tests := []struct {
+		opts    crypto.SignerOpts
+		message []byte
+		want    []byte
+	}{
+		{crypto.Hash(0), message, Sign(priv, message)},
+		{&Options{}, message, Sign(priv, message)},
+		{crypto.SHA512, digest[:], ph},
+		{&Options{Hash: crypto.SHA512}, digest[:], ph},
+		{&Options{Hash: crypto.SHA512, Context: "context"}, digest[:], phContext},
+		{&Options{Context: "context"}, message, ctx},
+		{crypto.SHA256, message, nil},
+		{&Options{Hash: crypto.SHA384}, message, nil},
+	}
+	for _, signer := range []crypto.Signer{priv, priv.Expand()} {
+		pub := signer.Public().(PublicKey)
+		for i, test := range tests {
+			sig, err := signer.Sign(rand.Reader, test.message, test.opts)
+			if test.want == nil {
+				if err == nil {
+					t.Errorf("%T #%d: %v accepted", signer, i, test.opts)
+				}
+				continue
+			}
+			if err != nil || !bytes.Equal(sig, test.want) {
+				t.Errorf("%T #%d: Sign = %x, %v, want %x", signer, i, sig, err, test.want)
+				continue
+			}
+			opts, ok := test.opts.(*Options)
+			if !ok {
+				opts = &Options{Hash: test.opts.HashFunc()}
+			}
+			if err := VerifyWithOptions(pub, test.message, sig, opts); err != nil {
+				t.Errorf("%T #%d: VerifyWithOptions = %v", signer, i, err)
+			}
+		}
+	}
+}
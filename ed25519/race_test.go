@@ -0,0 +1,11 @@
+// Copyright 2018 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+// +build race
+
+package ed25519
+
+// raceEnabled is true when the race detector is enabled. It makes sync.Pool
+// drop items at random, so allocations cannot be measured.
+const raceEnabled = true
@@ -0,0 +1,123 @@
+// Copyright 2018 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package ed25519
+
+import (
+	cryptorand "crypto/rand"
+	"crypto/subtle"
+	"errors"
+	"io"
+	"strconv"
+
+	"golang.org/x/crypto/ed25519/internal/edwards25519"
+)
+
+// Ed25519 private keys come in three forms, which are all made of 32 or 64
+// random-looking bytes, and which must not be mistaken for one another:
+//
+//   - a SecretSeed is the 32-byte private key of RFC 8032;
+//   - a PrivateKey is the 64-byte concatenation of a seed and its public key;
+//   - an ExpandedPrivateKey holds the 64-byte hash of a seed, the secret
+//     scalar and the nonce prefix.
+//
+// Feeding one where another is expected does not fail by itself, but gives
+// an unrelated key, or signatures that leak the private key: a PrivateKey
+// whose second half is not the public key of its seed makes signatures from
+// which the private key can be computed. SecretSeed and ExpandedPrivateKey
+// are distinct types that can only be converted with the methods below, and
+// NewPrivateKey checks that a 64-byte blob is a PrivateKey. The byte slice
+// functions, such as NewKeyFromSeed and Sign, are kept for compatibility.
+
+// A SecretSeed is the private key of RFC 8032, from which the secret scalar,
+// the nonce prefix and the public key are derived. It is a distinct type so
+// that it is never mistaken for the first half of a PrivateKey or of an
+// expanded key.
+type SecretSeed [SeedSize]byte
+
+// NewSecretSeed returns a copy of the seed b, which must be SeedSize bytes
+// long.
+func NewSecretSeed(b []byte) (*SecretSeed, error) {
+	if len(b) != SeedSize {
+		return nil, errors.New("ed25519: bad seed length: " + strconv.Itoa(len(b)))
+	}
+	s := new(SecretSeed)
+	copy(s[:], b)
+	return s, nil
+}
+
+// GenerateSecretSeed returns a seed read from rand. If rand is nil,
+// crypto/rand.Reader is used.
+func GenerateSecretSeed(rand io.Reader) (*SecretSeed, error) {
+	if rand == nil {
+		rand = cryptorand.Reader
+	}
+	s := new(SecretSeed)
+	if _, err := io.ReadFull(rand, s[:]); err != nil {
+		return nil, err
+	}
+	return s, nil
+}
+
+// PrivateKey returns the PrivateKey of s, the seed followed by its public
+// key.
+func (s *SecretSeed) PrivateKey() PrivateKey {
+	return NewKeyFromSeed(s[:])
+}
+
+// Expand returns the expanded form of the key of s.
+func (s *SecretSeed) Expand() *ExpandedPrivateKey {
+	priv := s.PrivateKey()
+	defer priv.Zero()
+	return priv.Expand()
+}
+
+// Zero overwrites s with zeroes. It is a best effort: copies of s made by the
+// caller, or by the runtime, such as when growing a stack, are not erased.
+func (s *SecretSeed) Zero() {
+	*s = SecretSeed{}
+}
+
+// SecretSeed returns the seed of priv. It will panic if len(priv) is not
+// PrivateKeySize.
+func (priv PrivateKey) SecretSeed() *SecretSeed {
+	if l := len(priv); l != PrivateKeySize {
+		panic("ed25519: bad private key length: " + strconv.Itoa(l))
+	}
+	s := new(SecretSeed)
+	copy(s[:], priv[:SeedSize])
+	return s
+}
+
+// Zero overwrites priv with zeroes, with the same limits as SecretSeed.Zero.
+// priv must not be used afterwards.
+func (priv PrivateKey) Zero() {
+	for i := range priv {
+		priv[i] = 0
+	}
+}
+
+// NewPrivateKey returns a copy of b as a PrivateKey, after checking that it
+// is one: PrivateKeySize bytes long, with a second half that is the public
+// key of the first. It rejects, among others, expanded private keys, and
+// private keys whose public key was corrupted or replaced, which would make
+// signatures that reveal the private key.
+func NewPrivateKey(b []byte) (PrivateKey, error) {
+	if len(b) != PrivateKeySize {
+		return nil, errors.New("ed25519: bad private key length: " + strconv.Itoa(len(b)))
+	}
+	priv := NewKeyFromSeed(b[:SeedSize])
+	if subtle.ConstantTimeCompare(priv[SeedSize:], b[SeedSize:]) != 1 {
+		priv.Zero()
+		return nil, errors.New("ed25519: private key does not match its public key")
+	}
+	return priv, nil
+}
+
+// Zero overwrites the secret scalar and the nonce prefix of k with zeroes,
+// with the same limits as SecretSeed.Zero. k must not be used afterwards.
+func (k *ExpandedPrivateKey) Zero() {
+	k.expanded = [64]byte{}
+	k.scalar = edwards25519.Scalar{}
+}
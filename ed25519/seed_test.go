@@ -0,0 +1,101 @@
+// Copyright 2018 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package ed25519
+
+import (
+	"bytes"
+	"encoding/hex"
+	"testing"
+)
+
+func TestSecretSeed(t *testing.T) {
+	// The first test vector of RFC 8032, Section 7.1.
+	seedBytes, _ := hex.DecodeString("9d61b19deffd5a60ba844af492ec2cc44449c5697b326919703bac031cae7f60")
+	pub, _ := hex.DecodeString("d75a980182b10ab7d54bfed3c964073a0ee172f3daa62325af021a68f707511a")
+
+	seed, err := NewSecretSeed(seedBytes)
+	if err != nil {
+		t.Fatal(err)
+	}
+	priv := seed.PrivateKey()
+	if !bytes.Equal(priv, NewKeyFromSeed(seedBytes)) {
+		t.Errorf("PrivateKey() = %x, want the output of NewKeyFromSeed", priv)
+	}
+	if got := priv.SecretSeed(); *got != *seed {
+		t.Errorf("SecretSeed() = %x, want %x", got[:], seed[:])
+	}
+	expanded := seed.Expand()
+	if !bytes.Equal(expanded.Bytes(), priv.Expand().Bytes()) {
+		t.Errorf("Expand() differs from PrivateKey.Expand")
+	}
+	if got := expanded.Public().(PublicKey); !bytes.Equal(got, pub) {
+		t.Errorf("Expand().Public() = %x, want %x", got, pub)
+	}
+
+	seedBytes[0] ^= 1
+	if seed[0] == seedBytes[0] {
+		t.Errorf("SecretSeed shares memory with its input")
+	}
+
+	seed.Zero()
+	if *seed != (SecretSeed{}) {
+		t.Errorf("Zero left %x", seed[:])
+	}
+	priv.Zero()
+	if !bytes.Equal(priv, make([]byte, PrivateKeySize)) {
+		t.Errorf("Zero left %x", priv)
+	}
+	expanded.Zero()
+	if !bytes.Equal(expanded.Bytes(), make([]byte, ExpandedPrivateKeySize)) {
+		t.Errorf("Zero left %x", expanded.Bytes())
+	}
+
+	if _, err := NewSecretSeed(make([]byte, PrivateKeySize)); err == nil {
+		t.Errorf("NewSecretSeed accepted %d bytes", PrivateKeySize)
+	}
+}
+
+func TestGenerateSecretSeed(t *testing.T) {
+	a, err := GenerateSecretSeed(nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	b, err := GenerateSecretSeed(zeroReader{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if *a == *b || *b != (SecretSeed{}) {
+		t.Errorf("GenerateSecretSeed did not read from rand")
+	}
+}
+
+func TestNewPrivateKey(t *testing.T) {
+	_, priv, _ := GenerateKey(nil)
+	got, err := NewPrivateKey(priv)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(got, priv) {
+		t.Errorf("NewPrivateKey = %x, want %x", got, priv)
+	}
+	got[0] ^= 1
+	if got[0] == priv[0] {
+		t.Errorf("NewPrivateKey shares memory with its input")
+	}
+
+	// An expanded key has the length of a PrivateKey, but its second half
+	// is not a public key.
+	if _, err := NewPrivateKey(priv.Expand().Bytes()); err == nil {
+		t.Errorf("NewPrivateKey accepted an expanded key")
+	}
+	corrupted := append(PrivateKey(nil), priv...)
+	corrupted[PrivateKeySize-1] ^= 1
+	if _, err := NewPrivateKey(corrupted); err == nil {
+		t.Errorf("NewPrivateKey accepted a key with the wrong public key")
+	}
+	if _, err := NewPrivateKey(priv.Seed()); err == nil {
+		t.Errorf("NewPrivateKey accepted a seed")
+	}
+}
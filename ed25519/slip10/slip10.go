@@ -0,0 +1,199 @@
+// Copyright 2018 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+// Package slip10 implements the hierarchical derivation of Ed25519 keys of
+// SLIP-0010, as used by wallets to derive many keys from a single seed.
+//
+// A master Key is computed from a seed, and each Key has children, numbered
+// by a 32-bit index, which are themselves Keys. A DerivationPath, written
+// like m/44'/501'/0', lists the indexes from the master key to a descendant.
+//
+// Unlike BIP-32 and the SLIP-0010 derivation of other curves, Ed25519 only
+// supports hardened derivation, of children whose index has the top bit set,
+// written with a trailing ' or h. Non-hardened indexes are rejected, rather
+// than silently hardened, so that a path never yields a different key than
+// another implementation that honors it. Package bip32ed25519 implements a
+// different scheme, BIP32-Ed25519, which supports non-hardened derivation but
+// derives other keys.
+//
+// See https://github.com/satoshilabs/slips/blob/master/slip-0010.md.
+package slip10 // import "golang.org/x/crypto/ed25519/slip10"
+
+import (
+	"crypto/hmac"
+	"crypto/sha512"
+	"encoding/binary"
+	"errors"
+	"strconv"
+	"strings"
+
+	"golang.org/x/crypto/ed25519"
+)
+
+const (
+	// HardenedOffset is the index of the first hardened child. Indexes below
+	// it are not supported for Ed25519.
+	HardenedOffset = 1 << 31
+
+	// MinSeedSize and MaxSeedSize are the bounds, in bytes, of the length of
+	// seeds, which SLIP-0010 requires to be from 128 to 512 bits.
+	MinSeedSize = 16
+	MaxSeedSize = 64
+)
+
+// ErrNotHardened is returned for a child index below HardenedOffset.
+var ErrNotHardened = errors.New("slip10: Ed25519 only supports hardened derivation")
+
+// A DerivationPath is a sequence of child indexes, starting from the master
+// key. Hardened indexes include HardenedOffset.
+type DerivationPath []uint32
+
+// ParseDerivationPath parses a path such as m/44'/501'/0'. It must start with
+// m, the master key, followed by indexes in decimal, without a sign or leading
+// zeros, each ending with ', h or H to mark it hardened, and below 2^31. An
+// empty component, as in m//0' or m/0'/, is an error.
+func ParseDerivationPath(s string) (DerivationPath, error) {
+	parts := strings.Split(s, "/")
+	if parts[0] != "m" {
+		return nil, errors.New("slip10: derivation path " + strconv.Quote(s) + " does not start with m")
+	}
+	path := make(DerivationPath, 0, len(parts)-1)
+	for _, part := range parts[1:] {
+		digits, hardened := part, false
+		if n := len(part) - 1; n >= 0 && (part[n] == '\'' || part[n] == 'h' || part[n] == 'H') {
+			digits, hardened = part[:n], true
+		}
+		i, err := strconv.ParseUint(digits, 10, 32)
+		if err != nil || i >= HardenedOffset || len(digits) > 1 && digits[0] == '0' {
+			return nil, errors.New("slip10: invalid derivation path component " + strconv.Quote(part))
+		}
+		if !hardened {
+			return nil, ErrNotHardened
+		}
+		path = append(path, uint32(i)+HardenedOffset)
+	}
+	return path, nil
+}
+
+// String returns the path in the form parsed by ParseDerivationPath, with
+// hardened indexes marked by '.
+func (p DerivationPath) String() string {
+	b := []byte("m")
+	for _, i := range p {
+		b = append(b, '/')
+		b = strconv.AppendUint(b, uint64(i&^HardenedOffset), 10)
+		if i >= HardenedOffset {
+			b = append(b, '\'')
+		}
+	}
+	return string(b)
+}
+
+// A Key is an extended private key: an Ed25519 seed and the chain code from
+// which its children are derived. Both are secret.
+type Key struct {
+	seed      ed25519.SecretSeed
+	chainCode [32]byte
+}
+
+// newKey returns the Key made of HMAC-SHA512(key, data).
+func newKey(key []byte, data ...[]byte) *Key {
+	mac := hmac.New(sha512.New, key)
+	for _, d := range data {
+		mac.Write(d)
+	}
+	var sum [64]byte
+	mac.Sum(sum[:0])
+	k := new(Key)
+	copy(k.seed[:], sum[:32])
+	copy(k.chainCode[:], sum[32:])
+	sum = [64]byte{}
+	return k
+}
+
+// NewMasterKey returns the master key of seed, which must be from
+// MinSeedSize to MaxSeedSize bytes long, such as a BIP-39 seed.
+func NewMasterKey(seed []byte) (*Key, error) {
+	if len(seed) < MinSeedSize || len(seed) > MaxSeedSize {
+		return nil, errors.New("slip10: bad seed length: " + strconv.Itoa(len(seed)))
+	}
+	return newKey([]byte("ed25519 seed"), seed), nil
+}
+
+// Child returns the child of k with the given index, which must be at least
+// HardenedOffset.
+func (k *Key) Child(index uint32) (*Key, error) {
+	if index < HardenedOffset {
+		return nil, ErrNotHardened
+	}
+	var i [4]byte
+	binary.BigEndian.PutUint32(i[:], index)
+	return newKey(k.chainCode[:], []byte{0}, k.seed[:], i[:]), nil
+}
+
+// Derive returns the descendant of k at path, relative to k. The
+// intermediate keys are zeroed.
+func (k *Key) Derive(path DerivationPath) (*Key, error) {
+	child := *k
+	for _, i := range path {
+		next, err := child.Child(i)
+		child.Zero()
+		if err != nil {
+			return nil, err
+		}
+		child = *next
+		next.Zero()
+	}
+	return &child, nil
+}
+
+// SecretSeed returns the Ed25519 seed of k, the private key of RFC 8032.
+func (k *Key) SecretSeed() *ed25519.SecretSeed {
+	s := k.seed
+	return &s
+}
+
+// PrivateKey returns the Ed25519 private key of k.
+func (k *Key) PrivateKey() ed25519.PrivateKey {
+	return k.seed.PrivateKey()
+}
+
+// PublicKey returns the Ed25519 public key of k. SLIP-0010 serializes it
+// with a leading zero byte, which is not included.
+func (k *Key) PublicKey() ed25519.PublicKey {
+	priv := k.PrivateKey()
+	defer priv.Zero()
+	return append(ed25519.PublicKey(nil), priv[ed25519.SeedSize:]...)
+}
+
+// ChainCode returns a copy of the chain code of k.
+func (k *Key) ChainCode() []byte {
+	return append([]byte(nil), k.chainCode[:]...)
+}
+
+// Zero overwrites k with zeroes, with the same limits as
+// ed25519.SecretSeed.Zero. k must not be used afterwards.
+func (k *Key) Zero() {
+	*k = Key{}
+}
+
+// NewKeyFromSeed returns the Ed25519 private key derived from seed at path,
+// which is parsed by ParseDerivationPath.
+func NewKeyFromSeed(seed []byte, path string) (ed25519.PrivateKey, error) {
+	p, err := ParseDerivationPath(path)
+	if err != nil {
+		return nil, err
+	}
+	master, err := NewMasterKey(seed)
+	if err != nil {
+		return nil, err
+	}
+	k, err := master.Derive(p)
+	master.Zero()
+	if err != nil {
+		return nil, err
+	}
+	defer k.Zero()
+	return k.PrivateKey(), nil
+}
@@ -0,0 +1,88 @@
+// Copyright 2018 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package ed25519
+
+import (
+	"hash"
+	"strconv"
+)
+
+// A StreamSigner makes Ed25519ph signatures of messages written to it
+// incrementally, so that they never need to be held in memory. It implements
+// io.Writer, and is a convenience around SignPrehashed.
+type StreamSigner struct {
+	h   hash.Hash
+	key *ExpandedPrivateKey
+	dom []byte
+}
+
+// NewStreamSigner returns a StreamSigner that signs with privateKey and the
+// given context, which is at most 255 bytes long. It will panic if
+// len(privateKey) is not PrivateKeySize.
+func NewStreamSigner(privateKey PrivateKey, context string) (*StreamSigner, error) {
+	if l := len(privateKey); l != PrivateKeySize {
+		panic("ed25519: bad private key length: " + strconv.Itoa(l))
+	}
+	dom, err := dom2(1, context)
+	if err != nil {
+		return nil, err
+	}
+	return &StreamSigner{h: newHash(), key: privateKey.Expand(), dom: dom}, nil
+}
+
+// Write adds more data to the message. It never returns an error.
+func (s *StreamSigner) Write(p []byte) (int, error) {
+	return s.h.Write(p)
+}
+
+// Sum appends the signature of the message written so far to b and returns
+// the resulting slice. It does not change the state of s, so more data can
+// be written and signed afterwards.
+func (s *StreamSigner) Sum(b []byte) []byte {
+	return append(b, s.key.sign(s.h.Sum(nil), s.dom, nil)...)
+}
+
+// Reset starts a new message.
+func (s *StreamSigner) Reset() {
+	s.h.Reset()
+}
+
+// A StreamVerifier verifies Ed25519ph signatures of messages written to it
+// incrementally. It implements io.Writer, and is a convenience around
+// VerifyPrehashed.
+type StreamVerifier struct {
+	h         hash.Hash
+	publicKey PublicKey
+	dom       []byte
+}
+
+// NewStreamVerifier returns a StreamVerifier for signatures by publicKey with
+// the given context. It will panic if len(publicKey) is not PublicKeySize.
+func NewStreamVerifier(publicKey PublicKey, context string) (*StreamVerifier, error) {
+	if l := len(publicKey); l != PublicKeySize {
+		panic("ed25519: bad public key length: " + strconv.Itoa(l))
+	}
+	dom, err := dom2(1, context)
+	if err != nil {
+		return nil, err
+	}
+	return &StreamVerifier{h: newHash(), publicKey: append(PublicKey(nil), publicKey...), dom: dom}, nil
+}
+
+// Write adds more data to the message. It never returns an error.
+func (v *StreamVerifier) Write(p []byte) (int, error) {
+	return v.h.Write(p)
+}
+
+// Verify reports whether sig is a valid signature of the message written so
+// far. It does not change the state of v.
+func (v *StreamVerifier) Verify(sig []byte) bool {
+	return verify(v.publicKey, v.h.Sum(nil), sig, v.dom)
+}
+
+// Reset starts a new message.
+func (v *StreamVerifier) Reset() {
+	v.h.Reset()
+}
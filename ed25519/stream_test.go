@@ -0,0 +1,79 @@
+// Copyright 2018 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package ed25519
+
+import (
+	"bytes"
+	"crypto/sha512"
+	"io"
+	"strings"
+	"testing"
+)
+
+func TestStreamSigner(t *testing.T) {
+	pub, priv, _ := GenerateKey(nil)
+	message := bytes.Repeat([]byte("firmware image "), 100000)
+	digest := sha512.Sum512(message)
+
+	s, err := NewStreamSigner(priv, "firmware")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := io.Copy(s, bytes.NewReader(message)); err != nil {
+		t.Fatal(err)
+	}
+	sig := s.Sum(nil)
+	want, _ := SignPrehashed(priv, digest[:], "firmware")
+	if !bytes.Equal(sig, want) {
+		t.Errorf("Sum = %x, want %x", sig, want)
+	}
+	if prefix := []byte("prefix"); !bytes.Equal(s.Sum(prefix), append(prefix, want...)) {
+		t.Error("Sum did not append to its argument, or changed the state")
+	}
+
+	v, err := NewStreamVerifier(pub, "firmware")
+	if err != nil {
+		t.Fatal(err)
+	}
+	for i := 0; i < len(message); i += 4096 {
+		end := i + 4096
+		if end > len(message) {
+			end = len(message)
+		}
+		v.Write(message[i:end])
+	}
+	if !v.Verify(sig) {
+		t.Error("StreamVerifier rejected a valid signature")
+	}
+	v.Write([]byte("!"))
+	if v.Verify(sig) {
+		t.Error("StreamVerifier accepted a signature of another message")
+	}
+	v.Reset()
+	io.Copy(v, bytes.NewReader(message))
+	if !v.Verify(sig) {
+		t.Error("StreamVerifier rejected a valid signature after Reset")
+	}
+
+	v, _ = NewStreamVerifier(pub, "")
+	io.Copy(v, bytes.NewReader(message))
+	if v.Verify(sig) {
+		t.Error("StreamVerifier accepted a signature with the wrong context")
+	}
+
+	s.Reset()
+	s.Write([]byte("abc"))
+	digest = sha512.Sum512([]byte("abc"))
+	if !VerifyPrehashed(pub, digest[:], s.Sum(nil), "firmware") {
+		t.Error("signature after Reset rejected by VerifyPrehashed")
+	}
+
+	if _, err := NewStreamSigner(priv, strings.Repeat("a", 256)); err == nil {
+		t.Error("NewStreamSigner accepted a long context")
+	}
+	if _, err := NewStreamVerifier(pub, strings.Repeat("a", 256)); err == nil {
+		t.Error("NewStreamVerifier accepted a long context")
+	}
+}
@@ -0,0 +1,551 @@
+// Copyright 2018 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+// Package threshold implements threshold encryption: messages are encrypted
+// to a public key whose private key is split among n parties, any t of which
+// can jointly decrypt, while fewer than t learn nothing about the messages.
+//
+// It is hybrid ElGamal encryption over the ristretto255 group, with the
+// private key shared with Shamir's scheme. The private key is a scalar x and
+// the public key is Y = [x]B. A message is encrypted with a random scalar r
+// by sending C = [r]B along with the message sealed with ChaCha20-Poly1305,
+// under a key derived from [r]Y, and a Schnorr proof of knowledge of r bound
+// to the sealed message. Party i holds x_i, the evaluation at i of a
+// random polynomial of degree t-1 whose constant term is x, and decrypts
+// partially by computing D_i = [x_i]C. Any t of the D_i determine [x]C = [r]Y
+// by Lagrange interpolation.
+//
+// Each partial decryption comes with a proof that it was correctly computed
+// from the party's share, whose public verification key Y_i = [x_i]B is part
+// of the PublicKey. Combine discards shares whose proof is invalid, so that a
+// faulty or malicious party cannot prevent decryption or make it return a
+// wrong message, and VerifyShare identifies such parties.
+//
+// The shares are generated by a trusted dealer, Deal, which learns the
+// private key and must destroy it after handing out the shares. This suits
+// ballots and key escrow, where the dealer is the organizer, and avoids the
+// rounds of a distributed key generation.
+//
+// PartialDecrypt checks the proof of knowledge of r before releasing a share,
+// as in the TDH1 scheme of Shoup and Gennaro. Only whoever created C can make
+// a valid ciphertext from it, so the element of a ciphertext cannot be
+// attached to a forged message to get it decrypted. This is Schnorr-signed
+// ElGamal, which is secure against chosen-ciphertext attacks in the random
+// oracle and generic group models, but, unlike TDH2, not under the
+// Decisional Diffie-Hellman assumption alone.
+package threshold // import "golang.org/x/crypto/ed25519/threshold"
+
+import (
+	"crypto/cipher"
+	cryptorand "crypto/rand"
+	"crypto/sha256"
+	"crypto/sha512"
+	"crypto/subtle"
+	"errors"
+	"io"
+
+	"golang.org/x/crypto/chacha20poly1305"
+	"golang.org/x/crypto/ed25519/internal/edwards25519"
+	"golang.org/x/crypto/hkdf"
+)
+
+const (
+	// MaxParties is the largest number of parties a key can be shared among.
+	MaxParties = 255
+
+	// ElementSize is the size, in bytes, of the encoding of a group element.
+	ElementSize = 32
+
+	// Overhead is the number of bytes by which a ciphertext is longer than
+	// its plaintext: an element, the proof of knowledge of r and the
+	// Poly1305 tag.
+	Overhead = ElementSize + proofSize + 16
+
+	// KeyShareSize is the size, in bytes, of the encoding of a KeyShare.
+	KeyShareSize = 1 + 32
+
+	// DecryptionShareSize is the size, in bytes, of the partial decryptions
+	// returned by PartialDecrypt: the index of the party, D_i and the proof.
+	DecryptionShareSize = 1 + ElementSize + 64
+
+	// proofSize is the size of the proof of knowledge of r that follows C in
+	// a ciphertext.
+	proofSize = 64
+)
+
+var (
+	// ErrTooFewShares is returned by Combine if fewer than the threshold of
+	// the key are valid.
+	ErrTooFewShares = errors.New("threshold: too few valid decryption shares")
+
+	errCiphertext = errors.New("threshold: invalid ciphertext")
+	errShare      = errors.New("threshold: invalid decryption share")
+	errOpen       = errors.New("threshold: message authentication failed")
+)
+
+// A PublicKey is the key messages are encrypted to, along with the
+// verification keys of the partial decryptions of each party.
+type PublicKey struct {
+	threshold int
+	key       edwards25519.ExtendedGroupElement
+	// shares[i-1] is Y_i, the verification key of party i.
+	shares []edwards25519.ExtendedGroupElement
+}
+
+// Threshold returns the number of parties needed to decrypt.
+func (pub *PublicKey) Threshold() int {
+	return pub.threshold
+}
+
+// Parties returns the number of parties the key is shared among, which are
+// numbered from 1 to Parties.
+func (pub *PublicKey) Parties() int {
+	return len(pub.shares)
+}
+
+// MarshalBinary returns the encoding of pub: the threshold and the number of
+// parties, one byte each, followed by Y and by Y_1 to Y_n. It never returns
+// an error.
+func (pub *PublicKey) MarshalBinary() ([]byte, error) {
+	b := make([]byte, 2, 2+ElementSize*(1+len(pub.shares)))
+	b[0], b[1] = byte(pub.threshold), byte(len(pub.shares))
+	var s [32]byte
+	pub.key.ToRistrettoBytes(&s)
+	b = append(b, s[:]...)
+	for i := range pub.shares {
+		pub.shares[i].ToRistrettoBytes(&s)
+		b = append(b, s[:]...)
+	}
+	return b, nil
+}
+
+// UnmarshalPublicKey decodes a PublicKey encoded by MarshalBinary. It checks
+// that the verification keys are consistent with the public key, that is,
+// that they are the shares of a polynomial of degree t-1 whose constant term
+// is Y.
+func UnmarshalPublicKey(b []byte) (*PublicKey, error) {
+	if len(b) < 2 || b[0] < 1 || b[0] > b[1] || len(b) != 2+ElementSize*(1+int(b[1])) {
+		return nil, errors.New("threshold: invalid public key encoding")
+	}
+	pub := &PublicKey{
+		threshold: int(b[0]),
+		shares:    make([]edwards25519.ExtendedGroupElement, b[1]),
+	}
+	if err := decodeElement(&pub.key, b[2:]); err != nil {
+		return nil, err
+	}
+	for i := range pub.shares {
+		if err := decodeElement(&pub.shares[i], b[2+ElementSize*(i+1):]); err != nil {
+			return nil, err
+		}
+	}
+
+	// Interpolate Y and the remaining Y_i from Y_1 to Y_t.
+	indexes := make([]int, pub.threshold)
+	for i := range indexes {
+		indexes[i] = i + 1
+	}
+	base := pub.shares[:pub.threshold]
+	var p edwards25519.ExtendedGroupElement
+	interpolate(&p, indexes, base, 0)
+	if !edwards25519.RistrettoEqual(&p, &pub.key) {
+		return nil, errors.New("threshold: verification keys do not match the public key")
+	}
+	for i := pub.threshold; i < len(pub.shares); i++ {
+		interpolate(&p, indexes, base, i+1)
+		if !edwards25519.RistrettoEqual(&p, &pub.shares[i]) {
+			return nil, errors.New("threshold: inconsistent verification keys")
+		}
+	}
+	return pub, nil
+}
+
+// A KeyShare is the share of the private key held by one party.
+type KeyShare struct {
+	index  int
+	secret edwards25519.Scalar
+	public [32]byte // Y_i
+}
+
+func newKeyShare(index int, secret *edwards25519.Scalar) *KeyShare {
+	k := &KeyShare{index: index, secret: *secret}
+	b := secret.Bytes()
+	var Y edwards25519.ExtendedGroupElement
+	edwards25519.GeScalarMultBase(&Y, &b)
+	Y.ToRistrettoBytes(&k.public)
+	return k
+}
+
+// Index returns the number of the party holding k, from 1 to Parties.
+func (k *KeyShare) Index() int {
+	return k.index
+}
+
+// MarshalBinary returns the KeyShareSize-byte encoding of k: its index
+// followed by its secret share. It must be stored as securely as a private
+// key.
+func (k *KeyShare) MarshalBinary() ([]byte, error) {
+	b := k.secret.Bytes()
+	return append([]byte{byte(k.index)}, b[:]...), nil
+}
+
+// UnmarshalKeyShare decodes a KeyShare encoded by MarshalBinary.
+func UnmarshalKeyShare(b []byte) (*KeyShare, error) {
+	if len(b) != KeyShareSize || b[0] == 0 {
+		return nil, errors.New("threshold: invalid key share encoding")
+	}
+	var secret edwards25519.Scalar
+	if _, err := secret.SetCanonicalBytes(b[1:]); err != nil || secret.IsZero() == 1 {
+		return nil, errors.New("threshold: invalid secret share")
+	}
+	return newKeyShare(int(b[0]), &secret), nil
+}
+
+// Deal generates a private key, shares it among parties parties, any
+// threshold of which can decrypt, and returns the public key and the shares,
+// where shares[i] is to be given to party i+1. The dealer must not keep the
+// shares. If rand is nil, crypto/rand.Reader is used.
+func Deal(rand io.Reader, threshold, parties int) (*PublicKey, []*KeyShare, error) {
+	if threshold < 1 || threshold > parties || parties > MaxParties {
+		return nil, nil, errors.New("threshold: invalid threshold or number of parties")
+	}
+
+	// f(z) = coefficients[0] + coefficients[1]*z + ...
+	coefficients := make([]edwards25519.Scalar, threshold)
+	defer func() {
+		for i := range coefficients {
+			coefficients[i] = edwards25519.Scalar{}
+		}
+	}()
+	for i := range coefficients {
+		c, err := edwards25519.NewRandomScalar(rand)
+		if err != nil {
+			return nil, nil, err
+		}
+		coefficients[i] = *c
+	}
+
+	pub := &PublicKey{
+		threshold: threshold,
+		shares:    make([]edwards25519.ExtendedGroupElement, parties),
+	}
+	b := coefficients[0].Bytes()
+	edwards25519.GeScalarMultBase(&pub.key, &b)
+
+	shares := make([]*KeyShare, parties)
+	for i := range shares {
+		var z, s edwards25519.Scalar
+		z.SetUint64(uint64(i + 1))
+		s = coefficients[threshold-1]
+		for j := threshold - 2; j >= 0; j-- {
+			s.MultiplyAdd(&s, &z, &coefficients[j])
+		}
+		shares[i] = newKeyShare(i+1, &s)
+		s = edwards25519.Scalar{}
+		pub.shares[i].FromRistrettoBytes(&shares[i].public)
+	}
+	return pub, shares, nil
+}
+
+// Encrypt encrypts and authenticates plaintext, and authenticates
+// additionalData, for pub. The ciphertext is Overhead bytes longer than
+// plaintext. If rand is nil, crypto/rand.Reader is used.
+func Encrypt(rand io.Reader, pub *PublicKey, plaintext, additionalData []byte) ([]byte, error) {
+	r, err := edwards25519.NewRandomScalar(rand)
+	if err != nil {
+		return nil, err
+	}
+	defer func() { *r = edwards25519.Scalar{} }()
+	b := r.Bytes()
+	var C, K edwards25519.ExtendedGroupElement
+	edwards25519.GeScalarMultBase(&C, &b)
+	edwards25519.GeScalarMult(&K, &b, &pub.key)
+
+	out := make([]byte, ElementSize+proofSize, len(plaintext)+Overhead)
+	var c [32]byte
+	C.ToRistrettoBytes(&c)
+	copy(out, c[:])
+	var nonce [chacha20poly1305.NonceSize]byte
+	out = newAEAD(pub, &c, &K).Seal(out, nonce[:], plaintext, additionalData)
+	body := out[ElementSize+proofSize:]
+
+	// Prove knowledge of r with a Schnorr proof bound to body. The nonce is
+	// derived from r and body, so it is as unpredictable as r.
+	h := sha512.New()
+	h.Write(b[:])
+	h.Write(body)
+	b = [32]byte{}
+	var w edwards25519.Scalar
+	if _, err := w.SetUniformBytes(h.Sum(nil)); err != nil {
+		panic("threshold: internal error: setting scalar failed")
+	}
+	wb := w.Bytes()
+	var U edwards25519.ExtendedGroupElement
+	edwards25519.GeScalarMultBase(&U, &wb)
+	wb = [32]byte{}
+
+	e := ciphertextChallenge(c[:], &U, body)
+	var f edwards25519.Scalar
+	f.MultiplyAdd(e, r, &w)
+	w = edwards25519.Scalar{}
+	eb, fb := e.Bytes(), f.Bytes()
+	copy(out[ElementSize:], eb[:])
+	copy(out[ElementSize+32:], fb[:])
+	return out, nil
+}
+
+// PartialDecrypt returns the DecryptionShareSize-byte partial decryption of
+// ciphertext by k, with a proof of its correctness. It returns an error,
+// without computing the partial decryption, if the proof of knowledge of r
+// in ciphertext is invalid. The proof is randomized
+// with 32 bytes read from rand, along with the secret share, so that it is
+// safe even if rand is weak. If rand is nil, crypto/rand.Reader is used.
+func (k *KeyShare) PartialDecrypt(rand io.Reader, ciphertext []byte) ([]byte, error) {
+	var C edwards25519.ExtendedGroupElement
+	if err := decodeCiphertext(&C, ciphertext); err != nil {
+		return nil, err
+	}
+	if rand == nil {
+		rand = cryptorand.Reader
+	}
+	var noise [32]byte
+	if _, err := io.ReadFull(rand, noise[:]); err != nil {
+		return nil, err
+	}
+
+	secret := k.secret.Bytes()
+	var D edwards25519.ExtendedGroupElement
+	edwards25519.GeScalarMult(&D, &secret, &C)
+	out := make([]byte, 1, DecryptionShareSize)
+	out[0] = byte(k.index)
+	var d [32]byte
+	D.ToRistrettoBytes(&d)
+	out = append(out, d[:]...)
+
+	// Prove that log_B(Y_i) = log_C(D_i) with a Chaum-Pedersen proof.
+	h := sha512.New()
+	h.Write(secret[:])
+	h.Write(noise[:])
+	h.Write(ciphertext[:ElementSize])
+	var w edwards25519.Scalar
+	if _, err := w.SetUniformBytes(h.Sum(nil)); err != nil {
+		panic("threshold: internal error: setting scalar failed")
+	}
+	secret = [32]byte{}
+	wb := w.Bytes()
+	var A1, A2 edwards25519.ExtendedGroupElement
+	edwards25519.GeScalarMultBase(&A1, &wb)
+	edwards25519.GeScalarMult(&A2, &wb, &C)
+	wb = [32]byte{}
+
+	c := challenge(k.index, &k.public, ciphertext[:ElementSize], d[:], &A1, &A2)
+	var z edwards25519.Scalar
+	z.MultiplyAdd(c, &k.secret, &w)
+	w = edwards25519.Scalar{}
+	cb, zb := c.Bytes(), z.Bytes()
+	out = append(out, cb[:]...)
+	return append(out, zb[:]...), nil
+}
+
+// VerifyShare checks that share is a correct partial decryption of
+// ciphertext by a party of pub.
+func VerifyShare(pub *PublicKey, ciphertext, share []byte) error {
+	var C edwards25519.ExtendedGroupElement
+	if err := decodeCiphertext(&C, ciphertext); err != nil {
+		return err
+	}
+	_, err := verifyShare(pub, &C, ciphertext[:ElementSize], share)
+	return err
+}
+
+// verifyShare checks share against C, encoded as c, and returns D_i.
+func verifyShare(pub *PublicKey, C *edwards25519.ExtendedGroupElement, c, share []byte) (*edwards25519.ExtendedGroupElement, error) {
+	if len(share) != DecryptionShareSize || share[0] == 0 || int(share[0]) > len(pub.shares) {
+		return nil, errShare
+	}
+	index := int(share[0])
+	D := new(edwards25519.ExtendedGroupElement)
+	if err := decodeElement(D, share[1:]); err != nil {
+		return nil, errShare
+	}
+	var ch, z edwards25519.Scalar
+	if _, err := ch.SetCanonicalBytes(share[1+ElementSize : 1+ElementSize+32]); err != nil {
+		return nil, errShare
+	}
+	if _, err := z.SetCanonicalBytes(share[1+ElementSize+32:]); err != nil {
+		return nil, errShare
+	}
+
+	// A1 = [z]B - [c]Y_i and A2 = [z]C - [c]D_i.
+	var negY, negD, A1, A2 edwards25519.ExtendedGroupElement
+	edwards25519.GeNeg(&negY, &pub.shares[index-1])
+	edwards25519.GeNeg(&negD, D)
+	terms := []edwards25519.Term{
+		{Scalar: ch.Bytes(), Point: negY},
+		{Scalar: z.Bytes(), Point: *edwards25519.NewGeneratorPoint()},
+	}
+	edwards25519.GeLinearCombinationVartime(&A1, terms, 1)
+	terms[0].Point, terms[1].Point = negD, *C
+	edwards25519.GeLinearCombinationVartime(&A2, terms, 1)
+
+	var Y [32]byte
+	pub.shares[index-1].ToRistrettoBytes(&Y)
+	if challenge(index, &Y, c, share[1:1+ElementSize], &A1, &A2).Equal(&ch) != 1 {
+		return nil, errShare
+	}
+	return D, nil
+}
+
+// Combine decrypts ciphertext, authenticating additionalData, from the
+// partial decryptions in shares, which must include at least Threshold valid
+// ones from distinct parties. Invalid and duplicate shares are ignored.
+func Combine(pub *PublicKey, ciphertext, additionalData []byte, shares [][]byte) ([]byte, error) {
+	var C edwards25519.ExtendedGroupElement
+	if err := decodeCiphertext(&C, ciphertext); err != nil {
+		return nil, err
+	}
+	c := ciphertext[:ElementSize]
+
+	seen := make([]bool, len(pub.shares)+1)
+	var indexes []int
+	var points []edwards25519.ExtendedGroupElement
+	for _, share := range shares {
+		if len(indexes) == pub.threshold {
+			break
+		}
+		D, err := verifyShare(pub, &C, c, share)
+		if err != nil || seen[share[0]] {
+			continue
+		}
+		seen[share[0]] = true
+		indexes = append(indexes, int(share[0]))
+		points = append(points, *D)
+	}
+	if len(indexes) < pub.threshold {
+		return nil, ErrTooFewShares
+	}
+
+	var K edwards25519.ExtendedGroupElement
+	interpolate(&K, indexes, points, 0)
+	var cc [32]byte
+	copy(cc[:], c)
+	var nonce [chacha20poly1305.NonceSize]byte
+	plaintext, err := newAEAD(pub, &cc, &K).Open(nil, nonce[:], ciphertext[ElementSize+proofSize:], additionalData)
+	if err != nil {
+		return nil, errOpen
+	}
+	return plaintext, nil
+}
+
+// interpolate sets r to the value at x of the polynomial, in the exponent,
+// whose values at indexes are points.
+func interpolate(r *edwards25519.ExtendedGroupElement, indexes []int, points []edwards25519.ExtendedGroupElement, x int) {
+	var xs edwards25519.Scalar
+	xs.SetUint64(uint64(x))
+	terms := make([]edwards25519.Term, len(indexes))
+	for i, index := range indexes {
+		// The Lagrange coefficient of index is the product of
+		// (x - j) / (index - j) for the other indexes j.
+		var num, den, is, js, t edwards25519.Scalar
+		num.SetUint64(1)
+		den.SetUint64(1)
+		is.SetUint64(uint64(index))
+		for _, j := range indexes {
+			if j == index {
+				continue
+			}
+			js.SetUint64(uint64(j))
+			num.Multiply(&num, t.Subtract(&xs, &js))
+			den.Multiply(&den, t.Subtract(&is, &js))
+		}
+		// The indexes are distinct, so den is not zero.
+		num.DivideVartime(&num, &den)
+		terms[i] = edwards25519.Term{Scalar: num.Bytes(), Point: points[i]}
+	}
+	edwards25519.GeLinearCombinationVartime(r, terms, 1)
+}
+
+// challenge returns the Fiat-Shamir challenge of the proof of a partial
+// decryption.
+func challenge(index int, Y *[32]byte, c, d []byte, A1, A2 *edwards25519.ExtendedGroupElement) *edwards25519.Scalar {
+	var a1, a2 [32]byte
+	A1.ToRistrettoBytes(&a1)
+	A2.ToRistrettoBytes(&a2)
+	msg := make([]byte, 0, 1+5*ElementSize)
+	msg = append(msg, byte(index))
+	msg = append(msg, Y[:]...)
+	msg = append(msg, c...)
+	msg = append(msg, d...)
+	msg = append(msg, a1[:]...)
+	msg = append(msg, a2[:]...)
+	return edwards25519.HashToScalar(msg, []byte("golang.org/x/crypto/ed25519/threshold DLEQ"))
+}
+
+// ciphertextChallenge returns the Fiat-Shamir challenge of the proof of
+// knowledge of r in the ciphertext starting with c and ending with body.
+func ciphertextChallenge(c []byte, U *edwards25519.ExtendedGroupElement, body []byte) *edwards25519.Scalar {
+	var u [32]byte
+	U.ToRistrettoBytes(&u)
+	msg := make([]byte, 0, 2*ElementSize+len(body))
+	msg = append(msg, c...)
+	msg = append(msg, u[:]...)
+	msg = append(msg, body...)
+	return edwards25519.HashToScalar(msg, []byte("golang.org/x/crypto/ed25519/threshold ciphertext"))
+}
+
+// newAEAD returns the cipher of the ciphertext starting with c, whose shared
+// element is K. Its key is only used once, so a zero nonce is safe.
+func newAEAD(pub *PublicKey, c *[32]byte, K *edwards25519.ExtendedGroupElement) cipher.AEAD {
+	var k, y [32]byte
+	K.ToRistrettoBytes(&k)
+	pub.key.ToRistrettoBytes(&y)
+	info := append([]byte("golang.org/x/crypto/ed25519/threshold key"), y[:]...)
+	info = append(info, c[:]...)
+	var key [chacha20poly1305.KeySize]byte
+	io.ReadFull(hkdf.New(sha256.New, k[:], nil, info), key[:])
+	aead, err := chacha20poly1305.New(key[:])
+	if err != nil {
+		panic("threshold: " + err.Error())
+	}
+	return aead
+}
+
+// decodeElement decodes the first ElementSize bytes of b, rejecting the
+// identity.
+func decodeElement(p *edwards25519.ExtendedGroupElement, b []byte) error {
+	var s, zero [32]byte
+	copy(s[:], b[:ElementSize])
+	if subtle.ConstantTimeCompare(s[:], zero[:]) == 1 || !p.FromRistrettoBytes(&s) {
+		return errors.New("threshold: invalid group element")
+	}
+	return nil
+}
+
+// decodeCiphertext decodes the first element of ciphertext, and checks the
+// proof of knowledge of its discrete logarithm that follows it.
+func decodeCiphertext(C *edwards25519.ExtendedGroupElement, ciphertext []byte) error {
+	if len(ciphertext) < Overhead || decodeElement(C, ciphertext) != nil {
+		return errCiphertext
+	}
+	var e, f edwards25519.Scalar
+	if _, err := e.SetCanonicalBytes(ciphertext[ElementSize : ElementSize+32]); err != nil {
+		return errCiphertext
+	}
+	if _, err := f.SetCanonicalBytes(ciphertext[ElementSize+32 : ElementSize+proofSize]); err != nil {
+		return errCiphertext
+	}
+
+	// U = [f]B - [e]C.
+	var negC, U edwards25519.ExtendedGroupElement
+	edwards25519.GeNeg(&negC, C)
+	terms := []edwards25519.Term{
+		{Scalar: e.Bytes(), Point: negC},
+		{Scalar: f.Bytes(), Point: *edwards25519.NewGeneratorPoint()},
+	}
+	edwards25519.GeLinearCombinationVartime(&U, terms, 1)
+	if ciphertextChallenge(ciphertext[:ElementSize], &U, ciphertext[ElementSize+proofSize:]).Equal(&e) != 1 {
+		return errCiphertext
+	}
+	return nil
+}
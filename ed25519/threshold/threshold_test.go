@@ -0,0 +1,244 @@
+// Copyright 2018 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package threshold
+
+import (
+	"bytes"
+	"testing"
+)
+
+func deal(t *testing.T, threshold, parties int) (*PublicKey, []*KeyShare) {
+	pub, shares, err := Deal(nil, threshold, parties)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if pub.Threshold() != threshold || pub.Parties() != parties || len(shares) != parties {
+		t.Fatalf("Deal(%d, %d) returned a %d-of-%d key and %d shares", threshold, parties, pub.Threshold(), pub.Parties(), len(shares))
+	}
+	for i, k := range shares {
+		if k.Index() != i+1 {
+			t.Fatalf("shares[%d].Index() = %d", i, k.Index())
+		}
+	}
+	return pub, shares
+}
+
+func partialDecrypt(t *testing.T, shares []*KeyShare, ciphertext []byte) [][]byte {
+	var out [][]byte
+	for _, k := range shares {
+		d, err := k.PartialDecrypt(nil, ciphertext)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(d) != DecryptionShareSize {
+			t.Fatalf("partial decryption is %d bytes, want %d", len(d), DecryptionShareSize)
+		}
+		out = append(out, d)
+	}
+	return out
+}
+
+func TestRoundTrip(t *testing.T) {
+	msg := []byte("ballot")
+	ad := []byte("election 1")
+	for _, tt := range []struct{ threshold, parties int }{
+		{1, 1}, {1, 3}, {2, 3}, {3, 3}, {3, 5}, {5, 7},
+	} {
+		pub, shares := deal(t, tt.threshold, tt.parties)
+		ciphertext, err := Encrypt(nil, pub, msg, ad)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(ciphertext) != len(msg)+Overhead {
+			t.Errorf("ciphertext is %d bytes, want %d", len(ciphertext), len(msg)+Overhead)
+		}
+
+		// Every window of threshold consecutive parties can decrypt.
+		for start := 0; start+tt.threshold <= tt.parties; start++ {
+			ds := partialDecrypt(t, shares[start:start+tt.threshold], ciphertext)
+			for _, d := range ds {
+				if err := VerifyShare(pub, ciphertext, d); err != nil {
+					t.Errorf("%d-of-%d: VerifyShare: %v", tt.threshold, tt.parties, err)
+				}
+			}
+			got, err := Combine(pub, ciphertext, ad, ds)
+			if err != nil {
+				t.Errorf("%d-of-%d, parties %d+: %v", tt.threshold, tt.parties, start+1, err)
+			} else if !bytes.Equal(got, msg) {
+				t.Errorf("%d-of-%d, parties %d+: got %q, want %q", tt.threshold, tt.parties, start+1, got, msg)
+			}
+			if tt.threshold > 1 {
+				if _, err := Combine(pub, ciphertext, ad, ds[1:]); err != ErrTooFewShares {
+					t.Errorf("%d-of-%d: Combine with too few shares: %v, want ErrTooFewShares", tt.threshold, tt.parties, err)
+				}
+			}
+		}
+		if _, err := Combine(pub, ciphertext, []byte("election 2"), partialDecrypt(t, shares, ciphertext)); err == nil {
+			t.Errorf("%d-of-%d: Combine accepted the wrong additional data", tt.threshold, tt.parties)
+		}
+	}
+}
+
+func TestInvalidShares(t *testing.T) {
+	pub, shares := deal(t, 2, 4)
+	msg := []byte("escrowed key")
+	ciphertext, err := Encrypt(nil, pub, msg, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	other, err := Encrypt(nil, pub, msg, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	ds := partialDecrypt(t, shares, ciphertext)
+
+	tampered := append([]byte(nil), ds[0]...)
+	tampered[len(tampered)-1] ^= 1
+	wrongIndex := append([]byte(nil), ds[0]...)
+	wrongIndex[0] = 2
+	badIndex := append([]byte(nil), ds[0]...)
+	badIndex[0] = 5
+	// A share of D_i for another ciphertext, with a valid proof for it.
+	otherShare := partialDecrypt(t, shares[:1], other)[0]
+
+	for name, d := range map[string][]byte{
+		"tampered":     tampered,
+		"wrong index":  wrongIndex,
+		"bad index":    badIndex,
+		"other":        otherShare,
+		"short":        ds[0][:DecryptionShareSize-1],
+		"zero element": append([]byte{1}, make([]byte, DecryptionShareSize-1)...),
+	} {
+		if err := VerifyShare(pub, ciphertext, d); err == nil {
+			t.Errorf("VerifyShare accepted a %s share", name)
+		}
+		// The invalid share and a single valid one are not enough.
+		if _, err := Combine(pub, ciphertext, nil, [][]byte{d, ds[2]}); err != ErrTooFewShares {
+			t.Errorf("Combine with a %s share: %v, want ErrTooFewShares", name, err)
+		}
+		// Adding a second valid one is.
+		got, err := Combine(pub, ciphertext, nil, [][]byte{d, ds[2], ds[3]})
+		if err != nil || !bytes.Equal(got, msg) {
+			t.Errorf("Combine with a %s share and two valid ones: %q, %v", name, got, err)
+		}
+	}
+
+	if _, err := Combine(pub, ciphertext, nil, [][]byte{ds[1], ds[1]}); err != ErrTooFewShares {
+		t.Errorf("Combine with a duplicate share: %v, want ErrTooFewShares", err)
+	}
+	if _, err := shares[0].PartialDecrypt(nil, ciphertext[:Overhead-1]); err == nil {
+		t.Errorf("PartialDecrypt accepted a short ciphertext")
+	}
+	if _, err := shares[0].PartialDecrypt(nil, make([]byte, Overhead)); err == nil {
+		t.Errorf("PartialDecrypt accepted the identity element")
+	}
+}
+
+func TestInvalidCiphertexts(t *testing.T) {
+	pub, shares := deal(t, 2, 3)
+	ciphertext, err := Encrypt(nil, pub, []byte("secret"), nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	other, err := Encrypt(nil, pub, []byte("public"), nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// The element of ciphertext, attached to the rest of other, whose body
+	// is valid but bound to another element.
+	mixed := append(append([]byte(nil), ciphertext[:ElementSize]...), other[ElementSize:]...)
+	// The element and proof of ciphertext, attached to a forged body.
+	forged := append(append([]byte(nil), ciphertext[:ElementSize+proofSize]...), other[ElementSize+proofSize:]...)
+	tamperedProof := append([]byte(nil), ciphertext...)
+	tamperedProof[ElementSize+40] ^= 1
+	tamperedBody := append([]byte(nil), ciphertext...)
+	tamperedBody[len(tamperedBody)-1] ^= 1
+
+	valid := partialDecrypt(t, shares, ciphertext)
+	for name, c := range map[string][]byte{
+		"mixed":          mixed,
+		"forged":         forged,
+		"tampered proof": tamperedProof,
+		"tampered body":  tamperedBody,
+	} {
+		if _, err := shares[0].PartialDecrypt(nil, c); err == nil {
+			t.Errorf("PartialDecrypt accepted a %s ciphertext", name)
+		}
+		if err := VerifyShare(pub, c, valid[0]); err == nil {
+			t.Errorf("VerifyShare accepted a %s ciphertext", name)
+		}
+		if _, err := Combine(pub, c, nil, valid); err == nil {
+			t.Errorf("Combine accepted a %s ciphertext", name)
+		}
+	}
+}
+
+func TestMarshal(t *testing.T) {
+	pub, shares := deal(t, 3, 5)
+	b, err := pub.MarshalBinary()
+	if err != nil {
+		t.Fatal(err)
+	}
+	pub2, err := UnmarshalPublicKey(b)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if pub2.Threshold() != 3 || pub2.Parties() != 5 {
+		t.Errorf("decoded a %d-of-%d key", pub2.Threshold(), pub2.Parties())
+	}
+
+	var shares2 []*KeyShare
+	for _, k := range shares[2:] {
+		b, err := k.MarshalBinary()
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(b) != KeyShareSize {
+			t.Errorf("key share is %d bytes, want %d", len(b), KeyShareSize)
+		}
+		k2, err := UnmarshalKeyShare(b)
+		if err != nil {
+			t.Fatal(err)
+		}
+		shares2 = append(shares2, k2)
+	}
+
+	msg := []byte("hello")
+	ciphertext, err := Encrypt(nil, pub2, msg, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	got, err := Combine(pub, ciphertext, nil, partialDecrypt(t, shares2, ciphertext))
+	if err != nil || !bytes.Equal(got, msg) {
+		t.Errorf("decrypting with decoded keys: %q, %v", got, err)
+	}
+
+	// Replacing a verification key with that of another party must be
+	// detected.
+	for _, i := range []int{0, 2, 4} {
+		bad := append([]byte(nil), b...)
+		at := 2 + ElementSize*(i+1)
+		copy(bad[at:at+ElementSize], b[2+ElementSize*((i+1)%5+1):])
+		if _, err := UnmarshalPublicKey(bad); err == nil {
+			t.Errorf("UnmarshalPublicKey accepted a wrong key at position %d", i)
+		}
+	}
+	for _, bad := range [][]byte{b[:len(b)-1], append([]byte{2}, b[1:]...), append([]byte{0}, b[1:]...), nil} {
+		if _, err := UnmarshalPublicKey(bad); err == nil {
+			t.Errorf("UnmarshalPublicKey accepted a bad encoding %x", bad)
+		}
+	}
+}
+
+func TestDealErrors(t *testing.T) {
+	for _, tt := range []struct{ threshold, parties int }{
+		{0, 3}, {4, 3}, {2, MaxParties + 1}, {1, 0},
+	} {
+		if _, _, err := Deal(nil, tt.threshold, tt.parties); err == nil {
+			t.Errorf("Deal(%d, %d) succeeded", tt.threshold, tt.parties)
+		}
+	}
+}
@@ -0,0 +1,57 @@
+// Copyright 2018 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package ed25519
+
+import (
+	"errors"
+
+	"golang.org/x/crypto/ed25519/internal/edwards25519"
+)
+
+// The errors returned by ValidatePublicKey, one for each check it makes.
+var (
+	ErrInvalidPublicKeyLength = errors.New("ed25519: bad public key length")
+	ErrNonCanonicalPublicKey  = errors.New("ed25519: non-canonical public key encoding")
+	ErrPublicKeyNotOnCurve    = errors.New("ed25519: public key is not a point on the curve")
+	ErrSmallOrderPublicKey    = errors.New("ed25519: public key of small order")
+	ErrPublicKeyTorsion       = errors.New("ed25519: public key has a torsion component")
+)
+
+// ValidatePublicKey checks that publicKey is a public key that could have been
+// generated by GenerateKey, and returns nil if it is. Otherwise, it returns
+// ErrInvalidPublicKeyLength if publicKey is not PublicKeySize bytes long,
+// ErrNonCanonicalPublicKey if it is not the canonical encoding of a point,
+// ErrPublicKeyNotOnCurve if it does not encode a point on the curve,
+// ErrSmallOrderPublicKey if the point has an order dividing 8, and
+// ErrPublicKeyTorsion if the point is not in the prime-order subgroup.
+//
+// Verify does not need any of these checks, but keys that fail them can be
+// chosen so that a signature is valid for several messages or several keys,
+// or accepted by some implementations and not others. Applications that
+// register keys, or that need a signature to commit to its key, should
+// validate keys when they are received. Checking the subgroup costs a
+// scalar multiplication, about as much as a verification.
+func ValidatePublicKey(publicKey PublicKey) error {
+	if len(publicKey) != PublicKeySize {
+		return ErrInvalidPublicKeyLength
+	}
+	var b [32]byte
+	copy(b[:], publicKey)
+	var A edwards25519.ExtendedGroupElement
+	switch A.FromCanonicalBytes(&b, false) {
+	case nil:
+	case edwards25519.ErrNonCanonical:
+		return ErrNonCanonicalPublicKey
+	default:
+		return ErrPublicKeyNotOnCurve
+	}
+	if edwards25519.CofactorEqual(&A, edwards25519.NewIdentityPoint()) {
+		return ErrSmallOrderPublicKey
+	}
+	if !A.IsTorsionFree() {
+		return ErrPublicKeyTorsion
+	}
+	return nil
+}
@@ -0,0 +1,65 @@
+// Copyright 2018 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package ed25519
+
+import (
+	"testing"
+
+	"golang.org/x/crypto/ed25519/internal/edwards25519"
+)
+
+func TestValidatePublicKey(t *testing.T) {
+	pub, _, err := GenerateKey(nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := ValidatePublicKey(pub); err != nil {
+		t.Errorf("generated key rejected: %v", err)
+	}
+
+	// [3]B plus a point of order 8.
+	var A edwards25519.ExtendedGroupElement
+	three := [32]byte{3}
+	edwards25519.GeScalarMultBase(&A, &three)
+	var c edwards25519.CachedGroupElement
+	var r edwards25519.CompletedGroupElement
+	T := edwards25519.SmallOrderPoints()[1]
+	T.ToCached(&c)
+	edwards25519.GeAdd(&r, &A, &c)
+	r.ToExtended(&A)
+	var torsionKey [32]byte
+	A.ToBytes(&torsionKey)
+
+	// y = 2 is not the y coordinate of a point on the curve.
+	offCurve := make(PublicKey, PublicKeySize)
+	offCurve[0] = 2
+
+	tests := []struct {
+		name string
+		key  PublicKey
+		want error
+	}{
+		{"short", pub[:31], ErrInvalidPublicKeyLength},
+		{"long", append(pub[:32:32], 0), ErrInvalidPublicKeyLength},
+		{"off curve", offCurve, ErrPublicKeyNotOnCurve},
+		{"torsion component", torsionKey[:], ErrPublicKeyTorsion},
+	}
+	for i, b := range smallOrderEncodings() {
+		want := ErrSmallOrderPublicKey
+		if i >= len(edwards25519.SmallOrderPoints()) {
+			want = ErrNonCanonicalPublicKey
+		}
+		tests = append(tests, struct {
+			name string
+			key  PublicKey
+			want error
+		}{"small order encoding", append(PublicKey(nil), b[:]...), want})
+	}
+	for _, tt := range tests {
+		if err := ValidatePublicKey(tt.key); err != tt.want {
+			t.Errorf("%s %x: got %v, want %v", tt.name, []byte(tt.key), err, tt.want)
+		}
+	}
+}
@@ -0,0 +1,167 @@
+// Copyright 2018 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package ed25519
+
+import (
+	"bytes"
+	"errors"
+	"strconv"
+
+	"golang.org/x/crypto/ed25519/internal/edwards25519"
+)
+
+// PointEncodings selects which encodings of points VerifyWithOptions accepts.
+// A point has a single canonical encoding, but the y coordinate of an
+// encoding can also be a value not reduced modulo 2^255-19, and the sign of a
+// zero x coordinate can be negative.
+type PointEncodings int
+
+const (
+	// VerifyEncodings accepts the encodings Verify accepts: R must be
+	// canonical, as Verify compares it with an encoding it computes, but the
+	// public key need not be.
+	VerifyEncodings PointEncodings = iota
+	// CanonicalEncodings accepts only canonical encodings of R and of the
+	// public key, as the decoding of RFC 8032, Section 5.1.3, requires.
+	CanonicalEncodings
+	// AnyEncodings accepts non-canonical encodings of R and of the public
+	// key, comparing R as a point rather than as bytes.
+	AnyEncodings
+)
+
+var (
+	errInvalidSignature = errors.New("ed25519: invalid signature")
+	errInvalidPublicKey = errors.New("ed25519: invalid public key")
+)
+
+// VerifyWithOptions checks that sig is a valid signature of message by
+// publicKey, using Ed25519, Ed25519ph or Ed25519ctx as selected by opts.Hash
+// and opts.Context, and applying the checks selected by the other fields of
+// opts, which may be nil. It returns nil if the signature is valid. It will
+// panic if len(publicKey) is not PublicKeySize.
+//
+// Implementations of Ed25519 disagree on the edge cases of verification, so
+// the same signature can be valid for one and not for another. Applications
+// whose verifiers must all agree, such as consensus protocols, should select
+// the policy of the others. For example, VerifyZIP215 uses the options
+//
+//	&Options{Encodings: AnyEncodings, Cofactored: true}
+//
+// while the zero Options are equivalent to Verify.
+func VerifyWithOptions(publicKey PublicKey, message, sig []byte, opts *Options) error {
+	if l := len(publicKey); l != PublicKeySize {
+		panic("ed25519: bad public key length: " + strconv.Itoa(l))
+	}
+	if opts == nil {
+		opts = &Options{}
+	}
+	dom, err := signerDomain(message, opts)
+	if err != nil {
+		return err
+	}
+	if len(sig) != SignatureSize || sig[63]&224 != 0 {
+		return errInvalidSignature
+	}
+
+	var S edwards25519.Scalar
+	if _, err := S.SetCanonicalBytes(sig[32:]); err != nil {
+		if !opts.AllowNonCanonicalS {
+			return errors.New("ed25519: non-canonical S")
+		}
+		// [S]B only depends on S modulo the group order.
+		if _, err := S.SetReducedBytes(sig[32:]); err != nil {
+			panic("ed25519: internal error: setting scalar failed")
+		}
+	}
+
+	var A, R edwards25519.ExtendedGroupElement
+	var publicKeyBytes, encodedR [32]byte
+	copy(publicKeyBytes[:], publicKey)
+	copy(encodedR[:], sig[:32])
+	switch opts.Encodings {
+	case VerifyEncodings:
+		if !A.FromBytes(&publicKeyBytes) {
+			return errInvalidPublicKey
+		}
+		if R.FromCanonicalBytes(&encodedR, false) != nil {
+			return errInvalidSignature
+		}
+	case CanonicalEncodings:
+		if A.FromCanonicalBytes(&publicKeyBytes, false) != nil {
+			return errInvalidPublicKey
+		}
+		if R.FromCanonicalBytes(&encodedR, false) != nil {
+			return errInvalidSignature
+		}
+	case AnyEncodings:
+		if !A.FromBytes(&publicKeyBytes) {
+			return errInvalidPublicKey
+		}
+		if !R.FromBytes(&encodedR) {
+			return errInvalidSignature
+		}
+	default:
+		return errors.New("ed25519: unknown point encodings")
+	}
+	if opts.RejectSmallOrderKeys && edwards25519.CofactorEqual(&A, edwards25519.NewIdentityPoint()) {
+		return ErrSmallOrderPublicKey
+	}
+	edwards25519.FeNeg(&A.X, &A.X)
+	edwards25519.FeNeg(&A.T, &A.T)
+
+	sc := getScratch()
+	defer scratchPool.Put(sc)
+	h := sc.h
+	h.Write(dom)
+	h.Write(sig[:32])
+	h.Write(publicKey)
+	h.Write(message)
+	h.Sum(sc.hramDigest[:0])
+	var k edwards25519.Scalar
+	if _, err := k.SetUniformBytes(sc.hramDigest[:]); err != nil {
+		panic("ed25519: internal error: setting scalar failed")
+	}
+
+	// check is [S]B - [k]A, which must be equal to R.
+	var check edwards25519.ProjectiveGroupElement
+	kBytes, sBytes := k.Bytes(), S.Bytes()
+	edwards25519.GeDoubleScalarMultVartime(&check, &kBytes, &A, &sBytes)
+
+	if opts.Cofactored {
+		var checkExtended edwards25519.ExtendedGroupElement
+		check.ToExtended(&checkExtended)
+		if !edwards25519.CofactorEqual(&checkExtended, &R) {
+			return errInvalidSignature
+		}
+		return nil
+	}
+	var checkR, canonicalR [32]byte
+	check.ToBytes(&checkR)
+	R.ToBytes(&canonicalR)
+	if !bytes.Equal(checkR[:], canonicalR[:]) {
+		return errInvalidSignature
+	}
+	return nil
+}
+
+// zip215Options are the verification rules of ZIP 215.
+var zip215Options = &Options{Encodings: AnyEncodings, Cofactored: true}
+
+// VerifyZIP215 reports whether sig is a valid Ed25519 signature of message by
+// publicKey under the rules of ZIP 215, which Zcash adopted so that every
+// implementation agrees on the validity of every signature, including those
+// crafted to exploit the edge cases on which implementations differ. It will
+// panic if len(publicKey) is not PublicKeySize.
+//
+// ZIP 215 accepts non-canonical encodings of R and of the public key, hashing
+// them as they are encoded, accepts public keys of small order, requires S to
+// be canonical, and checks the cofactored equation. Signatures produced by
+// Sign are valid under these rules, but some that Verify rejects are valid
+// too. Consensus protocols that need to agree with other ZIP 215
+// implementations must use VerifyZIP215 rather than Verify or VerifyBatch,
+// which rejects non-canonical encodings of R.
+func VerifyZIP215(publicKey PublicKey, message, sig []byte) bool {
+	return VerifyWithOptions(publicKey, message, sig, zip215Options) == nil
+}
@@ -0,0 +1,249 @@
+// Copyright 2018 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package ed25519
+
+import (
+	"crypto"
+	"crypto/rand"
+	"crypto/sha512"
+	"math/big"
+	"testing"
+
+	"golang.org/x/crypto/ed25519/internal/edwards25519"
+)
+
+// craftSignature returns the public key [a]B and a signature of message by
+// it whose R is [r]B plus the torsion-th point of small order.
+func craftSignature(a, r uint64, torsion int, message []byte) (PublicKey, []byte) {
+	var aS, rS edwards25519.Scalar
+	aS.SetUint64(a)
+	rS.SetUint64(r)
+	aBytes, rBytes := aS.Bytes(), rS.Bytes()
+
+	var A, R edwards25519.ExtendedGroupElement
+	edwards25519.GeScalarMultBase(&A, &aBytes)
+	edwards25519.GeScalarMultBase(&R, &rBytes)
+	T := edwards25519.SmallOrderPoints()[torsion]
+	var tCached edwards25519.CachedGroupElement
+	T.ToCached(&tCached)
+	var c edwards25519.CompletedGroupElement
+	edwards25519.GeAdd(&c, &R, &tCached)
+	c.ToExtended(&R)
+
+	var pub, encodedR [32]byte
+	A.ToBytes(&pub)
+	R.ToBytes(&encodedR)
+	h := sha512.New()
+	h.Write(encodedR[:])
+	h.Write(pub[:])
+	h.Write(message)
+	var k, S edwards25519.Scalar
+	if _, err := k.SetUniformBytes(h.Sum(nil)); err != nil {
+		panic(err)
+	}
+	S.MultiplyAdd(&k, &aS, &rS)
+	sBytes := S.Bytes()
+	return PublicKey(pub[:]), append(encodedR[:], sBytes[:]...)
+}
+
+// addOrder returns sig with l added to its S.
+func addOrder(sig []byte) []byte {
+	l, _ := new(big.Int).SetString("7237005577332262213973186563042994240857116359379907606001950938285454250989", 10)
+	var be [32]byte
+	for i := range be {
+		be[i] = sig[63-i]
+	}
+	s := new(big.Int).SetBytes(be[:])
+	s.Add(s, l)
+	out := append([]byte(nil), sig[:32]...)
+	out = append(out, make([]byte, 32)...)
+	b := s.Bytes()
+	for i := range b {
+		out[32+i] = b[len(b)-1-i]
+	}
+	return out
+}
+
+func TestVerifyWithOptionsPolicies(t *testing.T) {
+	message := []byte("policy")
+	valid := func() (PublicKey, []byte) { return craftSignature(5, 7, 0, message) }
+	torsionR := func() (PublicKey, []byte) { return craftSignature(5, 7, 1, message) }
+	nonCanonicalS := func() (PublicKey, []byte) {
+		pub, sig := craftSignature(5, 7, 0, message)
+		return pub, addOrder(sig)
+	}
+	smallOrderKey := func() (PublicKey, []byte) { return craftSignature(0, 7, 0, message) }
+	// The identity, with y = 1 + p.
+	nonCanonicalIdentity := []byte{
+		0xee, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff,
+		0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0x7f,
+	}
+	nonCanonicalKey := func() (PublicKey, []byte) {
+		_, sig := craftSignature(0, 7, 0, message)
+		return PublicKey(nonCanonicalIdentity), sig
+	}
+	nonCanonicalR := func() (PublicKey, []byte) {
+		pub, sig := craftSignature(0, 0, 0, message)
+		copy(sig[:32], nonCanonicalIdentity)
+		return pub, sig
+	}
+
+	zip215 := &Options{Encodings: AnyEncodings, Cofactored: true}
+	tests := []struct {
+		desc string
+		sig  func() (PublicKey, []byte)
+		opts *Options
+		want bool
+	}{
+		{"valid", valid, nil, true},
+		{"valid, strict", valid, &Options{Encodings: CanonicalEncodings, RejectSmallOrderKeys: true}, true},
+		{"valid, ZIP 215", valid, zip215, true},
+		{"torsion in R", torsionR, nil, false},
+		{"torsion in R, cofactored", torsionR, &Options{Cofactored: true}, true},
+		{"torsion in R, ZIP 215", torsionR, zip215, true},
+		{"non-canonical S", nonCanonicalS, nil, false},
+		{"non-canonical S, allowed", nonCanonicalS, &Options{AllowNonCanonicalS: true}, true},
+		{"non-canonical S, ZIP 215", nonCanonicalS, zip215, false},
+		{"small order key", smallOrderKey, nil, true},
+		{"small order key, rejected", smallOrderKey, &Options{RejectSmallOrderKeys: true}, false},
+		{"non-canonical key", nonCanonicalKey, nil, true},
+		{"non-canonical key, canonical", nonCanonicalKey, &Options{Encodings: CanonicalEncodings}, false},
+		{"non-canonical key, ZIP 215", nonCanonicalKey, zip215, true},
+		{"non-canonical R", nonCanonicalR, nil, false},
+		{"non-canonical R, any", nonCanonicalR, &Options{Encodings: AnyEncodings}, true},
+		{"non-canonical R, ZIP 215", nonCanonicalR, zip215, true},
+	}
+	for _, test := range tests {
+		pub, sig := test.sig()
+		err := VerifyWithOptions(pub, message, sig, test.opts)
+		if got := err == nil; got != test.want {
+			t.Errorf("%s: VerifyWithOptions = %v, want valid %v", test.desc, err, test.want)
+		}
+		if test.opts == nil && Verify(pub, message, sig) != test.want {
+			t.Errorf("%s: Verify disagrees with VerifyWithOptions", test.desc)
+		}
+	}
+}
+
+func TestVerifyWithOptionsMatchesVerify(t *testing.T) {
+	pub, priv, _ := GenerateKey(rand.Reader)
+	message := []byte("test message")
+	sig := Sign(priv, message)
+	for i := 0; i < 8*SignatureSize; i++ {
+		s := append([]byte(nil), sig...)
+		if i > 0 {
+			s[(i-1)/8] ^= 1 << uint((i-1)%8)
+		}
+		want := Verify(pub, message, s)
+		if err := VerifyWithOptions(pub, message, s, &Options{}); (err == nil) != want {
+			t.Errorf("bit %d: Verify = %v, VerifyWithOptions = %v", i-1, want, err)
+		}
+	}
+	if err := VerifyWithOptions(pub, message, sig[:63], nil); err == nil {
+		t.Errorf("short signature accepted")
+	}
+	if err := VerifyWithOptions(pub, message, sig, &Options{Encodings: PointEncodings(42)}); err == nil {
+		t.Errorf("unknown encodings accepted")
+	}
+}
+
+func TestVerifyWithOptionsVariants(t *testing.T) {
+	pub, priv, _ := GenerateKey(rand.Reader)
+	message := []byte("test message")
+	digest := sha512.Sum512(message)
+
+	sig, err := SignPrehashed(priv, digest[:], "ph")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := VerifyWithOptions(pub, digest[:], sig, &Options{Hash: crypto.SHA512, Context: "ph"}); err != nil {
+		t.Errorf("Ed25519ph signature rejected: %v", err)
+	}
+	if err := VerifyWithOptions(pub, digest[:], sig, &Options{Hash: crypto.SHA512}); err == nil {
+		t.Errorf("Ed25519ph signature accepted without its context")
+	}
+
+	sig, err = SignWithContext(priv, message, "ctx")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := VerifyWithOptions(pub, message, sig, &Options{Context: "ctx", Cofactored: true}); err != nil {
+		t.Errorf("Ed25519ctx signature rejected: %v", err)
+	}
+	if err := VerifyWithOptions(pub, message, sig, nil); err == nil {
+		t.Errorf("Ed25519ctx signature accepted as Ed25519")
+	}
+}
+
+// smallOrderEncodings returns the 14 encodings of points of small order: the
+// canonical encodings of the eight points, and the six non-canonical ones,
+// with y >= p or with the sign bit set while x is zero.
+func smallOrderEncodings() [][32]byte {
+	var encodings [][32]byte
+	for _, p := range edwards25519.SmallOrderPoints() {
+		var b [32]byte
+		p.ToBytes(&b)
+		encodings = append(encodings, b)
+	}
+	// p + 1 and p encode y = 1 and y = 0.
+	p1 := [32]byte{0xee, 31: 0x7f}
+	p0 := [32]byte{0xed, 31: 0x7f}
+	for i := 1; i < 31; i++ {
+		p1[i], p0[i] = 0xff, 0xff
+	}
+	for _, b := range [][32]byte{p1, p0} {
+		encodings = append(encodings, b)
+		b[31] |= 0x80
+		encodings = append(encodings, b)
+	}
+	// y = 1 and y = -1 have x = 0, so their sign bit must be clear.
+	one := [32]byte{1}
+	minusOne := p0
+	minusOne[0] = 0xec
+	for _, b := range [][32]byte{one, minusOne} {
+		b[31] |= 0x80
+		encodings = append(encodings, b)
+	}
+	return encodings
+}
+
+func TestVerifyZIP215(t *testing.T) {
+	// The test vectors of ZIP 215 are the signatures with S = 0, and every
+	// combination of encodings of small order points as R and A. They are
+	// all valid under ZIP 215.
+	encodings := smallOrderEncodings()
+	if len(encodings) != 14 {
+		t.Fatalf("got %d encodings of small order points, want 14", len(encodings))
+	}
+	seen := make(map[[32]byte]bool)
+	message := []byte("Zcash")
+	for _, a := range encodings {
+		if seen[a] {
+			t.Fatalf("duplicate encoding %x", a)
+		}
+		seen[a] = true
+		for _, r := range encodings {
+			sig := make([]byte, SignatureSize)
+			copy(sig, r[:])
+			if !VerifyZIP215(a[:], message, sig) {
+				t.Errorf("A = %x, R = %x: rejected", a, r)
+			}
+		}
+	}
+
+	// A signature that only Verify accepts is valid under ZIP 215, and one
+	// with a non-canonical S is not.
+	pub, priv, _ := GenerateKey(rand.Reader)
+	sig := Sign(priv, message)
+	if !VerifyZIP215(pub, message, sig) {
+		t.Errorf("valid signature rejected")
+	}
+	if VerifyZIP215(pub, []byte("Zcash!"), sig) {
+		t.Errorf("signature of another message accepted")
+	}
+	if VerifyZIP215(pub, message, addOrder(sig)) {
+		t.Errorf("signature with a non-canonical S accepted")
+	}
+}
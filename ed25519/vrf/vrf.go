@@ -0,0 +1,272 @@
+// Copyright 2018 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+// Package vrf implements the ECVRF-EDWARDS25519-SHA512-TAI and
+// ECVRF-EDWARDS25519-SHA512-ELL2 verifiable random functions of RFC 9381,
+// with Ed25519 keys.
+//
+// A verifiable random function maps an input, alpha, to a pseudorandom
+// output, beta, with a private key, and produces a proof that lets anyone
+// with the public key check that beta is the output for alpha. Unlike a
+// signature, the proof and the output are unique: there is a single valid
+// proof, and thus a single output, for each input and key, which makes VRFs
+// suitable for leader election and lotteries.
+//
+// The two suites only differ in how they hash alpha to a point of the curve,
+// and give different proofs and outputs for the same key and input. TAI uses
+// try-and-increment, whose running time depends on alpha, and ELL2 uses the
+// Elligator 2 encoding of RFC 9380, which does not. ELL2 should be preferred
+// when alpha is secret.
+package vrf // import "golang.org/x/crypto/ed25519/vrf"
+
+import (
+	"crypto/sha512"
+	"errors"
+	"strconv"
+
+	"golang.org/x/crypto/ed25519"
+	"golang.org/x/crypto/ed25519/internal/edwards25519"
+)
+
+const (
+	// ProofSize is the size, in bytes, of proofs.
+	ProofSize = 80
+	// OutputSize is the size, in bytes, of the outputs, beta.
+	OutputSize = sha512.Size
+
+	// challengeSize is cLen, the size of the challenge in a proof.
+	challengeSize = 16
+)
+
+var (
+	errProof     = errors.New("vrf: invalid proof")
+	errPublicKey = errors.New("vrf: invalid public key")
+)
+
+// A Suite is an ECVRF ciphersuite.
+type Suite struct {
+	name string
+	id   byte // suite_string
+	// encode sets p to ECVRF_encode_to_curve(publicKey, alpha), and reports
+	// whether it succeeded.
+	encode func(s *Suite, p *edwards25519.ExtendedGroupElement, publicKey, alpha []byte) bool
+}
+
+var (
+	// TAI is ECVRF-EDWARDS25519-SHA512-TAI, which hashes to the curve by
+	// try-and-increment.
+	TAI = &Suite{name: "ECVRF-EDWARDS25519-SHA512-TAI", id: 0x03, encode: encodeTAI}
+
+	// ELL2 is ECVRF-EDWARDS25519-SHA512-ELL2, which hashes to the curve with
+	// the edwards25519_XMD:SHA-512_ELL2_NU_ suite of RFC 9380.
+	ELL2 = &Suite{name: "ECVRF-EDWARDS25519-SHA512-ELL2", id: 0x04, encode: encodeELL2}
+)
+
+// String returns the name of the suite in RFC 9381.
+func (s *Suite) String() string {
+	return s.name
+}
+
+// encodeTAI implements ECVRF_encode_to_curve_try_and_increment, from RFC
+// 9381, Section 5.4.1.1.
+func encodeTAI(s *Suite, p *edwards25519.ExtendedGroupElement, publicKey, alpha []byte) bool {
+	h := sha512.New()
+	var digest [sha512.Size]byte
+	var candidate [32]byte
+	for ctr := 0; ctr < 256; ctr++ {
+		h.Reset()
+		h.Write([]byte{s.id, 0x01})
+		h.Write(publicKey)
+		h.Write(alpha)
+		h.Write([]byte{byte(ctr), 0x00})
+		h.Sum(digest[:0])
+		copy(candidate[:], digest[:])
+		var q edwards25519.ExtendedGroupElement
+		if q.FromCanonicalBytes(&candidate, false) == nil {
+			edwards25519.GeMultByPow2(p, &q, 3)
+			return true
+		}
+	}
+	return false
+}
+
+// encodeELL2 implements ECVRF_encode_to_curve_h2c_suite, from RFC 9381,
+// Section 5.4.1.2.
+func encodeELL2(s *Suite, p *edwards25519.ExtendedGroupElement, publicKey, alpha []byte) bool {
+	dst := append([]byte("ECVRF_edwards25519_XMD:SHA-512_ELL2_NU_"), s.id)
+	msg := make([]byte, 0, len(publicKey)+len(alpha))
+	msg = append(msg, publicKey...)
+	msg = append(msg, alpha...)
+	edwards25519.EncodeToCurve(p, msg, dst)
+	return true
+}
+
+// challenge implements ECVRF_challenge_generation, from RFC 9381, Section
+// 5.4.3, and returns c as a scalar.
+func (s *Suite) challenge(points ...*edwards25519.ExtendedGroupElement) [32]byte {
+	h := sha512.New()
+	h.Write([]byte{s.id, 0x02})
+	var b [32]byte
+	for _, p := range points {
+		p.ToBytes(&b)
+		h.Write(b[:])
+	}
+	h.Write([]byte{0x00})
+	var c [32]byte
+	copy(c[:], h.Sum(nil)[:challengeSize])
+	return c
+}
+
+// proofToHash returns beta for Gamma, as ECVRF_proof_to_hash does in RFC
+// 9381, Section 5.2.
+func (s *Suite) proofToHash(gamma *edwards25519.ExtendedGroupElement) []byte {
+	var p edwards25519.ExtendedGroupElement
+	edwards25519.GeMultByPow2(&p, gamma, 3)
+	var b [32]byte
+	p.ToBytes(&b)
+	h := sha512.New()
+	h.Write([]byte{s.id, 0x03})
+	h.Write(b[:])
+	h.Write([]byte{0x00})
+	return h.Sum(nil)
+}
+
+// Prove returns the ProofSize-byte proof of the output of privateKey for
+// alpha. It will panic if len(privateKey) is not ed25519.PrivateKeySize.
+func (s *Suite) Prove(privateKey ed25519.PrivateKey, alpha []byte) []byte {
+	if l := len(privateKey); l != ed25519.PrivateKeySize {
+		panic("vrf: bad private key length: " + strconv.Itoa(l))
+	}
+	// x and the nonce prefix are derived from the seed as in RFC 8032.
+	expanded := privateKey.Expand().Bytes()
+	var x edwards25519.Scalar
+	if _, err := x.SetReducedBytes(expanded[:32]); err != nil {
+		panic("vrf: internal error: setting scalar failed")
+	}
+	publicKey := privateKey[ed25519.SeedSize:]
+
+	var Y, H, gamma, U, V edwards25519.ExtendedGroupElement
+	if err := Y.FromCanonicalBytes(publicKeyArray(publicKey), false); err != nil {
+		panic("vrf: invalid public key in private key")
+	}
+	if !s.encode(s, &H, publicKey, alpha) {
+		// This happens with probability 2^-256.
+		panic("vrf: failed to hash to the curve")
+	}
+	var hString [32]byte
+	H.ToBytes(&hString)
+
+	xb := x.Bytes()
+	edwards25519.GeScalarMult(&gamma, &xb, &H)
+
+	// ECVRF_nonce_generation_RFC8032, from RFC 9381, Section 5.4.2.2.
+	h := sha512.New()
+	h.Write(expanded[32:])
+	h.Write(hString[:])
+	var k edwards25519.Scalar
+	if _, err := k.SetUniformBytes(h.Sum(nil)); err != nil {
+		panic("vrf: internal error: setting scalar failed")
+	}
+	kb := k.Bytes()
+	edwards25519.GeScalarMultBase(&U, &kb)
+	edwards25519.GeScalarMult(&V, &kb, &H)
+
+	cb := s.challenge(&Y, &H, &gamma, &U, &V)
+	var c, sc edwards25519.Scalar
+	if _, err := c.SetCanonicalBytes(cb[:]); err != nil {
+		panic("vrf: internal error: setting scalar failed")
+	}
+	sc.MultiplyAdd(&c, &x, &k)
+
+	for i := range expanded {
+		expanded[i] = 0
+	}
+	x, k, xb, kb = edwards25519.Scalar{}, edwards25519.Scalar{}, [32]byte{}, [32]byte{}
+
+	proof := make([]byte, 0, ProofSize)
+	var b [32]byte
+	gamma.ToBytes(&b)
+	proof = append(proof, b[:]...)
+	proof = append(proof, cb[:challengeSize]...)
+	b = sc.Bytes()
+	return append(proof, b[:]...)
+}
+
+// Verify checks that proof is a valid proof of the output of publicKey for
+// alpha, and returns that output. It rejects public keys of small order, as
+// ECVRF_verify does with validate_key set to true, since they let the owner
+// of the key make proofs of several outputs for the same alpha.
+func (s *Suite) Verify(publicKey ed25519.PublicKey, alpha, proof []byte) ([]byte, error) {
+	if len(publicKey) != ed25519.PublicKeySize {
+		return nil, errPublicKey
+	}
+	var Y edwards25519.ExtendedGroupElement
+	if err := Y.FromCanonicalBytes(publicKeyArray(publicKey), true); err != nil {
+		return nil, errPublicKey
+	}
+	var gamma edwards25519.ExtendedGroupElement
+	var c, sc [32]byte
+	if err := decodeProof(&gamma, &c, &sc, proof); err != nil {
+		return nil, err
+	}
+	var H edwards25519.ExtendedGroupElement
+	if !s.encode(s, &H, publicKey, alpha) {
+		return nil, errProof
+	}
+
+	// U = [s]B - [c]Y and V = [s]H - [c]Gamma.
+	var negY, negGamma, U, V edwards25519.ExtendedGroupElement
+	edwards25519.GeNeg(&negY, &Y)
+	edwards25519.GeNeg(&negGamma, &gamma)
+	var u edwards25519.ProjectiveGroupElement
+	edwards25519.GeDoubleScalarMultVartime(&u, &c, &negY, &sc)
+	u.ToExtended(&U)
+	edwards25519.GeLinearCombinationVartime(&V, []edwards25519.Term{
+		{Scalar: sc, Point: H},
+		{Scalar: c, Point: negGamma},
+	}, 1)
+
+	if s.challenge(&Y, &H, &gamma, &U, &V) != c {
+		return nil, errProof
+	}
+	return s.proofToHash(&gamma), nil
+}
+
+// ProofToHash returns the output proved by proof, without checking the
+// proof. It must only be used on proofs that were verified, or made by
+// Prove, since anyone can make an unverified proof of any output.
+func (s *Suite) ProofToHash(proof []byte) ([]byte, error) {
+	var gamma edwards25519.ExtendedGroupElement
+	var c, sc [32]byte
+	if err := decodeProof(&gamma, &c, &sc, proof); err != nil {
+		return nil, err
+	}
+	return s.proofToHash(&gamma), nil
+}
+
+// decodeProof implements ECVRF_decode_proof, from RFC 9381, Section 5.4.4.
+func decodeProof(gamma *edwards25519.ExtendedGroupElement, c, s *[32]byte, proof []byte) error {
+	if len(proof) != ProofSize {
+		return errProof
+	}
+	var g [32]byte
+	copy(g[:], proof[:32])
+	if gamma.FromCanonicalBytes(&g, false) != nil {
+		return errProof
+	}
+	*c = [32]byte{}
+	copy(c[:], proof[32:32+challengeSize])
+	var sc edwards25519.Scalar
+	if _, err := sc.SetCanonicalBytes(proof[32+challengeSize:]); err != nil {
+		return errProof
+	}
+	*s = sc.Bytes()
+	return nil
+}
+
+func publicKeyArray(publicKey []byte) *[32]byte {
+	var b [32]byte
+	copy(b[:], publicKey)
+	return &b
+}
@@ -0,0 +1,141 @@
+// Copyright 2018 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package vrf
+
+import (
+	"bytes"
+	"encoding/hex"
+	"testing"
+
+	"golang.org/x/crypto/ed25519"
+)
+
+// vrfTests are from RFC 9381, Appendix B.3 and B.4.
+var vrfTests = []struct {
+	suite *Suite
+	seed  string
+	alpha string
+	proof string
+	beta  string
+}{
+	{
+		TAI,
+		"9d61b19deffd5a60ba844af492ec2cc44449c5697b326919703bac031cae7f60",
+		"",
+		"8657106690b5526245a92b003bb079ccd1a92130477671f6fc01ad16f26f723f26f8a57ccaed74ee1b190bed1f479d9727d2d0f9b005a6e456a35d4fb0daab1268a1b0db10836d9826a528ca76567805",
+		"90cf1df3b703cce59e2a35b925d411164068269d7b2d29f3301c03dd757876ff66b71dda49d2de59d03450451af026798e8f81cd2e333de5cdf4f3e140fdd8ae",
+	},
+	{
+		TAI,
+		"4ccd089b28ff96da9db6c346ec114e0f5b8a319f35aba624da8cf6ed4fb8a6fb",
+		"72",
+		"f3141cd382dc42909d19ec5110469e4feae18300e94f304590abdced48aed5933bf0864a62558b3ed7f2fea45c92a465301b3bbf5e3e54ddf2d935be3b67926da3ef39226bbc355bdc9850112c8f4b02",
+		"eb4440665d3891d668e7e0fcaf587f1b4bd7fbfe99d0eb2211ccec90496310eb5e33821bc613efb94db5e5b54c70a848a0bef4553a41befc57663b56373a5031",
+	},
+	{
+		TAI,
+		"c5aa8df43f9f837bedb7442f31dcb7b166d38535076f094b85ce3a2e0b4458f7",
+		"af82",
+		"9bc0f79119cc5604bf02d23b4caede71393cedfbb191434dd016d30177ccbf8096bb474e53895c362d8628ee9f9ea3c0e52c7a5c691b6c18c9979866568add7a2d41b00b05081ed0f58ee5e31b3a970e",
+		"645427e5d00c62a23fb703732fa5d892940935942101e456ecca7bb217c61c452118fec1219202a0edcf038bb6373241578be7217ba85a2687f7a0310b2df19f",
+	},
+	{
+		ELL2,
+		"9d61b19deffd5a60ba844af492ec2cc44449c5697b326919703bac031cae7f60",
+		"",
+		"7d9c633ffeee27349264cf5c667579fc583b4bda63ab71d001f89c10003ab46f14adf9a3cd8b8412d9038531e865c341cafa73589b023d14311c331a9ad15ff2fb37831e00f0acaa6d73bc9997b06501",
+		"9d574bf9b8302ec0fc1e21c3ec5368269527b87b462ce36dab2d14ccf80c53cccf6758f058c5b1c856b116388152bbe509ee3b9ecfe63d93c3b4346c1fbc6c54",
+	},
+	{
+		ELL2,
+		"4ccd089b28ff96da9db6c346ec114e0f5b8a319f35aba624da8cf6ed4fb8a6fb",
+		"72",
+		"47b327393ff2dd81336f8a2ef10339112401253b3c714eeda879f12c509072ef055b48372bb82efbdce8e10c8cb9a2f9d60e93908f93df1623ad78a86a028d6bc064dbfc75a6a57379ef855dc6733801",
+		"38561d6b77b71d30eb97a062168ae12b667ce5c28caccdf76bc88e093e4635987cd96814ce55b4689b3dd2947f80e59aac7b7675f8083865b46c89b2ce9cc735",
+	},
+	{
+		ELL2,
+		"c5aa8df43f9f837bedb7442f31dcb7b166d38535076f094b85ce3a2e0b4458f7",
+		"af82",
+		"926e895d308f5e328e7aa159c06eddbe56d06846abf5d98c2512235eaa57fdce35b46edfc655bc828d44ad09d1150f31374e7ef73027e14760d42e77341fe05467bb286cc2c9d7fde29120a0b2320d04",
+		"121b7f9b9aaaa29099fc04a94ba52784d44eac976dd1a3cca458733be5cd090a7b5fbd148444f17f8daf1fb55cb04b1ae85a626e30a54b4b0f8abf4a43314a58",
+	},
+}
+
+func decodeHex(t *testing.T, s string) []byte {
+	b, err := hex.DecodeString(s)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return b
+}
+
+func TestVectors(t *testing.T) {
+	for i, test := range vrfTests {
+		priv := ed25519.NewKeyFromSeed(decodeHex(t, test.seed))
+		pub := priv.Public().(ed25519.PublicKey)
+		alpha := decodeHex(t, test.alpha)
+
+		proof := test.suite.Prove(priv, alpha)
+		if got := hex.EncodeToString(proof); got != test.proof {
+			t.Errorf("#%d %v: proof = %s, want %s", i, test.suite, got, test.proof)
+		}
+		beta, err := test.suite.Verify(pub, alpha, proof)
+		if err != nil {
+			t.Errorf("#%d %v: Verify: %v", i, test.suite, err)
+		} else if got := hex.EncodeToString(beta); got != test.beta {
+			t.Errorf("#%d %v: beta = %s, want %s", i, test.suite, got, test.beta)
+		}
+		beta, err = test.suite.ProofToHash(proof)
+		if err != nil || hex.EncodeToString(beta) != test.beta {
+			t.Errorf("#%d %v: ProofToHash = %x, %v", i, test.suite, beta, err)
+		}
+	}
+}
+
+func TestVerifyRejects(t *testing.T) {
+	priv := ed25519.NewKeyFromSeed(make([]byte, ed25519.SeedSize))
+	pub := priv.Public().(ed25519.PublicKey)
+	other := ed25519.NewKeyFromSeed(bytes.Repeat([]byte{1}, ed25519.SeedSize)).Public().(ed25519.PublicKey)
+	alpha := []byte("round 42")
+
+	for _, suite := range []*Suite{TAI, ELL2} {
+		proof := suite.Prove(priv, alpha)
+		if _, err := suite.Verify(pub, alpha, proof); err != nil {
+			t.Fatalf("%v: Verify: %v", suite, err)
+		}
+
+		check := func(name string, pub ed25519.PublicKey, alpha, proof []byte) {
+			if _, err := suite.Verify(pub, alpha, proof); err == nil {
+				t.Errorf("%v: Verify accepted %s", suite, name)
+			}
+		}
+		check("another input", pub, []byte("round 43"), proof)
+		check("another key", other, alpha, proof)
+		check("a short proof", pub, alpha, proof[:ProofSize-1])
+		check("a short key", pub[:31], alpha, proof)
+		// The identity, of small order.
+		check("a key of small order", append([]byte{1}, make([]byte, 31)...), alpha, proof)
+		for _, i := range []int{0, 32, 48, ProofSize - 1} {
+			bad := append([]byte(nil), proof...)
+			bad[i] ^= 1
+			check("a modified proof", pub, alpha, bad)
+		}
+		// s must be less than the group order.
+		bad := append([]byte(nil), proof...)
+		copy(bad[48:], bytes.Repeat([]byte{0xff}, 32))
+		check("a non-canonical s", pub, alpha, bad)
+		if _, err := suite.ProofToHash(bad); err == nil {
+			t.Errorf("%v: ProofToHash accepted a non-canonical s", suite)
+		}
+
+		// The other suite gives another proof.
+		otherSuite := TAI
+		if suite == TAI {
+			otherSuite = ELL2
+		}
+		check("a proof of the other suite", pub, alpha, otherSuite.Prove(priv, alpha))
+	}
+}
@@ -0,0 +1,68 @@
+// Copyright 2018 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package ed25519
+
+import (
+	"errors"
+	"strconv"
+
+	"golang.org/x/crypto/ed25519/internal/edwards25519"
+)
+
+// X25519 keys are 32 bytes long, and can be used with the ScalarMult and
+// ScalarBaseMult functions of golang.org/x/crypto/curve25519.
+
+// PublicKeyToX25519 returns the X25519 public key corresponding to publicKey,
+// the Montgomery u-coordinate (1 + y) / (1 - y) of the Ed25519 point, as
+// given by the birational map of RFC 7748, Section 4.1. It will panic if
+// len(publicKey) is not PublicKeySize.
+//
+// It returns an error for non-canonical encodings, for encodings of points
+// not on the curve, and for points of small order, with which X25519 would
+// produce an all-zero shared secret.
+//
+// Converting keys lets a single Ed25519 identity also receive encrypted
+// messages, for example with golang.org/x/crypto/nacl/box. Using the same key
+// for signatures and key exchange is safe for these two algorithms, but a
+// protocol designed from scratch should rather have a key of each kind.
+func PublicKeyToX25519(publicKey PublicKey) ([]byte, error) {
+	if l := len(publicKey); l != PublicKeySize {
+		panic("ed25519: bad public key length: " + strconv.Itoa(l))
+	}
+	var A edwards25519.ExtendedGroupElement
+	var publicKeyBytes [32]byte
+	copy(publicKeyBytes[:], publicKey)
+	if err := A.FromCanonicalBytes(&publicKeyBytes, true); err != nil {
+		return nil, errors.New("ed25519: cannot convert public key to X25519: " + err.Error())
+	}
+
+	// u = (1 + y) / (1 - y) = (Z + Y) / (Z - Y). Z - Y is not zero, as y = 1
+	// only for the identity, which is of small order.
+	var num, den edwards25519.FieldElement
+	edwards25519.FeAdd(&num, &A.Z, &A.Y)
+	edwards25519.FeSub(&den, &A.Z, &A.Y)
+	edwards25519.FeInvert(&den, &den)
+	edwards25519.FeMul(&num, &num, &den)
+	var u [32]byte
+	edwards25519.FeToBytes(&u, &num)
+	return u[:], nil
+}
+
+// PrivateKeyToX25519 returns the X25519 private key corresponding to
+// privateKey: the clamped first half of the SHA-512 digest of its seed, which
+// is the secret scalar of Ed25519, as described in RFC 8032, Section 5.1.5.
+// Multiplying the X25519 base point by it gives the X25519 public key that
+// PublicKeyToX25519 returns for the public key of privateKey. It will panic
+// if len(privateKey) is not PrivateKeySize.
+//
+// The result must be kept as secret as privateKey.
+func PrivateKeyToX25519(privateKey PrivateKey) []byte {
+	if l := len(privateKey); l != PrivateKeySize {
+		panic("ed25519: bad private key length: " + strconv.Itoa(l))
+	}
+	var s [32]byte
+	expandSeed(&s, privateKey.Seed())
+	return s[:]
+}
@@ -0,0 +1,80 @@
+// Copyright 2018 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package ed25519
+
+import (
+	"bytes"
+	"crypto/rand"
+	"encoding/hex"
+	"testing"
+
+	"golang.org/x/crypto/curve25519"
+	"golang.org/x/crypto/ed25519/internal/edwards25519"
+)
+
+func TestX25519Conversion(t *testing.T) {
+	for i := 0; i < 16; i++ {
+		pub, priv, err := GenerateKey(rand.Reader)
+		if err != nil {
+			t.Fatal(err)
+		}
+		xPub, err := PublicKeyToX25519(pub)
+		if err != nil {
+			t.Fatal(err)
+		}
+		var xPriv, want [32]byte
+		copy(xPriv[:], PrivateKeyToX25519(priv))
+		curve25519.ScalarBaseMult(&want, &xPriv)
+		if !bytes.Equal(xPub, want[:]) {
+			t.Errorf("PublicKeyToX25519 = %x, want %x", xPub, want)
+		}
+	}
+
+	// Two converted keys agree on a shared secret.
+	_, alice, _ := GenerateKey(rand.Reader)
+	bob, _, _ := GenerateKey(rand.Reader)
+	bobPub, _ := PublicKeyToX25519(bob)
+	var in, base [32]byte
+	copy(in[:], PrivateKeyToX25519(alice))
+	copy(base[:], bobPub)
+	var shared [32]byte
+	curve25519.ScalarMult(&shared, &in, &base)
+	if shared == ([32]byte{}) {
+		t.Errorf("shared secret is zero")
+	}
+}
+
+func TestX25519ConversionVector(t *testing.T) {
+	// The key of the first test vector of RFC 8032, Section 7.1, converted
+	// with the birational map and the clamping of RFC 7748.
+	seed, _ := hex.DecodeString("9d61b19deffd5a60ba844af492ec2cc44449c5697b326919703bac031cae7f60")
+	wantPub, _ := hex.DecodeString("d85e07ec22b0ad881537c2f44d662d1a143cf830c57aca4305d85c7a90f6b62e")
+	wantPriv, _ := hex.DecodeString("307c83864f2833cb427a2ef1c00a013cfdff2768d980c0a3a520f006904de94f")
+	priv := NewKeyFromSeed(seed)
+	xPub, err := PublicKeyToX25519(priv.Public().(PublicKey))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(xPub, wantPub) {
+		t.Errorf("PublicKeyToX25519 = %x, want %x", xPub, wantPub)
+	}
+	if xPriv := PrivateKeyToX25519(priv); !bytes.Equal(xPriv, wantPriv) {
+		t.Errorf("PrivateKeyToX25519 = %x, want %x", xPriv, wantPriv)
+	}
+}
+
+func TestX25519ConversionRejects(t *testing.T) {
+	for _, p := range edwards25519.SmallOrderPoints() {
+		var b [32]byte
+		p.ToBytes(&b)
+		if _, err := PublicKeyToX25519(b[:]); err == nil {
+			t.Errorf("small order point %x accepted", b)
+		}
+	}
+	// y = 2 is not on the curve.
+	if _, err := PublicKeyToX25519(append([]byte{2}, make([]byte, 31)...)); err == nil {
+		t.Errorf("point not on the curve accepted")
+	}
+}
@@ -0,0 +1,146 @@
+// Copyright 2018 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+// Package fingerprint formats, parses and compares the fingerprints of public
+// keys in the forms shown to users by OpenSSH, OpenPGP implementations and
+// OTR clients, so that the protocol packages present them consistently. It
+// is used by the ssh, openpgp/packet and otr packages.
+//
+// The package works on fingerprints, the digests of encoded keys, and not on
+// keys: each protocol defines what is hashed, and with which hash.
+package fingerprint // import "golang.org/x/crypto/fingerprint"
+
+import (
+	"crypto/subtle"
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
+	"strings"
+)
+
+var (
+	errMalformed     = errors.New("fingerprint: malformed fingerprint")
+	errPGPLength     = errors.New("fingerprint: OpenPGP fingerprint is neither 20 nor 32 bytes long")
+	errSSHDigestSize = errors.New("fingerprint: OpenSSH fingerprint of unexpected length")
+)
+
+// Equal reports whether the fingerprints a and b are equal, in time that
+// only depends on their lengths, so that comparing a fingerprint against one
+// chosen by an attacker does not reveal how many of their leading bytes
+// match.
+func Equal(a, b []byte) bool {
+	return subtle.ConstantTimeCompare(a, b) == 1
+}
+
+// Hex returns the lowercase hexadecimal encoding of fp, in groups of group
+// bytes separated by sep. If group is zero or negative, fp is not grouped.
+// For example, Hex(fp, ":", 1) is the legacy MD5 form of OpenSSH, such as
+// "63:49:e7:4f:...".
+func Hex(fp []byte, sep string, group int) string {
+	if group <= 0 {
+		group = len(fp)
+	}
+	b := make([]byte, 0, 2*len(fp)+len(sep)*(len(fp)/group))
+	for i := 0; i < len(fp); i += group {
+		if i > 0 {
+			b = append(b, sep...)
+		}
+		end := i + group
+		if end > len(fp) {
+			end = len(fp)
+		}
+		b = append(b, hex.EncodeToString(fp[i:end])...)
+	}
+	return string(b)
+}
+
+// ParseHex parses a hexadecimal fingerprint in either case, ignoring the
+// colons, spaces and dashes that separate its groups, as well as a leading
+// "0x".
+func ParseHex(s string) ([]byte, error) {
+	s = strings.TrimPrefix(strings.TrimPrefix(strings.TrimSpace(s), "0x"), "0X")
+	s = strings.Map(func(r rune) rune {
+		switch r {
+		case ':', ' ', '-':
+			return -1
+		}
+		return r
+	}, s)
+	if s == "" {
+		return nil, errMalformed
+	}
+	fp, err := hex.DecodeString(s)
+	if err != nil {
+		return nil, errMalformed
+	}
+	return fp, nil
+}
+
+// SSH returns the fingerprint fp of an SSH public key in the form printed by
+// ssh-keygen -l: "SHA256:" followed by the unpadded base64 encoding of fp if
+// it is a SHA-256 digest, or "MD5:" followed by the colon-separated hex
+// encoding of fp if it is an MD5 digest. It panics if fp is neither 32 nor
+// 16 bytes long.
+func SSH(fp []byte) string {
+	switch len(fp) {
+	case 32:
+		return "SHA256:" + base64.RawStdEncoding.EncodeToString(fp)
+	case 16:
+		return "MD5:" + Hex(fp, ":", 1)
+	}
+	panic(errSSHDigestSize)
+}
+
+// ParseSSH parses a fingerprint in the form returned by SSH. It also accepts
+// the colon-separated hex form of MD5 fingerprints without the "MD5:" prefix,
+// as printed by versions of OpenSSH older than 6.8.
+func ParseSSH(s string) ([]byte, error) {
+	switch {
+	case strings.HasPrefix(s, "SHA256:"):
+		fp, err := base64.RawStdEncoding.DecodeString(s[len("SHA256:"):])
+		if err != nil || len(fp) != 32 {
+			return nil, errMalformed
+		}
+		return fp, nil
+	case strings.HasPrefix(s, "MD5:"):
+		s = s[len("MD5:"):]
+	}
+	if len(s) != 16*3-1 || strings.Count(s, ":") != 15 {
+		return nil, errMalformed
+	}
+	return ParseHex(s)
+}
+
+// PGP returns the fingerprint fp of an OpenPGP key in the form shown by GnuPG:
+// uppercase hex in groups of two bytes separated by spaces, with two spaces
+// between the two halves. fp is 20 bytes long for version 4 keys and 32 bytes
+// long for version 6 keys. It panics if fp has any other length.
+func PGP(fp []byte) string {
+	if len(fp) != 20 && len(fp) != 32 {
+		panic(errPGPLength)
+	}
+	half := len(fp) / 2
+	s := Hex(fp[:half], " ", 2) + "  " + Hex(fp[half:], " ", 2)
+	return strings.ToUpper(s)
+}
+
+// ParsePGP parses an OpenPGP fingerprint in the form returned by PGP, or in
+// any hex form accepted by ParseHex, such as "0x" followed by 40 hex digits.
+func ParsePGP(s string) ([]byte, error) {
+	fp, err := ParseHex(s)
+	if err != nil {
+		return nil, err
+	}
+	if len(fp) != 20 && len(fp) != 32 {
+		return nil, errPGPLength
+	}
+	return fp, nil
+}
+
+// OTR returns the fingerprint fp of an OTR public key in the form shown by
+// libotr clients: five groups of eight uppercase hex digits separated by
+// spaces.
+func OTR(fp []byte) string {
+	return strings.ToUpper(Hex(fp, " ", 4))
+}
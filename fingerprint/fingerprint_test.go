@@ -0,0 +1,171 @@
+// Copyright 2018 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package fingerprint
+
+import (
+	"bytes"
+	"crypto/md5"
+	"crypto/sha256"
+	"encoding/base64"
+	"testing"
+)
+
+// sshKey is the wire encoding of an Ed25519 key from ssh-keygen, whose
+// fingerprints and random art below are as printed by ssh-keygen -lv.
+const sshKey = "AAAAC3NzaC1lZDI1NTE5AAAAICrzCIpHi+WM0Yl/rfl5gpMXjTbroPjR3pDOF2fOXsTJ"
+
+const sshArt = `+--[ED25519 256]--+
+|o                |
+|=        . o     |
+|=.    . = = .    |
+|oE   . + O o     |
+| +o o o S = .    |
+|o .+ . % + o     |
+| .  . * = =      |
+|     .o* B.      |
+|     .o+Oo       |
++----[SHA256]-----+`
+
+func sshDigests(t *testing.T) (sha, md []byte) {
+	key, err := base64.StdEncoding.DecodeString(sshKey)
+	if err != nil {
+		t.Fatal(err)
+	}
+	s, m := sha256.Sum256(key), md5.Sum(key)
+	return s[:], m[:]
+}
+
+func TestSSH(t *testing.T) {
+	sha, md := sshDigests(t)
+	for _, tt := range []struct {
+		fp  []byte
+		out string
+	}{
+		{sha, "SHA256:CnK1c2aabVscraZQ4XRuCXnWqIu38smn4gkA6E5DCOg"},
+		{md, "MD5:63:49:e7:4f:2d:99:6d:bb:f9:b7:bb:5e:36:57:f4:d0"},
+	} {
+		if got := SSH(tt.fp); got != tt.out {
+			t.Errorf("SSH(%x) = %q, want %q", tt.fp, got, tt.out)
+		}
+		fp, err := ParseSSH(tt.out)
+		if err != nil || !bytes.Equal(fp, tt.fp) {
+			t.Errorf("ParseSSH(%q) = %x, %v, want %x", tt.out, fp, err, tt.fp)
+		}
+	}
+
+	// The legacy form, without a prefix.
+	if fp, err := ParseSSH("63:49:e7:4f:2d:99:6d:bb:f9:b7:bb:5e:36:57:f4:d0"); err != nil || !bytes.Equal(fp, md) {
+		t.Errorf("ParseSSH(legacy MD5) = %x, %v, want %x", fp, err, md)
+	}
+
+	for _, s := range []string{
+		"",
+		"SHA256:",
+		"SHA256:CnK1c2aabVscraZQ4XRuCXnWqIu38smn4gkA6E5DCO",
+		"SHA256:CnK1c2aabVscraZQ4XRuCXnWqIu38smn4gkA6E5DCOg=",
+		"SHA512:CnK1c2aabVscraZQ4XRuCXnWqIu38smn4gkA6E5DCOg",
+		"MD5:6349e74f2d996dbbf9b7bb5e3657f4d0",
+		"MD5:63:49:e7:4f:2d:99:6d:bb:f9:b7:bb:5e:36:57:f4",
+		"MD5:63:49:e7:4f:2d:99:6d:bb:f9:b7:bb:5e:36:57:f4:zz",
+	} {
+		if fp, err := ParseSSH(s); err == nil {
+			t.Errorf("ParseSSH(%q) = %x, want error", s, fp)
+		}
+	}
+}
+
+func TestPGP(t *testing.T) {
+	v4 := []byte{
+		0x5c, 0x2e, 0x46, 0xa0, 0xf5, 0x3a, 0x76, 0xed, 0x6f, 0x3c,
+		0x09, 0x1b, 0x5c, 0xde, 0xa2, 0xbf, 0x30, 0x13, 0x2a, 0x4d,
+	}
+	v6 := bytes.Repeat([]byte{0xcb, 0x18, 0x6c, 0x4f}, 8)
+	for _, tt := range []struct {
+		fp  []byte
+		out string
+	}{
+		{v4, "5C2E 46A0 F53A 76ED 6F3C  091B 5CDE A2BF 3013 2A4D"},
+		{v6, "CB18 6C4F CB18 6C4F CB18 6C4F CB18 6C4F  CB18 6C4F CB18 6C4F CB18 6C4F CB18 6C4F"},
+	} {
+		if got := PGP(tt.fp); got != tt.out {
+			t.Errorf("PGP(%x) = %q, want %q", tt.fp, got, tt.out)
+		}
+		fp, err := ParsePGP(tt.out)
+		if err != nil || !bytes.Equal(fp, tt.fp) {
+			t.Errorf("ParsePGP(%q) = %x, %v, want %x", tt.out, fp, err, tt.fp)
+		}
+	}
+	if fp, err := ParsePGP("0x5c2e46a0f53a76ed6f3c091b5cdea2bf30132a4d"); err != nil || !bytes.Equal(fp, v4) {
+		t.Errorf("ParsePGP(0x form) = %x, %v, want %x", fp, err, v4)
+	}
+	for _, s := range []string{"", "0x", "5C2E 46A0", "5C2E 46A0 F53A 76ED 6F3C  091B 5CDE A2BF 3013 2A4G"} {
+		if fp, err := ParsePGP(s); err == nil {
+			t.Errorf("ParsePGP(%q) = %x, want error", s, fp)
+		}
+	}
+}
+
+func TestHex(t *testing.T) {
+	fp := []byte{0x01, 0x23, 0x45, 0x67, 0x89, 0xab, 0xcd}
+	for _, tt := range []struct {
+		sep   string
+		group int
+		out   string
+	}{
+		{"", 0, "0123456789abcd"},
+		{":", 1, "01:23:45:67:89:ab:cd"},
+		{" ", 2, "0123 4567 89ab cd"},
+		{"-", 3, "012345-6789ab-cd"},
+		{"-", 7, "0123456789abcd"},
+		{"-", 8, "0123456789abcd"},
+	} {
+		out := Hex(fp, tt.sep, tt.group)
+		if out != tt.out {
+			t.Errorf("Hex(%q, %d) = %q, want %q", tt.sep, tt.group, out, tt.out)
+		}
+		got, err := ParseHex(out)
+		if err != nil || !bytes.Equal(got, fp) {
+			t.Errorf("ParseHex(%q) = %x, %v, want %x", out, got, err, fp)
+		}
+	}
+	if got := OTR(bytes.Repeat([]byte{0xab}, 20)); got != "ABABABAB ABABABAB ABABABAB ABABABAB ABABABAB" {
+		t.Errorf("OTR = %q", got)
+	}
+}
+
+func TestEqual(t *testing.T) {
+	a := []byte{1, 2, 3}
+	if !Equal(a, []byte{1, 2, 3}) {
+		t.Error("Equal fingerprints reported as different")
+	}
+	if Equal(a, []byte{1, 2, 4}) || Equal(a, []byte{1, 2}) {
+		t.Error("different fingerprints reported as equal")
+	}
+}
+
+func TestRandomArt(t *testing.T) {
+	sha, _ := sshDigests(t)
+	if got := RandomArt(sha, "ED25519 256", "SHA256"); got != sshArt {
+		t.Errorf("RandomArt =\n%s\nwant\n%s", got, sshArt)
+	}
+
+	// Long labels are truncated, and empty ones omitted.
+	art := RandomArt(sha, "ED25519-CERT 256 bits", "")
+	lines := bytes.Split([]byte(art), []byte("\n"))
+	if len(lines) != artHeight+2 {
+		t.Fatalf("RandomArt returned %d lines, want %d", len(lines), artHeight+2)
+	}
+	for i, l := range lines {
+		if len(l) != artWidth+2 {
+			t.Errorf("line %d is %q, want %d characters", i, l, artWidth+2)
+		}
+	}
+	if top := string(lines[0]); top != "+[ED25519-CERT 25]+" {
+		t.Errorf("top border is %q", top)
+	}
+	if bottom := string(lines[len(lines)-1]); bottom != "+-----------------+" {
+		t.Errorf("bottom border is %q", bottom)
+	}
+}
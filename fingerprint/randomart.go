@@ -0,0 +1,105 @@
+// Copyright 2018 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package fingerprint
+
+import (
+	"bytes"
+	"strings"
+)
+
+// The size of the randomart board, as in OpenSSH.
+const (
+	artWidth  = 17
+	artHeight = 9
+)
+
+// artSymbols are the symbols of the squares of the board, by number of
+// visits. The last two mark the start and end of the walk.
+const artSymbols = " .o+=*BOX@%&#/^SE"
+
+// RandomArt returns the fingerprint fp drawn as the "drunken bishop" random
+// art of ssh-keygen -lv, which makes differences between fingerprints easier
+// to spot than in their text forms. title and footer are written, in
+// brackets, in the top and bottom borders, and are usually the key type and
+// size, such as "ED25519 256", and the hash, such as "SHA256". They are
+// omitted if empty, and truncated if too long to fit.
+//
+// The result is made of eleven lines, each 19 characters wide, separated by
+// newlines and with no final newline. It matches the output of OpenSSH for
+// the same fingerprint, title and footer.
+func RandomArt(fp []byte, title, footer string) string {
+	var board [artWidth][artHeight]int
+	const (
+		start = len(artSymbols) - 2
+		end   = len(artSymbols) - 1
+	)
+
+	// The bishop starts at the center, and each pair of bits of fp, from the
+	// lowest pair of the first byte, moves it diagonally. Moves into a wall
+	// slide along it instead.
+	x, y := artWidth/2, artHeight/2
+	for _, b := range fp {
+		for i := 0; i < 4; i++ {
+			if b&1 != 0 {
+				x++
+			} else {
+				x--
+			}
+			if b&2 != 0 {
+				y++
+			} else {
+				y--
+			}
+			x = clamp(x, artWidth-1)
+			y = clamp(y, artHeight-1)
+			if board[x][y] < start-1 {
+				board[x][y]++
+			}
+			b >>= 2
+		}
+	}
+	board[artWidth/2][artHeight/2] = start
+	board[x][y] = end
+
+	var s bytes.Buffer
+	writeArtBorder(&s, title)
+	s.WriteByte('\n')
+	for y := 0; y < artHeight; y++ {
+		s.WriteByte('|')
+		for x := 0; x < artWidth; x++ {
+			s.WriteByte(artSymbols[board[x][y]])
+		}
+		s.WriteString("|\n")
+	}
+	writeArtBorder(&s, footer)
+	return s.String()
+}
+
+func clamp(v, max int) int {
+	if v < 0 {
+		return 0
+	}
+	if v > max {
+		return max
+	}
+	return v
+}
+
+// writeArtBorder writes a horizontal border of the board with label centered
+// in it, rounding towards the left as OpenSSH does.
+func writeArtBorder(s *bytes.Buffer, label string) {
+	if label != "" {
+		if len(label) > artWidth-2 {
+			label = label[:artWidth-2]
+		}
+		label = "[" + label + "]"
+	}
+	left := (artWidth - len(label)) / 2
+	s.WriteByte('+')
+	s.WriteString(strings.Repeat("-", left))
+	s.WriteString(label)
+	s.WriteString(strings.Repeat("-", artWidth-left-len(label)))
+	s.WriteByte('+')
+}
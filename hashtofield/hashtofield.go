@@ -0,0 +1,180 @@
+// Copyright 2018 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+// Package hashtofield implements the expand_message and hash_to_field
+// functions defined in RFC 9380, which hash arbitrary messages to elements of
+// a finite field.
+//
+// They are the first step of hashing to elliptic curves, and are also used by
+// VRFs and OPRFs. The domain separation tag (DST) must be unique to each
+// protocol and ciphersuite, and should include a version number.
+package hashtofield // import "golang.org/x/crypto/hashtofield"
+
+import (
+	"errors"
+	"hash"
+	"math/big"
+
+	"golang.org/x/crypto/sha3"
+)
+
+var (
+	errLength   = errors.New("hashtofield: requested output too long")
+	errEmptyDST = errors.New("hashtofield: empty domain separation tag")
+)
+
+// An Expander implements an expand_message variant, returning length
+// uniformly random bytes derived from msg and dst.
+type Expander func(msg, dst []byte, length int) ([]byte, error)
+
+// XMD returns an Expander implementing expand_message_xmd from RFC 9380,
+// section 5.3.1, with the given hash function, typically sha256.New or
+// sha512.New. The hash function must be a Merkle-Damgård hash such as SHA-2;
+// SHA-3 is to be used with XOF.
+func XMD(newHash func() hash.Hash) Expander {
+	return func(msg, dst []byte, length int) ([]byte, error) {
+		return ExpandMessageXMD(newHash, msg, dst, length)
+	}
+}
+
+// XOF returns an Expander implementing expand_message_xof from RFC 9380,
+// section 5.3.2, with the given extendable-output function and target
+// security level k in bits, typically sha3.NewShake128 with k = 128 or
+// sha3.NewShake256 with k = 256.
+func XOF(newXOF func() sha3.ShakeHash, k int) Expander {
+	return func(msg, dst []byte, length int) ([]byte, error) {
+		return ExpandMessageXOF(newXOF, k, msg, dst, length)
+	}
+}
+
+// ExpandMessageXMD implements expand_message_xmd from RFC 9380, section
+// 5.3.1. DSTs longer than 255 bytes are hashed as specified in section
+// 5.3.3. It returns an error if length is more than 255 times the size of
+// the hash, or more than 65535.
+func ExpandMessageXMD(newHash func() hash.Hash, msg, dst []byte, length int) ([]byte, error) {
+	if len(dst) == 0 {
+		return nil, errEmptyDST
+	}
+	h := newHash()
+	if len(dst) > 255 {
+		h.Write([]byte("H2C-OVERSIZE-DST-"))
+		h.Write(dst)
+		dst = h.Sum(nil)
+		h.Reset()
+	}
+	ell := (length + h.Size() - 1) / h.Size()
+	if length < 0 || ell > 255 || length > 65535 {
+		return nil, errLength
+	}
+	dstPrime := append(dst[:len(dst):len(dst)], byte(len(dst)))
+
+	h.Write(make([]byte, h.BlockSize()))
+	h.Write(msg)
+	h.Write([]byte{byte(length >> 8), byte(length), 0})
+	h.Write(dstPrime)
+	b0 := h.Sum(nil)
+
+	h.Reset()
+	h.Write(b0)
+	h.Write([]byte{1})
+	h.Write(dstPrime)
+	bi := h.Sum(nil)
+
+	out := make([]byte, 0, ell*h.Size())
+	out = append(out, bi...)
+	for i := 2; i <= ell; i++ {
+		for j := range bi {
+			bi[j] ^= b0[j]
+		}
+		h.Reset()
+		h.Write(bi)
+		h.Write([]byte{byte(i)})
+		h.Write(dstPrime)
+		bi = h.Sum(nil)
+		out = append(out, bi...)
+	}
+	return out[:length], nil
+}
+
+// ExpandMessageXOF implements expand_message_xof from RFC 9380, section
+// 5.3.2, with target security level k in bits. DSTs longer than 255 bytes are
+// hashed as specified in section 5.3.3. It returns an error if length is more
+// than 65535.
+func ExpandMessageXOF(newXOF func() sha3.ShakeHash, k int, msg, dst []byte, length int) ([]byte, error) {
+	if len(dst) == 0 {
+		return nil, errEmptyDST
+	}
+	if length < 0 || length > 65535 {
+		return nil, errLength
+	}
+	h := newXOF()
+	if len(dst) > 255 {
+		h.Write([]byte("H2C-OVERSIZE-DST-"))
+		h.Write(dst)
+		dst = make([]byte, (2*k+7)/8)
+		h.Read(dst)
+		h.Reset()
+	}
+
+	h.Write(msg)
+	h.Write([]byte{byte(length >> 8), byte(length)})
+	h.Write(dst)
+	h.Write([]byte{byte(len(dst))})
+	out := make([]byte, length)
+	h.Read(out)
+	return out, nil
+}
+
+// A Field describes the field GF(p^m) targeted by HashToField.
+type Field struct {
+	// P is the characteristic of the field.
+	P *big.Int
+	// M is the extension degree of the field, 1 for prime fields.
+	M int
+	// L is the number of bytes hashed to each coordinate, that is,
+	// ceil((ceil(log2(P)) + k) / 8) for a security level of k bits.
+	L int
+}
+
+var (
+	// Field25519 is GF(2^255 - 19), the field of Curve25519 and
+	// edwards25519, with L = 48 for 128-bit security.
+	Field25519 = &Field{P: fromHex("7fffffffffffffffffffffffffffffffffffffffffffffffffffffffffffffed"), M: 1, L: 48}
+	// FieldBLS12381 is the base field of BLS12-381, with L = 64 for 128-bit
+	// security, used for hashing to G1.
+	FieldBLS12381 = &Field{P: blsP, M: 1, L: 64}
+	// FieldBLS12381P2 is the quadratic extension of the base field of
+	// BLS12-381, with L = 64 for 128-bit security, used for hashing to G2.
+	FieldBLS12381P2 = &Field{P: blsP, M: 2, L: 64}
+)
+
+var blsP = fromHex("1a0111ea397fe69a4b1ba7b6434bacd764774b84f38512bf6730d2a0f6b0f6241eabfffeb153ffffb9feffffffffaaab")
+
+func fromHex(s string) *big.Int {
+	n, ok := new(big.Int).SetString(s, 16)
+	if !ok {
+		panic("hashtofield: internal error: invalid constant")
+	}
+	return n
+}
+
+// HashToField implements hash_to_field from RFC 9380, section 5.2. It hashes
+// msg to count elements of f using expand, and returns each of them as its M
+// coordinates over GF(P), fully reduced.
+func (f *Field) HashToField(expand Expander, msg, dst []byte, count int) ([][]*big.Int, error) {
+	uniform, err := expand(msg, dst, count*f.M*f.L)
+	if err != nil {
+		return nil, err
+	}
+	out := make([][]*big.Int, count)
+	for i := range out {
+		out[i] = make([]*big.Int, f.M)
+		for j := range out[i] {
+			offset := f.L * (j + i*f.M)
+			e := new(big.Int).SetBytes(uniform[offset : offset+f.L])
+			out[i][j] = e.Mod(e, f.P)
+		}
+	}
+	return out, nil
+}
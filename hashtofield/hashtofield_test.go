@@ -0,0 +1,122 @@
+// Copyright 2018 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package hashtofield
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"crypto/sha512"
+	"encoding/hex"
+	"math/big"
+	"testing"
+
+	"golang.org/x/crypto/sha3"
+)
+
+func TestExpandMessage(t *testing.T) {
+	// Test vectors from RFC 9380, appendices K.1, K.3 and K.4.
+	tests := []struct {
+		name   string
+		expand Expander
+		dst    string
+		msg    string
+		out    string
+	}{
+		{"SHA-256", XMD(sha256.New), "QUUX-V01-CS02-with-expander-SHA256-128", "", "68a985b87eb6b46952128911f2a4412bbc302a9d759667f87f7a21d803f07235"},
+		{"SHA-256", XMD(sha256.New), "QUUX-V01-CS02-with-expander-SHA256-128", "abc", "d8ccab23b5985ccea865c6c97b6e5b8350e794e603b4b97902f53a8a0d605615"},
+		{"SHA-512", XMD(sha512.New), "QUUX-V01-CS02-with-expander-SHA512-256", "", "6b9a7312411d92f921c6f68ca0b6380730a1a4d982c507211a90964c394179ba"},
+		{"SHA-512", XMD(sha512.New), "QUUX-V01-CS02-with-expander-SHA512-256", "abc", "0da749f12fbe5483eb066a5f595055679b976e93abe9be6f0f6318bce7aca8dc"},
+		{"SHAKE128", XOF(sha3.NewShake128, 128), "QUUX-V01-CS02-with-expander-SHAKE128", "", "86518c9cd86581486e9485aa74ab35ba150d1c75c88e26b7043e44e2acd735a2"},
+		{"SHAKE128", XOF(sha3.NewShake128, 128), "QUUX-V01-CS02-with-expander-SHAKE128", "abc", "8696af52a4d862417c0763556073f47bc9b9ba43c99b505305cb1ec04a9ab468"},
+	}
+	for _, tt := range tests {
+		want, _ := hex.DecodeString(tt.out)
+		got, err := tt.expand([]byte(tt.msg), []byte(tt.dst), len(want))
+		if err != nil {
+			t.Errorf("%s(%q): %v", tt.name, tt.msg, err)
+		} else if !bytes.Equal(got, want) {
+			t.Errorf("%s(%q) = %x, want %x", tt.name, tt.msg, got, want)
+		}
+	}
+}
+
+func TestOversizeDST(t *testing.T) {
+	dst := bytes.Repeat([]byte("x"), 256)
+	h := sha256.New()
+	h.Write([]byte("H2C-OVERSIZE-DST-"))
+	h.Write(dst)
+	got, err := ExpandMessageXMD(sha256.New, []byte("msg"), dst, 64)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want, _ := ExpandMessageXMD(sha256.New, []byte("msg"), h.Sum(nil), 64)
+	if !bytes.Equal(got, want) {
+		t.Errorf("oversize DST was not hashed")
+	}
+
+	x := sha3.NewShake256()
+	x.Write([]byte("H2C-OVERSIZE-DST-"))
+	x.Write(dst)
+	short := make([]byte, 64)
+	x.Read(short)
+	got, _ = ExpandMessageXOF(sha3.NewShake256, 256, []byte("msg"), dst, 64)
+	want, _ = ExpandMessageXOF(sha3.NewShake256, 256, []byte("msg"), short, 64)
+	if !bytes.Equal(got, want) {
+		t.Errorf("oversize DST was not hashed")
+	}
+}
+
+func TestExpandMessageErrors(t *testing.T) {
+	dst := []byte("DST")
+	if _, err := ExpandMessageXMD(sha256.New, nil, dst, 255*32); err != nil {
+		t.Errorf("maximal length rejected: %v", err)
+	}
+	if _, err := ExpandMessageXMD(sha256.New, nil, dst, 255*32+1); err != errLength {
+		t.Errorf("got %v, want %v", err, errLength)
+	}
+	if _, err := ExpandMessageXOF(sha3.NewShake128, 128, nil, dst, 65536); err != errLength {
+		t.Errorf("got %v, want %v", err, errLength)
+	}
+	if _, err := ExpandMessageXMD(sha256.New, nil, nil, 32); err != errEmptyDST {
+		t.Errorf("got %v, want %v", err, errEmptyDST)
+	}
+	if _, err := ExpandMessageXOF(sha3.NewShake128, 128, nil, nil, 32); err != errEmptyDST {
+		t.Errorf("got %v, want %v", err, errEmptyDST)
+	}
+}
+
+func TestHashToField(t *testing.T) {
+	msg, dst := []byte("msg"), []byte("QUUX-V01-CS02-with-test")
+	for _, f := range []*Field{Field25519, FieldBLS12381, FieldBLS12381P2} {
+		expand := XMD(sha512.New)
+		u, err := f.HashToField(expand, msg, dst, 2)
+		if err != nil {
+			t.Fatal(err)
+		}
+		uniform, _ := expand(msg, dst, 2*f.M*f.L)
+		if len(u) != 2 {
+			t.Fatalf("got %d elements, want 2", len(u))
+		}
+		for i := range u {
+			if len(u[i]) != f.M {
+				t.Fatalf("element %d has %d coordinates, want %d", i, len(u[i]), f.M)
+			}
+			for j, e := range u[i] {
+				want := new(big.Int).SetBytes(uniform[:f.L])
+				want.Mod(want, f.P)
+				uniform = uniform[f.L:]
+				if e.Cmp(want) != 0 {
+					t.Errorf("coordinate %d of element %d: got %v, want %v", j, i, e, want)
+				}
+			}
+		}
+	}
+	if f := Field25519; f.P.BitLen() != 255 || !f.P.ProbablyPrime(20) {
+		t.Errorf("bad 25519 field characteristic")
+	}
+	if f := FieldBLS12381; f.P.BitLen() != 381 || !f.P.ProbablyPrime(20) {
+		t.Errorf("bad BLS12-381 field characteristic")
+	}
+}
@@ -61,15 +61,36 @@ func (f *hkdf) Read(p []byte) (int, error) {
 	return need, nil
 }
 
-// New returns a new HKDF using the given hash, the secret keying material to expand
-// and optional salt and info fields.
-func New(hash func() hash.Hash, secret, salt, info []byte) io.Reader {
+// Extract generates a pseudorandom key for use with Expand from an input
+// secret and an optional independent salt, as HKDF-Extract does.
+//
+// Only use this function if the extracted key is reused with several calls to
+// Expand with different info. Most uses, including deriving several keys,
+// should use New instead.
+func Extract(hash func() hash.Hash, secret, salt []byte) []byte {
 	if salt == nil {
 		salt = make([]byte, hash().Size())
 	}
 	extractor := hmac.New(hash, salt)
 	extractor.Write(secret)
-	prk := extractor.Sum(nil)
+	return extractor.Sum(nil)
+}
 
-	return &hkdf{hmac.New(hash, prk), extractor.Size(), info, 1, nil, nil}
+// Expand returns a Reader, from which keys can be read, using the given
+// pseudorandom key and optional info, as HKDF-Expand does, skipping the
+// extraction step.
+//
+// pseudorandomKey should have been generated by Extract, or be a uniformly
+// random or pseudorandom cryptographically strong key. See RFC 5869, Section
+// 3.3. Most uses should use New instead.
+func Expand(hash func() hash.Hash, pseudorandomKey, info []byte) io.Reader {
+	expander := hmac.New(hash, pseudorandomKey)
+	return &hkdf{expander, expander.Size(), info, 1, nil, nil}
+}
+
+// New returns a new HKDF using the given hash, the secret keying material to expand
+// and optional salt and info fields.
+func New(hash func() hash.Hash, secret, salt, info []byte) io.Reader {
+	prk := Extract(hash, secret, salt)
+	return Expand(hash, prk, info)
 }
@@ -247,6 +247,31 @@ func TestHKDF(t *testing.T) {
 	}
 }
 
+func TestHKDFExtractExpand(t *testing.T) {
+	for i, tt := range hkdfTests {
+		prk := Extract(tt.hash, tt.master, tt.salt)
+		out := make([]byte, len(tt.out))
+		if _, err := io.ReadFull(Expand(tt.hash, prk, tt.info), out); err != nil {
+			t.Errorf("test %d: %v", i, err)
+		}
+		if !bytes.Equal(out, tt.out) {
+			t.Errorf("test %d: incorrect output: have %v, need %v.", i, out, tt.out)
+		}
+	}
+
+	// The PRK of test case 1 of RFC 5869.
+	prk := Extract(sha256.New, bytes.Repeat([]byte{0x0b}, 22), []byte{0, 1, 2, 3, 4, 5, 6, 7, 8, 9, 10, 11, 12})
+	want := []byte{
+		0x07, 0x77, 0x09, 0x36, 0x2c, 0x2e, 0x32, 0xdf,
+		0x0d, 0xdc, 0x3f, 0x0d, 0xc4, 0x7b, 0xba, 0x63,
+		0x90, 0xb6, 0xc7, 0x3b, 0xb5, 0x0f, 0x9c, 0x31,
+		0x22, 0xec, 0x84, 0x4a, 0xd7, 0xc2, 0xb3, 0xe5,
+	}
+	if !bytes.Equal(prk, want) {
+		t.Errorf("Extract = %x, want %x", prk, want)
+	}
+}
+
 func TestHKDFMultiRead(t *testing.T) {
 	for i, tt := range hkdfTests {
 		hkdf := New(tt.hash, tt.master, tt.salt, tt.info)
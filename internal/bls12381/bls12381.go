@@ -0,0 +1,278 @@
+// Copyright 2018 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+// Package bls12381 implements the BLS12-381 pairing-friendly curve, as far as
+// needed to verify BLS signatures.
+//
+// The implementation follows the structure of golang.org/x/crypto/bn256 and,
+// like it, uses math/big throughout. It is therefore slow and not constant
+// time, and must only be used with public inputs. Points are serialized in the
+// compressed format of https://github.com/zkcrypto/pairing.
+package bls12381
+
+import (
+	"math/big"
+)
+
+const (
+	// G1Size is the size, in bytes, of a compressed G₁ element.
+	G1Size = 48
+	// G2Size is the size, in bytes, of a compressed G₂ element.
+	G2Size = 96
+)
+
+const (
+	flagCompressed = 0x80
+	flagInfinity   = 0x40
+	flagSign       = 0x20
+	flagMask       = flagCompressed | flagInfinity | flagSign
+)
+
+// G1 is an abstract cyclic group. The zero value is suitable for use as the
+// output of an operation, but cannot be used as an input.
+type G1 struct {
+	p *curvePoint
+}
+
+func (e *G1) String() string {
+	return "bls12381.G1" + newCurvePoint().Set(e.p).String()
+}
+
+// ScalarBaseMult sets e to g*k where g is the generator of the group and
+// then returns e.
+func (e *G1) ScalarBaseMult(k *big.Int) *G1 {
+	if e.p == nil {
+		e.p = newCurvePoint()
+	}
+	e.p.Mul(curveGen, k)
+	return e
+}
+
+// ScalarMult sets e to a*k and then returns e.
+func (e *G1) ScalarMult(a *G1, k *big.Int) *G1 {
+	if e.p == nil {
+		e.p = newCurvePoint()
+	}
+	e.p.Mul(a.p, k)
+	return e
+}
+
+// Add sets e to a+b and then returns e.
+func (e *G1) Add(a, b *G1) *G1 {
+	if e.p == nil {
+		e.p = newCurvePoint()
+	}
+	e.p.Add(a.p, b.p)
+	return e
+}
+
+// Neg sets e to -a and then returns e.
+func (e *G1) Neg(a *G1) *G1 {
+	if e.p == nil {
+		e.p = newCurvePoint()
+	}
+	e.p.Negative(a.p)
+	return e
+}
+
+// Marshal converts e to its compressed byte representation.
+func (e *G1) Marshal() []byte {
+	out := make([]byte, G1Size)
+	if e.p.IsInfinity() {
+		out[0] = flagCompressed | flagInfinity
+		return out
+	}
+
+	a := newCurvePoint().Set(e.p).MakeAffine()
+	fillBytes(a.x, out)
+	out[0] |= flagCompressed
+	if a.y.Cmp(pMinus1Over2) > 0 {
+		out[0] |= flagSign
+	}
+	return out
+}
+
+// Unmarshal sets e to the result of converting the output of Marshal back into
+// a group element and then returns e. It checks that the encoding is
+// canonical, that the point is on the curve and that it is in G₁.
+func (e *G1) Unmarshal(m []byte) (*G1, bool) {
+	if len(m) != G1Size || m[0]&flagCompressed == 0 {
+		return nil, false
+	}
+	if e.p == nil {
+		e.p = newCurvePoint()
+	}
+
+	flags := m[0] & flagMask
+	buf := append([]byte{m[0] &^ flagMask}, m[1:]...)
+	x := new(big.Int).SetBytes(buf)
+
+	if flags&flagInfinity != 0 {
+		if flags&flagSign != 0 || x.Sign() != 0 {
+			return nil, false
+		}
+		e.p.SetInfinity()
+		return e, true
+	}
+	if x.Cmp(p) >= 0 {
+		return nil, false
+	}
+
+	y := new(big.Int).Mul(x, x)
+	y.Mul(y, x)
+	y.Add(y, curveB)
+	y.Mod(y, p)
+	if y.ModSqrt(y, p) == nil {
+		return nil, false
+	}
+	if (y.Cmp(pMinus1Over2) > 0) != (flags&flagSign != 0) {
+		y.Sub(p, y)
+	}
+
+	e.p.x.Set(x)
+	e.p.y.Set(y)
+	e.p.z.SetInt64(1)
+	if !e.p.IsInSubgroup() {
+		return nil, false
+	}
+	return e, true
+}
+
+// fillBytes sets buf to the big-endian encoding of n, which must fit.
+func fillBytes(n *big.Int, buf []byte) {
+	b := n.Bytes()
+	copy(buf[len(buf)-len(b):], b)
+}
+
+// G2 is an abstract cyclic group. The zero value is suitable for use as the
+// output of an operation, but cannot be used as an input.
+type G2 struct {
+	p *twistPoint
+}
+
+func (e *G2) String() string {
+	return "bls12381.G2" + newTwistPoint().Set(e.p).String()
+}
+
+// ScalarBaseMult sets e to g*k where g is the generator of the group and
+// then returns e.
+func (e *G2) ScalarBaseMult(k *big.Int) *G2 {
+	if e.p == nil {
+		e.p = newTwistPoint()
+	}
+	e.p.Mul(twistGen, k)
+	return e
+}
+
+// ScalarMult sets e to a*k and then returns e.
+func (e *G2) ScalarMult(a *G2, k *big.Int) *G2 {
+	if e.p == nil {
+		e.p = newTwistPoint()
+	}
+	e.p.Mul(a.p, k)
+	return e
+}
+
+// Add sets e to a+b and then returns e.
+func (e *G2) Add(a, b *G2) *G2 {
+	if e.p == nil {
+		e.p = newTwistPoint()
+	}
+	e.p.Add(a.p, b.p)
+	return e
+}
+
+// Neg sets e to -a and then returns e.
+func (e *G2) Neg(a *G2) *G2 {
+	if e.p == nil {
+		e.p = newTwistPoint()
+	}
+	e.p.Negative(a.p)
+	return e
+}
+
+// Marshal converts e to its compressed byte representation. The i coefficient
+// of x is encoded first.
+func (e *G2) Marshal() []byte {
+	out := make([]byte, G2Size)
+	if e.p.IsInfinity() {
+		out[0] = flagCompressed | flagInfinity
+		return out
+	}
+
+	a := newTwistPoint().Set(e.p).MakeAffine()
+	fillBytes(a.x.x, out[:G1Size])
+	fillBytes(a.x.y, out[G1Size:])
+	out[0] |= flagCompressed
+	if a.y.IsLexicographicallyLargest() {
+		out[0] |= flagSign
+	}
+	return out
+}
+
+// Unmarshal sets e to the result of converting the output of Marshal back into
+// a group element and then returns e. It checks that the encoding is
+// canonical, that the point is on the curve and that it is in G₂.
+func (e *G2) Unmarshal(m []byte) (*G2, bool) {
+	if len(m) != G2Size || m[0]&flagCompressed == 0 {
+		return nil, false
+	}
+	if e.p == nil {
+		e.p = newTwistPoint()
+	}
+
+	flags := m[0] & flagMask
+	buf := append([]byte{m[0] &^ flagMask}, m[1:G1Size]...)
+	x := &gfP2{new(big.Int).SetBytes(buf), new(big.Int).SetBytes(m[G1Size:])}
+
+	if flags&flagInfinity != 0 {
+		if flags&flagSign != 0 || !x.IsZero() {
+			return nil, false
+		}
+		e.p.SetInfinity()
+		return e, true
+	}
+	if x.x.Cmp(p) >= 0 || x.y.Cmp(p) >= 0 {
+		return nil, false
+	}
+
+	y := newGFp2().Square(x)
+	y.Mul(y, x)
+	y.Add(y, twistB)
+	if !y.Sqrt(y) {
+		return nil, false
+	}
+	if y.IsLexicographicallyLargest() != (flags&flagSign != 0) {
+		y.Negative(y)
+	}
+
+	e.p.x.Set(x)
+	e.p.y.Set(y)
+	e.p.z.SetOne()
+	if !e.p.IsInSubgroup() {
+		return nil, false
+	}
+	return e, true
+}
+
+// HashToG2 hashes msg to G₂ using the BLS12381G2_XMD:SHA-256_SSWU_RO_ suite
+// of RFC 9380 with the domain separation tag dst.
+func HashToG2(msg, dst []byte) *G2 {
+	return &G2{hashToTwist(msg, dst)}
+}
+
+// PairingCheck reports whether the product of e(a[i], b[i]) is the identity in
+// GT. It panics if a and b have different lengths.
+func PairingCheck(a []*G1, b []*G2) bool {
+	if len(a) != len(b) {
+		panic("bls12381: mismatched number of G1 and G2 elements")
+	}
+	ps := make([]*curvePoint, len(a))
+	qs := make([]*twistPoint, len(b))
+	for i := range a {
+		ps[i] = a[i].p
+		qs[i] = b[i].p
+	}
+	return optimalAte(ps, qs).IsOne()
+}
@@ -0,0 +1,180 @@
+// Copyright 2018 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package bls12381
+
+import (
+	"bytes"
+	"encoding/hex"
+	"math/big"
+	"testing"
+)
+
+func mustDecodeHex(t *testing.T, s string) []byte {
+	b, err := hex.DecodeString(s)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return b
+}
+
+func TestGenerators(t *testing.T) {
+	if !curveGen.IsOnCurve() || !curveGen.IsInSubgroup() {
+		t.Error("G₁ generator is not in G₁")
+	}
+	if !twistGen.IsOnCurve() || !twistGen.IsInSubgroup() {
+		t.Error("G₂ generator is not in G₂")
+	}
+
+	g1 := new(G1).ScalarBaseMult(big.NewInt(1)).Marshal()
+	if want := mustDecodeHex(t, "97f1d3a73197d7942695638c4fa9ac0fc3688c4f9774b905a14e3a3f171bac586c55e83ff97a1aeffb3af00adb22c6bb"); !bytes.Equal(g1, want) {
+		t.Errorf("G₁ generator encodes to %x, want %x", g1, want)
+	}
+	g2 := new(G2).ScalarBaseMult(big.NewInt(1)).Marshal()
+	if want := mustDecodeHex(t, "93e02b6052719f607dacd3a088274f65596bd0d09920b61ab5da61bbdc7f5049334cf11213945d57e5ac7d055d042b7e024aa2b2f08f0a91260805272dc51051c6e47ad4fa403b02b4510b647ae3d1770bac0326a805bbefd48056c8c121bdb8"); !bytes.Equal(g2, want) {
+		t.Errorf("G₂ generator encodes to %x, want %x", g2, want)
+	}
+}
+
+func TestFieldInverses(t *testing.T) {
+	a := &gfP2{big.NewInt(12345), big.NewInt(67890)}
+	if b := newGFp2().Invert(a); !b.Mul(b, a).IsOne() {
+		t.Error("gfP2 inversion failed")
+	}
+
+	x := newGFp6()
+	x.x.Set(a)
+	x.y.Set(&gfP2{big.NewInt(3), big.NewInt(5)})
+	x.z.Set(&gfP2{big.NewInt(7), big.NewInt(11)})
+	if y := newGFp6().Invert(x); !y.Mul(y, x).IsOne() {
+		t.Error("gfP6 inversion failed")
+	}
+
+	f := newGFp12()
+	f.x.Set(x)
+	f.y.MulTau(x)
+	f.y.z.x.SetInt64(13)
+	if g := newGFp12().Invert(f); !g.Mul(g, f).IsOne() {
+		t.Error("gfP12 inversion failed")
+	}
+}
+
+func TestMarshalRoundTrip(t *testing.T) {
+	for _, k := range []int64{0, 1, 2, 0x1234567} {
+		a := new(G1).ScalarBaseMult(big.NewInt(k))
+		a2, ok := new(G1).Unmarshal(a.Marshal())
+		if !ok {
+			t.Fatalf("G₁ %d·g failed to unmarshal", k)
+		}
+		if !bytes.Equal(a.Marshal(), a2.Marshal()) {
+			t.Errorf("G₁ %d·g does not round-trip", k)
+		}
+
+		b := new(G2).ScalarBaseMult(big.NewInt(k))
+		b2, ok := new(G2).Unmarshal(b.Marshal())
+		if !ok {
+			t.Fatalf("G₂ %d·g failed to unmarshal", k)
+		}
+		if !bytes.Equal(b.Marshal(), b2.Marshal()) {
+			t.Errorf("G₂ %d·g does not round-trip", k)
+		}
+	}
+}
+
+func TestUnmarshalRejects(t *testing.T) {
+	g1 := new(G1).ScalarBaseMult(big.NewInt(1)).Marshal()
+
+	uncompressed := append([]byte{}, g1...)
+	uncompressed[0] &^= flagCompressed
+	if _, ok := new(G1).Unmarshal(uncompressed); ok {
+		t.Error("accepted encoding without the compression flag")
+	}
+
+	badInfinity := make([]byte, G1Size)
+	badInfinity[0] = flagCompressed | flagInfinity
+	badInfinity[G1Size-1] = 1
+	if _, ok := new(G1).Unmarshal(badInfinity); ok {
+		t.Error("accepted non-canonical point at infinity")
+	}
+
+	// x=0 is on the curve y²=x³+4 but is not in G₁.
+	notInSubgroup := make([]byte, G1Size)
+	notInSubgroup[0] = flagCompressed
+	if _, ok := new(G1).Unmarshal(notInSubgroup); ok {
+		t.Error("accepted point outside of G₁")
+	}
+
+	if _, ok := new(G2).Unmarshal(g1); ok {
+		t.Error("accepted G₁ encoding as G₂")
+	}
+}
+
+func TestBilinearity(t *testing.T) {
+	a, b := big.NewInt(0x1234), big.NewInt(0x5678)
+	ab := new(big.Int).Mul(a, b)
+
+	pa := new(G1).ScalarBaseMult(a)
+	qb := new(G2).ScalarBaseMult(b)
+	pab := new(G1).ScalarBaseMult(ab)
+	q := new(G2).ScalarBaseMult(big.NewInt(1))
+
+	// e(a·g₁, b·g₂) · e(-ab·g₁, g₂) = 1
+	if !PairingCheck([]*G1{pa, new(G1).Neg(pab)}, []*G2{qb, q}) {
+		t.Error("pairing is not bilinear")
+	}
+	if PairingCheck([]*G1{pa, pab}, []*G2{qb, q}) {
+		t.Error("pairing check accepted an invalid equation")
+	}
+	if PairingCheck([]*G1{pa}, []*G2{qb}) {
+		t.Error("pairing is degenerate")
+	}
+}
+
+func TestExpandMessageXMD(t *testing.T) {
+	// Test vectors from RFC 9380, appendix K.1.
+	dst := []byte("QUUX-V01-CS02-with-expander-SHA256-128")
+	tests := []struct {
+		msg    string
+		length int
+		out    string
+	}{
+		{"", 0x20, "68a985b87eb6b46952128911f2a4412bbc302a9d759667f87f7a21d803f07235"},
+		{"abc", 0x20, "d8ccab23b5985ccea865c6c97b6e5b8350e794e603b4b97902f53a8a0d605615"},
+	}
+	for _, tt := range tests {
+		got := expandMessageXMD([]byte(tt.msg), dst, tt.length)
+		if want := mustDecodeHex(t, tt.out); !bytes.Equal(got, want) {
+			t.Errorf("expand_message_xmd(%q) = %x, want %x", tt.msg, got, want)
+		}
+	}
+}
+
+func TestHashToG2(t *testing.T) {
+	// Test vectors from RFC 9380, appendix J.10.1, in compressed form.
+	dst := []byte("QUUX-V01-CS02-with-BLS12381G2_XMD:SHA-256_SSWU_RO_")
+	tests := []struct {
+		msg string
+		out string
+	}{
+		{"", "a5cb8437535e20ecffaef7752baddf98034139c38452458baeefab379ba13dff5bf5dd71b72418717047f5b0f37da03d0141ebfbdca40eb85b87142e130ab689c673cf60f1a3e98d69335266f30d9b8d4ac44c1038e9dcdd5393faf5c41fb78a"},
+		{"abc", "939cddbccdc5e91b9623efd38c49f81a6f83f175e80b06fc374de9eb4b41dfe4ca3a230ed250fbe3a2acf73a41177fd802c2d18e033b960562aae3cab37a27ce00d80ccd5ba4b7fe0e7a210245129dbec7780ccc7954725f4168aff2787776e6"},
+	}
+	for _, tt := range tests {
+		got := HashToG2([]byte(tt.msg), dst).Marshal()
+		if want := mustDecodeHex(t, tt.out); !bytes.Equal(got, want) {
+			t.Errorf("HashToG2(%q) = %x, want %x", tt.msg, got, want)
+		}
+	}
+}
+
+func BenchmarkPairingCheck(b *testing.B) {
+	p := new(G1).ScalarBaseMult(big.NewInt(1))
+	q := new(G2).ScalarBaseMult(big.NewInt(1))
+	ps := []*G1{p, new(G1).Neg(p)}
+	qs := []*G2{q, q}
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		PairingCheck(ps, qs)
+	}
+}
@@ -0,0 +1,85 @@
+// Copyright 2018 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package bls12381
+
+import (
+	"math/big"
+)
+
+func bigFromBase16(s string) *big.Int {
+	n, ok := new(big.Int).SetString(s, 16)
+	if !ok {
+		panic("bls12381: internal error: invalid encoding")
+	}
+	return n
+}
+
+// u is the BLS parameter which determines the curve. It is negative, so only
+// its absolute value is stored here.
+var u = bigFromBase16("d201000000010000")
+
+// p is a prime over which we form a basic field: 36u⁴+36u³+24u²+6u+1.
+var p = bigFromBase16("1a0111ea397fe69a4b1ba7b6434bacd764774b84f38512bf6730d2a0f6b0f6241eabfffeb153ffffb9feffffffffaaab")
+
+// Order is the number of elements in both G₁ and G₂: u⁴-u²+1.
+var Order = bigFromBase16("73eda753299d7d483339d80809a1d80553bda402fffe5bfeffffffff00000001")
+
+// pMinus1Over2 is (p-1)/2, the largest value considered non-negative for the
+// purposes of point compression.
+var pMinus1Over2 = new(big.Int).Rsh(p, 1)
+
+// finalExponent is (p⁶+1)/Order, the part of the final exponentiation that
+// remains after raising to p⁶-1.
+var finalExponent = func() *big.Int {
+	e := new(big.Int).Exp(p, big.NewInt(6), nil)
+	e.Add(e, big.NewInt(1))
+	return e.Div(e, Order)
+}()
+
+// g2CofactorEff is the effective cofactor h_eff from RFC 9380, section 8.8.2,
+// used to map points on the twist into G₂.
+var g2CofactorEff = bigFromBase16("bc69f08f2ee75b3584c6a0ea91b352888e2a8e9145ad7689986ff031508ffe1329c2f178731db956d82bf015d1212b02ec0ec69d7477c1ae954cbc06689f6a359894c0adebbf6b4e8020005aaa95551")
+
+// sswuA, sswuB and sswuZ are the parameters of the curve E₂′, which is
+// 3-isogenous to the twist, and of the simplified SWU map onto it. See RFC
+// 9380, section 8.8.2.
+var (
+	sswuA = &gfP2{big.NewInt(240), big.NewInt(0)}
+	sswuB = &gfP2{big.NewInt(1012), big.NewInt(1012)}
+	sswuZ = &gfP2{new(big.Int).Sub(p, big.NewInt(1)), new(big.Int).Sub(p, big.NewInt(2))}
+)
+
+func newGFp2FromBase16(x, y string) *gfP2 {
+	return &gfP2{bigFromBase16(x), bigFromBase16(y)}
+}
+
+// isoXNum, isoXDen, isoYNum and isoYDen are the coefficients, lowest degree
+// first, of the rational maps making up the 3-isogeny from E₂′ to the twist.
+// See RFC 9380, appendix E.3.
+var (
+	isoXNum = []*gfP2{
+		newGFp2FromBase16("5c759507e8e333ebb5b7a9a47d7ed8532c52d39fd3a042a88b58423c50ae15d5c2638e343d9c71c6238aaaaaaaa97d6", "5c759507e8e333ebb5b7a9a47d7ed8532c52d39fd3a042a88b58423c50ae15d5c2638e343d9c71c6238aaaaaaaa97d6"),
+		newGFp2FromBase16("11560bf17baa99bc32126fced787c88f984f87adf7ae0c7f9a208c6b4f20a4181472aaa9cb8d555526a9ffffffffc71a", "0"),
+		newGFp2FromBase16("8ab05f8bdd54cde190937e76bc3e447cc27c3d6fbd7063fcd104635a790520c0a395554e5c6aaaa9354ffffffffe38d", "11560bf17baa99bc32126fced787c88f984f87adf7ae0c7f9a208c6b4f20a4181472aaa9cb8d555526a9ffffffffc71e"),
+		newGFp2FromBase16("0", "171d6541fa38ccfaed6dea691f5fb614cb14b4e7f4e810aa22d6108f142b85757098e38d0f671c7188e2aaaaaaaa5ed1"),
+	}
+	isoXDen = []*gfP2{
+		newGFp2FromBase16("1a0111ea397fe69a4b1ba7b6434bacd764774b84f38512bf6730d2a0f6b0f6241eabfffeb153ffffb9feffffffffaa63", "0"),
+		newGFp2FromBase16("1a0111ea397fe69a4b1ba7b6434bacd764774b84f38512bf6730d2a0f6b0f6241eabfffeb153ffffb9feffffffffaa9f", "c"),
+		newGFp2FromBase16("0", "1"),
+	}
+	isoYNum = []*gfP2{
+		newGFp2FromBase16("1530477c7ab4113b59a4c18b076d11930f7da5d4a07f649bf54439d87d27e500fc8c25ebf8c92f6812cfc71c71c6d706", "1530477c7ab4113b59a4c18b076d11930f7da5d4a07f649bf54439d87d27e500fc8c25ebf8c92f6812cfc71c71c6d706"),
+		newGFp2FromBase16("5c759507e8e333ebb5b7a9a47d7ed8532c52d39fd3a042a88b58423c50ae15d5c2638e343d9c71c6238aaaaaaaa97be", "0"),
+		newGFp2FromBase16("8ab05f8bdd54cde190937e76bc3e447cc27c3d6fbd7063fcd104635a790520c0a395554e5c6aaaa9354ffffffffe38f", "11560bf17baa99bc32126fced787c88f984f87adf7ae0c7f9a208c6b4f20a4181472aaa9cb8d555526a9ffffffffc71c"),
+		newGFp2FromBase16("0", "124c9ad43b6cf79bfbf7043de3811ad0761b0f37a1e26286b0e977c69aa274524e79097a56dc4bd9e1b371c71c718b10"),
+	}
+	isoYDen = []*gfP2{
+		newGFp2FromBase16("1a0111ea397fe69a4b1ba7b6434bacd764774b84f38512bf6730d2a0f6b0f6241eabfffeb153ffffb9feffffffffa8fb", "1a0111ea397fe69a4b1ba7b6434bacd764774b84f38512bf6730d2a0f6b0f6241eabfffeb153ffffb9feffffffffa8fb"),
+		newGFp2FromBase16("1a0111ea397fe69a4b1ba7b6434bacd764774b84f38512bf6730d2a0f6b0f6241eabfffeb153ffffb9feffffffffa9d3", "0"),
+		newGFp2FromBase16("1a0111ea397fe69a4b1ba7b6434bacd764774b84f38512bf6730d2a0f6b0f6241eabfffeb153ffffb9feffffffffaa99", "12"),
+		newGFp2FromBase16("0", "1"),
+	}
+)
@@ -0,0 +1,215 @@
+// Copyright 2018 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package bls12381
+
+import (
+	"math/big"
+)
+
+var bigOne = big.NewInt(1)
+
+// curvePoint implements the elliptic curve y²=x³+4. Points are kept in
+// Jacobian form. G₁ is the prime-order subgroup of this curve on GF(p).
+type curvePoint struct {
+	x, y, z *big.Int
+}
+
+var curveB = big.NewInt(4)
+
+// curveGen is the generator of G₁.
+var curveGen = &curvePoint{
+	bigFromBase16("17f1d3a73197d7942695638c4fa9ac0fc3688c4f9774b905a14e3a3f171bac586c55e83ff97a1aeffb3af00adb22c6bb"),
+	bigFromBase16("08b3f481e3aaa0f1a09e30ed741d8ae4fcf5e095d5d00af600db18cb2c04b3edd03cc744a2888ae40caa232946c5e7e1"),
+	big.NewInt(1),
+}
+
+func newCurvePoint() *curvePoint {
+	return &curvePoint{new(big.Int), new(big.Int), new(big.Int)}
+}
+
+func (c *curvePoint) String() string {
+	c.MakeAffine()
+	return "(" + c.x.String() + ", " + c.y.String() + ")"
+}
+
+func (c *curvePoint) Set(a *curvePoint) *curvePoint {
+	c.x.Set(a.x)
+	c.y.Set(a.y)
+	c.z.Set(a.z)
+	return c
+}
+
+// IsOnCurve returns true iff c is on the curve where c must be in affine form.
+func (c *curvePoint) IsOnCurve() bool {
+	yy := new(big.Int).Mul(c.y, c.y)
+	xxx := new(big.Int).Mul(c.x, c.x)
+	xxx.Mul(xxx, c.x)
+	yy.Sub(yy, xxx)
+	yy.Sub(yy, curveB)
+	yy.Mod(yy, p)
+	return yy.Sign() == 0
+}
+
+func (c *curvePoint) SetInfinity() *curvePoint {
+	c.x.SetInt64(0)
+	c.y.SetInt64(1)
+	c.z.SetInt64(0)
+	return c
+}
+
+func (c *curvePoint) IsInfinity() bool {
+	return c.z.Sign() == 0
+}
+
+func (c *curvePoint) Add(a, b *curvePoint) *curvePoint {
+	if a.IsInfinity() {
+		return c.Set(b)
+	}
+	if b.IsInfinity() {
+		return c.Set(a)
+	}
+
+	// See http://hyperelliptic.org/EFD/g1p/auto-code/shortw/jacobian-0/addition/add-2007-bl.op3
+	z1z1 := new(big.Int).Mul(a.z, a.z)
+	z1z1.Mod(z1z1, p)
+	z2z2 := new(big.Int).Mul(b.z, b.z)
+	z2z2.Mod(z2z2, p)
+	u1 := new(big.Int).Mul(a.x, z2z2)
+	u1.Mod(u1, p)
+	u2 := new(big.Int).Mul(b.x, z1z1)
+	u2.Mod(u2, p)
+
+	s1 := new(big.Int).Mul(a.y, b.z)
+	s1.Mul(s1, z2z2)
+	s1.Mod(s1, p)
+	s2 := new(big.Int).Mul(b.y, a.z)
+	s2.Mul(s2, z1z1)
+	s2.Mod(s2, p)
+
+	h := new(big.Int).Sub(u2, u1)
+	h.Mod(h, p)
+	r := new(big.Int).Sub(s2, s1)
+	r.Mod(r, p)
+	if h.Sign() == 0 {
+		if r.Sign() == 0 {
+			return c.Double(a)
+		}
+		return c.SetInfinity()
+	}
+	r.Lsh(r, 1)
+
+	i := new(big.Int).Lsh(h, 1)
+	i.Mul(i, i)
+	i.Mod(i, p)
+	j := new(big.Int).Mul(h, i)
+	v := new(big.Int).Mul(u1, i)
+
+	x3 := new(big.Int).Mul(r, r)
+	x3.Sub(x3, j)
+	x3.Sub(x3, v)
+	x3.Sub(x3, v)
+	x3.Mod(x3, p)
+
+	y3 := new(big.Int).Sub(v, x3)
+	y3.Mul(y3, r)
+	s1.Mul(s1, j)
+	s1.Lsh(s1, 1)
+	y3.Sub(y3, s1)
+	y3.Mod(y3, p)
+
+	z3 := new(big.Int).Add(a.z, b.z)
+	z3.Mul(z3, z3)
+	z3.Sub(z3, z1z1)
+	z3.Sub(z3, z2z2)
+	z3.Mul(z3, h)
+	z3.Mod(z3, p)
+
+	c.x.Set(x3)
+	c.y.Set(y3)
+	c.z.Set(z3)
+	return c
+}
+
+func (c *curvePoint) Double(a *curvePoint) *curvePoint {
+	// See http://hyperelliptic.org/EFD/g1p/auto-code/shortw/jacobian-0/doubling/dbl-2009-l.op3
+	A := new(big.Int).Mul(a.x, a.x)
+	A.Mod(A, p)
+	B := new(big.Int).Mul(a.y, a.y)
+	B.Mod(B, p)
+	C := new(big.Int).Mul(B, B)
+	C.Mod(C, p)
+
+	t := new(big.Int).Add(a.x, B)
+	t.Mul(t, t)
+	t.Sub(t, A)
+	t.Sub(t, C)
+	d := new(big.Int).Lsh(t, 1)
+	d.Mod(d, p)
+
+	e := new(big.Int).Lsh(A, 1)
+	e.Add(e, A)
+	f := new(big.Int).Mul(e, e)
+
+	x3 := new(big.Int).Lsh(d, 1)
+	x3.Sub(f, x3)
+	x3.Mod(x3, p)
+
+	z3 := new(big.Int).Mul(a.y, a.z)
+	z3.Lsh(z3, 1)
+	z3.Mod(z3, p)
+
+	y3 := new(big.Int).Sub(d, x3)
+	y3.Mul(y3, e)
+	C.Lsh(C, 3)
+	y3.Sub(y3, C)
+	y3.Mod(y3, p)
+
+	c.x.Set(x3)
+	c.y.Set(y3)
+	c.z.Set(z3)
+	return c
+}
+
+func (c *curvePoint) Mul(a *curvePoint, scalar *big.Int) *curvePoint {
+	sum := newCurvePoint().SetInfinity()
+	for i := scalar.BitLen() - 1; i >= 0; i-- {
+		sum.Double(sum)
+		if scalar.Bit(i) != 0 {
+			sum.Add(sum, a)
+		}
+	}
+	return c.Set(sum)
+}
+
+func (c *curvePoint) Negative(a *curvePoint) *curvePoint {
+	c.x.Set(a.x)
+	c.y.Neg(a.y)
+	c.y.Mod(c.y, p)
+	c.z.Set(a.z)
+	return c
+}
+
+// MakeAffine converts c to affine form. The point at infinity is left as is.
+func (c *curvePoint) MakeAffine() *curvePoint {
+	if c.IsInfinity() || c.z.Cmp(bigOne) == 0 {
+		return c
+	}
+
+	zInv := new(big.Int).ModInverse(c.z, p)
+	t := new(big.Int).Mul(zInv, zInv)
+	c.x.Mul(c.x, t)
+	c.x.Mod(c.x, p)
+	t.Mul(t, zInv)
+	c.y.Mul(c.y, t)
+	c.y.Mod(c.y, p)
+	c.z.SetInt64(1)
+	return c
+}
+
+// IsInSubgroup reports whether c is in G₁, i.e. whether Order·c is the point
+// at infinity.
+func (c *curvePoint) IsInSubgroup() bool {
+	return newCurvePoint().Mul(c, Order).IsInfinity()
+}
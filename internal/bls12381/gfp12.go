@@ -0,0 +1,106 @@
+// Copyright 2018 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package bls12381
+
+import (
+	"math/big"
+)
+
+// gfP12 implements the field of size p¹² as a quadratic extension of gfP6
+// where ω²=τ.
+type gfP12 struct {
+	x, y *gfP6 // value is xω + y
+}
+
+func newGFp12() *gfP12 {
+	return &gfP12{newGFp6(), newGFp6()}
+}
+
+func (e *gfP12) String() string {
+	return "(" + e.x.String() + "," + e.y.String() + ")"
+}
+
+func (e *gfP12) Set(a *gfP12) *gfP12 {
+	e.x.Set(a.x)
+	e.y.Set(a.y)
+	return e
+}
+
+func (e *gfP12) SetZero() *gfP12 {
+	e.x.SetZero()
+	e.y.SetZero()
+	return e
+}
+
+func (e *gfP12) SetOne() *gfP12 {
+	e.x.SetZero()
+	e.y.SetOne()
+	return e
+}
+
+func (e *gfP12) IsOne() bool {
+	return e.x.IsZero() && e.y.IsOne()
+}
+
+func (e *gfP12) Equal(a *gfP12) bool {
+	return e.x.Equal(a.x) && e.y.Equal(a.y)
+}
+
+// Conjugate sets e to a^(p⁶), which negates the ω coefficient.
+func (e *gfP12) Conjugate(a *gfP12) *gfP12 {
+	e.x.Negative(a.x)
+	e.y.Set(a.y)
+	return e
+}
+
+func (e *gfP12) Mul(a, b *gfP12) *gfP12 {
+	// (a.x ω + a.y)(b.x ω + b.y) = (a.x b.y + a.y b.x)ω + a.y b.y + τ a.x b.x
+	tx := newGFp6().Mul(a.x, b.y)
+	t := newGFp6().Mul(b.x, a.y)
+	tx.Add(tx, t)
+
+	ty := newGFp6().Mul(a.y, b.y)
+	t.Mul(a.x, b.x)
+	t.MulTau(t)
+	ty.Add(ty, t)
+
+	e.x.Set(tx)
+	e.y.Set(ty)
+	return e
+}
+
+func (e *gfP12) Square(a *gfP12) *gfP12 {
+	return e.Mul(a, a)
+}
+
+// Invert sets e=a⁻¹, computed as (y - xω)/(y² - τx²).
+func (e *gfP12) Invert(a *gfP12) *gfP12 {
+	t1 := newGFp6().Square(a.x)
+	t1.MulTau(t1)
+	t2 := newGFp6().Square(a.y)
+	t2.Sub(t2, t1)
+	t2.Invert(t2)
+
+	e.x.Negative(a.x)
+	e.x.Mul(e.x, t2)
+	e.y.Mul(a.y, t2)
+	return e
+}
+
+func (e *gfP12) Exp(a *gfP12, power *big.Int) *gfP12 {
+	sum := newGFp12().SetOne()
+	t := newGFp12()
+
+	for i := power.BitLen() - 1; i >= 0; i-- {
+		t.Square(sum)
+		if power.Bit(i) != 0 {
+			sum.Mul(t, a)
+		} else {
+			sum.Set(t)
+		}
+	}
+
+	return e.Set(sum)
+}
@@ -0,0 +1,256 @@
+// Copyright 2018 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package bls12381
+
+import (
+	"math/big"
+)
+
+// gfP2 implements a field of size p² as a quadratic extension of the base
+// field where i²=-1. Elements are always kept fully reduced.
+type gfP2 struct {
+	x, y *big.Int // value is xi+y.
+}
+
+func newGFp2() *gfP2 {
+	return &gfP2{new(big.Int), new(big.Int)}
+}
+
+func (e *gfP2) String() string {
+	return "(" + e.x.String() + "," + e.y.String() + ")"
+}
+
+func (e *gfP2) Set(a *gfP2) *gfP2 {
+	e.x.Set(a.x)
+	e.y.Set(a.y)
+	return e
+}
+
+func (e *gfP2) SetZero() *gfP2 {
+	e.x.SetInt64(0)
+	e.y.SetInt64(0)
+	return e
+}
+
+func (e *gfP2) SetOne() *gfP2 {
+	e.x.SetInt64(0)
+	e.y.SetInt64(1)
+	return e
+}
+
+func (e *gfP2) IsZero() bool {
+	return e.x.Sign() == 0 && e.y.Sign() == 0
+}
+
+func (e *gfP2) IsOne() bool {
+	return e.x.Sign() == 0 && e.y.Cmp(bigOne) == 0
+}
+
+func (e *gfP2) Equal(a *gfP2) bool {
+	return e.x.Cmp(a.x) == 0 && e.y.Cmp(a.y) == 0
+}
+
+func (e *gfP2) Conjugate(a *gfP2) *gfP2 {
+	e.y.Set(a.y)
+	e.x.Neg(a.x)
+	e.x.Mod(e.x, p)
+	return e
+}
+
+func (e *gfP2) Negative(a *gfP2) *gfP2 {
+	e.x.Neg(a.x)
+	e.x.Mod(e.x, p)
+	e.y.Neg(a.y)
+	e.y.Mod(e.y, p)
+	return e
+}
+
+func (e *gfP2) Add(a, b *gfP2) *gfP2 {
+	e.x.Add(a.x, b.x)
+	e.x.Mod(e.x, p)
+	e.y.Add(a.y, b.y)
+	e.y.Mod(e.y, p)
+	return e
+}
+
+func (e *gfP2) Sub(a, b *gfP2) *gfP2 {
+	e.x.Sub(a.x, b.x)
+	e.x.Mod(e.x, p)
+	e.y.Sub(a.y, b.y)
+	e.y.Mod(e.y, p)
+	return e
+}
+
+func (e *gfP2) Double(a *gfP2) *gfP2 {
+	return e.Add(a, a)
+}
+
+// Mul sets e=a*b. See "Multiplication and Squaring in Pairing-Friendly
+// Fields", http://eprint.iacr.org/2006/471.pdf.
+func (e *gfP2) Mul(a, b *gfP2) *gfP2 {
+	tx := new(big.Int).Mul(a.x, b.y)
+	t := new(big.Int).Mul(b.x, a.y)
+	tx.Add(tx, t)
+	tx.Mod(tx, p)
+
+	ty := new(big.Int).Mul(a.y, b.y)
+	t.Mul(a.x, b.x)
+	ty.Sub(ty, t)
+	ty.Mod(ty, p)
+
+	e.x.Set(tx)
+	e.y.Set(ty)
+	return e
+}
+
+// MulScalar sets e=a*b where b is an element of GF(p).
+func (e *gfP2) MulScalar(a *gfP2, b *big.Int) *gfP2 {
+	e.x.Mul(a.x, b)
+	e.x.Mod(e.x, p)
+	e.y.Mul(a.y, b)
+	e.y.Mod(e.y, p)
+	return e
+}
+
+// MulXi sets e=ξa where ξ=i+1 and then returns e.
+func (e *gfP2) MulXi(a *gfP2) *gfP2 {
+	// (xi+y)(i+1) = (x+y)i + (y-x)
+	tx := new(big.Int).Add(a.x, a.y)
+	tx.Mod(tx, p)
+	ty := new(big.Int).Sub(a.y, a.x)
+	ty.Mod(ty, p)
+
+	e.x.Set(tx)
+	e.y.Set(ty)
+	return e
+}
+
+func (e *gfP2) Square(a *gfP2) *gfP2 {
+	// Complex squaring algorithm:
+	// (xi+y)² = (x+y)(y-x) + 2*i*x*y
+	t1 := new(big.Int).Sub(a.y, a.x)
+	t2 := new(big.Int).Add(a.x, a.y)
+	ty := new(big.Int).Mul(t1, t2)
+	ty.Mod(ty, p)
+
+	t1.Mul(a.x, a.y)
+	t1.Lsh(t1, 1)
+	t1.Mod(t1, p)
+
+	e.x.Set(t1)
+	e.y.Set(ty)
+	return e
+}
+
+// Invert sets e=a⁻¹. The inverse of zero is zero.
+func (e *gfP2) Invert(a *gfP2) *gfP2 {
+	// See "Implementing cryptographic pairings", M. Scott, section 3.2.
+	// ftp://136.206.11.249/pub/crypto/pairings.pdf
+	t := new(big.Int).Mul(a.y, a.y)
+	t2 := new(big.Int).Mul(a.x, a.x)
+	t.Add(t, t2)
+	t.Mod(t, p)
+
+	inv := new(big.Int).ModInverse(t, p)
+	if inv == nil {
+		return e.SetZero()
+	}
+
+	e.x.Neg(a.x)
+	e.x.Mul(e.x, inv)
+	e.x.Mod(e.x, p)
+
+	e.y.Mul(a.y, inv)
+	e.y.Mod(e.y, p)
+	return e
+}
+
+func (e *gfP2) Exp(a *gfP2, power *big.Int) *gfP2 {
+	sum := newGFp2().SetOne()
+	t := newGFp2()
+
+	for i := power.BitLen() - 1; i >= 0; i-- {
+		t.Square(sum)
+		if power.Bit(i) != 0 {
+			sum.Mul(t, a)
+		} else {
+			sum.Set(t)
+		}
+	}
+
+	return e.Set(sum)
+}
+
+// norm returns x²+y², which is a square in GF(p) exactly when e is a square
+// in GF(p²).
+func (e *gfP2) norm() *big.Int {
+	t := new(big.Int).Mul(e.x, e.x)
+	t2 := new(big.Int).Mul(e.y, e.y)
+	t.Add(t, t2)
+	return t.Mod(t, p)
+}
+
+// IsSquare reports whether e has a square root in GF(p²).
+func (e *gfP2) IsSquare() bool {
+	return big.Jacobi(e.norm(), p) >= 0
+}
+
+// Sqrt sets e to a square root of a and returns true, or returns false and
+// leaves e unchanged if a is not a square. See "Square root computation over
+// even extension fields", algorithm 9, https://eprint.iacr.org/2012/685.pdf.
+func (e *gfP2) Sqrt(a *gfP2) bool {
+	exp := new(big.Int).Sub(p, big.NewInt(3))
+	exp.Rsh(exp, 2)
+	a1 := newGFp2().Exp(a, exp)
+
+	alpha := newGFp2().Square(a1)
+	alpha.Mul(alpha, a)
+
+	x0 := newGFp2().Mul(a1, a)
+
+	minusOne := newGFp2().SetOne()
+	minusOne.Negative(minusOne)
+
+	root := newGFp2()
+	if alpha.Equal(minusOne) {
+		// x = i*x0
+		root.x.Set(x0.y)
+		root.y.Neg(x0.x)
+		root.y.Mod(root.y, p)
+	} else {
+		exp.Rsh(p, 1)
+		b := newGFp2().SetOne()
+		b.Add(b, alpha)
+		b.Exp(b, exp)
+		root.Mul(b, x0)
+	}
+
+	if check := newGFp2().Square(root); !check.Equal(a) {
+		return false
+	}
+	e.Set(root)
+	return true
+}
+
+// Sgn0 returns the "sign" of e as defined in RFC 9380, section 4.1.
+func (e *gfP2) Sgn0() uint {
+	sign0 := e.y.Bit(0)
+	zero0 := e.y.Sign() == 0
+	sign1 := e.x.Bit(0)
+	if sign0 == 1 || (zero0 && sign1 == 1) {
+		return 1
+	}
+	return 0
+}
+
+// IsLexicographicallyLargest reports whether e is larger than -e, comparing
+// the i coefficient first. This is the sign convention of the point
+// compression format.
+func (e *gfP2) IsLexicographicallyLargest() bool {
+	if e.x.Sign() != 0 {
+		return e.x.Cmp(pMinus1Over2) > 0
+	}
+	return e.y.Cmp(pMinus1Over2) > 0
+}
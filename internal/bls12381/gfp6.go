@@ -0,0 +1,165 @@
+// Copyright 2018 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package bls12381
+
+// gfP6 implements the field of size p⁶ as a cubic extension of gfP2 where
+// τ³=ξ and ξ=i+1.
+type gfP6 struct {
+	x, y, z *gfP2 // value is xτ² + yτ + z
+}
+
+func newGFp6() *gfP6 {
+	return &gfP6{newGFp2(), newGFp2(), newGFp2()}
+}
+
+func (e *gfP6) String() string {
+	return "(" + e.x.String() + "," + e.y.String() + "," + e.z.String() + ")"
+}
+
+func (e *gfP6) Set(a *gfP6) *gfP6 {
+	e.x.Set(a.x)
+	e.y.Set(a.y)
+	e.z.Set(a.z)
+	return e
+}
+
+func (e *gfP6) SetZero() *gfP6 {
+	e.x.SetZero()
+	e.y.SetZero()
+	e.z.SetZero()
+	return e
+}
+
+func (e *gfP6) SetOne() *gfP6 {
+	e.x.SetZero()
+	e.y.SetZero()
+	e.z.SetOne()
+	return e
+}
+
+func (e *gfP6) IsZero() bool {
+	return e.x.IsZero() && e.y.IsZero() && e.z.IsZero()
+}
+
+func (e *gfP6) IsOne() bool {
+	return e.x.IsZero() && e.y.IsZero() && e.z.IsOne()
+}
+
+func (e *gfP6) Equal(a *gfP6) bool {
+	return e.x.Equal(a.x) && e.y.Equal(a.y) && e.z.Equal(a.z)
+}
+
+func (e *gfP6) Negative(a *gfP6) *gfP6 {
+	e.x.Negative(a.x)
+	e.y.Negative(a.y)
+	e.z.Negative(a.z)
+	return e
+}
+
+func (e *gfP6) Add(a, b *gfP6) *gfP6 {
+	e.x.Add(a.x, b.x)
+	e.y.Add(a.y, b.y)
+	e.z.Add(a.z, b.z)
+	return e
+}
+
+func (e *gfP6) Sub(a, b *gfP6) *gfP6 {
+	e.x.Sub(a.x, b.x)
+	e.y.Sub(a.y, b.y)
+	e.z.Sub(a.z, b.z)
+	return e
+}
+
+func (e *gfP6) Mul(a, b *gfP6) *gfP6 {
+	// (a.x τ² + a.y τ + a.z)(b.x τ² + b.y τ + b.z), reduced with τ³=ξ:
+	//   τ⁰: a.z b.z + ξ(a.y b.x + a.x b.y)
+	//   τ¹: a.z b.y + a.y b.z + ξ a.x b.x
+	//   τ²: a.z b.x + a.y b.y + a.x b.z
+	t := newGFp2()
+
+	tz := newGFp2().Mul(a.y, b.x)
+	t.Mul(a.x, b.y)
+	tz.Add(tz, t)
+	tz.MulXi(tz)
+	t.Mul(a.z, b.z)
+	tz.Add(tz, t)
+
+	ty := newGFp2().Mul(a.x, b.x)
+	ty.MulXi(ty)
+	t.Mul(a.z, b.y)
+	ty.Add(ty, t)
+	t.Mul(a.y, b.z)
+	ty.Add(ty, t)
+
+	tx := newGFp2().Mul(a.z, b.x)
+	t.Mul(a.y, b.y)
+	tx.Add(tx, t)
+	t.Mul(a.x, b.z)
+	tx.Add(tx, t)
+
+	e.x.Set(tx)
+	e.y.Set(ty)
+	e.z.Set(tz)
+	return e
+}
+
+// MulScalar sets e=a*b where b is an element of GF(p²).
+func (e *gfP6) MulScalar(a *gfP6, b *gfP2) *gfP6 {
+	e.x.Mul(a.x, b)
+	e.y.Mul(a.y, b)
+	e.z.Mul(a.z, b)
+	return e
+}
+
+// MulTau computes τ·(aτ²+bτ+c) = bτ²+cτ+aξ
+func (e *gfP6) MulTau(a *gfP6) *gfP6 {
+	tz := newGFp2().MulXi(a.x)
+	ty := newGFp2().Set(a.z)
+	e.x.Set(a.y)
+	e.y.Set(ty)
+	e.z.Set(tz)
+	return e
+}
+
+func (e *gfP6) Square(a *gfP6) *gfP6 {
+	return e.Mul(a, a)
+}
+
+// Invert sets e=a⁻¹. See "Implementing cryptographic pairings", M. Scott,
+// section 3.2.
+func (e *gfP6) Invert(a *gfP6) *gfP6 {
+	// With a = z + yτ + xτ², the inverse is (A + Bτ + Cτ²)/F where
+	//   A = z² - ξxy, B = ξx² - yz, C = y² - xz, F = zA + ξ(xB + yC).
+	t1 := newGFp2()
+
+	A := newGFp2().Square(a.z)
+	t1.Mul(a.x, a.y)
+	t1.MulXi(t1)
+	A.Sub(A, t1)
+
+	B := newGFp2().Square(a.x)
+	B.MulXi(B)
+	t1.Mul(a.y, a.z)
+	B.Sub(B, t1)
+
+	C := newGFp2().Square(a.y)
+	t1.Mul(a.x, a.z)
+	C.Sub(C, t1)
+
+	F := newGFp2().Mul(C, a.y)
+	F.MulXi(F)
+	t1.Mul(A, a.z)
+	F.Add(F, t1)
+	t1.Mul(B, a.x)
+	t1.MulXi(t1)
+	F.Add(F, t1)
+
+	F.Invert(F)
+
+	e.x.Mul(C, F)
+	e.y.Mul(B, F)
+	e.z.Mul(A, F)
+	return e
+}
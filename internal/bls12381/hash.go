@@ -0,0 +1,122 @@
+// Copyright 2018 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package bls12381
+
+import (
+	"crypto/sha256"
+
+	"golang.org/x/crypto/hashtofield"
+)
+
+// expandMessageXMD implements expand_message_xmd from RFC 9380, section
+// 5.3.1, instantiated with SHA-256.
+func expandMessageXMD(msg, dst []byte, length int) []byte {
+	out, err := hashtofield.ExpandMessageXMD(sha256.New, msg, dst, length)
+	if err != nil {
+		panic("bls12381: " + err.Error())
+	}
+	return out
+}
+
+// hashToFieldP2 implements hash_to_field from RFC 9380, section 5.2, for
+// GF(p²) with L = 64.
+func hashToFieldP2(msg, dst []byte, count int) []*gfP2 {
+	u, err := hashtofield.FieldBLS12381P2.HashToField(hashtofield.XMD(sha256.New), msg, dst, count)
+	if err != nil {
+		panic("bls12381: " + err.Error())
+	}
+	out := make([]*gfP2, count)
+	for i := range out {
+		out[i] = &gfP2{x: u[i][1], y: u[i][0]}
+	}
+	return out
+}
+
+// mapToCurveSSWU implements the simplified Shallue-van de Woestijne-Ulas
+// method from RFC 9380, section 6.6.2, mapping u to an affine point on E₂′.
+// It is not constant time.
+func mapToCurveSSWU(u *gfP2) (x, y *gfP2) {
+	// tv1 = 1 / (Z² u⁴ + Z u²)
+	zu2 := newGFp2().Square(u)
+	zu2.Mul(zu2, sswuZ)
+	tv1 := newGFp2().Square(zu2)
+	tv1.Add(tv1, zu2)
+	tv1.Invert(tv1)
+
+	x1 := newGFp2()
+	if tv1.IsZero() {
+		// x1 = B / (Z A)
+		x1.Mul(sswuZ, sswuA)
+		x1.Invert(x1)
+		x1.Mul(x1, sswuB)
+	} else {
+		// x1 = (-B / A) (1 + tv1)
+		x1.Invert(sswuA)
+		x1.Mul(x1, sswuB)
+		x1.Negative(x1)
+		tv1.Add(tv1, newGFp2().SetOne())
+		x1.Mul(x1, tv1)
+	}
+
+	x, y = x1, newGFp2()
+	if !y.Sqrt(sswuCurve(x1)) {
+		// x2 = Z u² x1
+		x = newGFp2().Mul(zu2, x1)
+		if !y.Sqrt(sswuCurve(x)) {
+			panic("bls12381: internal error: neither candidate is on the curve")
+		}
+	}
+
+	if u.Sgn0() != y.Sgn0() {
+		y.Negative(y)
+	}
+	return x, y
+}
+
+// sswuCurve returns x³ + Ax + B for the curve E₂′.
+func sswuCurve(x *gfP2) *gfP2 {
+	t := newGFp2().Square(x)
+	t.Add(t, sswuA)
+	t.Mul(t, x)
+	return t.Add(t, sswuB)
+}
+
+// evalPoly evaluates the polynomial with the given coefficients, lowest degree
+// first, at x.
+func evalPoly(coeffs []*gfP2, x *gfP2) *gfP2 {
+	out := newGFp2().Set(coeffs[len(coeffs)-1])
+	for i := len(coeffs) - 2; i >= 0; i-- {
+		out.Mul(out, x)
+		out.Add(out, coeffs[i])
+	}
+	return out
+}
+
+// isoMap maps an affine point on E₂′ to the twist via the 3-isogeny from RFC
+// 9380, appendix E.3.
+func isoMap(x, y *gfP2) *twistPoint {
+	xDen := evalPoly(isoXDen, x)
+	yDen := evalPoly(isoYDen, x)
+	if xDen.IsZero() || yDen.IsZero() {
+		return newTwistPoint().SetInfinity()
+	}
+
+	out := newTwistPoint()
+	out.x.Mul(evalPoly(isoXNum, x), xDen.Invert(xDen))
+	out.y.Mul(evalPoly(isoYNum, x), yDen.Invert(yDen))
+	out.y.Mul(out.y, y)
+	out.z.SetOne()
+	return out
+}
+
+// hashToTwist implements the BLS12381G2_XMD:SHA-256_SSWU_RO_ suite from RFC
+// 9380, returning a point in G₂.
+func hashToTwist(msg, dst []byte) *twistPoint {
+	u := hashToFieldP2(msg, dst, 2)
+	q0 := isoMap(mapToCurveSSWU(u[0]))
+	q1 := isoMap(mapToCurveSSWU(u[1]))
+	r := newTwistPoint().Add(q0, q1)
+	return r.Mul(r, g2CofactorEff)
+}
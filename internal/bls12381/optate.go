@@ -0,0 +1,109 @@
+// Copyright 2018 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package bls12381
+
+// lineFunction returns the line through r with slope lambda, where r and
+// lambda live on the twist, evaluated at the untwisted image of q. The result
+// is scaled by ω³, a factor which is removed by the final exponentiation:
+//
+//	l(q) = (λ·r.x - r.y) - λ·q.x·τ + q.y·τω
+func lineFunction(lambda *gfP2, r *twistPoint, q *curvePoint) *gfP12 {
+	l := newGFp12()
+
+	l.y.z.Mul(lambda, r.x)
+	l.y.z.Sub(l.y.z, r.y)
+	l.y.y.MulScalar(lambda, q.x)
+	l.y.y.Negative(l.y.y)
+	l.x.y.y.Set(q.y)
+
+	return l
+}
+
+// miller implements the Miller loop for the optimal Ate pairing with loop
+// parameter |u|. Both inputs must be in affine form and neither may be the
+// point at infinity. The computation runs on the twist in affine coordinates,
+// which costs one GF(p²) inversion per step but keeps the line functions
+// simple.
+func miller(q *twistPoint, p *curvePoint) *gfP12 {
+	f := newGFp12().SetOne()
+
+	r := newTwistPoint().Set(q)
+	lambda := newGFp2()
+	t, t2 := newGFp2(), newGFp2()
+
+	for i := u.BitLen() - 2; i >= 0; i-- {
+		f.Square(f)
+
+		// Doubling step: λ = 3x²/2y.
+		t.Square(r.x)
+		lambda.Double(t)
+		lambda.Add(lambda, t)
+		t.Double(r.y)
+		t.Invert(t)
+		lambda.Mul(lambda, t)
+
+		f.Mul(f, lineFunction(lambda, r, p))
+
+		// x' = λ² - 2x, y' = λ(x - x') - y
+		t.Square(lambda)
+		t.Sub(t, r.x)
+		t.Sub(t, r.x)
+		t2.Sub(r.x, t)
+		t2.Mul(t2, lambda)
+		r.y.Sub(t2, r.y)
+		r.x.Set(t)
+
+		if u.Bit(i) == 0 {
+			continue
+		}
+
+		// Addition step: λ = (q.y - y)/(q.x - x).
+		t.Sub(q.x, r.x)
+		t.Invert(t)
+		lambda.Sub(q.y, r.y)
+		lambda.Mul(lambda, t)
+
+		f.Mul(f, lineFunction(lambda, r, p))
+
+		// x' = λ² - x - q.x, y' = λ(x - x') - y
+		t.Square(lambda)
+		t.Sub(t, r.x)
+		t.Sub(t, q.x)
+		t2.Sub(r.x, t)
+		t2.Mul(t2, lambda)
+		r.y.Sub(t2, r.y)
+		r.x.Set(t)
+	}
+
+	// u is negative, so the loop computed f_{|u|} and the result must be
+	// inverted. After the final exponentiation this is the same as
+	// conjugation.
+	return f.Conjugate(f)
+}
+
+// finalExponentiation computes in^((p¹²-1)/Order). The easy part, p⁶-1, is a
+// conjugation and an inversion; the remainder is a plain exponentiation.
+func finalExponentiation(in *gfP12) *gfP12 {
+	t := newGFp12().Invert(in)
+	f := newGFp12().Conjugate(in)
+	f.Mul(f, t)
+	return f.Exp(f, finalExponent)
+}
+
+// optimalAte computes the product of the Miller loops of the given pairs
+// followed by a single final exponentiation. Pairs where either point is the
+// point at infinity contribute nothing.
+func optimalAte(a []*curvePoint, b []*twistPoint) *gfP12 {
+	f := newGFp12().SetOne()
+	for i := range a {
+		if a[i].IsInfinity() || b[i].IsInfinity() {
+			continue
+		}
+		p := newCurvePoint().Set(a[i]).MakeAffine()
+		q := newTwistPoint().Set(b[i]).MakeAffine()
+		f.Mul(f, miller(q, p))
+	}
+	return finalExponentiation(f)
+}
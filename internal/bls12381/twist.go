@@ -0,0 +1,198 @@
+// Copyright 2018 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package bls12381
+
+import (
+	"math/big"
+)
+
+// twistPoint implements the elliptic curve y²=x³+4ξ over GF(p²). Points are
+// kept in Jacobian form. G₂ is the prime-order subgroup of this curve.
+type twistPoint struct {
+	x, y, z *gfP2
+}
+
+var twistB = &gfP2{big.NewInt(4), big.NewInt(4)}
+
+// twistGen is the generator of G₂.
+var twistGen = &twistPoint{
+	newGFp2FromBase16(
+		"13e02b6052719f607dacd3a088274f65596bd0d09920b61ab5da61bbdc7f5049334cf11213945d57e5ac7d055d042b7e",
+		"024aa2b2f08f0a91260805272dc51051c6e47ad4fa403b02b4510b647ae3d1770bac0326a805bbefd48056c8c121bdb8",
+	),
+	newGFp2FromBase16(
+		"0606c4a02ea734cc32acd2b02bc28b99cb3e287e85a763af267492ab572e99ab3f370d275cec1da1aaa9075ff05f79be",
+		"0ce5d527727d6e118cc9cdc6da2e351aadfd9baa8cbdd3a76d429a695160d12c923ac9cc3baca289e193548608b82801",
+	),
+	newGFp2FromBase16("0", "1"),
+}
+
+func newTwistPoint() *twistPoint {
+	return &twistPoint{newGFp2(), newGFp2(), newGFp2()}
+}
+
+func (c *twistPoint) String() string {
+	c.MakeAffine()
+	return "(" + c.x.String() + ", " + c.y.String() + ")"
+}
+
+func (c *twistPoint) Set(a *twistPoint) *twistPoint {
+	c.x.Set(a.x)
+	c.y.Set(a.y)
+	c.z.Set(a.z)
+	return c
+}
+
+// IsOnCurve returns true iff c is on the curve where c must be in affine form.
+func (c *twistPoint) IsOnCurve() bool {
+	yy := newGFp2().Square(c.y)
+	xxx := newGFp2().Square(c.x)
+	xxx.Mul(xxx, c.x)
+	yy.Sub(yy, xxx)
+	yy.Sub(yy, twistB)
+	return yy.IsZero()
+}
+
+func (c *twistPoint) SetInfinity() *twistPoint {
+	c.x.SetZero()
+	c.y.SetOne()
+	c.z.SetZero()
+	return c
+}
+
+func (c *twistPoint) IsInfinity() bool {
+	return c.z.IsZero()
+}
+
+func (c *twistPoint) Add(a, b *twistPoint) *twistPoint {
+	if a.IsInfinity() {
+		return c.Set(b)
+	}
+	if b.IsInfinity() {
+		return c.Set(a)
+	}
+
+	// See http://hyperelliptic.org/EFD/g1p/auto-code/shortw/jacobian-0/addition/add-2007-bl.op3
+	z1z1 := newGFp2().Square(a.z)
+	z2z2 := newGFp2().Square(b.z)
+	u1 := newGFp2().Mul(a.x, z2z2)
+	u2 := newGFp2().Mul(b.x, z1z1)
+
+	s1 := newGFp2().Mul(a.y, b.z)
+	s1.Mul(s1, z2z2)
+	s2 := newGFp2().Mul(b.y, a.z)
+	s2.Mul(s2, z1z1)
+
+	h := newGFp2().Sub(u2, u1)
+	r := newGFp2().Sub(s2, s1)
+	if h.IsZero() {
+		if r.IsZero() {
+			return c.Double(a)
+		}
+		return c.SetInfinity()
+	}
+	r.Double(r)
+
+	i := newGFp2().Double(h)
+	i.Square(i)
+	j := newGFp2().Mul(h, i)
+	v := newGFp2().Mul(u1, i)
+
+	x3 := newGFp2().Square(r)
+	x3.Sub(x3, j)
+	x3.Sub(x3, v)
+	x3.Sub(x3, v)
+
+	y3 := newGFp2().Sub(v, x3)
+	y3.Mul(y3, r)
+	s1.Mul(s1, j)
+	s1.Double(s1)
+	y3.Sub(y3, s1)
+
+	z3 := newGFp2().Add(a.z, b.z)
+	z3.Square(z3)
+	z3.Sub(z3, z1z1)
+	z3.Sub(z3, z2z2)
+	z3.Mul(z3, h)
+
+	c.x.Set(x3)
+	c.y.Set(y3)
+	c.z.Set(z3)
+	return c
+}
+
+func (c *twistPoint) Double(a *twistPoint) *twistPoint {
+	// See http://hyperelliptic.org/EFD/g1p/auto-code/shortw/jacobian-0/doubling/dbl-2009-l.op3
+	A := newGFp2().Square(a.x)
+	B := newGFp2().Square(a.y)
+	C := newGFp2().Square(B)
+
+	d := newGFp2().Add(a.x, B)
+	d.Square(d)
+	d.Sub(d, A)
+	d.Sub(d, C)
+	d.Double(d)
+
+	e := newGFp2().Double(A)
+	e.Add(e, A)
+	f := newGFp2().Square(e)
+
+	x3 := newGFp2().Double(d)
+	x3.Sub(f, x3)
+
+	z3 := newGFp2().Mul(a.y, a.z)
+	z3.Double(z3)
+
+	y3 := newGFp2().Sub(d, x3)
+	y3.Mul(y3, e)
+	C.Double(C)
+	C.Double(C)
+	C.Double(C)
+	y3.Sub(y3, C)
+
+	c.x.Set(x3)
+	c.y.Set(y3)
+	c.z.Set(z3)
+	return c
+}
+
+func (c *twistPoint) Mul(a *twistPoint, scalar *big.Int) *twistPoint {
+	sum := newTwistPoint().SetInfinity()
+	for i := scalar.BitLen() - 1; i >= 0; i-- {
+		sum.Double(sum)
+		if scalar.Bit(i) != 0 {
+			sum.Add(sum, a)
+		}
+	}
+	return c.Set(sum)
+}
+
+func (c *twistPoint) Negative(a *twistPoint) *twistPoint {
+	c.x.Set(a.x)
+	c.y.Negative(a.y)
+	c.z.Set(a.z)
+	return c
+}
+
+// MakeAffine converts c to affine form. The point at infinity is left as is.
+func (c *twistPoint) MakeAffine() *twistPoint {
+	if c.IsInfinity() || c.z.IsOne() {
+		return c
+	}
+
+	zInv := newGFp2().Invert(c.z)
+	t := newGFp2().Square(zInv)
+	c.x.Mul(c.x, t)
+	t.Mul(t, zInv)
+	c.y.Mul(c.y, t)
+	c.z.SetOne()
+	return c
+}
+
+// IsInSubgroup reports whether c is in G₂, i.e. whether Order·c is the point
+// at infinity.
+func (c *twistPoint) IsInSubgroup() bool {
+	return newTwistPoint().Mul(c, Order).IsInfinity()
+}
@@ -186,3 +186,24 @@ func BenchmarkChaCha20(b *testing.B) {
 		})
 	}
 }
+
+func TestHChaCha20(t *testing.T) {
+	// This is the test vector from
+	// https://tools.ietf.org/html/draft-irtf-cfrg-xchacha-01#section-2.2.1.
+	var key [8]uint32
+	for i := range key {
+		b := byte(4 * i)
+		key[i] = uint32(b) | uint32(b+1)<<8 | uint32(b+2)<<16 | uint32(b+3)<<24
+	}
+	nonce := [4]uint32{0x09000000, 0x4a000000, 0x00000000, 0x27594131}
+	subkey := HChaCha20(&key, &nonce)
+
+	out := make([]byte, 0, 32)
+	for _, w := range subkey {
+		out = append(out, byte(w), byte(w>>8), byte(w>>16), byte(w>>24))
+	}
+	const expected = "82413b4227b27bfed30e42508a877d73a0f9e4d58a74a853c12ec41326d3ecdc"
+	if result := hex.EncodeToString(out); result != expected {
+		t.Errorf("wanted %s but got %s", expected, result)
+	}
+}
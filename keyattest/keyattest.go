@@ -0,0 +1,286 @@
+// Copyright 2018 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+// Package keyattest produces and verifies reports that bind a freshly
+// generated public key to evidence from the platform that generated it, so
+// that a provisioning system can require keys to be generated in approved
+// environments, such as a TPM or a TEE.
+//
+// The package does not interpret the evidence. When a report is made, the
+// caller's AttestFunc is given a digest of the public key, of a nonce chosen
+// by the verifier and of the creation time, and must return evidence bound to
+// that digest. The verifier checks the nonce and the age of the report,
+// recomputes the digest, and passes it along with the evidence to its own
+// function, which checks that the evidence comes from an approved environment
+// and covers the digest.
+//
+// Binding the digest to the evidence only proves that an approved environment
+// saw the public key, not that it generated the private key: a TPM quote with
+// the digest as qualifying data, or a TEE report with the digest in its user
+// data, can be made for a key generated anywhere. The evidence mechanism
+// itself must bind the key to its generation, as TPM2_Certify does for a key
+// resident in the TPM, or as a TEE report does when the code it measures only
+// reports keys it generated and never exports.
+//
+// Public keys are encoded as PKIX SubjectPublicKeyInfo structures. Keys
+// from this repository's ed25519 package are supported, as defined in RFC
+// 8410, in addition to those supported by crypto/x509.
+package keyattest // import "golang.org/x/crypto/keyattest"
+
+import (
+	"crypto"
+	"crypto/sha256"
+	"crypto/subtle"
+	"crypto/x509"
+	"errors"
+	"fmt"
+	"io"
+	"time"
+
+	"golang.org/x/crypto/cryptobyte"
+	"golang.org/x/crypto/ed25519"
+)
+
+const (
+	// MinNonceSize is the minimum size, in bytes, of nonces.
+	MinNonceSize = 16
+	// MaxNonceSize is the maximum size, in bytes, of nonces.
+	MaxNonceSize = 255
+)
+
+const version1 = 1
+
+// digestDomain separates the digests of reports from other uses of the
+// attestation mechanism.
+const digestDomain = "golang.org/x/crypto/keyattest v1\x00"
+
+var (
+	// ErrNonceMismatch is returned by Verify if the report was not made for
+	// the expected nonce, and may be replayed.
+	ErrNonceMismatch = errors.New("keyattest: report nonce does not match")
+	// ErrExpired is returned by Verify if the report is older than the
+	// maximum age, or was created in the future.
+	ErrExpired = errors.New("keyattest: report is outside its validity period")
+
+	errNonceSize   = errors.New("keyattest: nonce must be between 16 and 255 bytes long")
+	errFormat      = errors.New("keyattest: evidence format must be between 1 and 255 bytes long")
+	errMalformed   = errors.New("keyattest: malformed report")
+	errNoEvidence  = errors.New("keyattest: VerifyOptions.VerifyEvidence is required")
+	errEvidenceLen = errors.New("keyattest: evidence too long")
+)
+
+// An AttestFunc returns platform evidence bound to digest. It is provided by
+// the caller, as it depends on the platform.
+type AttestFunc func(digest []byte) (evidence []byte, err error)
+
+// A Report binds a public key to platform evidence.
+type Report struct {
+	// PublicKey is the attested key.
+	PublicKey crypto.PublicKey
+	// Nonce is the challenge chosen by the verifier, which prevents the
+	// replay of reports.
+	Nonce []byte
+	// Created is when the report was made, with a precision of one second.
+	Created time.Time
+	// Format identifies the kind of evidence, such as "tpm2-quote", so that
+	// the verifier knows how to check it.
+	Format string
+	// Evidence is the platform evidence, bound to Digest.
+	Evidence []byte
+}
+
+// New returns a report for pub, made at the current time in response to the
+// verifier's nonce, with the evidence of the given format returned by attest.
+func New(pub crypto.PublicKey, nonce []byte, format string, attest AttestFunc) (*Report, error) {
+	r := &Report{
+		PublicKey: pub,
+		Nonce:     append([]byte(nil), nonce...),
+		Created:   time.Now().Truncate(time.Second),
+		Format:    format,
+	}
+	digest, err := r.Digest()
+	if err != nil {
+		return nil, err
+	}
+	if r.Evidence, err = attest(digest); err != nil {
+		return nil, err
+	}
+	return r, nil
+}
+
+// GenerateEd25519 generates an Ed25519 key using entropy from rand, or from
+// crypto/rand.Reader if rand is nil, and returns it along with its report, as
+// New would. The caller must run in the environment described by the
+// evidence, and keep the private key in it. Since the key is generated in
+// this process, attest must return evidence of the code that runs it, such as
+// a TEE report, and not evidence of a separate device.
+func GenerateEd25519(rand io.Reader, nonce []byte, format string, attest AttestFunc) (ed25519.PrivateKey, *Report, error) {
+	pub, priv, err := ed25519.GenerateKey(rand)
+	if err != nil {
+		return nil, nil, err
+	}
+	r, err := New(pub, nonce, format, attest)
+	if err != nil {
+		return nil, nil, err
+	}
+	return priv, r, nil
+}
+
+// Digest returns the SHA-256 digest of the report, without its evidence, to
+// which the evidence must be bound.
+func (r *Report) Digest() ([]byte, error) {
+	b := cryptobyte.NewBuilder(nil)
+	b.AddBytes([]byte(digestDomain))
+	if err := r.marshalSigned(b); err != nil {
+		return nil, err
+	}
+	signed, err := b.Bytes()
+	if err != nil {
+		return nil, err
+	}
+	digest := sha256.Sum256(signed)
+	return digest[:], nil
+}
+
+// marshalSigned adds the fields covered by the digest to b.
+func (r *Report) marshalSigned(b *cryptobyte.Builder) error {
+	if len(r.Nonce) < MinNonceSize || len(r.Nonce) > MaxNonceSize {
+		return errNonceSize
+	}
+	if len(r.Format) == 0 || len(r.Format) > 255 {
+		return errFormat
+	}
+	spki, err := marshalPublicKey(r.PublicKey)
+	if err != nil {
+		return err
+	}
+	created := uint64(r.Created.Unix())
+	b.AddUint8(version1)
+	b.AddUint16LengthPrefixed(func(b *cryptobyte.Builder) {
+		b.AddBytes(spki)
+	})
+	b.AddUint8LengthPrefixed(func(b *cryptobyte.Builder) {
+		b.AddBytes(r.Nonce)
+	})
+	b.AddUint32(uint32(created >> 32))
+	b.AddUint32(uint32(created))
+	b.AddUint8LengthPrefixed(func(b *cryptobyte.Builder) {
+		b.AddBytes([]byte(r.Format))
+	})
+	return nil
+}
+
+// Marshal returns the binary encoding of the report.
+func (r *Report) Marshal() ([]byte, error) {
+	if len(r.Evidence) >= 1<<24 {
+		return nil, errEvidenceLen
+	}
+	var b cryptobyte.Builder
+	if err := r.marshalSigned(&b); err != nil {
+		return nil, err
+	}
+	b.AddUint24LengthPrefixed(func(b *cryptobyte.Builder) {
+		b.AddBytes(r.Evidence)
+	})
+	return b.Bytes()
+}
+
+// Parse parses a report encoded by Marshal. The report must then be checked
+// with Verify.
+func Parse(data []byte) (*Report, error) {
+	s := cryptobyte.String(data)
+	var (
+		version                  uint8
+		spki, nonce, format, evd cryptobyte.String
+		hi, lo                   uint32
+	)
+	if !s.ReadUint8(&version) || version != version1 ||
+		!s.ReadUint16LengthPrefixed(&spki) ||
+		!s.ReadUint8LengthPrefixed(&nonce) ||
+		!s.ReadUint32(&hi) || !s.ReadUint32(&lo) ||
+		!s.ReadUint8LengthPrefixed(&format) ||
+		!s.ReadUint24LengthPrefixed(&evd) || !s.Empty() {
+		return nil, errMalformed
+	}
+	if len(nonce) < MinNonceSize || len(format) == 0 {
+		return nil, errMalformed
+	}
+	pub, err := parsePublicKey(spki)
+	if err != nil {
+		return nil, err
+	}
+	return &Report{
+		PublicKey: pub,
+		Nonce:     append([]byte(nil), nonce...),
+		Created:   time.Unix(int64(uint64(hi)<<32|uint64(lo)), 0),
+		Format:    string(format),
+		Evidence:  append([]byte(nil), evd...),
+	}, nil
+}
+
+// VerifyOptions holds the parameters of Verify.
+type VerifyOptions struct {
+	// Nonce is the challenge that was sent to the platform. It is required.
+	Nonce []byte
+
+	// MaxAge, if not zero, is the maximum age of the report.
+	MaxAge time.Duration
+
+	// CurrentTime is the time at which the report is checked. If zero, the
+	// current time is used.
+	CurrentTime time.Time
+
+	// VerifyEvidence must check that evidence, of the given format, comes
+	// from an approved environment, is bound to digest, and attests that
+	// the environment generated the key, and return nil if it does. It is
+	// required.
+	VerifyEvidence func(format string, evidence, digest []byte) error
+}
+
+// Verify checks that the report answers opts.Nonce, is not too old, and has
+// evidence accepted by opts.VerifyEvidence. If it returns nil, r.PublicKey
+// can be trusted to have been generated on an approved platform only as far
+// as opts.VerifyEvidence checks that the evidence binds the key to its
+// generation, as described in the package documentation.
+func (r *Report) Verify(opts VerifyOptions) error {
+	if opts.VerifyEvidence == nil {
+		return errNoEvidence
+	}
+	if len(opts.Nonce) == 0 || subtle.ConstantTimeCompare(r.Nonce, opts.Nonce) != 1 {
+		return ErrNonceMismatch
+	}
+	now := opts.CurrentTime
+	if now.IsZero() {
+		now = time.Now()
+	}
+	// Reports are created with a precision of one second.
+	if r.Created.After(now.Add(time.Second)) ||
+		opts.MaxAge != 0 && now.Sub(r.Created) > opts.MaxAge {
+		return ErrExpired
+	}
+	digest, err := r.Digest()
+	if err != nil {
+		return err
+	}
+	if err := opts.VerifyEvidence(r.Format, r.Evidence, digest); err != nil {
+		return fmt.Errorf("keyattest: evidence rejected: %v", err)
+	}
+	return nil
+}
+
+// marshalPublicKey returns the SubjectPublicKeyInfo encoding of pub.
+func marshalPublicKey(pub crypto.PublicKey) ([]byte, error) {
+	if k, ok := pub.(ed25519.PublicKey); ok {
+		return ed25519.MarshalPKIXPublicKey(k)
+	}
+	return x509.MarshalPKIXPublicKey(pub)
+}
+
+// parsePublicKey parses a SubjectPublicKeyInfo encoded by marshalPublicKey.
+func parsePublicKey(spki []byte) (crypto.PublicKey, error) {
+	if pub, err := ed25519.ParsePKIXPublicKey(spki); err == nil {
+		return pub, nil
+	}
+	return x509.ParsePKIXPublicKey(spki)
+}
@@ -0,0 +1,164 @@
+// Copyright 2018 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package keyattest
+
+import (
+	"bytes"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"errors"
+	"reflect"
+	"testing"
+	"time"
+
+	"golang.org/x/crypto/ed25519"
+)
+
+// The fake platform authenticates digests with a MAC key that only it and the
+// verifier know.
+var platformKey = []byte("platform attestation key")
+
+const testFormat = "test-mac"
+
+func attest(digest []byte) ([]byte, error) {
+	m := hmac.New(sha256.New, platformKey)
+	m.Write(digest)
+	return m.Sum(nil), nil
+}
+
+func verifyEvidence(format string, evidence, digest []byte) error {
+	if format != testFormat {
+		return errors.New("unknown format")
+	}
+	want, _ := attest(digest)
+	if !hmac.Equal(evidence, want) {
+		return errors.New("bad evidence")
+	}
+	return nil
+}
+
+var testNonce = bytes.Repeat([]byte{7}, 32)
+
+func TestReport(t *testing.T) {
+	priv, r, err := GenerateEd25519(nil, testNonce, testFormat, attest)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(r.PublicKey, priv.Public()) {
+		t.Errorf("report is for %x, want %x", r.PublicKey, priv.Public())
+	}
+
+	encoded, err := r.Marshal()
+	if err != nil {
+		t.Fatal(err)
+	}
+	parsed, err := Parse(encoded)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(parsed.PublicKey, r.PublicKey) || !parsed.Created.Equal(r.Created) ||
+		!bytes.Equal(parsed.Nonce, r.Nonce) || parsed.Format != r.Format || !bytes.Equal(parsed.Evidence, r.Evidence) {
+		t.Errorf("Parse(Marshal()) = %+v, want %+v", parsed, r)
+	}
+
+	opts := VerifyOptions{Nonce: testNonce, MaxAge: time.Minute, VerifyEvidence: verifyEvidence}
+	if err := parsed.Verify(opts); err != nil {
+		t.Errorf("Verify: %v", err)
+	}
+
+	wrongNonce := opts
+	wrongNonce.Nonce = bytes.Repeat([]byte{8}, 32)
+	if err := parsed.Verify(wrongNonce); err != ErrNonceMismatch {
+		t.Errorf("wrong nonce: got %v, want ErrNonceMismatch", err)
+	}
+	late := opts
+	late.CurrentTime = r.Created.Add(2 * time.Minute)
+	if err := parsed.Verify(late); err != ErrExpired {
+		t.Errorf("old report: got %v, want ErrExpired", err)
+	}
+	early := opts
+	early.CurrentTime = r.Created.Add(-time.Hour)
+	if err := parsed.Verify(early); err != ErrExpired {
+		t.Errorf("future report: got %v, want ErrExpired", err)
+	}
+	if err := parsed.Verify(VerifyOptions{Nonce: testNonce}); err == nil {
+		t.Error("Verify succeeded without VerifyEvidence")
+	}
+
+	// Replacing the key invalidates the evidence.
+	other, _, _ := ed25519.GenerateKey(nil)
+	swapped := *parsed
+	swapped.PublicKey = other
+	if err := swapped.Verify(opts); err == nil {
+		t.Error("report with a replaced key verified")
+	}
+	swapped = *parsed
+	swapped.Created = swapped.Created.Add(-time.Second)
+	if err := swapped.Verify(opts); err == nil {
+		t.Error("report with a modified creation time verified")
+	}
+	swapped = *parsed
+	swapped.Format = "other"
+	if err := swapped.Verify(opts); err == nil {
+		t.Error("report with a modified format verified")
+	}
+
+	for i := range encoded {
+		corrupted := append([]byte(nil), encoded...)
+		corrupted[i] ^= 0x40
+		if r, err := Parse(corrupted); err == nil && r.Verify(opts) == nil {
+			t.Errorf("report with byte %d modified verified", i)
+		}
+	}
+	if _, err := Parse(append(encoded, 0)); err == nil {
+		t.Error("report with trailing data parsed")
+	}
+}
+
+func TestReportECDSA(t *testing.T) {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	r, err := New(&key.PublicKey, testNonce, testFormat, attest)
+	if err != nil {
+		t.Fatal(err)
+	}
+	encoded, err := r.Marshal()
+	if err != nil {
+		t.Fatal(err)
+	}
+	parsed, err := Parse(encoded)
+	if err != nil {
+		t.Fatal(err)
+	}
+	pub, ok := parsed.PublicKey.(*ecdsa.PublicKey)
+	if !ok || pub.X.Cmp(key.X) != 0 || pub.Y.Cmp(key.Y) != 0 {
+		t.Errorf("parsed key = %v, want %v", parsed.PublicKey, &key.PublicKey)
+	}
+	if err := parsed.Verify(VerifyOptions{Nonce: testNonce, VerifyEvidence: verifyEvidence}); err != nil {
+		t.Errorf("Verify: %v", err)
+	}
+}
+
+func TestNewErrors(t *testing.T) {
+	pub, _, _ := ed25519.GenerateKey(nil)
+	if _, err := New(pub, testNonce[:MinNonceSize-1], testFormat, attest); err == nil {
+		t.Error("short nonce accepted")
+	}
+	if _, err := New(pub, testNonce, "", attest); err == nil {
+		t.Error("empty format accepted")
+	}
+	if _, err := New(pub[:31], testNonce, testFormat, attest); err == nil {
+		t.Error("short Ed25519 key accepted")
+	}
+	failure := errors.New("no TPM")
+	if _, err := New(pub, testNonce, testFormat, func([]byte) ([]byte, error) { return nil, failure }); err != failure {
+		t.Errorf("got %v, want the error of the AttestFunc", err)
+	}
+}
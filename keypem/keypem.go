@@ -0,0 +1,287 @@
+// Copyright 2018 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+// Package keypem serializes keys to and from PEM blocks, optionally
+// protecting them with a passphrase.
+//
+// Each key type is identified by a PEM block type, such as "ED25519 PRIVATE
+// KEY", and the block contains the raw encoding of the key. Keys from this
+// repository's ed25519 and curve25519 packages are supported out of the box,
+// and other packages can make their key types available with Register.
+//
+// A key protected with a passphrase keeps its block type, so that tools can
+// tell what it contains without decrypting it. The key is encrypted with
+// ChaCha20-Poly1305 under a key derived from the passphrase with Argon2id,
+// and the parameters needed to decrypt it are carried in the PEM headers.
+package keypem // import "golang.org/x/crypto/keypem"
+
+import (
+	"crypto/cipher"
+	"crypto/rand"
+	"encoding/base64"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"reflect"
+	"strconv"
+	"strings"
+	"sync"
+
+	"golang.org/x/crypto/argon2"
+	"golang.org/x/crypto/chacha20poly1305"
+)
+
+var (
+	// ErrPassphraseRequired is returned by Unmarshal when the key is
+	// protected with a passphrase.
+	ErrPassphraseRequired = errors.New("keypem: key is protected with a passphrase")
+	// ErrIncorrectPassphrase is returned by UnmarshalWithPassphrase when the
+	// key cannot be decrypted, either because the passphrase is wrong or
+	// because the block was modified.
+	ErrIncorrectPassphrase = errors.New("keypem: incorrect passphrase")
+
+	errNoBlock       = errors.New("keypem: no PEM data found")
+	errNotEncrypted  = errors.New("keypem: key is not protected with a passphrase")
+	errBadEncryption = errors.New("keypem: malformed encryption headers")
+)
+
+// codec converts keys of one registered type to and from their raw encoding.
+type codec struct {
+	blockType string
+	marshal   func(key interface{}) ([]byte, error)
+	unmarshal func(data []byte) (interface{}, error)
+}
+
+var (
+	registryMu  sync.RWMutex
+	byBlockType = make(map[string]*codec)
+	byGoType    = make(map[reflect.Type]*codec)
+)
+
+// Register makes a key type available to the marshaling functions of this
+// package. blockType is the PEM block type used for the key, and key is a
+// value of the Go type of the keys, which is how codecs are selected when
+// marshaling. marshal is only called with values of that type and must return
+// the raw encoding of the key; unmarshal must parse that encoding.
+//
+// Register is typically called from the init function of the package that
+// defines the key type. It panics if blockType or the Go type of key is
+// already registered.
+func Register(blockType string, key interface{}, marshal func(key interface{}) ([]byte, error), unmarshal func(data []byte) (interface{}, error)) {
+	registryMu.Lock()
+	defer registryMu.Unlock()
+
+	t := reflect.TypeOf(key)
+	if _, dup := byBlockType[blockType]; dup {
+		panic("keypem: Register called twice for block type " + blockType)
+	}
+	if _, dup := byGoType[t]; dup {
+		panic("keypem: Register called twice for type " + t.String())
+	}
+	c := &codec{blockType, marshal, unmarshal}
+	byBlockType[blockType] = c
+	byGoType[t] = c
+}
+
+func codecForKey(key interface{}) (*codec, error) {
+	registryMu.RLock()
+	defer registryMu.RUnlock()
+	c, ok := byGoType[reflect.TypeOf(key)]
+	if !ok {
+		return nil, fmt.Errorf("keypem: unregistered key type %T", key)
+	}
+	return c, nil
+}
+
+func codecForBlock(b *pem.Block) (*codec, error) {
+	registryMu.RLock()
+	defer registryMu.RUnlock()
+	c, ok := byBlockType[b.Type]
+	if !ok {
+		return nil, fmt.Errorf("keypem: unknown block type %q", b.Type)
+	}
+	return c, nil
+}
+
+// Marshal returns the PEM encoding of key, which must be of a registered
+// type.
+func Marshal(key interface{}) ([]byte, error) {
+	c, err := codecForKey(key)
+	if err != nil {
+		return nil, err
+	}
+	data, err := c.marshal(key)
+	if err != nil {
+		return nil, err
+	}
+	return pem.EncodeToMemory(&pem.Block{Type: c.blockType, Bytes: data}), nil
+}
+
+// Unmarshal parses the first PEM block in data and returns the key it
+// contains. If the key is protected with a passphrase, Unmarshal returns
+// ErrPassphraseRequired.
+func Unmarshal(data []byte) (interface{}, error) {
+	b, c, err := decodeBlock(data)
+	if err != nil {
+		return nil, err
+	}
+	if _, ok := b.Headers[headerEncryption]; ok {
+		return nil, ErrPassphraseRequired
+	}
+	return c.unmarshal(b.Bytes)
+}
+
+func decodeBlock(data []byte) (*pem.Block, *codec, error) {
+	b, _ := pem.Decode(data)
+	if b == nil {
+		return nil, nil, errNoBlock
+	}
+	c, err := codecForBlock(b)
+	if err != nil {
+		return nil, nil, err
+	}
+	return b, c, nil
+}
+
+const (
+	headerEncryption = "Encryption"
+	headerKDFParams  = "KDF-Params"
+	headerSalt       = "Salt"
+	headerNonce      = "Nonce"
+
+	encryptionScheme = "argon2id-chacha20poly1305"
+	saltSize         = 16
+)
+
+// The Argon2id parameters used by MarshalWithPassphrase, following the
+// recommendations in the documentation of golang.org/x/crypto/argon2.
+const (
+	argon2Time    = 1
+	argon2Memory  = 64 * 1024
+	argon2Threads = 4
+)
+
+// MarshalWithPassphrase is like Marshal, but encrypts the key with a key
+// derived from passphrase.
+func MarshalWithPassphrase(key interface{}, passphrase []byte) ([]byte, error) {
+	c, err := codecForKey(key)
+	if err != nil {
+		return nil, err
+	}
+	plaintext, err := c.marshal(key)
+	if err != nil {
+		return nil, err
+	}
+	defer zero(plaintext)
+
+	salt := make([]byte, saltSize)
+	if _, err := rand.Read(salt); err != nil {
+		return nil, err
+	}
+	// Every encryption uses a fresh salt, and therefore a fresh key, so a
+	// random nonce cannot repeat.
+	nonce := make([]byte, chacha20poly1305.NonceSize)
+	if _, err := rand.Read(nonce); err != nil {
+		return nil, err
+	}
+
+	aead, err := newAEAD(passphrase, salt, argon2Time, argon2Memory, argon2Threads)
+	if err != nil {
+		return nil, err
+	}
+	b := &pem.Block{
+		Type: c.blockType,
+		Headers: map[string]string{
+			headerEncryption: encryptionScheme,
+			headerKDFParams:  fmt.Sprintf("t=%d,m=%d,p=%d", argon2Time, argon2Memory, argon2Threads),
+			headerSalt:       base64.StdEncoding.EncodeToString(salt),
+			headerNonce:      base64.StdEncoding.EncodeToString(nonce),
+		},
+		Bytes: aead.Seal(nil, nonce, plaintext, []byte(c.blockType)),
+	}
+	return pem.EncodeToMemory(b), nil
+}
+
+// UnmarshalWithPassphrase is like Unmarshal, but decrypts a key protected
+// with a passphrase. It returns an error if the key is not protected.
+func UnmarshalWithPassphrase(data, passphrase []byte) (interface{}, error) {
+	b, c, err := decodeBlock(data)
+	if err != nil {
+		return nil, err
+	}
+	scheme, ok := b.Headers[headerEncryption]
+	if !ok {
+		return nil, errNotEncrypted
+	}
+	if scheme != encryptionScheme {
+		return nil, fmt.Errorf("keypem: unsupported encryption scheme %q", scheme)
+	}
+
+	time, memory, threads, err := parseKDFParams(b.Headers[headerKDFParams])
+	if err != nil {
+		return nil, err
+	}
+	salt, err := base64.StdEncoding.DecodeString(b.Headers[headerSalt])
+	if err != nil || len(salt) < saltSize {
+		return nil, errBadEncryption
+	}
+	nonce, err := base64.StdEncoding.DecodeString(b.Headers[headerNonce])
+	if err != nil || len(nonce) != chacha20poly1305.NonceSize {
+		return nil, errBadEncryption
+	}
+
+	aead, err := newAEAD(passphrase, salt, time, memory, threads)
+	if err != nil {
+		return nil, err
+	}
+	plaintext, err := aead.Open(nil, nonce, b.Bytes, []byte(b.Type))
+	if err != nil {
+		return nil, ErrIncorrectPassphrase
+	}
+	defer zero(plaintext)
+	return c.unmarshal(plaintext)
+}
+
+const (
+	// maxArgon2Time bounds the number of passes, and maxArgon2Memory the
+	// memory, in KiB, that a key from an untrusted source can make
+	// UnmarshalWithPassphrase perform and allocate.
+	maxArgon2Time   = 64
+	maxArgon2Memory = 4 * 1024 * 1024
+)
+
+// parseKDFParams parses a KDF-Params header of the form "t=1,m=65536,p=4".
+func parseKDFParams(s string) (time, memory uint32, threads uint8, err error) {
+	fields := strings.Split(s, ",")
+	if len(fields) != 3 {
+		return 0, 0, 0, errBadEncryption
+	}
+	var vals [3]uint64
+	for i, name := range []string{"t=", "m=", "p="} {
+		if !strings.HasPrefix(fields[i], name) {
+			return 0, 0, 0, errBadEncryption
+		}
+		v, err := strconv.ParseUint(fields[i][len(name):], 10, 32)
+		if err != nil {
+			return 0, 0, 0, errBadEncryption
+		}
+		vals[i] = v
+	}
+	if vals[0] < 1 || vals[0] > maxArgon2Time || vals[2] < 1 || vals[2] > 255 || vals[1] > maxArgon2Memory {
+		return 0, 0, 0, errBadEncryption
+	}
+	return uint32(vals[0]), uint32(vals[1]), uint8(vals[2]), nil
+}
+
+func newAEAD(passphrase, salt []byte, time, memory uint32, threads uint8) (cipher.AEAD, error) {
+	key := argon2.IDKey(passphrase, salt, time, memory, threads, chacha20poly1305.KeySize)
+	defer zero(key)
+	return chacha20poly1305.New(key)
+}
+
+func zero(b []byte) {
+	for i := range b {
+		b[i] = 0
+	}
+}
@@ -0,0 +1,141 @@
+// Copyright 2018 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package keypem
+
+import (
+	"bytes"
+	"encoding/pem"
+	"reflect"
+	"strings"
+	"testing"
+
+	"golang.org/x/crypto/ed25519"
+)
+
+func testKeys(t *testing.T) []interface{} {
+	pub, priv, err := ed25519.GenerateKey(nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var xPriv X25519PrivateKey
+	var xPub X25519PublicKey
+	copy(xPriv[:], bytes.Repeat([]byte{0x42}, 32))
+	copy(xPub[:], bytes.Repeat([]byte{0x09}, 32))
+	return []interface{}{priv, pub, xPriv, xPub}
+}
+
+func TestRoundTrip(t *testing.T) {
+	for _, key := range testKeys(t) {
+		data, err := Marshal(key)
+		if err != nil {
+			t.Fatalf("Marshal(%T): %v", key, err)
+		}
+		got, err := Unmarshal(data)
+		if err != nil {
+			t.Fatalf("Unmarshal(%T): %v", key, err)
+		}
+		if !reflect.DeepEqual(got, key) {
+			t.Errorf("%T does not round-trip: got %x, want %x", key, got, key)
+		}
+		if _, err := UnmarshalWithPassphrase(data, []byte("x")); err == nil {
+			t.Errorf("UnmarshalWithPassphrase(%T) accepted an unprotected key", key)
+		}
+	}
+}
+
+func TestPassphrase(t *testing.T) {
+	passphrase := []byte("correct horse battery staple")
+	for _, key := range testKeys(t)[:3] {
+		data, err := MarshalWithPassphrase(key, passphrase)
+		if err != nil {
+			t.Fatalf("MarshalWithPassphrase(%T): %v", key, err)
+		}
+		if _, err := Unmarshal(data); err != ErrPassphraseRequired {
+			t.Errorf("Unmarshal(%T) of a protected key returned %v, want ErrPassphraseRequired", key, err)
+		}
+		if _, err := UnmarshalWithPassphrase(data, []byte("wrong")); err != ErrIncorrectPassphrase {
+			t.Errorf("UnmarshalWithPassphrase(%T) with a wrong passphrase returned %v", key, err)
+		}
+		got, err := UnmarshalWithPassphrase(data, passphrase)
+		if err != nil {
+			t.Fatalf("UnmarshalWithPassphrase(%T): %v", key, err)
+		}
+		if !reflect.DeepEqual(got, key) {
+			t.Errorf("%T does not round-trip: got %x, want %x", key, got, key)
+		}
+	}
+}
+
+func TestPassphraseBindsType(t *testing.T) {
+	var key X25519PrivateKey
+	data, err := MarshalWithPassphrase(key, []byte("pw"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	b, _ := pem.Decode(data)
+	b.Type = X25519PublicKeyType
+	if _, err := UnmarshalWithPassphrase(pem.EncodeToMemory(b), []byte("pw")); err != ErrIncorrectPassphrase {
+		t.Errorf("changing the block type was not detected: %v", err)
+	}
+}
+
+func TestRejects(t *testing.T) {
+	if _, err := Marshal("not a key"); err == nil {
+		t.Error("Marshal accepted an unregistered type")
+	}
+	if _, err := Marshal(ed25519.PublicKey{1, 2, 3}); err == nil {
+		t.Error("Marshal accepted a short Ed25519 public key")
+	}
+	if _, err := Unmarshal([]byte("not PEM")); err == nil {
+		t.Error("Unmarshal accepted non-PEM data")
+	}
+	unknown := pem.EncodeToMemory(&pem.Block{Type: "RSA PRIVATE KEY", Bytes: []byte{1}})
+	if _, err := Unmarshal(unknown); err == nil {
+		t.Error("Unmarshal accepted an unknown block type")
+	}
+	short := pem.EncodeToMemory(&pem.Block{Type: Ed25519PrivateKeyType, Bytes: []byte{1}})
+	if _, err := Unmarshal(short); err == nil {
+		t.Error("Unmarshal accepted a short Ed25519 seed")
+	}
+
+	var key X25519PrivateKey
+	data, err := MarshalWithPassphrase(key, []byte("pw"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, params := range []string{"t=0,m=8,p=1", "t=1,m=8", "m=8,t=1,p=1", "t=1,m=1099511627776,p=1", "t=1,m=8,p=256", "t=65,m=8,p=1", "t=4294967295,m=8,p=1", "t=1,m=4194305,p=1"} {
+		b, _ := pem.Decode(data)
+		b.Headers[headerKDFParams] = params
+		if _, err := UnmarshalWithPassphrase(pem.EncodeToMemory(b), []byte("pw")); err == nil || err == ErrIncorrectPassphrase {
+			t.Errorf("KDF parameters %q were not rejected as malformed: %v", params, err)
+		}
+	}
+}
+
+func TestRegister(t *testing.T) {
+	type testKey string
+	Register("TEST KEY", testKey(""),
+		func(key interface{}) ([]byte, error) { return []byte(key.(testKey)), nil },
+		func(data []byte) (interface{}, error) { return testKey(data), nil })
+
+	data, err := Marshal(testKey("hello"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.HasPrefix(string(data), "-----BEGIN TEST KEY-----") {
+		t.Errorf("unexpected encoding %q", data)
+	}
+	got, err := Unmarshal(data)
+	if err != nil || got != testKey("hello") {
+		t.Errorf("Unmarshal = %v, %v", got, err)
+	}
+
+	defer func() {
+		if recover() == nil {
+			t.Error("registering a block type twice did not panic")
+		}
+	}()
+	Register(Ed25519PublicKeyType, struct{}{}, nil, nil)
+}
@@ -0,0 +1,88 @@
+// Copyright 2018 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package keypem
+
+import (
+	"errors"
+
+	"golang.org/x/crypto/ed25519"
+)
+
+// X25519PrivateKey is a private key for use with
+// golang.org/x/crypto/curve25519.ScalarMult.
+type X25519PrivateKey [32]byte
+
+// X25519PublicKey is a public key for use with
+// golang.org/x/crypto/curve25519.ScalarMult.
+type X25519PublicKey [32]byte
+
+// The PEM block types of the built-in key types.
+const (
+	Ed25519PrivateKeyType = "ED25519 PRIVATE KEY"
+	Ed25519PublicKeyType  = "ED25519 PUBLIC KEY"
+	X25519PrivateKeyType  = "X25519 PRIVATE KEY"
+	X25519PublicKeyType   = "X25519 PUBLIC KEY"
+)
+
+var errBadLength = errors.New("keypem: key has the wrong length")
+
+func init() {
+	// Ed25519 private keys are stored as their 32-byte seed.
+	Register(Ed25519PrivateKeyType, ed25519.PrivateKey(nil),
+		func(key interface{}) ([]byte, error) {
+			k := key.(ed25519.PrivateKey)
+			if len(k) != ed25519.PrivateKeySize {
+				return nil, errBadLength
+			}
+			return k.Seed(), nil
+		},
+		func(data []byte) (interface{}, error) {
+			if len(data) != ed25519.SeedSize {
+				return nil, errBadLength
+			}
+			return ed25519.NewKeyFromSeed(data), nil
+		})
+	Register(Ed25519PublicKeyType, ed25519.PublicKey(nil),
+		func(key interface{}) ([]byte, error) {
+			k := key.(ed25519.PublicKey)
+			if len(k) != ed25519.PublicKeySize {
+				return nil, errBadLength
+			}
+			return append([]byte{}, k...), nil
+		},
+		func(data []byte) (interface{}, error) {
+			if len(data) != ed25519.PublicKeySize {
+				return nil, errBadLength
+			}
+			return ed25519.PublicKey(append([]byte{}, data...)), nil
+		})
+
+	Register(X25519PrivateKeyType, X25519PrivateKey{},
+		func(key interface{}) ([]byte, error) {
+			k := key.(X25519PrivateKey)
+			return k[:], nil
+		},
+		func(data []byte) (interface{}, error) {
+			var k X25519PrivateKey
+			if len(data) != len(k) {
+				return nil, errBadLength
+			}
+			copy(k[:], data)
+			return k, nil
+		})
+	Register(X25519PublicKeyType, X25519PublicKey{},
+		func(key interface{}) ([]byte, error) {
+			k := key.(X25519PublicKey)
+			return k[:], nil
+		},
+		func(data []byte) (interface{}, error) {
+			var k X25519PublicKey
+			if len(data) != len(k) {
+				return nil, errBadLength
+			}
+			copy(k[:], data)
+			return k, nil
+		})
+}
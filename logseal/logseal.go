@@ -0,0 +1,276 @@
+// Copyright 2018 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+// Package logseal authenticates append-only logs with forward-secure MACs, so
+// that an attacker who takes over the host writing a log cannot alter,
+// reorder or remove the entries written before the compromise without being
+// detected.
+//
+// The host seals each entry with a Sealer, which tags it with a MAC under the
+// current key and then replaces the key by a one-way function of itself,
+// erasing the previous one. The keys of past entries therefore cannot be
+// recovered from the state of the host. Each tag also covers the previous
+// one, chaining the entries, so that entries cannot be removed or reordered.
+//
+// The initial key is shared with the Verifier, which is kept off the host,
+// and which recomputes the keys to check the entries in order. Removing the
+// last entries of a log, however, leaves a valid chain. To detect it, the
+// Sealer produces epoch proofs, which commit to the number of entries sealed
+// so far and to the chain, and which should regularly be sent to a remote
+// system, such as a log collector. A log must then extend every epoch proof
+// issued for it.
+//
+// The scheme is the one of Schneier and Kelsey, "Secure Audit Logs to Support
+// Computer Forensics", with HMAC-SHA256 as both the MAC and the one-way
+// function.
+package logseal // import "golang.org/x/crypto/logseal"
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/binary"
+	"errors"
+	"fmt"
+)
+
+const (
+	// KeySize is the size, in bytes, of initial keys.
+	KeySize = 32
+	// TagSize is the size, in bytes, of the tags of entries.
+	TagSize = sha256.Size
+	// EpochProofSize is the size, in bytes, of encoded epoch proofs.
+	EpochProofSize = 8 + 2*sha256.Size
+	// sealerStateSize is the size, in bytes, of encoded Sealer states.
+	sealerStateSize = 1 + 8 + KeySize + TagSize
+)
+
+// Labels that separate the uses of the keys.
+const (
+	labelEntry   = "golang.org/x/crypto/logseal entry"
+	labelRatchet = "golang.org/x/crypto/logseal ratchet"
+	labelEpoch   = "golang.org/x/crypto/logseal epoch"
+)
+
+const stateVersion = 1
+
+var (
+	// ErrInvalidTag is returned by Verifier.Verify when an entry does not
+	// match its tag, because it, or an entry before it, was modified,
+	// inserted, removed or moved.
+	ErrInvalidTag = errors.New("logseal: invalid tag")
+	// ErrInvalidEpochProof is returned by Verifier.VerifyEpochProof when the
+	// log verified so far does not match the epoch proof.
+	ErrInvalidEpochProof = errors.New("logseal: log does not match epoch proof")
+
+	errKeySize    = errors.New("logseal: bad initial key size")
+	errBadState   = errors.New("logseal: malformed Sealer state")
+	errBadProof   = errors.New("logseal: malformed epoch proof")
+	errTagSize    = errors.New("logseal: bad tag size")
+	errExhausted  = errors.New("logseal: sequence number exhausted")
+	errProofEarly = errors.New("logseal: epoch proof is for more entries than were verified")
+)
+
+// chain holds the state shared by Sealer and Verifier: the key of the next
+// entry, its sequence number, and the tag of the previous entry.
+type chain struct {
+	key  [KeySize]byte
+	seq  uint64
+	last [TagSize]byte
+}
+
+func (c *chain) mac(label string, data ...[]byte) []byte {
+	m := hmac.New(sha256.New, c.key[:])
+	var n [8]byte
+	binary.BigEndian.PutUint64(n[:], c.seq)
+	m.Write([]byte(label))
+	m.Write(n[:])
+	m.Write(c.last[:])
+	for _, d := range data {
+		m.Write(d)
+	}
+	return m.Sum(nil)
+}
+
+// tag returns the tag of entry as the next entry.
+func (c *chain) tag(entry []byte) []byte {
+	return c.mac(labelEntry, entry)
+}
+
+// advance moves to the next entry, whose previous tag is tag, erasing the
+// current key.
+func (c *chain) advance(tag []byte) {
+	m := hmac.New(sha256.New, c.key[:])
+	m.Write([]byte(labelRatchet))
+	m.Sum(c.key[:0])
+	copy(c.last[:], tag)
+	c.seq++
+}
+
+// epochTag returns the tag of the epoch proof for the entries so far.
+func (c *chain) epochTag() []byte {
+	return c.mac(labelEpoch)
+}
+
+// A Sealer seals the entries of a log. It is not safe for concurrent use.
+type Sealer struct {
+	c chain
+}
+
+// NewSealer returns a Sealer for a new log, starting with initialKey, which
+// must be KeySize random bytes. The caller must give initialKey to the
+// Verifier and erase it from the host, as anyone who knows it can forge the
+// whole log.
+func NewSealer(initialKey []byte) (*Sealer, error) {
+	if len(initialKey) != KeySize {
+		return nil, errKeySize
+	}
+	s := new(Sealer)
+	copy(s.c.key[:], initialKey)
+	return s, nil
+}
+
+// Seal returns the tag of entry, which is the Seq()-th entry of the log, and
+// moves to the next one. The tag must be stored with the entry.
+func (s *Sealer) Seal(entry []byte) ([]byte, error) {
+	if s.c.seq == 1<<64-1 {
+		return nil, errExhausted
+	}
+	tag := s.c.tag(entry)
+	s.c.advance(tag)
+	return tag, nil
+}
+
+// Seq returns the number of entries sealed so far.
+func (s *Sealer) Seq() uint64 {
+	return s.c.seq
+}
+
+// EpochProof returns an epoch proof for the entries sealed so far. It should
+// be sent to a remote system as soon as it is produced, as a compromised host
+// can produce epoch proofs for any log it forges after the compromise.
+func (s *Sealer) EpochProof() *EpochProof {
+	p := &EpochProof{Entries: s.c.seq}
+	copy(p.Chain[:], s.c.last[:])
+	copy(p.Tag[:], s.c.epochTag())
+	return p
+}
+
+// MarshalBinary encodes the state of s, so that a log can be resumed after a
+// restart with UnmarshalBinary. The state includes the current key, and must
+// be stored as securely as the log; it reveals nothing about past keys.
+func (s *Sealer) MarshalBinary() ([]byte, error) {
+	b := make([]byte, 0, sealerStateSize)
+	b = append(b, stateVersion)
+	var n [8]byte
+	binary.BigEndian.PutUint64(n[:], s.c.seq)
+	b = append(b, n[:]...)
+	b = append(b, s.c.key[:]...)
+	return append(b, s.c.last[:]...), nil
+}
+
+// UnmarshalBinary restores the state of s from the output of MarshalBinary.
+func (s *Sealer) UnmarshalBinary(b []byte) error {
+	if len(b) != sealerStateSize || b[0] != stateVersion {
+		return errBadState
+	}
+	b = b[1:]
+	s.c.seq = binary.BigEndian.Uint64(b)
+	copy(s.c.key[:], b[8:])
+	copy(s.c.last[:], b[8+KeySize:])
+	return nil
+}
+
+// An EpochProof commits to the first Entries entries of a log.
+type EpochProof struct {
+	// Entries is the number of entries sealed before the proof.
+	Entries uint64
+	// Chain is the tag of the last of those entries, or zero if there are
+	// none.
+	Chain [TagSize]byte
+	// Tag authenticates the proof with the key of the next entry.
+	Tag [TagSize]byte
+}
+
+// MarshalBinary encodes p in EpochProofSize bytes.
+func (p *EpochProof) MarshalBinary() ([]byte, error) {
+	b := make([]byte, 8, EpochProofSize)
+	binary.BigEndian.PutUint64(b, p.Entries)
+	b = append(b, p.Chain[:]...)
+	return append(b, p.Tag[:]...), nil
+}
+
+// UnmarshalBinary decodes an epoch proof encoded by MarshalBinary.
+func (p *EpochProof) UnmarshalBinary(b []byte) error {
+	if len(b) != EpochProofSize {
+		return errBadProof
+	}
+	p.Entries = binary.BigEndian.Uint64(b)
+	copy(p.Chain[:], b[8:])
+	copy(p.Tag[:], b[8+TagSize:])
+	return nil
+}
+
+// A Verifier checks the entries of a log in order, starting from its initial
+// key. It is not safe for concurrent use.
+type Verifier struct {
+	c chain
+}
+
+// NewVerifier returns a Verifier for the log sealed by NewSealer with
+// initialKey.
+func NewVerifier(initialKey []byte) (*Verifier, error) {
+	if len(initialKey) != KeySize {
+		return nil, errKeySize
+	}
+	v := new(Verifier)
+	copy(v.c.key[:], initialKey)
+	return v, nil
+}
+
+// Verify checks that tag is the tag of entry as the Seq()-th entry of the
+// log, and moves to the next entry if it is. Otherwise, it returns an
+// *EntryError whose Err is ErrInvalidTag, and the Verifier is unchanged.
+func (v *Verifier) Verify(entry, tag []byte) error {
+	if len(tag) != TagSize {
+		return errTagSize
+	}
+	if !hmac.Equal(v.c.tag(entry), tag) {
+		return &EntryError{Seq: v.c.seq, Err: ErrInvalidTag}
+	}
+	v.c.advance(tag)
+	return nil
+}
+
+// Seq returns the number of entries verified so far.
+func (v *Verifier) Seq() uint64 {
+	return v.c.seq
+}
+
+// VerifyEpochProof checks that the entries verified so far are the ones
+// committed to by p. As the key of the proof is erased by the next call to
+// Verify, it must be called when Seq() is p.Entries. If Seq() is still lower
+// than p.Entries once the whole log was verified, the log was truncated.
+func (v *Verifier) VerifyEpochProof(p *EpochProof) error {
+	switch {
+	case p.Entries > v.c.seq:
+		return errProofEarly
+	case p.Entries < v.c.seq:
+		return fmt.Errorf("logseal: epoch proof for %d entries checked after %d entries", p.Entries, v.c.seq)
+	}
+	if !hmac.Equal(p.Chain[:], v.c.last[:]) || !hmac.Equal(p.Tag[:], v.c.epochTag()) {
+		return ErrInvalidEpochProof
+	}
+	return nil
+}
+
+// An EntryError records the sequence number of the entry that failed
+// verification.
+type EntryError struct {
+	Seq uint64
+	Err error
+}
+
+func (e *EntryError) Error() string {
+	return fmt.Sprintf("logseal: entry %d: %v", e.Seq, e.Err)
+}
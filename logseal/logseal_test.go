@@ -0,0 +1,220 @@
+// Copyright 2018 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package logseal
+
+import (
+	"bytes"
+	"fmt"
+	"testing"
+)
+
+var testKey = bytes.Repeat([]byte{0x42}, KeySize)
+
+func sealLog(t *testing.T, n int) (entries, tags [][]byte, s *Sealer) {
+	s, err := NewSealer(testKey)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for i := 0; i < n; i++ {
+		entry := []byte(fmt.Sprintf("entry %d", i))
+		tag, err := s.Seal(entry)
+		if err != nil {
+			t.Fatal(err)
+		}
+		entries = append(entries, entry)
+		tags = append(tags, tag)
+	}
+	return entries, tags, s
+}
+
+// verifyLog returns the sequence number of the first entry that fails to
+// verify, or len(entries) if they all verify.
+func verifyLog(t *testing.T, entries, tags [][]byte) int {
+	v, err := NewVerifier(testKey)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for i := range entries {
+		if err := v.Verify(entries[i], tags[i]); err != nil {
+			e, ok := err.(*EntryError)
+			if !ok || e.Err != ErrInvalidTag || e.Seq != uint64(i) {
+				t.Fatalf("entry %d: unexpected error %v", i, err)
+			}
+			return i
+		}
+	}
+	return len(entries)
+}
+
+func TestSealVerify(t *testing.T) {
+	entries, tags, s := sealLog(t, 10)
+	if s.Seq() != 10 {
+		t.Errorf("Seq() = %d, want 10", s.Seq())
+	}
+	if n := verifyLog(t, entries, tags); n != 10 {
+		t.Errorf("valid log failed to verify at entry %d", n)
+	}
+	for i := 1; i < len(tags); i++ {
+		if bytes.Equal(tags[i], tags[i-1]) {
+			t.Errorf("entries %d and %d have the same tag", i-1, i)
+		}
+	}
+}
+
+func TestTampering(t *testing.T) {
+	entries, tags, _ := sealLog(t, 10)
+	copyLog := func() ([][]byte, [][]byte) {
+		return append([][]byte(nil), entries...), append([][]byte(nil), tags...)
+	}
+
+	e, g := copyLog()
+	e[3] = []byte("entry 3, modified")
+	if n := verifyLog(t, e, g); n != 3 {
+		t.Errorf("modified entry: failed at %d, want 3", n)
+	}
+
+	e, g = copyLog()
+	e = append(e[:4], e[5:]...)
+	g = append(g[:4], g[5:]...)
+	if n := verifyLog(t, e, g); n != 4 {
+		t.Errorf("removed entry: failed at %d, want 4", n)
+	}
+
+	e, g = copyLog()
+	e[5], e[6] = e[6], e[5]
+	g[5], g[6] = g[6], g[5]
+	if n := verifyLog(t, e, g); n != 5 {
+		t.Errorf("swapped entries: failed at %d, want 5", n)
+	}
+
+	// An attacker who steals the state of the sealer after entry 6 can
+	// append entries, but not rewrite entry 6 or any before it.
+	_, _, s := sealLog(t, 7)
+	tag, err := s.Seal([]byte("entry 6, forged"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	e, g = copyLog()
+	e[6], g[6] = []byte("entry 6, forged"), tag
+	if n := verifyLog(t, e, g); n != 6 {
+		t.Errorf("forged entry: failed at %d, want 6", n)
+	}
+
+	v, _ := NewVerifier(testKey)
+	if err := v.Verify(entries[0], tags[0][:TagSize-1]); err == nil {
+		t.Error("short tag was accepted")
+	}
+	if v.Seq() != 0 {
+		t.Error("failed verification advanced the Verifier")
+	}
+}
+
+func TestEpochProofs(t *testing.T) {
+	s, _ := NewSealer(testKey)
+	var entries, tags [][]byte
+	proofs := make(map[uint64]*EpochProof)
+	for i := 0; i < 12; i++ {
+		if i%4 == 0 {
+			p := s.EpochProof()
+			if p.Entries != uint64(i) {
+				t.Fatalf("proof for %d entries, want %d", p.Entries, i)
+			}
+			b, _ := p.MarshalBinary()
+			if len(b) != EpochProofSize {
+				t.Fatalf("encoded proof is %d bytes, want %d", len(b), EpochProofSize)
+			}
+			p = new(EpochProof)
+			if err := p.UnmarshalBinary(b); err != nil {
+				t.Fatal(err)
+			}
+			proofs[p.Entries] = p
+		}
+		entry := []byte(fmt.Sprintf("entry %d", i))
+		tag, _ := s.Seal(entry)
+		entries, tags = append(entries, entry), append(tags, tag)
+	}
+	last := s.EpochProof()
+
+	v, _ := NewVerifier(testKey)
+	for i := range entries {
+		if p := proofs[uint64(i)]; p != nil {
+			if err := v.VerifyEpochProof(p); err != nil {
+				t.Errorf("proof for %d entries: %v", i, err)
+			}
+		}
+		if err := v.VerifyEpochProof(last); err == nil {
+			t.Errorf("proof for %d entries accepted after %d entries", last.Entries, i)
+		}
+		if err := v.Verify(entries[i], tags[i]); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := v.VerifyEpochProof(last); err != nil {
+		t.Errorf("final proof: %v", err)
+	}
+	if err := v.VerifyEpochProof(proofs[8]); err == nil {
+		t.Error("stale proof was accepted")
+	}
+
+	// A proof from another log with the same number of entries is rejected.
+	forged := *last
+	forged.Chain[0] ^= 1
+	if err := v.VerifyEpochProof(&forged); err != ErrInvalidEpochProof {
+		t.Errorf("proof with a modified chain: got %v, want ErrInvalidEpochProof", err)
+	}
+	forged = *last
+	forged.Tag[0] ^= 1
+	if err := v.VerifyEpochProof(&forged); err != ErrInvalidEpochProof {
+		t.Errorf("proof with a modified tag: got %v, want ErrInvalidEpochProof", err)
+	}
+
+	if err := new(EpochProof).UnmarshalBinary(make([]byte, EpochProofSize-1)); err == nil {
+		t.Error("short proof was decoded")
+	}
+}
+
+func TestSealerState(t *testing.T) {
+	entries, tags, s := sealLog(t, 5)
+	state, err := s.MarshalBinary()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if bytes.Contains(state, testKey) {
+		t.Error("state contains the initial key")
+	}
+
+	resumed := new(Sealer)
+	if err := resumed.UnmarshalBinary(state); err != nil {
+		t.Fatal(err)
+	}
+	if resumed.Seq() != 5 {
+		t.Errorf("resumed Seq() = %d, want 5", resumed.Seq())
+	}
+	for i := 5; i < 8; i++ {
+		entry := []byte(fmt.Sprintf("entry %d", i))
+		tag, _ := resumed.Seal(entry)
+		entries, tags = append(entries, entry), append(tags, tag)
+	}
+	if n := verifyLog(t, entries, tags); n != 8 {
+		t.Errorf("resumed log failed to verify at entry %d", n)
+	}
+
+	state[0] = 2
+	if err := resumed.UnmarshalBinary(state); err == nil {
+		t.Error("state with an unknown version was decoded")
+	}
+	if err := resumed.UnmarshalBinary(state[:len(state)-1]); err == nil {
+		t.Error("short state was decoded")
+	}
+}
+
+func TestKeySize(t *testing.T) {
+	if _, err := NewSealer(testKey[1:]); err == nil {
+		t.Error("NewSealer accepted a short key")
+	}
+	if _, err := NewVerifier(append(testKey, 0)); err == nil {
+		t.Error("NewVerifier accepted a long key")
+	}
+}
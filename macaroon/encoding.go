@@ -0,0 +1,129 @@
+// Copyright 2018 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package macaroon
+
+import (
+	"encoding/binary"
+	"errors"
+)
+
+// The binary format is version 2 of libmacaroons:
+//
+//	macaroon = version [location] identifier eos *caveat eos signature
+//	caveat   = [location] identifier [verification-id] eos
+//
+// where every element but the version and eos is a field: a type byte, the
+// length of the data as a uvarint, and the data.
+const formatVersion = 2
+
+// Field types.
+const (
+	fieldEOS            = 0
+	fieldLocation       = 1
+	fieldIdentifier     = 2
+	fieldVerificationID = 4
+	fieldSignature      = 6
+)
+
+var errMalformed = errors.New("macaroon: malformed binary encoding")
+
+func appendField(b []byte, typ byte, data []byte) []byte {
+	var n [binary.MaxVarintLen64]byte
+	b = append(b, typ)
+	b = append(b, n[:binary.PutUvarint(n[:], uint64(len(data)))]...)
+	return append(b, data...)
+}
+
+// MarshalBinary encodes m in the binary format of libmacaroons, version 2.
+func (m *Macaroon) MarshalBinary() ([]byte, error) {
+	b := []byte{formatVersion}
+	if m.location != "" {
+		b = appendField(b, fieldLocation, []byte(m.location))
+	}
+	b = appendField(b, fieldIdentifier, m.id)
+	b = append(b, fieldEOS)
+	for _, c := range m.caveats {
+		if c.Location != "" {
+			b = appendField(b, fieldLocation, []byte(c.Location))
+		}
+		b = appendField(b, fieldIdentifier, c.ID)
+		if c.VerificationID != nil {
+			b = appendField(b, fieldVerificationID, c.VerificationID)
+		}
+		b = append(b, fieldEOS)
+	}
+	b = append(b, fieldEOS)
+	return appendField(b, fieldSignature, m.sig[:]), nil
+}
+
+// decoder reads the fields of an encoded macaroon.
+type decoder struct {
+	b   []byte
+	err error
+}
+
+// field reads the next field if it has type typ, and returns its data and
+// true, or returns false otherwise.
+func (d *decoder) field(typ byte) ([]byte, bool) {
+	if d.err != nil || len(d.b) == 0 || d.b[0] != typ {
+		return nil, false
+	}
+	n, l := binary.Uvarint(d.b[1:])
+	if l <= 0 || n > uint64(len(d.b)-1-l) {
+		d.err = errMalformed
+		return nil, false
+	}
+	data := d.b[1+l : 1+l+int(n)]
+	d.b = d.b[1+l+int(n):]
+	return append([]byte(nil), data...), true
+}
+
+// eos reads an end of section, and reports whether there was one.
+func (d *decoder) eos() bool {
+	if d.err != nil || len(d.b) == 0 || d.b[0] != fieldEOS {
+		return false
+	}
+	d.b = d.b[1:]
+	return true
+}
+
+// UnmarshalBinary decodes a macaroon encoded by MarshalBinary, or by
+// libmacaroons in its version 2 binary format.
+func (m *Macaroon) UnmarshalBinary(b []byte) error {
+	if len(b) == 0 || b[0] != formatVersion {
+		return errMalformed
+	}
+	d := &decoder{b: b[1:]}
+	var m1 Macaroon
+	if loc, ok := d.field(fieldLocation); ok {
+		m1.location = string(loc)
+	}
+	id, ok := d.field(fieldIdentifier)
+	if !ok || !d.eos() {
+		return errMalformed
+	}
+	m1.id = id
+	for !d.eos() {
+		var c Caveat
+		if loc, ok := d.field(fieldLocation); ok {
+			c.Location = string(loc)
+		}
+		if c.ID, ok = d.field(fieldIdentifier); !ok {
+			return errMalformed
+		}
+		c.VerificationID, _ = d.field(fieldVerificationID)
+		if !d.eos() {
+			return errMalformed
+		}
+		m1.caveats = append(m1.caveats, c)
+	}
+	sig, ok := d.field(fieldSignature)
+	if !ok || len(sig) != SignatureSize || len(d.b) != 0 {
+		return errMalformed
+	}
+	copy(m1.sig[:], sig)
+	*m = m1
+	return nil
+}
@@ -0,0 +1,308 @@
+// Copyright 2018 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+// Package macaroon implements macaroons, bearer credentials that their holder
+// can attenuate, and whose verification can be delegated to third parties,
+// as described in "Macaroons: Cookies with Contextual Caveats for
+// Decentralized Authorization in the Cloud" by Birgisson et al.
+//
+// A macaroon is minted by a target service from a root key that only it
+// knows, and an identifier from which it can find that key. Anyone holding
+// the macaroon can add caveats to it, which restrict the requests it
+// authorizes, but cannot remove them: the signature of a macaroon is an HMAC
+// chain, in which each caveat is authenticated with the previous signature.
+//
+// First-party caveats are conditions, such as "time < 2019-01-01T00:00",
+// checked by the target service with a Checker when the macaroon is
+// verified. Third-party caveats require a discharge macaroon, minted by
+// another service, such as an identity provider, once it checked the caveat.
+// Discharge macaroons must be bound to the macaroon they discharge with Bind
+// before being sent along with it, so that they cannot be reused with
+// other macaroons.
+//
+// The cryptography, and the binary format of MarshalBinary, are those of
+// libmacaroons, so that macaroons are interoperable with other
+// implementations.
+package macaroon // import "golang.org/x/crypto/macaroon"
+
+import (
+	"bytes"
+	"crypto/hmac"
+	cryptorand "crypto/rand"
+	"crypto/sha256"
+	"errors"
+	"fmt"
+	"io"
+
+	"golang.org/x/crypto/nacl/secretbox"
+)
+
+// SignatureSize is the size, in bytes, of the signatures of macaroons.
+const SignatureSize = sha256.Size
+
+// keyGenerator is the HMAC key with which root and caveat keys are derived,
+// as in libmacaroons.
+const keyGenerator = "macaroons-key-generator"
+
+const nonceSize = 24
+
+var (
+	// ErrInvalidSignature is returned by Verify if the signature of the
+	// macaroon, or of one of its discharges, is invalid.
+	ErrInvalidSignature = errors.New("macaroon: invalid signature")
+
+	errMissingDischarge = errors.New("macaroon: no discharge for third-party caveat")
+	errDischargeReused  = errors.New("macaroon: discharge used for several caveats")
+	errBadVerification  = errors.New("macaroon: malformed third-party caveat verification ID")
+)
+
+// A Caveat is a restriction on the requests authorized by a macaroon.
+type Caveat struct {
+	// ID is the condition of a first-party caveat, or the identifier of a
+	// third-party caveat, from which the third party can find the caveat
+	// key and the condition to check.
+	ID []byte
+	// VerificationID is the caveat key of a third-party caveat, encrypted
+	// with the signature that preceded it. It is nil for first-party
+	// caveats.
+	VerificationID []byte
+	// Location is an optional hint of the third party to which the caveat
+	// should be discharged. It is not authenticated.
+	Location string
+}
+
+// IsThirdParty reports whether c is a third-party caveat.
+func (c *Caveat) IsThirdParty() bool {
+	return c.VerificationID != nil
+}
+
+// A Macaroon is a bearer credential with caveats. Its methods that add
+// caveats modify it in place, and it must not be modified concurrently.
+type Macaroon struct {
+	location string
+	id       []byte
+	caveats  []Caveat
+	sig      [SignatureSize]byte
+}
+
+// New returns a macaroon without caveats minted with rootKey. id identifies
+// the macaroon to the service that minted it, which must be able to find
+// rootKey from it, and location is an optional, unauthenticated, hint of
+// that service.
+func New(rootKey, id []byte, location string) *Macaroon {
+	m := &Macaroon{
+		location: location,
+		id:       append([]byte(nil), id...),
+	}
+	k := deriveKey(rootKey)
+	copy(m.sig[:], keyedHash(k[:], id))
+	return m
+}
+
+// deriveKey returns the HMAC key of a macaroon or of a third-party caveat
+// from the key chosen by its minter, which may have any length.
+func deriveKey(key []byte) *[32]byte {
+	var k [32]byte
+	copy(k[:], keyedHash([]byte(keyGenerator), key))
+	return &k
+}
+
+func keyedHash(key, data []byte) []byte {
+	h := hmac.New(sha256.New, key)
+	h.Write(data)
+	return h.Sum(nil)
+}
+
+// keyedHash2 authenticates a and b together, as in libmacaroons.
+func keyedHash2(key, a, b []byte) []byte {
+	h := hmac.New(sha256.New, key)
+	h.Write(keyedHash(key, a))
+	h.Write(keyedHash(key, b))
+	return h.Sum(nil)
+}
+
+// Location returns the location of m.
+func (m *Macaroon) Location() string {
+	return m.location
+}
+
+// ID returns the identifier of m.
+func (m *Macaroon) ID() []byte {
+	return append([]byte(nil), m.id...)
+}
+
+// Caveats returns the caveats of m, in the order in which they were added.
+// They must not be modified.
+func (m *Macaroon) Caveats() []Caveat {
+	return m.caveats
+}
+
+// Signature returns the signature of m.
+func (m *Macaroon) Signature() []byte {
+	return append([]byte(nil), m.sig[:]...)
+}
+
+// Clone returns a copy of m, to which caveats can be added without changing m.
+func (m *Macaroon) Clone() *Macaroon {
+	m1 := *m
+	m1.caveats = append([]Caveat(nil), m.caveats...)
+	return &m1
+}
+
+// AddFirstPartyCaveat restricts m with condition, which the target service
+// checks with its Checker.
+func (m *Macaroon) AddFirstPartyCaveat(condition []byte) {
+	m.caveats = append(m.caveats, Caveat{ID: append([]byte(nil), condition...)})
+	copy(m.sig[:], keyedHash(m.sig[:], condition))
+}
+
+// AddThirdPartyCaveat restricts m with a caveat to be discharged by a third
+// party. caveatKey is a random key that the third party must be able to
+// recover from caveatID, for example because caveatID holds it encrypted to
+// the third party, along with the condition to check. The third party then
+// mints the discharge macaroon with New(caveatKey, caveatID, ...).
+func (m *Macaroon) AddThirdPartyCaveat(caveatKey, caveatID []byte, location string) error {
+	return m.addThirdPartyCaveat(cryptorand.Reader, caveatKey, caveatID, location)
+}
+
+func (m *Macaroon) addThirdPartyCaveat(rand io.Reader, caveatKey, caveatID []byte, location string) error {
+	var nonce [nonceSize]byte
+	if _, err := io.ReadFull(rand, nonce[:]); err != nil {
+		return err
+	}
+	k := deriveKey(caveatKey)
+	vid := secretbox.Seal(nonce[:], k[:], &nonce, &m.sig)
+	m.caveats = append(m.caveats, Caveat{
+		ID:             append([]byte(nil), caveatID...),
+		VerificationID: vid,
+		Location:       location,
+	})
+	copy(m.sig[:], keyedHash2(m.sig[:], vid, caveatID))
+	return nil
+}
+
+// Bind returns a copy of discharge bound to m, which is what must be sent
+// with m to discharge its third-party caveats. A bound discharge macaroon is
+// only valid with m.
+func (m *Macaroon) Bind(discharge *Macaroon) *Macaroon {
+	d := discharge.Clone()
+	copy(d.sig[:], bindSignature(&m.sig, &discharge.sig))
+	return d
+}
+
+func bindSignature(root, discharge *[SignatureSize]byte) []byte {
+	var zero [32]byte
+	return keyedHash2(zero[:], root[:], discharge[:])
+}
+
+// A Checker decides whether the first-party caveats of macaroons hold for a
+// request.
+type Checker interface {
+	// CheckCaveat returns nil if condition holds, and an error explaining
+	// why otherwise. Conditions that it does not recognize must fail.
+	CheckCaveat(condition []byte) error
+}
+
+// CheckerFunc is a function that implements Checker.
+type CheckerFunc func(condition []byte) error
+
+// CheckCaveat returns f(condition).
+func (f CheckerFunc) CheckCaveat(condition []byte) error {
+	return f(condition)
+}
+
+// A CaveatError is returned by Verify when a first-party caveat does not
+// hold.
+type CaveatError struct {
+	Condition []byte
+	Err       error
+}
+
+func (e *CaveatError) Error() string {
+	return fmt.Sprintf("macaroon: caveat %q not satisfied: %v", e.Condition, e.Err)
+}
+
+// Verify checks that m was minted with rootKey, that check accepts the
+// first-party caveats of m and of its discharges, and that discharges, bound
+// to m with Bind, discharge its third-party caveats. Discharges can
+// themselves have third-party caveats, discharged by other elements of
+// discharges. It returns nil if m authorizes the request.
+func (m *Macaroon) Verify(rootKey []byte, check Checker, discharges []*Macaroon) error {
+	v := &verifier{
+		root:       &m.sig,
+		check:      check,
+		discharges: discharges,
+		used:       make([]bool, len(discharges)),
+	}
+	return v.verify(m, deriveKey(rootKey), true)
+}
+
+type verifier struct {
+	root       *[SignatureSize]byte
+	check      Checker
+	discharges []*Macaroon
+	used       []bool
+}
+
+func (v *verifier) verify(m *Macaroon, key *[32]byte, isRoot bool) error {
+	sig := keyedHash(key[:], m.id)
+	for i := range m.caveats {
+		c := &m.caveats[i]
+		if !c.IsThirdParty() {
+			if err := v.check.CheckCaveat(c.ID); err != nil {
+				return &CaveatError{Condition: c.ID, Err: err}
+			}
+			sig = keyedHash(sig, c.ID)
+			continue
+		}
+
+		if len(c.VerificationID) < nonceSize {
+			return errBadVerification
+		}
+		var nonce [nonceSize]byte
+		var sigKey [32]byte
+		copy(nonce[:], c.VerificationID)
+		copy(sigKey[:], sig)
+		caveatKey, ok := secretbox.Open(nil, c.VerificationID[nonceSize:], &nonce, &sigKey)
+		if !ok || len(caveatKey) != 32 {
+			return ErrInvalidSignature
+		}
+		d, err := v.discharge(c.ID)
+		if err != nil {
+			return err
+		}
+		var k [32]byte
+		copy(k[:], caveatKey)
+		if err := v.verify(d, &k, false); err != nil {
+			return err
+		}
+		sig = keyedHash2(sig, c.VerificationID, c.ID)
+	}
+
+	var final [SignatureSize]byte
+	copy(final[:], sig)
+	if !isRoot {
+		copy(final[:], bindSignature(v.root, &final))
+	}
+	if !hmac.Equal(final[:], m.sig[:]) {
+		return ErrInvalidSignature
+	}
+	return nil
+}
+
+// discharge returns the discharge macaroon of the caveat with the given id,
+// and marks it as used, so that discharges cannot form a cycle.
+func (v *verifier) discharge(id []byte) (*Macaroon, error) {
+	for i, d := range v.discharges {
+		if !bytes.Equal(d.id, id) {
+			continue
+		}
+		if v.used[i] {
+			return nil, errDischargeReused
+		}
+		v.used[i] = true
+		return d, nil
+	}
+	return nil, errMissingDischarge
+}
@@ -0,0 +1,219 @@
+// Copyright 2018 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package macaroon
+
+import (
+	"bytes"
+	"encoding/hex"
+	"errors"
+	"testing"
+)
+
+// TestLibmacaroonsVectors checks the signatures of the example of the
+// libmacaroons README.
+func TestLibmacaroonsVectors(t *testing.T) {
+	m := New([]byte("this is our super secret key; only we should know it"),
+		[]byte("we used our secret key"), "http://mybank/")
+	for _, step := range []struct {
+		caveat string
+		sig    string
+	}{
+		{"", "e3d9e02908526c4c0039ae15114115d97fdd68bf2ba379b342aaf0f617d0552f"},
+		{"account = 3735928559", "1efe4763f290dbce0c1d08477367e11f4eee456a64933cf662d79772dbb82128"},
+		{"time < 2020-01-01T00:00", "b5f06c8c8ef92f6c82c6ff282cd1f8bd1849301d09a2db634ba182536a611c49"},
+		{"email = alice@example.org", "ddf553e46083e55b8d71ab822be3d8fcf21d6bf19c40d617bb9fb438934474b6"},
+	} {
+		if step.caveat != "" {
+			m.AddFirstPartyCaveat([]byte(step.caveat))
+		}
+		if got := hex.EncodeToString(m.Signature()); got != step.sig {
+			t.Errorf("after caveat %q, signature is %s, want %s", step.caveat, got, step.sig)
+		}
+	}
+}
+
+// conditions is a Checker that accepts the conditions it holds.
+type conditions map[string]bool
+
+func (c conditions) CheckCaveat(condition []byte) error {
+	if !c[string(condition)] {
+		return errors.New("condition not met")
+	}
+	return nil
+}
+
+var rootKey = []byte("this is our super secret key; only we should know it")
+
+func TestFirstPartyCaveats(t *testing.T) {
+	m := New(rootKey, []byte("id"), "")
+	m.AddFirstPartyCaveat([]byte("account = 1"))
+	m.AddFirstPartyCaveat([]byte("op = read"))
+
+	ok := conditions{"account = 1": true, "op = read": true}
+	if err := m.Verify(rootKey, ok, nil); err != nil {
+		t.Fatalf("Verify: %v", err)
+	}
+
+	err := m.Verify(rootKey, conditions{"account = 1": true}, nil)
+	if ce, isCaveat := err.(*CaveatError); !isCaveat || string(ce.Condition) != "op = read" {
+		t.Errorf("Verify with an unmet caveat returned %v, want a *CaveatError", err)
+	}
+	if err := m.Verify([]byte("another key"), ok, nil); err != ErrInvalidSignature {
+		t.Errorf("Verify with the wrong key returned %v, want ErrInvalidSignature", err)
+	}
+
+	// Attenuating a clone does not change the original.
+	m1 := m.Clone()
+	m1.AddFirstPartyCaveat([]byte("time < 2019"))
+	if err := m.Verify(rootKey, ok, nil); err != nil {
+		t.Errorf("Verify after attenuating a clone: %v", err)
+	}
+	if err := m1.Verify(rootKey, ok, nil); err == nil {
+		t.Errorf("Verify accepted a clone with an unmet caveat")
+	}
+	err = m1.Verify(rootKey, CheckerFunc(func([]byte) error { return nil }), nil)
+	if err != nil {
+		t.Errorf("Verify with a CheckerFunc: %v", err)
+	}
+}
+
+func TestRemovedCaveat(t *testing.T) {
+	m := New(rootKey, []byte("id"), "")
+	m.AddFirstPartyCaveat([]byte("account = 1"))
+	m.AddFirstPartyCaveat([]byte("op = read"))
+	forged := m.Clone()
+	forged.caveats = forged.caveats[:1]
+	if err := forged.Verify(rootKey, conditions{"account = 1": true}, nil); err != ErrInvalidSignature {
+		t.Errorf("Verify of a macaroon without its last caveat returned %v, want ErrInvalidSignature", err)
+	}
+}
+
+func TestThirdPartyCaveats(t *testing.T) {
+	m := New(rootKey, []byte("id"), "https://service.example/")
+	m.AddFirstPartyCaveat([]byte("account = 1"))
+	caveatKey := []byte("caveat key shared with the third party")
+	if err := m.AddThirdPartyCaveat(caveatKey, []byte("user = alice"), "https://idp.example/"); err != nil {
+		t.Fatal(err)
+	}
+	if c := m.Caveats()[1]; !c.IsThirdParty() || c.Location != "https://idp.example/" {
+		t.Errorf("third-party caveat is %+v", c)
+	}
+
+	// The third party checks the caveat, then mints a discharge, which can
+	// have caveats of its own.
+	d := New(caveatKey, []byte("user = alice"), "https://idp.example/")
+	d.AddFirstPartyCaveat([]byte("time < 2019"))
+	bound := m.Bind(d)
+
+	ok := conditions{"account = 1": true, "time < 2019": true}
+	if err := m.Verify(rootKey, ok, []*Macaroon{bound}); err != nil {
+		t.Fatalf("Verify: %v", err)
+	}
+	if err := m.Verify(rootKey, ok, nil); err == nil {
+		t.Errorf("Verify accepted a macaroon without its discharge")
+	}
+	if err := m.Verify(rootKey, ok, []*Macaroon{d}); err != ErrInvalidSignature {
+		t.Errorf("Verify with an unbound discharge returned %v, want ErrInvalidSignature", err)
+	}
+	if err := m.Verify(rootKey, conditions{"account = 1": true}, []*Macaroon{bound}); err == nil {
+		t.Errorf("Verify accepted a discharge with an unmet caveat")
+	}
+
+	// A discharge bound to another macaroon is rejected.
+	other := New(rootKey, []byte("other id"), "")
+	if err := m.Verify(rootKey, ok, []*Macaroon{other.Bind(d)}); err != ErrInvalidSignature {
+		t.Errorf("Verify with a discharge bound to another macaroon returned %v, want ErrInvalidSignature", err)
+	}
+
+	// A discharge minted with another key is rejected.
+	wrong := New([]byte("wrong key"), []byte("user = alice"), "")
+	if err := m.Verify(rootKey, ok, []*Macaroon{m.Bind(wrong)}); err != ErrInvalidSignature {
+		t.Errorf("Verify with a forged discharge returned %v, want ErrInvalidSignature", err)
+	}
+}
+
+func TestNestedThirdPartyCaveats(t *testing.T) {
+	m := New(rootKey, []byte("id"), "")
+	key1, key2 := []byte("first caveat key"), []byte("second caveat key")
+	if err := m.AddThirdPartyCaveat(key1, []byte("caveat 1"), ""); err != nil {
+		t.Fatal(err)
+	}
+	d1 := New(key1, []byte("caveat 1"), "")
+	if err := d1.AddThirdPartyCaveat(key2, []byte("caveat 2"), ""); err != nil {
+		t.Fatal(err)
+	}
+	d2 := New(key2, []byte("caveat 2"), "")
+
+	// All discharges are bound to the macaroon being verified.
+	discharges := []*Macaroon{m.Bind(d1), m.Bind(d2)}
+	if err := m.Verify(rootKey, conditions{}, discharges); err != nil {
+		t.Fatalf("Verify: %v", err)
+	}
+	if err := m.Verify(rootKey, conditions{}, discharges[:1]); err == nil {
+		t.Errorf("Verify accepted a discharge without its own discharge")
+	}
+
+	// A discharge cannot be used for two caveats.
+	loop := New(rootKey, []byte("id"), "")
+	loop.AddThirdPartyCaveat(key1, []byte("caveat"), "")
+	d := New(key1, []byte("caveat"), "")
+	d.AddThirdPartyCaveat(key1, []byte("caveat"), "")
+	if err := loop.Verify(rootKey, conditions{}, []*Macaroon{loop.Bind(d)}); err == nil {
+		t.Errorf("Verify accepted a discharge that discharges itself")
+	}
+}
+
+func TestBinaryEncoding(t *testing.T) {
+	m := New(rootKey, []byte("id"), "https://service.example/")
+	m.AddFirstPartyCaveat([]byte("account = 1"))
+	if err := m.AddThirdPartyCaveat([]byte("caveat key"), []byte("user = alice"), "https://idp.example/"); err != nil {
+		t.Fatal(err)
+	}
+	m.AddFirstPartyCaveat(bytes.Repeat([]byte("x"), 300))
+
+	b, err := m.MarshalBinary()
+	if err != nil {
+		t.Fatal(err)
+	}
+	var m1 Macaroon
+	if err := m1.UnmarshalBinary(b); err != nil {
+		t.Fatal(err)
+	}
+	b1, _ := m1.MarshalBinary()
+	if !bytes.Equal(b, b1) {
+		t.Errorf("round trip changed the encoding:\n%x\n%x", b, b1)
+	}
+	if m1.Location() != m.Location() || !bytes.Equal(m1.ID(), m.ID()) || !bytes.Equal(m1.Signature(), m.Signature()) {
+		t.Errorf("round trip changed the macaroon")
+	}
+	if len(m1.Caveats()) != 3 || !m1.Caveats()[1].IsThirdParty() || m1.Caveats()[2].IsThirdParty() {
+		t.Errorf("round trip changed the caveats: %+v", m1.Caveats())
+	}
+
+	for i := 0; i < len(b); i++ {
+		if err := new(Macaroon).UnmarshalBinary(b[:i]); err == nil {
+			t.Errorf("UnmarshalBinary accepted %d of %d bytes", i, len(b))
+		}
+	}
+	if err := new(Macaroon).UnmarshalBinary(append(b, 0)); err == nil {
+		t.Errorf("UnmarshalBinary accepted trailing data")
+	}
+}
+
+// TestBinaryEncodingLibmacaroons checks the encoding of the macaroon of the
+// libmacaroons README, which has no third-party caveats and so no random
+// nonces.
+func TestBinaryEncodingLibmacaroons(t *testing.T) {
+	m := New(rootKey, []byte("we used our secret key"), "http://mybank/")
+	m.AddFirstPartyCaveat([]byte("account = 3735928559"))
+	b, _ := m.MarshalBinary()
+	want := "02" + "010e" + hex.EncodeToString([]byte("http://mybank/")) +
+		"0216" + hex.EncodeToString([]byte("we used our secret key")) + "00" +
+		"0214" + hex.EncodeToString([]byte("account = 3735928559")) + "00" +
+		"00" + "0620" + "1efe4763f290dbce0c1d08477367e11f4eee456a64933cf662d79772dbb82128"
+	if got := hex.EncodeToString(b); got != want {
+		t.Errorf("MarshalBinary = %s, want %s", got, want)
+	}
+}
@@ -0,0 +1,256 @@
+// Copyright 2018 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+// Package multihash encodes and decodes self-describing digests in the
+// multihash format, which content-addressed systems, such as IPFS, use to
+// name data by its digest while allowing the hash function to change.
+//
+// A multihash is the code of the hash function and the length of the digest,
+// both as unsigned varints, followed by the digest, which may be truncated.
+// The codes are those of the multicodec table, for the hash functions of the
+// standard library and of this repository.
+//
+// See https://multiformats.io/multihash/.
+package multihash // import "golang.org/x/crypto/multihash"
+
+import (
+	"crypto/md5"
+	"crypto/sha1"
+	"crypto/sha256"
+	"crypto/sha512"
+	"crypto/subtle"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"hash"
+
+	"golang.org/x/crypto/blake2b"
+	"golang.org/x/crypto/blake2s"
+	"golang.org/x/crypto/md4"
+	"golang.org/x/crypto/ripemd160"
+	"golang.org/x/crypto/sha3"
+)
+
+// A Code identifies a hash function in a multihash.
+type Code uint64
+
+// The codes of the supported hash functions. BLAKE2b is also supported with
+// any output size that is a whole number of bytes, with the codes from 0xb201,
+// for blake2b-8, to 0xb240, for blake2b-512.
+const (
+	SHA1         Code = 0x11
+	SHA2_256     Code = 0x12
+	SHA2_512     Code = 0x13
+	SHA3_512     Code = 0x14
+	SHA3_384     Code = 0x15
+	SHA3_256     Code = 0x16
+	SHA3_224     Code = 0x17
+	SHAKE128     Code = 0x18
+	SHAKE256     Code = 0x19
+	Keccak256    Code = 0x1b
+	SHA2_384     Code = 0x20
+	MD4          Code = 0xd4
+	MD5          Code = 0xd5
+	SHA2_224     Code = 0x1013
+	SHA2_512_224 Code = 0x1014
+	SHA2_512_256 Code = 0x1015
+	RIPEMD160    Code = 0x1053
+	BLAKE2b_256  Code = 0xb220
+	BLAKE2b_384  Code = 0xb230
+	BLAKE2b_512  Code = 0xb240
+	BLAKE2s_256  Code = 0xb260
+)
+
+// blake2bBase is the code of BLAKE2b with a zero output size, to which the
+// output size in bytes is added.
+const blake2bBase = 0xb200
+
+var names = map[Code]string{
+	SHA1:         "sha1",
+	SHA2_256:     "sha2-256",
+	SHA2_512:     "sha2-512",
+	SHA3_512:     "sha3-512",
+	SHA3_384:     "sha3-384",
+	SHA3_256:     "sha3-256",
+	SHA3_224:     "sha3-224",
+	SHAKE128:     "shake-128",
+	SHAKE256:     "shake-256",
+	Keccak256:    "keccak-256",
+	SHA2_384:     "sha2-384",
+	MD4:          "md4",
+	MD5:          "md5",
+	SHA2_224:     "sha2-224",
+	SHA2_512_224: "sha2-512-224",
+	SHA2_512_256: "sha2-512-256",
+	RIPEMD160:    "ripemd-160",
+	BLAKE2s_256:  "blake2s-256",
+}
+
+// String returns the multicodec name of c, such as "sha2-256".
+func (c Code) String() string {
+	if n, ok := names[c]; ok {
+		return n
+	}
+	if c > blake2bBase && c <= BLAKE2b_512 {
+		return fmt.Sprintf("blake2b-%d", (c-blake2bBase)*8)
+	}
+	return fmt.Sprintf("Code(%#x)", uint64(c))
+}
+
+var (
+	// ErrMismatch is returned by Verify when the data does not match the
+	// multihash.
+	ErrMismatch = errors.New("multihash: digest mismatch")
+
+	errMalformed = errors.New("multihash: malformed multihash")
+	errLength    = errors.New("multihash: digest longer than the output of the hash function")
+)
+
+// An UnsupportedCodeError is returned for hash functions that this package
+// does not implement.
+type UnsupportedCodeError Code
+
+func (e UnsupportedCodeError) Error() string {
+	return "multihash: unsupported hash function " + Code(e).String()
+}
+
+// New returns a new hash.Hash computing the hash function of code. The
+// extendable-output functions SHAKE128 and SHAKE256 output 32 and 64 bytes.
+func New(code Code) (hash.Hash, error) {
+	switch code {
+	case SHA1:
+		return sha1.New(), nil
+	case SHA2_224:
+		return sha256.New224(), nil
+	case SHA2_256:
+		return sha256.New(), nil
+	case SHA2_384:
+		return sha512.New384(), nil
+	case SHA2_512:
+		return sha512.New(), nil
+	case SHA2_512_224:
+		return sha512.New512_224(), nil
+	case SHA2_512_256:
+		return sha512.New512_256(), nil
+	case SHA3_224:
+		return sha3.New224(), nil
+	case SHA3_256:
+		return sha3.New256(), nil
+	case SHA3_384:
+		return sha3.New384(), nil
+	case SHA3_512:
+		return sha3.New512(), nil
+	case SHAKE128:
+		return &shakeHash{sha3.NewShake128(), 32, 168}, nil
+	case SHAKE256:
+		return &shakeHash{sha3.NewShake256(), 64, 136}, nil
+	case Keccak256:
+		return sha3.NewLegacyKeccak256(), nil
+	case MD4:
+		return md4.New(), nil
+	case MD5:
+		return md5.New(), nil
+	case RIPEMD160:
+		return ripemd160.New(), nil
+	case BLAKE2s_256:
+		return blake2s.New256(nil)
+	}
+	if code > blake2bBase && code <= BLAKE2b_512 {
+		return blake2b.New(int(code-blake2bBase), nil)
+	}
+	return nil, UnsupportedCodeError(code)
+}
+
+// shakeHash adapts a SHAKE function to hash.Hash, with a fixed output size.
+type shakeHash struct {
+	sha3.ShakeHash
+	size, rate int
+}
+
+func (h *shakeHash) Size() int      { return h.size }
+func (h *shakeHash) BlockSize() int { return h.rate }
+
+func (h *shakeHash) Sum(b []byte) []byte {
+	out := make([]byte, h.size)
+	h.Clone().Read(out)
+	return append(b, out...)
+}
+
+// Encode returns the multihash of digest, a digest made with the hash
+// function of code, possibly truncated.
+func Encode(code Code, digest []byte) []byte {
+	b := make([]byte, 0, 2*binary.MaxVarintLen64+len(digest))
+	var n [binary.MaxVarintLen64]byte
+	b = append(b, n[:binary.PutUvarint(n[:], uint64(code))]...)
+	b = append(b, n[:binary.PutUvarint(n[:], uint64(len(digest)))]...)
+	return append(b, digest...)
+}
+
+// Sum returns the multihash of data with the hash function of code. If length
+// is positive, the digest is truncated to length bytes, which must not be
+// more than the output size of the hash function. Otherwise, it is not
+// truncated.
+func Sum(code Code, data []byte, length int) ([]byte, error) {
+	h, err := New(code)
+	if err != nil {
+		return nil, err
+	}
+	if length > h.Size() {
+		return nil, errLength
+	}
+	h.Write(data)
+	digest := h.Sum(nil)
+	if length > 0 {
+		digest = digest[:length]
+	}
+	return Encode(code, digest), nil
+}
+
+// Decode returns the code and the digest of the multihash mh. It does not
+// check that the code is supported, so that multihashes of unknown functions
+// can still be stored and compared. The digest aliases mh.
+func Decode(mh []byte) (Code, []byte, error) {
+	code, n := uvarint(mh)
+	if n <= 0 {
+		return 0, nil, errMalformed
+	}
+	mh = mh[n:]
+	length, n := uvarint(mh)
+	if n <= 0 || length != uint64(len(mh)-n) {
+		return 0, nil, errMalformed
+	}
+	return Code(code), mh[n:], nil
+}
+
+// uvarint decodes a varint like binary.Uvarint, but rejects non-minimal
+// encodings, as the multiformats specification requires, so that every
+// multihash has a single encoding.
+func uvarint(b []byte) (uint64, int) {
+	v, n := binary.Uvarint(b)
+	if n > 1 && b[n-1] == 0 {
+		return 0, 0
+	}
+	return v, n
+}
+
+// Verify checks that mh is a multihash of data, and returns ErrMismatch if it
+// is not, or an UnsupportedCodeError if its hash function is not supported.
+// The digest may be truncated, but must not be empty.
+func Verify(mh, data []byte) error {
+	code, digest, err := Decode(mh)
+	if err != nil {
+		return err
+	}
+	if len(digest) == 0 {
+		return errMalformed
+	}
+	want, err := Sum(code, data, len(digest))
+	if err != nil {
+		return err
+	}
+	if subtle.ConstantTimeCompare(want, mh) != 1 {
+		return ErrMismatch
+	}
+	return nil
+}
@@ -0,0 +1,115 @@
+// Copyright 2018 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package multihash
+
+import (
+	"bytes"
+	"encoding/hex"
+	"testing"
+)
+
+var sumTests = []struct {
+	code   Code
+	length int
+	mh     string
+}{
+	// The example of https://multiformats.io/multihash/.
+	{SHA2_256, 0, "1220" + "9cbc07c3f991725836a3aa2a581ca2029198aa420b9d99bc0e131d9f3e2cbe47"},
+	{SHA2_256, 16, "1210" + "9cbc07c3f991725836a3aa2a581ca202"},
+	{SHA1, 0, "1114" + "88c2f11fb2ce392acb5b2986e640211c4690073e"},
+	{SHA3_512, 0, "1440" + "a32744b7ff5b1eb0973e17e3d9468a45bd4acd741b781d5c7d42041473f932b2" +
+		"2b440aaef266d5d6c51ecc5fd7a736f796ede9324070cff62a08f258c3be759d"},
+	{SHAKE128, 0, "1820" + "d37045663a07fb35ec571d8f6ef98300a2daa5a82d9d055e684bc292e98a02a3"},
+	{blake2bBase + 20, 0, "94e40214" + "b3bd9ad76d575f722026472d7f59cafe5ee1fc3a"},
+	{BLAKE2s_256, 0, "e0e40220" + "fd819eb9fa98079c54be7da4608acc01a44042bf1ad0e66c95fc27bf43c1a317"},
+}
+
+func TestSum(t *testing.T) {
+	data := []byte("multihash")
+	for _, tt := range sumTests {
+		mh, err := Sum(tt.code, data, tt.length)
+		if err != nil {
+			t.Errorf("Sum(%v): %v", tt.code, err)
+			continue
+		}
+		if got := hex.EncodeToString(mh); got != tt.mh {
+			t.Errorf("Sum(%v, %d) = %s, want %s", tt.code, tt.length, got, tt.mh)
+		}
+		code, digest, err := Decode(mh)
+		if err != nil || code != tt.code || !bytes.Equal(Encode(code, digest), mh) {
+			t.Errorf("Decode(%x) = %v, %x, %v", mh, code, digest, err)
+		}
+		if err := Verify(mh, data); err != nil {
+			t.Errorf("Verify(%x): %v", mh, err)
+		}
+		if err := Verify(mh, []byte("multihasH")); err != ErrMismatch {
+			t.Errorf("Verify(%x) of other data returned %v, want ErrMismatch", mh, err)
+		}
+	}
+}
+
+func TestAllCodes(t *testing.T) {
+	codes := []Code{SHA2_224, SHA2_384, SHA2_512, SHA2_512_224, SHA2_512_256,
+		SHA3_224, SHA3_256, SHA3_384, SHAKE256, Keccak256, MD4, MD5, RIPEMD160,
+		BLAKE2b_256, BLAKE2b_384, BLAKE2b_512, blake2bBase + 1}
+	for _, code := range codes {
+		h, err := New(code)
+		if err != nil {
+			t.Errorf("New(%v): %v", code, err)
+			continue
+		}
+		mh, err := Sum(code, []byte("data"), 0)
+		if err != nil {
+			t.Errorf("Sum(%v): %v", code, err)
+			continue
+		}
+		if _, digest, _ := Decode(mh); len(digest) != h.Size() {
+			t.Errorf("Sum(%v) has a %d-byte digest, want %d", code, len(digest), h.Size())
+		}
+		if err := Verify(mh, []byte("data")); err != nil {
+			t.Errorf("Verify(%v): %v", code, err)
+		}
+		if _, err := Sum(code, nil, h.Size()+1); err == nil {
+			t.Errorf("Sum(%v) accepted a length longer than the digest", code)
+		}
+	}
+	if got := Code(blake2bBase + 20).String(); got != "blake2b-160" {
+		t.Errorf("String = %q, want blake2b-160", got)
+	}
+}
+
+func TestUnsupported(t *testing.T) {
+	mh := Encode(0x1e, make([]byte, 32)) // BLAKE3
+	code, digest, err := Decode(mh)
+	if err != nil || code != 0x1e || len(digest) != 32 {
+		t.Errorf("Decode of an unknown function = %v, %x, %v", code, digest, err)
+	}
+	if err := Verify(mh, nil); err != UnsupportedCodeError(0x1e) {
+		t.Errorf("Verify of an unknown function returned %v", err)
+	}
+	if _, err := New(blake2bBase); err == nil {
+		t.Errorf("New accepted blake2b-0")
+	}
+}
+
+func TestDecodeErrors(t *testing.T) {
+	for _, s := range []string{
+		"",
+		"12",
+		"1220",
+		"12209cbc07c3",
+		"1202aabbcc",
+		"928000" + "02aabb", // non-minimal code
+		"12a000",            // non-minimal length
+	} {
+		b, _ := hex.DecodeString(s)
+		if code, digest, err := Decode(b); err == nil {
+			t.Errorf("Decode(%s) = %v, %x, want error", s, code, digest)
+		}
+	}
+	if err := Verify([]byte{0x12, 0x00}, nil); err == nil {
+		t.Errorf("Verify accepted an empty digest")
+	}
+}
@@ -29,6 +29,16 @@ import (
 // Overhead is the number of bytes of overhead when signing a message.
 const Overhead = 64
 
+// The sizes, in bytes, of keys and detached signatures, which are those of
+// libsodium's crypto_sign. A private key is the 32-byte seed followed by the
+// public key, the layout of libsodium and of ed25519.PrivateKey.
+const (
+	SeedSize       = 32
+	PublicKeySize  = 32
+	PrivateKeySize = 64
+	SignatureSize  = Overhead
+)
+
 // GenerateKey generates a new public/private key pair suitable for use with
 // Sign and Open.
 func GenerateKey(rand io.Reader) (publicKey *[32]byte, privateKey *[64]byte, err error) {
@@ -42,6 +52,33 @@ func GenerateKey(rand io.Reader) (publicKey *[32]byte, privateKey *[64]byte, err
 	return publicKey, privateKey, nil
 }
 
+// NewKeyFromSeed returns the key pair derived from seed, as
+// crypto_sign_seed_keypair does. It is how keys stored as seeds, the private
+// keys of RFC 8032, are loaded.
+func NewKeyFromSeed(seed *[SeedSize]byte) (publicKey *[32]byte, privateKey *[64]byte) {
+	priv := ed25519.NewKeyFromSeed((*seed)[:])
+	publicKey, privateKey = new([32]byte), new([64]byte)
+	copy((*publicKey)[:], priv[32:])
+	copy((*privateKey)[:], priv)
+	return publicKey, privateKey
+}
+
+// Seed returns the seed of privateKey, as crypto_sign_ed25519_sk_to_seed
+// does.
+func Seed(privateKey *[64]byte) *[SeedSize]byte {
+	seed := new([SeedSize]byte)
+	copy((*seed)[:], (*privateKey)[:32])
+	return seed
+}
+
+// PublicKey returns the public key stored in privateKey, as
+// crypto_sign_ed25519_sk_to_pk does.
+func PublicKey(privateKey *[64]byte) *[32]byte {
+	publicKey := new([32]byte)
+	copy((*publicKey)[:], (*privateKey)[32:])
+	return publicKey
+}
+
 // Sign appends a signed copy of message to out, which will be Overhead bytes
 // longer than the original and must not overlap it.
 func Sign(out, message []byte, privateKey *[64]byte) []byte {
@@ -67,6 +104,23 @@ func Open(out, signedMessage []byte, publicKey *[32]byte) ([]byte, bool) {
 	return ret, true
 }
 
+// SignDetached appends the signature of message to out, which will be
+// SignatureSize bytes longer, as crypto_sign_detached does. The signature is
+// the first SignatureSize bytes of the output of Sign.
+func SignDetached(out, message []byte, privateKey *[64]byte) []byte {
+	sig := ed25519.Sign(ed25519.PrivateKey((*privateKey)[:]), message)
+	return append(out, sig...)
+}
+
+// VerifyDetached reports whether sig is a valid signature of message by
+// publicKey, as crypto_sign_verify_detached does.
+func VerifyDetached(sig, message []byte, publicKey *[32]byte) bool {
+	if len(sig) != SignatureSize {
+		return false
+	}
+	return ed25519.Verify(ed25519.PublicKey((*publicKey)[:]), message, sig)
+}
+
 // sliceForAppend takes a slice and a requested number of bytes. It returns a
 // slice with the contents of the given slice followed by that many bytes and a
 // second slice that aliases into it and contains only the extra bytes. If the
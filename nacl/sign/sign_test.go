@@ -72,3 +72,44 @@ func TestGenerateSignOpen(t *testing.T) {
 		t.Fatalf("verified message does not match signed messge, got\n%x\n, expected\n%x", message, testMessage)
 	}
 }
+
+func TestNewKeyFromSeed(t *testing.T) {
+	seed := Seed(&testPrivateKey)
+	publicKey, privateKey := NewKeyFromSeed(seed)
+	if *privateKey != testPrivateKey {
+		t.Errorf("NewKeyFromSeed returned private key %x, want %x", *privateKey, testPrivateKey)
+	}
+	if *publicKey != testPublicKey {
+		t.Errorf("NewKeyFromSeed returned public key %x, want %x", *publicKey, testPublicKey)
+	}
+	if got := PublicKey(&testPrivateKey); *got != testPublicKey {
+		t.Errorf("PublicKey = %x, want %x", *got, testPublicKey)
+	}
+	if !bytes.Equal(seed[:], testPrivateKey[:SeedSize]) {
+		t.Errorf("Seed = %x, want %x", seed[:], testPrivateKey[:SeedSize])
+	}
+}
+
+func TestDetached(t *testing.T) {
+	prefix := []byte("prefix")
+	out := SignDetached(prefix, testMessage, &testPrivateKey)
+	if !bytes.Equal(out[:len(prefix)], prefix) {
+		t.Errorf("SignDetached did not append to out")
+	}
+	sig := out[len(prefix):]
+	if !bytes.Equal(sig, testSignedMessage[:SignatureSize]) {
+		t.Errorf("SignDetached = %x, want %x", sig, testSignedMessage[:SignatureSize])
+	}
+	if !VerifyDetached(sig, testMessage, &testPublicKey) {
+		t.Errorf("valid detached signature not verified")
+	}
+	if VerifyDetached(sig[1:], testMessage, &testPublicKey) {
+		t.Errorf("truncated detached signature verified")
+	}
+	if VerifyDetached(sig, testMessage[1:], &testPublicKey) {
+		t.Errorf("detached signature verified for another message")
+	}
+	if VerifyDetached(testSignedMessage, testMessage, &testPublicKey) {
+		t.Errorf("combined signed message verified as a detached signature")
+	}
+}
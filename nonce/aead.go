@@ -0,0 +1,57 @@
+// Copyright 2018 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package nonce
+
+import (
+	"crypto/cipher"
+	"errors"
+)
+
+var errShortCiphertext = errors.New("nonce: ciphertext too short")
+
+// AEAD seals messages with nonces drawn from a Source. The nonce is
+// prepended to each ciphertext, so that Open does not need it separately.
+type AEAD struct {
+	aead   cipher.AEAD
+	source Source
+}
+
+// NewAEAD returns an AEAD that seals with aead, using nonces from source.
+// source must not be shared with any other key.
+func NewAEAD(aead cipher.AEAD, source Source) (*AEAD, error) {
+	if aead.NonceSize() != source.NonceSize() {
+		return nil, errors.New("nonce: source and AEAD have different nonce sizes")
+	}
+	return &AEAD{aead, source}, nil
+}
+
+// Overhead returns the difference between the lengths of a plaintext and
+// its ciphertext, including the nonce.
+func (a *AEAD) Overhead() int {
+	return a.aead.NonceSize() + a.aead.Overhead()
+}
+
+// Seal encrypts and authenticates plaintext and authenticates additionalData,
+// and appends the nonce followed by the result to dst. It fails if the source
+// cannot produce a new nonce.
+func (a *AEAD) Seal(dst, plaintext, additionalData []byte) ([]byte, error) {
+	nonce, err := a.source.Next()
+	if err != nil {
+		return nil, err
+	}
+	dst = append(dst, nonce...)
+	return a.aead.Seal(dst, nonce, plaintext, additionalData), nil
+}
+
+// Open decrypts and authenticates a ciphertext produced by Seal, and
+// authenticates additionalData. If successful, it appends the plaintext to
+// dst.
+func (a *AEAD) Open(dst, ciphertext, additionalData []byte) ([]byte, error) {
+	n := a.aead.NonceSize()
+	if len(ciphertext) < n {
+		return nil, errShortCiphertext
+	}
+	return a.aead.Open(dst, ciphertext[:n], ciphertext[n:], additionalData)
+}
@@ -0,0 +1,254 @@
+// Copyright 2018 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+// Package nonce implements nonce generation schemes for AEADs, such as those
+// in golang.org/x/crypto/chacha20poly1305.
+//
+// An AEAD loses its security guarantees as soon as a nonce is reused with the
+// same key. The sources in this package never return the same nonce twice,
+// and fail with ErrExhausted instead of wrapping around or exceeding the
+// number of nonces that can safely be generated by the scheme. Once a source
+// has returned an error, it keeps failing, and the key must be replaced.
+//
+// The schemes are
+//
+//   - Counter, a counter with an optional fixed prefix, whose state can be
+//     persisted across restarts with a Store;
+//   - NewRandom96, random 96-bit nonces, limited to 2³² messages per key;
+//   - NewRandom192, random 192-bit nonces for XChaCha20-Poly1305 and similar
+//     constructions, whose number is not practically limited;
+//   - Sequence, a fixed IV XORed with a message sequence number, as used by
+//     TLS 1.3, where both sides know the sequence number.
+//
+// AEAD combines a cipher.AEAD with a Source, so that callers never handle
+// nonces directly.
+package nonce // import "golang.org/x/crypto/nonce"
+
+import (
+	"crypto/rand"
+	"encoding/binary"
+	"errors"
+	"io"
+	"sync"
+)
+
+// ErrExhausted is returned by a Source that cannot produce any more nonces
+// for its key.
+var ErrExhausted = errors.New("nonce: nonces exhausted, the key must be replaced")
+
+// A Source produces nonces for use with a single AEAD key. Implementations
+// must be safe for concurrent use.
+type Source interface {
+	// NonceSize returns the size of the nonces returned by Next.
+	NonceSize() int
+	// Next returns a nonce that has never been returned before for the key.
+	// Once Next returns an error, all subsequent calls fail.
+	Next() ([]byte, error)
+}
+
+// A Store persists the state of a Counter, so that nonces are not reused if
+// the process restarts with the same key.
+type Store interface {
+	// Load returns the value passed to the last successful call to Save,
+	// or zero if Save was never called.
+	Load() (uint64, error)
+	// Save durably records that counter values below n may have been used.
+	Save(n uint64) error
+}
+
+// counterReservation is how many counter values a Counter reserves with its
+// Store at a time. After a crash, at most this many values are skipped.
+const counterReservation = 1 << 12
+
+// Counter produces nonces made of a fixed prefix followed by a big-endian
+// counter starting at zero.
+type Counter struct {
+	size   int
+	prefix []byte
+	// limit is one more than the largest counter value that may be used.
+	limit uint64
+
+	mu       sync.Mutex
+	next     uint64
+	reserved uint64 // values below reserved are recorded in store
+	store    Store
+	err      error
+}
+
+// NewCounter returns a Counter producing nonces of size bytes that start with
+// prefix. The remaining bytes hold the counter, of which at most eight are
+// used, and whose all-ones value is never used. If store is not nil, the
+// counter resumes from the state it records and reserves values with it
+// before they are used.
+func NewCounter(size int, prefix []byte, store Store) (*Counter, error) {
+	counterSize := size - len(prefix)
+	if counterSize < 1 {
+		return nil, errors.New("nonce: prefix leaves no room for a counter")
+	}
+	c := &Counter{
+		size:   size,
+		prefix: append([]byte{}, prefix...),
+		limit:  ^uint64(0),
+		store:  store,
+	}
+	if counterSize < 8 {
+		c.limit = 1<<(8*uint(counterSize)) - 1
+	}
+	if store != nil {
+		n, err := store.Load()
+		if err != nil {
+			return nil, err
+		}
+		if n > c.limit {
+			return nil, errors.New("nonce: stored counter does not fit the nonce")
+		}
+		c.next, c.reserved = n, n
+	}
+	return c, nil
+}
+
+// NonceSize implements Source.
+func (c *Counter) NonceSize() int {
+	return c.size
+}
+
+// Next implements Source.
+func (c *Counter) Next() ([]byte, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if c.err != nil {
+		return nil, c.err
+	}
+	if c.next == c.limit {
+		c.err = ErrExhausted
+		return nil, c.err
+	}
+	if c.store != nil && c.next >= c.reserved {
+		reserve := c.limit
+		if c.limit-c.next > counterReservation {
+			reserve = c.next + counterReservation
+		}
+		if err := c.store.Save(reserve); err != nil {
+			c.err = err
+			return nil, err
+		}
+		c.reserved = reserve
+	}
+
+	nonce := make([]byte, c.size)
+	copy(nonce, c.prefix)
+	var ctr [8]byte
+	binary.BigEndian.PutUint64(ctr[:], c.next)
+	if counterSize := c.size - len(c.prefix); counterSize >= 8 {
+		copy(nonce[c.size-8:], ctr[:])
+	} else {
+		copy(nonce[len(c.prefix):], ctr[8-counterSize:])
+	}
+	c.next++
+	return nonce, nil
+}
+
+// random produces random nonces, up to a limit beyond which collisions
+// become too likely.
+type random struct {
+	size  int
+	rand  io.Reader
+	mu    sync.Mutex
+	count uint64
+	limit uint64
+	err   error
+}
+
+// NewRandom96 returns a Source of random 96-bit nonces, as used by
+// AES-GCM and ChaCha20-Poly1305. Following NIST SP 800-38D, section 8.3, it
+// returns at most 2³² nonces, which keeps the probability of a collision
+// below 2⁻³².
+func NewRandom96() Source {
+	return &random{size: 12, rand: rand.Reader, limit: 1 << 32}
+}
+
+// NewRandom192 returns a Source of random 192-bit nonces, as used by
+// XChaCha20-Poly1305. Collisions are negligible even after 2⁶⁴ nonces, so
+// the number of nonces is not limited in practice.
+func NewRandom192() Source {
+	return &random{size: 24, rand: rand.Reader, limit: ^uint64(0)}
+}
+
+func (r *random) NonceSize() int {
+	return r.size
+}
+
+func (r *random) Next() ([]byte, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if r.err != nil {
+		return nil, r.err
+	}
+	if r.count == r.limit {
+		r.err = ErrExhausted
+		return nil, r.err
+	}
+	nonce := make([]byte, r.size)
+	if _, err := io.ReadFull(r.rand, nonce); err != nil {
+		r.err = err
+		return nil, err
+	}
+	r.count++
+	return nonce, nil
+}
+
+// Sequence derives nonces from a secret IV and a 64-bit sequence number, as
+// in TLS 1.3 (RFC 8446, section 5.3): the sequence number is encoded in big
+// endian, left-padded to the size of the IV, and XORed with it. The sending
+// side uses Next, and the receiving side, which tracks the sequence number
+// independently, uses NonceFor.
+type Sequence struct {
+	iv []byte
+
+	mu   sync.Mutex
+	next uint64
+	err  error
+}
+
+// NewSequence returns a Sequence with the given IV, which must be at least
+// eight bytes long, starting at sequence number zero.
+func NewSequence(iv []byte) (*Sequence, error) {
+	if len(iv) < 8 {
+		return nil, errors.New("nonce: IV shorter than the sequence number")
+	}
+	return &Sequence{iv: append([]byte{}, iv...)}, nil
+}
+
+// NonceSize implements Source.
+func (s *Sequence) NonceSize() int {
+	return len(s.iv)
+}
+
+// Next implements Source. The all-ones sequence number is never used.
+func (s *Sequence) Next() ([]byte, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.err != nil {
+		return nil, s.err
+	}
+	if s.next == ^uint64(0) {
+		s.err = ErrExhausted
+		return nil, s.err
+	}
+	nonce := s.NonceFor(s.next)
+	s.next++
+	return nonce, nil
+}
+
+// NonceFor returns the nonce for sequence number seq.
+func (s *Sequence) NonceFor(seq uint64) []byte {
+	nonce := append([]byte{}, s.iv...)
+	for i := 0; i < 8; i++ {
+		nonce[len(nonce)-1-i] ^= byte(seq >> (8 * uint(i)))
+	}
+	return nonce
+}
@@ -0,0 +1,256 @@
+// Copyright 2018 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package nonce
+
+import (
+	"bytes"
+	"encoding/hex"
+	"errors"
+	"testing"
+
+	"golang.org/x/crypto/chacha20poly1305"
+)
+
+func TestCounter(t *testing.T) {
+	c, err := NewCounter(12, []byte{0xaa, 0xbb}, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, want := range []string{
+		"aabb00000000000000000000",
+		"aabb00000000000000000001",
+		"aabb00000000000000000002",
+	} {
+		n, err := c.Next()
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got := hex.EncodeToString(n); got != want {
+			t.Errorf("got nonce %s, want %s", got, want)
+		}
+	}
+
+	if _, err := NewCounter(2, []byte{1, 2}, nil); err == nil {
+		t.Error("NewCounter accepted a prefix filling the nonce")
+	}
+}
+
+func TestCounterExhausted(t *testing.T) {
+	c, err := NewCounter(3, []byte{1, 2}, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	seen := make(map[string]bool)
+	for i := 0; i < 255; i++ {
+		n, err := c.Next()
+		if err != nil {
+			t.Fatalf("nonce %d: %v", i, err)
+		}
+		if seen[string(n)] {
+			t.Fatalf("nonce %x repeated", n)
+		}
+		seen[string(n)] = true
+	}
+	for i := 0; i < 2; i++ {
+		if _, err := c.Next(); err != ErrExhausted {
+			t.Fatalf("got %v after exhausting the counter, want ErrExhausted", err)
+		}
+	}
+}
+
+type memStore struct {
+	n     uint64
+	saves int
+	err   error
+}
+
+func (s *memStore) Load() (uint64, error) { return s.n, nil }
+
+func (s *memStore) Save(n uint64) error {
+	if s.err != nil {
+		return s.err
+	}
+	s.n = n
+	s.saves++
+	return nil
+}
+
+func TestCounterStore(t *testing.T) {
+	store := new(memStore)
+	c, err := NewCounter(12, nil, store)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var last []byte
+	for i := 0; i < counterReservation+1; i++ {
+		if last, err = c.Next(); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if store.saves != 2 || store.n != 2*counterReservation {
+		t.Errorf("after %d nonces: %d saves, stored %d", counterReservation+1, store.saves, store.n)
+	}
+
+	// A restart resumes after the reserved values.
+	c, err = NewCounter(12, nil, store)
+	if err != nil {
+		t.Fatal(err)
+	}
+	n, err := c.Next()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if bytes.Compare(n, last) <= 0 {
+		t.Errorf("nonce %x after restart is not after %x", n, last)
+	}
+
+	// Failing to persist the state stops the counter.
+	errStore := errors.New("disk full")
+	store.err = errStore
+	c, err = NewCounter(12, nil, store)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for i := 0; i < 2; i++ {
+		if _, err := c.Next(); err != errStore {
+			t.Errorf("got %v, want %v", err, errStore)
+		}
+	}
+	store.err = nil
+	if _, err := c.Next(); err != errStore {
+		t.Errorf("counter recovered from a failed save: %v", err)
+	}
+
+	if _, err := NewCounter(3, []byte{1, 2}, &memStore{n: 256}); err == nil {
+		t.Error("NewCounter accepted a stored value that does not fit")
+	}
+}
+
+func TestCounterStoreExhausted(t *testing.T) {
+	store := new(memStore)
+	c, err := NewCounter(1, nil, store)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for i := 0; i < 255; i++ {
+		if _, err := c.Next(); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if _, err := c.Next(); err != ErrExhausted {
+		t.Errorf("got %v, want ErrExhausted", err)
+	}
+
+	c, err = NewCounter(1, nil, store)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := c.Next(); err != ErrExhausted {
+		t.Errorf("got %v after restart, want ErrExhausted", err)
+	}
+}
+
+func TestRandom(t *testing.T) {
+	for _, s := range []Source{NewRandom96(), NewRandom192()} {
+		a, err := s.Next()
+		if err != nil {
+			t.Fatal(err)
+		}
+		b, err := s.Next()
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(a) != s.NonceSize() || bytes.Equal(a, b) {
+			t.Errorf("bad nonces %x, %x", a, b)
+		}
+	}
+
+	r := NewRandom96().(*random)
+	r.count = r.limit - 1
+	if _, err := r.Next(); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := r.Next(); err != ErrExhausted {
+		t.Errorf("got %v after 2³² nonces, want ErrExhausted", err)
+	}
+}
+
+func TestSequence(t *testing.T) {
+	iv, _ := hex.DecodeString("5d313eb2671276ee13000b30")
+	s, err := NewSequence(iv)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for seq, want := range []string{
+		"5d313eb2671276ee13000b30",
+		"5d313eb2671276ee13000b31",
+		"5d313eb2671276ee13000b32",
+	} {
+		n, err := s.Next()
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got := hex.EncodeToString(n); got != want {
+			t.Errorf("got nonce %s, want %s", got, want)
+		}
+		if !bytes.Equal(s.NonceFor(uint64(seq)), n) {
+			t.Errorf("NonceFor(%d) does not match Next", seq)
+		}
+	}
+	if got := hex.EncodeToString(s.NonceFor(0x0102030405060708)); got != "5d313eb2661075ea16060c38" {
+		t.Errorf("NonceFor(0x0102030405060708) = %s", got)
+	}
+
+	s.next = ^uint64(0)
+	if _, err := s.Next(); err != ErrExhausted {
+		t.Errorf("got %v, want ErrExhausted", err)
+	}
+
+	if _, err := NewSequence(make([]byte, 7)); err == nil {
+		t.Error("NewSequence accepted a short IV")
+	}
+}
+
+func TestAEAD(t *testing.T) {
+	key := make([]byte, chacha20poly1305.KeySize)
+	aead, err := chacha20poly1305.New(key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := NewAEAD(aead, NewRandom192()); err == nil {
+		t.Error("NewAEAD accepted mismatched nonce sizes")
+	}
+
+	c, err := NewCounter(chacha20poly1305.NonceSize, nil, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	a, err := NewAEAD(aead, c)
+	if err != nil {
+		t.Fatal(err)
+	}
+	msg, ad := []byte("hello"), []byte("ad")
+	ct1, err := a.Seal(nil, msg, ad)
+	if err != nil {
+		t.Fatal(err)
+	}
+	ct2, err := a.Seal(nil, msg, ad)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(ct1) != len(msg)+a.Overhead() || bytes.Equal(ct1, ct2) {
+		t.Errorf("unexpected ciphertexts %x, %x", ct1, ct2)
+	}
+	pt, err := a.Open(nil, ct2, ad)
+	if err != nil || !bytes.Equal(pt, msg) {
+		t.Errorf("Open = %q, %v", pt, err)
+	}
+	if _, err := a.Open(nil, ct2, nil); err == nil {
+		t.Error("Open accepted wrong additional data")
+	}
+	if _, err := a.Open(nil, ct2[:5], ad); err == nil {
+		t.Error("Open accepted a truncated ciphertext")
+	}
+}
@@ -65,6 +65,60 @@ func (keyRevokedError) Error() string {
 
 var ErrKeyRevoked error = keyRevokedError(0)
 
+type keyExpiredError int
+
+func (keyExpiredError) Error() string {
+	return "openpgp: key expired"
+}
+
+// ErrKeyExpired is the reason given in a KeySelectionError for a key that has
+// expired.
+var ErrKeyExpired error = keyExpiredError(0)
+
+type keyUsageError int
+
+func (keyUsageError) Error() string {
+	return "openpgp: key not usable for the requested operation"
+}
+
+// ErrKeyUsage is the reason given in a KeySelectionError for a key whose
+// usage flags or algorithm do not allow the requested operation.
+var ErrKeyUsage error = keyUsageError(0)
+
+type keyNotPinnedError int
+
+func (keyNotPinnedError) Error() string {
+	return "openpgp: key does not match the pinned fingerprint"
+}
+
+// ErrKeyNotPinned is the reason given in a KeySelectionError for a key that
+// was excluded because another key was pinned.
+var ErrKeyNotPinned error = keyNotPinnedError(0)
+
+type decompressedTooLargeError int
+
+func (decompressedTooLargeError) Error() string {
+	return "openpgp: decompressed data exceeds the configured limit"
+}
+
+// ErrDecompressedTooLarge is returned when reading more decompressed data
+// from a compressed packet than the MaxDecompressedSize of the Config allows.
+var ErrDecompressedTooLarge error = decompressedTooLargeError(0)
+
+// A KeySelectionError is returned when none of the keys of an entity can be
+// used for an operation. Revoked keys are reported with ErrKeyRevoked.
+type KeySelectionError struct {
+	// Usage is the requested operation, "encryption" or "signing".
+	Usage string
+	// Rejected gives, for each key id of the entity, the reason it was not
+	// selected.
+	Rejected map[uint64]error
+}
+
+func (e *KeySelectionError) Error() string {
+	return "openpgp: no key usable for " + e.Usage
+}
+
 type UnknownPacketTypeError uint8
 
 func (upte UnknownPacketTypeError) Error() string {
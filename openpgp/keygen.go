@@ -0,0 +1,264 @@
+// Copyright 2018 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package openpgp
+
+import (
+	"crypto/rsa"
+	"io"
+	"math"
+	"time"
+
+	"golang.org/x/crypto/ed25519"
+	"golang.org/x/crypto/openpgp/errors"
+	"golang.org/x/crypto/openpgp/packet"
+)
+
+// A KeyAlgorithm is the type of a generated key.
+type KeyAlgorithm int
+
+const (
+	// KeyRSA keys can be used for any operation. Their size is given by
+	// KeySpec.RSABits.
+	KeyRSA KeyAlgorithm = iota
+	// KeyEd25519 keys can certify, sign and authenticate.
+	KeyEd25519
+	// KeyX25519 keys, which use ECDH over Curve25519, can only encrypt.
+	KeyX25519
+)
+
+// A KeySpec describes a key to generate.
+type KeySpec struct {
+	Algorithm KeyAlgorithm
+
+	// RSABits is the size of RSA keys. If zero, config.RSABits is used,
+	// or 2048 bits if that is zero too.
+	RSABits int
+
+	// Usage is the bitwise-OR of the packet.KeyFlag* values the key is
+	// marked for.
+	Usage byte
+
+	// Lifetime, if not zero, is the time after its creation at which the
+	// key expires.
+	Lifetime time.Duration
+}
+
+// encryptionOnlyUsage is the usage of keys that can only encrypt.
+const encryptionOnlyUsage = packet.KeyFlagEncryptCommunications | packet.KeyFlagEncryptStorage
+
+// A KeyGenConfig describes the keys of an Entity generated by its NewEntity
+// method. Named configurations are returned by KeyGenProfile.
+type KeyGenConfig struct {
+	// Primary is the primary key, which must be able to certify.
+	Primary KeySpec
+	// Subkeys are bound to the primary key, in order. Signing subkeys are
+	// cross-signed, as required to use them.
+	Subkeys []KeySpec
+}
+
+// Names of the profiles returned by KeyGenProfile.
+const (
+	// ProfileModern is an Ed25519 primary key for certification and
+	// signing, with an X25519 subkey for encryption, the layout of
+	// GnuPG's future-default. The keys are small and fast, but can only be
+	// used by implementations of draft-ietf-openpgp-rfc4880bis.
+	ProfileModern = "modern"
+	// ProfileRFC4880Compat is an RSA primary key for certification and
+	// signing, with an RSA subkey for encryption, which any implementation
+	// of RFC 4880 can use. It is what NewEntity generates.
+	ProfileRFC4880Compat = "rfc4880-compat"
+)
+
+var keyGenProfiles = map[string]KeyGenConfig{
+	ProfileModern: {
+		Primary: KeySpec{Algorithm: KeyEd25519, Usage: packet.KeyFlagCertify | packet.KeyFlagSign},
+		Subkeys: []KeySpec{{Algorithm: KeyX25519, Usage: encryptionOnlyUsage}},
+	},
+	ProfileRFC4880Compat: {
+		Primary: KeySpec{Algorithm: KeyRSA, Usage: packet.KeyFlagCertify | packet.KeyFlagSign},
+		Subkeys: []KeySpec{{Algorithm: KeyRSA, Usage: encryptionOnlyUsage}},
+	},
+}
+
+// KeyGenProfile returns a new copy of the KeyGenConfig with the given name,
+// one of the Profile* constants, which the caller may then adjust, for
+// example to set lifetimes or add subkeys.
+func KeyGenProfile(name string) (*KeyGenConfig, error) {
+	profile, ok := keyGenProfiles[name]
+	if !ok {
+		return nil, errors.InvalidArgumentError("unknown key generation profile " + name)
+	}
+	profile.Subkeys = append([]KeySpec(nil), profile.Subkeys...)
+	return &profile, nil
+}
+
+// check returns an error if spec describes a key that cannot be generated.
+func (spec *KeySpec) check() error {
+	switch spec.Algorithm {
+	case KeyRSA:
+		if spec.RSABits < 0 {
+			return errors.InvalidArgumentError("negative RSA key size")
+		}
+	case KeyEd25519:
+		if spec.Usage&encryptionOnlyUsage != 0 {
+			return errors.InvalidArgumentError("Ed25519 keys cannot encrypt")
+		}
+	case KeyX25519:
+		if spec.Usage&^encryptionOnlyUsage != 0 {
+			return errors.InvalidArgumentError("X25519 keys can only encrypt")
+		}
+	default:
+		return errors.InvalidArgumentError("unknown key algorithm")
+	}
+	if spec.Usage == 0 {
+		return errors.InvalidArgumentError("key without usage")
+	}
+	if spec.Lifetime < 0 || spec.Lifetime/time.Second > math.MaxUint32 {
+		return errors.InvalidArgumentError("key lifetime out of range")
+	}
+	return nil
+}
+
+// generate returns a new private key as described by spec.
+func (spec *KeySpec) generate(currentTime time.Time, config *packet.Config) (*packet.PrivateKey, error) {
+	switch spec.Algorithm {
+	case KeyRSA:
+		bits := spec.RSABits
+		if bits == 0 {
+			bits = defaultRSAKeyBits
+			if config != nil && config.RSABits != 0 {
+				bits = config.RSABits
+			}
+		}
+		priv, err := rsa.GenerateKey(config.Random(), bits)
+		if err != nil {
+			return nil, err
+		}
+		return packet.NewRSAPrivateKey(currentTime, priv), nil
+	case KeyEd25519:
+		_, priv, err := ed25519.GenerateKey(config.Random())
+		if err != nil {
+			return nil, err
+		}
+		return packet.NewEdDSAPrivateKey(currentTime, priv), nil
+	case KeyX25519:
+		var secret [32]byte
+		if _, err := io.ReadFull(config.Random(), secret[:]); err != nil {
+			return nil, err
+		}
+		return packet.NewX25519PrivateKey(currentTime, &secret), nil
+	}
+	panic("unreachable")
+}
+
+// selfSignature returns a self-signature, not yet signed, asserting the
+// usage and lifetime of spec.
+func (spec *KeySpec) selfSignature(sigType packet.SignatureType, primary *packet.PrivateKey, currentTime time.Time, config *packet.Config) *packet.Signature {
+	sig := &packet.Signature{
+		CreationTime:              currentTime,
+		SigType:                   sigType,
+		PubKeyAlgo:                primary.PubKeyAlgo,
+		Hash:                      config.Hash(),
+		FlagsValid:                true,
+		FlagCertify:               spec.Usage&packet.KeyFlagCertify != 0,
+		FlagSign:                  spec.Usage&packet.KeyFlagSign != 0,
+		FlagEncryptCommunications: spec.Usage&packet.KeyFlagEncryptCommunications != 0,
+		FlagEncryptStorage:        spec.Usage&packet.KeyFlagEncryptStorage != 0,
+		FlagAuthenticate:          spec.Usage&packet.KeyFlagAuthenticate != 0,
+		IssuerKeyId:               &primary.KeyId,
+	}
+	if spec.Lifetime > 0 {
+		lifetime := uint32(spec.Lifetime / time.Second)
+		sig.KeyLifetimeSecs = &lifetime
+	}
+	return sig
+}
+
+// NewEntity returns an Entity with fresh keys, as described by kc, and a
+// single identity composed of the given full name, comment and email, any of
+// which may be empty but must not contain any of "()<>\x00".
+// If config is nil, sensible defaults will be used.
+func (kc *KeyGenConfig) NewEntity(name, comment, email string, config *packet.Config) (*Entity, error) {
+	if kc.Primary.Usage&packet.KeyFlagCertify == 0 {
+		return nil, errors.InvalidArgumentError("primary key must be able to certify")
+	}
+	if err := kc.Primary.check(); err != nil {
+		return nil, err
+	}
+	for i := range kc.Subkeys {
+		if err := kc.Subkeys[i].check(); err != nil {
+			return nil, err
+		}
+	}
+
+	currentTime := config.Now()
+	uid := packet.NewUserId(name, comment, email)
+	if uid == nil {
+		return nil, errors.InvalidArgumentError("user id field contained invalid characters")
+	}
+
+	primary, err := kc.Primary.generate(currentTime, config)
+	if err != nil {
+		return nil, err
+	}
+	e := &Entity{
+		PrimaryKey: &primary.PublicKey,
+		PrivateKey: primary,
+		Identities: make(map[string]*Identity),
+	}
+
+	isPrimaryId := true
+	selfSig := kc.Primary.selfSignature(packet.SigTypePositiveCert, primary, currentTime, config)
+	selfSig.IsPrimaryId = &isPrimaryId
+	// If the user passes in a DefaultHash or DefaultCipher via
+	// packet.Config, set them as preferences in the SelfSignature.
+	if config != nil && config.DefaultHash != 0 {
+		selfSig.PreferredHash = []uint8{hashToHashId(config.DefaultHash)}
+	}
+	if config != nil && config.DefaultCipher != 0 {
+		selfSig.PreferredSymmetric = []uint8{uint8(config.DefaultCipher)}
+	}
+	if err := selfSig.SignUserId(uid.Id, e.PrimaryKey, primary, config); err != nil {
+		return nil, err
+	}
+	e.Identities[uid.Id] = &Identity{
+		Name:          uid.Id,
+		UserId:        uid,
+		SelfSignature: selfSig,
+	}
+
+	for i := range kc.Subkeys {
+		spec := &kc.Subkeys[i]
+		priv, err := spec.generate(currentTime, config)
+		if err != nil {
+			return nil, err
+		}
+		priv.IsSubkey = true
+		pub := &priv.PublicKey
+
+		sig := spec.selfSignature(packet.SigTypeSubkeyBinding, primary, currentTime, config)
+		if sig.FlagSign {
+			sig.EmbeddedSignature = &packet.Signature{
+				CreationTime: currentTime,
+				SigType:      packet.SigTypePrimaryKeyBinding,
+				PubKeyAlgo:   priv.PubKeyAlgo,
+				Hash:         config.Hash(),
+				IssuerKeyId:  &pub.KeyId,
+			}
+			if err := sig.EmbeddedSignature.CrossSignKey(e.PrimaryKey, priv, config); err != nil {
+				return nil, err
+			}
+		}
+		if err := sig.SignKey(pub, primary, config); err != nil {
+			return nil, err
+		}
+		e.Subkeys = append(e.Subkeys, Subkey{
+			PublicKey:  pub,
+			PrivateKey: priv,
+			Sig:        sig,
+		})
+	}
+	return e, nil
+}
@@ -0,0 +1,203 @@
+// Copyright 2018 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package openpgp
+
+import (
+	"bytes"
+	"crypto"
+	"io/ioutil"
+	"strings"
+	"testing"
+	"time"
+
+	"golang.org/x/crypto/openpgp/packet"
+)
+
+// rereadEntity serializes e with its private keys and parses it back.
+func rereadEntity(t *testing.T, e *Entity) *Entity {
+	var buf bytes.Buffer
+	if err := e.SerializePrivate(&buf, nil); err != nil {
+		t.Fatal(err)
+	}
+	reread, err := ReadEntity(packet.NewReader(&buf))
+	if err != nil {
+		t.Fatal(err)
+	}
+	return reread
+}
+
+func TestKeyGenProfiles(t *testing.T) {
+	config := &packet.Config{RSABits: 1024, DefaultHash: crypto.SHA256}
+	for _, name := range []string{ProfileModern, ProfileRFC4880Compat} {
+		kc, err := KeyGenProfile(name)
+		if err != nil {
+			t.Fatal(err)
+		}
+		e, err := kc.NewEntity("Golang Gopher", name, "no-reply@golang.com", config)
+		if err != nil {
+			t.Fatalf("%s: %s", name, err)
+		}
+		e = rereadEntity(t, e)
+		if len(e.Subkeys) != 1 {
+			t.Fatalf("%s: got %d subkeys, want 1", name, len(e.Subkeys))
+		}
+
+		const message = "testing key generation profiles"
+		var buf bytes.Buffer
+		w, err := Encrypt(&buf, []*Entity{e}, e, nil, config)
+		if err != nil {
+			t.Fatalf("%s: error encrypting: %s", name, err)
+		}
+		if _, err := w.Write([]byte(message)); err != nil {
+			t.Fatal(err)
+		}
+		if err := w.Close(); err != nil {
+			t.Fatal(err)
+		}
+
+		md, err := ReadMessage(&buf, EntityList{e}, nil, config)
+		if err != nil {
+			t.Fatalf("%s: error reading message: %s", name, err)
+		}
+		if md.DecryptedWith.PublicKey != e.Subkeys[0].PublicKey {
+			t.Errorf("%s: message not decrypted with the subkey", name)
+		}
+		contents, err := ioutil.ReadAll(md.UnverifiedBody)
+		if err != nil {
+			t.Fatalf("%s: error reading body: %s", name, err)
+		}
+		if string(contents) != message {
+			t.Errorf("%s: got %q, want %q", name, contents, message)
+		}
+		if md.SignatureError != nil || md.Signature == nil {
+			t.Errorf("%s: bad signature: %v", name, md.SignatureError)
+		}
+	}
+}
+
+func TestKeyGenLayout(t *testing.T) {
+	kc := &KeyGenConfig{
+		Primary: KeySpec{Algorithm: KeyEd25519, Usage: packet.KeyFlagCertify},
+		Subkeys: []KeySpec{
+			{Algorithm: KeyEd25519, Usage: packet.KeyFlagSign, Lifetime: 24 * time.Hour},
+			{Algorithm: KeyX25519, Usage: encryptionOnlyUsage, Lifetime: 48 * time.Hour},
+			{Algorithm: KeyEd25519, Usage: packet.KeyFlagAuthenticate},
+		},
+	}
+	e, err := kc.NewEntity("Golang Gopher", "", "no-reply@golang.com", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	e = rereadEntity(t, e)
+	if len(e.Subkeys) != 3 {
+		t.Fatalf("got %d subkeys, want 3", len(e.Subkeys))
+	}
+
+	selfSig := e.Identities["Golang Gopher <no-reply@golang.com>"].SelfSignature
+	if !selfSig.FlagCertify || selfSig.FlagSign {
+		t.Errorf("primary key flags: certify %v, sign %v", selfSig.FlagCertify, selfSig.FlagSign)
+	}
+	sign, encrypt, auth := e.Subkeys[0].Sig, e.Subkeys[1].Sig, e.Subkeys[2].Sig
+	if !sign.FlagSign || sign.KeyLifetimeSecs == nil || *sign.KeyLifetimeSecs != 24*60*60 {
+		t.Errorf("bad signing subkey binding: %#v", sign)
+	}
+	if sign.EmbeddedSignature == nil {
+		t.Errorf("signing subkey is not cross-signed")
+	}
+	if !encrypt.FlagEncryptCommunications || !encrypt.FlagEncryptStorage || encrypt.KeyLifetimeSecs == nil || *encrypt.KeyLifetimeSecs != 48*60*60 {
+		t.Errorf("bad encryption subkey binding: %#v", encrypt)
+	}
+	if !auth.FlagAuthenticate || auth.FlagSign || auth.KeyLifetimeSecs != nil {
+		t.Errorf("bad authentication subkey binding: %#v", auth)
+	}
+	if keys := (EntityList{e}).KeysByIdUsage(e.Subkeys[2].PublicKey.KeyId, packet.KeyFlagAuthenticate); len(keys) != 1 {
+		t.Errorf("got %d authentication keys, want 1", len(keys))
+	}
+
+	now := time.Now()
+	signer, err := e.SigningKey(now, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if signer.PublicKey != e.Subkeys[0].PublicKey {
+		t.Errorf("signing key is not the signing subkey")
+	}
+	if _, err := e.SigningKey(now.Add(25*time.Hour), nil); err == nil {
+		t.Errorf("expired signing subkey was selected")
+	}
+
+	const message = "testing key generation layouts"
+	var sig bytes.Buffer
+	if err := DetachSign(&sig, e, strings.NewReader(message), nil); err != nil {
+		t.Fatal(err)
+	}
+	p, err := packet.Read(bytes.NewReader(sig.Bytes()))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if s, ok := p.(*packet.Signature); !ok || *s.IssuerKeyId != e.Subkeys[0].PublicKey.KeyId {
+		t.Errorf("detached signature not issued by the signing subkey")
+	}
+	if _, err := CheckDetachedSignature(EntityList{e}, strings.NewReader(message), &sig); err != nil {
+		t.Errorf("error checking detached signature: %s", err)
+	}
+}
+
+func TestKeyGenInvalid(t *testing.T) {
+	tests := []struct {
+		desc string
+		kc   KeyGenConfig
+	}{
+		{"primary cannot certify", KeyGenConfig{
+			Primary: KeySpec{Algorithm: KeyEd25519, Usage: packet.KeyFlagSign},
+		}},
+		{"X25519 primary", KeyGenConfig{
+			Primary: KeySpec{Algorithm: KeyX25519, Usage: packet.KeyFlagCertify},
+		}},
+		{"encrypting Ed25519 subkey", KeyGenConfig{
+			Primary: KeySpec{Algorithm: KeyEd25519, Usage: packet.KeyFlagCertify},
+			Subkeys: []KeySpec{{Algorithm: KeyEd25519, Usage: packet.KeyFlagEncryptStorage}},
+		}},
+		{"subkey without usage", KeyGenConfig{
+			Primary: KeySpec{Algorithm: KeyEd25519, Usage: packet.KeyFlagCertify},
+			Subkeys: []KeySpec{{Algorithm: KeyX25519}},
+		}},
+		{"negative lifetime", KeyGenConfig{
+			Primary: KeySpec{Algorithm: KeyEd25519, Usage: packet.KeyFlagCertify, Lifetime: -time.Hour},
+		}},
+		{"negative RSA size", KeyGenConfig{
+			Primary: KeySpec{Algorithm: KeyRSA, Usage: packet.KeyFlagCertify, RSABits: -1},
+		}},
+		{"unknown algorithm", KeyGenConfig{
+			Primary: KeySpec{Algorithm: 42, Usage: packet.KeyFlagCertify},
+		}},
+	}
+	for _, test := range tests {
+		if _, err := test.kc.NewEntity("Golang Gopher", "", "no-reply@golang.com", nil); err == nil {
+			t.Errorf("%s: NewEntity succeeded", test.desc)
+		}
+	}
+
+	if _, err := KeyGenProfile("future-default"); err == nil {
+		t.Errorf("unknown profile was returned")
+	}
+}
+
+func TestKeyGenProfileCopy(t *testing.T) {
+	kc, err := KeyGenProfile(ProfileModern)
+	if err != nil {
+		t.Fatal(err)
+	}
+	kc.Subkeys[0].Lifetime = time.Hour
+	kc.Subkeys = append(kc.Subkeys, KeySpec{Algorithm: KeyEd25519, Usage: packet.KeyFlagSign})
+
+	kc, err = KeyGenProfile(ProfileModern)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(kc.Subkeys) != 1 || kc.Subkeys[0].Lifetime != 0 {
+		t.Errorf("changes to a profile were kept: %#v", kc)
+	}
+}
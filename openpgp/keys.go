@@ -5,10 +5,10 @@
 package openpgp
 
 import (
-	"crypto/rsa"
 	"io"
 	"time"
 
+	"golang.org/x/crypto/fingerprint"
 	"golang.org/x/crypto/openpgp/armor"
 	"golang.org/x/crypto/openpgp/errors"
 	"golang.org/x/crypto/openpgp/packet"
@@ -29,6 +29,10 @@ type Entity struct {
 	Identities  map[string]*Identity // indexed by Identity.Name
 	Revocations []*packet.Signature
 	Subkeys     []Subkey
+
+	// KeySelection, if not nil, is the policy used to choose the keys of
+	// this Entity when encrypting to it or signing with it.
+	KeySelection *KeySelectionPolicy
 }
 
 // An Identity represents an identity claimed by an Entity and zero or more
@@ -86,73 +90,133 @@ func (e *Entity) primaryIdentity() *Identity {
 	return firstIdentity
 }
 
-// encryptionKey returns the best candidate Key for encrypting a message to the
-// given Entity.
-func (e *Entity) encryptionKey(now time.Time) (Key, bool) {
-	candidateSubkey := -1
+// A KeySelectionPolicy controls how the key of an Entity used for encryption
+// or signing is chosen. With the zero value, the newest valid subkey marked
+// for the operation is chosen, and the primary key is used if there is no
+// such subkey.
+type KeySelectionPolicy struct {
+	// Fingerprint, if not nil, restricts the selection to the key, primary
+	// or subkey, with this fingerprint. The key must still be valid for the
+	// operation.
+	Fingerprint []byte
+}
 
-	// Iterate the keys to find the newest key
-	var maxTime time.Time
-	for i, subkey := range e.Subkeys {
-		if subkey.Sig.FlagsValid &&
-			subkey.Sig.FlagEncryptCommunications &&
-			subkey.PublicKey.PubKeyAlgo.CanEncrypt() &&
-			!subkey.Sig.KeyExpired(now) &&
-			(maxTime.IsZero() || subkey.Sig.CreationTime.After(maxTime)) {
-			candidateSubkey = i
-			maxTime = subkey.Sig.CreationTime
-		}
-	}
+// keyUsage describes what an operation requires from a key.
+type keyUsage struct {
+	name string
+	flag func(sig *packet.Signature) bool
+	algo func(algo packet.PublicKeyAlgorithm) bool
+}
 
-	if candidateSubkey != -1 {
-		subkey := e.Subkeys[candidateSubkey]
-		return Key{e, subkey.PublicKey, subkey.PrivateKey, subkey.Sig}, true
+var (
+	encryptionUsage = keyUsage{
+		name: "encryption",
+		flag: func(sig *packet.Signature) bool { return sig.FlagEncryptCommunications },
+		algo: packet.PublicKeyAlgorithm.CanEncrypt,
 	}
-
-	// If we don't have any candidate subkeys for encryption and
-	// the primary key doesn't have any usage metadata then we
-	// assume that the primary key is ok. Or, if the primary key is
-	// marked as ok to encrypt to, then we can obviously use it.
-	i := e.primaryIdentity()
-	if !i.SelfSignature.FlagsValid || i.SelfSignature.FlagEncryptCommunications &&
-		e.PrimaryKey.PubKeyAlgo.CanEncrypt() &&
-		!i.SelfSignature.KeyExpired(now) {
-		return Key{e, e.PrimaryKey, e.PrivateKey, i.SelfSignature}, true
+	signingUsage = keyUsage{
+		name: "signing",
+		flag: func(sig *packet.Signature) bool { return sig.FlagSign },
+		algo: packet.PublicKeyAlgorithm.CanSign,
 	}
+)
 
-	// This Entity appears to be signing only.
-	return Key{}, false
+// EncryptionKey returns the key to use to encrypt a message to e at time now,
+// chosen according to policy, which may be nil. If no key is usable, the
+// error is a *errors.KeySelectionError.
+func (e *Entity) EncryptionKey(now time.Time, policy *KeySelectionPolicy) (Key, error) {
+	return e.selectKey(now, policy, encryptionUsage)
 }
 
-// signingKey return the best candidate Key for signing a message with this
-// Entity.
-func (e *Entity) signingKey(now time.Time) (Key, bool) {
-	candidateSubkey := -1
+// SigningKey returns the key to use to sign a message with e at time now,
+// chosen according to policy, which may be nil. If no key is usable, the
+// error is a *errors.KeySelectionError.
+func (e *Entity) SigningKey(now time.Time, policy *KeySelectionPolicy) (Key, error) {
+	return e.selectKey(now, policy, signingUsage)
+}
 
+func (e *Entity) selectKey(now time.Time, policy *KeySelectionPolicy, usage keyUsage) (Key, error) {
+	if policy == nil {
+		policy = e.KeySelection
+	}
+	var pin []byte
+	if policy != nil {
+		pin = policy.Fingerprint
+	}
+	pinned := func(pk *packet.PublicKey) bool {
+		return pin == nil || fingerprint.Equal(pk.Fingerprint[:], pin)
+	}
+	selErr := &errors.KeySelectionError{Usage: usage.name, Rejected: make(map[uint64]error)}
+
+	// A revoked or expired primary key invalidates all the subkeys.
+	primarySig := e.primaryIdentity().SelfSignature
+	var primaryErr error
+	switch {
+	case len(e.Revocations) > 0:
+		primaryErr = errors.ErrKeyRevoked
+	case primarySig.KeyExpired(now):
+		primaryErr = errors.ErrKeyExpired
+	}
+
+	candidate := -1
 	for i, subkey := range e.Subkeys {
-		if subkey.Sig.FlagsValid &&
-			subkey.Sig.FlagSign &&
-			subkey.PublicKey.PubKeyAlgo.CanSign() &&
-			!subkey.Sig.KeyExpired(now) {
-			candidateSubkey = i
-			break
+		var reason error
+		switch {
+		case primaryErr != nil:
+			reason = primaryErr
+		case !pinned(subkey.PublicKey):
+			reason = errors.ErrKeyNotPinned
+		case subkey.Sig.SigType == packet.SigTypeSubkeyRevocation:
+			reason = errors.ErrKeyRevoked
+		case !subkey.Sig.FlagsValid || !usage.flag(subkey.Sig) || !usage.algo(subkey.PublicKey.PubKeyAlgo):
+			reason = errors.ErrKeyUsage
+		case subkey.Sig.KeyExpired(now):
+			reason = errors.ErrKeyExpired
+		}
+		if reason != nil {
+			selErr.Rejected[subkey.PublicKey.KeyId] = reason
+			continue
+		}
+		if candidate == -1 || subkey.Sig.CreationTime.After(e.Subkeys[candidate].Sig.CreationTime) {
+			candidate = i
 		}
 	}
 
-	if candidateSubkey != -1 {
-		subkey := e.Subkeys[candidateSubkey]
-		return Key{e, subkey.PublicKey, subkey.PrivateKey, subkey.Sig}, true
+	if candidate != -1 {
+		subkey := e.Subkeys[candidate]
+		return Key{e, subkey.PublicKey, subkey.PrivateKey, subkey.Sig}, nil
 	}
 
-	// If we have no candidate subkey then we assume that it's ok to sign
-	// with the primary key.
-	i := e.primaryIdentity()
-	if !i.SelfSignature.FlagsValid || i.SelfSignature.FlagSign &&
-		!i.SelfSignature.KeyExpired(now) {
-		return Key{e, e.PrimaryKey, e.PrivateKey, i.SelfSignature}, true
+	// If there is no usable subkey, the primary key may be used if it is
+	// marked for the operation, or if it has no usage metadata at all.
+	var reason error
+	switch {
+	case primaryErr != nil:
+		reason = primaryErr
+	case !pinned(e.PrimaryKey):
+		reason = errors.ErrKeyNotPinned
+	case primarySig.FlagsValid && !usage.flag(primarySig) || !usage.algo(e.PrimaryKey.PubKeyAlgo):
+		reason = errors.ErrKeyUsage
+	}
+	if reason != nil {
+		selErr.Rejected[e.PrimaryKey.KeyId] = reason
+		return Key{}, selErr
 	}
+	return Key{e, e.PrimaryKey, e.PrivateKey, primarySig}, nil
+}
 
-	return Key{}, false
+// encryptionKey returns the best candidate Key for encrypting a message to the
+// given Entity.
+func (e *Entity) encryptionKey(now time.Time) (Key, bool) {
+	key, err := e.EncryptionKey(now, nil)
+	return key, err == nil
+}
+
+// signingKey return the best candidate Key for signing a message with this
+// Entity.
+func (e *Entity) signingKey(now time.Time) (Key, bool) {
+	key, err := e.SigningKey(now, nil)
+	return key, err == nil
 }
 
 // An EntityList contains one or more Entities.
@@ -210,6 +274,9 @@ func (el EntityList) KeysByIdUsage(id uint64, requiredUsage byte) (keys []Key) {
 			if key.SelfSignature.FlagEncryptStorage {
 				usage |= packet.KeyFlagEncryptStorage
 			}
+			if key.SelfSignature.FlagAuthenticate {
+				usage |= packet.KeyFlagAuthenticate
+			}
 			if usage&requiredUsage != requiredUsage {
 				continue
 			}
@@ -456,93 +523,20 @@ const defaultRSAKeyBits = 2048
 
 // NewEntity returns an Entity that contains a fresh RSA/RSA keypair with a
 // single identity composed of the given full name, comment and email, any of
-// which may be empty but must not contain any of "()<>\x00".
+// which may be empty but must not contain any of "()<>\x00". The keys are
+// those of ProfileRFC4880Compat; use KeyGenConfig.NewEntity for others.
 // If config is nil, sensible defaults will be used.
 func NewEntity(name, comment, email string, config *packet.Config) (*Entity, error) {
-	currentTime := config.Now()
-
-	bits := defaultRSAKeyBits
-	if config != nil && config.RSABits != 0 {
-		bits = config.RSABits
-	}
-
-	uid := packet.NewUserId(name, comment, email)
-	if uid == nil {
-		return nil, errors.InvalidArgumentError("user id field contained invalid characters")
-	}
-	signingPriv, err := rsa.GenerateKey(config.Random(), bits)
-	if err != nil {
-		return nil, err
-	}
-	encryptingPriv, err := rsa.GenerateKey(config.Random(), bits)
+	kc, err := KeyGenProfile(ProfileRFC4880Compat)
 	if err != nil {
 		return nil, err
 	}
-
-	e := &Entity{
-		PrimaryKey: packet.NewRSAPublicKey(currentTime, &signingPriv.PublicKey),
-		PrivateKey: packet.NewRSAPrivateKey(currentTime, signingPriv),
-		Identities: make(map[string]*Identity),
-	}
-	isPrimaryId := true
-	e.Identities[uid.Id] = &Identity{
-		Name:   uid.Id,
-		UserId: uid,
-		SelfSignature: &packet.Signature{
-			CreationTime: currentTime,
-			SigType:      packet.SigTypePositiveCert,
-			PubKeyAlgo:   packet.PubKeyAlgoRSA,
-			Hash:         config.Hash(),
-			IsPrimaryId:  &isPrimaryId,
-			FlagsValid:   true,
-			FlagSign:     true,
-			FlagCertify:  true,
-			IssuerKeyId:  &e.PrimaryKey.KeyId,
-		},
-	}
-	err = e.Identities[uid.Id].SelfSignature.SignUserId(uid.Id, e.PrimaryKey, e.PrivateKey, config)
-	if err != nil {
-		return nil, err
-	}
-
-	// If the user passes in a DefaultHash via packet.Config,
-	// set the PreferredHash for the SelfSignature.
-	if config != nil && config.DefaultHash != 0 {
-		e.Identities[uid.Id].SelfSignature.PreferredHash = []uint8{hashToHashId(config.DefaultHash)}
-	}
-
-	// Likewise for DefaultCipher.
-	if config != nil && config.DefaultCipher != 0 {
-		e.Identities[uid.Id].SelfSignature.PreferredSymmetric = []uint8{uint8(config.DefaultCipher)}
-	}
-
-	e.Subkeys = make([]Subkey, 1)
-	e.Subkeys[0] = Subkey{
-		PublicKey:  packet.NewRSAPublicKey(currentTime, &encryptingPriv.PublicKey),
-		PrivateKey: packet.NewRSAPrivateKey(currentTime, encryptingPriv),
-		Sig: &packet.Signature{
-			CreationTime:              currentTime,
-			SigType:                   packet.SigTypeSubkeyBinding,
-			PubKeyAlgo:                packet.PubKeyAlgoRSA,
-			Hash:                      config.Hash(),
-			FlagsValid:                true,
-			FlagEncryptStorage:        true,
-			FlagEncryptCommunications: true,
-			IssuerKeyId:               &e.PrimaryKey.KeyId,
-		},
-	}
-	e.Subkeys[0].PublicKey.IsSubkey = true
-	e.Subkeys[0].PrivateKey.IsSubkey = true
-	err = e.Subkeys[0].Sig.SignKey(e.Subkeys[0].PublicKey, e.PrivateKey, config)
-	if err != nil {
-		return nil, err
-	}
-	return e, nil
+	return kc.NewEntity(name, comment, email, config)
 }
 
 // SerializePrivate serializes an Entity, including private key material, to
 // the given Writer. For now, it must only be used on an Entity returned from
-// NewEntity.
+// NewEntity or KeyGenConfig.NewEntity.
 // config is ignored
 func (e *Entity) SerializePrivate(w io.Writer, config *packet.Config) (err error) {
 	err = e.PrivateKey.Serialize(w)
@@ -481,3 +481,114 @@ SqLHvbKh2dL/RXymC3+rjPvQf5cup9bPxNMa6WagdYBNAfzWGtkVISeaQW+cTEp/
 MtgVijRGXR/lGLGETPg2X3Afwn9N9bLMBkBprKgbBqU7lpaoPupxT61bL70=
 =vtbN
 -----END PGP PUBLIC KEY BLOCK-----`
+
+func TestKeySelection(t *testing.T) {
+	kring, err := ReadKeyRing(readerFromHex(subkeyUsageHex))
+	if err != nil {
+		t.Fatal(err)
+	}
+	entity := kring[0]
+	now := time.Now()
+
+	// subkeyUsageHex is described in TestKeyUsage. The newest subkey marked
+	// for each operation should be selected.
+	key, err := entity.EncryptionKey(now, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if id := key.PublicKey.KeyIdShortString(); id != "64D5F5BB" {
+		t.Errorf("Expected encryption key 64D5F5BB, but got key %s", id)
+	}
+	key, err = entity.SigningKey(now, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if id := key.PublicKey.KeyIdShortString(); id != "BC0BA992" {
+		t.Errorf("Expected signing key BC0BA992, but got key %s", id)
+	}
+
+	// Pinning an older subkey selects it instead.
+	older := entity.Subkeys[0].PublicKey
+	policy := &KeySelectionPolicy{Fingerprint: older.Fingerprint[:]}
+	key, err = entity.EncryptionKey(now, policy)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if key.PublicKey != older {
+		t.Errorf("Expected pinned key %s, but got key %s", older.KeyIdShortString(), key.PublicKey.KeyIdShortString())
+	}
+
+	// The policy of the Entity is used when none is given.
+	entity.KeySelection = policy
+	key, _ = entity.encryptionKey(now)
+	if key.PublicKey != older {
+		t.Errorf("Expected pinned key %s, but got key %s", older.KeyIdShortString(), key.PublicKey.KeyIdShortString())
+	}
+	entity.KeySelection = nil
+
+	// Pinning a key that cannot be used for the operation fails.
+	policy = &KeySelectionPolicy{Fingerprint: entity.PrimaryKey.Fingerprint[:]}
+	_, err = entity.EncryptionKey(now, policy)
+	selErr, ok := err.(*errors.KeySelectionError)
+	if !ok {
+		t.Fatalf("Expected a KeySelectionError, but got %v", err)
+	}
+	if selErr.Usage != "encryption" {
+		t.Errorf("Expected usage \"encryption\", but got %q", selErr.Usage)
+	}
+	want := map[uint64]error{
+		0xA42704B92866382A: errors.ErrKeyUsage,
+		0x09C0C7D9936C9153: errors.ErrKeyNotPinned,
+		0xC104E98664D5F5BB: errors.ErrKeyNotPinned,
+		0x42CE2C64BC0BA992: errors.ErrKeyNotPinned,
+	}
+	if len(selErr.Rejected) != len(want) {
+		t.Errorf("Expected %d rejected keys, but got %d", len(want), len(selErr.Rejected))
+	}
+	for id, reason := range want {
+		if got := selErr.Rejected[id]; got != reason {
+			t.Errorf("Expected key %X to be rejected with %q, but got %v", id, reason, got)
+		}
+	}
+}
+
+func TestKeySelectionExpired(t *testing.T) {
+	kring, err := ReadKeyRing(readerFromHex(expiringKeyHex))
+	if err != nil {
+		t.Fatal(err)
+	}
+	entity := kring[0]
+
+	// The first encryption subkey of expiringKeyHex expires on 2013-07-08.
+	// Pinning it works until then.
+	first := entity.Subkeys[0].PublicKey
+	policy := &KeySelectionPolicy{Fingerprint: first.Fingerprint[:]}
+	before, _ := time.Parse("2006-01-02", "2013-07-02")
+	if _, err := entity.EncryptionKey(before, policy); err != nil {
+		t.Errorf("Expected pinned key %s to be usable: %v", first.KeyIdShortString(), err)
+	}
+	after, _ := time.Parse("2006-01-02", "2013-07-09")
+	_, err = entity.EncryptionKey(after, policy)
+	selErr, ok := err.(*errors.KeySelectionError)
+	if !ok {
+		t.Fatalf("Expected a KeySelectionError, but got %v", err)
+	}
+	if reason := selErr.Rejected[first.KeyId]; reason != errors.ErrKeyExpired {
+		t.Errorf("Expected key %s to be rejected with %q, but got %v", first.KeyIdShortString(), errors.ErrKeyExpired, reason)
+	}
+
+	// Once the primary key has expired, every key is rejected.
+	expired, _ := time.Parse("2006-01-02", "2013-08-01")
+	_, err = entity.SigningKey(expired, nil)
+	if selErr, ok = err.(*errors.KeySelectionError); !ok {
+		t.Fatalf("Expected a KeySelectionError, but got %v", err)
+	}
+	if len(selErr.Rejected) != 3 {
+		t.Errorf("Expected 3 rejected keys, but got %d", len(selErr.Rejected))
+	}
+	for id, reason := range selErr.Rejected {
+		if reason != errors.ErrKeyExpired {
+			t.Errorf("Expected key %X to be rejected with %q, but got %v", id, errors.ErrKeyExpired, reason)
+		}
+	}
+}
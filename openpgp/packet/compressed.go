@@ -11,6 +11,7 @@ import (
 	"golang.org/x/crypto/openpgp/errors"
 	"io"
 	"strconv"
+	"sync"
 )
 
 // Compressed represents a compressed OpenPGP packet. The decompressed contents
@@ -39,6 +40,54 @@ type CompressionConfig struct {
 	Level int
 }
 
+// The compression algorithms added by RegisterDecompressor and
+// RegisterCompressor.
+var (
+	codecsMu      sync.RWMutex
+	decompressors = make(map[CompressionAlgo]func(io.Reader) (io.Reader, error))
+	compressors   = make(map[CompressionAlgo]func(io.Writer, int) (io.WriteCloser, error))
+)
+
+// RegisterDecompressor makes compressed packets that use algo readable, by
+// decompressing them with newReader, which must return a reader of the data
+// decompressed from r. It is meant for algorithms that this package does not
+// implement, such as zstd, which RFC 4880 does not define and which can only
+// be used with one of the private or experimental ids, or with ids defined
+// by later standards. It panics if algo is implemented by this package or
+// already registered.
+func RegisterDecompressor(algo CompressionAlgo, newReader func(r io.Reader) (io.Reader, error)) {
+	codecsMu.Lock()
+	defer codecsMu.Unlock()
+	if algo <= CompressionBZIP2 {
+		panic("openpgp: compression algorithm " + strconv.Itoa(int(algo)) + " is implemented by this package")
+	}
+	if decompressors[algo] != nil {
+		panic("openpgp: compression algorithm " + strconv.Itoa(int(algo)) + " registered twice")
+	}
+	decompressors[algo] = newReader
+}
+
+// RegisterCompressor makes SerializeCompressed, and thus the DefaultCompressionAlgo
+// of Config, accept algo, by compressing with newWriter, which must return a
+// writer that compresses to w at the given level, as CompressionConfig.Level,
+// and whose Close method flushes the compressed data without closing w.
+//
+// Other implementations cannot read algorithms that RFC 4880 does not define,
+// so, for use within closed ecosystems, algo must be one of the private or
+// experimental ids, from CompressionPrivateMin to CompressionPrivateMax. It
+// panics otherwise, or if algo is already registered.
+func RegisterCompressor(algo CompressionAlgo, newWriter func(w io.Writer, level int) (io.WriteCloser, error)) {
+	codecsMu.Lock()
+	defer codecsMu.Unlock()
+	if algo < CompressionPrivateMin || algo > CompressionPrivateMax {
+		panic("openpgp: compression algorithm " + strconv.Itoa(int(algo)) + " is not a private or experimental id")
+	}
+	if compressors[algo] != nil {
+		panic("openpgp: compression algorithm " + strconv.Itoa(int(algo)) + " registered twice")
+	}
+	compressors[algo] = newWriter
+}
+
 func (c *Compressed) parse(r io.Reader) error {
 	var buf [1]byte
 	_, err := readFull(r, buf[:])
@@ -46,15 +95,21 @@ func (c *Compressed) parse(r io.Reader) error {
 		return err
 	}
 
-	switch buf[0] {
-	case 1:
+	switch algo := CompressionAlgo(buf[0]); algo {
+	case CompressionZIP:
 		c.Body = flate.NewReader(r)
-	case 2:
+	case CompressionZLIB:
 		c.Body, err = zlib.NewReader(r)
-	case 3:
+	case CompressionBZIP2:
 		c.Body = bzip2.NewReader(r)
 	default:
-		err = errors.UnsupportedError("unknown compression algorithm: " + strconv.Itoa(int(buf[0])))
+		codecsMu.RLock()
+		newReader := decompressors[algo]
+		codecsMu.RUnlock()
+		if newReader == nil {
+			return errors.UnsupportedError("unknown compression algorithm: " + strconv.Itoa(int(buf[0])))
+		}
+		c.Body, err = newReader(r)
 	}
 
 	return err
@@ -110,8 +165,15 @@ func SerializeCompressed(w io.WriteCloser, algo CompressionAlgo, cc *Compression
 	case CompressionZLIB:
 		compressor, err = zlib.NewWriterLevel(compressed, level)
 	default:
-		s := strconv.Itoa(int(algo))
-		err = errors.UnsupportedError("Unsupported compression algorithm: " + s)
+		codecsMu.RLock()
+		newWriter := compressors[algo]
+		codecsMu.RUnlock()
+		if newWriter == nil {
+			s := strconv.Itoa(int(algo))
+			err = errors.UnsupportedError("Unsupported compression algorithm: " + s)
+			break
+		}
+		compressor, err = newWriter(compressed, level)
 	}
 	if err != nil {
 		return
@@ -6,9 +6,11 @@ package packet
 
 import (
 	"bytes"
+	"compress/flate"
 	"encoding/hex"
 	"io"
 	"io/ioutil"
+	"strings"
 	"testing"
 )
 
@@ -39,3 +41,76 @@ func TestCompressed(t *testing.T) {
 
 const compressedHex = "a3013b2d90c4e02b72e25f727e5e496a5e49b11e1700"
 const compressedExpectedHex = "cb1062004d14c8fe636f6e74656e74732e0a"
+
+// testPrivateAlgo is a private compression algorithm, registered as raw
+// DEFLATE, which is enough to exercise the registration.
+const testPrivateAlgo = CompressionPrivateMin + 3
+
+func init() {
+	RegisterDecompressor(testPrivateAlgo, func(r io.Reader) (io.Reader, error) {
+		return flate.NewReader(r), nil
+	})
+	RegisterCompressor(testPrivateAlgo, func(w io.Writer, level int) (io.WriteCloser, error) {
+		return flate.NewWriter(w, level)
+	})
+}
+
+func TestRegisteredCompression(t *testing.T) {
+	buf := new(bytes.Buffer)
+	w, err := SerializeCompressed(noOpCloser{buf}, testPrivateAlgo, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	message := bytes.Repeat([]byte("hello world\n"), 100)
+	if _, err := w.Write(message); err != nil {
+		t.Fatal(err)
+	}
+	if err := w.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	p, err := Read(buf)
+	if err != nil {
+		t.Fatal(err)
+	}
+	c, ok := p.(*Compressed)
+	if !ok {
+		t.Fatalf("got %T, want *Compressed", p)
+	}
+	contents, err := ioutil.ReadAll(c.Body)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(contents, message) {
+		t.Errorf("got %q, want %q", contents, message)
+	}
+
+	// An unregistered private algorithm is still unsupported.
+	if _, err := SerializeCompressed(noOpCloser{buf}, CompressionPrivateMax, nil); err == nil {
+		t.Error("unregistered algorithm accepted for writing")
+	}
+}
+
+func TestRegisterCompressionPanics(t *testing.T) {
+	newReader := func(r io.Reader) (io.Reader, error) { return r, nil }
+	newWriter := func(w io.Writer, level int) (io.WriteCloser, error) { return nil, nil }
+	for name, tt := range map[string]struct {
+		f    func()
+		want string
+	}{
+		"built-in decompressor":  {func() { RegisterDecompressor(CompressionBZIP2, newReader) }, "implemented by this package"},
+		"duplicate decompressor": {func() { RegisterDecompressor(testPrivateAlgo, newReader) }, "registered twice"},
+		"standard compressor":    {func() { RegisterCompressor(CompressionZLIB, newWriter) }, "not a private or experimental id"},
+		"unassigned compressor":  {func() { RegisterCompressor(CompressionPrivateMax+1, newWriter) }, "not a private or experimental id"},
+		"duplicate compressor":   {func() { RegisterCompressor(testPrivateAlgo, newWriter) }, "registered twice"},
+	} {
+		func() {
+			defer func() {
+				if r, _ := recover().(string); !strings.Contains(r, tt.want) {
+					t.Errorf("%s: panicked with %q, want %q", name, r, tt.want)
+				}
+			}()
+			tt.f()
+		}()
+	}
+}
@@ -46,6 +46,12 @@ type Config struct {
 	// RSABits is the number of bits in new RSA keys made with NewEntity.
 	// If zero, then 2048 bit keys are created.
 	RSABits int
+	// MaxDecompressedSize limits the size of the decompressed contents of
+	// each compressed packet of a message read with ReadMessage, so that a
+	// small message cannot expand into an unbounded amount of data.
+	// Reading past the limit returns errors.ErrDecompressedTooLarge. If
+	// zero, there is no limit.
+	MaxDecompressedSize int64
 }
 
 func (c *Config) Random() io.Reader {
@@ -89,3 +95,10 @@ func (c *Config) PasswordHashIterations() int {
 	}
 	return c.S2KCount
 }
+
+func (c *Config) DecompressedSizeLimit() int64 {
+	if c == nil {
+		return 0
+	}
+	return c.MaxDecompressedSize
+}
@@ -0,0 +1,214 @@
+// Copyright 2018 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package packet
+
+import (
+	"bytes"
+	"crypto/aes"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/subtle"
+	"encoding/binary"
+	"io"
+	"math/big"
+	"strconv"
+
+	"golang.org/x/crypto/curve25519"
+	"golang.org/x/crypto/openpgp/errors"
+	"golang.org/x/crypto/openpgp/s2k"
+)
+
+// ECDH session keys are wrapped with a key derived from the shared point, as
+// described in RFC 6637, Sections 7 and 8.
+
+// ecdhKDFParam returns the parameters of the key derivation for pub, which
+// bind the derived key to the recipient. See RFC 6637, Section 8.
+func ecdhKDFParam(pub *PublicKey) []byte {
+	var param bytes.Buffer
+	param.WriteByte(byte(len(pub.ec.oid)))
+	param.Write(pub.ec.oid)
+	param.WriteByte(byte(PubKeyAlgoECDH))
+	pub.ecdh.serialize(&param)
+	param.WriteString("Anonymous Sender    ")
+	param.Write(pub.Fingerprint[:])
+	return param.Bytes()
+}
+
+// ecdhKEK derives the key that wraps session keys encrypted to pub from the
+// shared secret z.
+func ecdhKEK(pub *PublicKey, z []byte) ([]byte, error) {
+	h, ok := s2k.HashIdToHash(byte(pub.ecdh.KdfHash))
+	if !ok || !h.Available() {
+		return nil, errors.UnsupportedError("ECDH KDF hash function " + strconv.Itoa(int(pub.ecdh.KdfHash)))
+	}
+	cipherFunc := CipherFunction(pub.ecdh.KdfAlgo)
+	switch cipherFunc {
+	case CipherAES128, CipherAES192, CipherAES256:
+	default:
+		return nil, errors.UnsupportedError("ECDH key wrap cipher " + strconv.Itoa(int(cipherFunc)))
+	}
+	if h.Size() < cipherFunc.KeySize() {
+		return nil, errors.UnsupportedError("ECDH KDF hash function too short for cipher")
+	}
+
+	d := h.New()
+	d.Write([]byte{0, 0, 0, 1})
+	d.Write(z)
+	d.Write(ecdhKDFParam(pub))
+	return d.Sum(nil)[:cipherFunc.KeySize()], nil
+}
+
+// ecdhEncapsulate generates an ephemeral key pair and returns its public
+// point, and the secret it shares with pub.
+func ecdhEncapsulate(rand io.Reader, pub *PublicKey) (ephemeral parsedMPI, z []byte, err error) {
+	switch key := pub.PublicKey.(type) {
+	case *[32]byte:
+		var e, v, s [32]byte
+		if _, err = io.ReadFull(rand, e[:]); err != nil {
+			return
+		}
+		curve25519.ScalarBaseMult(&v, &e)
+		curve25519.ScalarMult(&s, &e, key)
+		return nativePoint(v[:]), s[:], nil
+	case *ecdsa.PublicKey:
+		var d []byte
+		var x, y *big.Int
+		d, x, y, err = elliptic.GenerateKey(key.Curve, rand)
+		if err != nil {
+			return
+		}
+		ephemeral.bytes = elliptic.Marshal(key.Curve, x, y)
+		fieldBits := (key.Curve.Params().BitSize + 7) & ^7
+		ephemeral.bitLength = uint16(3 + fieldBits + fieldBits)
+		sx, _ := key.Curve.ScalarMult(key.X, key.Y, d)
+		return ephemeral, ecdhSecretBytes(key.Curve, sx), nil
+	}
+	return ephemeral, nil, errors.InvalidArgumentError("unknown ECDH public key type")
+}
+
+// ecdhDecapsulate returns the secret shared by priv and the ephemeral public
+// point of the sender.
+func ecdhDecapsulate(priv *PrivateKey, ephemeral []byte) ([]byte, error) {
+	switch key := priv.PrivateKey.(type) {
+	case *[32]byte:
+		if len(ephemeral) != 33 || ephemeral[0] != 0x40 {
+			return nil, errors.StructuralError("bad ECDH ephemeral point")
+		}
+		var v, s, zero [32]byte
+		copy(v[:], ephemeral[1:])
+		curve25519.ScalarMult(&s, key, &v)
+		// A low-order point yields an all-zero secret, which would let
+		// the sender choose the key encryption key regardless of ours.
+		if subtle.ConstantTimeCompare(s[:], zero[:]) == 1 {
+			return nil, errors.StructuralError("bad ECDH ephemeral point")
+		}
+		return s[:], nil
+	case *ecdsa.PrivateKey:
+		x, y := elliptic.Unmarshal(key.Curve, ephemeral)
+		if x == nil {
+			return nil, errors.StructuralError("bad ECDH ephemeral point")
+		}
+		sx, _ := key.Curve.ScalarMult(x, y, key.D.Bytes())
+		return ecdhSecretBytes(key.Curve, sx), nil
+	}
+	return nil, errors.InvalidArgumentError("unknown ECDH private key type")
+}
+
+// ecdhSecretBytes returns the x coordinate of the shared point on a NIST
+// curve, encoded with the length of a field element.
+func ecdhSecretBytes(curve elliptic.Curve, x *big.Int) []byte {
+	z := make([]byte, (curve.Params().BitSize+7)/8)
+	b := x.Bytes()
+	copy(z[len(z)-len(b):], b)
+	return z
+}
+
+// keyWrapIV is the initial value of the AES key wrap algorithm of RFC 3394.
+var keyWrapIV = [8]byte{0xa6, 0xa6, 0xa6, 0xa6, 0xa6, 0xa6, 0xa6, 0xa6}
+
+// keyWrap wraps plaintext, whose length must be a multiple of 8 of at least
+// 16 bytes, with kek, as in RFC 3394, Section 2.2.1.
+func keyWrap(kek, plaintext []byte) ([]byte, error) {
+	if len(plaintext)%8 != 0 || len(plaintext) < 16 {
+		return nil, errors.InvalidArgumentError("key wrap input must be a multiple of 8 bytes")
+	}
+	block, err := aes.NewCipher(kek)
+	if err != nil {
+		return nil, err
+	}
+	n := len(plaintext) / 8
+	out := make([]byte, 8+len(plaintext))
+	copy(out[8:], plaintext)
+	a := keyWrapIV
+	var b [16]byte
+	for j := 0; j < 6; j++ {
+		for i := 1; i <= n; i++ {
+			copy(b[:8], a[:])
+			copy(b[8:], out[8*i:])
+			block.Encrypt(b[:], b[:])
+			t := uint64(n*j + i)
+			binary.BigEndian.PutUint64(a[:], binary.BigEndian.Uint64(b[:8])^t)
+			copy(out[8*i:], b[8:])
+		}
+	}
+	copy(out, a[:])
+	return out, nil
+}
+
+// keyUnwrap reverses keyWrap, as in RFC 3394, Section 2.2.2, and checks the
+// integrity of the result.
+func keyUnwrap(kek, ciphertext []byte) ([]byte, error) {
+	if len(ciphertext)%8 != 0 || len(ciphertext) < 24 {
+		return nil, errors.StructuralError("wrapped key has bad length")
+	}
+	block, err := aes.NewCipher(kek)
+	if err != nil {
+		return nil, err
+	}
+	n := len(ciphertext)/8 - 1
+	out := make([]byte, len(ciphertext)-8)
+	copy(out, ciphertext[8:])
+	var a [8]byte
+	copy(a[:], ciphertext)
+	var b [16]byte
+	for j := 5; j >= 0; j-- {
+		for i := n; i >= 1; i-- {
+			t := uint64(n*j + i)
+			binary.BigEndian.PutUint64(b[:8], binary.BigEndian.Uint64(a[:])^t)
+			copy(b[8:], out[8*(i-1):])
+			block.Decrypt(b[:], b[:])
+			copy(a[:], b[:8])
+			copy(out[8*(i-1):], b[8:])
+		}
+	}
+	if subtle.ConstantTimeCompare(a[:], keyWrapIV[:]) != 1 {
+		return nil, errors.StructuralError("wrapped key integrity check failed")
+	}
+	return out, nil
+}
+
+// padKeyBlock pads a session key block to a multiple of 8 bytes, as in
+// PKCS #5, so that it can be wrapped. See RFC 6637, Section 8.
+func padKeyBlock(keyBlock []byte) []byte {
+	padding := 8 - len(keyBlock)%8
+	return append(keyBlock, bytes.Repeat([]byte{byte(padding)}, padding)...)
+}
+
+// unpadKeyBlock removes the padding added by padKeyBlock.
+func unpadKeyBlock(b []byte) ([]byte, error) {
+	if len(b) == 0 {
+		return nil, errors.StructuralError("empty ECDH key block")
+	}
+	padding := int(b[len(b)-1])
+	if padding == 0 || padding > 8 || padding > len(b) {
+		return nil, errors.StructuralError("bad ECDH key block padding")
+	}
+	for _, v := range b[len(b)-padding:] {
+		if int(v) != padding {
+			return nil, errors.StructuralError("bad ECDH key block padding")
+		}
+	}
+	return b[:len(b)-padding], nil
+}
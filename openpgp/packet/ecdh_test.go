@@ -0,0 +1,141 @@
+// Copyright 2018 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package packet
+
+import (
+	"bytes"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"encoding/hex"
+	"testing"
+	"time"
+)
+
+func TestKeyWrap(t *testing.T) {
+	// RFC 3394, Section 4.1.
+	kek, _ := hex.DecodeString("000102030405060708090A0B0C0D0E0F")
+	plaintext, _ := hex.DecodeString("00112233445566778899AABBCCDDEEFF")
+	expected, _ := hex.DecodeString("1FA68B0A8112B447AEF34BD8FB5A7B829D3E862371D2CFE5")
+
+	wrapped, err := keyWrap(kek, plaintext)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(wrapped, expected) {
+		t.Fatalf("keyWrap = %x, want %x", wrapped, expected)
+	}
+	unwrapped, err := keyUnwrap(kek, wrapped)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(unwrapped, plaintext) {
+		t.Fatalf("keyUnwrap = %x, want %x", unwrapped, plaintext)
+	}
+
+	wrapped[len(wrapped)-1] ^= 1
+	if _, err := keyUnwrap(kek, wrapped); err == nil {
+		t.Fatal("keyUnwrap accepted a modified key")
+	}
+}
+
+func TestKeyBlockPadding(t *testing.T) {
+	for n := 1; n <= 40; n++ {
+		block := bytes.Repeat([]byte{0x42}, n)
+		padded := padKeyBlock(append([]byte(nil), block...))
+		if len(padded)%8 != 0 || len(padded) <= n {
+			t.Fatalf("padKeyBlock of %d bytes returned %d bytes", n, len(padded))
+		}
+		unpadded, err := unpadKeyBlock(padded)
+		if err != nil || !bytes.Equal(unpadded, block) {
+			t.Fatalf("unpadKeyBlock(%x) = %x, %v", padded, unpadded, err)
+		}
+	}
+	for _, bad := range []string{"", "00", "0102030405060709", "4242424242420303"} {
+		b, _ := hex.DecodeString(bad)
+		if _, err := unpadKeyBlock(b); err == nil {
+			t.Errorf("unpadKeyBlock(%s) succeeded", bad)
+		}
+	}
+}
+
+func testECDHEncryptedKey(t *testing.T, priv *PrivateKey) {
+	key := []byte{1, 2, 3, 4, 5, 6, 7, 8, 9, 10, 11, 12, 13, 14, 15, 16}
+
+	buf := new(bytes.Buffer)
+	if err := SerializeEncryptedKey(buf, &priv.PublicKey, CipherAES128, key, nil); err != nil {
+		t.Fatalf("error writing encrypted key packet: %s", err)
+	}
+	serialized := buf.Bytes()
+
+	p, err := Read(bytes.NewReader(serialized))
+	if err != nil {
+		t.Fatalf("error from Read: %s", err)
+	}
+	ek, ok := p.(*EncryptedKey)
+	if !ok {
+		t.Fatalf("didn't parse an EncryptedKey, got %#v", p)
+	}
+	if ek.KeyId != priv.KeyId || ek.Algo != PubKeyAlgoECDH {
+		t.Fatalf("unexpected EncryptedKey contents: %#v", ek)
+	}
+
+	buf.Reset()
+	if err := ek.Serialize(buf); err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(buf.Bytes(), serialized) {
+		t.Errorf("reserialized EncryptedKey differs:\n%x\n%x", buf.Bytes(), serialized)
+	}
+
+	if err := ek.Decrypt(priv, nil); err != nil {
+		t.Fatalf("error from Decrypt: %s", err)
+	}
+	if ek.CipherFunc != CipherAES128 || !bytes.Equal(ek.Key, key) {
+		t.Errorf("bad key, got %d %x want %d %x", ek.CipherFunc, ek.Key, CipherAES128, key)
+	}
+}
+
+func TestX25519EncryptedKey(t *testing.T) {
+	var secret [32]byte
+	if _, err := rand.Read(secret[:]); err != nil {
+		t.Fatal(err)
+	}
+	testECDHEncryptedKey(t, NewX25519PrivateKey(time.Now(), &secret))
+}
+
+func TestX25519EncryptedKeyLowOrderPoint(t *testing.T) {
+	var secret [32]byte
+	if _, err := rand.Read(secret[:]); err != nil {
+		t.Fatal(err)
+	}
+	priv := NewX25519PrivateKey(time.Now(), &secret)
+	// The point of order two, u = 0, yields an all-zero shared secret.
+	ephemeral := append([]byte{0x40}, make([]byte, 32)...)
+	if _, err := ecdhDecapsulate(priv, ephemeral); err == nil {
+		t.Fatal("low-order ephemeral point accepted")
+	}
+}
+
+func TestP256EncryptedKey(t *testing.T) {
+	ecdsaPriv, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	priv := &PrivateKey{
+		PublicKey: PublicKey{
+			CreationTime: time.Now(),
+			PubKeyAlgo:   PubKeyAlgoECDH,
+			PublicKey:    &ecdsaPriv.PublicKey,
+			ec:           &ecdsaKey{oid: oidCurveP256},
+			ecdh:         &ecdhKdf{KdfHash: 8, KdfAlgo: kdfAlgorithm(CipherAES128)},
+		},
+		PrivateKey: ecdsaPriv,
+	}
+	priv.ec.p.bytes = elliptic.Marshal(ecdsaPriv.Curve, ecdsaPriv.X, ecdsaPriv.Y)
+	priv.ec.p.bitLength = 515
+	priv.setFingerPrintAndKeyId()
+	testECDHEncryptedKey(t, priv)
+}
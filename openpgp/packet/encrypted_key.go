@@ -25,6 +25,8 @@ type EncryptedKey struct {
 	CipherFunc CipherFunction // only valid after a successful Decrypt
 	Key        []byte         // only valid after a successful Decrypt
 
+	// For ECDH, encryptedMPI1 is the ephemeral point and encryptedMPI2
+	// holds the wrapped key, which is not an MPI.
 	encryptedMPI1, encryptedMPI2 parsedMPI
 }
 
@@ -54,6 +56,19 @@ func (e *EncryptedKey) parse(r io.Reader) (err error) {
 		if err != nil {
 			return
 		}
+	case PubKeyAlgoECDH:
+		e.encryptedMPI1.bytes, e.encryptedMPI1.bitLength, err = readMPI(r)
+		if err != nil {
+			return
+		}
+		// RFC 6637, Section 10.
+		if _, err = readFull(r, buf[:1]); err != nil {
+			return
+		}
+		e.encryptedMPI2.bytes = make([]byte, buf[0])
+		if _, err = readFull(r, e.encryptedMPI2.bytes); err != nil {
+			return
+		}
 	}
 	_, err = consumeAll(r)
 	return
@@ -84,6 +99,8 @@ func (e *EncryptedKey) Decrypt(priv *PrivateKey, config *Config) error {
 		c1 := new(big.Int).SetBytes(e.encryptedMPI1.bytes)
 		c2 := new(big.Int).SetBytes(e.encryptedMPI2.bytes)
 		b, err = elgamal.Decrypt(priv.PrivateKey.(*elgamal.PrivateKey), c1, c2)
+	case PubKeyAlgoECDH:
+		b, err = decryptKeyBlockECDH(priv, e.encryptedMPI1.bytes, e.encryptedMPI2.bytes)
 	default:
 		err = errors.InvalidArgumentError("cannot decrypted encrypted session key with private key of type " + strconv.Itoa(int(priv.PubKeyAlgo)))
 	}
@@ -111,6 +128,8 @@ func (e *EncryptedKey) Serialize(w io.Writer) error {
 		mpiLen = 2 + len(e.encryptedMPI1.bytes)
 	case PubKeyAlgoElGamal:
 		mpiLen = 2 + len(e.encryptedMPI1.bytes) + 2 + len(e.encryptedMPI2.bytes)
+	case PubKeyAlgoECDH:
+		mpiLen = 2 + len(e.encryptedMPI1.bytes) + 1 + len(e.encryptedMPI2.bytes)
 	default:
 		return errors.InvalidArgumentError("don't know how to serialize encrypted key type " + strconv.Itoa(int(e.Algo)))
 	}
@@ -126,6 +145,10 @@ func (e *EncryptedKey) Serialize(w io.Writer) error {
 		writeMPIs(w, e.encryptedMPI1)
 	case PubKeyAlgoElGamal:
 		writeMPIs(w, e.encryptedMPI1, e.encryptedMPI2)
+	case PubKeyAlgoECDH:
+		writeMPIs(w, e.encryptedMPI1)
+		w.Write([]byte{byte(len(e.encryptedMPI2.bytes))})
+		w.Write(e.encryptedMPI2.bytes)
 	default:
 		panic("internal error")
 	}
@@ -154,6 +177,8 @@ func SerializeEncryptedKey(w io.Writer, pub *PublicKey, cipherFunc CipherFunctio
 		return serializeEncryptedKeyRSA(w, config.Random(), buf, pub.PublicKey.(*rsa.PublicKey), keyBlock)
 	case PubKeyAlgoElGamal:
 		return serializeEncryptedKeyElGamal(w, config.Random(), buf, pub.PublicKey.(*elgamal.PublicKey), keyBlock)
+	case PubKeyAlgoECDH:
+		return serializeEncryptedKeyECDH(w, config.Random(), buf, pub, keyBlock)
 	case PubKeyAlgoDSA, PubKeyAlgoRSASignOnly:
 		return errors.InvalidArgumentError("cannot encrypt to public key of type " + strconv.Itoa(int(pub.PubKeyAlgo)))
 	}
@@ -204,3 +229,64 @@ func serializeEncryptedKeyElGamal(w io.Writer, rand io.Reader, header [10]byte,
 	}
 	return writeBig(w, c2)
 }
+
+func serializeEncryptedKeyECDH(w io.Writer, rand io.Reader, header [10]byte, pub *PublicKey, keyBlock []byte) error {
+	ephemeral, z, err := ecdhEncapsulate(rand, pub)
+	if err != nil {
+		return err
+	}
+	kek, err := ecdhKEK(pub, z)
+	if err != nil {
+		return err
+	}
+	wrapped, err := keyWrap(kek, padKeyBlock(keyBlock))
+	if err != nil {
+		return err
+	}
+
+	packetLen := 10 /* header length */
+	packetLen += 2 /* mpi size */ + len(ephemeral.bytes)
+	packetLen += 1 /* wrapped key size */ + len(wrapped)
+
+	err = serializeHeader(w, packetTypeEncryptedKey, packetLen)
+	if err != nil {
+		return err
+	}
+	_, err = w.Write(header[:])
+	if err != nil {
+		return err
+	}
+	err = writeMPIs(w, ephemeral)
+	if err != nil {
+		return err
+	}
+	_, err = w.Write([]byte{byte(len(wrapped))})
+	if err != nil {
+		return err
+	}
+	_, err = w.Write(wrapped)
+	return err
+}
+
+// decryptKeyBlockECDH returns the session key block wrapped for priv.
+func decryptKeyBlockECDH(priv *PrivateKey, ephemeral, wrapped []byte) ([]byte, error) {
+	z, err := ecdhDecapsulate(priv, ephemeral)
+	if err != nil {
+		return nil, err
+	}
+	kek, err := ecdhKEK(&priv.PublicKey, z)
+	if err != nil {
+		return nil, err
+	}
+	b, err := keyUnwrap(kek, wrapped)
+	if err != nil {
+		return nil, err
+	}
+	if b, err = unpadKeyBlock(b); err != nil {
+		return nil, err
+	}
+	if len(b) < 3 {
+		return nil, errors.StructuralError("ECDH key block too short")
+	}
+	return b, nil
+}
@@ -412,13 +412,16 @@ const (
 	// RFC 6637, Section 5.
 	PubKeyAlgoECDH  PublicKeyAlgorithm = 18
 	PubKeyAlgoECDSA PublicKeyAlgorithm = 19
+	// draft-ietf-openpgp-rfc4880bis, Section 9.1. Only Ed25519 keys are
+	// supported.
+	PubKeyAlgoEdDSA PublicKeyAlgorithm = 22
 )
 
 // CanEncrypt returns true if it's possible to encrypt a message to a public
 // key of the given type.
 func (pka PublicKeyAlgorithm) CanEncrypt() bool {
 	switch pka {
-	case PubKeyAlgoRSA, PubKeyAlgoRSAEncryptOnly, PubKeyAlgoElGamal:
+	case PubKeyAlgoRSA, PubKeyAlgoRSAEncryptOnly, PubKeyAlgoElGamal, PubKeyAlgoECDH:
 		return true
 	}
 	return false
@@ -428,7 +431,7 @@ func (pka PublicKeyAlgorithm) CanEncrypt() bool {
 // sign a message.
 func (pka PublicKeyAlgorithm) CanSign() bool {
 	switch pka {
-	case PubKeyAlgoRSA, PubKeyAlgoRSASignOnly, PubKeyAlgoDSA, PubKeyAlgoECDSA:
+	case PubKeyAlgoRSA, PubKeyAlgoRSASignOnly, PubKeyAlgoDSA, PubKeyAlgoECDSA, PubKeyAlgoEdDSA:
 		return true
 	}
 	return false
@@ -538,12 +541,19 @@ func padToKeySize(pub *rsa.PublicKey, b []byte) []byte {
 }
 
 // CompressionAlgo Represents the different compression algorithms
-// supported by OpenPGP (except for BZIP2, which is not currently
-// supported). See Section 9.3 of RFC 4880.
+// supported by OpenPGP (except for writing BZIP2, which is not currently
+// supported). Other algorithms can be added with RegisterDecompressor and
+// RegisterCompressor. See Section 9.3 of RFC 4880.
 type CompressionAlgo uint8
 
 const (
-	CompressionNone CompressionAlgo = 0
-	CompressionZIP  CompressionAlgo = 1
-	CompressionZLIB CompressionAlgo = 2
+	CompressionNone  CompressionAlgo = 0
+	CompressionZIP   CompressionAlgo = 1
+	CompressionZLIB  CompressionAlgo = 2
+	CompressionBZIP2 CompressionAlgo = 3
+
+	// CompressionPrivateMin and CompressionPrivateMax bound the ids that
+	// RFC 4880 reserves for private or experimental algorithms.
+	CompressionPrivateMin CompressionAlgo = 100
+	CompressionPrivateMax CompressionAlgo = 110
 )
@@ -18,6 +18,8 @@ import (
 	"strconv"
 	"time"
 
+	"golang.org/x/crypto/curve25519"
+	"golang.org/x/crypto/ed25519"
 	"golang.org/x/crypto/openpgp/elgamal"
 	"golang.org/x/crypto/openpgp/errors"
 	"golang.org/x/crypto/openpgp/s2k"
@@ -31,7 +33,7 @@ type PrivateKey struct {
 	encryptedData []byte
 	cipher        CipherFunction
 	s2k           func(out, in []byte)
-	PrivateKey    interface{} // An *{rsa|dsa|ecdsa}.PrivateKey or a crypto.Signer.
+	PrivateKey    interface{} // An *{rsa|dsa|ecdsa}.PrivateKey, an ed25519.PrivateKey, a *[32]byte Curve25519 scalar or a crypto.Signer.
 	sha1Checksum  bool
 	iv            []byte
 }
@@ -64,8 +66,34 @@ func NewECDSAPrivateKey(currentTime time.Time, priv *ecdsa.PrivateKey) *PrivateK
 	return pk
 }
 
+// NewEdDSAPrivateKey returns a PrivateKey that wraps the given Ed25519
+// private key.
+func NewEdDSAPrivateKey(currentTime time.Time, priv ed25519.PrivateKey) *PrivateKey {
+	pk := new(PrivateKey)
+	pk.PublicKey = *NewEdDSAPublicKey(currentTime, priv.Public().(ed25519.PublicKey))
+	pk.PrivateKey = priv
+	return pk
+}
+
+// NewX25519PrivateKey returns an ECDH PrivateKey for the given Curve25519
+// secret scalar, which is clamped as by X25519, like GnuPG stores it.
+func NewX25519PrivateKey(currentTime time.Time, priv *[32]byte) *PrivateKey {
+	secret := new([32]byte)
+	*secret = *priv
+	secret[0] &= 248
+	secret[31] &= 127
+	secret[31] |= 64
+	var pub [32]byte
+	curve25519.ScalarBaseMult(&pub, secret)
+
+	pk := new(PrivateKey)
+	pk.PublicKey = *NewX25519PublicKey(currentTime, &pub)
+	pk.PrivateKey = secret
+	return pk
+}
+
 // NewSignerPrivateKey creates a sign-only PrivateKey from a crypto.Signer that
-// implements RSA or ECDSA.
+// implements RSA, ECDSA or Ed25519.
 func NewSignerPrivateKey(currentTime time.Time, signer crypto.Signer) *PrivateKey {
 	pk := new(PrivateKey)
 	switch pubkey := signer.Public().(type) {
@@ -74,6 +102,8 @@ func NewSignerPrivateKey(currentTime time.Time, signer crypto.Signer) *PrivateKe
 		pk.PubKeyAlgo = PubKeyAlgoRSASignOnly
 	case ecdsa.PublicKey:
 		pk.PublicKey = *NewECDSAPublicKey(currentTime, &pubkey)
+	case ed25519.PublicKey:
+		pk.PublicKey = *NewEdDSAPublicKey(currentTime, pubkey)
 	default:
 		panic("openpgp: unknown crypto.Signer type in NewSignerPrivateKey")
 	}
@@ -168,6 +198,10 @@ func (pk *PrivateKey) Serialize(w io.Writer) (err error) {
 		err = serializeElGamalPrivateKey(privateKeyBuf, priv)
 	case *ecdsa.PrivateKey:
 		err = serializeECDSAPrivateKey(privateKeyBuf, priv)
+	case ed25519.PrivateKey:
+		err = serializeEdDSAPrivateKey(privateKeyBuf, priv)
+	case *[32]byte:
+		err = serializeX25519PrivateKey(privateKeyBuf, priv)
 	default:
 		err = errors.InvalidArgumentError("unknown private key type")
 	}
@@ -231,6 +265,22 @@ func serializeECDSAPrivateKey(w io.Writer, priv *ecdsa.PrivateKey) error {
 	return writeBig(w, priv.D)
 }
 
+// serializeEdDSAPrivateKey writes the seed of priv, from which the rest of
+// the key is derived. See draft-ietf-openpgp-rfc4880bis, Section 5.5.3.
+func serializeEdDSAPrivateKey(w io.Writer, priv ed25519.PrivateKey) error {
+	return writeBig(w, new(big.Int).SetBytes(priv.Seed()))
+}
+
+// serializeX25519PrivateKey writes the secret scalar priv. Unlike the public
+// value, it is stored as a big-endian integer.
+func serializeX25519PrivateKey(w io.Writer, priv *[32]byte) error {
+	var be [32]byte
+	for i, b := range priv {
+		be[31-i] = b
+	}
+	return writeBig(w, new(big.Int).SetBytes(be[:]))
+}
+
 // Decrypt decrypts an encrypted private key using a passphrase.
 func (pk *PrivateKey) Decrypt(passphrase []byte) error {
 	if !pk.Encrypted {
@@ -284,6 +334,15 @@ func (pk *PrivateKey) parsePrivateKey(data []byte) (err error) {
 		return pk.parseElGamalPrivateKey(data)
 	case PubKeyAlgoECDSA:
 		return pk.parseECDSAPrivateKey(data)
+	case PubKeyAlgoECDH:
+		if _, ok := pk.PublicKey.PublicKey.(*[32]byte); ok {
+			return pk.parseX25519PrivateKey(data)
+		}
+		// Like the public key, the ECDH private key is stored in an
+		// ecdsa.PrivateKey for convenience.
+		return pk.parseECDSAPrivateKey(data)
+	case PubKeyAlgoEdDSA:
+		return pk.parseEdDSAPrivateKey(data)
 	}
 	panic("impossible")
 }
@@ -378,3 +437,61 @@ func (pk *PrivateKey) parseECDSAPrivateKey(data []byte) (err error) {
 
 	return nil
 }
+
+// readPaddedMPI reads an MPI from data and returns it left-padded with
+// zeroes to size bytes.
+func readPaddedMPI(data []byte, size int) ([]byte, error) {
+	b, _, err := readMPI(bytes.NewBuffer(data))
+	if err != nil {
+		return nil, err
+	}
+	if len(b) > size {
+		return nil, errors.StructuralError("private key MPI too long")
+	}
+	padded := make([]byte, size)
+	copy(padded[size-len(b):], b)
+	return padded, nil
+}
+
+func (pk *PrivateKey) parseEdDSAPrivateKey(data []byte) (err error) {
+	eddsaPub := pk.PublicKey.PublicKey.(ed25519.PublicKey)
+
+	seed, err := readPaddedMPI(data, ed25519.SeedSize)
+	if err != nil {
+		return
+	}
+	priv := ed25519.NewKeyFromSeed(seed)
+	if !bytes.Equal(priv.Public().(ed25519.PublicKey), eddsaPub) {
+		return errors.StructuralError("EdDSA private key does not match public key")
+	}
+
+	pk.PrivateKey = priv
+	pk.Encrypted = false
+	pk.encryptedData = nil
+
+	return nil
+}
+
+func (pk *PrivateKey) parseX25519PrivateKey(data []byte) (err error) {
+	x25519Pub := pk.PublicKey.PublicKey.(*[32]byte)
+
+	be, err := readPaddedMPI(data, 32)
+	if err != nil {
+		return
+	}
+	secret := new([32]byte)
+	for i, b := range be {
+		secret[31-i] = b
+	}
+	var pub [32]byte
+	curve25519.ScalarBaseMult(&pub, secret)
+	if pub != *x25519Pub {
+		return errors.StructuralError("ECDH private key does not match public key")
+	}
+
+	pk.PrivateKey = secret
+	pk.Encrypted = false
+	pk.encryptedData = nil
+
+	return nil
+}
@@ -17,6 +17,8 @@ import (
 	"io"
 	"testing"
 	"time"
+
+	"golang.org/x/crypto/ed25519"
 )
 
 var privateKeyTests = []struct {
@@ -173,6 +175,99 @@ func (s *rsaSigner) Sign(rand io.Reader, msg []byte, opts crypto.SignerOpts) ([]
 	return s.priv.Sign(rand, msg, opts)
 }
 
+func TestEdDSAPrivateKey(t *testing.T) {
+	_, eddsaPriv, err := ed25519.GenerateKey(rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var buf bytes.Buffer
+	if err := NewEdDSAPrivateKey(time.Now(), eddsaPriv).Serialize(&buf); err != nil {
+		t.Fatal(err)
+	}
+
+	p, err := Read(&buf)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	priv, ok := p.(*PrivateKey)
+	if !ok {
+		t.Fatal("didn't parse private key")
+	}
+	if !bytes.Equal(priv.PrivateKey.(ed25519.PrivateKey), eddsaPriv) {
+		t.Fatal("parsed private key differs")
+	}
+
+	sig := &Signature{
+		PubKeyAlgo: PubKeyAlgoEdDSA,
+		Hash:       crypto.SHA256,
+	}
+	msg := []byte("Hello World!")
+
+	h, err := populateHash(sig.Hash, msg)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := sig.Sign(h, priv, nil); err != nil {
+		t.Fatal(err)
+	}
+
+	// The signature must survive serialization, which drops the leading
+	// zeroes of R and S.
+	buf.Reset()
+	if err := sig.Serialize(&buf); err != nil {
+		t.Fatal(err)
+	}
+	if p, err = Read(&buf); err != nil {
+		t.Fatal(err)
+	}
+	sig = p.(*Signature)
+
+	if h, err = populateHash(sig.Hash, msg); err != nil {
+		t.Fatal(err)
+	}
+	if err := priv.VerifySignature(h, sig); err != nil {
+		t.Fatal(err)
+	}
+
+	if h, err = populateHash(sig.Hash, []byte("Goodbye World!")); err != nil {
+		t.Fatal(err)
+	}
+	if err := priv.VerifySignature(h, sig); err == nil {
+		t.Fatal("signature of another message verified")
+	}
+}
+
+func TestX25519PrivateKey(t *testing.T) {
+	var secret [32]byte
+	if _, err := rand.Read(secret[:]); err != nil {
+		t.Fatal(err)
+	}
+
+	var buf bytes.Buffer
+	key := NewX25519PrivateKey(time.Now(), &secret)
+	if err := key.Serialize(&buf); err != nil {
+		t.Fatal(err)
+	}
+
+	p, err := Read(&buf)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	priv, ok := p.(*PrivateKey)
+	if !ok {
+		t.Fatal("didn't parse private key")
+	}
+	if priv.PubKeyAlgo != PubKeyAlgoECDH || priv.Fingerprint != key.Fingerprint {
+		t.Fatalf("parsed a different key: %#v", priv.PublicKey)
+	}
+	if *priv.PrivateKey.(*[32]byte) != *key.PrivateKey.(*[32]byte) {
+		t.Fatal("parsed private key differs")
+	}
+}
+
 func TestRSASignerPrivateKey(t *testing.T) {
 	rsaPriv, err := rsa.GenerateKey(rand.Reader, 1024)
 	if err != nil {
@@ -22,6 +22,8 @@ import (
 	"strconv"
 	"time"
 
+	"golang.org/x/crypto/ed25519"
+	"golang.org/x/crypto/fingerprint"
 	"golang.org/x/crypto/openpgp/elgamal"
 	"golang.org/x/crypto/openpgp/errors"
 )
@@ -33,9 +35,13 @@ var (
 	oidCurveP384 []byte = []byte{0x2B, 0x81, 0x04, 0x00, 0x22}
 	// NIST curve P-521
 	oidCurveP521 []byte = []byte{0x2B, 0x81, 0x04, 0x00, 0x23}
+	// Curve25519, for ECDH
+	oidCurve25519 []byte = []byte{0x2B, 0x06, 0x01, 0x04, 0x01, 0x97, 0x55, 0x01, 0x05, 0x01}
+	// Ed25519, for EdDSA
+	oidEd25519 []byte = []byte{0x2B, 0x06, 0x01, 0x04, 0x01, 0xDA, 0x47, 0x0F, 0x01}
 )
 
-const maxOIDLength = 8
+const maxOIDLength = 10
 
 // ecdsaKey stores the algorithm-specific fields for ECDSA keys.
 // as defined in RFC 6637, Section 9.
@@ -98,6 +104,46 @@ func (f *ecdsaKey) newECDSA() (*ecdsa.PublicKey, error) {
 	return &ecdsa.PublicKey{Curve: c, X: x, Y: y}, nil
 }
 
+// nativePoint returns the MPI encoding of a Curve25519 or Ed25519 point,
+// which is its native encoding prefixed with 0x40. See
+// draft-ietf-openpgp-rfc4880bis, Section 13.2.
+func nativePoint(b []byte) parsedMPI {
+	return parsedMPI{
+		bytes:     append([]byte{0x40}, b...),
+		bitLength: uint16(7 + 8*len(b)),
+	}
+}
+
+// parseNativePoint returns the native encoding of the 32-byte point in p,
+// whose curve must be oid.
+func (f *ecdsaKey) parseNativePoint(oid []byte) ([]byte, error) {
+	if !bytes.Equal(f.oid, oid) {
+		return nil, errors.UnsupportedError(fmt.Sprintf("unsupported oid: %x", f.oid))
+	}
+	if len(f.p.bytes) != 33 || f.p.bytes[0] != 0x40 {
+		return nil, errors.UnsupportedError("failed to parse EC point")
+	}
+	return f.p.bytes[1:], nil
+}
+
+func (f *ecdsaKey) newEd25519() (ed25519.PublicKey, error) {
+	p, err := f.parseNativePoint(oidEd25519)
+	if err != nil {
+		return nil, err
+	}
+	return ed25519.PublicKey(p), nil
+}
+
+func (f *ecdsaKey) newX25519() (*[32]byte, error) {
+	p, err := f.parseNativePoint(oidCurve25519)
+	if err != nil {
+		return nil, err
+	}
+	pub := new([32]byte)
+	copy(pub[:], p)
+	return pub, nil
+}
+
 func (f *ecdsaKey) byteLen() int {
 	return 1 + len(f.oid) + 2 + len(f.p.bytes)
 }
@@ -153,7 +199,7 @@ func (f *ecdhKdf) byteLen() int {
 type PublicKey struct {
 	CreationTime time.Time
 	PubKeyAlgo   PublicKeyAlgorithm
-	PublicKey    interface{} // *rsa.PublicKey, *dsa.PublicKey or *ecdsa.PublicKey
+	PublicKey    interface{} // *rsa.PublicKey, *dsa.PublicKey, *ecdsa.PublicKey, ed25519.PublicKey or, for Curve25519 ECDH, *[32]byte
 	Fingerprint  [20]byte
 	KeyId        uint64
 	IsSubkey     bool
@@ -255,6 +301,45 @@ func NewECDSAPublicKey(creationTime time.Time, pub *ecdsa.PublicKey) *PublicKey
 	return pk
 }
 
+// NewEdDSAPublicKey returns a PublicKey that wraps the given Ed25519 public
+// key.
+func NewEdDSAPublicKey(creationTime time.Time, pub ed25519.PublicKey) *PublicKey {
+	pk := &PublicKey{
+		CreationTime: creationTime,
+		PubKeyAlgo:   PubKeyAlgoEdDSA,
+		PublicKey:    pub,
+		ec: &ecdsaKey{
+			oid: oidEd25519,
+			p:   nativePoint(pub),
+		},
+	}
+
+	pk.setFingerPrintAndKeyId()
+	return pk
+}
+
+// NewX25519PublicKey returns an ECDH PublicKey for the given Curve25519
+// public value. Session keys encrypted to it are wrapped with AES-128, using
+// a key derived with SHA-256.
+func NewX25519PublicKey(creationTime time.Time, pub *[32]byte) *PublicKey {
+	pk := &PublicKey{
+		CreationTime: creationTime,
+		PubKeyAlgo:   PubKeyAlgoECDH,
+		PublicKey:    pub,
+		ec: &ecdsaKey{
+			oid: oidCurve25519,
+			p:   nativePoint(pub[:]),
+		},
+		ecdh: &ecdhKdf{
+			KdfHash: kdfHashFunction(8), // SHA-256
+			KdfAlgo: kdfAlgorithm(CipherAES128),
+		},
+	}
+
+	pk.setFingerPrintAndKeyId()
+	return pk
+}
+
 func (pk *PublicKey) parse(r io.Reader) (err error) {
 	// RFC 4880, section 5.5.2
 	var buf [6]byte
@@ -280,6 +365,12 @@ func (pk *PublicKey) parse(r io.Reader) (err error) {
 			return err
 		}
 		pk.PublicKey, err = pk.ec.newECDSA()
+	case PubKeyAlgoEdDSA:
+		pk.ec = new(ecdsaKey)
+		if err = pk.ec.parse(r); err != nil {
+			return err
+		}
+		pk.PublicKey, err = pk.ec.newEd25519()
 	case PubKeyAlgoECDH:
 		pk.ec = new(ecdsaKey)
 		if err = pk.ec.parse(r); err != nil {
@@ -289,8 +380,12 @@ func (pk *PublicKey) parse(r io.Reader) (err error) {
 		if err = pk.ecdh.parse(r); err != nil {
 			return
 		}
-		// The ECDH key is stored in an ecdsa.PublicKey for convenience.
-		pk.PublicKey, err = pk.ec.newECDSA()
+		if bytes.Equal(pk.ec.oid, oidCurve25519) {
+			pk.PublicKey, err = pk.ec.newX25519()
+		} else {
+			// The ECDH key is stored in an ecdsa.PublicKey for convenience.
+			pk.PublicKey, err = pk.ec.newECDSA()
+		}
 	default:
 		err = errors.UnsupportedError("public key type: " + strconv.Itoa(int(pk.PubKeyAlgo)))
 	}
@@ -410,7 +505,7 @@ func (pk *PublicKey) SerializeSignaturePrefix(h io.Writer) {
 		pLength += 2 + uint16(len(pk.p.bytes))
 		pLength += 2 + uint16(len(pk.g.bytes))
 		pLength += 2 + uint16(len(pk.y.bytes))
-	case PubKeyAlgoECDSA:
+	case PubKeyAlgoECDSA, PubKeyAlgoEdDSA:
 		pLength += uint16(pk.ec.byteLen())
 	case PubKeyAlgoECDH:
 		pLength += uint16(pk.ec.byteLen())
@@ -439,7 +534,7 @@ func (pk *PublicKey) Serialize(w io.Writer) (err error) {
 		length += 2 + len(pk.p.bytes)
 		length += 2 + len(pk.g.bytes)
 		length += 2 + len(pk.y.bytes)
-	case PubKeyAlgoECDSA:
+	case PubKeyAlgoECDSA, PubKeyAlgoEdDSA:
 		length += pk.ec.byteLen()
 	case PubKeyAlgoECDH:
 		length += pk.ec.byteLen()
@@ -483,7 +578,7 @@ func (pk *PublicKey) serializeWithoutHeaders(w io.Writer) (err error) {
 		return writeMPIs(w, pk.p, pk.q, pk.g, pk.y)
 	case PubKeyAlgoElGamal:
 		return writeMPIs(w, pk.p, pk.g, pk.y)
-	case PubKeyAlgoECDSA:
+	case PubKeyAlgoECDSA, PubKeyAlgoEdDSA:
 		return pk.ec.serialize(w)
 	case PubKeyAlgoECDH:
 		if err = pk.ec.serialize(w); err != nil {
@@ -542,6 +637,20 @@ func (pk *PublicKey) VerifySignature(signed hash.Hash, sig *Signature) (err erro
 			return errors.SignatureError("ECDSA verification failure")
 		}
 		return nil
+	case PubKeyAlgoEdDSA:
+		eddsaPublicKey := pk.PublicKey.(ed25519.PublicKey)
+		r, s := sig.EdDSASigR.bytes, sig.EdDSASigS.bytes
+		if len(r) > 32 || len(s) > 32 {
+			return errors.SignatureError("EdDSA verification failure")
+		}
+		// The MPIs drop the leading zeroes of the halves of the signature.
+		var sigBytes [ed25519.SignatureSize]byte
+		copy(sigBytes[32-len(r):32], r)
+		copy(sigBytes[64-len(s):], s)
+		if !ed25519.Verify(eddsaPublicKey, hashBytes, sigBytes[:]) {
+			return errors.SignatureError("EdDSA verification failure")
+		}
+		return nil
 	default:
 		return errors.SignatureError("Unsupported public key algorithm used in signature")
 	}
@@ -705,6 +814,13 @@ func (pk *PublicKey) VerifyUserIdSignatureV3(id string, pub *PublicKey, sig *Sig
 	return pk.VerifySignatureV3(h, sig)
 }
 
+// FingerprintString returns the public key's fingerprint in the form shown
+// by GnuPG, uppercase hex in groups of four digits
+// (e.g. "5FB7 4B1D 03B1 E3CB 31BC  2F8A A34D 7E18 C20C 31BB").
+func (pk *PublicKey) FingerprintString() string {
+	return fingerprint.PGP(pk.Fingerprint[:])
+}
+
 // KeyIdString returns the public key's fingerprint in capital hex
 // (e.g. "6C7EE1B8621CC013").
 func (pk *PublicKey) KeyIdString() string {
@@ -62,6 +62,15 @@ func TestPublicKeyRead(t *testing.T) {
 	}
 }
 
+func TestFingerprintString(t *testing.T) {
+	pk := &PublicKey{}
+	fp, _ := hex.DecodeString(rsaFingerprintHex)
+	copy(pk.Fingerprint[:], fp)
+	if got, want := pk.FingerprintString(), "5FB7 4B1D 03B1 E3CB 31BC  2F8A A34D 7E18 C20C 31BB"; got != want {
+		t.Errorf("FingerprintString() = %q, want %q", got, want)
+	}
+}
+
 func TestPublicKeySerialize(t *testing.T) {
 	for i, test := range pubKeyTests {
 		packet, err := Read(readerFromHex(test.hexData))
@@ -27,6 +27,8 @@ const (
 	KeyFlagSign
 	KeyFlagEncryptCommunications
 	KeyFlagEncryptStorage
+	KeyFlagSplitKey
+	KeyFlagAuthenticate
 )
 
 // Signature represents a signature. See RFC 4880, section 5.2.
@@ -45,6 +47,7 @@ type Signature struct {
 	RSASignature         parsedMPI
 	DSASigR, DSASigS     parsedMPI
 	ECDSASigR, ECDSASigS parsedMPI
+	EdDSASigR, EdDSASigS parsedMPI
 
 	// rawSubpackets contains the unparsed subpackets, in order.
 	rawSubpackets []outputSubpacket
@@ -61,6 +64,7 @@ type Signature struct {
 	// 5.2.3.21 for details.
 	FlagsValid                                                           bool
 	FlagCertify, FlagSign, FlagEncryptCommunications, FlagEncryptStorage bool
+	FlagAuthenticate                                                     bool
 
 	// RevocationReason is set if this signature has been revoked.
 	// See RFC 4880, section 5.2.3.23 for details.
@@ -73,7 +77,8 @@ type Signature struct {
 
 	// EmbeddedSignature, if non-nil, is a signature of the parent key, by
 	// this key. This prevents an attacker from claiming another's signing
-	// subkey as their own.
+	// subkey as their own. When signing, it must have been computed first,
+	// with CrossSignKey.
 	EmbeddedSignature *Signature
 
 	outSubpackets []outputSubpacket
@@ -98,7 +103,7 @@ func (sig *Signature) parse(r io.Reader) (err error) {
 	sig.SigType = SignatureType(buf[0])
 	sig.PubKeyAlgo = PublicKeyAlgorithm(buf[1])
 	switch sig.PubKeyAlgo {
-	case PubKeyAlgoRSA, PubKeyAlgoRSASignOnly, PubKeyAlgoDSA, PubKeyAlgoECDSA:
+	case PubKeyAlgoRSA, PubKeyAlgoRSASignOnly, PubKeyAlgoDSA, PubKeyAlgoECDSA, PubKeyAlgoEdDSA:
 	default:
 		err = errors.UnsupportedError("public key algorithm " + strconv.Itoa(int(sig.PubKeyAlgo)))
 		return
@@ -167,6 +172,11 @@ func (sig *Signature) parse(r io.Reader) (err error) {
 		if err == nil {
 			sig.ECDSASigS.bytes, sig.ECDSASigS.bitLength, err = readMPI(r)
 		}
+	case PubKeyAlgoEdDSA:
+		sig.EdDSASigR.bytes, sig.EdDSASigR.bitLength, err = readMPI(r)
+		if err == nil {
+			sig.EdDSASigS.bytes, sig.EdDSASigS.bitLength, err = readMPI(r)
+		}
 	default:
 		panic("unreachable")
 	}
@@ -346,6 +356,9 @@ func parseSignatureSubpacket(sig *Signature, subpacket []byte, isHashed bool) (r
 		if subpacket[0]&KeyFlagEncryptStorage != 0 {
 			sig.FlagEncryptStorage = true
 		}
+		if subpacket[0]&KeyFlagAuthenticate != 0 {
+			sig.FlagAuthenticate = true
+		}
 	case reasonForRevocationSubpacket:
 		// Reason For Revocation, section 5.2.3.23
 		if !isHashed {
@@ -509,7 +522,10 @@ func (sig *Signature) signPrepareHash(h hash.Hash) (digest []byte, err error) {
 // On success, the signature is stored in sig. Call Serialize to write it out.
 // If config is nil, sensible defaults will be used.
 func (sig *Signature) Sign(h hash.Hash, priv *PrivateKey, config *Config) (err error) {
-	sig.outSubpackets = sig.buildSubpackets()
+	sig.outSubpackets, err = sig.buildSubpackets()
+	if err != nil {
+		return
+	}
 	digest, err := sig.signPrepareHash(h)
 	if err != nil {
 		return
@@ -551,6 +567,14 @@ func (sig *Signature) Sign(h hash.Hash, priv *PrivateKey, config *Config) (err e
 			sig.ECDSASigR = fromBig(r)
 			sig.ECDSASigS = fromBig(s)
 		}
+	case PubKeyAlgoEdDSA:
+		// Ed25519 signs the digest itself, rather than a hash of it.
+		var b []byte
+		b, err = priv.PrivateKey.(crypto.Signer).Sign(config.Random(), digest, crypto.Hash(0))
+		if err == nil {
+			sig.EdDSASigR = fromBig(new(big.Int).SetBytes(b[:32]))
+			sig.EdDSASigS = fromBig(new(big.Int).SetBytes(b[32:]))
+		}
 	default:
 		err = errors.UnsupportedError("public key algorithm: " + strconv.Itoa(int(sig.PubKeyAlgo)))
 	}
@@ -594,13 +618,31 @@ func (sig *Signature) SignKey(pub *PublicKey, priv *PrivateKey, config *Config)
 	return sig.Sign(h, priv, config)
 }
 
+// CrossSignKey computes a primary key binding signature from priv, a signing
+// subkey, asserting that it belongs to primary. On success, the signature is
+// stored in sig, which is then to be used as the EmbeddedSignature of the
+// binding signature of the subkey.
+// If config is nil, sensible defaults will be used.
+func (sig *Signature) CrossSignKey(primary *PublicKey, priv *PrivateKey, config *Config) error {
+	h, err := keySignatureHash(primary, &priv.PublicKey, sig.Hash)
+	if err != nil {
+		return err
+	}
+	return sig.Sign(h, priv, config)
+}
+
+// signed reports whether sig holds a signature, either parsed or computed.
+func (sig *Signature) signed() bool {
+	return sig.RSASignature.bytes != nil || sig.DSASigR.bytes != nil || sig.ECDSASigR.bytes != nil || sig.EdDSASigR.bytes != nil
+}
+
 // Serialize marshals sig to w. Sign, SignUserId or SignKey must have been
 // called first.
 func (sig *Signature) Serialize(w io.Writer) (err error) {
 	if len(sig.outSubpackets) == 0 {
 		sig.outSubpackets = sig.rawSubpackets
 	}
-	if sig.RSASignature.bytes == nil && sig.DSASigR.bytes == nil && sig.ECDSASigR.bytes == nil {
+	if !sig.signed() {
 		return errors.InvalidArgumentError("Signature: need to call Sign, SignUserId or SignKey before Serialize")
 	}
 
@@ -614,6 +656,9 @@ func (sig *Signature) Serialize(w io.Writer) (err error) {
 	case PubKeyAlgoECDSA:
 		sigLength = 2 + len(sig.ECDSASigR.bytes)
 		sigLength += 2 + len(sig.ECDSASigS.bytes)
+	case PubKeyAlgoEdDSA:
+		sigLength = 2 + len(sig.EdDSASigR.bytes)
+		sigLength += 2 + len(sig.EdDSASigS.bytes)
 	default:
 		panic("impossible")
 	}
@@ -626,12 +671,18 @@ func (sig *Signature) Serialize(w io.Writer) (err error) {
 	if err != nil {
 		return
 	}
+	return sig.serializeBody(w)
+}
 
+// serializeBody marshals sig to w without the packet header, which is also
+// the contents of an embedded signature subpacket.
+func (sig *Signature) serializeBody(w io.Writer) (err error) {
 	_, err = w.Write(sig.HashSuffix[:len(sig.HashSuffix)-6])
 	if err != nil {
 		return
 	}
 
+	unhashedSubpacketsLen := subpacketsLength(sig.outSubpackets, false)
 	unhashedSubpackets := make([]byte, 2+unhashedSubpacketsLen)
 	unhashedSubpackets[0] = byte(unhashedSubpacketsLen >> 8)
 	unhashedSubpackets[1] = byte(unhashedSubpacketsLen)
@@ -653,6 +704,8 @@ func (sig *Signature) Serialize(w io.Writer) (err error) {
 		err = writeMPIs(w, sig.DSASigR, sig.DSASigS)
 	case PubKeyAlgoECDSA:
 		err = writeMPIs(w, sig.ECDSASigR, sig.ECDSASigS)
+	case PubKeyAlgoEdDSA:
+		err = writeMPIs(w, sig.EdDSASigR, sig.EdDSASigS)
 	default:
 		panic("impossible")
 	}
@@ -667,7 +720,7 @@ type outputSubpacket struct {
 	contents      []byte
 }
 
-func (sig *Signature) buildSubpackets() (subpackets []outputSubpacket) {
+func (sig *Signature) buildSubpackets() (subpackets []outputSubpacket, err error) {
 	creationTime := make([]byte, 4)
 	binary.BigEndian.PutUint32(creationTime, uint32(sig.CreationTime.Unix()))
 	subpackets = append(subpackets, outputSubpacket{true, creationTimeSubpacket, false, creationTime})
@@ -700,6 +753,9 @@ func (sig *Signature) buildSubpackets() (subpackets []outputSubpacket) {
 		if sig.FlagEncryptStorage {
 			flags |= KeyFlagEncryptStorage
 		}
+		if sig.FlagAuthenticate {
+			flags |= KeyFlagAuthenticate
+		}
 		subpackets = append(subpackets, outputSubpacket{true, keyFlagsSubpacket, false, []byte{flags}})
 	}
 
@@ -727,5 +783,20 @@ func (sig *Signature) buildSubpackets() (subpackets []outputSubpacket) {
 		subpackets = append(subpackets, outputSubpacket{true, prefCompressionSubpacket, false, sig.PreferredCompression})
 	}
 
+	if sig.EmbeddedSignature != nil {
+		embeddedSig := sig.EmbeddedSignature
+		if len(embeddedSig.outSubpackets) == 0 {
+			embeddedSig.outSubpackets = embeddedSig.rawSubpackets
+		}
+		if !embeddedSig.signed() {
+			return nil, errors.InvalidArgumentError("Signature: embedded signature must be computed with CrossSignKey first")
+		}
+		var embedded bytes.Buffer
+		if err = embeddedSig.serializeBody(&embedded); err != nil {
+			return
+		}
+		subpackets = append(subpackets, outputSubpacket{true, embeddedSignatureSubpacket, false, embedded.Bytes()})
+	}
+
 	return
 }
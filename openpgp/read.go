@@ -113,7 +113,7 @@ ParsePackets:
 			// This packet contains the decryption key encrypted to a public key.
 			md.EncryptedToKeyIds = append(md.EncryptedToKeyIds, p.KeyId)
 			switch p.Algo {
-			case packet.PubKeyAlgoRSA, packet.PubKeyAlgoRSAEncryptOnly, packet.PubKeyAlgoElGamal:
+			case packet.PubKeyAlgoRSA, packet.PubKeyAlgoRSAEncryptOnly, packet.PubKeyAlgoElGamal, packet.PubKeyAlgoECDH:
 				break
 			default:
 				continue
@@ -136,7 +136,7 @@ ParsePackets:
 				return nil, errors.StructuralError("key material not followed by encrypted message")
 			}
 			packets.Unread(p)
-			return readSignedMessage(packets, nil, keyring)
+			return readSignedMessage(packets, nil, keyring, config)
 		}
 	}
 
@@ -216,13 +216,13 @@ FindKey:
 	if err := packets.Push(decrypted); err != nil {
 		return nil, err
 	}
-	return readSignedMessage(packets, md, keyring)
+	return readSignedMessage(packets, md, keyring, config)
 }
 
 // readSignedMessage reads a possibly signed message if mdin is non-zero then
 // that structure is updated and returned. Otherwise a fresh MessageDetails is
 // used.
-func readSignedMessage(packets *packet.Reader, mdin *MessageDetails, keyring KeyRing) (md *MessageDetails, err error) {
+func readSignedMessage(packets *packet.Reader, mdin *MessageDetails, keyring KeyRing, config *packet.Config) (md *MessageDetails, err error) {
 	if mdin == nil {
 		mdin = new(MessageDetails)
 	}
@@ -239,7 +239,11 @@ FindLiteralData:
 		}
 		switch p := p.(type) {
 		case *packet.Compressed:
-			if err := packets.Push(p.Body); err != nil {
+			body := p.Body
+			if limit := config.DecompressedSizeLimit(); limit > 0 {
+				body = &decompressedLimitReader{body, limit}
+			}
+			if err := packets.Push(body); err != nil {
 				return nil, err
 			}
 		case *packet.OnePassSignature:
@@ -276,6 +280,31 @@ FindLiteralData:
 	return md, nil
 }
 
+// decompressedLimitReader returns errors.ErrDecompressedTooLarge instead of
+// reading more than n bytes from r.
+type decompressedLimitReader struct {
+	r io.Reader
+	n int64 // bytes left
+}
+
+func (l *decompressedLimitReader) Read(p []byte) (int, error) {
+	if l.n <= 0 {
+		// Tell the end of the data from data past the limit.
+		var b [1]byte
+		n, err := l.r.Read(b[:])
+		if n > 0 {
+			err = errors.ErrDecompressedTooLarge
+		}
+		return 0, err
+	}
+	if int64(len(p)) > l.n {
+		p = p[:l.n]
+	}
+	n, err := l.r.Read(p)
+	l.n -= int64(n)
+	return n, err
+}
+
 // hashForSignature returns a pair of hashes that can be used to verify a
 // signature. The signature may specify that the contents of the signed message
 // should be preprocessed (i.e. to normalize line endings). Thus this function
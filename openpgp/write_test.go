@@ -11,6 +11,7 @@ import (
 	"testing"
 	"time"
 
+	"golang.org/x/crypto/openpgp/errors"
 	"golang.org/x/crypto/openpgp/packet"
 )
 
@@ -159,6 +160,45 @@ func TestSymmetricEncryption(t *testing.T) {
 	}
 }
 
+func TestSymmetricEncryptionDecompressionLimit(t *testing.T) {
+	buf := new(bytes.Buffer)
+	config := &packet.Config{DefaultCompressionAlgo: packet.CompressionZLIB}
+	plaintext, err := SymmetricallyEncrypt(buf, []byte("testing"), nil, config)
+	if err != nil {
+		t.Fatal(err)
+	}
+	message := make([]byte, 1<<20)
+	if _, err := plaintext.Write(message); err != nil {
+		t.Fatal(err)
+	}
+	if err := plaintext.Close(); err != nil {
+		t.Fatal(err)
+	}
+	ciphertext := buf.Bytes()
+
+	prompt := func(keys []Key, symmetric bool) ([]byte, error) {
+		return []byte("testing"), nil
+	}
+	for _, limit := range []int64{0, 2 << 20, 1 << 20, 1 << 10} {
+		config := &packet.Config{MaxDecompressedSize: limit}
+		md, err := ReadMessage(bytes.NewReader(ciphertext), nil, prompt, config)
+		if err != nil {
+			t.Fatalf("limit %d: %s", limit, err)
+		}
+		contents, err := ioutil.ReadAll(md.UnverifiedBody)
+		// The decompressed data also holds the literal data packet header.
+		if limit == 0 || limit > int64(len(message)) {
+			if err != nil {
+				t.Errorf("limit %d: %s", limit, err)
+			} else if !bytes.Equal(contents, message) {
+				t.Errorf("limit %d: wrong contents", limit)
+			}
+		} else if err != errors.ErrDecompressedTooLarge {
+			t.Errorf("limit %d: got error %v, want ErrDecompressedTooLarge", limit, err)
+		}
+	}
+}
+
 var testEncryptionTests = []struct {
 	keyRingHex string
 	isSigned   bool
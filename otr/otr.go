@@ -23,6 +23,8 @@ import (
 	"io"
 	"math/big"
 	"strconv"
+
+	"golang.org/x/crypto/fingerprint"
 )
 
 // SecurityChange describes a change in the security state of a Conversation.
@@ -1215,6 +1217,12 @@ func (pk *PublicKey) Fingerprint() []byte {
 	return h.Sum(nil)
 }
 
+// FingerprintString returns the fingerprint of the PublicKey in the form
+// shown by libotr clients, five groups of eight uppercase hex digits.
+func (pk *PublicKey) FingerprintString() string {
+	return fingerprint.OTR(pk.Fingerprint())
+}
+
 func (pk *PublicKey) Verify(hashed, sig []byte) ([]byte, bool) {
 	if len(sig) != 2*dsaSubgroupBytes {
 		return nil, false
@@ -69,6 +69,9 @@ func TestKeySerialization(t *testing.T) {
 	if !bytes.Equal(aliceFingerprint, fingerprint) {
 		t.Errorf("fingerprint (%x) is not equal to expected value (%x)", fingerprint, aliceFingerprint)
 	}
+	if got, want := priv.PublicKey.FingerprintString(), "0BB01C36 0424522E 94EE9C34 6CE877A1 A4288B2F"; got != want {
+		t.Errorf("FingerprintString() = %q, want %q", got, want)
+	}
 }
 
 const libOTRPrivateKey = `(privkeys
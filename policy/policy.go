@@ -0,0 +1,142 @@
+// Copyright 2018 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+// Package policy controls which algorithms the packages of this repository
+// may use.
+//
+// Packages register their algorithms, marking those approved by FIPS 140-2,
+// and their constructors fail with a *DisabledError when the algorithm is
+// disabled. Only the following packages register algorithms and honor the
+// policy: blowfish, cast5, chacha20poly1305 (for both New and NewX), tea,
+// twofish, xtea and xts. Other packages of this repository, such as md4,
+// ripemd160, salsa20, nacl, bcrypt, scrypt, argon2 and blake2, do not consult
+// the policy and keep working whatever it is set to.
+//
+// Disabling an algorithm does not affect ciphers that were already
+// constructed, and does not gate the constructors of the standard library.
+package policy // import "golang.org/x/crypto/policy"
+
+import (
+	"sort"
+	"sync"
+)
+
+// An Algorithm is an algorithm registered with the policy.
+type Algorithm struct {
+	name     string
+	approved bool
+}
+
+// Name returns the name of the algorithm, as given to Register.
+func (a *Algorithm) Name() string {
+	return a.name
+}
+
+// Approved reports whether the algorithm is approved by FIPS 140-2.
+func (a *Algorithm) Approved() bool {
+	return a.approved
+}
+
+// Check returns a *DisabledError if the algorithm is disabled by the current
+// policy, and nil otherwise. Constructors call it before returning a cipher.
+func (a *Algorithm) Check() error {
+	mu.RLock()
+	defer mu.RUnlock()
+	if disabled[a.name] {
+		return &DisabledError{Algorithm: a.name}
+	}
+	if approvedOnly && !a.approved {
+		return &DisabledError{Algorithm: a.name, NotApproved: true}
+	}
+	return nil
+}
+
+// A DisabledError is returned by the constructors of disabled algorithms.
+type DisabledError struct {
+	Algorithm string
+	// NotApproved is true if the algorithm is disabled because only
+	// approved algorithms are allowed, and false if it was disabled by name.
+	NotApproved bool
+}
+
+func (e *DisabledError) Error() string {
+	if e.NotApproved {
+		return "policy: algorithm " + e.Algorithm + " is not approved"
+	}
+	return "policy: algorithm " + e.Algorithm + " is disabled"
+}
+
+var (
+	mu           sync.RWMutex
+	algorithms   = make(map[string]*Algorithm)
+	disabled     = make(map[string]bool)
+	approvedOnly bool
+)
+
+// Register registers an algorithm. It is meant to be called when initializing
+// the package that implements the algorithm, and panics if the name is
+// already registered.
+func Register(name string, approved bool) *Algorithm {
+	mu.Lock()
+	defer mu.Unlock()
+	if _, dup := algorithms[name]; dup {
+		panic("policy: algorithm " + name + " registered twice")
+	}
+	a := &Algorithm{name: name, approved: approved}
+	algorithms[name] = a
+	return a
+}
+
+// Lookup returns the registered algorithm with the given name, or nil.
+func Lookup(name string) *Algorithm {
+	mu.RLock()
+	defer mu.RUnlock()
+	return algorithms[name]
+}
+
+// Algorithms returns the registered algorithms, sorted by name. Only the
+// algorithms of the packages linked into the program are registered.
+func Algorithms() []*Algorithm {
+	mu.RLock()
+	defer mu.RUnlock()
+	algs := make([]*Algorithm, 0, len(algorithms))
+	for _, a := range algorithms {
+		algs = append(algs, a)
+	}
+	sort.Slice(algs, func(i, j int) bool { return algs[i].name < algs[j].name })
+	return algs
+}
+
+// SetApprovedOnly sets whether only approved algorithms are allowed. When on,
+// the constructors of the registered algorithms that are not approved fail,
+// which are those of blowfish, cast5, chacha20poly1305, tea, twofish and
+// xtea. It has no effect on the packages that do not register with the
+// policy.
+func SetApprovedOnly(on bool) {
+	mu.Lock()
+	defer mu.Unlock()
+	approvedOnly = on
+}
+
+// ApprovedOnly reports whether only approved algorithms are allowed.
+func ApprovedOnly() bool {
+	mu.RLock()
+	defer mu.RUnlock()
+	return approvedOnly
+}
+
+// Disable disables the named algorithm, which need not be registered yet.
+func Disable(name string) {
+	mu.Lock()
+	defer mu.Unlock()
+	disabled[name] = true
+}
+
+// Enable reverts a previous call to Disable. It does not allow an algorithm
+// that is not approved while SetApprovedOnly is in effect.
+func Enable(name string) {
+	mu.Lock()
+	defer mu.Unlock()
+	delete(disabled, name)
+}
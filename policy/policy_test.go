@@ -0,0 +1,62 @@
+// Copyright 2018 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package policy
+
+import "testing"
+
+var (
+	testApproved    = Register("test-approved", true)
+	testNotApproved = Register("test-not-approved", false)
+)
+
+func TestPolicy(t *testing.T) {
+	defer SetApprovedOnly(false)
+	defer Enable("test-approved")
+
+	check := func(a *Algorithm, want error) {
+		t.Helper()
+		err := a.Check()
+		if want == nil && err == nil {
+			return
+		}
+		if want == nil || err == nil || *err.(*DisabledError) != *want.(*DisabledError) {
+			t.Errorf("%s: got %v, want %v", a.Name(), err, want)
+		}
+	}
+
+	check(testApproved, nil)
+	check(testNotApproved, nil)
+
+	SetApprovedOnly(true)
+	check(testApproved, nil)
+	check(testNotApproved, &DisabledError{Algorithm: "test-not-approved", NotApproved: true})
+
+	Disable("test-approved")
+	check(testApproved, &DisabledError{Algorithm: "test-approved"})
+	Enable("test-approved")
+	check(testApproved, nil)
+
+	SetApprovedOnly(false)
+	check(testNotApproved, nil)
+}
+
+func TestRegistry(t *testing.T) {
+	if Lookup("test-approved") != testApproved || Lookup("unknown") != nil {
+		t.Errorf("Lookup returned the wrong algorithms")
+	}
+	algs := Algorithms()
+	for i := 1; i < len(algs); i++ {
+		if algs[i-1].Name() >= algs[i].Name() {
+			t.Errorf("Algorithms is not sorted: %q before %q", algs[i-1].Name(), algs[i].Name())
+		}
+	}
+
+	defer func() {
+		if recover() == nil {
+			t.Errorf("registering a name twice did not panic")
+		}
+	}()
+	Register("test-approved", true)
+}
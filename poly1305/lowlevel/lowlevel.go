@@ -0,0 +1,204 @@
+// Copyright 2018 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+// Package lowlevel exposes the pieces of the Poly1305 evaluation: clamping of
+// r, accumulation of message blocks as a polynomial evaluated at r modulo
+// 2^130 - 5, and finalization with s. They allow constructions that
+// golang.org/x/crypto/poly1305 does not support, such as authenticating a
+// message under several keys with a single pass, and research variants.
+//
+// This package is unsafe: misusing it, for example by reusing r and s across
+// messages, or by finalizing with an s that is not secret and unique, breaks
+// the security of Poly1305. Most users should use
+// golang.org/x/crypto/poly1305 or golang.org/x/crypto/chacha20poly1305.
+//
+// This package is not optimized, and its functions do not run as fast as
+// poly1305.Sum on platforms with an assembly implementation. They run in
+// constant time.
+package lowlevel // import "golang.org/x/crypto/poly1305/lowlevel"
+
+import "encoding/binary"
+
+// BlockSize is the size, in bytes, of a Poly1305 message block.
+const BlockSize = 16
+
+// Clamp clears the bits of r that Poly1305 requires to be zero, in place: the
+// top four bits of r[3], r[7], r[11] and r[15], and the bottom two bits of
+// r[4], r[8] and r[12].
+func Clamp(r *[16]byte) {
+	r[3] &= 15
+	r[7] &= 15
+	r[11] &= 15
+	r[15] &= 15
+	r[4] &= 252
+	r[8] &= 252
+	r[12] &= 252
+}
+
+// An R is a clamped Poly1305 multiplier, the point at which messages are
+// evaluated.
+type R struct {
+	r0, r1, r2, r3, r4 uint64
+	s1, s2, s3, s4     uint64 // 5*r1 to 5*r4
+}
+
+// NewR returns the multiplier encoded in r, the first half of a Poly1305 key,
+// clamped as by Clamp. The array r is not modified.
+func NewR(r *[16]byte) *R {
+	k := &R{
+		r0: uint64(binary.LittleEndian.Uint32(r[0:]) & 0x3ffffff),
+		r1: uint64((binary.LittleEndian.Uint32(r[3:]) >> 2) & 0x3ffff03),
+		r2: uint64((binary.LittleEndian.Uint32(r[6:]) >> 4) & 0x3ffc0ff),
+		r3: uint64((binary.LittleEndian.Uint32(r[9:]) >> 6) & 0x3f03fff),
+		r4: uint64((binary.LittleEndian.Uint32(r[12:]) >> 8) & 0x00fffff),
+	}
+	k.s1, k.s2, k.s3, k.s4 = k.r1*5, k.r2*5, k.r3*5, k.r4*5
+	return k
+}
+
+// An Accumulator holds the value h of a Poly1305 evaluation, which is updated
+// as h = (h + block) * r mod 2^130 - 5 for every block. Its zero value is
+// ready to use. Since the multiplier is passed to every call, several
+// accumulators can evaluate the same message at different points, or one
+// accumulator can use a different point for every block.
+type Accumulator struct {
+	h0, h1, h2, h3, h4 uint32
+}
+
+// Block adds the full block b, followed by a 1 bit as Poly1305 requires, to
+// the accumulator, and multiplies the result by r.
+func (a *Accumulator) Block(r *R, b *[BlockSize]byte) {
+	a.block(r, b, 1<<24)
+}
+
+// Update processes msg as Poly1305 does: every full block as by Block and a
+// final partial block, if any, padded with a 1 byte and zeroes. Since the
+// padding is only correct at the end of a message, only the last call to
+// Update for a message may have a length that is not a multiple of BlockSize.
+func (a *Accumulator) Update(r *R, msg []byte) {
+	var b [BlockSize]byte
+	for len(msg) >= BlockSize {
+		copy(b[:], msg)
+		a.block(r, &b, 1<<24)
+		msg = msg[BlockSize:]
+	}
+	if len(msg) > 0 {
+		b = [BlockSize]byte{}
+		b[copy(b[:], msg)] = 1
+		a.block(r, &b, 0)
+	}
+}
+
+func (a *Accumulator) block(r *R, b *[BlockSize]byte, hibit uint32) {
+	h0, h1, h2, h3, h4 := a.h0, a.h1, a.h2, a.h3, a.h4
+
+	// h += b
+	h0 += binary.LittleEndian.Uint32(b[0:]) & 0x3ffffff
+	h1 += (binary.LittleEndian.Uint32(b[3:]) >> 2) & 0x3ffffff
+	h2 += (binary.LittleEndian.Uint32(b[6:]) >> 4) & 0x3ffffff
+	h3 += (binary.LittleEndian.Uint32(b[9:]) >> 6) & 0x3ffffff
+	h4 += (binary.LittleEndian.Uint32(b[12:]) >> 8) | hibit
+
+	// h *= r
+	d0 := (uint64(h0) * r.r0) + (uint64(h1) * r.s4) + (uint64(h2) * r.s3) + (uint64(h3) * r.s2) + (uint64(h4) * r.s1)
+	d1 := (d0 >> 26) + (uint64(h0) * r.r1) + (uint64(h1) * r.r0) + (uint64(h2) * r.s4) + (uint64(h3) * r.s3) + (uint64(h4) * r.s2)
+	d2 := (d1 >> 26) + (uint64(h0) * r.r2) + (uint64(h1) * r.r1) + (uint64(h2) * r.r0) + (uint64(h3) * r.s4) + (uint64(h4) * r.s3)
+	d3 := (d2 >> 26) + (uint64(h0) * r.r3) + (uint64(h1) * r.r2) + (uint64(h2) * r.r1) + (uint64(h3) * r.r0) + (uint64(h4) * r.s4)
+	d4 := (d3 >> 26) + (uint64(h0) * r.r4) + (uint64(h1) * r.r3) + (uint64(h2) * r.r2) + (uint64(h3) * r.r1) + (uint64(h4) * r.r0)
+
+	// h %= p
+	h0 = uint32(d0) & 0x3ffffff
+	h1 = uint32(d1) & 0x3ffffff
+	h2 = uint32(d2) & 0x3ffffff
+	h3 = uint32(d3) & 0x3ffffff
+	h4 = uint32(d4) & 0x3ffffff
+
+	h0 += uint32(d4>>26) * 5
+	h1 += h0 >> 26
+	h0 = h0 & 0x3ffffff
+
+	a.h0, a.h1, a.h2, a.h3, a.h4 = h0, h1, h2, h3, h4
+}
+
+// reduce returns h fully reduced modulo 2^130 - 5, in 26-bit limbs.
+func (a *Accumulator) reduce() (h0, h1, h2, h3, h4 uint32) {
+	h0, h1, h2, h3, h4 = a.h0, a.h1, a.h2, a.h3, a.h4
+
+	h2 += h1 >> 26
+	h1 &= 0x3ffffff
+	h3 += h2 >> 26
+	h2 &= 0x3ffffff
+	h4 += h3 >> 26
+	h3 &= 0x3ffffff
+	h0 += 5 * (h4 >> 26)
+	h4 &= 0x3ffffff
+	h1 += h0 >> 26
+	h0 &= 0x3ffffff
+
+	// h - p
+	t0 := h0 + 5
+	t1 := h1 + (t0 >> 26)
+	t2 := h2 + (t1 >> 26)
+	t3 := h3 + (t2 >> 26)
+	t4 := h4 + (t3 >> 26) - (1 << 26)
+	t0 &= 0x3ffffff
+	t1 &= 0x3ffffff
+	t2 &= 0x3ffffff
+	t3 &= 0x3ffffff
+
+	// select h if h < p else h - p
+	tMask := (t4 >> 31) - 1
+	hMask := ^tMask
+	h0 = (h0 & hMask) | (t0 & tMask)
+	h1 = (h1 & hMask) | (t1 & tMask)
+	h2 = (h2 & hMask) | (t2 & tMask)
+	h3 = (h3 & hMask) | (t3 & tMask)
+	h4 = (h4 & hMask) | (t4 & tMask)
+	return
+}
+
+// Value returns h modulo 2^130 - 5, as a 17-byte little-endian integer. It
+// does not modify the accumulator.
+func (a *Accumulator) Value() [17]byte {
+	h0, h1, h2, h3, h4 := a.reduce()
+	var v [17]byte
+	binary.LittleEndian.PutUint32(v[0:], h0|h1<<26)
+	binary.LittleEndian.PutUint32(v[4:], h1>>6|h2<<20)
+	binary.LittleEndian.PutUint32(v[8:], h2>>12|h3<<14)
+	binary.LittleEndian.PutUint32(v[12:], h3>>18|h4<<8)
+	v[16] = byte(h4 >> 24)
+	return v
+}
+
+// Finalize sets out to the Poly1305 tag (h mod 2^130 - 5) + s mod 2^128, where
+// s is the second half of a Poly1305 key. It does not modify the accumulator,
+// so it can be called with different values of s.
+func (a *Accumulator) Finalize(out *[16]byte, s *[16]byte) {
+	h0, h1, h2, h3, h4 := a.reduce()
+
+	// h %= 2^128
+	h0 |= h1 << 26
+	h1 = (h1 >> 6) | (h2 << 20)
+	h2 = (h2 >> 12) | (h3 << 14)
+	h3 = (h3 >> 18) | (h4 << 8)
+
+	t := uint64(h0) + uint64(binary.LittleEndian.Uint32(s[0:]))
+	h0 = uint32(t)
+	t = uint64(h1) + uint64(binary.LittleEndian.Uint32(s[4:])) + (t >> 32)
+	h1 = uint32(t)
+	t = uint64(h2) + uint64(binary.LittleEndian.Uint32(s[8:])) + (t >> 32)
+	h2 = uint32(t)
+	t = uint64(h3) + uint64(binary.LittleEndian.Uint32(s[12:])) + (t >> 32)
+	h3 = uint32(t)
+
+	binary.LittleEndian.PutUint32(out[0:], h0)
+	binary.LittleEndian.PutUint32(out[4:], h1)
+	binary.LittleEndian.PutUint32(out[8:], h2)
+	binary.LittleEndian.PutUint32(out[12:], h3)
+}
+
+// Reset sets the accumulator back to zero.
+func (a *Accumulator) Reset() {
+	*a = Accumulator{}
+}
@@ -0,0 +1,167 @@
+// Copyright 2018 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package lowlevel
+
+import (
+	"bytes"
+	"math/big"
+	"math/rand"
+	"testing"
+
+	"golang.org/x/crypto/poly1305"
+)
+
+func randomKey(rnd *rand.Rand) (key [32]byte, r, s [16]byte) {
+	rnd.Read(key[:])
+	copy(r[:], key[:16])
+	copy(s[:], key[16:])
+	return
+}
+
+func TestMatchesSum(t *testing.T) {
+	rnd := rand.New(rand.NewSource(1))
+	for n := 0; n < 200; n++ {
+		msg := make([]byte, n)
+		rnd.Read(msg)
+		key, r, s := randomKey(rnd)
+
+		var want, got [16]byte
+		poly1305.Sum(&want, msg, &key)
+
+		var a Accumulator
+		a.Update(NewR(&r), msg)
+		a.Finalize(&got, &s)
+		if got != want {
+			t.Fatalf("length %d: got %x, want %x", n, got, want)
+		}
+
+		// Feeding full blocks separately gives the same result.
+		a.Reset()
+		k := NewR(&r)
+		m := msg
+		for len(m) >= BlockSize {
+			var b [BlockSize]byte
+			copy(b[:], m)
+			a.Block(k, &b)
+			m = m[BlockSize:]
+		}
+		a.Update(k, m)
+		a.Finalize(&got, &s)
+		if got != want {
+			t.Fatalf("length %d, by blocks: got %x, want %x", n, got, want)
+		}
+	}
+}
+
+func TestMultipleKeys(t *testing.T) {
+	// Evaluating a message at several points in one pass, as a construction
+	// authenticating it for several recipients would.
+	rnd := rand.New(rand.NewSource(2))
+	msg := make([]byte, 1000)
+	rnd.Read(msg)
+
+	const n = 4
+	var keys [n][32]byte
+	var rs [n]*R
+	var ss [n][16]byte
+	var accs [n]Accumulator
+	for i := range keys {
+		var r [16]byte
+		keys[i], r, ss[i] = randomKey(rnd)
+		rs[i] = NewR(&r)
+	}
+	for m := msg; len(m) > 0; m = m[BlockSize:] {
+		var b [BlockSize]byte
+		copy(b[:], m)
+		for i := range accs {
+			accs[i].Block(rs[i], &b)
+		}
+		if len(m) < 2*BlockSize {
+			// 1000 is not a multiple of BlockSize.
+			for i := range accs {
+				accs[i].Update(rs[i], m[BlockSize:])
+			}
+			break
+		}
+	}
+	for i := range accs {
+		var got, want [16]byte
+		accs[i].Finalize(&got, &ss[i])
+		poly1305.Sum(&want, msg, &keys[i])
+		if got != want {
+			t.Errorf("key %d: got %x, want %x", i, got, want)
+		}
+	}
+}
+
+func TestClamp(t *testing.T) {
+	r := [16]byte{}
+	for i := range r {
+		r[i] = 0xff
+	}
+	Clamp(&r)
+	want := [16]byte{
+		0xff, 0xff, 0xff, 0x0f, 0xfc, 0xff, 0xff, 0x0f,
+		0xfc, 0xff, 0xff, 0x0f, 0xfc, 0xff, 0xff, 0x0f,
+	}
+	if r != want {
+		t.Errorf("Clamp(ff...) = %x, want %x", r, want)
+	}
+
+	// NewR clamps, so clamping first makes no difference.
+	rnd := rand.New(rand.NewSource(3))
+	_, raw, _ := randomKey(rnd)
+	clamped := raw
+	Clamp(&clamped)
+	if *NewR(&raw) != *NewR(&clamped) {
+		t.Error("NewR does not clamp")
+	}
+}
+
+func leBytes(b []byte) *big.Int {
+	be := make([]byte, len(b))
+	for i := range b {
+		be[len(b)-1-i] = b[i]
+	}
+	return new(big.Int).SetBytes(be)
+}
+
+func TestValue(t *testing.T) {
+	p := new(big.Int).Sub(new(big.Int).Lsh(big.NewInt(1), 130), big.NewInt(5))
+	rnd := rand.New(rand.NewSource(4))
+	for n := 0; n < 100; n++ {
+		msg := make([]byte, n)
+		rnd.Read(msg)
+		_, r, _ := randomKey(rnd)
+		Clamp(&r)
+
+		var a Accumulator
+		a.Update(NewR(&r), msg)
+		v := a.Value()
+
+		h, br := new(big.Int), leBytes(r[:])
+		for m := msg; len(m) > 0; {
+			l := len(m)
+			if l > BlockSize {
+				l = BlockSize
+			}
+			b := append(append([]byte{}, m[:l]...), 1)
+			h.Add(h, leBytes(b))
+			h.Mul(h, br)
+			h.Mod(h, p)
+			m = m[l:]
+		}
+		if got := leBytes(v[:]); got.Cmp(h) != 0 {
+			t.Fatalf("length %d: Value() = %x, want %x", n, got, h)
+		}
+	}
+
+	// The all-ones value of h reduces to a value below p.
+	a := Accumulator{0x3ffffff, 0x3ffffff, 0x3ffffff, 0x3ffffff, 0x3ffffff}
+	v := a.Value()
+	if want := []byte{4}; !bytes.Equal(v[:1], want) || leBytes(v[:]).Cmp(p) >= 0 {
+		t.Errorf("Value() of 2^130 - 1 = %x", v)
+	}
+}
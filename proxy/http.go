@@ -0,0 +1,118 @@
+// Copyright 2018 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package proxy
+
+import (
+	"bufio"
+	"context"
+	"crypto/tls"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"strconv"
+)
+
+// An HTTPDialer connects through an HTTP proxy with the CONNECT method, as
+// defined in RFC 7231, Section 4.3.6.
+type HTTPDialer struct {
+	// Addr is the address of the proxy, as host:port.
+	Addr string
+
+	// Auth, if not nil, holds the credentials sent to the proxy with Basic
+	// authentication.
+	Auth *Auth
+
+	// TLSConfig, if not nil, makes the connection to the proxy use TLS with
+	// that configuration. If its ServerName is empty, the host of Addr is
+	// used.
+	TLSConfig *tls.Config
+
+	// Forward connects to the proxy. If nil, Direct is used.
+	Forward ContextDialer
+}
+
+// DialContext connects to addr through the proxy, which resolves the host
+// name of addr. network must be "tcp", "tcp4" or "tcp6", but the proxy
+// chooses the address family.
+func (d *HTTPDialer) DialContext(ctx context.Context, network, addr string) (net.Conn, error) {
+	switch network {
+	case "tcp", "tcp4", "tcp6":
+	default:
+		return nil, errUnsupportedNetwork
+	}
+	// addr is written as is in the request line and the Host header, and
+	// must not be able to inject other headers or requests.
+	if _, _, err := net.SplitHostPort(addr); err != nil {
+		return nil, err
+	}
+	for i := 0; i < len(addr); i++ {
+		if addr[i] <= ' ' || addr[i] == 0x7f {
+			return nil, errors.New("proxy: invalid character in address " + strconv.Quote(addr))
+		}
+	}
+	conn, err := dialProxy(ctx, d.Forward, d.Addr)
+	if err != nil {
+		return nil, err
+	}
+	var br *bufio.Reader
+	err = handshake(ctx, conn, func() error {
+		if d.TLSConfig != nil {
+			config := d.TLSConfig
+			if config.ServerName == "" {
+				config = config.Clone()
+				config.ServerName, _, _ = net.SplitHostPort(d.Addr)
+			}
+			tlsConn := tls.Client(conn, config)
+			if err := tlsConn.Handshake(); err != nil {
+				return err
+			}
+			conn = tlsConn
+		}
+		req := "CONNECT " + addr + " HTTP/1.1\r\nHost: " + addr + "\r\n"
+		if d.Auth != nil {
+			cred := base64.StdEncoding.EncodeToString([]byte(d.Auth.User + ":" + d.Auth.Password))
+			req += "Proxy-Authorization: Basic " + cred + "\r\n"
+		}
+		if _, err := conn.Write([]byte(req + "\r\n")); err != nil {
+			return err
+		}
+		br = bufio.NewReader(conn)
+		resp, err := http.ReadResponse(br, &http.Request{Method: "CONNECT"})
+		if err != nil {
+			return err
+		}
+		// The body of the response is not closed, as it would read the
+		// tunneled connection when the response has no Content-Length.
+		if resp.StatusCode < 200 || resp.StatusCode > 299 {
+			return fmt.Errorf("proxy: HTTP proxy %s refused to connect to %s: %s", d.Addr, addr, resp.Status)
+		}
+		return nil
+	})
+	if err != nil {
+		conn.Close()
+		return nil, err
+	}
+	// The server may have spoken first, as SSH servers do, and its first
+	// bytes may already have been read along with the response.
+	if br.Buffered() > 0 {
+		return &bufferedConn{Conn: conn, r: br}, nil
+	}
+	return conn, nil
+}
+
+// A bufferedConn is a connection whose first bytes were read in r.
+type bufferedConn struct {
+	net.Conn
+	r *bufio.Reader
+}
+
+func (c *bufferedConn) Read(b []byte) (int, error) {
+	if c.r.Buffered() > 0 {
+		return c.r.Read(b)
+	}
+	return c.Conn.Read(b)
+}
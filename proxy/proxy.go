@@ -0,0 +1,117 @@
+// Copyright 2018 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+// Package proxy provides dialers that make outbound connections through HTTP
+// CONNECT and SOCKS5 proxies, as networks that restrict egress often require.
+//
+// The dialers implement ContextDialer, which the ssh package accepts in
+// ssh.Dialer.Proxy and the acme package in acme.Client.Dialer, and which
+// HTTPTransport turns into an HTTP transport for other clients. Proxies can
+// be chained by setting the Forward dialer of one to another.
+package proxy // import "golang.org/x/crypto/proxy"
+
+import (
+	"context"
+	"crypto/tls"
+	"errors"
+	"net"
+	"net/http"
+	"net/url"
+	"time"
+)
+
+// A ContextDialer makes connections. *net.Dialer implements it.
+type ContextDialer interface {
+	DialContext(ctx context.Context, network, addr string) (net.Conn, error)
+}
+
+// Direct is a ContextDialer that connects directly, without a proxy.
+var Direct ContextDialer = new(net.Dialer)
+
+// Auth holds the credentials used to authenticate to a proxy.
+type Auth struct {
+	User, Password string
+}
+
+var errUnsupportedNetwork = errors.New("proxy: only TCP connections can be proxied")
+
+// FromURL returns a dialer for the proxy described by u, which connects to
+// the proxy with forward, or Direct if forward is nil. The supported schemes
+// are "http" and "https", for HTTP CONNECT proxies, the latter reached over
+// TLS, and "socks5" and "socks5h", which are equivalent as host names are
+// always resolved by the proxy. The user information of u, if any, is used
+// to authenticate to the proxy.
+func FromURL(u *url.URL, forward ContextDialer) (ContextDialer, error) {
+	var auth *Auth
+	if u.User != nil {
+		auth = &Auth{User: u.User.Username()}
+		auth.Password, _ = u.User.Password()
+	}
+	host, port := u.Hostname(), u.Port()
+	withPort := func(defaultPort string) string {
+		if port == "" {
+			port = defaultPort
+		}
+		return net.JoinHostPort(host, port)
+	}
+	switch u.Scheme {
+	case "http":
+		return &HTTPDialer{Addr: withPort("80"), Auth: auth, Forward: forward}, nil
+	case "https":
+		return &HTTPDialer{Addr: withPort("443"), Auth: auth, Forward: forward, TLSConfig: &tls.Config{ServerName: host}}, nil
+	case "socks5", "socks5h":
+		return &SOCKS5Dialer{Addr: withPort("1080"), Auth: auth, Forward: forward}, nil
+	}
+	return nil, errors.New("proxy: unsupported proxy scheme " + u.Scheme)
+}
+
+// HTTPTransport returns an HTTP transport that makes its connections with d,
+// with the same settings as http.DefaultTransport otherwise. It does not use
+// the proxy settings of the environment.
+func HTTPTransport(d ContextDialer) *http.Transport {
+	return &http.Transport{
+		DialContext:           d.DialContext,
+		MaxIdleConns:          100,
+		IdleConnTimeout:       90 * time.Second,
+		TLSHandshakeTimeout:   10 * time.Second,
+		ExpectContinueTimeout: 1 * time.Second,
+	}
+}
+
+// dialProxy connects to the proxy at addr with forward.
+func dialProxy(ctx context.Context, forward ContextDialer, addr string) (net.Conn, error) {
+	if forward == nil {
+		forward = Direct
+	}
+	return forward.DialContext(ctx, "tcp", addr)
+}
+
+// aLongTimeAgo is a deadline in the past, which aborts pending I/O.
+var aLongTimeAgo = time.Unix(1, 0)
+
+// handshake runs f, which talks to a proxy over conn, so that it is aborted
+// when ctx is done. The deadline of ctx is not set on conn, so that errors
+// caused by ctx are always reported as ctx.Err().
+func handshake(ctx context.Context, conn net.Conn, f func() error) error {
+	stop, exited := make(chan struct{}), make(chan struct{})
+	go func() {
+		defer close(exited)
+		select {
+		case <-ctx.Done():
+			conn.SetDeadline(aLongTimeAgo)
+		case <-stop:
+		}
+	}()
+	err := f()
+	close(stop)
+	<-exited
+	if err != nil {
+		if ctx.Err() != nil {
+			return ctx.Err()
+		}
+		return err
+	}
+	conn.SetDeadline(time.Time{})
+	return nil
+}
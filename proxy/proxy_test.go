@@ -0,0 +1,344 @@
+// Copyright 2018 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package proxy
+
+import (
+	"bufio"
+	"context"
+	"encoding/binary"
+	"io"
+	"io/ioutil"
+	"net"
+	"net/http"
+	"net/url"
+	"strconv"
+	"testing"
+	"time"
+)
+
+// serveTunnel greets the client through an established tunnel and echoes
+// what it sends.
+func serveTunnel(conn net.Conn, r io.Reader) {
+	conn.Write([]byte("hello\n"))
+	io.Copy(conn, r)
+}
+
+// startProxy runs serve for each connection to a new listener, and returns
+// its address.
+func startProxy(t *testing.T, serve func(net.Conn)) string {
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	go func() {
+		for {
+			conn, err := l.Accept()
+			if err != nil {
+				return
+			}
+			go func() {
+				defer conn.Close()
+				serve(conn)
+			}()
+		}
+	}()
+	return l.Addr().String()
+}
+
+// fakeHTTPProxy accepts CONNECT requests for which auth returns true, and
+// sends the requested addresses on addrs.
+func fakeHTTPProxy(t *testing.T, addrs chan<- string, auth func(string) bool) string {
+	return startProxy(t, func(conn net.Conn) {
+		br := bufio.NewReader(conn)
+		req, err := http.ReadRequest(br)
+		if err != nil {
+			return
+		}
+		addrs <- req.Method + " " + req.Host
+		if !auth(req.Header.Get("Proxy-Authorization")) {
+			conn.Write([]byte("HTTP/1.1 407 Proxy Authentication Required\r\nContent-Length: 0\r\n\r\n"))
+			return
+		}
+		// The greeting is sent along with the response, so that the client
+		// reads both at once.
+		conn.Write([]byte("HTTP/1.1 200 Connection established\r\n\r\nhello\n"))
+		io.Copy(conn, br)
+	})
+}
+
+// fakeSOCKS5Proxy accepts connections with the given credentials, or without
+// authentication if user is empty, and sends the requested addresses on
+// addrs.
+func fakeSOCKS5Proxy(t *testing.T, addrs chan<- string, user, password string) string {
+	return startProxy(t, func(conn net.Conn) {
+		buf := make([]byte, 2)
+		if _, err := io.ReadFull(conn, buf); err != nil || buf[0] != 5 {
+			return
+		}
+		methods := make([]byte, buf[1])
+		if _, err := io.ReadFull(conn, methods); err != nil {
+			return
+		}
+		want := byte(socks5AuthNone)
+		if user != "" {
+			want = socks5AuthPassword
+		}
+		method := byte(socks5NoAcceptable)
+		for _, m := range methods {
+			if m == want {
+				method = m
+			}
+		}
+		conn.Write([]byte{5, method})
+		if method == socks5NoAcceptable {
+			return
+		}
+		if method == socks5AuthPassword {
+			br := bufio.NewReader(conn)
+			readString := func() string {
+				n, _ := br.ReadByte()
+				b := make([]byte, n)
+				io.ReadFull(br, b)
+				return string(b)
+			}
+			br.ReadByte()
+			u, p := readString(), readString()
+			if u != user || p != password {
+				conn.Write([]byte{1, 1})
+				return
+			}
+			conn.Write([]byte{1, 0})
+		}
+
+		buf = make([]byte, 4)
+		if _, err := io.ReadFull(conn, buf); err != nil {
+			return
+		}
+		var host string
+		switch buf[3] {
+		case socks5IP4, socks5IP6:
+			ip := make(net.IP, net.IPv4len)
+			if buf[3] == socks5IP6 {
+				ip = make(net.IP, net.IPv6len)
+			}
+			io.ReadFull(conn, ip)
+			host = ip.String()
+		case socks5Domain:
+			io.ReadFull(conn, buf[:1])
+			b := make([]byte, buf[0])
+			io.ReadFull(conn, b)
+			host = string(b)
+		}
+		io.ReadFull(conn, buf[:2])
+		port := binary.BigEndian.Uint16(buf)
+		addrs <- net.JoinHostPort(host, strconv.Itoa(int(port)))
+		if host == "refused.example" {
+			conn.Write([]byte{5, 5, 0, socks5IP4, 0, 0, 0, 0, 0, 0})
+			return
+		}
+		// The bound address is a domain name, to exercise its parsing.
+		conn.Write([]byte{5, 0, 0, socks5Domain, 3, 'f', 'o', 'o', 0, 1})
+		if host != "127.0.0.1" {
+			serveTunnel(conn, conn)
+			return
+		}
+		// Local addresses are actually connected to, for chaining.
+		target, err := net.Dial("tcp", net.JoinHostPort(host, strconv.Itoa(int(port))))
+		if err != nil {
+			return
+		}
+		defer target.Close()
+		go io.Copy(target, conn)
+		io.Copy(conn, target)
+	})
+}
+
+// checkTunnel checks that conn is connected to serveTunnel.
+func checkTunnel(t *testing.T, conn net.Conn) {
+	defer conn.Close()
+	br := bufio.NewReader(conn)
+	if line, err := br.ReadString('\n'); err != nil || line != "hello\n" {
+		t.Fatalf("got greeting %q, %v", line, err)
+	}
+	conn.Write([]byte("ping\n"))
+	if line, err := br.ReadString('\n'); err != nil || line != "ping\n" {
+		t.Fatalf("got echo %q, %v", line, err)
+	}
+}
+
+func TestHTTPDialer(t *testing.T) {
+	addrs := make(chan string, 10)
+	proxyAddr := fakeHTTPProxy(t, addrs, func(auth string) bool {
+		return auth == "" || auth == "Basic dXNlcjpwYXNz"
+	})
+
+	d := &HTTPDialer{Addr: proxyAddr}
+	conn, err := d.DialContext(context.Background(), "tcp", "example.com:22")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if addr := <-addrs; addr != "CONNECT example.com:22" {
+		t.Errorf("proxy got %q", addr)
+	}
+	checkTunnel(t, conn)
+
+	for _, addr := range []string{"example.com", "example.com:22\r\nX-Injected: 1", "example.com:22 HTTP/1.0", "example.com\x00:22"} {
+		if _, err := d.DialContext(context.Background(), "tcp", addr); err == nil {
+			t.Errorf("DialContext accepted the address %q", addr)
+		}
+	}
+
+	u, _ := url.Parse("http://user:pass@" + proxyAddr)
+	pd, err := FromURL(u, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	conn, err = pd.DialContext(context.Background(), "tcp", "[2001:db8::1]:443")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if addr := <-addrs; addr != "CONNECT [2001:db8::1]:443" {
+		t.Errorf("proxy got %q", addr)
+	}
+	checkTunnel(t, conn)
+
+	u, _ = url.Parse("http://user:wrong@" + proxyAddr)
+	pd, _ = FromURL(u, nil)
+	if _, err := pd.DialContext(context.Background(), "tcp", "example.com:22"); err == nil {
+		t.Error("connection established with wrong credentials")
+	}
+	<-addrs
+
+	if _, err := d.DialContext(context.Background(), "udp", "example.com:53"); err == nil {
+		t.Error("UDP connection established")
+	}
+}
+
+func TestSOCKS5Dialer(t *testing.T) {
+	addrs := make(chan string, 10)
+	for _, test := range []struct {
+		user, password string
+	}{
+		{"", ""},
+		{"user", "pass"},
+	} {
+		proxyAddr := fakeSOCKS5Proxy(t, addrs, test.user, test.password)
+		u := &url.URL{Scheme: "socks5", Host: proxyAddr}
+		if test.user != "" {
+			u.User = url.UserPassword(test.user, test.password)
+		}
+		d, err := FromURL(u, nil)
+		if err != nil {
+			t.Fatal(err)
+		}
+		for _, addr := range []string{"example.com:22", "192.0.2.1:80", "[2001:db8::1]:443"} {
+			conn, err := d.DialContext(context.Background(), "tcp", addr)
+			if err != nil {
+				t.Fatalf("%s: %v", addr, err)
+			}
+			if got := <-addrs; got != addr {
+				t.Errorf("proxy got %q, want %q", got, addr)
+			}
+			checkTunnel(t, conn)
+		}
+
+		if _, err := d.DialContext(context.Background(), "tcp", "refused.example:22"); err == nil {
+			t.Error("refused connection succeeded")
+		}
+		<-addrs
+
+		if test.user != "" {
+			u.User = url.UserPassword(test.user, "wrong")
+			d, _ = FromURL(u, nil)
+			if _, err := d.DialContext(context.Background(), "tcp", "example.com:22"); err == nil {
+				t.Error("connection established with wrong credentials")
+			}
+			d = &SOCKS5Dialer{Addr: proxyAddr}
+			if _, err := d.DialContext(context.Background(), "tcp", "example.com:22"); err == nil {
+				t.Error("connection established without credentials")
+			}
+		}
+	}
+}
+
+func TestChainedProxies(t *testing.T) {
+	addrs := make(chan string, 10)
+	socksAddr := fakeSOCKS5Proxy(t, addrs, "", "")
+	httpAddr := fakeHTTPProxy(t, addrs, func(string) bool { return true })
+	d := &HTTPDialer{Addr: httpAddr, Forward: &SOCKS5Dialer{Addr: socksAddr}}
+	conn, err := d.DialContext(context.Background(), "tcp", "example.com:22")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := <-addrs; got != httpAddr {
+		t.Errorf("SOCKS proxy got %q, want %q", got, httpAddr)
+	}
+	if got := <-addrs; got != "CONNECT example.com:22" {
+		t.Errorf("HTTP proxy got %q", got)
+	}
+	checkTunnel(t, conn)
+}
+
+func TestHandshakeCancel(t *testing.T) {
+	// The proxy never answers.
+	proxyAddr := startProxy(t, func(conn net.Conn) { io.Copy(ioutil.Discard, conn) })
+	for _, d := range []ContextDialer{&HTTPDialer{Addr: proxyAddr}, &SOCKS5Dialer{Addr: proxyAddr}} {
+		ctx, cancel := context.WithCancel(context.Background())
+		go func() {
+			time.Sleep(10 * time.Millisecond)
+			cancel()
+		}()
+		if _, err := d.DialContext(ctx, "tcp", "example.com:22"); err != context.Canceled {
+			t.Errorf("%T: got %v, want context.Canceled", d, err)
+		}
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+	if _, err := (&HTTPDialer{Addr: proxyAddr}).DialContext(ctx, "tcp", "example.com:22"); err != context.DeadlineExceeded {
+		t.Errorf("got %v, want context.DeadlineExceeded", err)
+	}
+}
+
+func TestFromURL(t *testing.T) {
+	for _, test := range []struct {
+		url  string
+		want ContextDialer
+	}{
+		{"http://proxy.example", &HTTPDialer{Addr: "proxy.example:80"}},
+		{"http://u:p@proxy.example:3128", &HTTPDialer{Addr: "proxy.example:3128", Auth: &Auth{"u", "p"}}},
+		{"socks5://proxy.example", &SOCKS5Dialer{Addr: "proxy.example:1080"}},
+		{"socks5h://[2001:db8::1]:9050", &SOCKS5Dialer{Addr: "[2001:db8::1]:9050"}},
+	} {
+		u, _ := url.Parse(test.url)
+		d, err := FromURL(u, nil)
+		if err != nil {
+			t.Errorf("%s: %v", test.url, err)
+			continue
+		}
+		switch want := test.want.(type) {
+		case *HTTPDialer:
+			got, ok := d.(*HTTPDialer)
+			if !ok || got.Addr != want.Addr || (want.Auth == nil) != (got.Auth == nil) || (want.Auth != nil && *want.Auth != *got.Auth) {
+				t.Errorf("%s: got %#v, want %#v", test.url, d, want)
+			}
+		case *SOCKS5Dialer:
+			got, ok := d.(*SOCKS5Dialer)
+			if !ok || got.Addr != want.Addr {
+				t.Errorf("%s: got %#v, want %#v", test.url, d, want)
+			}
+		}
+	}
+
+	u, _ := url.Parse("https://proxy.example")
+	d, _ := FromURL(u, nil)
+	if hd, ok := d.(*HTTPDialer); !ok || hd.Addr != "proxy.example:443" || hd.TLSConfig == nil || hd.TLSConfig.ServerName != "proxy.example" {
+		t.Errorf("https: got %#v", d)
+	}
+	u, _ = url.Parse("ftp://proxy.example")
+	if _, err := FromURL(u, nil); err == nil {
+		t.Error("unsupported scheme accepted")
+	}
+}
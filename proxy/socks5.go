@@ -0,0 +1,186 @@
+// Copyright 2018 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package proxy
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net"
+	"strconv"
+)
+
+// A SOCKS5Dialer connects through a SOCKS version 5 proxy, as defined in RFC
+// 1928, with optional username/password authentication, as defined in RFC
+// 1929.
+type SOCKS5Dialer struct {
+	// Addr is the address of the proxy, as host:port.
+	Addr string
+
+	// Auth, if not nil, holds the credentials sent to the proxy if it
+	// requests username/password authentication.
+	Auth *Auth
+
+	// Forward connects to the proxy. If nil, Direct is used.
+	Forward ContextDialer
+}
+
+const (
+	socks5Version = 5
+
+	socks5AuthNone     = 0
+	socks5AuthPassword = 2
+	socks5NoAcceptable = 0xff
+
+	socks5Connect = 1
+
+	socks5IP4    = 1
+	socks5Domain = 3
+	socks5IP6    = 4
+)
+
+var socks5Errors = []string{
+	"",
+	"general SOCKS server failure",
+	"connection not allowed by ruleset",
+	"network unreachable",
+	"host unreachable",
+	"connection refused",
+	"TTL expired",
+	"command not supported",
+	"address type not supported",
+}
+
+// DialContext connects to addr through the proxy. Host names are resolved by
+// the proxy. network must be "tcp", "tcp4" or "tcp6", but the proxy chooses
+// the address family.
+func (d *SOCKS5Dialer) DialContext(ctx context.Context, network, addr string) (net.Conn, error) {
+	switch network {
+	case "tcp", "tcp4", "tcp6":
+	default:
+		return nil, errUnsupportedNetwork
+	}
+	host, portStr, err := net.SplitHostPort(addr)
+	if err != nil {
+		return nil, err
+	}
+	port, err := strconv.ParseUint(portStr, 10, 16)
+	if err != nil {
+		return nil, errors.New("proxy: invalid port " + portStr)
+	}
+	if ip := net.ParseIP(host); ip == nil && len(host) > 255 {
+		return nil, errors.New("proxy: host name too long")
+	}
+
+	conn, err := dialProxy(ctx, d.Forward, d.Addr)
+	if err != nil {
+		return nil, err
+	}
+	err = handshake(ctx, conn, func() error {
+		return d.connect(conn, host, uint16(port))
+	})
+	if err != nil {
+		conn.Close()
+		return nil, err
+	}
+	return conn, nil
+}
+
+func (d *SOCKS5Dialer) connect(conn net.Conn, host string, port uint16) error {
+	methods := []byte{socks5Version, 1, socks5AuthNone}
+	if d.Auth != nil {
+		methods = []byte{socks5Version, 2, socks5AuthNone, socks5AuthPassword}
+	}
+	if _, err := conn.Write(methods); err != nil {
+		return err
+	}
+	buf := make([]byte, 2)
+	if _, err := io.ReadFull(conn, buf); err != nil {
+		return err
+	}
+	if buf[0] != socks5Version {
+		return errors.New("proxy: unexpected SOCKS version " + strconv.Itoa(int(buf[0])))
+	}
+	switch {
+	case buf[1] == socks5AuthNone:
+	case buf[1] == socks5AuthPassword && d.Auth != nil:
+		if err := d.authenticate(conn); err != nil {
+			return err
+		}
+	case buf[1] == socks5NoAcceptable:
+		return errors.New("proxy: SOCKS proxy accepts none of the offered authentication methods")
+	default:
+		return errors.New("proxy: SOCKS proxy selected an authentication method that was not offered")
+	}
+
+	req := []byte{socks5Version, socks5Connect, 0}
+	if ip := net.ParseIP(host); ip == nil {
+		req = append(req, socks5Domain, byte(len(host)))
+		req = append(req, host...)
+	} else if ip4 := ip.To4(); ip4 != nil {
+		req = append(req, socks5IP4)
+		req = append(req, ip4...)
+	} else {
+		req = append(req, socks5IP6)
+		req = append(req, ip...)
+	}
+	req = append(req, byte(port>>8), byte(port))
+	if _, err := conn.Write(req); err != nil {
+		return err
+	}
+
+	// The reply is followed by the bound address, which is discarded.
+	buf = make([]byte, 4)
+	if _, err := io.ReadFull(conn, buf); err != nil {
+		return err
+	}
+	if buf[0] != socks5Version {
+		return errors.New("proxy: unexpected SOCKS version " + strconv.Itoa(int(buf[0])))
+	}
+	if rep := int(buf[1]); rep != 0 {
+		msg := "unknown error " + strconv.Itoa(rep)
+		if rep < len(socks5Errors) {
+			msg = socks5Errors[rep]
+		}
+		return errors.New("proxy: SOCKS proxy failed to connect: " + msg)
+	}
+	var n int
+	switch buf[3] {
+	case socks5IP4:
+		n = net.IPv4len
+	case socks5IP6:
+		n = net.IPv6len
+	case socks5Domain:
+		if _, err := io.ReadFull(conn, buf[:1]); err != nil {
+			return err
+		}
+		n = int(buf[0])
+	default:
+		return errors.New("proxy: unknown address type in SOCKS reply")
+	}
+	_, err := io.ReadFull(conn, make([]byte, n+2))
+	return err
+}
+
+func (d *SOCKS5Dialer) authenticate(conn net.Conn) error {
+	if len(d.Auth.User) == 0 || len(d.Auth.User) > 255 || len(d.Auth.Password) > 255 {
+		return errors.New("proxy: SOCKS username or password has an invalid length")
+	}
+	req := []byte{1, byte(len(d.Auth.User))}
+	req = append(req, d.Auth.User...)
+	req = append(req, byte(len(d.Auth.Password)))
+	req = append(req, d.Auth.Password...)
+	if _, err := conn.Write(req); err != nil {
+		return err
+	}
+	buf := make([]byte, 2)
+	if _, err := io.ReadFull(conn, buf); err != nil {
+		return err
+	}
+	if buf[1] != 0 {
+		return errors.New("proxy: SOCKS proxy rejected the username or password")
+	}
+	return nil
+}
@@ -0,0 +1,39 @@
+// Copyright 2018 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package ratelimit_test
+
+import (
+	"crypto/subtle"
+	"errors"
+
+	"golang.org/x/crypto/argon2"
+	"golang.org/x/crypto/ratelimit"
+	"golang.org/x/crypto/ssh"
+)
+
+func Example() {
+	// Each user may try one password every ten seconds, with a burst of
+	// five, and the server verifies at most 20 passwords per second.
+	limiter := ratelimit.New(&ratelimit.Config{
+		Rate: 0.1, Burst: 5,
+		GlobalRate: 20, GlobalBurst: 40,
+	})
+
+	var salt, hash []byte // Loaded from the user database.
+	config := &ssh.ServerConfig{
+		PasswordCallback: func(c ssh.ConnMetadata, pass []byte) (*ssh.Permissions, error) {
+			// Check the limits before the expensive hash.
+			if !limiter.Allow([]byte(c.User()), 1) {
+				return nil, errors.New("too many attempts")
+			}
+			h := argon2.IDKey(pass, salt, 1, 64*1024, 4, 32)
+			if subtle.ConstantTimeCompare(h, hash) != 1 {
+				return nil, errors.New("wrong password")
+			}
+			return nil, nil
+		},
+	}
+	_ = config
+}
@@ -0,0 +1,161 @@
+// Copyright 2018 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+// Package ratelimit implements admission control for expensive operations,
+// such as verifying Argon2 password hashes, issuing ACME certificates or
+// authenticating SSH clients, which an attacker could otherwise trigger in
+// bulk to exhaust a server's CPU or to guess secrets online.
+//
+// A Limiter holds token buckets, which refill at a steady rate up to a
+// maximum, the burst. Each operation has a cost, in tokens, and is admitted
+// only if the bucket of its key, such as a user name or a client address,
+// and the optional global bucket, hold enough tokens. Cheap and expensive
+// operations can thus share a budget.
+//
+// Keys are not stored: they are mapped to a fixed number of buckets by a MAC
+// keyed with a random salt, so that memory does not grow with the number of
+// keys. Keys that share a bucket share its budget, which only errs on the
+// side of rejecting operations. The salt keeps an attacker from computing
+// offline which keys share a bucket with a victim's, or reading the seen keys
+// out of a memory dump, but not from learning it online: with few buckets,
+// draining one bucket and probing which keys are then rejected, or simply
+// spreading requests over many keys to drain all of them, remains possible.
+// The global bucket, and per-address limits in front of per-user ones, are
+// the defense against such attacks.
+package ratelimit // import "golang.org/x/crypto/ratelimit"
+
+import (
+	cryptorand "crypto/rand"
+	"encoding/binary"
+	"sync"
+	"time"
+
+	"golang.org/x/crypto/blake2b"
+)
+
+// DefaultBuckets is the number of per-key buckets of a Limiter whose Config
+// does not set Buckets.
+const DefaultBuckets = 4096
+
+// Config configures a Limiter. Rates are in tokens per second.
+type Config struct {
+	// Rate and Burst are the refill rate and the capacity of the bucket of
+	// each key. Burst must be positive.
+	Rate, Burst float64
+
+	// GlobalRate and GlobalBurst are those of the bucket shared by all
+	// operations. If GlobalBurst is zero, there is no global limit.
+	GlobalRate, GlobalBurst float64
+
+	// Buckets is the number of per-key buckets. More buckets make it less
+	// likely for keys to share one, at a cost of 32 bytes each. If zero,
+	// DefaultBuckets is used.
+	Buckets int
+
+	// Metrics, if not nil, is called with every decision of the Limiter,
+	// without its lock held. It must be safe for concurrent use.
+	Metrics func(Event)
+}
+
+// An Event is a decision of a Limiter, reported to Config.Metrics. It does
+// not hold the key of the operation.
+type Event struct {
+	// Allowed reports whether the operation was admitted.
+	Allowed bool
+	// Global reports whether the operation was rejected by the global
+	// bucket rather than by the bucket of its key.
+	Global bool
+	// Cost is the cost of the operation.
+	Cost float64
+}
+
+type bucket struct {
+	tokens float64
+	last   time.Time // the time tokens was computed at, or zero if full
+}
+
+// refill adds the tokens b earned at rate since it was last refilled, up to
+// burst.
+func (b *bucket) refill(now time.Time, rate, burst float64) {
+	if b.last.IsZero() {
+		b.tokens = burst
+	} else if d := now.Sub(b.last); d > 0 {
+		b.tokens += d.Seconds() * rate
+		if b.tokens > burst {
+			b.tokens = burst
+		}
+	}
+	b.last = now
+}
+
+// A Limiter admits operations at the rates of its Config. It is safe for
+// concurrent use.
+type Limiter struct {
+	config Config
+	salt   [32]byte
+	now    func() time.Time
+
+	mu      sync.Mutex
+	buckets []bucket
+	global  bucket
+}
+
+// New returns a Limiter with the given configuration, with all buckets
+// full. It panics if config.Burst is not positive.
+func New(config *Config) *Limiter {
+	if !(config.Burst > 0) {
+		panic("ratelimit: Burst must be positive")
+	}
+	l := &Limiter{config: *config, now: time.Now}
+	if l.config.Buckets <= 0 {
+		l.config.Buckets = DefaultBuckets
+	}
+	l.buckets = make([]bucket, l.config.Buckets)
+	if _, err := cryptorand.Read(l.salt[:]); err != nil {
+		panic("ratelimit: failed to read random salt: " + err.Error())
+	}
+	return l
+}
+
+// index returns the index of the bucket of key.
+func (l *Limiter) index(key []byte) int {
+	h, _ := blake2b.New(8, l.salt[:])
+	h.Write(key)
+	return int(binary.LittleEndian.Uint64(h.Sum(nil)) % uint64(len(l.buckets)))
+}
+
+// Allow reports whether an operation of the given cost on key is admitted,
+// and if so takes cost tokens from its buckets. A rejected operation takes
+// no tokens, so that clients that retry are admitted as soon as their
+// bucket refills. An operation that costs more than the burst of a bucket
+// is never admitted. It panics if cost is negative.
+func (l *Limiter) Allow(key []byte, cost float64) bool {
+	if cost < 0 {
+		panic("ratelimit: negative cost")
+	}
+	i := l.index(key)
+
+	l.mu.Lock()
+	now := l.now()
+	b := &l.buckets[i]
+	b.refill(now, l.config.Rate, l.config.Burst)
+	ok, global := b.tokens >= cost, false
+	if ok && l.config.GlobalBurst > 0 {
+		l.global.refill(now, l.config.GlobalRate, l.config.GlobalBurst)
+		ok = l.global.tokens >= cost
+		global = !ok
+		if ok {
+			l.global.tokens -= cost
+		}
+	}
+	if ok {
+		b.tokens -= cost
+	}
+	l.mu.Unlock()
+
+	if l.config.Metrics != nil {
+		l.config.Metrics(Event{Allowed: ok, Global: global, Cost: cost})
+	}
+	return ok
+}
@@ -0,0 +1,151 @@
+// Copyright 2018 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package ratelimit
+
+import (
+	"sync"
+	"testing"
+	"time"
+)
+
+// fakeClock is a clock that only moves when told to.
+type fakeClock struct {
+	t time.Time
+}
+
+func (c *fakeClock) now() time.Time          { return c.t }
+func (c *fakeClock) advance(d time.Duration) { c.t = c.t.Add(d) }
+func newTestLimiter(config *Config) (*Limiter, *fakeClock) {
+	l := New(config)
+	c := &fakeClock{t: time.Unix(1500000000, 0)}
+	l.now = c.now
+	return l, c
+}
+
+func TestAllow(t *testing.T) {
+	l, clock := newTestLimiter(&Config{Rate: 1, Burst: 3})
+	alice := []byte("alice")
+
+	for i := 0; i < 3; i++ {
+		if !l.Allow(alice, 1) {
+			t.Fatalf("operation %d of the burst rejected", i)
+		}
+	}
+	if l.Allow(alice, 1) {
+		t.Fatal("operation beyond the burst admitted")
+	}
+
+	clock.advance(1500 * time.Millisecond)
+	if l.Allow(alice, 2) {
+		t.Error("operation of cost 2 admitted with 1.5 tokens")
+	}
+	if !l.Allow(alice, 1) {
+		t.Error("operation of cost 1 rejected with 1.5 tokens")
+	}
+
+	// The bucket does not refill beyond the burst.
+	clock.advance(time.Hour)
+	if !l.Allow(alice, 3) {
+		t.Error("operation of cost 3 rejected with a full bucket")
+	}
+	if l.Allow(alice, 0.5) {
+		t.Error("operation admitted with an empty bucket")
+	}
+	if l.Allow([]byte("bob"), 4) {
+		t.Error("operation costing more than the burst admitted")
+	}
+}
+
+func TestKeysAreIndependent(t *testing.T) {
+	l, _ := newTestLimiter(&Config{Rate: 1, Burst: 1, Buckets: 1 << 16})
+	a, b := []byte("alice"), []byte("bob")
+	if l.index(a) == l.index(b) {
+		t.Skip("keys share a bucket")
+	}
+	if !l.Allow(a, 1) || l.Allow(a, 1) {
+		t.Fatal("alice's bucket holds more than one token")
+	}
+	if !l.Allow(b, 1) {
+		t.Error("bob rejected after alice exhausted her bucket")
+	}
+}
+
+func TestSalt(t *testing.T) {
+	// With 2^16 buckets, two limiters map keys the same way with
+	// negligible probability if they use different salts.
+	l1 := New(&Config{Burst: 1, Buckets: 1 << 16})
+	l2 := New(&Config{Burst: 1, Buckets: 1 << 16})
+	same := 0
+	for _, k := range []string{"a", "b", "c", "d", "e", "f", "g", "h"} {
+		if l1.index([]byte(k)) == l2.index([]byte(k)) {
+			same++
+		}
+	}
+	if same == 8 {
+		t.Error("limiters map keys to the same buckets")
+	}
+}
+
+func TestGlobal(t *testing.T) {
+	var events []Event
+	l, clock := newTestLimiter(&Config{
+		Rate: 10, Burst: 10,
+		GlobalRate: 1, GlobalBurst: 2,
+		Metrics: func(e Event) { events = append(events, e) },
+	})
+	if !l.Allow([]byte("a"), 1) || !l.Allow([]byte("b"), 1) {
+		t.Fatal("operations within the global burst rejected")
+	}
+	if l.Allow([]byte("c"), 1) {
+		t.Fatal("operation beyond the global burst admitted")
+	}
+	clock.advance(time.Second)
+	if !l.Allow([]byte("c"), 1) {
+		t.Error("operation rejected after the global bucket refilled")
+	}
+	if l.Allow([]byte("c"), 11) {
+		t.Error("operation costing more than the burst admitted")
+	}
+
+	want := []Event{
+		{Allowed: true, Cost: 1},
+		{Allowed: true, Cost: 1},
+		{Allowed: false, Global: true, Cost: 1},
+		{Allowed: true, Cost: 1},
+		{Allowed: false, Cost: 11},
+	}
+	if len(events) != len(want) {
+		t.Fatalf("got %d events, want %d", len(events), len(want))
+	}
+	for i := range want {
+		if events[i] != want[i] {
+			t.Errorf("event %d: got %+v, want %+v", i, events[i], want[i])
+		}
+	}
+}
+
+func TestConcurrent(t *testing.T) {
+	l := New(&Config{Burst: 100, GlobalBurst: 1000})
+	var wg sync.WaitGroup
+	var mu sync.Mutex
+	admitted := 0
+	for i := 0; i < 8; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for j := 0; j < 50; j++ {
+				if l.Allow([]byte("key"), 1) {
+					mu.Lock()
+					admitted++
+					mu.Unlock()
+				}
+			}
+		}()
+	}
+	wg.Wait()
+	if admitted != 100 {
+		t.Errorf("admitted %d operations, want 100", admitted)
+	}
+}
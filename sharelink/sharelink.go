@@ -0,0 +1,250 @@
+// Copyright 2018 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+// Package sharelink implements one-time links for sharing a secret through a
+// server that cannot read it.
+//
+// The creator of a link encrypts the secret under a random link key and
+// uploads the ciphertext to the server. The link key is given to the
+// recipient, typically in the fragment of a URL, which browsers do not send
+// to servers. From the link key, the recipient derives the ID under which
+// the ciphertext is stored and a retrieval token, which it presents to the
+// server to obtain the ciphertext, and then decrypts it.
+//
+// The server only stores a MAC of the retrieval token, so that the contents
+// of its storage are not enough to retrieve ciphertexts. It must delete a
+// record as soon as the token was verified, which makes the link single-use.
+//
+// A link can additionally be protected with a password, which is then needed
+// to decrypt the secret. The password is mixed into the encryption key with
+// Argon2id, and as the server never sees the link key, it cannot attempt to
+// guess the password.
+package sharelink // import "golang.org/x/crypto/sharelink"
+
+import (
+	"crypto/hmac"
+	cryptorand "crypto/rand"
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/base64"
+	"errors"
+	"io"
+
+	"golang.org/x/crypto/argon2"
+	"golang.org/x/crypto/chacha20poly1305"
+	"golang.org/x/crypto/hkdf"
+)
+
+const (
+	// KeySize is the size, in bytes, of link keys.
+	KeySize = 32
+	// IDSize is the size, in bytes, of the IDs of links.
+	IDSize = 16
+	// TokenSize is the size, in bytes, of retrieval tokens.
+	TokenSize = 32
+)
+
+var (
+	// ErrPasswordRequired is returned by Link.Open when the secret is
+	// protected with a password and none was given.
+	ErrPasswordRequired = errors.New("sharelink: secret is protected with a password")
+	// ErrDecrypt is returned by Link.Open when the ciphertext cannot be
+	// decrypted, because the password is wrong or the ciphertext does not
+	// belong to the link or was modified.
+	ErrDecrypt = errors.New("sharelink: cannot decrypt secret")
+
+	errBadLink       = errors.New("sharelink: malformed link")
+	errBadCiphertext = errors.New("sharelink: malformed ciphertext")
+	errShortMACKey   = errors.New("sharelink: MAC key too short")
+)
+
+// The first byte of ciphertexts identifies the format version, and the second
+// one holds flags.
+const (
+	version1     = 1
+	flagPassword = 1 << 0
+	headerSize   = 2
+)
+
+// The Argon2id parameters used for passwords, following the recommendations
+// in the documentation of golang.org/x/crypto/argon2. They are fixed by the
+// format version.
+const (
+	argon2Time    = 1
+	argon2Memory  = 64 * 1024
+	argon2Threads = 4
+)
+
+// A Link holds the key of a share link.
+type Link struct {
+	key [KeySize]byte
+}
+
+// ParseLink parses a link key encoded by Link.String.
+func ParseLink(s string) (*Link, error) {
+	key, err := base64.RawURLEncoding.DecodeString(s)
+	if err != nil || len(key) != KeySize {
+		return nil, errBadLink
+	}
+	l := new(Link)
+	copy(l.key[:], key)
+	return l, nil
+}
+
+// String returns the link key encoded in unpadded base64url, which can be
+// used as the fragment of a URL.
+func (l *Link) String() string {
+	return base64.RawURLEncoding.EncodeToString(l.key[:])
+}
+
+func (l *Link) derive(ikm []byte, label string, n int) []byte {
+	out := make([]byte, n)
+	r := hkdf.New(sha256.New, ikm, nil, []byte("golang.org/x/crypto/sharelink "+label))
+	if _, err := io.ReadFull(r, out); err != nil {
+		panic("sharelink: HKDF failed: " + err.Error())
+	}
+	return out
+}
+
+// ID returns the ID under which the server stores the ciphertext of the link.
+func (l *Link) ID() []byte {
+	return l.derive(l.key[:], "id", IDSize)
+}
+
+// Token returns the token that the recipient presents to the server to
+// retrieve the ciphertext.
+func (l *Link) Token() []byte {
+	return l.derive(l.key[:], "token", TokenSize)
+}
+
+// encryptionKey returns the key encrypting the secret, which depends on the
+// password if there is one.
+func (l *Link) encryptionKey(password []byte, protected bool) []byte {
+	ikm := l.key[:]
+	if protected {
+		// The ID is unique to the link, and can serve as the salt.
+		pwKey := argon2.IDKey(password, l.ID(), argon2Time, argon2Memory, argon2Threads, KeySize)
+		ikm = append(append([]byte{}, l.key[:]...), pwKey...)
+	}
+	return l.derive(ikm, "encryption", chacha20poly1305.KeySize)
+}
+
+// An Upload is sent to the server by the creator of a link.
+type Upload struct {
+	ID         []byte
+	Token      []byte
+	Ciphertext []byte
+}
+
+// Seal creates a new link for secret and returns it with the data to upload
+// to the server. If password is not empty, it is needed to decrypt the
+// secret. If rand is nil, crypto/rand.Reader is used.
+func Seal(rand io.Reader, secret, password []byte) (*Link, *Upload, error) {
+	if rand == nil {
+		rand = cryptorand.Reader
+	}
+	l := new(Link)
+	if _, err := io.ReadFull(rand, l.key[:]); err != nil {
+		return nil, nil, err
+	}
+
+	header := []byte{version1, 0}
+	if len(password) > 0 {
+		header[1] |= flagPassword
+	}
+	aead, err := chacha20poly1305.NewX(l.encryptionKey(password, len(password) > 0))
+	if err != nil {
+		return nil, nil, err
+	}
+	ciphertext := make([]byte, headerSize+chacha20poly1305.NonceSizeX, headerSize+chacha20poly1305.NonceSizeX+len(secret)+aead.Overhead())
+	copy(ciphertext, header)
+	nonce := ciphertext[headerSize:]
+	if _, err := io.ReadFull(rand, nonce); err != nil {
+		return nil, nil, err
+	}
+	id := l.ID()
+	ciphertext = aead.Seal(ciphertext, nonce, secret, additionalData(header, id))
+	return l, &Upload{ID: id, Token: l.Token(), Ciphertext: ciphertext}, nil
+}
+
+// additionalData binds the ciphertext to its header and to the link.
+func additionalData(header, id []byte) []byte {
+	return append(append([]byte{}, header...), id...)
+}
+
+// PasswordProtected reports whether a ciphertext needs a password to be
+// decrypted.
+func PasswordProtected(ciphertext []byte) bool {
+	return len(ciphertext) >= headerSize && ciphertext[1]&flagPassword != 0
+}
+
+// Open decrypts the ciphertext of the link, retrieved from the server. If
+// the secret is protected with a password and password is empty, Open returns
+// ErrPasswordRequired.
+func (l *Link) Open(ciphertext, password []byte) ([]byte, error) {
+	if len(ciphertext) < headerSize+chacha20poly1305.NonceSizeX || ciphertext[0] != version1 || ciphertext[1]&^flagPassword != 0 {
+		return nil, errBadCiphertext
+	}
+	protected := PasswordProtected(ciphertext)
+	if protected && len(password) == 0 {
+		return nil, ErrPasswordRequired
+	}
+	header := ciphertext[:headerSize]
+	nonce := ciphertext[headerSize : headerSize+chacha20poly1305.NonceSizeX]
+	aead, err := chacha20poly1305.NewX(l.encryptionKey(password, protected))
+	if err != nil {
+		return nil, err
+	}
+	secret, err := aead.Open(nil, nonce, ciphertext[headerSize+chacha20poly1305.NonceSizeX:], additionalData(header, l.ID()))
+	if err != nil {
+		return nil, ErrDecrypt
+	}
+	return secret, nil
+}
+
+// A Record is what the server stores for a link until it is used.
+type Record struct {
+	ID         []byte
+	TokenMAC   []byte
+	Ciphertext []byte
+}
+
+// A Verifier checks retrieval tokens on the server. It holds a secret MAC
+// key, which must be kept separately from the stored records.
+type Verifier struct {
+	key []byte
+}
+
+// NewVerifier returns a Verifier with the given MAC key, which must be at
+// least 32 bytes long.
+func NewVerifier(key []byte) (*Verifier, error) {
+	if len(key) < 32 {
+		return nil, errShortMACKey
+	}
+	return &Verifier{key: append([]byte{}, key...)}, nil
+}
+
+func (v *Verifier) mac(id, token []byte) []byte {
+	m := hmac.New(sha256.New, v.key)
+	m.Write(id)
+	m.Write(token)
+	return m.Sum(nil)
+}
+
+// NewRecord returns the record to store for an upload. The token of the
+// upload is not kept.
+func (v *Verifier) NewRecord(u *Upload) *Record {
+	return &Record{
+		ID:         append([]byte{}, u.ID...),
+		TokenMAC:   v.mac(u.ID, u.Token),
+		Ciphertext: append([]byte{}, u.Ciphertext...),
+	}
+}
+
+// Verify reports whether token allows retrieving r. If it does, the server
+// must delete r before returning its ciphertext, so that the link can only
+// be used once, even by concurrent requests.
+func (v *Verifier) Verify(r *Record, token []byte) bool {
+	return subtle.ConstantTimeCompare(v.mac(r.ID, token), r.TokenMAC) == 1
+}
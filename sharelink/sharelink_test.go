@@ -0,0 +1,131 @@
+// Copyright 2018 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package sharelink
+
+import (
+	"bytes"
+	"testing"
+)
+
+func newVerifier(t *testing.T) *Verifier {
+	v, err := NewVerifier(bytes.Repeat([]byte{0x42}, 32))
+	if err != nil {
+		t.Fatal(err)
+	}
+	return v
+}
+
+func TestRoundTrip(t *testing.T) {
+	v := newVerifier(t)
+	secret := []byte("correct horse battery staple")
+	for _, password := range [][]byte{nil, []byte("hunter2")} {
+		link, up, err := Seal(nil, secret, password)
+		if err != nil {
+			t.Fatal(err)
+		}
+		rec := v.NewRecord(up)
+		if bytes.Contains(rec.Ciphertext, secret) {
+			t.Fatal("ciphertext contains the secret")
+		}
+
+		// The recipient only has the string form of the link.
+		recipient, err := ParseLink(link.String())
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !bytes.Equal(recipient.ID(), rec.ID) {
+			t.Errorf("recipient derived ID %x, want %x", recipient.ID(), rec.ID)
+		}
+		if !v.Verify(rec, recipient.Token()) {
+			t.Fatal("valid token rejected")
+		}
+		if got := PasswordProtected(rec.Ciphertext); got != (password != nil) {
+			t.Errorf("PasswordProtected = %v, want %v", got, password != nil)
+		}
+		got, err := recipient.Open(rec.Ciphertext, password)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !bytes.Equal(got, secret) {
+			t.Errorf("Open = %q, want %q", got, secret)
+		}
+	}
+}
+
+func TestPassword(t *testing.T) {
+	link, up, err := Seal(nil, []byte("secret"), []byte("hunter2"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := link.Open(up.Ciphertext, nil); err != ErrPasswordRequired {
+		t.Errorf("Open without a password: got %v, want %v", err, ErrPasswordRequired)
+	}
+	if _, err := link.Open(up.Ciphertext, []byte("hunter3")); err != ErrDecrypt {
+		t.Errorf("Open with a wrong password: got %v, want %v", err, ErrDecrypt)
+	}
+
+	// Clearing the password flag does not allow decrypting without it.
+	stripped := append([]byte{}, up.Ciphertext...)
+	stripped[1] = 0
+	if _, err := link.Open(stripped, nil); err != ErrDecrypt {
+		t.Errorf("Open with the password flag cleared: got %v, want %v", err, ErrDecrypt)
+	}
+}
+
+func TestWrongLink(t *testing.T) {
+	v := newVerifier(t)
+	_, up, err := Seal(nil, []byte("secret"), nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	other, otherUp, err := Seal(nil, []byte("other secret"), nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	rec := v.NewRecord(up)
+	if v.Verify(rec, other.Token()) {
+		t.Error("token of another link accepted")
+	}
+	if v.Verify(rec, otherUp.Token[:TokenSize-1]) {
+		t.Error("truncated token accepted")
+	}
+	if _, err := other.Open(up.Ciphertext, nil); err != ErrDecrypt {
+		t.Errorf("Open with another link: got %v, want %v", err, ErrDecrypt)
+	}
+
+	// A record verified with a different MAC key rejects the token.
+	v2, err := NewVerifier(bytes.Repeat([]byte{0x43}, 32))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if v2.Verify(rec, up.Token) {
+		t.Error("token accepted with a different MAC key")
+	}
+}
+
+func TestMalformed(t *testing.T) {
+	for _, s := range []string{"", "AAAA", "not base64!", string(bytes.Repeat([]byte{'A'}, 44))} {
+		if _, err := ParseLink(s); err == nil {
+			t.Errorf("ParseLink(%q) succeeded", s)
+		}
+	}
+	link, up, err := Seal(nil, []byte("secret"), nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, ct := range [][]byte{
+		nil,
+		up.Ciphertext[:headerSize+10],
+		append([]byte{2}, up.Ciphertext[1:]...),
+		append([]byte{version1, 0x80}, up.Ciphertext[2:]...),
+	} {
+		if _, err := link.Open(ct, nil); err != errBadCiphertext {
+			t.Errorf("Open(%x): got %v, want %v", ct, err, errBadCiphertext)
+		}
+	}
+	if _, err := NewVerifier(make([]byte, 31)); err == nil {
+		t.Error("NewVerifier accepted a short key")
+	}
+}
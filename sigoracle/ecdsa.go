@@ -0,0 +1,164 @@
+// Copyright 2018 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package sigoracle
+
+import (
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/hmac"
+	"encoding/asn1"
+	"errors"
+	"io"
+	"math/big"
+)
+
+var errHashUnavailable = errors.New("sigoracle: hash function is not available")
+
+// SignECDSA signs digest, the hash of a message computed with the given hash
+// function, with the deterministic ECDSA of RFC 6979. The nonce is derived
+// from priv and digest with an HMAC_DRBG based on the same hash function.
+//
+// SignECDSA uses math/big, whose operations are not constant time. It is
+// meant for tests, and for keys that are not exposed to timing attacks.
+func SignECDSA(priv *ecdsa.PrivateKey, hash crypto.Hash, digest []byte) (r, s *big.Int, err error) {
+	if !hash.Available() {
+		return nil, nil, errHashUnavailable
+	}
+	q := priv.Curve.Params().N
+	e := bits2int(digest, q)
+	g := newNonceGenerator(priv.D, q, hash, digest)
+	for {
+		k := g.next()
+		x, _ := priv.Curve.ScalarBaseMult(k.Bytes())
+		r = new(big.Int).Mod(x, q)
+		if r.Sign() == 0 {
+			continue
+		}
+		s = new(big.Int).Mul(priv.D, r)
+		s.Add(s, e)
+		s.Mul(s, new(big.Int).ModInverse(k, q))
+		s.Mod(s, q)
+		if s.Sign() != 0 {
+			return r, s, nil
+		}
+	}
+}
+
+// An ECDSASigner is a crypto.Signer that produces the deterministic
+// signatures of SignECDSA, encoded in ASN.1 like those of crypto/ecdsa.
+type ECDSASigner struct {
+	priv *ecdsa.PrivateKey
+}
+
+// NewECDSASigner returns an ECDSASigner for priv.
+func NewECDSASigner(priv *ecdsa.PrivateKey) *ECDSASigner {
+	return &ECDSASigner{priv: priv}
+}
+
+// Public returns the public key of the signer.
+func (e *ECDSASigner) Public() crypto.PublicKey {
+	return &e.priv.PublicKey
+}
+
+// Sign signs digest, which must have been computed with opts.HashFunc(). As
+// the signature is deterministic, rand is ignored.
+func (e *ECDSASigner) Sign(rand io.Reader, digest []byte, opts crypto.SignerOpts) ([]byte, error) {
+	r, s, err := SignECDSA(e.priv, opts.HashFunc(), digest)
+	if err != nil {
+		return nil, err
+	}
+	return asn1.Marshal(ecdsaSignature{r, s})
+}
+
+type ecdsaSignature struct {
+	R, S *big.Int
+}
+
+// bits2int implements the function of the same name of RFC 6979, Section
+// 2.3.2, which is also how ECDSA converts hashes to integers.
+func bits2int(b []byte, q *big.Int) *big.Int {
+	v := new(big.Int).SetBytes(b)
+	if excess := len(b)*8 - q.BitLen(); excess > 0 {
+		v.Rsh(v, uint(excess))
+	}
+	return v
+}
+
+// int2octets implements the function of the same name of RFC 6979, Section
+// 2.3.3.
+func int2octets(v, q *big.Int) []byte {
+	out := make([]byte, (q.BitLen()+7)/8)
+	b := v.Bytes()
+	copy(out[len(out)-len(b):], b)
+	return out
+}
+
+// bits2octets implements the function of the same name of RFC 6979, Section
+// 2.3.4.
+func bits2octets(b []byte, q *big.Int) []byte {
+	z := bits2int(b, q)
+	if z.Cmp(q) >= 0 {
+		z.Sub(z, q)
+	}
+	return int2octets(z, q)
+}
+
+// A nonceGenerator produces the candidate nonces of RFC 6979, Section 3.2.
+type nonceGenerator struct {
+	q    *big.Int
+	hash crypto.Hash
+	k, v []byte
+	// started is set once the first candidate was produced; the state must
+	// be updated before producing the next ones.
+	started bool
+}
+
+func newNonceGenerator(x, q *big.Int, hash crypto.Hash, h1 []byte) *nonceGenerator {
+	size := hash.Size()
+	g := &nonceGenerator{
+		q:    q,
+		hash: hash,
+		k:    make([]byte, size),
+		v:    make([]byte, size),
+	}
+	for i := range g.v {
+		g.v[i] = 0x01
+	}
+	seed := append(int2octets(x, q), bits2octets(h1, q)...)
+	g.k = g.mac(g.v, []byte{0x00}, seed)
+	g.v = g.mac(g.v)
+	g.k = g.mac(g.v, []byte{0x01}, seed)
+	g.v = g.mac(g.v)
+	return g
+}
+
+func (g *nonceGenerator) mac(data ...[]byte) []byte {
+	m := hmac.New(g.hash.New, g.k)
+	for _, d := range data {
+		m.Write(d)
+	}
+	return m.Sum(nil)
+}
+
+// next returns the next candidate nonce between 1 and q-1.
+func (g *nonceGenerator) next() *big.Int {
+	for {
+		if g.started {
+			g.k = g.mac(g.v, []byte{0x00})
+			g.v = g.mac(g.v)
+		}
+		g.started = true
+
+		var t []byte
+		for len(t)*8 < g.q.BitLen() {
+			g.v = g.mac(g.v)
+			t = append(t, g.v...)
+		}
+		k := bits2int(t, g.q)
+		if k.Sign() > 0 && k.Cmp(g.q) < 0 {
+			return k
+		}
+	}
+}
@@ -0,0 +1,138 @@
+// Copyright 2018 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package sigoracle
+
+import (
+	"crypto/sha512"
+	"math/big"
+)
+
+// This file implements Ed25519 signing following the reference code of RFC
+// 8032, Section 6, with math/big and affine coordinates. It is slow, and
+// shares no code with golang.org/x/crypto/ed25519, so that it can catch
+// mistakes in it.
+
+var (
+	edP = new(big.Int).Sub(new(big.Int).Lsh(big.NewInt(1), 255), big.NewInt(19))
+	edL = func() *big.Int {
+		l, _ := new(big.Int).SetString("27742317777372353535851937790883648493", 10)
+		return l.Add(l, new(big.Int).Lsh(big.NewInt(1), 252))
+	}()
+	edD = func() *big.Int {
+		d := new(big.Int).ModInverse(big.NewInt(121666), edP)
+		d.Mul(d, big.NewInt(-121665))
+		return d.Mod(d, edP)
+	}()
+	edB = func() edPoint {
+		y := new(big.Int).ModInverse(big.NewInt(5), edP)
+		y.Mul(y, big.NewInt(4)).Mod(y, edP)
+		return edPoint{edRecoverX(y), y}
+	}()
+)
+
+type edPoint struct {
+	x, y *big.Int
+}
+
+// edRecoverX returns the even x coordinate of the point with coordinate y.
+func edRecoverX(y *big.Int) *big.Int {
+	// x^2 = (y^2 - 1) / (d*y^2 + 1)
+	yy := new(big.Int).Mul(y, y)
+	num := new(big.Int).Sub(yy, big.NewInt(1))
+	den := new(big.Int).Mul(edD, yy)
+	den.Add(den, big.NewInt(1))
+	xx := num.Mul(num, den.ModInverse(den, edP))
+	xx.Mod(xx, edP)
+
+	exp := new(big.Int).Add(edP, big.NewInt(3))
+	x := new(big.Int).Exp(xx, exp.Rsh(exp, 3), edP)
+	if new(big.Int).Mod(new(big.Int).Mul(x, x), edP).Cmp(xx) != 0 {
+		exp := new(big.Int).Sub(edP, big.NewInt(1))
+		sqrtM1 := new(big.Int).Exp(big.NewInt(2), exp.Rsh(exp, 2), edP)
+		x.Mul(x, sqrtM1).Mod(x, edP)
+	}
+	if x.Bit(0) == 1 {
+		x.Sub(edP, x)
+	}
+	return x
+}
+
+func edAdd(p, q edPoint) edPoint {
+	x1y2 := new(big.Int).Mul(p.x, q.y)
+	x2y1 := new(big.Int).Mul(q.x, p.y)
+	x1x2 := new(big.Int).Mul(p.x, q.x)
+	y1y2 := new(big.Int).Mul(p.y, q.y)
+	dxxyy := new(big.Int).Mul(x1x2, y1y2)
+	dxxyy.Mul(dxxyy, edD).Mod(dxxyy, edP)
+
+	den := new(big.Int).Add(big.NewInt(1), dxxyy)
+	x := x1y2.Add(x1y2, x2y1)
+	x.Mul(x, den.ModInverse(den, edP)).Mod(x, edP)
+	den = new(big.Int).Sub(big.NewInt(1), dxxyy)
+	den.Mod(den, edP)
+	y := y1y2.Add(y1y2, x1x2)
+	y.Mul(y, den.ModInverse(den, edP)).Mod(y, edP)
+	return edPoint{x, y}
+}
+
+func edScalarMult(p edPoint, k *big.Int) edPoint {
+	r := edPoint{big.NewInt(0), big.NewInt(1)}
+	for i := k.BitLen() - 1; i >= 0; i-- {
+		r = edAdd(r, r)
+		if k.Bit(i) == 1 {
+			r = edAdd(r, p)
+		}
+	}
+	return r
+}
+
+func edEncode(p edPoint) []byte {
+	out := littleEndian(p.y, 32)
+	out[31] |= byte(p.x.Bit(0)) << 7
+	return out
+}
+
+func littleEndian(v *big.Int, n int) []byte {
+	out := make([]byte, n)
+	b := v.Bytes()
+	for i := range b {
+		out[i] = b[len(b)-1-i]
+	}
+	return out
+}
+
+func fromLittleEndian(b []byte) *big.Int {
+	r := make([]byte, len(b))
+	for i := range b {
+		r[i] = b[len(b)-1-i]
+	}
+	return new(big.Int).SetBytes(r)
+}
+
+func edHashToScalar(parts ...[]byte) *big.Int {
+	h := sha512.New()
+	for _, p := range parts {
+		h.Write(p)
+	}
+	return new(big.Int).Mod(fromLittleEndian(h.Sum(nil)), edL)
+}
+
+// edSign returns the Ed25519 public key and signature of message for the
+// 32-byte seed.
+func edSign(seed, message []byte) (publicKey, signature []byte) {
+	h := sha512.Sum512(seed)
+	h[0] &= 248
+	h[31] &= 127
+	h[31] |= 64
+	a := fromLittleEndian(h[:32])
+	publicKey = edEncode(edScalarMult(edB, a))
+
+	r := edHashToScalar(h[32:], message)
+	encR := edEncode(edScalarMult(edB, r))
+	k := edHashToScalar(encR, publicKey, message)
+	s := k.Mul(k, a)
+	s.Add(s, r).Mod(s, edL)
+	return publicKey, append(encR, littleEndian(s, 32)...)
+}
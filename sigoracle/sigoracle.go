@@ -0,0 +1,132 @@
+// Copyright 2018 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+// Package sigoracle recomputes deterministic signatures independently, to
+// check signers at run time.
+//
+// Programs that must test their cryptographic modules when they start, such
+// as the power-on self tests of FIPS 140-2, can call SelfTest to run known
+// answer tests, and Check to cross-check the signers they use. Ed25519
+// signatures are recomputed with a slow reference implementation that shares
+// no code with golang.org/x/crypto/ed25519, and ECDSA signatures with the
+// deterministic nonces of RFC 6979, which this package also implements.
+package sigoracle // import "golang.org/x/crypto/sigoracle"
+
+import (
+	"bytes"
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"math/big"
+
+	"golang.org/x/crypto/ed25519"
+)
+
+var (
+	// ErrMismatch is returned by Check and SelfTest when a signature differs
+	// from the expected one.
+	ErrMismatch = errors.New("sigoracle: signature differs from the expected one")
+
+	errUnsupportedKey = errors.New("sigoracle: unsupported private key type")
+	errWrongKey       = errors.New("sigoracle: private key does not match the signer")
+)
+
+// checkMessage is the message signed by Check.
+var checkMessage = []byte("golang.org/x/crypto/sigoracle check message")
+
+// Check signs a fixed message with signer, and compares the signature with the
+// one that the oracle computes from priv. priv can be an ed25519.PrivateKey,
+// whose signatures are always deterministic, or an *ecdsa.PrivateKey, in
+// which case the signer must implement RFC 6979 and is asked for a
+// signature of a SHA-256 digest. If priv is nil, signer itself must be an
+// ed25519.PrivateKey or an *ECDSASigner.
+func Check(signer crypto.Signer, priv crypto.PrivateKey) error {
+	if priv == nil {
+		switch s := signer.(type) {
+		case ed25519.PrivateKey:
+			priv = s
+		case *ECDSASigner:
+			priv = s.priv
+		default:
+			return errUnsupportedKey
+		}
+	}
+
+	var got, want []byte
+	var err error
+	switch k := priv.(type) {
+	case ed25519.PrivateKey:
+		if len(k) != ed25519.PrivateKeySize {
+			return errUnsupportedKey
+		}
+		var pub []byte
+		pub, want = edSign(k.Seed(), checkMessage)
+		if p, ok := signer.Public().(ed25519.PublicKey); !ok || !bytes.Equal(p, pub) {
+			return errWrongKey
+		}
+		got, err = signer.Sign(nil, checkMessage, crypto.Hash(0))
+	case *ecdsa.PrivateKey:
+		if p, ok := signer.Public().(*ecdsa.PublicKey); !ok || p.Curve != k.Curve || p.X.Cmp(k.X) != 0 || p.Y.Cmp(k.Y) != 0 {
+			return errWrongKey
+		}
+		digest := sha256.Sum256(checkMessage)
+		if want, err = NewECDSASigner(k).Sign(nil, digest[:], crypto.SHA256); err != nil {
+			return err
+		}
+		got, err = signer.Sign(nil, digest[:], crypto.SHA256)
+	default:
+		return errUnsupportedKey
+	}
+	if err != nil {
+		return err
+	}
+	if !bytes.Equal(got, want) {
+		return ErrMismatch
+	}
+	return nil
+}
+
+// SelfTest runs known answer tests of the Ed25519 implementations of the
+// oracle and of golang.org/x/crypto/ed25519, with the first test vector of
+// RFC 8032, Section 7.1, and of the ECDSA implementation of the oracle, with
+// the P-256 and SHA-256 test vector of RFC 6979, Section A.2.5.
+func SelfTest() error {
+	seed := mustHex("9d61b19deffd5a60ba844af492ec2cc44449c5697b326919703bac031cae7f60")
+	wantSig := mustHex("e5564300c360ac729086e2cc806e828a84877f1eb8e5d974d873e065224901555fb8821590a33bacc61e39701cf9b46bd25bf5f0595bbe24655141438e7a100b")
+	if _, sig := edSign(seed, nil); !bytes.Equal(sig, wantSig) {
+		return ErrMismatch
+	}
+	if sig := ed25519.Sign(ed25519.NewKeyFromSeed(seed), nil); !bytes.Equal(sig, wantSig) {
+		return ErrMismatch
+	}
+
+	priv := &ecdsa.PrivateKey{D: mustInt("c9afa9d845ba75166b5c215767b1d6934e50c3db36e89b127b8a622b120f6721")}
+	priv.Curve = elliptic.P256()
+	priv.X, priv.Y = priv.Curve.ScalarBaseMult(priv.D.Bytes())
+	digest := sha256.Sum256([]byte("sample"))
+	r, s, err := SignECDSA(priv, crypto.SHA256, digest[:])
+	if err != nil {
+		return err
+	}
+	if r.Cmp(mustInt("efd48b2aacb6a8fd1140dd9cd45e81d69d2c877b56aaf991c34d0ea84eaf3716")) != 0 ||
+		s.Cmp(mustInt("f7cb1c942d657c41d436c7a1b6e29f65f3e900dbb9aff4064dc4ab2f843acda8")) != 0 {
+		return ErrMismatch
+	}
+	return nil
+}
+
+func mustHex(s string) []byte {
+	b, err := hex.DecodeString(s)
+	if err != nil {
+		panic(err)
+	}
+	return b
+}
+
+func mustInt(s string) *big.Int {
+	return new(big.Int).SetBytes(mustHex(s))
+}
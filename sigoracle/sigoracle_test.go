@@ -0,0 +1,94 @@
+// Copyright 2018 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package sigoracle
+
+import (
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/sha256"
+	"io"
+	"testing"
+
+	"golang.org/x/crypto/ed25519"
+)
+
+func TestSelfTest(t *testing.T) {
+	if err := SelfTest(); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestNonceVector(t *testing.T) {
+	// RFC 6979, Section A.2.5, P-256 with SHA-256 and message "sample".
+	q := elliptic.P256().Params().N
+	digest := sha256.Sum256([]byte("sample"))
+	x := mustInt("c9afa9d845ba75166b5c215767b1d6934e50c3db36e89b127b8a622b120f6721")
+	k := newNonceGenerator(x, q, crypto.SHA256, digest[:]).next()
+	if want := mustInt("a6e3c57dd01abe90086538398355dd4c3b17aa873382b0f24d6129493d8aad60"); k.Cmp(want) != 0 {
+		t.Errorf("k = %x, want %x", k, want)
+	}
+}
+
+func TestECDSASigner(t *testing.T) {
+	priv, err := ecdsa.GenerateKey(elliptic.P384(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	digest := sha256.Sum256([]byte("message"))
+	signer := NewECDSASigner(priv)
+	r, s, err := SignECDSA(priv, crypto.SHA256, digest[:])
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !ecdsa.Verify(&priv.PublicKey, digest[:], r, s) {
+		t.Errorf("signature does not verify")
+	}
+	if err := Check(signer, nil); err != nil {
+		t.Errorf("Check: %v", err)
+	}
+	// A randomized signer does not pass the check.
+	if err := Check(randomizedSigner{priv}, priv); err != ErrMismatch {
+		t.Errorf("Check of a randomized signer: got %v, want %v", err, ErrMismatch)
+	}
+}
+
+func TestCheckEd25519(t *testing.T) {
+	_, priv, err := ed25519.GenerateKey(nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := Check(priv, nil); err != nil {
+		t.Errorf("Check: %v", err)
+	}
+	if err := Check(badSigner{priv}, priv); err != ErrMismatch {
+		t.Errorf("Check of a wrong signer: got %v, want %v", err, ErrMismatch)
+	}
+	_, other, _ := ed25519.GenerateKey(nil)
+	if err := Check(priv, other); err != errWrongKey {
+		t.Errorf("Check with another key: got %v, want %v", err, errWrongKey)
+	}
+}
+
+// badSigner flips a bit of the signatures of an Ed25519 key.
+type badSigner struct {
+	ed25519.PrivateKey
+}
+
+func (b badSigner) Sign(rand io.Reader, message []byte, opts crypto.SignerOpts) ([]byte, error) {
+	sig, err := b.PrivateKey.Sign(rand, message, opts)
+	sig[0] ^= 1
+	return sig, err
+}
+
+// randomizedSigner produces randomized ECDSA signatures.
+type randomizedSigner struct {
+	*ecdsa.PrivateKey
+}
+
+func (r randomizedSigner) Sign(_ io.Reader, digest []byte, opts crypto.SignerOpts) ([]byte, error) {
+	return r.PrivateKey.Sign(rand.Reader, digest, opts)
+}
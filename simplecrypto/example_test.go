@@ -0,0 +1,41 @@
+// Copyright 2018 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package simplecrypto_test
+
+import (
+	"fmt"
+	"log"
+
+	"golang.org/x/crypto/simplecrypto"
+)
+
+func Example() {
+	key, err := simplecrypto.NewKey()
+	if err != nil {
+		log.Fatal(err)
+	}
+	ciphertext, err := simplecrypto.Encrypt(key, []byte("secret"), []byte("user 42"))
+	if err != nil {
+		log.Fatal(err)
+	}
+	plaintext, err := simplecrypto.Decrypt(key, ciphertext, []byte("user 42"))
+	if err != nil {
+		log.Fatal(err)
+	}
+	fmt.Printf("%s\n", plaintext)
+
+	signingKey, err := simplecrypto.NewSigningKey()
+	if err != nil {
+		log.Fatal(err)
+	}
+	sig := simplecrypto.Sign(signingKey, []byte("message"))
+	if err := simplecrypto.Verify(signingKey.VerifyingKey(), []byte("message"), sig); err != nil {
+		log.Fatal(err)
+	}
+	fmt.Println("signature verified")
+	// Output:
+	// secret
+	// signature verified
+}
@@ -0,0 +1,232 @@
+// Copyright 2018 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+// Package simplecrypto provides authenticated encryption and digital
+// signatures for applications that need one of them and should not have to
+// choose and combine primitives to get it.
+//
+// It has exactly four operations. Encrypt and Decrypt protect the
+// confidentiality and integrity of data with a secret Key, using
+// XChaCha20-Poly1305 with random nonces. Sign and Verify authenticate data
+// with a SigningKey and its VerifyingKey, using Ed25519. There are no
+// parameters to get wrong: nonces are generated internally, keys have their
+// own types and can only be created with the right length, and Decrypt and
+// Verify fail closed, returning an error rather than a boolean.
+//
+// Ciphertexts and signatures start with a version byte, so that the
+// algorithms can change in future versions without making existing data
+// ambiguous. Their formats are stable and can be stored.
+package simplecrypto // import "golang.org/x/crypto/simplecrypto"
+
+import (
+	cryptorand "crypto/rand"
+	"errors"
+	"io"
+
+	"golang.org/x/crypto/chacha20poly1305"
+	"golang.org/x/crypto/ed25519"
+)
+
+const (
+	// KeySize is the size, in bytes, of encryption keys.
+	KeySize = chacha20poly1305.KeySize
+	// SigningKeySize is the size, in bytes, of the encoding of signing keys.
+	SigningKeySize = ed25519.SeedSize
+	// VerifyingKeySize is the size, in bytes, of verifying keys.
+	VerifyingKeySize = ed25519.PublicKeySize
+	// SignatureSize is the size, in bytes, of signatures.
+	SignatureSize = 1 + ed25519.SignatureSize
+	// Overhead is the number of bytes by which a ciphertext is longer than
+	// its plaintext.
+	Overhead = 1 + chacha20poly1305.NonceSizeX + 16
+)
+
+// version1 is the first byte of the ciphertexts and signatures of the
+// current format.
+const version1 = 1
+
+// signatureContext is prepended to signed messages, so that signatures made
+// by this package cannot be mistaken for Ed25519 signatures made with the
+// same key for another purpose, or for those of a future format.
+const signatureContext = "golang.org/x/crypto/simplecrypto signature v1\x00"
+
+var (
+	// ErrDecrypt is returned by Decrypt if the ciphertext was not produced by
+	// Encrypt with the same key and additional data, or was modified.
+	ErrDecrypt = errors.New("simplecrypto: message authentication failed")
+	// ErrInvalidSignature is returned by Verify if the signature was not
+	// produced by Sign with the matching signing key and the same message.
+	ErrInvalidSignature = errors.New("simplecrypto: invalid signature")
+	// ErrUnsupportedVersion is returned by Decrypt and Verify for
+	// ciphertexts and signatures in a format this package does not know.
+	ErrUnsupportedVersion = errors.New("simplecrypto: unsupported format version")
+
+	errKeySize = errors.New("simplecrypto: bad key size")
+)
+
+// A Key is a secret key for Encrypt and Decrypt.
+type Key struct {
+	k [KeySize]byte
+}
+
+// NewKey returns a new random Key.
+func NewKey() (*Key, error) {
+	k := new(Key)
+	if _, err := io.ReadFull(cryptorand.Reader, k.k[:]); err != nil {
+		return nil, err
+	}
+	return k, nil
+}
+
+// KeyFromBytes returns the Key encoded by b, as returned by Key.Bytes. b must
+// come from a previous call to Bytes, not from a password or another secret
+// that is not uniformly random.
+func KeyFromBytes(b []byte) (*Key, error) {
+	if len(b) != KeySize {
+		return nil, errKeySize
+	}
+	k := new(Key)
+	copy(k.k[:], b)
+	return k, nil
+}
+
+// Bytes returns the encoding of k, which must be kept secret.
+func (k *Key) Bytes() []byte {
+	return append([]byte(nil), k.k[:]...)
+}
+
+// Encrypt encrypts and authenticates plaintext with key, and authenticates
+// additionalData, which may be nil, without encrypting it. The same
+// additionalData must be passed to Decrypt. It is typically the context of
+// the plaintext, such as the ID of the database row storing the ciphertext,
+// so that ciphertexts cannot be moved around.
+//
+// The ciphertext is Overhead bytes longer than plaintext. A key can encrypt
+// an unlimited number of messages.
+func Encrypt(key *Key, plaintext, additionalData []byte) ([]byte, error) {
+	aead, err := chacha20poly1305.NewX(key.k[:])
+	if err != nil {
+		return nil, err
+	}
+	out := make([]byte, 1+aead.NonceSize(), len(plaintext)+Overhead)
+	out[0] = version1
+	nonce := out[1:]
+	if _, err := io.ReadFull(cryptorand.Reader, nonce); err != nil {
+		return nil, err
+	}
+	return aead.Seal(out, nonce, plaintext, encryptionAD(out[0], additionalData)), nil
+}
+
+// Decrypt authenticates ciphertext and additionalData with key, and returns
+// the plaintext. It returns ErrDecrypt if either was modified, or if they
+// were not produced by Encrypt with the same key.
+func Decrypt(key *Key, ciphertext, additionalData []byte) ([]byte, error) {
+	if len(ciphertext) == 0 {
+		return nil, ErrDecrypt
+	}
+	if ciphertext[0] != version1 {
+		return nil, ErrUnsupportedVersion
+	}
+	if len(ciphertext) < Overhead {
+		return nil, ErrDecrypt
+	}
+	aead, err := chacha20poly1305.NewX(key.k[:])
+	if err != nil {
+		return nil, err
+	}
+	nonce := ciphertext[1 : 1+aead.NonceSize()]
+	plaintext, err := aead.Open(nil, nonce, ciphertext[1+aead.NonceSize():], encryptionAD(ciphertext[0], additionalData))
+	if err != nil {
+		return nil, ErrDecrypt
+	}
+	return plaintext, nil
+}
+
+// encryptionAD binds the ciphertext to its version as well as to the
+// additional data of the caller.
+func encryptionAD(version byte, additionalData []byte) []byte {
+	return append([]byte{version}, additionalData...)
+}
+
+// A SigningKey is a private key for Sign.
+type SigningKey struct {
+	priv ed25519.PrivateKey
+}
+
+// A VerifyingKey is the public key for Verify corresponding to a SigningKey.
+type VerifyingKey struct {
+	pub ed25519.PublicKey
+}
+
+// NewSigningKey returns a new random SigningKey.
+func NewSigningKey() (*SigningKey, error) {
+	_, priv, err := ed25519.GenerateKey(cryptorand.Reader)
+	if err != nil {
+		return nil, err
+	}
+	return &SigningKey{priv: priv}, nil
+}
+
+// SigningKeyFromBytes returns the SigningKey encoded by b, as returned by
+// SigningKey.Bytes.
+func SigningKeyFromBytes(b []byte) (*SigningKey, error) {
+	if len(b) != SigningKeySize {
+		return nil, errKeySize
+	}
+	return &SigningKey{priv: ed25519.NewKeyFromSeed(b)}, nil
+}
+
+// Bytes returns the encoding of k, which must be kept secret. It is the
+// 32-byte Ed25519 seed of RFC 8032.
+func (k *SigningKey) Bytes() []byte {
+	return k.priv.Seed()
+}
+
+// VerifyingKey returns the public key corresponding to k.
+func (k *SigningKey) VerifyingKey() *VerifyingKey {
+	return &VerifyingKey{pub: k.priv.Public().(ed25519.PublicKey)}
+}
+
+// VerifyingKeyFromBytes returns the VerifyingKey encoded by b, as returned by
+// VerifyingKey.Bytes.
+func VerifyingKeyFromBytes(b []byte) (*VerifyingKey, error) {
+	if len(b) != VerifyingKeySize {
+		return nil, errKeySize
+	}
+	return &VerifyingKey{pub: append(ed25519.PublicKey(nil), b...)}, nil
+}
+
+// Bytes returns the encoding of k, the 32-byte Ed25519 public key, which can
+// be published.
+func (k *VerifyingKey) Bytes() []byte {
+	return append([]byte(nil), k.pub...)
+}
+
+// Sign signs message with key and returns a signature of SignatureSize bytes.
+// Signatures are deterministic.
+func Sign(key *SigningKey, message []byte) []byte {
+	sig := make([]byte, 1, SignatureSize)
+	sig[0] = version1
+	return append(sig, ed25519.Sign(key.priv, signedMessage(message))...)
+}
+
+// Verify checks that sig is a signature of message by the SigningKey
+// corresponding to key, and returns ErrInvalidSignature if it is not.
+func Verify(key *VerifyingKey, message, sig []byte) error {
+	if len(sig) == 0 {
+		return ErrInvalidSignature
+	}
+	if sig[0] != version1 {
+		return ErrUnsupportedVersion
+	}
+	if len(sig) != SignatureSize || !ed25519.Verify(key.pub, signedMessage(message), sig[1:]) {
+		return ErrInvalidSignature
+	}
+	return nil
+}
+
+// signedMessage returns the message actually signed with Ed25519.
+func signedMessage(message []byte) []byte {
+	return append([]byte(signatureContext), message...)
+}
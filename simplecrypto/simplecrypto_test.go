@@ -0,0 +1,136 @@
+// Copyright 2018 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package simplecrypto
+
+import (
+	"bytes"
+	"testing"
+
+	"golang.org/x/crypto/ed25519"
+)
+
+func TestEncryptDecrypt(t *testing.T) {
+	key, err := NewKey()
+	if err != nil {
+		t.Fatal(err)
+	}
+	plaintext := []byte("attack at dawn")
+	ad := []byte("row 42")
+	ciphertext, err := Encrypt(key, plaintext, ad)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(ciphertext) != len(plaintext)+Overhead || ciphertext[0] != version1 {
+		t.Fatalf("bad ciphertext %x", ciphertext)
+	}
+	if got, err := Decrypt(key, ciphertext, ad); err != nil || !bytes.Equal(got, plaintext) {
+		t.Errorf("Decrypt = %q, %v", got, err)
+	}
+	if again, _ := Encrypt(key, plaintext, ad); bytes.Equal(again, ciphertext) {
+		t.Errorf("Encrypt is deterministic")
+	}
+
+	key2, err := KeyFromBytes(key.Bytes())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got, err := Decrypt(key2, ciphertext, ad); err != nil || !bytes.Equal(got, plaintext) {
+		t.Errorf("Decrypt with decoded key = %q, %v", got, err)
+	}
+	other, _ := NewKey()
+	if _, err := Decrypt(other, ciphertext, ad); err != ErrDecrypt {
+		t.Errorf("wrong key: got %v, want %v", err, ErrDecrypt)
+	}
+	if _, err := Decrypt(key, ciphertext, []byte("row 43")); err != ErrDecrypt {
+		t.Errorf("wrong additional data: got %v, want %v", err, ErrDecrypt)
+	}
+	for i := range ciphertext {
+		modified := append([]byte(nil), ciphertext...)
+		modified[i] ^= 0x80
+		if _, err := Decrypt(key, modified, ad); err == nil {
+			t.Errorf("ciphertext modified at byte %d accepted", i)
+		}
+	}
+	if _, err := Decrypt(key, ciphertext[:Overhead-1], ad); err != ErrDecrypt {
+		t.Errorf("short ciphertext: got %v, want %v", err, ErrDecrypt)
+	}
+	if _, err := Decrypt(key, nil, ad); err != ErrDecrypt {
+		t.Errorf("empty ciphertext: got %v, want %v", err, ErrDecrypt)
+	}
+	future := append([]byte{2}, ciphertext[1:]...)
+	if _, err := Decrypt(key, future, ad); err != ErrUnsupportedVersion {
+		t.Errorf("unknown version: got %v, want %v", err, ErrUnsupportedVersion)
+	}
+
+	if _, err := KeyFromBytes(make([]byte, KeySize-1)); err == nil {
+		t.Errorf("short key accepted")
+	}
+	if b := key.Bytes(); &b[0] == &key.k[0] {
+		t.Errorf("Bytes shares memory with the key")
+	}
+}
+
+func TestSignVerify(t *testing.T) {
+	key, err := NewSigningKey()
+	if err != nil {
+		t.Fatal(err)
+	}
+	pub := key.VerifyingKey()
+	message := []byte("release v1.2.3")
+	sig := Sign(key, message)
+	if len(sig) != SignatureSize || sig[0] != version1 {
+		t.Fatalf("bad signature %x", sig)
+	}
+	if err := Verify(pub, message, sig); err != nil {
+		t.Errorf("valid signature rejected: %v", err)
+	}
+
+	key2, err := SigningKeyFromBytes(key.Bytes())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(Sign(key2, message), sig) {
+		t.Errorf("decoded signing key makes different signatures")
+	}
+	pub2, err := VerifyingKeyFromBytes(pub.Bytes())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := Verify(pub2, message, sig); err != nil {
+		t.Errorf("decoded verifying key rejected the signature: %v", err)
+	}
+
+	if err := Verify(pub, []byte("release v1.2.4"), sig); err != ErrInvalidSignature {
+		t.Errorf("wrong message: got %v, want %v", err, ErrInvalidSignature)
+	}
+	other, _ := NewSigningKey()
+	if err := Verify(other.VerifyingKey(), message, sig); err != ErrInvalidSignature {
+		t.Errorf("wrong key: got %v, want %v", err, ErrInvalidSignature)
+	}
+	if err := Verify(pub, message, sig[:SignatureSize-1]); err != ErrInvalidSignature {
+		t.Errorf("short signature: got %v, want %v", err, ErrInvalidSignature)
+	}
+	if err := Verify(pub, message, nil); err != ErrInvalidSignature {
+		t.Errorf("empty signature: got %v, want %v", err, ErrInvalidSignature)
+	}
+	future := append([]byte{2}, sig[1:]...)
+	if err := Verify(pub, message, future); err != ErrUnsupportedVersion {
+		t.Errorf("unknown version: got %v, want %v", err, ErrUnsupportedVersion)
+	}
+
+	// Plain Ed25519 signatures of the message made with the same key are not
+	// accepted.
+	plain := append([]byte{version1}, ed25519.Sign(ed25519.NewKeyFromSeed(key.Bytes()), message)...)
+	if err := Verify(pub, message, plain); err != ErrInvalidSignature {
+		t.Errorf("plain Ed25519 signature: got %v, want %v", err, ErrInvalidSignature)
+	}
+
+	if _, err := SigningKeyFromBytes(make([]byte, SigningKeySize+1)); err == nil {
+		t.Errorf("long signing key accepted")
+	}
+	if _, err := VerifyingKeyFromBytes(make([]byte, VerifyingKeySize-1)); err == nil {
+		t.Errorf("short verifying key accepted")
+	}
+}
@@ -7,6 +7,7 @@ package ssh
 import (
 	"io"
 	"sync"
+	"time"
 )
 
 // buffer provides a linked list buffer for data exchange
@@ -19,7 +20,8 @@ type buffer struct {
 	head *element // the buffer that will be read first
 	tail *element // the buffer that will be read last
 
-	closed bool
+	closed   bool
+	deadline deadline
 }
 
 // An element represents a single link in a linked list.
@@ -59,8 +61,16 @@ func (b *buffer) eof() {
 	b.Cond.L.Unlock()
 }
 
+// setDeadline sets the time after which Read fails instead of blocking.
+func (b *buffer) setDeadline(t time.Time) {
+	b.Cond.L.Lock()
+	b.deadline.set(t, b.Cond)
+	b.Cond.L.Unlock()
+}
+
 // Read reads data from the internal buffer in buf.  Reads will block
-// if no data is available, or until the buffer is closed.
+// if no data is available, or until the buffer is closed or its deadline
+// passes.
 func (b *buffer) Read(buf []byte) (n int, err error) {
 	b.Cond.L.Lock()
 	defer b.Cond.L.Unlock()
@@ -90,6 +100,10 @@ func (b *buffer) Read(buf []byte) (n int, err error) {
 			err = io.EOF
 			break
 		}
+		if b.deadline.expired() {
+			err = errTimeout
+			break
+		}
 		// out of buffers, wait for producer
 		b.Cond.Wait()
 	}
@@ -0,0 +1,207 @@
+// Copyright 2018 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package ssh
+
+import (
+	"context"
+	"crypto/rand"
+	"errors"
+	"sync"
+	"time"
+)
+
+// CertRefreshRequest is the type of the global request with which a client
+// presents a renewed user certificate during a session. SSH has no way to
+// authenticate again once a connection is established, so servers that
+// expire sessions along with short-lived certificates can accept this
+// request, which proves that the client holds the private key of the new
+// certificate, to extend the session instead. Servers that do not know the
+// request reject it.
+const CertRefreshRequest = "cert-refresh@golang.org"
+
+var (
+	errNotCertificate     = errors.New("ssh: public key of signer is not a certificate")
+	errCertRefreshRefused = errors.New("ssh: server refused certificate refresh")
+	errBadCertRefresh     = errors.New("ssh: malformed certificate refresh request")
+	errCertTooShort       = errors.New("ssh: renewed certificate expires within RenewBefore")
+)
+
+// certRefreshMsg is the payload of a CertRefreshRequest.
+type certRefreshMsg struct {
+	Cert      []byte
+	Signature []byte
+}
+
+// certRefreshSignedData returns the data signed by the client to prove that
+// it holds the key of cert. It is bound to the session, and thus to the user
+// that logged in.
+func certRefreshSignedData(sessionID []byte, cert []byte) []byte {
+	return Marshal(struct {
+		SessionID []byte
+		Request   string
+		Cert      []byte
+	}{sessionID, CertRefreshRequest, cert})
+}
+
+// RefreshCertificate presents the certificate of signer to the server with a
+// CertRefreshRequest, and returns an error if the server refuses it or ctx
+// is done first. The public key of signer must be a *Certificate, as those
+// returned by NewCertSigner.
+func (c *Client) RefreshCertificate(ctx context.Context, signer Signer) error {
+	cert, ok := signer.PublicKey().(*Certificate)
+	if !ok {
+		return errNotCertificate
+	}
+	certBytes := cert.Marshal()
+	sig, err := signer.Sign(rand.Reader, certRefreshSignedData(c.SessionID(), certBytes))
+	if err != nil {
+		return err
+	}
+	payload := Marshal(certRefreshMsg{Cert: certBytes, Signature: Marshal(sig)})
+	ok, _, err = c.SendRequestContext(ctx, CertRefreshRequest, true, payload)
+	if err != nil {
+		return err
+	}
+	if !ok {
+		return errCertRefreshRefused
+	}
+	return nil
+}
+
+// VerifyCertRefresh parses a CertRefreshRequest received on conn, checks that
+// the client holds the private key of the certificate it presents, and
+// returns the certificate. It does not check the certificate itself: the
+// caller must do so, for instance with CertChecker.Authenticate, and should
+// check that the certificate is for the same key, or at least the same
+// principal, as the one used to log in. The caller must then reply to req.
+func VerifyCertRefresh(conn ConnMetadata, req *Request) (*Certificate, error) {
+	if req.Type != CertRefreshRequest {
+		return nil, errBadCertRefresh
+	}
+	var msg certRefreshMsg
+	if err := Unmarshal(req.Payload, &msg); err != nil {
+		return nil, errBadCertRefresh
+	}
+	key, err := ParsePublicKey(msg.Cert)
+	if err != nil {
+		return nil, err
+	}
+	cert, ok := key.(*Certificate)
+	if !ok {
+		return nil, errNotCertificate
+	}
+	sig, rest, ok := parseSignatureBody(msg.Signature)
+	if !ok || len(rest) > 0 {
+		return nil, errBadCertRefresh
+	}
+	if err := cert.Verify(certRefreshSignedData(conn.SessionID(), msg.Cert), sig); err != nil {
+		return nil, err
+	}
+	return cert, nil
+}
+
+// A CertRenewer provides short-lived user certificates for a private key,
+// fetching a new certificate when the current one is about to expire. Its
+// Signers method can be used with PublicKeysCallback to log in, and its
+// KeepRefreshed method to present renewed certificates during a session.
+type CertRenewer struct {
+	// Signer holds the private key of the certificates.
+	Signer Signer
+
+	// Fetch returns a new certificate for key, typically by asking a
+	// certificate authority.
+	Fetch func(key PublicKey) (*Certificate, error)
+
+	// RenewBefore is how long before its expiry a certificate is renewed.
+	RenewBefore time.Duration
+
+	// Clock is used to decide whether a certificate needs to be renewed. If
+	// nil, time.Now is used.
+	Clock func() time.Time
+
+	mu   sync.Mutex
+	cert *Certificate
+}
+
+func (r *CertRenewer) now() time.Time {
+	if r.Clock != nil {
+		return r.Clock()
+	}
+	return time.Now()
+}
+
+// renewAt returns the time at which cert must be renewed.
+func (r *CertRenewer) renewAt(cert *Certificate) time.Time {
+	if cert.ValidBefore == CertTimeInfinity {
+		return time.Unix(1<<63-1, 0)
+	}
+	return time.Unix(int64(cert.ValidBefore), 0).Add(-r.RenewBefore)
+}
+
+// Certificate returns the current certificate, after fetching a new one if
+// there is none yet or if the current one expires within RenewBefore.
+func (r *CertRenewer) Certificate() (*Certificate, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if r.cert != nil && r.now().Before(r.renewAt(r.cert)) {
+		return r.cert, nil
+	}
+	cert, err := r.Fetch(r.Signer.PublicKey())
+	if err != nil {
+		return nil, err
+	}
+	r.cert = cert
+	return cert, nil
+}
+
+// Signers returns a Signer for the current certificate, as returned by
+// Certificate. It has the signature expected by PublicKeysCallback.
+func (r *CertRenewer) Signers() ([]Signer, error) {
+	cert, err := r.Certificate()
+	if err != nil {
+		return nil, err
+	}
+	signer, err := NewCertSigner(cert, r.Signer)
+	if err != nil {
+		return nil, err
+	}
+	return []Signer{signer}, nil
+}
+
+// KeepRefreshed presents each renewed certificate to the server of c with
+// RefreshCertificate, until ctx is done or an error occurs. A certificate is
+// renewed RenewBefore its expiry, so RenewBefore must leave enough time for
+// fetching it and for the round trip to the server. KeepRefreshed returns an
+// error if a renewed certificate is already due for renewal, rather than
+// renewing continuously.
+func (r *CertRenewer) KeepRefreshed(ctx context.Context, c *Client) error {
+	cert, err := r.Certificate()
+	if err != nil {
+		return err
+	}
+	for {
+		t := time.NewTimer(r.renewAt(cert).Sub(r.now()))
+		select {
+		case <-ctx.Done():
+			t.Stop()
+			return ctx.Err()
+		case <-t.C:
+		}
+
+		if cert, err = r.Certificate(); err != nil {
+			return err
+		}
+		if !r.now().Before(r.renewAt(cert)) {
+			return errCertTooShort
+		}
+		signer, err := NewCertSigner(cert, r.Signer)
+		if err != nil {
+			return err
+		}
+		if err := c.RefreshCertificate(ctx, signer); err != nil {
+			return err
+		}
+	}
+}
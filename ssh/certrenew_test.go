@@ -0,0 +1,202 @@
+// Copyright 2018 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package ssh
+
+import (
+	"bytes"
+	"context"
+	"crypto/rand"
+	"sync"
+	"testing"
+	"time"
+)
+
+// certAuthority issues user certificates for testPublicKeys["rsa"].
+type certAuthority struct {
+	mu     sync.Mutex
+	serial uint64
+	// lifetime is the validity of the next certificates.
+	lifetime time.Duration
+}
+
+func (ca *certAuthority) fetch(key PublicKey) (*Certificate, error) {
+	ca.mu.Lock()
+	defer ca.mu.Unlock()
+	ca.serial++
+	cert := &Certificate{
+		Key:             key,
+		Serial:          ca.serial,
+		CertType:        UserCert,
+		ValidPrincipals: []string{"user"},
+		ValidBefore:     uint64(time.Now().Add(ca.lifetime).Unix()),
+	}
+	if err := cert.SignCert(rand.Reader, testSigners["ecdsa"]); err != nil {
+		return nil, err
+	}
+	return cert, nil
+}
+
+var testCertChecker = &CertChecker{
+	IsUserAuthority: func(auth PublicKey) bool {
+		return bytes.Equal(auth.Marshal(), testPublicKeys["ecdsa"].Marshal())
+	},
+}
+
+// certRefreshPair returns a client logged in with the certificates of r and
+// a channel receiving the serial numbers of the certificates presented with
+// CertRefreshRequest and accepted by the server.
+func certRefreshPair(t *testing.T, r *CertRenewer) (*Client, <-chan uint64) {
+	c1, c2, err := netPipe()
+	if err != nil {
+		t.Fatalf("netPipe: %v", err)
+	}
+	serverConfig := &ServerConfig{PublicKeyCallback: testCertChecker.Authenticate}
+	serverConfig.AddHostKey(testSigners["rsa"])
+	refreshed := make(chan uint64, 10)
+	go func() {
+		conn, chans, reqs, err := NewServerConn(c1, serverConfig)
+		if err != nil {
+			t.Errorf("NewServerConn: %v", err)
+			return
+		}
+		go func() {
+			for ch := range chans {
+				ch.Reject(Prohibited, "")
+			}
+		}()
+		for req := range reqs {
+			cert, err := VerifyCertRefresh(conn, req)
+			if err == nil {
+				_, err = testCertChecker.Authenticate(conn, cert)
+			}
+			req.Reply(err == nil, nil)
+			if err == nil {
+				refreshed <- cert.Serial
+			}
+		}
+	}()
+
+	clientConfig := &ClientConfig{
+		User:            "user",
+		Auth:            []AuthMethod{PublicKeysCallback(r.Signers)},
+		HostKeyCallback: InsecureIgnoreHostKey(),
+	}
+	conn, chans, reqs, err := NewClientConn(c2, "", clientConfig)
+	if err != nil {
+		t.Fatalf("NewClientConn: %v", err)
+	}
+	return NewClient(conn, chans, reqs), refreshed
+}
+
+func TestRefreshCertificate(t *testing.T) {
+	ca := &certAuthority{lifetime: time.Hour}
+	r := &CertRenewer{Signer: testSigners["rsa"], Fetch: ca.fetch, RenewBefore: time.Minute}
+	client, refreshed := certRefreshPair(t, r)
+	defer client.Close()
+
+	cert, err := ca.fetch(testPublicKeys["rsa"])
+	if err != nil {
+		t.Fatal(err)
+	}
+	signer, err := NewCertSigner(cert, testSigners["rsa"])
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := client.RefreshCertificate(context.Background(), signer); err != nil {
+		t.Fatalf("RefreshCertificate: %v", err)
+	}
+	if serial := <-refreshed; serial != cert.Serial {
+		t.Errorf("server accepted certificate %d, want %d", serial, cert.Serial)
+	}
+
+	// A certificate signed by another authority is refused by the server.
+	cert.SignCert(rand.Reader, testSigners["dsa"])
+	if err := client.RefreshCertificate(context.Background(), signer); err != errCertRefreshRefused {
+		t.Errorf("RefreshCertificate with an unknown authority: got %v, want %v", err, errCertRefreshRefused)
+	}
+	if err := client.RefreshCertificate(context.Background(), testSigners["rsa"]); err != errNotCertificate {
+		t.Errorf("RefreshCertificate with a plain key: got %v, want %v", err, errNotCertificate)
+	}
+}
+
+func TestVerifyCertRefreshBinding(t *testing.T) {
+	ca := &certAuthority{lifetime: time.Hour}
+	cert, _ := ca.fetch(testPublicKeys["rsa"])
+	certBytes := cert.Marshal()
+	sig, err := testSigners["rsa"].Sign(rand.Reader, certRefreshSignedData([]byte("session"), certBytes))
+	if err != nil {
+		t.Fatal(err)
+	}
+	req := &Request{
+		Type:    CertRefreshRequest,
+		Payload: Marshal(certRefreshMsg{Cert: certBytes, Signature: Marshal(sig)}),
+	}
+
+	if _, err := VerifyCertRefresh(&sshConn{user: "user", sessionID: []byte("session")}, req); err != nil {
+		t.Errorf("VerifyCertRefresh: %v", err)
+	}
+	// The signature is bound to the session.
+	if _, err := VerifyCertRefresh(&sshConn{user: "user", sessionID: []byte("other")}, req); err == nil {
+		t.Error("request replayed in another session was accepted")
+	}
+	req.Payload = req.Payload[:len(req.Payload)-1]
+	if _, err := VerifyCertRefresh(&sshConn{user: "user", sessionID: []byte("session")}, req); err == nil {
+		t.Error("truncated request was accepted")
+	}
+}
+
+func TestCertRenewerCertificate(t *testing.T) {
+	ca := &certAuthority{lifetime: time.Hour}
+	now := time.Now()
+	r := &CertRenewer{
+		Signer:      testSigners["rsa"],
+		Fetch:       ca.fetch,
+		RenewBefore: 10 * time.Minute,
+		Clock:       func() time.Time { return now },
+	}
+	first, err := r.Certificate()
+	if err != nil {
+		t.Fatal(err)
+	}
+	now = now.Add(40 * time.Minute)
+	if cert, _ := r.Certificate(); cert != first {
+		t.Errorf("certificate renewed too early")
+	}
+	now = now.Add(15 * time.Minute)
+	if cert, _ := r.Certificate(); cert == first {
+		t.Errorf("certificate not renewed before expiry")
+	}
+}
+
+func TestCertRenewerKeepRefreshed(t *testing.T) {
+	// The first certificate is due for renewal within a second, the next
+	// ones last an hour.
+	ca := &certAuthority{lifetime: 2 * time.Second}
+	r := &CertRenewer{Signer: testSigners["rsa"], Fetch: ca.fetch, RenewBefore: time.Second}
+	client, refreshed := certRefreshPair(t, r)
+	defer client.Close()
+	ca.mu.Lock()
+	ca.lifetime = time.Hour
+	ca.mu.Unlock()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	errc := make(chan error, 1)
+	go func() { errc <- r.KeepRefreshed(ctx, client) }()
+
+	select {
+	case serial := <-refreshed:
+		if serial != 2 {
+			t.Errorf("server accepted certificate %d, want 2", serial)
+		}
+	case err := <-errc:
+		t.Fatalf("KeepRefreshed: %v", err)
+	case <-time.After(5 * time.Second):
+		t.Fatal("certificate was not refreshed")
+	}
+	cancel()
+	if err := <-errc; err != context.Canceled {
+		t.Errorf("KeepRefreshed returned %v, want %v", err, context.Canceled)
+	}
+}
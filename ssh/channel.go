@@ -5,12 +5,14 @@
 package ssh
 
 import (
+	"context"
 	"encoding/binary"
 	"errors"
 	"fmt"
 	"io"
 	"log"
 	"sync"
+	"time"
 )
 
 const (
@@ -71,6 +73,25 @@ type Channel interface {
 	// is returned.
 	SendRequest(name string, wantReply bool, payload []byte) (bool, error)
 
+	// SendRequestContext is like SendRequest, but stops waiting for
+	// the reply and returns ctx.Err() when ctx is done. The reply is
+	// then discarded when it arrives.
+	SendRequestContext(ctx context.Context, name string, wantReply bool, payload []byte) (bool, error)
+
+	// SetReadDeadline sets the deadline for pending and future
+	// calls to Read, and to Read on the Stderr stream. Reads that
+	// would block after the deadline return an error that
+	// implements net.Error and whose Timeout method returns true.
+	// A zero value for t means reads do not time out.
+	SetReadDeadline(t time.Time) error
+
+	// SetWriteDeadline sets the deadline for pending and future
+	// calls to Write, and to Write on the Stderr stream, like
+	// SetReadDeadline. It bounds the time spent waiting for the
+	// peer to open its flow-control window, but not for the
+	// underlying network connection, which needs its own deadline.
+	SetWriteDeadline(t time.Time) error
+
 	// Stderr returns an io.ReadWriter that writes to this channel
 	// with the extended data type set to stderr. Stderr may
 	// safely be read and written from a different goroutine than
@@ -176,7 +197,7 @@ type channel struct {
 	// Since requests have no ID, there can be only one request
 	// with WantReply=true outstanding.  This lock is held by a
 	// goroutine that has such an outgoing request pending.
-	sentRequestMu sync.Mutex
+	sentRequestMu requestLock
 
 	incomingRequests chan *Request
 
@@ -467,6 +488,7 @@ func (m *mux) newChannel(chanType string, direction channelDirection, extraData
 		extraData:        extraData,
 		mux:              m,
 		packetPool:       make(map[uint32][]byte),
+		sentRequestMu:    make(requestLock, 1),
 	}
 	ch.localId = m.chanList.add(ch)
 	return ch
@@ -566,14 +588,36 @@ func (ch *channel) Stderr() io.ReadWriter {
 	return ch.Extended(1)
 }
 
+func (ch *channel) SetReadDeadline(t time.Time) error {
+	if !ch.decided {
+		return errUndecided
+	}
+	ch.pending.setDeadline(t)
+	ch.extPending.setDeadline(t)
+	return nil
+}
+
+func (ch *channel) SetWriteDeadline(t time.Time) error {
+	if !ch.decided {
+		return errUndecided
+	}
+	ch.remoteWin.setDeadline(t)
+	return nil
+}
+
 func (ch *channel) SendRequest(name string, wantReply bool, payload []byte) (bool, error) {
+	return ch.SendRequestContext(context.Background(), name, wantReply, payload)
+}
+
+func (ch *channel) SendRequestContext(ctx context.Context, name string, wantReply bool, payload []byte) (bool, error) {
 	if !ch.decided {
 		return false, errUndecided
 	}
 
 	if wantReply {
-		ch.sentRequestMu.Lock()
-		defer ch.sentRequestMu.Unlock()
+		if err := ch.sentRequestMu.lock(ctx); err != nil {
+			return false, err
+		}
 	}
 
 	msg := channelRequestMsg{
@@ -584,11 +628,27 @@ func (ch *channel) SendRequest(name string, wantReply bool, payload []byte) (boo
 	}
 
 	if err := ch.sendMessage(msg); err != nil {
+		if wantReply {
+			ch.sentRequestMu.unlock()
+		}
 		return false, err
 	}
 
 	if wantReply {
-		m, ok := (<-ch.msg)
+		var m interface{}
+		var ok bool
+		select {
+		case m, ok = <-ch.msg:
+			ch.sentRequestMu.unlock()
+		case <-ctx.Done():
+			// The reply must still be consumed before the next
+			// request can be sent.
+			go func() {
+				<-ch.msg
+				ch.sentRequestMu.unlock()
+			}()
+			return false, ctx.Err()
+		}
 		if !ok {
 			return false, io.EOF
 		}
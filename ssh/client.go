@@ -6,6 +6,7 @@ package ssh
 
 import (
 	"bytes"
+	"context"
 	"errors"
 	"fmt"
 	"net"
@@ -127,7 +128,13 @@ func verifyHostKeySignature(hostKey PublicKey, result *kexResult) error {
 // NewSession opens a new Session for this client. (A session is a remote
 // execution of a program.)
 func (c *Client) NewSession() (*Session, error) {
-	ch, in, err := c.OpenChannel("session", nil)
+	return c.NewSessionContext(context.Background())
+}
+
+// NewSessionContext is like NewSession, but stops waiting for the server and
+// returns ctx.Err() when ctx is done.
+func (c *Client) NewSessionContext(ctx context.Context) (*Session, error) {
+	ch, in, err := c.OpenChannelContext(ctx, "session", nil)
 	if err != nil {
 		return nil, err
 	}
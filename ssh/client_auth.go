@@ -324,6 +324,95 @@ func PublicKeysCallback(getSigners func() (signers []Signer, err error)) AuthMet
 	return publicKeyCallback(getSigners)
 }
 
+type hostbasedAuthMsg struct {
+	User       string `sshtype:"50"`
+	Service    string
+	Method     string
+	Algoname   string
+	HostKey    []byte
+	ClientHost string
+	ClientUser string
+	// Sig is tagged with "rest" so that it can be wrapped in a string
+	// by hand, as in publickeyAuthMsg.
+	Sig []byte `ssh:"rest"`
+}
+
+// hostbasedAuth is an AuthMethod that authenticates the user with the
+// keys of the host the client runs on.
+type hostbasedAuth struct {
+	clientHost string
+	clientUser string
+	signers    []Signer
+}
+
+func (h *hostbasedAuth) method() string {
+	return "hostbased"
+}
+
+func (h *hostbasedAuth) auth(session []byte, user string, c packetConn, rand io.Reader) (authResult, []string, error) {
+	// Unlike publickey, hostbased authentication has no query to test if a
+	// key is acceptable, so each host key is tried with a signed request
+	// until one succeeds.
+	var methods []string
+	for _, signer := range h.signers {
+		pub := signer.PublicKey()
+		hostKey := pub.Marshal()
+		sign, err := signer.Sign(rand, buildDataSignedForHostbased(session, userAuthRequestMsg{
+			User:    user,
+			Service: serviceSSH,
+			Method:  h.method(),
+		}, []byte(pub.Type()), hostKey, h.clientHost, h.clientUser))
+		if err != nil {
+			return authFailure, nil, err
+		}
+
+		// manually wrap the serialized signature in a string
+		s := Marshal(sign)
+		sig := make([]byte, stringLength(len(s)))
+		marshalString(sig, s)
+		msg := hostbasedAuthMsg{
+			User:       user,
+			Service:    serviceSSH,
+			Method:     h.method(),
+			Algoname:   pub.Type(),
+			HostKey:    hostKey,
+			ClientHost: h.clientHost,
+			ClientUser: h.clientUser,
+			Sig:        sig,
+		}
+		if err := c.writePacket(Marshal(&msg)); err != nil {
+			return authFailure, nil, err
+		}
+		var success authResult
+		success, methods, err = handleAuthResponse(c)
+		if err != nil {
+			return authFailure, nil, err
+		}
+		if success == authSuccess || !containsMethod(methods, h.method()) {
+			return success, methods, err
+		}
+	}
+
+	return authFailure, methods, nil
+}
+
+// Hostbased returns an AuthMethod that uses hostbased authentication, as
+// described in RFC 4252, section 9. The client asserts that the remote user
+// is clientUser on the machine clientHost, and proves that it runs on that
+// machine by signing the request with one of its host keys, which are tried
+// in order. clientHost should be the fully qualified domain name of the
+// machine; OpenSSH servers expect it with a trailing dot.
+//
+// Host keys are usually only readable by root. Signers that delegate to a
+// privileged helper, such as OpenSSH's ssh-keysign, can be used instead.
+func Hostbased(clientHost, clientUser string, hostKeys ...Signer) AuthMethod {
+	return &hostbasedAuth{
+		clientHost: clientHost,
+		clientUser: clientUser,
+		signers:    hostKeys,
+	}
+}
+
 // handleAuthResponse returns whether the preceding authentication request succeeded
 // along with a list of remaining authentication methods to try next and
 // an error if an unexpected response was received.
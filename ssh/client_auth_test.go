@@ -626,3 +626,86 @@ func TestClientAuthErrorList(t *testing.T) {
 		}
 	}
 }
+
+func tryHostbasedAuth(t *testing.T, auth AuthMethod) error {
+	c1, c2, err := netPipe()
+	if err != nil {
+		t.Fatalf("netPipe: %v", err)
+	}
+	defer c1.Close()
+	defer c2.Close()
+
+	serverConfig := &ServerConfig{
+		HostbasedCallback: func(conn ConnMetadata, clientHost, clientUser string, hostKey PublicKey) (*Permissions, error) {
+			if clientHost != "client.example.com" {
+				return nil, fmt.Errorf("unknown host %q", clientHost)
+			}
+			if !bytes.Equal(hostKey.Marshal(), testPublicKeys["ecdsa"].Marshal()) {
+				return nil, errors.New("wrong host key")
+			}
+			if conn.User() != "testuser" || clientUser != "alice" {
+				return nil, fmt.Errorf("%q may not log in as %q", clientUser, conn.User())
+			}
+			return &Permissions{Extensions: map[string]string{"client-user": clientUser}}, nil
+		},
+	}
+	serverConfig.AddHostKey(testSigners["rsa"])
+
+	clientConfig := &ClientConfig{
+		User:            "testuser",
+		Auth:            []AuthMethod{auth},
+		HostKeyCallback: InsecureIgnoreHostKey(),
+	}
+	go NewClientConn(c2, "", clientConfig)
+	serverConn, err := newServer(c1, serverConfig)
+	if err != nil {
+		return err
+	}
+	if got := serverConn.Permissions.Extensions["client-user"]; got != "alice" {
+		t.Errorf("server: got client user %q, want %q", got, "alice")
+	}
+	return nil
+}
+
+func TestClientAuthHostbased(t *testing.T) {
+	// The first host key is not accepted, and the trailing dot of the host
+	// name is removed by the server.
+	auth := Hostbased("client.example.com.", "alice", testSigners["rsa"], testSigners["ecdsa"])
+	if err := tryHostbasedAuth(t, auth); err != nil {
+		t.Fatalf("hostbased auth failed: %v", err)
+	}
+
+	for _, auth := range []AuthMethod{
+		Hostbased("client.example.com", "mallory", testSigners["ecdsa"]),
+		Hostbased("other.example.com", "alice", testSigners["ecdsa"]),
+		Hostbased("client.example.com", "alice", testSigners["rsa"], testSigners["dsa"]),
+	} {
+		if err := tryHostbasedAuth(t, auth); err == nil {
+			t.Errorf("hostbased auth with %+v succeeded", auth)
+		}
+	}
+}
+
+func TestHostbasedSignedData(t *testing.T) {
+	// The signed data is the request, with the session identifier instead of
+	// the signature, as described in RFC 4252, section 9.
+	session := []byte("session")
+	req := userAuthRequestMsg{User: "user", Service: serviceSSH, Method: "hostbased"}
+	hostKey := testPublicKeys["ecdsa"].Marshal()
+	got := buildDataSignedForHostbased(session, req, []byte(KeyAlgoECDSA256), hostKey, "host.", "alice")
+
+	msg := hostbasedAuthMsg{
+		User:       "user",
+		Service:    serviceSSH,
+		Method:     "hostbased",
+		Algoname:   KeyAlgoECDSA256,
+		HostKey:    hostKey,
+		ClientHost: "host.",
+		ClientUser: "alice",
+	}
+	want := appendString(nil, string(session))
+	want = append(want, Marshal(&msg)...)
+	if !bytes.Equal(got, want) {
+		t.Errorf("signed data:\ngot  %x\nwant %x", got, want)
+	}
+}
@@ -11,6 +11,7 @@ import (
 	"io"
 	"math"
 	"sync"
+	"time"
 
 	_ "crypto/sha1"
 	_ "crypto/sha256"
@@ -275,6 +276,34 @@ func buildDataSignedForAuth(sessionID []byte, req userAuthRequestMsg, algo, pubK
 	return Marshal(data)
 }
 
+// buildDataSignedForHostbased returns the data that is signed in order to
+// prove possession of a client host key, as described in RFC 4252,
+// section 9.
+func buildDataSignedForHostbased(sessionID []byte, req userAuthRequestMsg, algo, hostKey []byte, clientHost, clientUser string) []byte {
+	data := struct {
+		Session    []byte
+		Type       byte
+		User       string
+		Service    string
+		Method     string
+		Algo       []byte
+		HostKey    []byte
+		ClientHost string
+		ClientUser string
+	}{
+		sessionID,
+		msgUserAuthRequest,
+		req.User,
+		req.Service,
+		req.Method,
+		algo,
+		hostKey,
+		clientHost,
+		clientUser,
+	}
+	return Marshal(data)
+}
+
 func appendU16(buf []byte, n uint16) []byte {
 	return append(buf, byte(n>>8), byte(n))
 }
@@ -310,6 +339,47 @@ func appendBool(buf []byte, b bool) []byte {
 // value for sync.Cond.
 func newCond() *sync.Cond { return sync.NewCond(new(sync.Mutex)) }
 
+// errTimeout is returned by channel reads and writes whose deadline has
+// passed.
+var errTimeout error = timeoutError{}
+
+type timeoutError struct{}
+
+func (timeoutError) Error() string   { return "ssh: i/o timeout" }
+func (timeoutError) Timeout() bool   { return true }
+func (timeoutError) Temporary() bool { return true }
+
+// deadline holds the deadline of the waiters of a sync.Cond, and wakes them
+// up when it passes. Its methods must be called with the lock of the
+// sync.Cond held.
+type deadline struct {
+	t     time.Time
+	timer *time.Timer
+}
+
+// set sets the deadline to t, or removes it if t is zero.
+func (d *deadline) set(t time.Time, cond *sync.Cond) {
+	if d.timer != nil {
+		d.timer.Stop()
+		d.timer = nil
+	}
+	d.t = t
+	// Waiters must check the new deadline, which may have passed already.
+	cond.Broadcast()
+	if dur := time.Until(t); !t.IsZero() && dur > 0 {
+		d.timer = time.AfterFunc(dur, func() {
+			cond.L.Lock()
+			cond.Broadcast()
+			cond.L.Unlock()
+		})
+	}
+}
+
+// expired reports whether the deadline has passed.
+func (d *deadline) expired() bool {
+	return !d.t.IsZero() && !time.Now().Before(d.t)
+}
+
 // window represents the buffer available to clients
 // wishing to write to a channel.
 type window struct {
@@ -317,6 +387,7 @@ type window struct {
 	win          uint32 // RFC 4254 5.2 says the window size can grow to 2^32-1
 	writeWaiters int
 	closed       bool
+	deadline     deadline
 }
 
 // add adds win to the amount of window available
@@ -358,6 +429,11 @@ func (w *window) reserve(win uint32) (uint32, error) {
 	w.writeWaiters++
 	w.Broadcast()
 	for w.win == 0 && !w.closed {
+		if w.deadline.expired() {
+			w.writeWaiters--
+			w.L.Unlock()
+			return 0, errTimeout
+		}
 		w.Wait()
 	}
 	w.writeWaiters--
@@ -372,6 +448,13 @@ func (w *window) reserve(win uint32) (uint32, error) {
 	return win, err
 }
 
+// setDeadline sets the time after which reserve fails instead of blocking.
+func (w *window) setDeadline(t time.Time) {
+	w.L.Lock()
+	w.deadline.set(t, w.Cond)
+	w.L.Unlock()
+}
+
 // waitWriterBlocked waits until some goroutine is blocked for further
 // writes. It is used in tests only.
 func (w *window) waitWriterBlocked() {
@@ -5,6 +5,7 @@
 package ssh
 
 import (
+	"context"
 	"fmt"
 	"net"
 )
@@ -55,6 +56,11 @@ type Conn interface {
 	// and payload. See also RFC4254, section 4.
 	SendRequest(name string, wantReply bool, payload []byte) (bool, []byte, error)
 
+	// SendRequestContext is like SendRequest, but stops waiting for
+	// the reply and returns ctx.Err() when ctx is done. The reply is
+	// then discarded when it arrives.
+	SendRequestContext(ctx context.Context, name string, wantReply bool, payload []byte) (bool, []byte, error)
+
 	// OpenChannel tries to open an channel. If the request is
 	// rejected, it returns *OpenChannelError. On success it returns
 	// the SSH Channel and a Go channel for incoming, out-of-band
@@ -62,6 +68,11 @@ type Conn interface {
 	// connection will hang.
 	OpenChannel(name string, data []byte) (Channel, <-chan *Request, error)
 
+	// OpenChannelContext is like OpenChannel, but stops waiting for
+	// the peer and returns ctx.Err() when ctx is done. If the peer
+	// accepts the channel afterwards, it is closed.
+	OpenChannelContext(ctx context.Context, name string, data []byte) (Channel, <-chan *Request, error)
+
 	// Close closes the underlying network connection
 	Close() error
 
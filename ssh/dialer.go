@@ -0,0 +1,245 @@
+// Copyright 2018 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package ssh
+
+import (
+	"context"
+	"net"
+	"time"
+
+	"golang.org/x/crypto/proxy"
+)
+
+// defaultFallbackDelay is the Connection Attempt Delay recommended by RFC
+// 8305, section 5.
+const defaultFallbackDelay = 250 * time.Millisecond
+
+// A Dialer connects to SSH servers whose names resolve to several addresses.
+//
+// Connection attempts are made in the style of RFC 8305 ("Happy Eyeballs"):
+// the addresses are ordered alternating between IPv6 and IPv4, and each
+// attempt is started either when the previous one fails or when
+// FallbackDelay has passed without it succeeding. The first connection to be
+// established is used for the SSH handshake, and the other attempts are
+// abandoned. This avoids long hangs when one address family is broken on the
+// path to the server.
+//
+// The zero value is a usable Dialer.
+type Dialer struct {
+	// AttemptTimeout is the maximum amount of time for a single connection
+	// attempt. Once it expires, the attempt fails and the next address is
+	// tried. A zero AttemptTimeout means attempts are only bounded by the
+	// overall deadline.
+	AttemptTimeout time.Duration
+
+	// FallbackDelay is how long to wait for a pending attempt before
+	// starting the next one in parallel. If zero, a default of 250ms is
+	// used.
+	FallbackDelay time.Duration
+
+	// Resolver is used to look up the addresses of the server. If nil,
+	// net.DefaultResolver is used.
+	Resolver *net.Resolver
+
+	// Proxy, if not nil, makes the connection to the server instead, for
+	// example through an HTTP CONNECT or SOCKS5 proxy of package
+	// golang.org/x/crypto/proxy. The address of the server is then passed to
+	// it unresolved, and the fields above are ignored.
+	Proxy proxy.ContextDialer
+
+	// lookupIPAddr and dialContext, if not nil, replace name resolution and
+	// single connection attempts, for testing.
+	lookupIPAddr func(ctx context.Context, host string) ([]net.IPAddr, error)
+	dialContext  func(ctx context.Context, network, address string) (net.Conn, error)
+}
+
+// Dial is like DialContext with a background context.
+func (d *Dialer) Dial(network, addr string, config *ClientConfig) (*Client, error) {
+	return d.DialContext(context.Background(), network, addr, config)
+}
+
+// DialContext starts a client connection to the given SSH server, like the
+// package-level Dial function, but racing connection attempts over all the
+// addresses of the server. network must be "tcp", "tcp4" or "tcp6"; other
+// networks are dialed directly.
+//
+// config.Timeout, if not zero, bounds the time taken by all the connection
+// attempts together. The deadline of ctx, if any, also applies to the SSH
+// handshake.
+func (d *Dialer) DialContext(ctx context.Context, network, addr string, config *ClientConfig) (*Client, error) {
+	dialCtx := ctx
+	if config.Timeout > 0 {
+		var cancel context.CancelFunc
+		dialCtx, cancel = context.WithTimeout(ctx, config.Timeout)
+		defer cancel()
+	}
+	conn, err := d.dialParallel(dialCtx, network, addr)
+	if err != nil {
+		return nil, err
+	}
+
+	if deadline, ok := ctx.Deadline(); ok {
+		conn.SetDeadline(deadline)
+	}
+	c, chans, reqs, err := NewClientConn(conn, addr, config)
+	if err != nil {
+		conn.Close()
+		return nil, err
+	}
+	conn.SetDeadline(time.Time{})
+	return NewClient(c, chans, reqs), nil
+}
+
+func (d *Dialer) fallbackDelay() time.Duration {
+	if d.FallbackDelay > 0 {
+		return d.FallbackDelay
+	}
+	return defaultFallbackDelay
+}
+
+func (d *Dialer) dial(ctx context.Context, network, address string) (net.Conn, error) {
+	if d.dialContext != nil {
+		return d.dialContext(ctx, network, address)
+	}
+	var nd net.Dialer
+	return nd.DialContext(ctx, network, address)
+}
+
+// resolve returns the addresses of host usable with network, in the order in
+// which they should be tried.
+func (d *Dialer) resolve(ctx context.Context, network, host string) ([]net.IP, error) {
+	var addrs []net.IPAddr
+	if ip := net.ParseIP(host); ip != nil {
+		addrs = []net.IPAddr{{IP: ip}}
+	} else {
+		lookup := d.lookupIPAddr
+		if lookup == nil {
+			r := d.Resolver
+			if r == nil {
+				r = net.DefaultResolver
+			}
+			lookup = r.LookupIPAddr
+		}
+		var err error
+		if addrs, err = lookup(ctx, host); err != nil {
+			return nil, err
+		}
+	}
+
+	var ip6, ip4 []net.IP
+	for _, a := range addrs {
+		if a.IP.To4() != nil {
+			if network != "tcp6" {
+				ip4 = append(ip4, a.IP)
+			}
+		} else if network != "tcp4" {
+			ip6 = append(ip6, a.IP)
+		}
+	}
+	if len(ip6)+len(ip4) == 0 {
+		return nil, &net.AddrError{Err: "no suitable address found", Addr: host}
+	}
+
+	// RFC 8305, section 4: interleave the address families, starting with
+	// IPv6.
+	ips := make([]net.IP, 0, len(ip6)+len(ip4))
+	for i := 0; i < len(ip6) || i < len(ip4); i++ {
+		if i < len(ip6) {
+			ips = append(ips, ip6[i])
+		}
+		if i < len(ip4) {
+			ips = append(ips, ip4[i])
+		}
+	}
+	return ips, nil
+}
+
+type dialResult struct {
+	conn net.Conn
+	err  error
+}
+
+// dialParallel connects to addr, racing attempts over its addresses. It
+// returns the first connection established, or the error of the first failed
+// attempt if all of them fail.
+func (d *Dialer) dialParallel(ctx context.Context, network, addr string) (net.Conn, error) {
+	if d.Proxy != nil {
+		return d.Proxy.DialContext(ctx, network, addr)
+	}
+	switch network {
+	case "tcp", "tcp4", "tcp6":
+	default:
+		return d.dial(ctx, network, addr)
+	}
+
+	host, port, err := net.SplitHostPort(addr)
+	if err != nil {
+		return nil, err
+	}
+	ips, err := d.resolve(ctx, network, host)
+	if err != nil {
+		return nil, err
+	}
+
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	// The channel is large enough for every attempt, so that abandoned ones
+	// never block.
+	results := make(chan dialResult, len(ips))
+	next, pending := 0, 0
+	var fallback <-chan time.Time
+	startNext := func() {
+		address := net.JoinHostPort(ips[next].String(), port)
+		next++
+		pending++
+		go func() {
+			attemptCtx := ctx
+			if d.AttemptTimeout > 0 {
+				var cancel context.CancelFunc
+				attemptCtx, cancel = context.WithTimeout(ctx, d.AttemptTimeout)
+				defer cancel()
+			}
+			conn, err := d.dial(attemptCtx, network, address)
+			results <- dialResult{conn, err}
+		}()
+		fallback = nil
+		if next < len(ips) {
+			fallback = time.After(d.fallbackDelay())
+		}
+	}
+
+	startNext()
+	var firstErr error
+	for pending > 0 {
+		select {
+		case r := <-results:
+			pending--
+			if r.err == nil {
+				go closeAbandoned(results, pending)
+				return r.conn, nil
+			}
+			if firstErr == nil {
+				firstErr = r.err
+			}
+			if next < len(ips) {
+				startNext()
+			}
+		case <-fallback:
+			startNext()
+		}
+	}
+	return nil, firstErr
+}
+
+// closeAbandoned waits for the n attempts still pending after a connection
+// was established, and closes any connection they produce.
+func closeAbandoned(results <-chan dialResult, n int) {
+	for i := 0; i < n; i++ {
+		if r := <-results; r.conn != nil {
+			r.conn.Close()
+		}
+	}
+}
@@ -0,0 +1,217 @@
+// Copyright 2018 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package ssh
+
+import (
+	"context"
+	"errors"
+	"net"
+	"reflect"
+	"sync"
+	"testing"
+	"time"
+)
+
+func fakeLookup(addrs ...string) func(context.Context, string) ([]net.IPAddr, error) {
+	return func(context.Context, string) ([]net.IPAddr, error) {
+		var out []net.IPAddr
+		for _, a := range addrs {
+			out = append(out, net.IPAddr{IP: net.ParseIP(a)})
+		}
+		return out, nil
+	}
+}
+
+func TestDialerResolveOrder(t *testing.T) {
+	d := &Dialer{lookupIPAddr: fakeLookup("192.0.2.1", "192.0.2.2", "2001:db8::1", "192.0.2.3")}
+	tests := []struct {
+		network string
+		want    []string
+	}{
+		{"tcp", []string{"2001:db8::1", "192.0.2.1", "192.0.2.2", "192.0.2.3"}},
+		{"tcp4", []string{"192.0.2.1", "192.0.2.2", "192.0.2.3"}},
+		{"tcp6", []string{"2001:db8::1"}},
+	}
+	for _, tt := range tests {
+		ips, err := d.resolve(context.Background(), tt.network, "example.com")
+		if err != nil {
+			t.Fatal(err)
+		}
+		var got []string
+		for _, ip := range ips {
+			got = append(got, ip.String())
+		}
+		if !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("%s: got %v, want %v", tt.network, got, tt.want)
+		}
+	}
+
+	d.lookupIPAddr = fakeLookup("192.0.2.1")
+	if _, err := d.resolve(context.Background(), "tcp6", "example.com"); err == nil {
+		t.Error("resolve succeeded without any IPv6 address")
+	}
+	if ips, err := d.resolve(context.Background(), "tcp", "::1"); err != nil || len(ips) != 1 || !ips[0].Equal(net.IPv6loopback) {
+		t.Errorf("resolve of an IP literal = %v, %v", ips, err)
+	}
+}
+
+// trackedConn records whether it was closed.
+type trackedConn struct {
+	net.Conn
+	mu     sync.Mutex
+	closed bool
+}
+
+func (c *trackedConn) Close() error {
+	c.mu.Lock()
+	c.closed = true
+	c.mu.Unlock()
+	return c.Conn.Close()
+}
+
+func (c *trackedConn) isClosed() bool {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.closed
+}
+
+func TestDialerFallback(t *testing.T) {
+	var attempts []string
+	var mu sync.Mutex
+	d := &Dialer{
+		FallbackDelay: 10 * time.Millisecond,
+		lookupIPAddr:  fakeLookup("2001:db8::1", "192.0.2.1"),
+		dialContext: func(ctx context.Context, network, address string) (net.Conn, error) {
+			mu.Lock()
+			attempts = append(attempts, address)
+			mu.Unlock()
+			if address == "[2001:db8::1]:22" {
+				// A black-holed IPv6 path.
+				<-ctx.Done()
+				return nil, ctx.Err()
+			}
+			c, _ := net.Pipe()
+			return c, nil
+		},
+	}
+
+	start := time.Now()
+	conn, err := d.dialParallel(context.Background(), "tcp", "example.com:22")
+	if err != nil {
+		t.Fatal(err)
+	}
+	conn.Close()
+	if elapsed := time.Since(start); elapsed > 5*time.Second {
+		t.Errorf("dial took %v", elapsed)
+	}
+	mu.Lock()
+	defer mu.Unlock()
+	if want := []string{"[2001:db8::1]:22", "192.0.2.1:22"}; !reflect.DeepEqual(attempts, want) {
+		t.Errorf("attempts = %v, want %v", attempts, want)
+	}
+}
+
+func TestDialerAttemptTimeout(t *testing.T) {
+	d := &Dialer{
+		// Attempts must be started by the failure of the previous one.
+		FallbackDelay:  time.Hour,
+		AttemptTimeout: 10 * time.Millisecond,
+		lookupIPAddr:   fakeLookup("2001:db8::1", "192.0.2.1"),
+		dialContext: func(ctx context.Context, network, address string) (net.Conn, error) {
+			if address == "[2001:db8::1]:22" {
+				<-ctx.Done()
+				return nil, ctx.Err()
+			}
+			c, _ := net.Pipe()
+			return c, nil
+		},
+	}
+	conn, err := d.dialParallel(context.Background(), "tcp", "example.com:22")
+	if err != nil {
+		t.Fatal(err)
+	}
+	conn.Close()
+}
+
+func TestDialerAllFail(t *testing.T) {
+	errFirst := errors.New("first")
+	d := &Dialer{
+		lookupIPAddr: fakeLookup("2001:db8::1", "192.0.2.1", "192.0.2.2"),
+		dialContext: func(ctx context.Context, network, address string) (net.Conn, error) {
+			if address == "[2001:db8::1]:22" {
+				return nil, errFirst
+			}
+			time.Sleep(time.Millisecond)
+			return nil, errors.New("later")
+		},
+	}
+	if _, err := d.dialParallel(context.Background(), "tcp", "example.com:22"); err != errFirst {
+		t.Errorf("got error %v, want %v", err, errFirst)
+	}
+}
+
+func TestDialerClosesAbandoned(t *testing.T) {
+	late := make(chan *trackedConn, 1)
+	release := make(chan struct{})
+	d := &Dialer{
+		FallbackDelay: time.Millisecond,
+		lookupIPAddr:  fakeLookup("2001:db8::1", "192.0.2.1"),
+		dialContext: func(ctx context.Context, network, address string) (net.Conn, error) {
+			c, _ := net.Pipe()
+			if address == "[2001:db8::1]:22" {
+				// Succeeds, but only after the other attempt won.
+				<-release
+				tc := &trackedConn{Conn: c}
+				late <- tc
+				return tc, nil
+			}
+			return c, nil
+		},
+	}
+	conn, err := d.dialParallel(context.Background(), "tcp", "example.com:22")
+	if err != nil {
+		t.Fatal(err)
+	}
+	conn.Close()
+	close(release)
+
+	tc := <-late
+	deadline := time.Now().Add(5 * time.Second)
+	for !tc.isClosed() {
+		if time.Now().After(deadline) {
+			t.Fatal("abandoned connection was not closed")
+		}
+		time.Sleep(time.Millisecond)
+	}
+}
+
+type fakeProxy struct {
+	addrs []string
+}
+
+func (p *fakeProxy) DialContext(ctx context.Context, network, addr string) (net.Conn, error) {
+	p.addrs = append(p.addrs, network+" "+addr)
+	c, _ := net.Pipe()
+	return c, nil
+}
+
+func TestDialerProxy(t *testing.T) {
+	p := new(fakeProxy)
+	d := &Dialer{
+		Proxy: p,
+		lookupIPAddr: func(context.Context, string) ([]net.IPAddr, error) {
+			t.Error("server name resolved locally")
+			return nil, errors.New("unexpected lookup")
+		},
+	}
+	conn, err := d.dialParallel(context.Background(), "tcp", "example.com:22")
+	if err != nil {
+		t.Fatal(err)
+	}
+	conn.Close()
+	if want := []string{"tcp example.com:22"}; !reflect.DeepEqual(p.addrs, want) {
+		t.Errorf("proxy dialed %q, want %q", p.addrs, want)
+	}
+}
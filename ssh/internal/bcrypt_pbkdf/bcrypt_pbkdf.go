@@ -0,0 +1,104 @@
+// Copyright 2018 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+// Package bcrypt_pbkdf implements the bcrypt_pbkdf key derivation function
+// of OpenBSD, with which OpenSSH derives the keys that encrypt its private
+// key files.
+//
+// It is a variant of PBKDF2 whose pseudorandom function is a bcrypt hash of
+// SHA-512 digests. It is only meant to read and write OpenSSH keys: new
+// protocols should use golang.org/x/crypto/argon2 or
+// golang.org/x/crypto/scrypt instead.
+package bcrypt_pbkdf
+
+import (
+	"crypto/sha512"
+	"errors"
+
+	"golang.org/x/crypto/blowfish"
+)
+
+// blockSize is the size of the output of the bcrypt hash.
+const blockSize = 32
+
+// magic is the plaintext that the bcrypt hash encrypts.
+var magic = []byte("OxychromaticBlowfishSwatDynamite")
+
+var (
+	errRounds    = errors.New("bcrypt_pbkdf: number of rounds is too small")
+	errPassword  = errors.New("bcrypt_pbkdf: empty password")
+	errSalt      = errors.New("bcrypt_pbkdf: bad salt length")
+	errKeyLength = errors.New("bcrypt_pbkdf: bad key length")
+)
+
+// Key derives a key of keyLen bytes from password and salt with the given
+// number of rounds, as bcrypt_pbkdf does. keyLen must be between 1 and 1024.
+func Key(password, salt []byte, rounds, keyLen int) ([]byte, error) {
+	switch {
+	case rounds < 1:
+		return nil, errRounds
+	case len(password) == 0:
+		return nil, errPassword
+	case len(salt) == 0 || len(salt) > 1<<20:
+		return nil, errSalt
+	case keyLen < 1 || keyLen > 1024:
+		return nil, errKeyLength
+	}
+
+	numBlocks := (keyLen + blockSize - 1) / blockSize
+	key := make([]byte, numBlocks*blockSize)
+
+	h := sha512.New()
+	h.Write(password)
+	shaPass := h.Sum(nil)
+
+	shaSalt := make([]byte, 0, sha512.Size)
+	var tmp, out [blockSize]byte
+	for block := 1; block <= numBlocks; block++ {
+		h.Reset()
+		h.Write(salt)
+		h.Write([]byte{byte(block >> 24), byte(block >> 16), byte(block >> 8), byte(block)})
+		bcryptHash(&tmp, shaPass, h.Sum(shaSalt))
+		out = tmp
+
+		for i := 1; i < rounds; i++ {
+			h.Reset()
+			h.Write(tmp[:])
+			bcryptHash(&tmp, shaPass, h.Sum(shaSalt))
+			for j := range out {
+				out[j] ^= tmp[j]
+			}
+		}
+
+		// Unlike PBKDF2, the output blocks are interleaved, byte by byte.
+		for i, v := range out {
+			key[i*numBlocks+block-1] = v
+		}
+	}
+	return key[:keyLen], nil
+}
+
+// bcryptHash sets out to the bcrypt hash of shaPass and shaSalt, the SHA-512
+// digests of the password and of the salt.
+func bcryptHash(out *[blockSize]byte, shaPass, shaSalt []byte) {
+	c, err := blowfish.NewSaltedCipher(shaPass, shaSalt)
+	if err != nil {
+		panic(err)
+	}
+	for i := 0; i < 64; i++ {
+		blowfish.ExpandKey(shaSalt, c)
+		blowfish.ExpandKey(shaPass, c)
+	}
+	copy(out[:], magic)
+	for i := 0; i < blockSize; i += 8 {
+		for j := 0; j < 64; j++ {
+			c.Encrypt(out[i:i+8], out[i:i+8])
+		}
+	}
+	// OpenBSD encrypts the magic as big-endian words, but outputs them in
+	// little-endian order.
+	for i := 0; i < blockSize; i += 4 {
+		out[i], out[i+1], out[i+2], out[i+3] = out[i+3], out[i+2], out[i+1], out[i]
+	}
+}
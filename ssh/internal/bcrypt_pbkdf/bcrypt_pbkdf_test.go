@@ -0,0 +1,72 @@
+// Copyright 2018 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package bcrypt_pbkdf
+
+import (
+	"bytes"
+	"encoding/hex"
+	"testing"
+)
+
+func mustHex(s string) []byte {
+	b, err := hex.DecodeString(s)
+	if err != nil {
+		panic(err)
+	}
+	return b
+}
+
+// TestKey checks the key and IV derived for an Ed25519 key encrypted by
+// ssh-keygen with aes256-ctr, the default, which this output decrypts.
+func TestKey(t *testing.T) {
+	salt := mustHex("098409e31407bb2ae36972f8179e5300")
+	want := mustHex("a1db245d292464d634c1a508a9e049a34058dc68095ff0911664326da50b3a46" +
+		"a52306bd1fc92f90990e10f54e265c62")
+	got, err := Key([]byte("g0pher-s3cret"), salt, 16, len(want))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(got, want) {
+		t.Errorf("Key = %x, want %x", got, want)
+	}
+}
+
+func TestKeyLength(t *testing.T) {
+	password, salt := []byte("password"), []byte("salt")
+	for _, n := range []int{1, 31, 32, 33, 64, 100} {
+		k, err := Key(password, salt, 2, n)
+		if err != nil {
+			t.Fatalf("Key(%d): %v", n, err)
+		}
+		if len(k) != n {
+			t.Errorf("Key(%d) returned %d bytes", n, len(k))
+		}
+	}
+	// The blocks are interleaved, so keys of different lengths differ in
+	// their first bytes, as long as they have a different number of blocks.
+	short, _ := Key(password, salt, 2, 32)
+	long, _ := Key(password, salt, 2, 64)
+	if bytes.Equal(short, long[:32]) {
+		t.Error("keys of one and two blocks share their first block")
+	}
+}
+
+func TestKeyErrors(t *testing.T) {
+	for _, tt := range []struct {
+		name           string
+		password, salt []byte
+		rounds, keyLen int
+	}{
+		{"zero rounds", []byte("password"), []byte("salt"), 0, 32},
+		{"empty password", nil, []byte("salt"), 1, 32},
+		{"empty salt", []byte("password"), nil, 1, 32},
+		{"zero key length", []byte("password"), []byte("salt"), 1, 0},
+		{"long key", []byte("password"), []byte("salt"), 1, 1025},
+	} {
+		if _, err := Key(tt.password, tt.salt, tt.rounds, tt.keyLen); err == nil {
+			t.Errorf("%s: Key succeeded", tt.name)
+		}
+	}
+}
@@ -7,16 +7,19 @@ package ssh
 import (
 	"bytes"
 	"crypto"
+	"crypto/aes"
+	"crypto/cipher"
 	"crypto/dsa"
 	"crypto/ecdsa"
 	"crypto/elliptic"
 	"crypto/md5"
+	"crypto/rand"
 	"crypto/rsa"
 	"crypto/sha256"
 	"crypto/x509"
 	"encoding/asn1"
 	"encoding/base64"
-	"encoding/hex"
+	"encoding/binary"
 	"encoding/pem"
 	"errors"
 	"fmt"
@@ -25,6 +28,8 @@ import (
 	"strings"
 
 	"golang.org/x/crypto/ed25519"
+	"golang.org/x/crypto/fingerprint"
+	"golang.org/x/crypto/ssh/internal/bcrypt_pbkdf"
 )
 
 // These constants represent the algorithm names for key types supported by this
@@ -803,7 +808,9 @@ func encryptedBlock(block *pem.Block) bool {
 }
 
 // ParseRawPrivateKey returns a private key from a PEM encoded private key. It
-// supports RSA (PKCS#1), DSA (OpenSSL), and ECDSA private keys.
+// supports RSA (PKCS#1), DSA (OpenSSL), and ECDSA private keys, as well as RSA
+// and Ed25519 keys in the OpenSSH format. For OpenSSH keys encrypted with a
+// passphrase, it returns a *PassphraseMissingError.
 func ParseRawPrivateKey(pemBytes []byte) (interface{}, error) {
 	block, _ := pem.Decode(pemBytes)
 	if block == nil {
@@ -822,7 +829,7 @@ func ParseRawPrivateKey(pemBytes []byte) (interface{}, error) {
 	case "DSA PRIVATE KEY":
 		return ParseDSAPrivateKey(block.Bytes)
 	case "OPENSSH PRIVATE KEY":
-		return parseOpenSSHPrivateKey(block.Bytes)
+		return parseOpenSSHPrivateKey(block.Bytes, nil)
 	default:
 		return nil, fmt.Errorf("ssh: unsupported key type %q", block.Type)
 	}
@@ -830,7 +837,9 @@ func ParseRawPrivateKey(pemBytes []byte) (interface{}, error) {
 
 // ParseRawPrivateKeyWithPassphrase returns a private key decrypted with
 // passphrase from a PEM encoded private key. If wrong passphrase, return
-// x509.IncorrectPasswordError.
+// x509.IncorrectPasswordError. It supports PEM blocks encrypted as by OpenSSL,
+// and OpenSSH keys encrypted with a key derived by bcrypt_pbkdf, as written
+// by ssh-keygen.
 func ParseRawPrivateKeyWithPassphrase(pemBytes, passPhrase []byte) (interface{}, error) {
 	block, _ := pem.Decode(pemBytes)
 	if block == nil {
@@ -859,7 +868,7 @@ func ParseRawPrivateKeyWithPassphrase(pemBytes, passPhrase []byte) (interface{},
 	case "DSA PRIVATE KEY":
 		return ParseDSAPrivateKey(buf)
 	case "OPENSSH PRIVATE KEY":
-		return parseOpenSSHPrivateKey(buf)
+		return parseOpenSSHPrivateKey(buf, passPhrase)
 	default:
 		return nil, fmt.Errorf("ssh: unsupported key type %q", block.Type)
 	}
@@ -897,30 +906,82 @@ func ParseDSAPrivateKey(der []byte) (*dsa.PrivateKey, error) {
 	}, nil
 }
 
+// PassphraseMissingError is returned by ParseRawPrivateKey and
+// ParsePrivateKey for OpenSSH private keys encrypted with a passphrase, which
+// must be parsed with ParseRawPrivateKeyWithPassphrase instead.
+type PassphraseMissingError struct {
+	// PublicKey is the public key of the encrypted private key, which
+	// OpenSSH stores unencrypted.
+	PublicKey PublicKey
+}
+
+func (*PassphraseMissingError) Error() string {
+	return "ssh: cannot decode encrypted private keys without a passphrase"
+}
+
+const openSSHMagic = "openssh-key-v1\x00"
+
+// openSSHKey is the outer structure of an OpenSSH private key, after its
+// magic.
+type openSSHKey struct {
+	CipherName   string
+	KdfName      string
+	KdfOpts      string
+	NumKeys      uint32
+	PubKey       []byte
+	PrivKeyBlock []byte
+}
+
+// openSSHBcryptOpts are the KdfOpts of keys encrypted with a key derived by
+// bcrypt_pbkdf.
+type openSSHBcryptOpts struct {
+	Salt   []byte
+	Rounds uint32
+}
+
+// openSSHCipherKeySizes are the key sizes of the ciphers with which OpenSSH
+// private keys can be encrypted. Their IVs are all aes.BlockSize bytes long.
+var openSSHCipherKeySizes = map[string]int{
+	"aes128-ctr": 16,
+	"aes192-ctr": 24,
+	"aes256-ctr": 32,
+	"aes256-cbc": 32,
+}
+
 // Implemented based on the documentation at
 // https://github.com/openssh/openssh-portable/blob/master/PROTOCOL.key
-func parseOpenSSHPrivateKey(key []byte) (crypto.PrivateKey, error) {
-	magic := append([]byte("openssh-key-v1"), 0)
-	if !bytes.Equal(magic, key[0:len(magic)]) {
+func parseOpenSSHPrivateKey(key, passphrase []byte) (crypto.PrivateKey, error) {
+	if !bytes.HasPrefix(key, []byte(openSSHMagic)) {
 		return nil, errors.New("ssh: invalid openssh private key format")
 	}
-	remaining := key[len(magic):]
-
-	var w struct {
-		CipherName   string
-		KdfName      string
-		KdfOpts      string
-		NumKeys      uint32
-		PubKey       []byte
-		PrivKeyBlock []byte
-	}
+	remaining := key[len(openSSHMagic):]
 
+	var w openSSHKey
 	if err := Unmarshal(remaining, &w); err != nil {
 		return nil, err
 	}
+	if w.NumKeys != 1 {
+		return nil, errors.New("ssh: multi-key files are not supported")
+	}
 
-	if w.KdfName != "none" || w.CipherName != "none" {
-		return nil, errors.New("ssh: cannot decode encrypted private keys")
+	privKeyBlock := w.PrivKeyBlock
+	switch {
+	case w.KdfName == "none" && w.CipherName == "none":
+	case w.KdfName == "bcrypt":
+		if len(passphrase) == 0 {
+			pub, err := ParsePublicKey(w.PubKey)
+			if err != nil {
+				return nil, err
+			}
+			return nil, &PassphraseMissingError{PublicKey: pub}
+		}
+		var err error
+		privKeyBlock, err = decryptOpenSSHPrivateKey(&w, passphrase)
+		if err != nil {
+			return nil, err
+		}
+	default:
+		return nil, fmt.Errorf("ssh: cannot decode private keys encrypted with cipher %q and KDF %q", w.CipherName, w.KdfName)
 	}
 
 	pk1 := struct {
@@ -930,11 +991,17 @@ func parseOpenSSHPrivateKey(key []byte) (crypto.PrivateKey, error) {
 		Rest    []byte `ssh:"rest"`
 	}{}
 
-	if err := Unmarshal(w.PrivKeyBlock, &pk1); err != nil {
+	if err := Unmarshal(privKeyBlock, &pk1); err != nil {
+		if w.CipherName != "none" {
+			return nil, x509.IncorrectPasswordError
+		}
 		return nil, err
 	}
 
 	if pk1.Check1 != pk1.Check2 {
+		if w.CipherName != "none" {
+			return nil, x509.IncorrectPasswordError
+		}
 		return nil, errors.New("ssh: checkint mismatch")
 	}
 
@@ -957,10 +1024,8 @@ func parseOpenSSHPrivateKey(key []byte) (crypto.PrivateKey, error) {
 			return nil, err
 		}
 
-		for i, b := range key.Pad {
-			if int(b) != i+1 {
-				return nil, errors.New("ssh: padding not as expected")
-			}
+		if err := checkOpenSSHKeyPadding(key.Pad); err != nil {
+			return nil, err
 		}
 
 		pk := &rsa.PrivateKey{
@@ -980,13 +1045,7 @@ func parseOpenSSHPrivateKey(key []byte) (crypto.PrivateKey, error) {
 
 		return pk, nil
 	case KeyAlgoED25519:
-		key := struct {
-			Pub     []byte
-			Priv    []byte
-			Comment string
-			Pad     []byte `ssh:"rest"`
-		}{}
-
+		var key openSSHEd25519PrivateKey
 		if err := Unmarshal(pk1.Rest, &key); err != nil {
 			return nil, err
 		}
@@ -994,11 +1053,12 @@ func parseOpenSSHPrivateKey(key []byte) (crypto.PrivateKey, error) {
 		if len(key.Priv) != ed25519.PrivateKeySize {
 			return nil, errors.New("ssh: private key unexpected length")
 		}
+		if !bytes.Equal(key.Pub, key.Priv[32:]) {
+			return nil, errors.New("ssh: private key does not match its public key")
+		}
 
-		for i, b := range key.Pad {
-			if int(b) != i+1 {
-				return nil, errors.New("ssh: padding not as expected")
-			}
+		if err := checkOpenSSHKeyPadding(key.Pad); err != nil {
+			return nil, err
 		}
 
 		pk := ed25519.PrivateKey(make([]byte, ed25519.PrivateKeySize))
@@ -1009,15 +1069,183 @@ func parseOpenSSHPrivateKey(key []byte) (crypto.PrivateKey, error) {
 	}
 }
 
+type openSSHEd25519PrivateKey struct {
+	Pub     []byte
+	Priv    []byte
+	Comment string
+	Pad     []byte `ssh:"rest"`
+}
+
+func checkOpenSSHKeyPadding(pad []byte) error {
+	for i, b := range pad {
+		if int(b) != i+1 {
+			return errors.New("ssh: padding not as expected")
+		}
+	}
+	return nil
+}
+
+// decryptOpenSSHPrivateKey returns the decrypted private key block of w,
+// which is encrypted with a key derived from passphrase by bcrypt_pbkdf.
+func decryptOpenSSHPrivateKey(w *openSSHKey, passphrase []byte) ([]byte, error) {
+	var opts openSSHBcryptOpts
+	if err := Unmarshal([]byte(w.KdfOpts), &opts); err != nil {
+		return nil, err
+	}
+	if opts.Rounds < 1 || opts.Rounds > openSSHMaxRounds {
+		return nil, fmt.Errorf("ssh: unsupported number of bcrypt_pbkdf rounds %d", opts.Rounds)
+	}
+	if len(opts.Salt) == 0 || len(opts.Salt) > openSSHMaxSaltSize {
+		return nil, errors.New("ssh: invalid bcrypt_pbkdf salt size")
+	}
+	block := append([]byte(nil), w.PrivKeyBlock...)
+	if err := openSSHCrypt(w.CipherName, passphrase, &opts, block, true); err != nil {
+		return nil, err
+	}
+	return block, nil
+}
+
+// openSSHCrypt encrypts or decrypts b in place, with the cipher named
+// cipherName and a key and IV derived from passphrase with opts.
+func openSSHCrypt(cipherName string, passphrase []byte, opts *openSSHBcryptOpts, b []byte, decrypt bool) error {
+	keySize, ok := openSSHCipherKeySizes[cipherName]
+	if !ok {
+		return fmt.Errorf("ssh: unsupported private key cipher %q", cipherName)
+	}
+	if len(b)%aes.BlockSize != 0 {
+		return errors.New("ssh: encrypted private key has an invalid length")
+	}
+	k, err := bcrypt_pbkdf.Key(passphrase, opts.Salt, int(opts.Rounds), keySize+aes.BlockSize)
+	if err != nil {
+		return fmt.Errorf("ssh: cannot derive private key encryption key: %v", err)
+	}
+	c, err := aes.NewCipher(k[:keySize])
+	if err != nil {
+		return err
+	}
+	iv := k[keySize:]
+	switch {
+	case strings.HasSuffix(cipherName, "-ctr"):
+		cipher.NewCTR(c, iv).XORKeyStream(b, b)
+	case decrypt:
+		cipher.NewCBCDecrypter(c, iv).CryptBlocks(b, b)
+	default:
+		cipher.NewCBCEncrypter(c, iv).CryptBlocks(b, b)
+	}
+	return nil
+}
+
+// MarshalPrivateKey returns a PEM block holding key, with the given comment,
+// in the OpenSSH private key format written by ssh-keygen, unencrypted. Only
+// Ed25519 keys, of type ed25519.PrivateKey or *ed25519.PrivateKey, are
+// supported.
+func MarshalPrivateKey(key crypto.PrivateKey, comment string) (*pem.Block, error) {
+	return marshalOpenSSHPrivateKey(rand.Reader, key, comment, nil)
+}
+
+// MarshalPrivateKeyWithPassphrase is like MarshalPrivateKey, but encrypts the
+// key with aes256-ctr under a key derived from passphrase by bcrypt_pbkdf, as
+// ssh-keygen does by default. The result can be read back with
+// ParseRawPrivateKeyWithPassphrase, or by OpenSSH.
+func MarshalPrivateKeyWithPassphrase(key crypto.PrivateKey, comment string, passphrase []byte) (*pem.Block, error) {
+	if len(passphrase) == 0 {
+		return nil, errors.New("ssh: empty passphrase")
+	}
+	return marshalOpenSSHPrivateKey(rand.Reader, key, comment, passphrase)
+}
+
+// The parameters of ssh-keygen for new encrypted keys.
+const (
+	openSSHDefaultCipher = "aes256-ctr"
+	openSSHSaltSize      = 16
+	openSSHDefaultRounds = 16
+)
+
+// openSSHMaxRounds and openSSHMaxSaltSize bound the work that an encrypted
+// key from an untrusted source can make ParseRawPrivateKeyWithPassphrase
+// perform. ssh-keygen uses a 16-byte salt, and 16 rounds unless told
+// otherwise with -a.
+const (
+	openSSHMaxRounds   = 1024
+	openSSHMaxSaltSize = 64
+)
+
+func marshalOpenSSHPrivateKey(rand io.Reader, key crypto.PrivateKey, comment string, passphrase []byte) (*pem.Block, error) {
+	var priv ed25519.PrivateKey
+	switch k := key.(type) {
+	case ed25519.PrivateKey:
+		priv = k
+	case *ed25519.PrivateKey:
+		priv = *k
+	default:
+		return nil, fmt.Errorf("ssh: unsupported key type %T", key)
+	}
+	if len(priv) != ed25519.PrivateKeySize {
+		return nil, errors.New("ssh: private key unexpected length")
+	}
+	pub := ed25519PublicKey(priv[32:])
+
+	// The check integers are random, so that they reveal whether an
+	// encrypted key was decrypted with the right passphrase, but nothing
+	// else.
+	var check [4]byte
+	if _, err := io.ReadFull(rand, check[:]); err != nil {
+		return nil, err
+	}
+	checkInt := binary.BigEndian.Uint32(check[:])
+
+	w := openSSHKey{
+		CipherName: "none",
+		KdfName:    "none",
+		NumKeys:    1,
+		PubKey:     pub.Marshal(),
+	}
+	blockSize := 8
+	var opts openSSHBcryptOpts
+	if passphrase != nil {
+		opts.Salt = make([]byte, openSSHSaltSize)
+		if _, err := io.ReadFull(rand, opts.Salt); err != nil {
+			return nil, err
+		}
+		opts.Rounds = openSSHDefaultRounds
+		w.CipherName = openSSHDefaultCipher
+		w.KdfName = "bcrypt"
+		w.KdfOpts = string(Marshal(&opts))
+		blockSize = aes.BlockSize
+	}
+
+	pk1 := struct {
+		Check1  uint32
+		Check2  uint32
+		Keytype string
+		Key     []byte `ssh:"rest"`
+	}{checkInt, checkInt, KeyAlgoED25519, Marshal(&openSSHEd25519PrivateKey{
+		Pub:     []byte(pub),
+		Priv:    []byte(priv),
+		Comment: comment,
+	})}
+	block := Marshal(&pk1)
+	for i := 1; len(block)%blockSize != 0; i++ {
+		block = append(block, byte(i))
+	}
+	if passphrase != nil {
+		if err := openSSHCrypt(w.CipherName, passphrase, &opts, block, false); err != nil {
+			return nil, err
+		}
+	}
+	w.PrivKeyBlock = block
+
+	return &pem.Block{
+		Type:  "OPENSSH PRIVATE KEY",
+		Bytes: append([]byte(openSSHMagic), Marshal(&w)...),
+	}, nil
+}
+
 // FingerprintLegacyMD5 returns the user presentation of the key's
 // fingerprint as described by RFC 4716 section 4.
 func FingerprintLegacyMD5(pubKey PublicKey) string {
 	md5sum := md5.Sum(pubKey.Marshal())
-	hexarray := make([]string, len(md5sum))
-	for i, c := range md5sum {
-		hexarray[i] = hex.EncodeToString([]byte{c})
-	}
-	return strings.Join(hexarray, ":")
+	return fingerprint.Hex(md5sum[:], ":", 1)
 }
 
 // FingerprintSHA256 returns the user presentation of the key's
@@ -1027,6 +1255,5 @@ func FingerprintLegacyMD5(pubKey PublicKey) string {
 // https://tools.ietf.org/html/rfc4648#section-3.2 (unpadded base64 encoding)
 func FingerprintSHA256(pubKey PublicKey) string {
 	sha256sum := sha256.Sum256(pubKey.Marshal())
-	hash := base64.RawStdEncoding.EncodeToString(sha256sum[:])
-	return "SHA256:" + hash
+	return fingerprint.SSH(sha256sum[:])
 }
@@ -13,6 +13,7 @@ import (
 	"crypto/rsa"
 	"crypto/x509"
 	"encoding/base64"
+	"encoding/pem"
 	"fmt"
 	"reflect"
 	"strings"
@@ -174,6 +175,121 @@ func TestParseEncryptedPrivateKeysWithPassphrase(t *testing.T) {
 	}
 }
 
+func TestParseOpenSSHEncryptedPrivateKey(t *testing.T) {
+	tt := testdata.PEMEncryptedKeys[2]
+	wantPub, _, _, _, err := ParseAuthorizedKey([]byte("ssh-ed25519 AAAAC3NzaC1lZDI1NTE5AAAAID+kW7UCqdgWa6EBoNP+kp7DWD8w/2RcpL19/FTtYOkG"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	_, err = ParseRawPrivateKey(tt.PEMBytes)
+	missing, ok := err.(*PassphraseMissingError)
+	if !ok {
+		t.Fatalf("ParseRawPrivateKey returned %v, want a *PassphraseMissingError", err)
+	}
+	if !bytes.Equal(missing.PublicKey.Marshal(), wantPub.Marshal()) {
+		t.Errorf("PassphraseMissingError.PublicKey is %v, want %v", missing.PublicKey, wantPub)
+	}
+
+	if _, err := ParseRawPrivateKeyWithPassphrase(tt.PEMBytes, []byte("incorrect")); err != x509.IncorrectPasswordError {
+		t.Errorf("got %v, want IncorrectPasswordError", err)
+	}
+
+	key, err := ParseRawPrivateKeyWithPassphrase(tt.PEMBytes, []byte(tt.EncryptionKey))
+	if err != nil {
+		t.Fatal(err)
+	}
+	priv, ok := key.(*ed25519.PrivateKey)
+	if !ok {
+		t.Fatalf("got %T, want *ed25519.PrivateKey", key)
+	}
+	if pub := (ed25519PublicKey)(priv.Public().(ed25519.PublicKey)); !bytes.Equal(pub.Marshal(), wantPub.Marshal()) {
+		t.Errorf("decrypted key has public key %v, want %v", pub, wantPub)
+	}
+}
+
+func TestMarshalPrivateKey(t *testing.T) {
+	_, priv, err := ed25519.GenerateKey(rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	block, err := MarshalPrivateKey(priv, "gopher@example.com")
+	if err != nil {
+		t.Fatal(err)
+	}
+	key, err := ParseRawPrivateKey(pem.EncodeToMemory(block))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := key.(*ed25519.PrivateKey); !bytes.Equal(*got, priv) {
+		t.Errorf("MarshalPrivateKey round trip returned %x, want %x", *got, priv)
+	}
+
+	passphrase := []byte("g0pher-s3cret")
+	block, err = MarshalPrivateKeyWithPassphrase(&priv, "", passphrase)
+	if err != nil {
+		t.Fatal(err)
+	}
+	encoded := pem.EncodeToMemory(block)
+	if _, err := ParseRawPrivateKey(encoded); err == nil {
+		t.Fatal("ParseRawPrivateKey decoded a key encrypted with a passphrase")
+	} else if _, ok := err.(*PassphraseMissingError); !ok {
+		t.Fatalf("ParseRawPrivateKey returned %v, want a *PassphraseMissingError", err)
+	}
+	if _, err := ParseRawPrivateKeyWithPassphrase(encoded, []byte("incorrect")); err != x509.IncorrectPasswordError {
+		t.Errorf("got %v, want IncorrectPasswordError", err)
+	}
+	key, err = ParseRawPrivateKeyWithPassphrase(encoded, passphrase)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := key.(*ed25519.PrivateKey); !bytes.Equal(*got, priv) {
+		t.Errorf("MarshalPrivateKeyWithPassphrase round trip returned %x, want %x", *got, priv)
+	}
+
+	if _, err := MarshalPrivateKeyWithPassphrase(priv, "", nil); err == nil {
+		t.Error("MarshalPrivateKeyWithPassphrase accepted an empty passphrase")
+	}
+	if _, err := MarshalPrivateKey(testPrivateKeys["rsa"], ""); err == nil {
+		t.Error("MarshalPrivateKey accepted an RSA key")
+	}
+}
+
+func TestParseOpenSSHEncryptedPrivateKeyKDFOptions(t *testing.T) {
+	_, priv, err := ed25519.GenerateKey(rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	passphrase := []byte("g0pher-s3cret")
+	block, err := MarshalPrivateKeyWithPassphrase(priv, "", passphrase)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var w openSSHKey
+	if err := Unmarshal(block.Bytes[len(openSSHMagic):], &w); err != nil {
+		t.Fatal(err)
+	}
+
+	for _, opts := range []openSSHBcryptOpts{
+		{Salt: make([]byte, 16), Rounds: 0},
+		{Salt: make([]byte, 16), Rounds: openSSHMaxRounds + 1},
+		{Salt: make([]byte, 16), Rounds: 1<<32 - 1},
+		{Salt: nil, Rounds: 16},
+		{Salt: make([]byte, openSSHMaxSaltSize+1), Rounds: 16},
+	} {
+		w := w
+		w.KdfOpts = string(Marshal(&opts))
+		b := &pem.Block{
+			Type:  block.Type,
+			Bytes: append([]byte(openSSHMagic), Marshal(&w)...),
+		}
+		if _, err := ParseRawPrivateKeyWithPassphrase(pem.EncodeToMemory(b), passphrase); err == nil || err == x509.IncorrectPasswordError {
+			t.Errorf("ParseRawPrivateKeyWithPassphrase with %d rounds and a %d-byte salt returned %v", opts.Rounds, len(opts.Salt), err)
+		}
+	}
+}
+
 func TestParseDSA(t *testing.T) {
 	// We actually exercise the ParsePrivateKey codepath here, as opposed to
 	// using the ParseRawPrivateKey+NewSignerFromKey path that testdata_test.go
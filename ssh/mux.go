@@ -5,6 +5,7 @@
 package ssh
 
 import (
+	"context"
 	"encoding/binary"
 	"fmt"
 	"io"
@@ -91,7 +92,7 @@ type mux struct {
 
 	incomingChannels chan NewChannel
 
-	globalSentMu     sync.Mutex
+	globalSentMu     requestLock
 	globalResponses  chan interface{}
 	incomingRequests chan *Request
 
@@ -120,6 +121,7 @@ func newMux(p packetConn) *mux {
 		globalResponses:  make(chan interface{}, 1),
 		incomingRequests: make(chan *Request, chanSize),
 		errCond:          newCond(),
+		globalSentMu:     make(requestLock, 1),
 	}
 	if debugMux {
 		m.chanList.offset = atomic.AddUint32(&globalOff, 1)
@@ -137,10 +139,33 @@ func (m *mux) sendMessage(msg interface{}) error {
 	return m.conn.writePacket(p)
 }
 
+// A requestLock serializes the requests that want a reply, as replies are
+// matched to requests by their order. Unlike with a sync.Mutex, waiting for
+// it can be canceled.
+type requestLock chan struct{}
+
+func (l requestLock) lock(ctx context.Context) error {
+	select {
+	case l <- struct{}{}:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+func (l requestLock) unlock() {
+	<-l
+}
+
 func (m *mux) SendRequest(name string, wantReply bool, payload []byte) (bool, []byte, error) {
+	return m.SendRequestContext(context.Background(), name, wantReply, payload)
+}
+
+func (m *mux) SendRequestContext(ctx context.Context, name string, wantReply bool, payload []byte) (bool, []byte, error) {
 	if wantReply {
-		m.globalSentMu.Lock()
-		defer m.globalSentMu.Unlock()
+		if err := m.globalSentMu.lock(ctx); err != nil {
+			return false, nil, err
+		}
 	}
 
 	if err := m.sendMessage(globalRequestMsg{
@@ -148,6 +173,9 @@ func (m *mux) SendRequest(name string, wantReply bool, payload []byte) (bool, []
 		WantReply: wantReply,
 		Data:      payload,
 	}); err != nil {
+		if wantReply {
+			m.globalSentMu.unlock()
+		}
 		return false, nil, err
 	}
 
@@ -155,7 +183,20 @@ func (m *mux) SendRequest(name string, wantReply bool, payload []byte) (bool, []
 		return false, nil, nil
 	}
 
-	msg, ok := <-m.globalResponses
+	var msg interface{}
+	var ok bool
+	select {
+	case msg, ok = <-m.globalResponses:
+		m.globalSentMu.unlock()
+	case <-ctx.Done():
+		// The reply must still be consumed before the next request
+		// can be sent.
+		go func() {
+			<-m.globalResponses
+			m.globalSentMu.unlock()
+		}()
+		return false, nil, ctx.Err()
+	}
 	if !ok {
 		return false, nil, io.EOF
 	}
@@ -295,7 +336,11 @@ func (m *mux) handleChannelOpen(packet []byte) error {
 }
 
 func (m *mux) OpenChannel(chanType string, extra []byte) (Channel, <-chan *Request, error) {
-	ch, err := m.openChannel(chanType, extra)
+	return m.OpenChannelContext(context.Background(), chanType, extra)
+}
+
+func (m *mux) OpenChannelContext(ctx context.Context, chanType string, extra []byte) (Channel, <-chan *Request, error) {
+	ch, err := m.openChannelContext(ctx, chanType, extra)
 	if err != nil {
 		return nil, nil, err
 	}
@@ -304,6 +349,10 @@ func (m *mux) OpenChannel(chanType string, extra []byte) (Channel, <-chan *Reque
 }
 
 func (m *mux) openChannel(chanType string, extra []byte) (*channel, error) {
+	return m.openChannelContext(context.Background(), chanType, extra)
+}
+
+func (m *mux) openChannelContext(ctx context.Context, chanType string, extra []byte) (*channel, error) {
 	ch := m.newChannel(chanType, channelOutbound, extra)
 
 	ch.maxIncomingPayload = channelMaxPacket
@@ -319,7 +368,22 @@ func (m *mux) openChannel(chanType string, extra []byte) (*channel, error) {
 		return nil, err
 	}
 
-	switch msg := (<-ch.msg).(type) {
+	var reply interface{}
+	select {
+	case reply = <-ch.msg:
+	case <-ctx.Done():
+		// If the peer confirms the channel later, close it so that
+		// it does not stay open on both sides.
+		go func() {
+			if _, ok := (<-ch.msg).(*channelOpenConfirmMsg); ok {
+				go DiscardRequests(ch.incomingRequests)
+				ch.Close()
+			}
+		}()
+		return nil, ctx.Err()
+	}
+
+	switch msg := reply.(type) {
 	case *channelOpenConfirmMsg:
 		return ch, nil
 	case *channelOpenFailureMsg:
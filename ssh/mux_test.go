@@ -5,10 +5,13 @@
 package ssh
 
 import (
+	"context"
 	"io"
 	"io/ioutil"
+	"net"
 	"sync"
 	"testing"
+	"time"
 )
 
 func muxPair() (*mux, *mux) {
@@ -373,6 +376,143 @@ func TestMuxChannelRequestUnblock(t *testing.T) {
 	}
 }
 
+func isTimeout(err error) bool {
+	netErr, ok := err.(net.Error)
+	return ok && netErr.Timeout()
+}
+
+func TestMuxReadDeadline(t *testing.T) {
+	reader, writer, mux := channelPair(t)
+	defer reader.Close()
+	defer writer.Close()
+	defer mux.Close()
+
+	reader.SetReadDeadline(time.Now().Add(10 * time.Millisecond))
+	var buf [16]byte
+	if _, err := reader.Read(buf[:]); !isTimeout(err) {
+		t.Fatalf("Read after deadline: got %v, want a timeout", err)
+	}
+	if _, err := reader.Stderr().Read(buf[:]); !isTimeout(err) {
+		t.Fatalf("Stderr Read after deadline: got %v, want a timeout", err)
+	}
+
+	// Clearing the deadline unblocks reads again.
+	reader.SetReadDeadline(time.Time{})
+	go writer.Write([]byte("hello"))
+	if n, err := reader.Read(buf[:]); err != nil || string(buf[:n]) != "hello" {
+		t.Fatalf("Read: got %q, %v", buf[:n], err)
+	}
+
+	// Setting a deadline unblocks a pending read.
+	result := make(chan error, 1)
+	go func() {
+		_, err := reader.Read(buf[:])
+		result <- err
+	}()
+	time.Sleep(10 * time.Millisecond)
+	reader.SetReadDeadline(time.Now())
+	if err := <-result; !isTimeout(err) {
+		t.Fatalf("pending Read: got %v, want a timeout", err)
+	}
+}
+
+func TestMuxWriteDeadline(t *testing.T) {
+	reader, writer, mux := channelPair(t)
+	defer reader.Close()
+	defer writer.Close()
+	defer mux.Close()
+
+	writer.SetWriteDeadline(time.Now().Add(10 * time.Millisecond))
+	n, err := writer.Write(make([]byte, channelWindowSize+1))
+	if !isTimeout(err) {
+		t.Fatalf("Write beyond the window: got %v, want a timeout", err)
+	}
+	if n != channelWindowSize {
+		t.Errorf("wrote %d bytes, want %d", n, channelWindowSize)
+	}
+}
+
+func TestMuxGlobalRequestContext(t *testing.T) {
+	clientMux, serverMux := muxPair()
+	defer serverMux.Close()
+	defer clientMux.Close()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	result := make(chan error, 1)
+	go func() {
+		_, _, err := clientMux.SendRequestContext(ctx, "first", true, nil)
+		result <- err
+	}()
+	first := <-serverMux.incomingRequests
+	cancel()
+	if err := <-result; err != context.Canceled {
+		t.Fatalf("canceled SendRequestContext: got %v, want %v", err, context.Canceled)
+	}
+
+	// The late reply to the first request is not taken for the reply to
+	// the second one.
+	go func() {
+		first.Reply(false, nil)
+		second := <-serverMux.incomingRequests
+		second.Reply(true, []byte(second.Type))
+	}()
+	ok, data, err := clientMux.SendRequest("second", true, nil)
+	if !ok || string(data) != "second" || err != nil {
+		t.Errorf("SendRequest after a canceled request: %v %q %v", ok, data, err)
+	}
+}
+
+func TestMuxChannelRequestContext(t *testing.T) {
+	a, b, connB := channelPair(t)
+	defer a.Close()
+	defer b.Close()
+	defer connB.Close()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+	if _, err := a.SendRequestContext(ctx, "first", true, nil); err != context.DeadlineExceeded {
+		t.Fatalf("SendRequestContext without a reply: got %v, want %v", err, context.DeadlineExceeded)
+	}
+
+	go func() {
+		first := <-b.incomingRequests
+		first.Reply(false, nil)
+		second := <-b.incomingRequests
+		second.Reply(true, nil)
+	}()
+	if ok, err := a.SendRequest("second", true, nil); !ok || err != nil {
+		t.Errorf("SendRequest after a canceled request: %v %v", ok, err)
+	}
+}
+
+func TestMuxOpenChannelContext(t *testing.T) {
+	clientMux, serverMux := muxPair()
+	defer serverMux.Close()
+	defer clientMux.Close()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	result := make(chan error, 1)
+	go func() {
+		_, _, err := clientMux.OpenChannelContext(ctx, "chan", nil)
+		result <- err
+	}()
+	newCh := <-serverMux.incomingChannels
+	cancel()
+	if err := <-result; err != context.Canceled {
+		t.Fatalf("canceled OpenChannelContext: got %v, want %v", err, context.Canceled)
+	}
+
+	// Accepting the channel late makes the client close it.
+	ch, reqs, err := newCh.Accept()
+	if err != nil {
+		t.Fatal(err)
+	}
+	go DiscardRequests(reqs)
+	if _, err := ch.Read(make([]byte, 1)); err != io.EOF {
+		t.Errorf("Read on the abandoned channel: got %v, want EOF", err)
+	}
+}
+
 func TestMuxCloseChannel(t *testing.T) {
 	r, w, mux := channelPair(t)
 	defer mux.Close()
@@ -0,0 +1,294 @@
+// Copyright 2018 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package ssh
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+	"sync"
+	"time"
+)
+
+// The kinds of values of terminal mode opcodes.
+const (
+	modeChar  = iota + 1 // a control character, or ttyCharDisabled
+	modeFlag             // 0 or 1
+	modeSpeed            // a baud rate
+)
+
+// ttyCharDisabled is the value of a control character that is disabled, as
+// OpenSSH encodes _POSIX_VDISABLE.
+const ttyCharDisabled = 255
+
+// terminalModeKind returns the kind of the value of op, or 0 if op is not
+// defined by RFC 4254 or RFC 8160.
+func terminalModeKind(op uint8) int {
+	switch {
+	case op >= VINTR && op <= VDISCARD:
+		return modeChar
+	case op >= IGNPAR && op <= IUTF8,
+		op >= ISIG && op <= PENDIN,
+		op >= OPOST && op <= ONLRET,
+		op >= CS7 && op <= PARODD:
+		return modeFlag
+	case op == TTY_OP_ISPEED || op == TTY_OP_OSPEED:
+		return modeSpeed
+	}
+	return 0
+}
+
+func (m TerminalModes) set(op uint8, kind int, v uint32) TerminalModes {
+	if terminalModeKind(op) != kind {
+		panic(fmt.Sprintf("ssh: terminal mode %d set with a value of the wrong kind", op))
+	}
+	m[op] = v
+	return m
+}
+
+// SetChar sets the control character op, such as VINTR, to c, and returns m
+// so that calls can be chained, as in
+//
+//	TerminalModes{}.SetChar(VINTR, 3).SetFlag(ECHO, false).SetSpeed(38400)
+//
+// It panics if op is not a control character opcode.
+func (m TerminalModes) SetChar(op uint8, c byte) TerminalModes {
+	return m.set(op, modeChar, uint32(c))
+}
+
+// DisableChar disables the control character op, and returns m. It panics if
+// op is not a control character opcode.
+func (m TerminalModes) DisableChar(op uint8) TerminalModes {
+	return m.set(op, modeChar, ttyCharDisabled)
+}
+
+// SetFlag sets the flag op, such as ECHO or ICANON, and returns m. It panics
+// if op is not a flag opcode.
+func (m TerminalModes) SetFlag(op uint8, on bool) TerminalModes {
+	var v uint32
+	if on {
+		v = 1
+	}
+	return m.set(op, modeFlag, v)
+}
+
+// SetSpeed sets both the input and the output baud rates, and returns m.
+func (m TerminalModes) SetSpeed(baud uint32) TerminalModes {
+	m[TTY_OP_ISPEED] = baud
+	m[TTY_OP_OSPEED] = baud
+	return m
+}
+
+// Char returns the control character op. ok is false if it is not set, or
+// is disabled.
+func (m TerminalModes) Char(op uint8) (c byte, ok bool) {
+	v, ok := m[op]
+	if !ok || v >= ttyCharDisabled {
+		return 0, false
+	}
+	return byte(v), true
+}
+
+// Flag returns the value of the flag op, and whether it is set.
+func (m TerminalModes) Flag(op uint8) (on, ok bool) {
+	v, ok := m[op]
+	return v != 0, ok
+}
+
+// Marshal returns the encoded terminal modes of RFC 4254, Section 8, sorted
+// by opcode so that the encoding of m is deterministic.
+func (m TerminalModes) Marshal() []byte {
+	ops := make([]int, 0, len(m))
+	for op := range m {
+		ops = append(ops, int(op))
+	}
+	sort.Ints(ops)
+
+	b := make([]byte, 0, 5*len(m)+1)
+	for _, op := range ops {
+		b = append(b, byte(op))
+		b = appendU32(b, m[uint8(op)])
+	}
+	return append(b, tty_OP_END)
+}
+
+var errTerminalModes = errors.New("ssh: malformed terminal modes")
+
+// ParseTerminalModes decodes the encoded terminal modes of RFC 4254, Section
+// 8. Opcodes that are not defined are kept, for the caller to ignore. As the
+// RFC requires, decoding stops at the first opcode from 160 to 255, which
+// have arguments of unknown length.
+func ParseTerminalModes(b []byte) (TerminalModes, error) {
+	m := make(TerminalModes)
+	for len(b) > 0 {
+		op := b[0]
+		if op == tty_OP_END || op >= 160 {
+			return m, nil
+		}
+		v, rest, ok := parseUint32(b[1:])
+		if !ok {
+			return nil, errTerminalModes
+		}
+		m[op] = v
+		b = rest
+	}
+	// A missing TTY_OP_END is tolerated.
+	return m, nil
+}
+
+// A PtyRequest is the payload of a "pty-req" channel request, which the
+// client sends with Session.RequestPty.
+type PtyRequest struct {
+	Term          string
+	Columns, Rows uint32
+	// Width and Height are in pixels, and are zero if unknown.
+	Width, Height uint32
+	Modes         TerminalModes
+}
+
+// ParsePtyRequest decodes the payload of a "pty-req" channel request.
+func ParsePtyRequest(payload []byte) (*PtyRequest, error) {
+	var msg ptyRequestMsg
+	if err := Unmarshal(payload, &msg); err != nil {
+		return nil, err
+	}
+	modes, err := ParseTerminalModes([]byte(msg.Modelist))
+	if err != nil {
+		return nil, err
+	}
+	return &PtyRequest{
+		Term:    msg.Term,
+		Columns: msg.Columns,
+		Rows:    msg.Rows,
+		Width:   msg.Width,
+		Height:  msg.Height,
+		Modes:   modes,
+	}, nil
+}
+
+// A WindowSize is the payload of a "window-change" channel request, which
+// the client sends with Session.WindowChange.
+type WindowSize struct {
+	Columns, Rows uint32
+	// Width and Height are in pixels, and are zero if unknown.
+	Width, Height uint32
+}
+
+// ParseWindowChange decodes the payload of a "window-change" channel
+// request.
+func ParseWindowChange(payload []byte) (*WindowSize, error) {
+	var msg ptyWindowChangeMsg
+	if err := Unmarshal(payload, &msg); err != nil {
+		return nil, err
+	}
+	ws := WindowSize(msg)
+	return &ws, nil
+}
+
+// A WindowResizer sends the window size of a session to the server,
+// coalescing changes: a terminal being resized reports many sizes in a short
+// time, and only the last one matters. It sends at most one request per
+// interval, with the latest size, and none if the size did not change.
+type WindowResizer struct {
+	s        *Session
+	interval time.Duration
+
+	mu      sync.Mutex
+	pending *ptyWindowChangeMsg
+	last    *ptyWindowChangeMsg
+	running bool
+	err     error
+	closed  bool
+	done    chan struct{}
+	stop    chan struct{}
+}
+
+// NewWindowResizer returns a WindowResizer that sends window-change requests
+// on s at most once per interval. It must be closed when no longer used.
+func (s *Session) NewWindowResizer(interval time.Duration) *WindowResizer {
+	return &WindowResizer{
+		s:        s,
+		interval: interval,
+		stop:     make(chan struct{}),
+	}
+}
+
+// Resize records that the terminal is now h rows by w columns. It does not
+// block: the size is sent later if a request was sent less than an interval
+// ago, and is replaced if Resize is called again in the meantime. Once a
+// request failed, or the WindowResizer is closed, it does nothing.
+func (r *WindowResizer) Resize(h, w int) {
+	msg := &ptyWindowChangeMsg{
+		Columns: uint32(w),
+		Rows:    uint32(h),
+		Width:   uint32(w * 8),
+		Height:  uint32(h * 8),
+	}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if r.closed || r.err != nil {
+		return
+	}
+	r.pending = msg
+	if !r.running {
+		r.running = true
+		r.done = make(chan struct{})
+		go r.run(r.done)
+	}
+}
+
+// run sends the pending sizes until there are none left.
+func (r *WindowResizer) run(done chan struct{}) {
+	defer close(done)
+	for {
+		r.mu.Lock()
+		msg := r.pending
+		r.pending = nil
+		if msg == nil || r.err != nil {
+			r.running = false
+			r.mu.Unlock()
+			return
+		}
+		if r.last != nil && *r.last == *msg {
+			r.mu.Unlock()
+			continue
+		}
+		r.last = msg
+		r.mu.Unlock()
+
+		_, err := r.s.ch.SendRequest("window-change", false, Marshal(msg))
+		if err != nil {
+			r.mu.Lock()
+			r.err = err
+			r.mu.Unlock()
+		}
+
+		t := time.NewTimer(r.interval)
+		select {
+		case <-t.C:
+		case <-r.stop:
+			t.Stop()
+		}
+	}
+}
+
+// Close sends the last pending size, if any, without waiting for the end of
+// the interval, and stops r. It returns the error of the first request that
+// failed.
+func (r *WindowResizer) Close() error {
+	r.mu.Lock()
+	if !r.closed {
+		r.closed = true
+		close(r.stop)
+	}
+	done := r.done
+	r.mu.Unlock()
+	if done != nil {
+		<-done
+	}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return r.err
+}
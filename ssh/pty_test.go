@@ -0,0 +1,180 @@
+// Copyright 2018 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package ssh
+
+import (
+	"bytes"
+	"reflect"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestTerminalModesMarshal(t *testing.T) {
+	m := TerminalModes{}.SetChar(VINTR, 3).DisableChar(VEOL).SetFlag(ECHO, false).SetFlag(IUTF8, true).SetSpeed(38400)
+	want := []byte{
+		VINTR, 0, 0, 0, 3,
+		VEOL, 0, 0, 0, 255,
+		IUTF8, 0, 0, 0, 1,
+		ECHO, 0, 0, 0, 0,
+		TTY_OP_ISPEED, 0, 0, 0x96, 0,
+		TTY_OP_OSPEED, 0, 0, 0x96, 0,
+		tty_OP_END,
+	}
+	if got := m.Marshal(); !bytes.Equal(got, want) {
+		t.Errorf("got %x, want %x", got, want)
+	}
+
+	got, err := ParseTerminalModes(want)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(got, m) {
+		t.Errorf("ParseTerminalModes: got %v, want %v", got, m)
+	}
+	if c, ok := got.Char(VINTR); !ok || c != 3 {
+		t.Errorf("Char(VINTR) = %d, %v, want 3, true", c, ok)
+	}
+	if _, ok := got.Char(VEOL); ok {
+		t.Error("disabled VEOL is set")
+	}
+	if on, ok := got.Flag(ECHO); on || !ok {
+		t.Errorf("Flag(ECHO) = %v, %v, want false, true", on, ok)
+	}
+	if _, ok := got.Flag(ICANON); ok {
+		t.Error("ICANON is set")
+	}
+}
+
+func TestTerminalModesWrongKind(t *testing.T) {
+	for _, f := range []func(){
+		func() { TerminalModes{}.SetChar(ECHO, 1) },
+		func() { TerminalModes{}.SetFlag(VINTR, true) },
+		func() { TerminalModes{}.DisableChar(TTY_OP_ISPEED) },
+		func() { TerminalModes{}.SetFlag(43, true) },
+	} {
+		func() {
+			defer func() {
+				if recover() == nil {
+					t.Error("no panic")
+				}
+			}()
+			f()
+		}()
+	}
+}
+
+func TestParseTerminalModes(t *testing.T) {
+	tests := []struct {
+		in   []byte
+		want TerminalModes
+	}{
+		{nil, TerminalModes{}},
+		{[]byte{tty_OP_END}, TerminalModes{}},
+		{[]byte{ECHO, 0, 0, 0, 1}, TerminalModes{ECHO: 1}},
+		// Undefined opcodes below 160 are kept.
+		{[]byte{99, 0, 0, 0, 7, tty_OP_END}, TerminalModes{99: 7}},
+		// Opcodes from 160 stop the parsing.
+		{[]byte{ECHO, 0, 0, 0, 1, 160, 1, ICANON, 0, 0, 0, 1}, TerminalModes{ECHO: 1}},
+	}
+	for _, tt := range tests {
+		got, err := ParseTerminalModes(tt.in)
+		if err != nil {
+			t.Errorf("%x: %v", tt.in, err)
+			continue
+		}
+		if !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("%x: got %v, want %v", tt.in, got, tt.want)
+		}
+	}
+
+	if _, err := ParseTerminalModes([]byte{ECHO, 0, 0}); err == nil {
+		t.Error("truncated modes accepted")
+	}
+}
+
+func TestParsePtyRequest(t *testing.T) {
+	modes := TerminalModes{}.SetFlag(ECHO, true).SetSpeed(14400)
+	payload := Marshal(&ptyRequestMsg{
+		Term:     "xterm",
+		Columns:  80,
+		Rows:     40,
+		Width:    640,
+		Height:   320,
+		Modelist: string(modes.Marshal()),
+	})
+	got, err := ParsePtyRequest(payload)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := &PtyRequest{"xterm", 80, 40, 640, 320, modes}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("got %+v, want %+v", got, want)
+	}
+	if _, err := ParsePtyRequest(payload[:len(payload)-1]); err == nil {
+		t.Error("truncated request accepted")
+	}
+
+	ws, err := ParseWindowChange(Marshal(&ptyWindowChangeMsg{100, 50, 800, 400}))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if *ws != (WindowSize{100, 50, 800, 400}) {
+		t.Errorf("got %+v", ws)
+	}
+}
+
+// resizeChannel records the window-change requests sent on it. The first
+// request blocks until release is closed.
+type resizeChannel struct {
+	Channel
+	started chan struct{}
+	release chan struct{}
+
+	mu    sync.Mutex
+	sizes []WindowSize
+}
+
+func (c *resizeChannel) SendRequest(name string, wantReply bool, payload []byte) (bool, error) {
+	ws, err := ParseWindowChange(payload)
+	if name != "window-change" || wantReply || err != nil {
+		panic("unexpected request")
+	}
+	c.mu.Lock()
+	c.sizes = append(c.sizes, *ws)
+	first := len(c.sizes) == 1
+	c.mu.Unlock()
+	if first {
+		close(c.started)
+		<-c.release
+	}
+	return false, nil
+}
+
+func TestWindowResizer(t *testing.T) {
+	for _, last := range []int{10, 1} {
+		ch := &resizeChannel{started: make(chan struct{}), release: make(chan struct{})}
+		r := (&Session{ch: ch}).NewWindowResizer(time.Hour)
+		r.Resize(1, 1)
+		<-ch.started
+		for i := 2; i <= 10; i++ {
+			r.Resize(i, i)
+		}
+		r.Resize(last, last)
+		close(ch.release)
+		if err := r.Close(); err != nil {
+			t.Fatal(err)
+		}
+		r.Resize(20, 20)
+
+		want := []WindowSize{{1, 1, 8, 8}}
+		if last != 1 {
+			want = append(want, WindowSize{10, 10, 80, 80})
+		}
+		if !reflect.DeepEqual(ch.sizes, want) {
+			t.Errorf("last size %d: sent %v, want %v", last, ch.sizes, want)
+		}
+	}
+}
@@ -85,6 +85,18 @@ type ServerConfig struct {
 	// unknown.
 	KeyboardInteractiveCallback func(conn ConnMetadata, client KeyboardInteractiveChallenge) (*Permissions, error)
 
+	// HostbasedCallback, if non-nil, is called when a client attempts
+	// hostbased authentication (RFC 4252, section 9), after it has proven
+	// possession of hostKey, the host key of the machine it runs on.
+	// clientHost is the name the client claims for that machine, without
+	// any trailing dot, and clientUser is the name of the user there. The
+	// callback must return a nil error only if hostKey belongs to
+	// clientHost, for example by passing net.JoinHostPort(clientHost, "22")
+	// to a knownhosts callback or to CertChecker.CheckHostKey, and if
+	// clientUser on that host may log in as conn.User(). It should also
+	// check that clientHost matches conn.RemoteAddr().
+	HostbasedCallback func(conn ConnMetadata, clientHost, clientUser string, hostKey PublicKey) (*Permissions, error)
+
 	// AuthLogCallback, if non-nil, is called to log all authentication
 	// attempts.
 	AuthLogCallback func(conn ConnMetadata, method string, err error)
@@ -204,7 +216,8 @@ func (s *connection) serverHandshake(config *ServerConfig) (*Permissions, error)
 		return nil, errors.New("ssh: server has no host keys")
 	}
 
-	if !config.NoClientAuth && config.PasswordCallback == nil && config.PublicKeyCallback == nil && config.KeyboardInteractiveCallback == nil {
+	if !config.NoClientAuth && config.PasswordCallback == nil && config.PublicKeyCallback == nil &&
+		config.KeyboardInteractiveCallback == nil && config.HostbasedCallback == nil {
 		return nil, errors.New("ssh: no authentication methods configured but NoClientAuth is also false")
 	}
 
@@ -495,6 +508,54 @@ userAuthLoop:
 				authErr = candidate.result
 				perms = candidate.perms
 			}
+		case "hostbased":
+			if config.HostbasedCallback == nil {
+				authErr = errors.New("ssh: hostbased auth not configured")
+				break
+			}
+			payload := userAuthReq.Payload
+			algoBytes, payload, ok := parseString(payload)
+			if !ok {
+				return nil, parseError(msgUserAuthRequest)
+			}
+			algo := string(algoBytes)
+			if !isAcceptableAlgo(algo) {
+				authErr = fmt.Errorf("ssh: algorithm %q not accepted", algo)
+				break
+			}
+			hostKeyData, payload, ok := parseString(payload)
+			if !ok {
+				return nil, parseError(msgUserAuthRequest)
+			}
+			clientHost, payload, ok := parseString(payload)
+			if !ok {
+				return nil, parseError(msgUserAuthRequest)
+			}
+			clientUser, payload, ok := parseString(payload)
+			if !ok {
+				return nil, parseError(msgUserAuthRequest)
+			}
+			sig, payload, ok := parseSignature(payload)
+			if !ok || len(payload) > 0 {
+				return nil, parseError(msgUserAuthRequest)
+			}
+
+			hostKey, err := ParsePublicKey(hostKeyData)
+			if err != nil {
+				return nil, err
+			}
+			if !isAcceptableAlgo(sig.Format) {
+				break
+			}
+			signedData := buildDataSignedForHostbased(sessionID, userAuthReq, algoBytes, hostKeyData, string(clientHost), string(clientUser))
+			if err := hostKey.Verify(signedData, sig); err != nil {
+				return nil, err
+			}
+
+			perms, authErr = config.HostbasedCallback(s, strings.TrimSuffix(string(clientHost), "."), string(clientUser), hostKey)
+			if authErr == nil && perms != nil && perms.CriticalOptions != nil && perms.CriticalOptions[sourceAddressCriticalOption] != "" {
+				authErr = checkSourceAddress(s.RemoteAddr(), perms.CriticalOptions[sourceAddressCriticalOption])
+			}
 		default:
 			authErr = fmt.Errorf("ssh: unknown method %q", userAuthReq.Method)
 		}
@@ -521,6 +582,9 @@ userAuthLoop:
 		if config.KeyboardInteractiveCallback != nil {
 			failureMsg.Methods = append(failureMsg.Methods, "keyboard-interactive")
 		}
+		if config.HostbasedCallback != nil {
+			failureMsg.Methods = append(failureMsg.Methods, "hostbased")
+		}
 
 		if len(failureMsg.Methods) == 0 {
 			return nil, errors.New("ssh: no authentication methods configured but NoClientAuth is also false")
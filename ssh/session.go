@@ -9,6 +9,7 @@ package ssh
 
 import (
 	"bytes"
+	"context"
 	"encoding/binary"
 	"errors"
 	"fmt"
@@ -50,6 +51,10 @@ var signals = map[Signal]int{
 	SIGTERM: 15,
 }
 
+// TerminalModes holds the terminal modes of a pty, as a map from the
+// opcodes below to their values. Its Set methods check that values have the
+// kind their opcode expects, and servers decode the modes of a "pty-req"
+// with ParsePtyRequest.
 type TerminalModes map[uint8]uint32
 
 // POSIX terminal mode flags as listed in RFC 4254 Section 8.
@@ -85,6 +90,7 @@ const (
 	IXANY         = 39
 	IXOFF         = 40
 	IMAXBEL       = 41
+	IUTF8         = 42 // RFC 8160
 	ISIG          = 50
 	ICANON        = 51
 	XCASE         = 52
@@ -152,6 +158,12 @@ func (s *Session) SendRequest(name string, wantReply bool, payload []byte) (bool
 	return s.ch.SendRequest(name, wantReply, payload)
 }
 
+// SendRequestContext is like SendRequest, but stops waiting for the reply
+// and returns ctx.Err() when ctx is done.
+func (s *Session) SendRequestContext(ctx context.Context, name string, wantReply bool, payload []byte) (bool, error) {
+	return s.ch.SendRequestContext(ctx, name, wantReply, payload)
+}
+
 func (s *Session) Close() error {
 	return s.ch.Close()
 }
@@ -188,16 +200,7 @@ type ptyRequestMsg struct {
 
 // RequestPty requests the association of a pty with the session on the remote host.
 func (s *Session) RequestPty(term string, h, w int, termmodes TerminalModes) error {
-	var tm []byte
-	for k, v := range termmodes {
-		kv := struct {
-			Key byte
-			Val uint32
-		}{k, v}
-
-		tm = append(tm, Marshal(&kv)...)
-	}
-	tm = append(tm, tty_OP_END)
+	tm := termmodes.Marshal()
 	req := ptyRequestMsg{
 		Term:     term,
 		Columns:  uint32(w),
@@ -0,0 +1,264 @@
+// Copyright 2018 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package ssh
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net"
+	"sync"
+	"time"
+)
+
+// Default backoff bounds of a ReverseTunnel.
+const (
+	defaultMinBackoff = time.Second
+	defaultMaxBackoff = time.Minute
+)
+
+// keepAliveRequest is the global request sent by a ReverseTunnel to check
+// that the server is alive. Servers that do not know it reply with a
+// failure, which is enough.
+const keepAliveRequest = "keepalive@openssh.com"
+
+var (
+	errTunnelClosed    = errors.New("ssh: tunnel connection closed")
+	errKeepAliveFailed = errors.New("ssh: tunnel keepalive timed out")
+)
+
+// A RemoteForward is a listening socket opened on the server by a
+// ReverseTunnel, whose connections are forwarded to the client.
+type RemoteForward struct {
+	// Network and Addr are the arguments of Client.Listen.
+	Network, Addr string
+
+	// LocalNetwork and LocalAddr are dialed for every forwarded
+	// connection, which is then copied to and from the local connection.
+	LocalNetwork, LocalAddr string
+
+	// Handler, if not nil, is called in a new goroutine with every
+	// forwarded connection instead of dialing LocalAddr. It must close the
+	// connection.
+	Handler func(conn net.Conn)
+}
+
+// A ReverseTunnel keeps remote forwards open over a connection to a server,
+// reconnecting when the connection fails. This is how hosts behind NATs or
+// firewalls expose services through a bastion.
+//
+// When the connection cannot be established, or cannot open every forward,
+// or later fails, the tunnel waits before reconnecting. The wait starts at
+// MinBackoff and doubles after every failed attempt, up to MaxBackoff. It
+// is reset once every forward is open again.
+type ReverseTunnel struct {
+	// Dial connects to the server, for example with Dialer.DialContext.
+	Dial func(ctx context.Context) (*Client, error)
+
+	// Forwards are opened on every connection.
+	Forwards []RemoteForward
+
+	// MinBackoff and MaxBackoff bound the wait between connection
+	// attempts. If zero, they default to one second and one minute.
+	MinBackoff, MaxBackoff time.Duration
+
+	// KeepAliveInterval, if not zero, is the interval at which requests
+	// are sent to check that the server is alive. If a reply does not
+	// arrive within that interval, the connection is closed and the
+	// tunnel reconnects. Without keepalives, a silently dead connection
+	// is only noticed when the operating system gives up on it.
+	KeepAliveInterval time.Duration
+
+	// OnConnect, if not nil, is called once every forward is open on a new
+	// connection.
+	OnConnect func(c *Client)
+
+	// OnDisconnect, if not nil, is called with the error that ended a
+	// connection or a connection attempt, and the time until the next
+	// attempt.
+	OnDisconnect func(err error, retryIn time.Duration)
+}
+
+func (t *ReverseTunnel) minBackoff() time.Duration {
+	if t.MinBackoff > 0 {
+		return t.MinBackoff
+	}
+	return defaultMinBackoff
+}
+
+func (t *ReverseTunnel) maxBackoff() time.Duration {
+	if t.MaxBackoff > 0 {
+		return t.MaxBackoff
+	}
+	return defaultMaxBackoff
+}
+
+// Run connects to the server and serves the forwards, reconnecting as
+// needed, until ctx is done. It then closes the connection and returns
+// ctx.Err(). Forwarded connections in progress are not waited for.
+func (t *ReverseTunnel) Run(ctx context.Context) error {
+	backoff := t.minBackoff()
+	for {
+		c, err := t.Dial(ctx)
+		if err == nil {
+			var connected bool
+			connected, err = t.serve(ctx, c)
+			if connected {
+				backoff = t.minBackoff()
+			}
+		}
+		if ctx.Err() != nil {
+			return ctx.Err()
+		}
+		if t.OnDisconnect != nil {
+			t.OnDisconnect(err, backoff)
+		}
+
+		timer := time.NewTimer(backoff)
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return ctx.Err()
+		case <-timer.C:
+		}
+		if backoff *= 2; backoff > t.maxBackoff() {
+			backoff = t.maxBackoff()
+		}
+	}
+}
+
+// serve opens the forwards on c and serves them until c fails or ctx is
+// done, and closes c. It reports whether every forward was opened.
+func (t *ReverseTunnel) serve(ctx context.Context, c *Client) (connected bool, err error) {
+	listeners := make([]net.Listener, 0, len(t.Forwards))
+	var wg sync.WaitGroup
+	defer func() {
+		c.Close()
+		for _, l := range listeners {
+			l.Close()
+		}
+		wg.Wait()
+	}()
+
+	for _, f := range t.Forwards {
+		l, err := c.Listen(f.Network, f.Addr)
+		if err != nil {
+			return false, err
+		}
+		listeners = append(listeners, l)
+	}
+	if t.OnConnect != nil {
+		t.OnConnect(c)
+	}
+
+	for i, l := range listeners {
+		wg.Add(1)
+		go func(l net.Listener, f *RemoteForward) {
+			defer wg.Done()
+			acceptForwards(ctx, l, f)
+		}(l, &t.Forwards[i])
+	}
+	return true, t.wait(ctx, c)
+}
+
+// wait returns the error that ends c, or ctx.Err() if ctx is done first.
+func (t *ReverseTunnel) wait(ctx context.Context, c *Client) error {
+	closed := make(chan error, 1)
+	go func() {
+		closed <- c.Wait()
+	}()
+	var tick <-chan time.Time
+	if t.KeepAliveInterval > 0 {
+		ticker := time.NewTicker(t.KeepAliveInterval)
+		defer ticker.Stop()
+		tick = ticker.C
+	}
+	// At most one keepalive is outstanding: ticks are skipped until it is
+	// answered or times out.
+	keepAliveDone := make(chan error, 1)
+	keepAlivePending := false
+	for {
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case err := <-keepAliveDone:
+			if err != nil {
+				return err
+			}
+			keepAlivePending = false
+		case err := <-closed:
+			if err == nil || err == io.EOF {
+				err = errTunnelClosed
+			}
+			return err
+		case <-tick:
+			if keepAlivePending {
+				continue
+			}
+			keepAlivePending = true
+			go func() {
+				keepAliveDone <- keepAlive(ctx, c, t.KeepAliveInterval)
+			}()
+		}
+	}
+}
+
+// keepAlive sends a keepalive request on c and waits at most timeout for
+// the reply.
+func keepAlive(ctx context.Context, c *Client, timeout time.Duration) error {
+	ctx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+	_, _, err := c.SendRequestContext(ctx, keepAliveRequest, true, nil)
+	if err == context.DeadlineExceeded {
+		return errKeepAliveFailed
+	}
+	return err
+}
+
+// acceptForwards serves the connections forwarded by l until it is closed.
+func acceptForwards(ctx context.Context, l net.Listener, f *RemoteForward) {
+	for {
+		conn, err := l.Accept()
+		if err != nil {
+			return
+		}
+		if f.Handler != nil {
+			go f.Handler(conn)
+		} else {
+			go forwardLocal(ctx, conn, f.LocalNetwork, f.LocalAddr)
+		}
+	}
+}
+
+type closeWriter interface {
+	CloseWrite() error
+}
+
+// forwardLocal copies data between remote and a new connection to addr,
+// until both directions are done, and closes both connections.
+func forwardLocal(ctx context.Context, remote net.Conn, network, addr string) {
+	defer remote.Close()
+	var d net.Dialer
+	local, err := d.DialContext(ctx, network, addr)
+	if err != nil {
+		return
+	}
+	defer local.Close()
+
+	done := make(chan struct{}, 2)
+	copyHalf := func(dst, src net.Conn) {
+		io.Copy(dst, src)
+		if cw, ok := dst.(closeWriter); ok {
+			cw.CloseWrite()
+		} else {
+			dst.Close()
+		}
+		done <- struct{}{}
+	}
+	go copyHalf(local, remote)
+	go copyHalf(remote, local)
+	<-done
+	<-done
+}
@@ -0,0 +1,331 @@
+// Copyright 2018 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package ssh
+
+import (
+	"context"
+	"errors"
+	"io"
+	"io/ioutil"
+	"net"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+// tunnelServer is the server side of a connection made by a ReverseTunnel.
+type tunnelServer struct {
+	conn *ServerConn
+	// ports receives the ports allocated for the forwards of the client.
+	ports chan uint32
+}
+
+// dialTunnelServer returns a Dial function for a ReverseTunnel that
+// connects to a new in-memory server every time, and a channel receiving
+// those servers. If allow is false, the servers refuse every forward.
+func dialTunnelServer(t *testing.T, allow bool) (func(context.Context) (*Client, error), <-chan *tunnelServer) {
+	servers := make(chan *tunnelServer, 10)
+	nextPort := uint32(2000)
+	dial := func(ctx context.Context) (*Client, error) {
+		c1, c2, err := netPipe()
+		if err != nil {
+			return nil, err
+		}
+		serverConfig := &ServerConfig{NoClientAuth: true}
+		serverConfig.AddHostKey(testSigners["rsa"])
+		go func() {
+			conn, chans, reqs, err := NewServerConn(c1, serverConfig)
+			if err != nil {
+				t.Errorf("NewServerConn: %v", err)
+				return
+			}
+			go func() {
+				for ch := range chans {
+					ch.Reject(Prohibited, "")
+				}
+			}()
+			s := &tunnelServer{conn: conn, ports: make(chan uint32, 10)}
+			servers <- s
+			for req := range reqs {
+				if req.Type != "tcpip-forward" || !allow {
+					req.Reply(false, nil)
+					continue
+				}
+				nextPort++
+				req.Reply(true, Marshal(struct{ Port uint32 }{nextPort}))
+				s.ports <- nextPort
+			}
+		}()
+
+		clientConfig := &ClientConfig{
+			User:            "user",
+			HostKeyCallback: InsecureIgnoreHostKey(),
+		}
+		conn, chans, reqs, err := NewClientConn(c2, "", clientConfig)
+		if err != nil {
+			return nil, err
+		}
+		return NewClient(conn, chans, reqs), nil
+	}
+	return dial, servers
+}
+
+// open opens a forwarded connection to port on the client.
+func (s *tunnelServer) open(port uint32) (Channel, error) {
+	ch, reqs, err := s.conn.OpenChannel("forwarded-tcpip", Marshal(&forwardedTCPPayload{
+		Addr:       "127.0.0.1",
+		Port:       port,
+		OriginAddr: "192.0.2.1",
+		OriginPort: 1234,
+	}))
+	if err != nil {
+		return nil, err
+	}
+	go DiscardRequests(reqs)
+	return ch, nil
+}
+
+func echoConn(conn net.Conn) {
+	io.Copy(conn, conn)
+	conn.Close()
+}
+
+// roundTrip checks that a forwarded connection to port reaches an echo
+// server.
+func roundTrip(t *testing.T, s *tunnelServer, port uint32) {
+	t.Helper()
+	ch, err := s.open(port)
+	if err != nil {
+		t.Fatalf("OpenChannel: %v", err)
+	}
+	defer ch.Close()
+	msg := []byte("hello, tunnel")
+	if _, err := ch.Write(msg); err != nil {
+		t.Fatal(err)
+	}
+	ch.CloseWrite()
+	got, err := ioutil.ReadAll(ch)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(got) != string(msg) {
+		t.Fatalf("got %q, want %q", got, msg)
+	}
+}
+
+func TestReverseTunnelReconnect(t *testing.T) {
+	dial, servers := dialTunnelServer(t, true)
+	connected := make(chan *Client, 10)
+	disconnected := make(chan error, 10)
+	tun := &ReverseTunnel{
+		Dial:       dial,
+		Forwards:   []RemoteForward{{Network: "tcp", Addr: "127.0.0.1:0", Handler: echoConn}},
+		MinBackoff: time.Millisecond,
+		OnConnect:  func(c *Client) { connected <- c },
+		OnDisconnect: func(err error, retryIn time.Duration) {
+			if retryIn != time.Millisecond {
+				t.Errorf("retryIn = %v after a successful connection", retryIn)
+			}
+			disconnected <- err
+		},
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan error, 1)
+	go func() { done <- tun.Run(ctx) }()
+
+	for i := 0; i < 2; i++ {
+		s := <-servers
+		port := <-s.ports
+		<-connected
+		roundTrip(t, s, port)
+
+		// Dropping the connection makes the tunnel reconnect.
+		s.conn.Close()
+		if err := <-disconnected; err == nil {
+			t.Fatal("OnDisconnect called with a nil error")
+		}
+	}
+
+	s := <-servers
+	<-connected
+	cancel()
+	if err := <-done; err != context.Canceled {
+		t.Errorf("Run returned %v, want %v", err, context.Canceled)
+	}
+	if err := s.conn.Wait(); err == nil {
+		t.Error("connection still open after Run returned")
+	}
+}
+
+func TestReverseTunnelBackoff(t *testing.T) {
+	dial, _ := dialTunnelServer(t, false)
+	var delays []time.Duration
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	tun := &ReverseTunnel{
+		Dial:       dial,
+		Forwards:   []RemoteForward{{Network: "tcp", Addr: "127.0.0.1:0", Handler: echoConn}},
+		MinBackoff: time.Millisecond,
+		MaxBackoff: 4 * time.Millisecond,
+		OnConnect: func(*Client) {
+			t.Error("OnConnect called for a refused forward")
+		},
+		OnDisconnect: func(err error, retryIn time.Duration) {
+			if err == nil {
+				t.Error("OnDisconnect called with a nil error")
+			}
+			if delays = append(delays, retryIn); len(delays) == 5 {
+				cancel()
+			}
+		},
+	}
+	if err := tun.Run(ctx); err != context.Canceled {
+		t.Errorf("Run returned %v, want %v", err, context.Canceled)
+	}
+	want := []time.Duration{1, 2, 4, 4, 4}
+	for i := range want {
+		if delays[i] != want[i]*time.Millisecond {
+			t.Fatalf("delays = %v, want %v ms", delays, want)
+		}
+	}
+}
+
+func TestReverseTunnelDialError(t *testing.T) {
+	dialErr := errors.New("no route")
+	attempts := 0
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	tun := &ReverseTunnel{
+		Dial: func(context.Context) (*Client, error) {
+			attempts++
+			return nil, dialErr
+		},
+		MinBackoff: time.Millisecond,
+		OnDisconnect: func(err error, retryIn time.Duration) {
+			if err != dialErr {
+				t.Errorf("OnDisconnect got %v, want %v", err, dialErr)
+			}
+			if attempts == 3 {
+				cancel()
+			}
+		},
+	}
+	if err := tun.Run(ctx); err != context.Canceled {
+		t.Errorf("Run returned %v, want %v", err, context.Canceled)
+	}
+}
+
+func TestReverseTunnelLocal(t *testing.T) {
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Skipf("cannot listen on loopback: %v", err)
+	}
+	defer l.Close()
+	go func() {
+		for {
+			conn, err := l.Accept()
+			if err != nil {
+				return
+			}
+			go echoConn(conn)
+		}
+	}()
+
+	dial, servers := dialTunnelServer(t, true)
+	ctx, cancel := context.WithCancel(context.Background())
+	connected := make(chan *Client, 1)
+	tun := &ReverseTunnel{
+		Dial: dial,
+		Forwards: []RemoteForward{
+			{Network: "tcp", Addr: "127.0.0.1:0", Handler: echoConn},
+			{Network: "tcp", Addr: "127.0.0.1:0", LocalNetwork: "tcp", LocalAddr: l.Addr().String()},
+		},
+		OnConnect: func(c *Client) { connected <- c },
+	}
+	done := make(chan error, 1)
+	go func() { done <- tun.Run(ctx) }()
+
+	s := <-servers
+	<-s.ports
+	port := <-s.ports
+	<-connected
+	roundTrip(t, s, port)
+	cancel()
+	<-done
+}
+
+func TestReverseTunnelKeepAlive(t *testing.T) {
+	// A server that never answers requests looks dead.
+	c1, c2, err := netPipe()
+	if err != nil {
+		t.Fatal(err)
+	}
+	serverConfig := &ServerConfig{NoClientAuth: true}
+	serverConfig.AddHostKey(testSigners["rsa"])
+	go func() {
+		_, chans, reqs, err := NewServerConn(c1, serverConfig)
+		if err != nil {
+			return
+		}
+		go func() {
+			for range chans {
+			}
+		}()
+		for range reqs {
+		}
+	}()
+	conn, chans, reqs, err := NewClientConn(c2, "", &ClientConfig{User: "user", HostKeyCallback: InsecureIgnoreHostKey()})
+	if err != nil {
+		t.Fatal(err)
+	}
+	c := NewClient(conn, chans, reqs)
+
+	tun := &ReverseTunnel{KeepAliveInterval: 10 * time.Millisecond}
+	if err := tun.wait(context.Background(), c); err != errKeepAliveFailed {
+		t.Errorf("wait returned %v, want %v", err, errKeepAliveFailed)
+	}
+	c.Close()
+}
+
+func TestReverseTunnelKeepAliveAnswered(t *testing.T) {
+	// A server that answers requests keeps the tunnel up.
+	c1, c2, err := netPipe()
+	if err != nil {
+		t.Fatal(err)
+	}
+	serverConfig := &ServerConfig{NoClientAuth: true}
+	serverConfig.AddHostKey(testSigners["rsa"])
+	var requests int32
+	go func() {
+		_, chans, reqs, err := NewServerConn(c1, serverConfig)
+		if err != nil {
+			return
+		}
+		go func() {
+			for range chans {
+			}
+		}()
+		for req := range reqs {
+			atomic.AddInt32(&requests, 1)
+			req.Reply(false, nil)
+		}
+	}()
+	conn, chans, reqs, err := NewClientConn(c2, "", &ClientConfig{User: "user", HostKeyCallback: InsecureIgnoreHostKey()})
+	if err != nil {
+		t.Fatal(err)
+	}
+	c := NewClient(conn, chans, reqs)
+	defer c.Close()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 200*time.Millisecond)
+	defer cancel()
+	tun := &ReverseTunnel{KeepAliveInterval: 10 * time.Millisecond}
+	if err := tun.wait(ctx, c); err != context.DeadlineExceeded {
+		t.Errorf("wait returned %v, want %v", err, context.DeadlineExceeded)
+	}
+	if n := atomic.LoadInt32(&requests); n < 2 {
+		t.Errorf("server got %d keepalives, want several", n)
+	}
+}
@@ -0,0 +1,52 @@
+// Copyright 2018 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package subtle
+
+import (
+	"errors"
+
+	"golang.org/x/crypto/subtle/encoding"
+)
+
+// The decoders in this file are thin wrappers around the constant-time
+// encodings of golang.org/x/crypto/subtle/encoding.
+
+var (
+	errInvalidHex    = errors.New("subtle: invalid hex encoding")
+	errInvalidBase64 = errors.New("subtle: invalid base64 encoding")
+)
+
+// DecodeHexString returns the bytes represented by the hexadecimal string s,
+// in time that depends only on len(s). Both upper and lower case digits are
+// accepted.
+func DecodeHexString(s string) ([]byte, error) {
+	b, err := encoding.Hex.DecodeString(s)
+	if err != nil {
+		return nil, errInvalidHex
+	}
+	return b, nil
+}
+
+// DecodeBase64String returns the bytes represented by the padded, standard
+// base64 string s, as produced by encoding/base64.StdEncoding, in time that
+// depends only on len(s).
+func DecodeBase64String(s string) ([]byte, error) {
+	b, err := encoding.StdBase64.DecodeString(s)
+	if err != nil {
+		return nil, errInvalidBase64
+	}
+	return b, nil
+}
+
+// DecodeBase64URLString returns the bytes represented by the unpadded,
+// URL-safe base64 string s, as produced by encoding/base64.RawURLEncoding, in
+// time that depends only on len(s).
+func DecodeBase64URLString(s string) ([]byte, error) {
+	b, err := encoding.RawURLBase64.DecodeString(s)
+	if err != nil {
+		return nil, errInvalidBase64
+	}
+	return b, nil
+}
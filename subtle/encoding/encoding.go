@@ -0,0 +1,230 @@
+// Copyright 2018 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+// Package encoding implements constant-time hex, base64 and base32 encodings
+// for secret material such as private keys.
+//
+// The encoders and decoders in encoding/hex, encoding/base64 and
+// encoding/base32 map between characters and values with table lookups and
+// data-dependent branches, which can leak the data being processed through
+// cache and branch timing. The encodings in this package use arithmetic on
+// masks instead, so that they take time that depends only on the length of
+// their input.
+//
+// Decoding errors intentionally do not report the offset of the invalid
+// character. The amount of padding is treated as public, like the length.
+package encoding // import "golang.org/x/crypto/subtle/encoding"
+
+import (
+	"errors"
+)
+
+// charRange maps the count values starting at value to the count consecutive
+// characters starting at first.
+type charRange struct {
+	value, first, count byte
+}
+
+// An Encoding is a radix 16, 32 or 64 encoding scheme.
+type Encoding struct {
+	bits       uint // bits per character
+	blockChars int  // characters per padded block
+	padded     bool
+
+	ranges []charRange
+	// extra holds ranges that are accepted when decoding but never
+	// produced when encoding, such as upper case hex digits.
+	extra []charRange
+
+	err error
+}
+
+const padChar = '='
+
+var (
+	// Hex is the hexadecimal encoding of encoding/hex. It encodes to lower
+	// case, and accepts both upper and lower case when decoding.
+	Hex = &Encoding{
+		bits:       4,
+		blockChars: 2,
+		ranges:     []charRange{{0, '0', 10}, {10, 'a', 6}},
+		extra:      []charRange{{10, 'A', 6}},
+		err:        errors.New("encoding: invalid hex encoding"),
+	}
+
+	// StdBase64 is the standard, padded base64 encoding of RFC 4648, like
+	// encoding/base64.StdEncoding.
+	StdBase64 = newBase64('+', '/', true)
+	// RawStdBase64 is StdBase64 without padding.
+	RawStdBase64 = newBase64('+', '/', false)
+	// URLBase64 is the padded, URL-safe base64 encoding of RFC 4648, section
+	// 5, like encoding/base64.URLEncoding.
+	URLBase64 = newBase64('-', '_', true)
+	// RawURLBase64 is URLBase64 without padding.
+	RawURLBase64 = newBase64('-', '_', false)
+
+	// StdBase32 is the standard, padded base32 encoding of RFC 4648, like
+	// encoding/base32.StdEncoding.
+	StdBase32 = newBase32(true)
+	// RawStdBase32 is StdBase32 without padding.
+	RawStdBase32 = newBase32(false)
+)
+
+func newBase64(c62, c63 byte, padded bool) *Encoding {
+	return &Encoding{
+		bits:       6,
+		blockChars: 4,
+		padded:     padded,
+		ranges:     []charRange{{0, 'A', 26}, {26, 'a', 26}, {52, '0', 10}, {62, c62, 1}, {63, c63, 1}},
+		err:        errors.New("encoding: invalid base64 encoding"),
+	}
+}
+
+func newBase32(padded bool) *Encoding {
+	return &Encoding{
+		bits:       5,
+		blockChars: 8,
+		padded:     padded,
+		ranges:     []charRange{{0, 'A', 26}, {26, '2', 6}},
+		err:        errors.New("encoding: invalid base32 encoding"),
+	}
+}
+
+// inRange returns 0xff if lo <= c <= hi and 0 otherwise, without branching
+// on c.
+func inRange(c, lo, hi byte) byte {
+	return byte(((int32(lo) - 1 - int32(c)) & (int32(c) - int32(hi) - 1)) >> 8)
+}
+
+// char returns the character for the value v.
+func (e *Encoding) char(v byte) byte {
+	var c byte
+	for _, r := range e.ranges {
+		c |= inRange(v, r.value, r.value+r.count-1) & (v - r.value + r.first)
+	}
+	return c
+}
+
+// value returns the value of the character c and 0xff if c is valid, or an
+// unspecified value and 0 otherwise.
+func (e *Encoding) value(c byte) (value, valid byte) {
+	for _, r := range e.ranges {
+		m := inRange(c, r.first, r.first+r.count-1)
+		value |= m & (c - r.first + r.value)
+		valid |= m
+	}
+	for _, r := range e.extra {
+		m := inRange(c, r.first, r.first+r.count-1)
+		value |= m & (c - r.first + r.value)
+		valid |= m
+	}
+	return value, valid
+}
+
+// unpaddedLen returns the number of characters needed to encode n bytes,
+// without padding.
+func (e *Encoding) unpaddedLen(n int) int {
+	return (n*8 + int(e.bits) - 1) / int(e.bits)
+}
+
+// EncodedLen returns the length in bytes of the encoding of n source bytes.
+func (e *Encoding) EncodedLen(n int) int {
+	l := e.unpaddedLen(n)
+	if e.padded {
+		l = (l + e.blockChars - 1) / e.blockChars * e.blockChars
+	}
+	return l
+}
+
+// DecodedLen returns the maximum length in bytes of the decoded data
+// corresponding to n bytes of encoded data.
+func (e *Encoding) DecodedLen(n int) int {
+	return n * int(e.bits) / 8
+}
+
+// Encode encodes src into EncodedLen(len(src)) bytes of dst.
+func (e *Encoding) Encode(dst, src []byte) {
+	var acc uint32
+	var bits uint
+	mask := uint32(1)<<e.bits - 1
+	n := 0
+	for _, b := range src {
+		acc = acc<<8 | uint32(b)
+		bits += 8
+		for bits >= e.bits {
+			bits -= e.bits
+			dst[n] = e.char(byte(acc >> bits & mask))
+			n++
+		}
+	}
+	if bits > 0 {
+		dst[n] = e.char(byte(acc << (e.bits - bits) & mask))
+		n++
+	}
+	for ; n < e.EncodedLen(len(src)); n++ {
+		dst[n] = padChar
+	}
+}
+
+// EncodeToString returns the encoding of src.
+func (e *Encoding) EncodeToString(src []byte) string {
+	buf := make([]byte, e.EncodedLen(len(src)))
+	e.Encode(buf, src)
+	return string(buf)
+}
+
+// Decode decodes src into at most DecodedLen(len(src)) bytes of dst, and
+// returns the number of bytes written. Encodings that are not canonical,
+// because of missing or extra padding or non-zero trailing bits, are
+// rejected. On error, the contents of dst are unspecified.
+func (e *Encoding) Decode(dst, src []byte) (int, error) {
+	if e.padded {
+		if len(src)%e.blockChars != 0 {
+			return 0, e.err
+		}
+		pad := 0
+		for pad < e.blockChars-1 && pad < len(src) && src[len(src)-1-pad] == padChar {
+			pad++
+		}
+		src = src[:len(src)-pad]
+		if pad != e.EncodedLen(e.DecodedLen(len(src)))-len(src) {
+			return 0, e.err
+		}
+	}
+	if e.unpaddedLen(e.DecodedLen(len(src))) != len(src) {
+		return 0, e.err
+	}
+
+	valid := byte(0xff)
+	var acc uint32
+	var bits uint
+	n := 0
+	for _, c := range src {
+		v, ok := e.value(c)
+		valid &= ok
+		acc = acc<<e.bits | uint32(v)
+		bits += e.bits
+		if bits >= 8 {
+			bits -= 8
+			dst[n] = byte(acc >> bits)
+			n++
+		}
+	}
+	// The leftover bits must be zero for the encoding to be canonical.
+	valid &= byte(int32(acc&(1<<bits-1)-1) >> 8)
+	if valid != 0xff {
+		return 0, e.err
+	}
+	return n, nil
+}
+
+// DecodeString returns the bytes represented by s.
+func (e *Encoding) DecodeString(s string) ([]byte, error) {
+	buf := make([]byte, e.DecodedLen(len(s)))
+	n, err := e.Decode(buf, []byte(s))
+	if err != nil {
+		return nil, err
+	}
+	return buf[:n], nil
+}
@@ -0,0 +1,80 @@
+// Copyright 2018 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package encoding
+
+import (
+	"bytes"
+	"encoding/base32"
+	"encoding/base64"
+	"encoding/hex"
+	"math/rand"
+	"testing"
+)
+
+var stdEncodings = []struct {
+	name string
+	enc  *Encoding
+	std  func([]byte) string
+}{
+	{"Hex", Hex, hex.EncodeToString},
+	{"StdBase64", StdBase64, base64.StdEncoding.EncodeToString},
+	{"RawStdBase64", RawStdBase64, base64.RawStdEncoding.EncodeToString},
+	{"URLBase64", URLBase64, base64.URLEncoding.EncodeToString},
+	{"RawURLBase64", RawURLBase64, base64.RawURLEncoding.EncodeToString},
+	{"StdBase32", StdBase32, base32.StdEncoding.EncodeToString},
+	{"RawStdBase32", RawStdBase32, base32.StdEncoding.WithPadding(base32.NoPadding).EncodeToString},
+}
+
+func TestMatchesStandardLibrary(t *testing.T) {
+	rng := rand.New(rand.NewSource(1))
+	for _, tt := range stdEncodings {
+		for i := 0; i < 200; i++ {
+			b := make([]byte, rng.Intn(40))
+			rng.Read(b)
+
+			want := tt.std(b)
+			got := tt.enc.EncodeToString(b)
+			if got != want {
+				t.Fatalf("%s.EncodeToString(%x) = %q, want %q", tt.name, b, got, want)
+			}
+			if n := tt.enc.EncodedLen(len(b)); n != len(want) {
+				t.Fatalf("%s.EncodedLen(%d) = %d, want %d", tt.name, len(b), n, len(want))
+			}
+
+			dec, err := tt.enc.DecodeString(want)
+			if err != nil || !bytes.Equal(dec, b) {
+				t.Fatalf("%s.DecodeString(%q) = %x, %v; want %x", tt.name, want, dec, err, b)
+			}
+		}
+	}
+}
+
+func TestHexUpperCase(t *testing.T) {
+	got, err := Hex.DecodeString("DEADbeef")
+	if err != nil || !bytes.Equal(got, []byte{0xde, 0xad, 0xbe, 0xef}) {
+		t.Errorf("Hex.DecodeString(%q) = %x, %v", "DEADbeef", got, err)
+	}
+}
+
+func TestDecodeRejects(t *testing.T) {
+	tests := []struct {
+		enc  *Encoding
+		name string
+		in   []string
+	}{
+		{Hex, "Hex", []string{"0", "0g", "g0", "0x00", " 00", "/0", ":0", "@0", "G0", "`0"}},
+		{StdBase64, "StdBase64", []string{"A", "AA", "AA=", "AB==", "A===", "AA-_", "AA\n=", "====", "AAA=AAAA"}},
+		{RawURLBase64, "RawURLBase64", []string{"A", "AA==", "AA+/", "AB", "AAB"}},
+		{StdBase32, "StdBase32", []string{"AA", "AA=====", "AB======", "A=======", "AAA=====", "MY======\n", "my======", "18======"}},
+		{RawStdBase32, "RawStdBase32", []string{"A", "AAA", "AAAAAA", "AB", "MY=="}},
+	}
+	for _, tt := range tests {
+		for _, s := range tt.in {
+			if _, err := tt.enc.DecodeString(s); err == nil {
+				t.Errorf("%s.DecodeString(%q) succeeded, want error", tt.name, s)
+			}
+		}
+	}
+}
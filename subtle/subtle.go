@@ -0,0 +1,48 @@
+// Copyright 2018 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+// Package subtle implements functions that are often useful when handling
+// secret values such as API tokens, passwords and MAC tags, complementing
+// crypto/subtle.
+//
+// The functions in this package take time that depends on the lengths of
+// their inputs, but not on their contents. Lengths are treated as public.
+package subtle // import "golang.org/x/crypto/subtle"
+
+import (
+	"crypto/sha256"
+	"crypto/subtle"
+)
+
+// ConstantTimeCompareHashed returns 1 if x and y are equal and 0 otherwise.
+//
+// Unlike crypto/subtle.ConstantTimeCompare, x and y may have different
+// lengths, and comparing values of different lengths takes the same time as
+// comparing values of the same length. Both inputs are hashed with SHA-256
+// and the digests are compared in constant time, so the running time depends
+// only on the lengths of x and y, and not on where they differ.
+//
+// This is the recommended way to compare a user-provided credential with a
+// stored one.
+func ConstantTimeCompareHashed(x, y []byte) int {
+	hx := sha256.Sum256(x)
+	hy := sha256.Sum256(y)
+	return subtle.ConstantTimeCompare(hx[:], hy[:]) & subtle.ConstantTimeEq(int32(len(x)), int32(len(y)))
+}
+
+// ConstantTimeCompareStrings is like ConstantTimeCompareHashed, but for
+// strings.
+func ConstantTimeCompareStrings(x, y string) int {
+	return ConstantTimeCompareHashed([]byte(x), []byte(y))
+}
+
+// ConstantTimeHasPrefix returns 1 if s begins with prefix and 0 otherwise. The
+// contents of s[:len(prefix)] and prefix are examined in constant time; the
+// rest of s is not examined at all.
+func ConstantTimeHasPrefix(s, prefix []byte) int {
+	if len(s) < len(prefix) {
+		return 0
+	}
+	return subtle.ConstantTimeCompare(s[:len(prefix)], prefix)
+}
@@ -0,0 +1,104 @@
+// Copyright 2018 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package subtle
+
+import (
+	"bytes"
+	"encoding/base64"
+	"encoding/hex"
+	"math/rand"
+	"testing"
+)
+
+func TestConstantTimeCompareHashed(t *testing.T) {
+	tests := []struct {
+		x, y string
+		want int
+	}{
+		{"", "", 1},
+		{"secret", "secret", 1},
+		{"secret", "Secret", 0},
+		{"secret", "secret2", 0},
+		{"secret", "", 0},
+	}
+	for _, tt := range tests {
+		if got := ConstantTimeCompareHashed([]byte(tt.x), []byte(tt.y)); got != tt.want {
+			t.Errorf("ConstantTimeCompareHashed(%q, %q) = %d, want %d", tt.x, tt.y, got, tt.want)
+		}
+		if got := ConstantTimeCompareStrings(tt.x, tt.y); got != tt.want {
+			t.Errorf("ConstantTimeCompareStrings(%q, %q) = %d, want %d", tt.x, tt.y, got, tt.want)
+		}
+	}
+}
+
+func TestConstantTimeHasPrefix(t *testing.T) {
+	tests := []struct {
+		s, prefix string
+		want      int
+	}{
+		{"Bearer token", "Bearer ", 1},
+		{"bearer token", "Bearer ", 0},
+		{"Bear", "Bearer ", 0},
+		{"anything", "", 1},
+	}
+	for _, tt := range tests {
+		if got := ConstantTimeHasPrefix([]byte(tt.s), []byte(tt.prefix)); got != tt.want {
+			t.Errorf("ConstantTimeHasPrefix(%q, %q) = %d, want %d", tt.s, tt.prefix, got, tt.want)
+		}
+	}
+}
+
+func TestDecodeHexString(t *testing.T) {
+	rng := rand.New(rand.NewSource(1))
+	for i := 0; i < 100; i++ {
+		b := make([]byte, rng.Intn(40))
+		rng.Read(b)
+		s := hex.EncodeToString(b)
+		if i%2 == 1 {
+			s = string(bytes.ToUpper([]byte(s)))
+		}
+		got, err := DecodeHexString(s)
+		if err != nil || !bytes.Equal(got, b) {
+			t.Fatalf("DecodeHexString(%q) = %x, %v; want %x", s, got, err, b)
+		}
+	}
+
+	for _, s := range []string{"0", "0g", "g0", "0x00", " 00", "/0", ":0", "@0", "G0", "`0"} {
+		if _, err := DecodeHexString(s); err == nil {
+			t.Errorf("DecodeHexString(%q) succeeded, want error", s)
+		}
+	}
+}
+
+func TestDecodeBase64String(t *testing.T) {
+	rng := rand.New(rand.NewSource(1))
+	for i := 0; i < 100; i++ {
+		b := make([]byte, rng.Intn(40))
+		rng.Read(b)
+
+		s := base64.StdEncoding.EncodeToString(b)
+		got, err := DecodeBase64String(s)
+		if err != nil || !bytes.Equal(got, b) {
+			t.Fatalf("DecodeBase64String(%q) = %x, %v; want %x", s, got, err, b)
+		}
+
+		s = base64.RawURLEncoding.EncodeToString(b)
+		got, err = DecodeBase64URLString(s)
+		if err != nil || !bytes.Equal(got, b) {
+			t.Fatalf("DecodeBase64URLString(%q) = %x, %v; want %x", s, got, err, b)
+		}
+	}
+
+	for _, s := range []string{"A", "AA", "AA=", "AB==", "A===", "AA-_", "AA\n=", "====", "AAA=AAAA"} {
+		if _, err := DecodeBase64String(s); err == nil {
+			t.Errorf("DecodeBase64String(%q) succeeded, want error", s)
+		}
+	}
+	for _, s := range []string{"A", "AA==", "AA+/", "AB", "AAB"} {
+		if _, err := DecodeBase64URLString(s); err == nil {
+			t.Errorf("DecodeBase64URLString(%q) succeeded, want error", s)
+		}
+	}
+}
@@ -11,6 +11,8 @@ import (
 	"crypto/cipher"
 	"encoding/binary"
 	"errors"
+
+	"golang.org/x/crypto/policy"
 )
 
 const (
@@ -27,6 +29,8 @@ const (
 	numRounds = 64
 )
 
+var algorithm = policy.Register("tea", false)
+
 // tea is an instance of the TEA cipher with a particular key.
 type tea struct {
 	key    [16]byte
@@ -41,8 +45,11 @@ func NewCipher(key []byte) (cipher.Block, error) {
 
 // NewCipherWithRounds returns an instance of the TEA cipher with a given
 // number of rounds, which must be even. The key argument must be 16 bytes
-// long.
+// long. It returns a *policy.DisabledError if TEA is disabled.
 func NewCipherWithRounds(key []byte, rounds int) (cipher.Block, error) {
+	if err := algorithm.Check(); err != nil {
+		return nil, err
+	}
 	if len(key) != 16 {
 		return nil, errors.New("tea: incorrect key size")
 	}
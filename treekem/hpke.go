@@ -0,0 +1,180 @@
+// Copyright 2018 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package treekem
+
+import (
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/binary"
+	"errors"
+	"io"
+
+	"golang.org/x/crypto/chacha20poly1305"
+	"golang.org/x/crypto/curve25519"
+	"golang.org/x/crypto/hkdf"
+)
+
+// This file implements the base mode of HPKE (RFC 9180) with the
+// DHKEM(X25519, HKDF-SHA256) KEM, the HKDF-SHA256 KDF and the
+// ChaCha20-Poly1305 AEAD, which is all that the cipher suite of this package
+// needs. Only single-shot encryption is supported.
+
+const (
+	kemID  = 0x0020
+	kdfID  = 0x0001
+	aeadID = 0x0003
+
+	// hashSize is Nh, the output size of SHA-256.
+	hashSize = sha256.Size
+)
+
+var (
+	kemSuiteID  = []byte{'K', 'E', 'M', kemID >> 8, kemID & 0xff}
+	hpkeSuiteID = []byte{'H', 'P', 'K', 'E', kemID >> 8, kemID & 0xff, kdfID >> 8, kdfID & 0xff, aeadID >> 8, aeadID & 0xff}
+
+	errLowOrderPoint = errors.New("treekem: invalid X25519 public key")
+)
+
+// hkdfExpand returns length bytes of HKDF-Expand with SHA-256. length must
+// be at most 255 * hashSize.
+func hkdfExpand(prk, info []byte, length int) []byte {
+	out := make([]byte, length)
+	if _, err := io.ReadFull(hkdf.Expand(sha256.New, prk, info), out); err != nil {
+		panic("treekem: HKDF output too long")
+	}
+	return out
+}
+
+func labeledExtract(suiteID []byte, salt []byte, label string, ikm []byte) []byte {
+	labeled := make([]byte, 0, 7+len(suiteID)+len(label)+len(ikm))
+	labeled = append(labeled, "HPKE-v1"...)
+	labeled = append(labeled, suiteID...)
+	labeled = append(labeled, label...)
+	labeled = append(labeled, ikm...)
+	return hkdf.Extract(sha256.New, labeled, salt)
+}
+
+func labeledExpand(suiteID []byte, prk []byte, label string, info []byte, length int) []byte {
+	labeled := make([]byte, 2, 9+len(suiteID)+len(label)+len(info))
+	binary.BigEndian.PutUint16(labeled, uint16(length))
+	labeled = append(labeled, "HPKE-v1"...)
+	labeled = append(labeled, suiteID...)
+	labeled = append(labeled, label...)
+	labeled = append(labeled, info...)
+	return hkdfExpand(prk, labeled, length)
+}
+
+// deriveKeyPair implements DeriveKeyPair of DHKEM(X25519, HKDF-SHA256).
+func deriveKeyPair(ikm []byte) (priv, pub []byte) {
+	prk := labeledExtract(kemSuiteID, nil, "dkp_prk", ikm)
+	priv = labeledExpand(kemSuiteID, prk, "sk", nil, 32)
+	return priv, publicKey(priv)
+}
+
+// generateKeyPair returns a new key pair derived from 32 bytes read from rand.
+func generateKeyPair(rand io.Reader) (priv, pub []byte, err error) {
+	ikm := make([]byte, 32)
+	if _, err := io.ReadFull(rand, ikm); err != nil {
+		return nil, nil, err
+	}
+	priv, pub = deriveKeyPair(ikm)
+	return priv, pub, nil
+}
+
+func publicKey(priv []byte) []byte {
+	var dst, in [32]byte
+	copy(in[:], priv)
+	curve25519.ScalarBaseMult(&dst, &in)
+	return dst[:]
+}
+
+func dh(priv, pub []byte) ([]byte, error) {
+	if len(pub) != 32 {
+		return nil, errLowOrderPoint
+	}
+	var dst, in, base [32]byte
+	copy(in[:], priv)
+	copy(base[:], pub)
+	curve25519.ScalarMult(&dst, &in, &base)
+	var zero [32]byte
+	if subtle.ConstantTimeCompare(dst[:], zero[:]) == 1 {
+		return nil, errLowOrderPoint
+	}
+	return dst[:], nil
+}
+
+func extractAndExpand(dh, kemContext []byte) []byte {
+	prk := labeledExtract(kemSuiteID, nil, "eae_prk", dh)
+	return labeledExpand(kemSuiteID, prk, "shared_secret", kemContext, hashSize)
+}
+
+func encapWithKey(pkR, skE, pkE []byte) (sharedSecret, enc []byte, err error) {
+	secret, err := dh(skE, pkR)
+	if err != nil {
+		return nil, nil, err
+	}
+	kemContext := append(append([]byte{}, pkE...), pkR...)
+	return extractAndExpand(secret, kemContext), pkE, nil
+}
+
+func decap(enc, skR []byte) ([]byte, error) {
+	secret, err := dh(skR, enc)
+	if err != nil {
+		return nil, err
+	}
+	kemContext := append(append([]byte{}, enc...), publicKey(skR)...)
+	return extractAndExpand(secret, kemContext), nil
+}
+
+// keySchedule returns the key and base nonce of the base mode context for
+// sharedSecret and info.
+func keySchedule(sharedSecret, info []byte) (key, baseNonce []byte) {
+	pskIDHash := labeledExtract(hpkeSuiteID, nil, "psk_id_hash", nil)
+	infoHash := labeledExtract(hpkeSuiteID, nil, "info_hash", info)
+	context := append([]byte{0}, pskIDHash...)
+	context = append(context, infoHash...)
+
+	secret := labeledExtract(hpkeSuiteID, sharedSecret, "secret", nil)
+	key = labeledExpand(hpkeSuiteID, secret, "key", context, chacha20poly1305.KeySize)
+	baseNonce = labeledExpand(hpkeSuiteID, secret, "base_nonce", context, chacha20poly1305.NonceSize)
+	return key, baseNonce
+}
+
+// sealBase encrypts plaintext to pkR in a single-shot base mode context, and
+// returns the encapsulated key and the ciphertext.
+func sealBase(rand io.Reader, pkR, info, aad, plaintext []byte) (enc, ciphertext []byte, err error) {
+	skE, pkE, err := generateKeyPair(rand)
+	if err != nil {
+		return nil, nil, err
+	}
+	return sealBaseWithKey(pkR, skE, pkE, info, aad, plaintext)
+}
+
+func sealBaseWithKey(pkR, skE, pkE, info, aad, plaintext []byte) (enc, ciphertext []byte, err error) {
+	sharedSecret, enc, err := encapWithKey(pkR, skE, pkE)
+	if err != nil {
+		return nil, nil, err
+	}
+	key, nonce := keySchedule(sharedSecret, info)
+	aead, err := chacha20poly1305.New(key)
+	if err != nil {
+		return nil, nil, err
+	}
+	return enc, aead.Seal(nil, nonce, plaintext, aad), nil
+}
+
+// openBase decrypts a ciphertext produced by sealBase for the private key skR.
+func openBase(enc, skR, info, aad, ciphertext []byte) ([]byte, error) {
+	sharedSecret, err := decap(enc, skR)
+	if err != nil {
+		return nil, err
+	}
+	key, nonce := keySchedule(sharedSecret, info)
+	aead, err := chacha20poly1305.New(key)
+	if err != nil {
+		return nil, err
+	}
+	return aead.Open(nil, nonce, ciphertext, aad)
+}
@@ -0,0 +1,75 @@
+// Copyright 2018 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package treekem
+
+import (
+	"bytes"
+	"encoding/hex"
+	"testing"
+)
+
+func decodeHex(t *testing.T, s string) []byte {
+	b, err := hex.DecodeString(s)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return b
+}
+
+// TestHPKEVector checks the implementation against the test vector of RFC
+// 9180, Appendix A.2.1, for the first message.
+func TestHPKEVector(t *testing.T) {
+	info := decodeHex(t, "4f6465206f6e2061204772656369616e2055726e")
+	skE, pkE := deriveKeyPair(decodeHex(t, "909a9b35d3dc4713a5e72a4da274b55d3d3821a37e5d099e74a647db583a904b"))
+	if want := decodeHex(t, "1afa08d3dec047a643885163f1180476fa7ddb54c6a8029ea33f95796bf2ac4a"); !bytes.Equal(pkE, want) {
+		t.Errorf("pkEm = %x, want %x", pkE, want)
+	}
+	if want := decodeHex(t, "f4ec9b33b792c372c1d2c2063507b684ef925b8c75a42dbcbf57d63ccd381600"); !bytes.Equal(skE, want) {
+		t.Errorf("skEm = %x, want %x", skE, want)
+	}
+	skR, pkR := deriveKeyPair(decodeHex(t, "1ac01f181fdf9f352797655161c58b75c656a6cc2716dcb66372da835542e1df"))
+	if want := decodeHex(t, "4310ee97d88cc1f088a5576c77ab0cf5c3ac797f3d95139c6c84b5429c59662a"); !bytes.Equal(pkR, want) {
+		t.Errorf("pkRm = %x, want %x", pkR, want)
+	}
+
+	sharedSecret, _, err := encapWithKey(pkR, skE, pkE)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := decodeHex(t, "0bbe78490412b4bbea4812666f7916932b828bba79942424abb65244930d69a7"); !bytes.Equal(sharedSecret, want) {
+		t.Errorf("shared_secret = %x, want %x", sharedSecret, want)
+	}
+	key, nonce := keySchedule(sharedSecret, info)
+	if want := decodeHex(t, "ad2744de8e17f4ebba575b3f5f5a8fa1f69c2a07f6e7500bc60ca6e3e3ec1c91"); !bytes.Equal(key, want) {
+		t.Errorf("key = %x, want %x", key, want)
+	}
+	if want := decodeHex(t, "5c4d98150661b848853b547f"); !bytes.Equal(nonce, want) {
+		t.Errorf("base_nonce = %x, want %x", nonce, want)
+	}
+
+	pt := decodeHex(t, "4265617574792069732074727574682c20747275746820626561757479")
+	aad := decodeHex(t, "436f756e742d30")
+	enc, ct, err := sealBaseWithKey(pkR, skE, pkE, info, aad, pt)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := decodeHex(t, "1c5250d8034ec2b784ba2cfd69dbdb8af406cfe3ff938e131f0def8c8b60b4db21993c62ce81883d2dd1b51a28"); !bytes.Equal(ct, want) {
+		t.Errorf("ct = %x, want %x", ct, want)
+	}
+	got, err := openBase(enc, skR, info, aad, ct)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(got, pt) {
+		t.Errorf("openBase = %x, want %x", got, pt)
+	}
+}
+
+func TestHPKELowOrder(t *testing.T) {
+	skR, _ := deriveKeyPair([]byte("receiver"))
+	if _, err := openBase(make([]byte, 32), skR, nil, nil, make([]byte, 16)); err != errLowOrderPoint {
+		t.Errorf("openBase with a zero encapsulated key: got %v, want %v", err, errLowOrderPoint)
+	}
+}
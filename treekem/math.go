@@ -0,0 +1,100 @@
+// Copyright 2018 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package treekem
+
+// The tree is stored as an array in which leaves have even indices and
+// parents odd ones, so that node x is at level trailing_ones(x) and an
+// in-order traversal visits the nodes in array order (RFC 9420, Appendix C).
+// The number of leaves is always a power of two.
+
+// level returns the height of node x above the leaves.
+func level(x uint32) uint {
+	k := uint(0)
+	for x&1 == 1 {
+		x >>= 1
+		k++
+	}
+	return k
+}
+
+// nodeWidth returns the number of nodes in a tree with n leaves.
+func nodeWidth(n uint32) uint32 {
+	if n == 0 {
+		return 0
+	}
+	return 2*n - 1
+}
+
+// root returns the root of a tree with n leaves.
+func root(n uint32) uint32 {
+	return n - 1
+}
+
+func left(x uint32) uint32 {
+	k := level(x)
+	if k == 0 {
+		panic("treekem: leaf node has no children")
+	}
+	return x ^ 1<<(k-1)
+}
+
+func right(x uint32) uint32 {
+	k := level(x)
+	if k == 0 {
+		panic("treekem: leaf node has no children")
+	}
+	return x ^ 3<<(k-1)
+}
+
+// parent returns the parent of x, which must not be the root.
+func parent(x uint32) uint32 {
+	k := level(x)
+	b := (x >> (k + 1)) & 1
+	return (x | 1<<k) ^ b<<(k+1)
+}
+
+// sibling returns the other child of the parent of x.
+func sibling(x uint32) uint32 {
+	p := parent(x)
+	if x < p {
+		return right(p)
+	}
+	return left(p)
+}
+
+// directPath returns the ancestors of x in a tree with n leaves, from the
+// parent of x to the root.
+func directPath(x, n uint32) []uint32 {
+	r := root(n)
+	var d []uint32
+	for x != r {
+		x = parent(x)
+		d = append(d, x)
+	}
+	return d
+}
+
+// copath returns the siblings of x and of its ancestors but the root, in the
+// order of directPath.
+func copath(x, n uint32) []uint32 {
+	r := root(n)
+	var c []uint32
+	for x != r {
+		c = append(c, sibling(x))
+		x = parent(x)
+	}
+	return c
+}
+
+// isAncestor reports whether a is x or an ancestor of x.
+func isAncestor(a, x uint32) bool {
+	span := uint32(1)<<level(a) - 1
+	return a-span <= x && x <= a+span
+}
+
+// leafNode returns the node index of leaf l.
+func leafNode(l LeafIndex) uint32 {
+	return 2 * uint32(l)
+}
@@ -0,0 +1,149 @@
+// Copyright 2018 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package treekem
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestTreeMath(t *testing.T) {
+	// A tree with eight leaves:
+	//
+	//                              X
+	//              X                               X
+	//      X               X               X               X
+	//  X       X       X       X       X       X       X       X
+	//  0   1   2   3   4   5   6   7   8   9  10  11  12  13  14
+	if r := root(8); r != 7 {
+		t.Errorf("root(8) = %d, want 7", r)
+	}
+	if w := nodeWidth(8); w != 15 {
+		t.Errorf("nodeWidth(8) = %d, want 15", w)
+	}
+	for _, tt := range []struct {
+		x, level, left, right, parent, sibling uint32
+	}{
+		{x: 0, level: 0, parent: 1, sibling: 2},
+		{x: 6, level: 0, parent: 5, sibling: 4},
+		{x: 1, level: 1, left: 0, right: 2, parent: 3, sibling: 5},
+		{x: 9, level: 1, left: 8, right: 10, parent: 11, sibling: 13},
+		{x: 11, level: 2, left: 9, right: 13, parent: 7, sibling: 3},
+		{x: 7, level: 3, left: 3, right: 11},
+	} {
+		if l := level(tt.x); uint32(l) != tt.level {
+			t.Errorf("level(%d) = %d, want %d", tt.x, l, tt.level)
+		}
+		if tt.level > 0 {
+			if l := left(tt.x); l != tt.left {
+				t.Errorf("left(%d) = %d, want %d", tt.x, l, tt.left)
+			}
+			if r := right(tt.x); r != tt.right {
+				t.Errorf("right(%d) = %d, want %d", tt.x, r, tt.right)
+			}
+		}
+		if tt.x != 7 {
+			if p := parent(tt.x); p != tt.parent {
+				t.Errorf("parent(%d) = %d, want %d", tt.x, p, tt.parent)
+			}
+			if s := sibling(tt.x); s != tt.sibling {
+				t.Errorf("sibling(%d) = %d, want %d", tt.x, s, tt.sibling)
+			}
+		}
+	}
+
+	if d := directPath(4, 8); !reflect.DeepEqual(d, []uint32{5, 3, 7}) {
+		t.Errorf("directPath(4, 8) = %v, want [5 3 7]", d)
+	}
+	if c := copath(4, 8); !reflect.DeepEqual(c, []uint32{6, 1, 11}) {
+		t.Errorf("copath(4, 8) = %v, want [6 1 11]", c)
+	}
+	if d := directPath(0, 1); len(d) != 0 {
+		t.Errorf("directPath(0, 1) = %v, want none", d)
+	}
+
+	for a := uint32(0); a < 15; a++ {
+		for x := uint32(0); x < 15; x++ {
+			want := a == x
+			for _, p := range directPath(x, 8) {
+				want = want || p == a
+			}
+			if got := isAncestor(a, x); got != want {
+				t.Errorf("isAncestor(%d, %d) = %v, want %v", a, x, got, want)
+			}
+		}
+	}
+}
+
+// none marks the nodes that have no left, right, parent or sibling.
+const none = ^uint32(0)
+
+// treeMathTests are the tree-math test vectors of the MLS interoperability
+// suite, for trees whose number of leaves is a power of two, computed with
+// the reference code of RFC 9420, Appendix C.
+var treeMathTests = []struct {
+	leaves, nodes, root          uint32
+	left, right, parent, sibling []uint32
+}{
+	{
+		leaves: 1, nodes: 1, root: 0,
+		left:    []uint32{none},
+		right:   []uint32{none},
+		parent:  []uint32{none},
+		sibling: []uint32{none},
+	},
+	{
+		leaves: 2, nodes: 3, root: 1,
+		left:    []uint32{none, 0, none},
+		right:   []uint32{none, 2, none},
+		parent:  []uint32{1, none, 1},
+		sibling: []uint32{2, none, 0},
+	},
+	{
+		leaves: 4, nodes: 7, root: 3,
+		left:    []uint32{none, 0, none, 1, none, 4, none},
+		right:   []uint32{none, 2, none, 5, none, 6, none},
+		parent:  []uint32{1, 3, 1, none, 5, 3, 5},
+		sibling: []uint32{2, 5, 0, none, 6, 1, 4},
+	},
+	{
+		leaves: 8, nodes: 15, root: 7,
+		left:    []uint32{none, 0, none, 1, none, 4, none, 3, none, 8, none, 9, none, 12, none},
+		right:   []uint32{none, 2, none, 5, none, 6, none, 11, none, 10, none, 13, none, 14, none},
+		parent:  []uint32{1, 3, 1, 7, 5, 3, 5, none, 9, 11, 9, 7, 13, 11, 13},
+		sibling: []uint32{2, 5, 0, 11, 6, 1, 4, none, 10, 13, 8, 3, 14, 9, 12},
+	},
+	{
+		leaves: 16, nodes: 31, root: 15,
+		left:    []uint32{none, 0, none, 1, none, 4, none, 3, none, 8, none, 9, none, 12, none, 7, none, 16, none, 17, none, 20, none, 19, none, 24, none, 25, none, 28, none},
+		right:   []uint32{none, 2, none, 5, none, 6, none, 11, none, 10, none, 13, none, 14, none, 23, none, 18, none, 21, none, 22, none, 27, none, 26, none, 29, none, 30, none},
+		parent:  []uint32{1, 3, 1, 7, 5, 3, 5, 15, 9, 11, 9, 7, 13, 11, 13, none, 17, 19, 17, 23, 21, 19, 21, 15, 25, 27, 25, 23, 29, 27, 29},
+		sibling: []uint32{2, 5, 0, 11, 6, 1, 4, 23, 10, 13, 8, 3, 14, 9, 12, none, 18, 21, 16, 27, 22, 17, 20, 7, 26, 29, 24, 19, 30, 25, 28},
+	},
+}
+
+func TestTreeMathVectors(t *testing.T) {
+	for _, tt := range treeMathTests {
+		if w := nodeWidth(tt.leaves); w != tt.nodes {
+			t.Errorf("nodeWidth(%d) = %d, want %d", tt.leaves, w, tt.nodes)
+		}
+		if r := root(tt.leaves); r != tt.root {
+			t.Errorf("root(%d) = %d, want %d", tt.leaves, r, tt.root)
+		}
+		for x := uint32(0); x < tt.nodes; x++ {
+			l, r, p, s := none, none, none, none
+			if level(x) > 0 {
+				l, r = left(x), right(x)
+			}
+			if x != tt.root {
+				p, s = parent(x), sibling(x)
+			}
+			if l != tt.left[x] || r != tt.right[x] || p != tt.parent[x] || s != tt.sibling[x] {
+				t.Errorf("%d leaves, node %d: left, right, parent, sibling = %d, %d, %d, %d, want %d, %d, %d, %d",
+					tt.leaves, x, l, r, p, s, tt.left[x], tt.right[x], tt.parent[x], tt.sibling[x])
+			}
+		}
+	}
+}
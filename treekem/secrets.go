@@ -0,0 +1,60 @@
+// Copyright 2018 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package treekem
+
+import "io"
+
+// ExpandWithLabel derives length bytes from secret, as defined in RFC 9420,
+// section 8: HKDF-Expand with the serialized KDFLabel, whose label is "MLS
+// 1.0 " followed by label, as info.
+func ExpandWithLabel(secret []byte, label string, context []byte, length int) []byte {
+	info := []byte{byte(length >> 8), byte(length)}
+	info = appendOpaque(info, []byte("MLS 1.0 "+label))
+	info = appendOpaque(info, context)
+	return hkdfExpand(secret, info, length)
+}
+
+// DeriveSecret derives a secret of size SecretSize from secret and label.
+func DeriveSecret(secret []byte, label string) []byte {
+	return ExpandWithLabel(secret, label, nil, SecretSize)
+}
+
+// DeriveNodeKeyPair derives the HPKE key pair of a node from its path secret.
+func DeriveNodeKeyPair(pathSecret []byte) (privateKey, publicKey []byte) {
+	return deriveKeyPair(DeriveSecret(pathSecret, "node"))
+}
+
+// encryptContext returns the serialized EncryptContext used as HPKE info by
+// EncryptWithLabel.
+func encryptContext(label string, context []byte) []byte {
+	info := appendOpaque(nil, []byte("MLS 1.0 "+label))
+	return appendOpaque(info, context)
+}
+
+// encryptWithLabel implements EncryptWithLabel of RFC 9420, section 5.1.3.
+func encryptWithLabel(rand io.Reader, publicKey []byte, label string, context, plaintext []byte) (kemOutput, ciphertext []byte, err error) {
+	return sealBase(rand, publicKey, encryptContext(label, context), nil, plaintext)
+}
+
+// decryptWithLabel implements DecryptWithLabel of RFC 9420, section 5.1.3.
+func decryptWithLabel(privateKey []byte, label string, context, kemOutput, ciphertext []byte) ([]byte, error) {
+	return openBase(kemOutput, privateKey, encryptContext(label, context), nil, ciphertext)
+}
+
+// appendOpaque appends v prefixed with its length encoded as a variable-size
+// integer, as in the opaque<V> vectors of RFC 9420, section 2.1.2.
+func appendOpaque(b, v []byte) []byte {
+	switch n := len(v); {
+	case n < 1<<6:
+		b = append(b, byte(n))
+	case n < 1<<14:
+		b = append(b, 0x40|byte(n>>8), byte(n))
+	case n < 1<<30:
+		b = append(b, 0x80|byte(n>>24), byte(n>>16), byte(n>>8), byte(n))
+	default:
+		panic("treekem: vector too long")
+	}
+	return append(b, v...)
+}
@@ -0,0 +1,529 @@
+// Copyright 2018 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+// Package treekem implements TreeKEM, the ratchet tree at the core of the
+// Messaging Layer Security protocol (MLS), as specified in RFC 9420.
+//
+// A ratchet tree is a binary tree whose leaves are the members of a group.
+// Every non-blank node holds an HPKE public key, and each member knows the
+// private keys of the nodes between its leaf and the root. To change the
+// group secret, a member commits: it generates a chain of path secrets along
+// its direct path, derives a new key pair for every node from them, and
+// encrypts each path secret to the subtree on the other side of the node.
+// Every other member can then decrypt exactly one path secret, derive the rest
+// of the chain, and obtain the same commit secret as the committer.
+//
+// This package provides the cryptographic operations on the tree: the
+// derivation of node keys from path secrets, the generation and processing of
+// update paths, and the maintenance of the tree as members are added and
+// removed. Serializing messages, authenticating them, and computing tree
+// hashes, parent hashes and the group context are left to the protocol using
+// the tree.
+//
+// The cipher suite is fixed to the primitives of
+// MLS_128_DHKEMX25519_CHACHA20POLY1305_SHA256_Ed25519: HPKE with
+// DHKEM(X25519, HKDF-SHA256) and ChaCha20-Poly1305, and SHA-256 as the hash
+// function.
+package treekem // import "golang.org/x/crypto/treekem"
+
+import (
+	"bytes"
+	"crypto/rand"
+	"errors"
+	"io"
+)
+
+const (
+	// SecretSize is the size, in bytes, of path secrets and commit secrets.
+	SecretSize = hashSize
+	// PublicKeySize is the size, in bytes, of node public keys.
+	PublicKeySize = 32
+	// PrivateKeySize is the size, in bytes, of node private keys.
+	PrivateKeySize = 32
+)
+
+var (
+	errBadPublicKey   = errors.New("treekem: invalid public key")
+	errBadPrivateKey  = errors.New("treekem: private key does not match the leaf")
+	errBadTree        = errors.New("treekem: malformed tree")
+	errBadLeaf        = errors.New("treekem: leaf out of range or blank")
+	errOwnLeaf        = errors.New("treekem: operation not allowed on own leaf")
+	errBadUpdatePath  = errors.New("treekem: update path does not match the tree")
+	errNotRecipient   = errors.New("treekem: no path secret encrypted to this member")
+	errKeyMismatch    = errors.New("treekem: derived public key does not match the tree")
+	errNoCommonParent = errors.New("treekem: no common ancestor in the committer's path")
+	errNoPathSecret   = errors.New("treekem: no path secret for the leaf from the last commit")
+)
+
+// A LeafIndex identifies a leaf of the tree, counting from zero.
+type LeafIndex uint32
+
+// A Node is the public state of a node of the tree.
+type Node struct {
+	// PublicKey is the HPKE public key of the node, or nil if the node is
+	// blank.
+	PublicKey []byte
+	// UnmergedLeaves lists the leaves below a parent node that were added
+	// after the key of the node was set, and therefore do not know its
+	// private key.
+	UnmergedLeaves []LeafIndex
+}
+
+func (n Node) clone() Node {
+	return Node{
+		PublicKey:      append([]byte(nil), n.PublicKey...),
+		UnmergedLeaves: append([]LeafIndex(nil), n.UnmergedLeaves...),
+	}
+}
+
+func (n *Node) blank() bool {
+	return n.PublicKey == nil
+}
+
+// An HPKECiphertext is a path secret encrypted to one node.
+type HPKECiphertext struct {
+	KEMOutput  []byte
+	Ciphertext []byte
+}
+
+// An UpdatePathNode carries the new public key of a node on the path of a
+// committer, and its path secret encrypted to each node of the resolution of
+// the node's child that is not on the path.
+type UpdatePathNode struct {
+	PublicKey           []byte
+	EncryptedPathSecret []HPKECiphertext
+}
+
+// An UpdatePath is produced by a committing member. It holds the new public
+// key of the committer's leaf and of every node of its filtered direct path,
+// from the leaf towards the root.
+type UpdatePath struct {
+	LeafKey []byte
+	Nodes   []UpdatePathNode
+}
+
+// A Tree is the ratchet tree of a group, as seen by one of its members. It
+// must not be used concurrently.
+type Tree struct {
+	nodes []Node
+	own   LeafIndex
+	// privateKeys holds the private keys known to the member, indexed by
+	// node.
+	privateKeys map[uint32][]byte
+	// pathSecrets holds the path secrets of the last commit by the member,
+	// indexed by node, until the tree changes.
+	pathSecrets map[uint32][]byte
+}
+
+// New returns a tree with a single leaf, that of the calling member, with a
+// new key pair. If rand is nil, crypto/rand.Reader is used.
+func New(rand io.Reader) (*Tree, error) {
+	rand = randReader(rand)
+	priv, pub, err := generateKeyPair(rand)
+	if err != nil {
+		return nil, err
+	}
+	return &Tree{
+		nodes:       []Node{{PublicKey: pub}},
+		privateKeys: map[uint32][]byte{0: priv},
+	}, nil
+}
+
+// Join returns the tree of a member joining a group, given the public nodes
+// of the group's tree as returned by Nodes, the index of the member's leaf
+// and the private key of that leaf. The private keys of parent nodes are
+// obtained with ApplyPathSecret.
+func Join(nodes []Node, own LeafIndex, leafPrivateKey []byte) (*Tree, error) {
+	width := uint32(len(nodes))
+	if width%2 != 1 || (width+1)/2&((width+1)/2-1) != 0 {
+		return nil, errBadTree
+	}
+	t := &Tree{
+		nodes:       make([]Node, len(nodes)),
+		own:         own,
+		privateKeys: make(map[uint32][]byte),
+	}
+	for i := range nodes {
+		if !nodes[i].blank() && len(nodes[i].PublicKey) != PublicKeySize {
+			return nil, errBadPublicKey
+		}
+		t.nodes[i] = nodes[i].clone()
+	}
+	x := leafNode(own)
+	if x >= width || t.nodes[x].blank() {
+		return nil, errBadLeaf
+	}
+	if len(leafPrivateKey) != PrivateKeySize || !bytes.Equal(publicKey(leafPrivateKey), t.nodes[x].PublicKey) {
+		return nil, errBadPrivateKey
+	}
+	t.privateKeys[x] = append([]byte(nil), leafPrivateKey...)
+	return t, nil
+}
+
+// Nodes returns a copy of the public state of the tree, in array order: leaf
+// i is at index 2*i, and parent nodes are between their children.
+func (t *Tree) Nodes() []Node {
+	nodes := make([]Node, len(t.nodes))
+	for i := range t.nodes {
+		nodes[i] = t.nodes[i].clone()
+	}
+	return nodes
+}
+
+// NumLeaves returns the number of leaves of the tree, including blank ones.
+// It is always a power of two.
+func (t *Tree) NumLeaves() int {
+	return (len(t.nodes) + 1) / 2
+}
+
+// Own returns the leaf of the member holding the tree.
+func (t *Tree) Own() LeafIndex {
+	return t.own
+}
+
+func (t *Tree) leaves() uint32 {
+	return uint32(t.NumLeaves())
+}
+
+// AddLeaf adds a member with the given leaf public key in the leftmost blank
+// leaf, extending the tree if there is none, and returns its index.
+func (t *Tree) AddLeaf(publicKey []byte) (LeafIndex, error) {
+	if len(publicKey) != PublicKeySize {
+		return 0, errBadPublicKey
+	}
+	n := t.leaves()
+	l := LeafIndex(0)
+	for ; uint32(l) < n; l++ {
+		if t.nodes[leafNode(l)].blank() {
+			break
+		}
+	}
+	if uint32(l) == n {
+		t.nodes = append(t.nodes, make([]Node, nodeWidth(2*n)-nodeWidth(n))...)
+		n *= 2
+	}
+
+	t.pathSecrets = nil
+	x := leafNode(l)
+	t.nodes[x] = Node{PublicKey: append([]byte(nil), publicKey...)}
+	for _, p := range directPath(x, n) {
+		if !t.nodes[p].blank() {
+			t.nodes[p].UnmergedLeaves = append(t.nodes[p].UnmergedLeaves, l)
+		}
+	}
+	return l, nil
+}
+
+// RemoveLeaf removes the member at leaf l, blanking its leaf and direct path,
+// and truncates the tree if its right half becomes empty.
+func (t *Tree) RemoveLeaf(l LeafIndex) error {
+	if l == t.own {
+		return errOwnLeaf
+	}
+	n := t.leaves()
+	x := leafNode(l)
+	if uint32(l) >= n || t.nodes[x].blank() {
+		return errBadLeaf
+	}
+	t.pathSecrets = nil
+	t.blank(x)
+	for _, p := range directPath(x, n) {
+		t.blank(p)
+	}
+
+	for n > 1 {
+		empty := true
+		for i := n / 2; i < n; i++ {
+			if !t.nodes[leafNode(LeafIndex(i))].blank() {
+				empty = false
+				break
+			}
+		}
+		if !empty {
+			break
+		}
+		n /= 2
+		for x := nodeWidth(n); x < uint32(len(t.nodes)); x++ {
+			delete(t.privateKeys, x)
+		}
+		t.nodes = t.nodes[:nodeWidth(n)]
+	}
+	return nil
+}
+
+func (t *Tree) blank(x uint32) {
+	t.nodes[x] = Node{}
+	delete(t.privateKeys, x)
+}
+
+// resolution returns the nodes that cover the subtree rooted at x: x itself
+// and its unmerged leaves if it is not blank, or else the resolutions of its
+// children.
+func (t *Tree) resolution(x uint32) []uint32 {
+	if !t.nodes[x].blank() {
+		res := []uint32{x}
+		for _, l := range t.nodes[x].UnmergedLeaves {
+			res = append(res, leafNode(l))
+		}
+		return res
+	}
+	if level(x) == 0 {
+		return nil
+	}
+	return append(t.resolution(left(x)), t.resolution(right(x))...)
+}
+
+// filteredDirectPath returns the nodes of the direct path of leaf node x
+// whose child on the copath has a non-empty resolution, along with those
+// children.
+func (t *Tree) filteredDirectPath(x uint32) (path, copathNodes []uint32) {
+	n := t.leaves()
+	cp := copath(x, n)
+	for i, p := range directPath(x, n) {
+		if len(t.resolution(cp[i])) > 0 {
+			path = append(path, p)
+			copathNodes = append(copathNodes, cp[i])
+		}
+	}
+	return path, copathNodes
+}
+
+// recipients returns the resolution of x without the leaves in exclude.
+func (t *Tree) recipients(x uint32, exclude []LeafIndex) []uint32 {
+	var res []uint32
+next:
+	for _, r := range t.resolution(x) {
+		for _, l := range exclude {
+			if r == leafNode(l) {
+				continue next
+			}
+		}
+		res = append(res, r)
+	}
+	return res
+}
+
+// Commit generates new keys for the member's leaf and direct path, and
+// returns the update path that lets the other members learn them and the
+// resulting commit secret. The tree is updated with the new keys.
+//
+// groupContext is the serialized group context the path secrets are bound
+// to, and exclude lists the leaves that must not be sent path secrets, such
+// as members being added by the same commit. If rand is nil,
+// crypto/rand.Reader is used.
+func (t *Tree) Commit(rand io.Reader, groupContext []byte, exclude []LeafIndex) (*UpdatePath, []byte, error) {
+	rand = randReader(rand)
+	x := leafNode(t.own)
+	leafPriv, leafPub, err := generateKeyPair(rand)
+	if err != nil {
+		return nil, nil, err
+	}
+	pathSecret := make([]byte, SecretSize)
+	if _, err := io.ReadFull(rand, pathSecret); err != nil {
+		return nil, nil, err
+	}
+
+	path, copathNodes := t.filteredDirectPath(x)
+	up := &UpdatePath{LeafKey: leafPub, Nodes: make([]UpdatePathNode, len(path))}
+	privs := make([][]byte, len(path))
+	secrets := make(map[uint32][]byte, len(path))
+	for i, p := range path {
+		secrets[p] = pathSecret
+		var pub []byte
+		privs[i], pub = DeriveNodeKeyPair(pathSecret)
+		up.Nodes[i].PublicKey = pub
+		for _, r := range t.recipients(copathNodes[i], exclude) {
+			kemOutput, ct, err := encryptWithLabel(rand, t.nodes[r].PublicKey, "UpdatePathNode", groupContext, pathSecret)
+			if err != nil {
+				return nil, nil, err
+			}
+			up.Nodes[i].EncryptedPathSecret = append(up.Nodes[i].EncryptedPathSecret, HPKECiphertext{kemOutput, ct})
+		}
+		pathSecret = DeriveSecret(pathSecret, "path")
+	}
+
+	t.applyUpdatePath(x, path, up)
+	t.privateKeys[x] = leafPriv
+	for i, p := range path {
+		t.privateKeys[p] = privs[i]
+	}
+	t.pathSecrets = secrets
+	return up, pathSecret, nil
+}
+
+// PathSecretFor returns the path secret, from the last commit of the member,
+// of the lowest common ancestor of the member and leaf l. It is sent to
+// members excluded from the commit, who pass it to ApplyPathSecret. It
+// returns an error if the tree changed since the commit.
+func (t *Tree) PathSecretFor(l LeafIndex) ([]byte, error) {
+	if l == t.own {
+		return nil, errOwnLeaf
+	}
+	x := leafNode(l)
+	if uint32(l) >= t.leaves() || t.nodes[x].blank() {
+		return nil, errBadLeaf
+	}
+	path, copathNodes := t.filteredDirectPath(leafNode(t.own))
+	for i, c := range copathNodes {
+		if isAncestor(c, x) {
+			if secret, ok := t.pathSecrets[path[i]]; ok {
+				return append([]byte(nil), secret...), nil
+			}
+			break
+		}
+	}
+	return nil, errNoPathSecret
+}
+
+// Merge processes an update path produced by Commit at leaf sender, with the
+// same groupContext and exclude arguments. It updates the tree with the new
+// keys and returns the commit secret.
+//
+// If the member is excluded from the commit, it must instead obtain the
+// path secret out of band, apply the update path with the other members, and
+// call ApplyPathSecret.
+func (t *Tree) Merge(sender LeafIndex, up *UpdatePath, groupContext []byte, exclude []LeafIndex) ([]byte, error) {
+	if sender == t.own {
+		return nil, errOwnLeaf
+	}
+	x := leafNode(sender)
+	if uint32(sender) >= t.leaves() || t.nodes[x].blank() {
+		return nil, errBadLeaf
+	}
+	path, copathNodes := t.filteredDirectPath(x)
+	if len(up.LeafKey) != PublicKeySize || len(up.Nodes) != len(path) {
+		return nil, errBadUpdatePath
+	}
+	for i := range up.Nodes {
+		if len(up.Nodes[i].PublicKey) != PublicKeySize {
+			return nil, errBadUpdatePath
+		}
+	}
+	for _, l := range exclude {
+		if l == t.own {
+			return nil, errNotRecipient
+		}
+	}
+
+	// Find the node of the path above the member, and decrypt its path
+	// secret with a private key from the member's side of the tree.
+	own := leafNode(t.own)
+	start := -1
+	for i, c := range copathNodes {
+		if isAncestor(c, own) {
+			start = i
+			break
+		}
+	}
+	if start == -1 {
+		return nil, errNotRecipient
+	}
+	recipients := t.recipients(copathNodes[start], exclude)
+	cts := up.Nodes[start].EncryptedPathSecret
+	if len(cts) != len(recipients) {
+		return nil, errBadUpdatePath
+	}
+	var pathSecret []byte
+	for i, r := range recipients {
+		priv, ok := t.privateKeys[r]
+		if !ok {
+			continue
+		}
+		var err error
+		pathSecret, err = decryptWithLabel(priv, "UpdatePathNode", groupContext, cts[i].KEMOutput, cts[i].Ciphertext)
+		if err != nil {
+			return nil, err
+		}
+		break
+	}
+	if pathSecret == nil {
+		return nil, errNotRecipient
+	}
+
+	privs, commitSecret, err := derivePath(pathSecret, len(path)-start, func(i int) []byte {
+		return up.Nodes[start+i].PublicKey
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	t.pathSecrets = nil
+	t.applyUpdatePath(x, path, up)
+	for i, p := range path[start:] {
+		t.privateKeys[p] = privs[i]
+	}
+	return commitSecret, nil
+}
+
+// ApplyPathSecret sets the private keys of the nodes shared by the member and
+// the committer at leaf committer, from the path secret of their lowest
+// common ancestor. It is used by members that joined the group with the
+// commit, after their tree was updated with the committer's public keys.
+func (t *Tree) ApplyPathSecret(committer LeafIndex, pathSecret []byte) error {
+	if committer == t.own {
+		return errOwnLeaf
+	}
+	x := leafNode(committer)
+	if uint32(committer) >= t.leaves() || t.nodes[x].blank() {
+		return errBadLeaf
+	}
+	path, copathNodes := t.filteredDirectPath(x)
+	own := leafNode(t.own)
+	start := -1
+	for i, c := range copathNodes {
+		if isAncestor(c, own) {
+			start = i
+			break
+		}
+	}
+	if start == -1 {
+		return errNoCommonParent
+	}
+	privs, _, err := derivePath(pathSecret, len(path)-start, func(i int) []byte {
+		return t.nodes[path[start+i]].PublicKey
+	})
+	if err != nil {
+		return err
+	}
+	for i, p := range path[start:] {
+		t.privateKeys[p] = privs[i]
+	}
+	return nil
+}
+
+// derivePath derives the private keys of a chain of n nodes from the path
+// secret of the first one, checking them against the public keys returned
+// by publicKey, and returns them with the commit secret.
+func derivePath(pathSecret []byte, n int, publicKey func(i int) []byte) (privs [][]byte, commitSecret []byte, err error) {
+	privs = make([][]byte, n)
+	for i := 0; i < n; i++ {
+		priv, pub := DeriveNodeKeyPair(pathSecret)
+		if !bytes.Equal(pub, publicKey(i)) {
+			return nil, nil, errKeyMismatch
+		}
+		privs[i] = priv
+		pathSecret = DeriveSecret(pathSecret, "path")
+	}
+	return privs, pathSecret, nil
+}
+
+// applyUpdatePath blanks the direct path of leaf node x and sets the keys
+// of x and of path from up.
+func (t *Tree) applyUpdatePath(x uint32, path []uint32, up *UpdatePath) {
+	for _, p := range directPath(x, t.leaves()) {
+		t.blank(p)
+	}
+	t.blank(x)
+	t.nodes[x].PublicKey = append([]byte(nil), up.LeafKey...)
+	for i, p := range path {
+		t.nodes[p].PublicKey = append([]byte(nil), up.Nodes[i].PublicKey...)
+	}
+}
+
+func randReader(r io.Reader) io.Reader {
+	if r == nil {
+		return rand.Reader
+	}
+	return r
+}
@@ -0,0 +1,263 @@
+// Copyright 2018 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package treekem
+
+import (
+	"bytes"
+	mathrand "math/rand"
+	"reflect"
+	"testing"
+)
+
+func TestAppendOpaque(t *testing.T) {
+	for _, tt := range []struct {
+		n      int
+		prefix []byte
+	}{
+		{0, []byte{0x00}},
+		{63, []byte{0x3f}},
+		{64, []byte{0x40, 0x40}},
+		{16383, []byte{0x7f, 0xff}},
+		{16384, []byte{0x80, 0x00, 0x40, 0x00}},
+	} {
+		got := appendOpaque(nil, make([]byte, tt.n))
+		if !bytes.Equal(got[:len(tt.prefix)], tt.prefix) || len(got) != len(tt.prefix)+tt.n {
+			t.Errorf("length %d encoded with prefix %x, want %x", tt.n, got[:len(tt.prefix)], tt.prefix)
+		}
+	}
+}
+
+// group simulates the members of a group, each with its own view of the
+// tree. Removed members are nil.
+type group struct {
+	t       *testing.T
+	members []*Tree
+	context []byte
+}
+
+func newGroup(t *testing.T) *group {
+	tree, err := New(nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return &group{t: t, members: []*Tree{tree}, context: []byte("epoch 0")}
+}
+
+// commit makes member c commit, after adding the given number of new
+// members, and checks that all the members agree on the result.
+func (g *group) commit(c LeafIndex, adds int) {
+	g.t.Helper()
+	var joiners []LeafIndex
+	var joinerKeys [][]byte
+	for i := 0; i < adds; i++ {
+		priv, pub, err := generateKeyPair(randReader(nil))
+		if err != nil {
+			g.t.Fatal(err)
+		}
+		var l LeafIndex
+		for _, m := range g.members {
+			if m == nil {
+				continue
+			}
+			if l, err = m.AddLeaf(pub); err != nil {
+				g.t.Fatal(err)
+			}
+		}
+		joiners = append(joiners, l)
+		joinerKeys = append(joinerKeys, priv)
+	}
+
+	up, commitSecret, err := g.members[c].Commit(nil, g.context, joiners)
+	if err != nil {
+		g.t.Fatal(err)
+	}
+	for i, m := range g.members {
+		if m == nil || LeafIndex(i) == c || isJoiner(LeafIndex(i), joiners) {
+			continue
+		}
+		secret, err := m.Merge(c, up, g.context, joiners)
+		if err != nil {
+			g.t.Fatalf("member %d merging commit from %d: %v", i, c, err)
+		}
+		if !bytes.Equal(secret, commitSecret) {
+			g.t.Errorf("member %d derived commit secret %x, want %x", i, secret, commitSecret)
+		}
+	}
+
+	nodes := g.members[c].Nodes()
+	for i, l := range joiners {
+		tree, err := Join(nodes, l, joinerKeys[i])
+		if err != nil {
+			g.t.Fatal(err)
+		}
+		secret, err := g.members[c].PathSecretFor(l)
+		if err != nil {
+			g.t.Fatal(err)
+		}
+		if err := tree.ApplyPathSecret(c, secret); err != nil {
+			g.t.Fatalf("joiner %d applying path secret: %v", l, err)
+		}
+		for int(l) >= len(g.members) {
+			g.members = append(g.members, nil)
+		}
+		g.members[l] = tree
+	}
+	g.check()
+	g.context = append(g.context, '+')
+}
+
+func isJoiner(l LeafIndex, joiners []LeafIndex) bool {
+	for _, j := range joiners {
+		if j == l {
+			return true
+		}
+	}
+	return false
+}
+
+func (g *group) live() []LeafIndex {
+	var live []LeafIndex
+	for i, m := range g.members {
+		if m != nil {
+			live = append(live, LeafIndex(i))
+		}
+	}
+	return live
+}
+
+func (g *group) remove(r LeafIndex) {
+	g.t.Helper()
+	for i, m := range g.members {
+		if m == nil || LeafIndex(i) == r {
+			continue
+		}
+		if err := m.RemoveLeaf(r); err != nil {
+			g.t.Fatal(err)
+		}
+	}
+	g.members[r] = nil
+}
+
+// check verifies that all the members have the same public tree, and that
+// each member holds exactly the private keys of its non-blank leaf and
+// ancestors that it is not unmerged at.
+func (g *group) check() {
+	g.t.Helper()
+	var nodes []Node
+	for i, m := range g.members {
+		if m == nil {
+			continue
+		}
+		if nodes == nil {
+			nodes = m.Nodes()
+		} else if !reflect.DeepEqual(m.Nodes(), nodes) {
+			g.t.Fatalf("member %d has a different tree", i)
+		}
+
+		x := leafNode(m.own)
+		for _, p := range append([]uint32{x}, directPath(x, m.leaves())...) {
+			_, known := m.privateKeys[p]
+			want := !m.nodes[p].blank() && !isJoiner(m.own, m.nodes[p].UnmergedLeaves)
+			if known != want {
+				g.t.Fatalf("member %d knows private key of node %d: %v, want %v", i, p, known, want)
+			}
+			if known && !bytes.Equal(publicKey(m.privateKeys[p]), m.nodes[p].PublicKey) {
+				g.t.Fatalf("member %d has a wrong private key for node %d", i, p)
+			}
+		}
+	}
+}
+
+func TestGroup(t *testing.T) {
+	g := newGroup(t)
+	g.commit(0, 1)
+	g.commit(1, 2)
+	g.commit(3, 0)
+	g.commit(0, 3)
+	if n := g.members[0].NumLeaves(); n != 8 {
+		t.Errorf("tree has %d leaves, want 8", n)
+	}
+
+	// Removing members blanks their paths, and the tree shrinks once its
+	// right half is empty.
+	g.remove(6)
+	g.remove(2)
+	g.commit(5, 0)
+	g.remove(4)
+	g.remove(5)
+	g.commit(1, 0)
+	if n := g.members[0].NumLeaves(); n != 4 {
+		t.Errorf("tree has %d leaves, want 4", n)
+	}
+
+	// Blank leaves are filled before the tree is extended.
+	g.commit(3, 1)
+	if g.members[2] == nil || g.members[0].NumLeaves() != 4 {
+		t.Errorf("new member not added in the blank leaf")
+	}
+	g.commit(2, 1)
+	if n := g.members[0].NumLeaves(); n != 8 {
+		t.Errorf("tree has %d leaves, want 8", n)
+	}
+}
+
+func TestRandomGroup(t *testing.T) {
+	rng := mathrand.New(mathrand.NewSource(1))
+	g := newGroup(t)
+	for round := 0; round < 40; round++ {
+		if live := g.live(); len(live) > 2 && rng.Intn(3) == 0 {
+			g.remove(live[rng.Intn(len(live))])
+		}
+		live := g.live()
+		g.commit(live[rng.Intn(len(live))], rng.Intn(3))
+	}
+}
+
+func TestRemovedMember(t *testing.T) {
+	g := newGroup(t)
+	g.commit(0, 3)
+	removed := g.members[2]
+	g.remove(2)
+	up, _, err := g.members[0].Commit(nil, g.context, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := removed.Merge(0, up, g.context, nil); err == nil {
+		t.Errorf("removed member processed a commit")
+	}
+}
+
+func TestMergeErrors(t *testing.T) {
+	g := newGroup(t)
+	g.commit(0, 3)
+	up, _, err := g.members[0].Commit(nil, g.context, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// A wrong group context makes decryption fail.
+	if _, err := g.members[1].Merge(0, up, []byte("wrong"), nil); err == nil {
+		t.Errorf("commit accepted with the wrong group context")
+	}
+	// A public key that does not match the path secrets is detected.
+	bad := *up
+	bad.Nodes = append([]UpdatePathNode(nil), up.Nodes...)
+	bad.Nodes[1].PublicKey = up.LeafKey
+	if _, err := g.members[2].Merge(0, &bad, g.context, nil); err != errKeyMismatch {
+		t.Errorf("Merge with a wrong public key: got %v, want %v", err, errKeyMismatch)
+	}
+	if _, err := g.members[1].Merge(0, &UpdatePath{LeafKey: up.LeafKey}, g.context, nil); err != errBadUpdatePath {
+		t.Errorf("Merge with a short path: got %v, want %v", err, errBadUpdatePath)
+	}
+	if _, err := g.members[1].Merge(1, up, g.context, nil); err != errOwnLeaf {
+		t.Errorf("Merge of own commit: got %v, want %v", err, errOwnLeaf)
+	}
+	// Failed merges leave the tree unchanged.
+	for _, i := range []int{1, 2} {
+		if _, err := g.members[i].Merge(0, up, g.context, nil); err != nil {
+			t.Errorf("member %d: %v", i, err)
+		}
+	}
+}
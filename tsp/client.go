@@ -0,0 +1,66 @@
+// Copyright 2018 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package tsp
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+)
+
+// maxResponseSize bounds the size of the responses read by Fetch. Tokens
+// are a few kilobytes, or a few tens with a long certificate chain.
+const maxResponseSize = 1 << 20
+
+// Fetch sends req to the TSA at url over HTTP, as described in RFC 3161,
+// Section 3.4, and returns the token of its response once checked against
+// req with CheckRequest. The token must still be verified with Verify.
+//
+// If client is nil, http.DefaultClient is used.
+func Fetch(ctx context.Context, client *http.Client, url string, req *Request) (*Token, error) {
+	if client == nil {
+		client = http.DefaultClient
+	}
+	body, err := req.Marshal()
+	if err != nil {
+		return nil, err
+	}
+	httpReq, err := http.NewRequest("POST", url, bytes.NewReader(body))
+	if err != nil {
+		return nil, err
+	}
+	httpReq.Header.Set("Content-Type", "application/timestamp-query")
+	res, err := client.Do(httpReq.WithContext(ctx))
+	if err != nil {
+		return nil, err
+	}
+	defer res.Body.Close()
+
+	if res.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("tsp: unexpected HTTP status %q from TSA", res.Status)
+	}
+	if ct := res.Header.Get("Content-Type"); ct != "application/timestamp-reply" {
+		return nil, fmt.Errorf("tsp: unexpected content type %q from TSA", ct)
+	}
+	der, err := ioutil.ReadAll(io.LimitReader(res.Body, maxResponseSize+1))
+	if err != nil {
+		return nil, err
+	}
+	if len(der) > maxResponseSize {
+		return nil, ParseError("tsp: time-stamp response too large")
+	}
+
+	t, err := ParseResponse(der)
+	if err != nil {
+		return nil, err
+	}
+	if err := t.CheckRequest(req); err != nil {
+		return nil, err
+	}
+	return t, nil
+}
@@ -0,0 +1,517 @@
+// Copyright 2018 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+// Package tsp implements the client side of the Time-Stamp Protocol, as
+// specified in RFC 3161. A time-stamping authority (TSA) returns signed tokens
+// attesting that a hash of some data existed at a given time.
+//
+// Time-stamping a signature, rather than the data it signs, lets the
+// signature be checked after the signing key has expired or been revoked:
+// the token shows that the signature was made while the key was still valid.
+//
+// A client creates a Request with NewRequest, sends it to the TSA, for example
+// with Fetch, and checks the token in the response with Token.CheckRequest
+// and Token.Verify.
+package tsp // import "golang.org/x/crypto/tsp"
+
+import (
+	"bytes"
+	"crypto"
+	"crypto/rand"
+	_ "crypto/sha1"
+	_ "crypto/sha256"
+	_ "crypto/sha512"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/asn1"
+	"errors"
+	"io"
+	"math/big"
+	"strconv"
+	"time"
+)
+
+var (
+	oidSignedData = asn1.ObjectIdentifier{1, 2, 840, 113549, 1, 7, 2}
+	oidTSTInfo    = asn1.ObjectIdentifier{1, 2, 840, 113549, 1, 9, 16, 1, 4}
+)
+
+var hashOIDs = map[crypto.Hash]asn1.ObjectIdentifier{
+	crypto.SHA1:   asn1.ObjectIdentifier([]int{1, 3, 14, 3, 2, 26}),
+	crypto.SHA256: asn1.ObjectIdentifier([]int{2, 16, 840, 1, 101, 3, 4, 2, 1}),
+	crypto.SHA384: asn1.ObjectIdentifier([]int{2, 16, 840, 1, 101, 3, 4, 2, 2}),
+	crypto.SHA512: asn1.ObjectIdentifier([]int{2, 16, 840, 1, 101, 3, 4, 2, 3}),
+}
+
+func getHashAlgorithmFromOID(target asn1.ObjectIdentifier) crypto.Hash {
+	for hash, oid := range hashOIDs {
+		if oid.Equal(target) {
+			return hash
+		}
+	}
+	return crypto.Hash(0)
+}
+
+// These are internal structures that reflect the ASN.1 structure of
+// time-stamp requests and responses. See RFC 3161, Section 2.4.
+
+type messageImprint struct {
+	HashAlgorithm pkix.AlgorithmIdentifier
+	HashedMessage []byte
+}
+
+type timeStampReq struct {
+	Version        int
+	MessageImprint messageImprint
+	ReqPolicy      asn1.ObjectIdentifier `asn1:"optional"`
+	Nonce          *big.Int              `asn1:"optional"`
+	CertReq        bool                  `asn1:"optional"`
+	Extensions     []pkix.Extension      `asn1:"optional,tag:0"`
+}
+
+type timeStampResp struct {
+	Status         pkiStatusInfo
+	TimeStampToken asn1.RawValue `asn1:"optional"`
+}
+
+type pkiStatusInfo struct {
+	Status       int
+	StatusString []string       `asn1:"optional"`
+	FailInfo     asn1.BitString `asn1:"optional"`
+}
+
+type tstInfo struct {
+	Version        int
+	Policy         asn1.ObjectIdentifier
+	MessageImprint messageImprint
+	SerialNumber   *big.Int
+	GenTime        asn1.RawValue
+	Accuracy       accuracy         `asn1:"optional"`
+	Ordering       bool             `asn1:"optional"`
+	Nonce          *big.Int         `asn1:"optional"`
+	TSA            asn1.RawValue    `asn1:"explicit,optional,tag:0"`
+	Extensions     []pkix.Extension `asn1:"optional,tag:1"`
+}
+
+type accuracy struct {
+	Seconds int `asn1:"optional"`
+	Millis  int `asn1:"optional,tag:0"`
+	Micros  int `asn1:"optional,tag:1"`
+}
+
+// Status is the status of a time-stamp response. See RFC 3161, Section 2.4.2.
+type Status int
+
+const (
+	Granted                Status = 0
+	GrantedWithMods        Status = 1
+	Rejection              Status = 2
+	Waiting                Status = 3
+	RevocationWarning      Status = 4
+	RevocationNotification Status = 5
+)
+
+func (s Status) String() string {
+	switch s {
+	case Granted:
+		return "granted"
+	case GrantedWithMods:
+		return "granted with modifications"
+	case Rejection:
+		return "rejection"
+	case Waiting:
+		return "waiting"
+	case RevocationWarning:
+		return "revocation warning"
+	case RevocationNotification:
+		return "revocation notification"
+	default:
+		return "unknown time-stamp status: " + strconv.Itoa(int(s))
+	}
+}
+
+// FailureInfo is a set of reasons for which a TSA rejected a request, each of
+// them a bit. See RFC 3161, Section 2.4.2.
+type FailureInfo int
+
+const (
+	BadAlgorithm        FailureInfo = 1 << 0
+	BadRequest          FailureInfo = 1 << 2
+	BadDataFormat       FailureInfo = 1 << 5
+	TimeNotAvailable    FailureInfo = 1 << 14
+	UnacceptedPolicy    FailureInfo = 1 << 15
+	UnacceptedExtension FailureInfo = 1 << 16
+	AddInfoNotAvailable FailureInfo = 1 << 17
+	SystemFailure       FailureInfo = 1 << 25
+)
+
+// failureInfoBits is the highest bit of PKIFailureInfo that is parsed.
+const failureInfoBits = 26
+
+// ResponseError is returned by ParseResponse when the TSA did not grant the
+// request.
+type ResponseError struct {
+	Status      Status
+	FailureInfo FailureInfo
+	// StatusString holds the explanations given by the TSA, if any.
+	StatusString []string
+}
+
+func (r ResponseError) Error() string {
+	s := "tsp: error from server: " + r.Status.String()
+	if len(r.StatusString) > 0 {
+		s += ": " + r.StatusString[0]
+	}
+	return s
+}
+
+// ParseError results from an invalid time-stamp request, response or token.
+type ParseError string
+
+func (p ParseError) Error() string {
+	return string(p)
+}
+
+// Request represents a time-stamp request. See RFC 3161, Section 2.4.1.
+type Request struct {
+	// HashAlgorithm is the hash function with which HashedMessage was
+	// computed.
+	HashAlgorithm crypto.Hash
+	HashedMessage []byte
+
+	// Policy, if not nil, is the TSA policy under which the token should be
+	// issued.
+	Policy asn1.ObjectIdentifier
+	// Nonce, if not nil, is a random number that the TSA must include in
+	// the token, to bind it to this request.
+	Nonce *big.Int
+	// CertReq asks the TSA to include its certificate in the token.
+	CertReq bool
+
+	Extensions []pkix.Extension
+}
+
+// Marshal marshals the time-stamp request to ASN.1 DER encoded form.
+func (req *Request) Marshal() ([]byte, error) {
+	hashOID, ok := hashOIDs[req.HashAlgorithm]
+	if !ok {
+		return nil, x509.ErrUnsupportedAlgorithm
+	}
+	if len(req.HashedMessage) != req.HashAlgorithm.Size() {
+		return nil, errors.New("tsp: hashed message has the wrong length")
+	}
+	return asn1.Marshal(timeStampReq{
+		Version: 1,
+		MessageImprint: messageImprint{
+			HashAlgorithm: pkix.AlgorithmIdentifier{
+				Algorithm:  hashOID,
+				Parameters: asn1.RawValue{Tag: 5 /* ASN.1 NULL */},
+			},
+			HashedMessage: req.HashedMessage,
+		},
+		ReqPolicy:  req.Policy,
+		Nonce:      req.Nonce,
+		CertReq:    req.CertReq,
+		Extensions: req.Extensions,
+	})
+}
+
+// ParseRequest parses a time-stamp request in DER form.
+func ParseRequest(bytes []byte) (*Request, error) {
+	var req timeStampReq
+	rest, err := asn1.Unmarshal(bytes, &req)
+	if err != nil {
+		return nil, err
+	}
+	if len(rest) > 0 {
+		return nil, ParseError("tsp: trailing data in time-stamp request")
+	}
+	if req.Version != 1 {
+		return nil, ParseError("tsp: unsupported time-stamp request version")
+	}
+	hashFunc, err := parseMessageImprint(&req.MessageImprint)
+	if err != nil {
+		return nil, err
+	}
+	return &Request{
+		HashAlgorithm: hashFunc,
+		HashedMessage: req.MessageImprint.HashedMessage,
+		Policy:        req.ReqPolicy,
+		Nonce:         req.Nonce,
+		CertReq:       req.CertReq,
+		Extensions:    req.Extensions,
+	}, nil
+}
+
+func parseMessageImprint(mi *messageImprint) (crypto.Hash, error) {
+	hashFunc := getHashAlgorithmFromOID(mi.HashAlgorithm.Algorithm)
+	if hashFunc == crypto.Hash(0) {
+		return 0, ParseError("tsp: message imprint uses unknown hash function")
+	}
+	if len(mi.HashedMessage) != hashFunc.Size() {
+		return 0, ParseError("tsp: message imprint has the wrong length")
+	}
+	return hashFunc, nil
+}
+
+// RequestOptions contains options for constructing time-stamp requests.
+type RequestOptions struct {
+	// Hash contains the hash function that should be used to hash the
+	// message. If zero, SHA-256 will be used.
+	Hash crypto.Hash
+
+	// Policy, if not nil, is the TSA policy to request.
+	Policy asn1.ObjectIdentifier
+
+	// Certificates asks the TSA to include its certificate in the token,
+	// so that it can be verified without fetching the certificate
+	// separately.
+	Certificates bool
+}
+
+func (opts *RequestOptions) hash() crypto.Hash {
+	if opts == nil || opts.Hash == 0 {
+		return crypto.SHA256
+	}
+	return opts.Hash
+}
+
+// nonceBytes is the size of the random nonces of requests.
+const nonceBytes = 16
+
+// NewRequest returns a request for a token over the data read from message,
+// with a random nonce. If opts is nil then sensible defaults are used.
+func NewRequest(message io.Reader, opts *RequestOptions) (*Request, error) {
+	hashFunc := opts.hash()
+	if _, ok := hashOIDs[hashFunc]; !ok || !hashFunc.Available() {
+		return nil, x509.ErrUnsupportedAlgorithm
+	}
+	h := hashFunc.New()
+	if _, err := io.Copy(h, message); err != nil {
+		return nil, err
+	}
+
+	nonce := make([]byte, nonceBytes)
+	if _, err := io.ReadFull(rand.Reader, nonce); err != nil {
+		return nil, err
+	}
+
+	req := &Request{
+		HashAlgorithm: hashFunc,
+		HashedMessage: h.Sum(nil),
+		Nonce:         new(big.Int).SetBytes(nonce),
+	}
+	if opts != nil {
+		req.Policy = opts.Policy
+		req.CertReq = opts.Certificates
+	}
+	return req, nil
+}
+
+// Token is a time-stamp token, a CMS SignedData structure whose content
+// describes what was time-stamped, and when. See RFC 3161, Section 2.4.2.
+//
+// The fields of a parsed Token are only trustworthy once Verify succeeds.
+type Token struct {
+	// Raw contains the complete DER encoded token.
+	Raw []byte
+
+	Policy        asn1.ObjectIdentifier
+	HashAlgorithm crypto.Hash
+	HashedMessage []byte
+	SerialNumber  *big.Int
+	// Time is when the TSA created the token, give or take Accuracy if it
+	// is not zero.
+	Time     time.Time
+	Accuracy time.Duration
+	// Ordering is true if tokens from this TSA can be ordered by Time even
+	// when their times are within Accuracy of each other.
+	Ordering bool
+	Nonce    *big.Int
+	// RawTSAName optionally contains the DER-encoded GeneralName of the
+	// TSA.
+	RawTSAName []byte
+	Extensions []pkix.Extension
+
+	// Certificates are the certificates included in the token, which
+	// usually include that of the TSA.
+	Certificates []*x509.Certificate
+
+	signedData *signedData
+	signer     *signerInfo
+}
+
+// ParseResponse parses a time-stamp response in DER form and returns the
+// token it contains, which must still be checked with CheckRequest and
+// verified with Verify.
+//
+// Invalid responses and parse failures will result in a ParseError. Responses
+// that do not grant the request will result in a ResponseError.
+func ParseResponse(bytes []byte) (*Token, error) {
+	var resp timeStampResp
+	rest, err := asn1.Unmarshal(bytes, &resp)
+	if err != nil {
+		return nil, err
+	}
+	if len(rest) > 0 {
+		return nil, ParseError("tsp: trailing data in time-stamp response")
+	}
+
+	if status := Status(resp.Status.Status); status != Granted && status != GrantedWithMods {
+		var failureInfo FailureInfo
+		for i := 0; i < failureInfoBits; i++ {
+			if resp.Status.FailInfo.At(i) != 0 {
+				failureInfo |= 1 << uint(i)
+			}
+		}
+		return nil, ResponseError{status, failureInfo, resp.Status.StatusString}
+	}
+
+	if len(resp.TimeStampToken.FullBytes) == 0 {
+		return nil, ParseError("tsp: time-stamp response contains no token")
+	}
+	return ParseToken(resp.TimeStampToken.FullBytes)
+}
+
+// ParseToken parses a time-stamp token in DER form, as obtained from a
+// response or stored alongside the data it time-stamps. The token must still
+// be verified with Verify.
+func ParseToken(bytes []byte) (*Token, error) {
+	sd, err := parseSignedData(bytes)
+	if err != nil {
+		return nil, err
+	}
+	if !sd.EncapContentInfo.EContentType.Equal(oidTSTInfo) {
+		return nil, ParseError("tsp: token does not contain TSTInfo")
+	}
+	if len(sd.SignerInfos) != 1 {
+		return nil, ParseError("tsp: token must have exactly one signer")
+	}
+
+	var info tstInfo
+	rest, err := asn1.Unmarshal(sd.EncapContentInfo.EContent, &info)
+	if err != nil {
+		return nil, err
+	}
+	if len(rest) > 0 {
+		return nil, ParseError("tsp: trailing data in TSTInfo")
+	}
+	if info.Version != 1 {
+		return nil, ParseError("tsp: unsupported TSTInfo version")
+	}
+	hashFunc, err := parseMessageImprint(&info.MessageImprint)
+	if err != nil {
+		return nil, err
+	}
+	if info.GenTime.Class != asn1.ClassUniversal || info.GenTime.Tag != asn1.TagGeneralizedTime {
+		return nil, ParseError("tsp: bad TSTInfo time")
+	}
+	genTime, err := parseGeneralizedTime(info.GenTime.Bytes)
+	if err != nil {
+		return nil, err
+	}
+	acc := info.Accuracy
+	if acc.Seconds < 0 || acc.Millis < 0 || acc.Millis > 999 || acc.Micros < 0 || acc.Micros > 999 {
+		return nil, ParseError("tsp: bad TSTInfo accuracy")
+	}
+
+	t := &Token{
+		Raw:           bytes,
+		Policy:        info.Policy,
+		HashAlgorithm: hashFunc,
+		HashedMessage: info.MessageImprint.HashedMessage,
+		SerialNumber:  info.SerialNumber,
+		Time:          genTime,
+		Accuracy:      time.Duration(acc.Seconds)*time.Second + time.Duration(acc.Millis)*time.Millisecond + time.Duration(acc.Micros)*time.Microsecond,
+		Ordering:      info.Ordering,
+		Nonce:         info.Nonce,
+		RawTSAName:    info.TSA.FullBytes,
+		Extensions:    info.Extensions,
+		signedData:    sd,
+		signer:        &sd.SignerInfos[0],
+	}
+	if len(sd.Certificates.Bytes) > 0 {
+		t.Certificates, err = x509.ParseCertificates(sd.Certificates.Bytes)
+		if err != nil {
+			return nil, err
+		}
+	}
+	for _, ext := range t.Extensions {
+		if ext.Critical {
+			return nil, ParseError("tsp: unsupported critical extension")
+		}
+	}
+	return t, nil
+}
+
+// parseGeneralizedTime parses the GeneralizedTime of a TSTInfo, which unlike
+// those of certificates may have a fractional part. See RFC 3161, Section
+// 2.4.2.
+func parseGeneralizedTime(b []byte) (time.Time, error) {
+	const layout = "20060102150405"
+	s := string(b)
+	if len(s) < len(layout)+1 || s[len(s)-1] != 'Z' {
+		return time.Time{}, ParseError("tsp: bad TSTInfo time")
+	}
+	t, err := time.Parse(layout, s[:len(layout)])
+	if err != nil || t.Format(layout) != s[:len(layout)] {
+		return time.Time{}, ParseError("tsp: bad TSTInfo time")
+	}
+
+	frac := s[len(layout) : len(s)-1]
+	if frac == "" {
+		return t, nil
+	}
+	// The fraction has at least one digit, and no trailing zero.
+	if len(frac) < 2 || frac[0] != '.' || frac[len(frac)-1] == '0' {
+		return time.Time{}, ParseError("tsp: bad TSTInfo time")
+	}
+	var nsec time.Duration
+	scale := time.Second
+	for _, c := range frac[1:] {
+		if c < '0' || c > '9' {
+			return time.Time{}, ParseError("tsp: bad TSTInfo time")
+		}
+		// Digits beyond the nanosecond are dropped.
+		scale /= 10
+		nsec += time.Duration(c-'0') * scale
+	}
+	return t.Add(nsec), nil
+}
+
+// CheckRequest checks that t was issued in response to req: that it
+// time-stamps the same hash, has the same nonce, if any, and was issued under
+// the requested policy, if any.
+func (t *Token) CheckRequest(req *Request) error {
+	if t.HashAlgorithm != req.HashAlgorithm || !bytes.Equal(t.HashedMessage, req.HashedMessage) {
+		return errors.New("tsp: token does not match the requested message")
+	}
+	if req.Nonce != nil && (t.Nonce == nil || t.Nonce.Cmp(req.Nonce) != 0) {
+		return errors.New("tsp: token does not match the request nonce")
+	}
+	if req.Policy != nil && !t.Policy.Equal(req.Policy) {
+		return errors.New("tsp: token was issued under another policy")
+	}
+	if req.CertReq {
+		if _, err := t.findSigner(nil); err != nil {
+			return errors.New("tsp: token does not include the requested certificate")
+		}
+	}
+	return nil
+}
+
+// CheckMessage checks that t time-stamps the data read from message.
+func (t *Token) CheckMessage(message io.Reader) error {
+	if !t.HashAlgorithm.Available() {
+		return x509.ErrUnsupportedAlgorithm
+	}
+	h := t.HashAlgorithm.New()
+	if _, err := io.Copy(h, message); err != nil {
+		return err
+	}
+	if !bytes.Equal(h.Sum(nil), t.HashedMessage) {
+		return errors.New("tsp: token does not match the message")
+	}
+	return nil
+}
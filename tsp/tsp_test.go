@@ -0,0 +1,617 @@
+// Copyright 2018 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package tsp
+
+import (
+	"bytes"
+	"context"
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha1"
+	"crypto/sha256"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/asn1"
+	"encoding/hex"
+	"errors"
+	"io/ioutil"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+// opensslMessage is the message time-stamped in opensslRequestHex and
+// opensslResponseHex, which were generated with "openssl ts -query -sha256
+// -cert" and "openssl ts -reply".
+const opensslMessage = "hello, world\n"
+
+// opensslRoots returns a pool with the root certificate included in token.
+func opensslRoots(token *Token) *x509.CertPool {
+	roots := x509.NewCertPool()
+	for _, cert := range token.Certificates {
+		if cert.IsCA {
+			roots.AddCert(cert)
+		}
+	}
+	return roots
+}
+
+func TestParseRequestOpenSSL(t *testing.T) {
+	der, _ := hex.DecodeString(opensslRequestHex)
+	req, err := ParseRequest(der)
+	if err != nil {
+		t.Fatal(err)
+	}
+	digest := sha256.Sum256([]byte(opensslMessage))
+	if req.HashAlgorithm != crypto.SHA256 || !bytes.Equal(req.HashedMessage, digest[:]) {
+		t.Errorf("bad message imprint: %v %x", req.HashAlgorithm, req.HashedMessage)
+	}
+	if req.Nonce == nil || req.Nonce.Cmp(big.NewInt(0x6f584f45087cf839)) != 0 {
+		t.Errorf("bad nonce: %v", req.Nonce)
+	}
+	if !req.CertReq || req.Policy != nil {
+		t.Errorf("bad request options: %#v", req)
+	}
+
+	marshaled, err := req.Marshal()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(marshaled, der) {
+		t.Errorf("remarshaled request differs:\n%x\n%x", marshaled, der)
+	}
+}
+
+func TestParseResponseOpenSSL(t *testing.T) {
+	der, _ := hex.DecodeString(opensslResponseHex)
+	token, err := ParseResponse(der)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if want := asn1.ObjectIdentifier([]int{1, 2, 3, 4, 1}); !token.Policy.Equal(want) {
+		t.Errorf("token.Policy: got %v, want %v", token.Policy, want)
+	}
+	if token.SerialNumber.Cmp(big.NewInt(2)) != 0 {
+		t.Errorf("token.SerialNumber: got %v, want 2", token.SerialNumber)
+	}
+	if want := time.Date(2026, 10, 16, 16, 39, 38, 237e6, time.UTC); !token.Time.Equal(want) {
+		t.Errorf("token.Time: got %v, want %v", token.Time, want)
+	}
+	if want := 1500100 * time.Microsecond; token.Accuracy != want {
+		t.Errorf("token.Accuracy: got %v, want %v", token.Accuracy, want)
+	}
+	if !token.Ordering {
+		t.Errorf("token.Ordering: got false, want true")
+	}
+	if len(token.RawTSAName) == 0 || len(token.Certificates) != 2 {
+		t.Errorf("got %d byte TSA name and %d certificates", len(token.RawTSAName), len(token.Certificates))
+	}
+
+	reqDER, _ := hex.DecodeString(opensslRequestHex)
+	req, err := ParseRequest(reqDER)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := token.CheckRequest(req); err != nil {
+		t.Errorf("CheckRequest: %s", err)
+	}
+	if err := token.CheckMessage(strings.NewReader(opensslMessage)); err != nil {
+		t.Errorf("CheckMessage: %s", err)
+	}
+	if err := token.CheckMessage(strings.NewReader("goodbye, world\n")); err == nil {
+		t.Errorf("CheckMessage accepted another message")
+	}
+	req.Nonce.Add(req.Nonce, big.NewInt(1))
+	if err := token.CheckRequest(req); err == nil {
+		t.Errorf("CheckRequest accepted another nonce")
+	}
+
+	signer, err := token.Verify(VerifyOptions{Roots: opensslRoots(token)})
+	if err != nil {
+		t.Fatalf("Verify: %s", err)
+	}
+	if signer.Subject.CommonName != "Test TSA" {
+		t.Errorf("signed by %q", signer.Subject.CommonName)
+	}
+
+	errPinned := errors.New("not the pinned TSA")
+	_, err = token.Verify(VerifyOptions{
+		Roots: opensslRoots(token),
+		CheckSigner: func(signer *x509.Certificate, chains [][]*x509.Certificate) error {
+			if len(chains) != 1 || len(chains[0]) != 2 {
+				t.Errorf("CheckSigner called with chains %v", chains)
+			}
+			return errPinned
+		},
+	})
+	if err != errPinned {
+		t.Errorf("Verify with failing CheckSigner: got %v, want %v", err, errPinned)
+	}
+
+	if _, err := token.Verify(VerifyOptions{Roots: x509.NewCertPool()}); err == nil {
+		t.Errorf("Verify accepted an untrusted TSA")
+	}
+	if _, err := token.Verify(VerifyOptions{Roots: opensslRoots(token), CurrentTime: time.Date(2040, 1, 1, 0, 0, 0, 0, time.UTC)}); err == nil {
+		t.Errorf("Verify accepted an expired TSA certificate")
+	}
+}
+
+// testTSA signs tokens with freshly generated certificates.
+type testTSA struct {
+	roots *x509.CertPool
+	cert  *x509.Certificate
+	key   crypto.Signer
+}
+
+var oidExtKeyUsageTimeStamping = asn1.ObjectIdentifier{1, 3, 6, 1, 5, 5, 7, 3, 8}
+
+// newTestTSA returns a TSA with a key of the type of key, and a certificate
+// for it issued by a new root. The extended key usage extension of the
+// certificate, if critical, contains the given usages.
+func newTestTSA(t *testing.T, key crypto.Signer, usages []asn1.ObjectIdentifier, critical bool) *testTSA {
+	rootKey, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	notBefore := time.Date(2018, 1, 1, 0, 0, 0, 0, time.UTC)
+	rootTemplate := &x509.Certificate{
+		SerialNumber:          big.NewInt(1),
+		Subject:               pkix.Name{CommonName: "Test Root"},
+		NotBefore:             notBefore,
+		NotAfter:              notBefore.AddDate(10, 0, 0),
+		KeyUsage:              x509.KeyUsageCertSign,
+		BasicConstraintsValid: true,
+		IsCA:                  true,
+	}
+	rootDER, err := x509.CreateCertificate(rand.Reader, rootTemplate, rootTemplate, rootKey.Public(), rootKey)
+	if err != nil {
+		t.Fatal(err)
+	}
+	root, err := x509.ParseCertificate(rootDER)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	ekuDER, err := asn1.Marshal(usages)
+	if err != nil {
+		t.Fatal(err)
+	}
+	template := &x509.Certificate{
+		SerialNumber: big.NewInt(2),
+		Subject:      pkix.Name{CommonName: "Test TSA"},
+		NotBefore:    notBefore,
+		NotAfter:     notBefore.AddDate(5, 0, 0),
+		KeyUsage:     x509.KeyUsageDigitalSignature,
+		SubjectKeyId: []byte{1, 2, 3, 4},
+		ExtraExtensions: []pkix.Extension{
+			{Id: oidExtensionExtendedKeyUsage, Critical: critical, Value: ekuDER},
+		},
+	}
+	certDER, err := x509.CreateCertificate(rand.Reader, template, root, key.Public(), rootKey)
+	if err != nil {
+		t.Fatal(err)
+	}
+	cert, err := x509.ParseCertificate(certDER)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	roots := x509.NewCertPool()
+	roots.AddCert(root)
+	return &testTSA{roots, cert, key}
+}
+
+func testAttribute(t *testing.T, oid asn1.ObjectIdentifier, value interface{}) attribute {
+	der, err := asn1.Marshal(value)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return attribute{oid, []asn1.RawValue{{FullBytes: der}}}
+}
+
+// sign returns a token for info, signed with SHA-256. If modify is not nil,
+// it is called with the signed attributes and SignerInfo before signing.
+func (tsa *testTSA) sign(t *testing.T, info *tstInfo, modify func(attrs *[]attribute, si *signerInfo)) []byte {
+	eContent, err := asn1.Marshal(*info)
+	if err != nil {
+		t.Fatal(err)
+	}
+	digest := sha256.Sum256(eContent)
+	certHash := sha256.Sum256(tsa.cert.Raw)
+	attrs := []attribute{
+		testAttribute(t, oidAttributeContentType, oidTSTInfo),
+		testAttribute(t, oidAttributeMessageDigest, digest[:]),
+		testAttribute(t, oidAttributeSigningCertificateV2, signingCertificateV2{
+			Certs: []essCertIDv2{{CertHash: certHash[:]}},
+		}),
+	}
+
+	sid, err := asn1.Marshal(issuerAndSerialNumber{
+		Issuer:       asn1.RawValue{FullBytes: tsa.cert.RawIssuer},
+		SerialNumber: tsa.cert.SerialNumber,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	sha256Algorithm := pkix.AlgorithmIdentifier{Algorithm: hashOIDs[crypto.SHA256]}
+	si := signerInfo{
+		Version:         1,
+		SID:             asn1.RawValue{FullBytes: sid},
+		DigestAlgorithm: sha256Algorithm,
+	}
+	switch tsa.key.Public().(type) {
+	case *rsa.PublicKey:
+		si.SignatureAlgorithm.Algorithm = oidPublicKeyRSA
+	case *ecdsa.PublicKey:
+		si.SignatureAlgorithm.Algorithm = oidSignatureECDSAWithSHA256
+	}
+	if modify != nil {
+		modify(&attrs, &si)
+	}
+
+	if attrs != nil {
+		attrsDER, err := asn1.MarshalWithParams(attrs, "set")
+		if err != nil {
+			t.Fatal(err)
+		}
+		h := sha256.Sum256(attrsDER)
+		if si.Signature == nil {
+			si.Signature, err = tsa.key.Sign(rand.Reader, h[:], crypto.SHA256)
+			if err != nil {
+				t.Fatal(err)
+			}
+		}
+		attrsDER[0] = 0xa0 // [0] IMPLICIT, constructed
+		si.SignedAttrs = asn1.RawValue{FullBytes: attrsDER}
+	}
+
+	sd, err := asn1.Marshal(signedData{
+		Version:          3,
+		DigestAlgorithms: []pkix.AlgorithmIdentifier{sha256Algorithm},
+		EncapContentInfo: encapsulatedContentInfo{
+			EContentType: oidTSTInfo,
+			EContent:     eContent,
+		},
+		Certificates: asn1.RawValue{Class: asn1.ClassContextSpecific, Tag: 0, IsCompound: true, Bytes: tsa.cert.Raw},
+		SignerInfos:  []signerInfo{si},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	// encoding/asn1 does not add explicit tags to RawValues, so the
+	// content is tagged by hand.
+	token, err := asn1.Marshal(struct {
+		ContentType asn1.ObjectIdentifier
+		Content     asn1.RawValue
+	}{oidSignedData, asn1.RawValue{Class: asn1.ClassContextSpecific, Tag: 0, IsCompound: true, Bytes: sd}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return token
+}
+
+func testTSTInfo(genTime string) *tstInfo {
+	digest := sha256.Sum256([]byte("test message"))
+	return &tstInfo{
+		Version: 1,
+		Policy:  asn1.ObjectIdentifier{1, 2, 3},
+		MessageImprint: messageImprint{
+			HashAlgorithm: pkix.AlgorithmIdentifier{Algorithm: hashOIDs[crypto.SHA256]},
+			HashedMessage: digest[:],
+		},
+		SerialNumber: big.NewInt(42),
+		GenTime:      asn1.RawValue{Tag: asn1.TagGeneralizedTime, Bytes: []byte(genTime)},
+	}
+}
+
+func TestVerify(t *testing.T) {
+	ecdsaKey, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	rsaKey, err := rsa.GenerateKey(rand.Reader, 1024)
+	if err != nil {
+		t.Fatal(err)
+	}
+	timeStamping := []asn1.ObjectIdentifier{oidExtKeyUsageTimeStamping}
+	ecdsaTSA := newTestTSA(t, ecdsaKey, timeStamping, true)
+	rsaTSA := newTestTSA(t, rsaKey, timeStamping, true)
+
+	removeAttribute := func(oid asn1.ObjectIdentifier) func(*[]attribute, *signerInfo) {
+		return func(attrs *[]attribute, si *signerInfo) {
+			var kept []attribute
+			for _, attr := range *attrs {
+				if !attr.Type.Equal(oid) {
+					kept = append(kept, attr)
+				}
+			}
+			*attrs = kept
+		}
+	}
+	replaceAttribute := func(oid asn1.ObjectIdentifier, value interface{}) func(*[]attribute, *signerInfo) {
+		return func(attrs *[]attribute, si *signerInfo) {
+			removeAttribute(oid)(attrs, si)
+			*attrs = append(*attrs, testAttribute(t, oid, value))
+		}
+	}
+
+	tests := []struct {
+		desc    string
+		tsa     *testTSA
+		genTime string
+		modify  func(attrs *[]attribute, si *signerInfo)
+		ok      bool
+	}{
+		{desc: "ECDSA", tsa: ecdsaTSA, ok: true},
+		{desc: "RSA", tsa: rsaTSA, ok: true},
+		{desc: "subject key identifier", tsa: ecdsaTSA, ok: true, modify: func(attrs *[]attribute, si *signerInfo) {
+			si.Version = 3
+			si.SID = asn1.RawValue{Class: asn1.ClassContextSpecific, Tag: 0, Bytes: ecdsaTSA.cert.SubjectKeyId}
+		}},
+		{desc: "ESS signing certificate", tsa: ecdsaTSA, ok: true, modify: func(attrs *[]attribute, si *signerInfo) {
+			certHash := sha1.Sum(ecdsaTSA.cert.Raw)
+			removeAttribute(oidAttributeSigningCertificateV2)(attrs, si)
+			*attrs = append(*attrs, testAttribute(t, oidAttributeSigningCertificate, signingCertificate{
+				Certs: []essCertID{{CertHash: certHash[:]}},
+			}))
+		}},
+		{desc: "no signed attributes", tsa: ecdsaTSA, modify: func(attrs *[]attribute, si *signerInfo) {
+			*attrs = nil
+			si.Signature = []byte{0}
+		}},
+		{desc: "no signing certificate", tsa: ecdsaTSA, modify: removeAttribute(oidAttributeSigningCertificateV2)},
+		{desc: "no message digest", tsa: ecdsaTSA, modify: removeAttribute(oidAttributeMessageDigest)},
+		{desc: "wrong signing certificate", tsa: ecdsaTSA, modify: replaceAttribute(oidAttributeSigningCertificateV2, signingCertificateV2{
+			Certs: []essCertIDv2{{CertHash: make([]byte, 32)}},
+		})},
+		{desc: "wrong message digest", tsa: ecdsaTSA, modify: replaceAttribute(oidAttributeMessageDigest, make([]byte, 32))},
+		{desc: "wrong content type", tsa: ecdsaTSA, modify: replaceAttribute(oidAttributeContentType, oidSignedData)},
+		{desc: "bad signature", tsa: ecdsaTSA, modify: func(attrs *[]attribute, si *signerInfo) {
+			*attrs = append(*attrs, testAttribute(t, asn1.ObjectIdentifier{1, 2, 3}, 0))
+			si.Signature, _ = ecdsaKey.Sign(rand.Reader, make([]byte, 32), crypto.SHA256)
+		}},
+		{desc: "wrong signature algorithm", tsa: ecdsaTSA, modify: func(attrs *[]attribute, si *signerInfo) {
+			si.SignatureAlgorithm.Algorithm = oidSignatureSHA256WithRSA
+		}},
+		{desc: "unknown signer", tsa: ecdsaTSA, modify: func(attrs *[]attribute, si *signerInfo) {
+			si.SID = asn1.RawValue{Class: asn1.ClassContextSpecific, Tag: 0, Bytes: []byte{5, 6, 7, 8}}
+		}},
+		{desc: "before certificate", tsa: ecdsaTSA, genTime: "20171231235959.999Z"},
+		{desc: "non-critical extended key usage", tsa: newTestTSA(t, ecdsaKey, timeStamping, false)},
+		{desc: "extra extended key usage", tsa: newTestTSA(t, ecdsaKey, []asn1.ObjectIdentifier{
+			oidExtKeyUsageTimeStamping,
+			{1, 3, 6, 1, 5, 5, 7, 3, 3}, // id-kp-codeSigning
+		}, true)},
+	}
+	for _, test := range tests {
+		genTime := test.genTime
+		if genTime == "" {
+			genTime = "20180601120000.5Z"
+		}
+		der := test.tsa.sign(t, testTSTInfo(genTime), test.modify)
+		token, err := ParseToken(der)
+		if err != nil {
+			t.Errorf("%s: ParseToken: %s", test.desc, err)
+			continue
+		}
+		if err := token.CheckMessage(strings.NewReader("test message")); err != nil {
+			t.Errorf("%s: CheckMessage: %s", test.desc, err)
+		}
+		signer, err := token.Verify(VerifyOptions{Roots: test.tsa.roots})
+		if test.ok && err != nil {
+			t.Errorf("%s: Verify: %s", test.desc, err)
+		} else if test.ok && !signer.Equal(test.tsa.cert) {
+			t.Errorf("%s: Verify returned the certificate of %v", test.desc, signer.Subject)
+		} else if !test.ok && err == nil {
+			t.Errorf("%s: Verify succeeded", test.desc)
+		}
+	}
+}
+
+func TestVerifyIntermediates(t *testing.T) {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	tsa := newTestTSA(t, key, []asn1.ObjectIdentifier{oidExtKeyUsageTimeStamping}, true)
+	token, err := ParseToken(tsa.sign(t, testTSTInfo("20180601120000Z"), nil))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// Tokens need not include the certificate of the TSA.
+	token.Certificates = nil
+	if _, err := token.Verify(VerifyOptions{Roots: tsa.roots}); err == nil {
+		t.Errorf("Verify succeeded without the TSA certificate")
+	}
+	if _, err := token.Verify(VerifyOptions{Roots: tsa.roots, Intermediates: []*x509.Certificate{tsa.cert}}); err != nil {
+		t.Errorf("Verify with the TSA certificate in Intermediates: %s", err)
+	}
+	if err := token.CheckRequest(&Request{HashAlgorithm: token.HashAlgorithm, HashedMessage: token.HashedMessage, CertReq: true}); err == nil {
+		t.Errorf("CheckRequest accepted a token without the requested certificate")
+	}
+}
+
+func TestParseResponseError(t *testing.T) {
+	der, err := asn1.Marshal(timeStampResp{
+		Status: pkiStatusInfo{
+			Status:       int(Rejection),
+			StatusString: []string{"unsupported algorithm"},
+			FailInfo:     asn1.BitString{Bytes: []byte{0x80}, BitLength: 1},
+		},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, err = ParseResponse(der)
+	respErr, ok := err.(ResponseError)
+	if !ok {
+		t.Fatalf("got %v, want a ResponseError", err)
+	}
+	if respErr.Status != Rejection || respErr.FailureInfo != BadAlgorithm || len(respErr.StatusString) != 1 {
+		t.Errorf("unexpected ResponseError: %#v", respErr)
+	}
+
+	der, err = asn1.Marshal(timeStampResp{Status: pkiStatusInfo{Status: int(Granted)}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := ParseResponse(der); err == nil {
+		t.Errorf("ParseResponse accepted a response without a token")
+	}
+}
+
+func TestParseGeneralizedTime(t *testing.T) {
+	tests := []struct {
+		in   string
+		want time.Time
+		ok   bool
+	}{
+		{"20180102030405Z", time.Date(2018, 1, 2, 3, 4, 5, 0, time.UTC), true},
+		{"20180102030405.5Z", time.Date(2018, 1, 2, 3, 4, 5, 5e8, time.UTC), true},
+		{"20180102030405.000001Z", time.Date(2018, 1, 2, 3, 4, 5, 1e3, time.UTC), true},
+		{"20180102030405.1234567891Z", time.Date(2018, 1, 2, 3, 4, 5, 123456789, time.UTC), true},
+		{"20180102030405", time.Time{}, false},
+		{"20180102030405.Z", time.Time{}, false},
+		{"20180102030405.50Z", time.Time{}, false},
+		{"20180102030405,5Z", time.Time{}, false},
+		{"20180102030405.5aZ", time.Time{}, false},
+		{"20180102030405+0100", time.Time{}, false},
+		{"20181302030405Z", time.Time{}, false},
+		{"2018010203040Z", time.Time{}, false},
+	}
+	for _, test := range tests {
+		got, err := parseGeneralizedTime([]byte(test.in))
+		if test.ok && (err != nil || !got.Equal(test.want)) {
+			t.Errorf("parseGeneralizedTime(%q) = %v, %v, want %v", test.in, got, err, test.want)
+		} else if !test.ok && err == nil {
+			t.Errorf("parseGeneralizedTime(%q) succeeded", test.in)
+		}
+	}
+}
+
+func TestNewRequest(t *testing.T) {
+	req1, err := NewRequest(strings.NewReader("message"), nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	req2, err := NewRequest(strings.NewReader("message"), &RequestOptions{
+		Hash:         crypto.SHA512,
+		Policy:       asn1.ObjectIdentifier{1, 2, 3},
+		Certificates: true,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if req1.HashAlgorithm != crypto.SHA256 || req2.HashAlgorithm != crypto.SHA512 || len(req2.HashedMessage) != 64 {
+		t.Errorf("bad hash algorithms: %v, %v", req1.HashAlgorithm, req2.HashAlgorithm)
+	}
+	if req1.Nonce == nil || req1.Nonce.Cmp(req2.Nonce) == 0 {
+		t.Errorf("bad nonces: %v, %v", req1.Nonce, req2.Nonce)
+	}
+
+	der, err := req2.Marshal()
+	if err != nil {
+		t.Fatal(err)
+	}
+	parsed, err := ParseRequest(der)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !parsed.Policy.Equal(req2.Policy) || !parsed.CertReq || parsed.Nonce.Cmp(req2.Nonce) != 0 || !bytes.Equal(parsed.HashedMessage, req2.HashedMessage) {
+		t.Errorf("request did not round-trip: %#v, %#v", parsed, req2)
+	}
+
+	if _, err := NewRequest(strings.NewReader("message"), &RequestOptions{Hash: crypto.MD5}); err == nil {
+		t.Errorf("NewRequest accepted MD5")
+	}
+}
+
+func TestFetch(t *testing.T) {
+	reqDER, _ := hex.DecodeString(opensslRequestHex)
+	respDER, _ := hex.DecodeString(opensslResponseHex)
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := ioutil.ReadAll(r.Body)
+		if r.Method != "POST" || r.Header.Get("Content-Type") != "application/timestamp-query" || !bytes.Equal(body, reqDER) {
+			http.Error(w, "bad request", http.StatusBadRequest)
+			return
+		}
+		w.Header().Set("Content-Type", "application/timestamp-reply")
+		w.Write(respDER)
+	}))
+	defer ts.Close()
+
+	req, err := ParseRequest(reqDER)
+	if err != nil {
+		t.Fatal(err)
+	}
+	token, err := Fetch(context.Background(), nil, ts.URL, req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(token.HashedMessage, req.HashedMessage) {
+		t.Errorf("unexpected token: %#v", token)
+	}
+
+	// The server rejects any other request, and the token of the fixture
+	// does not match it either.
+	req.Nonce.Add(req.Nonce, big.NewInt(1))
+	if _, err := Fetch(context.Background(), nil, ts.URL, req); err == nil {
+		t.Errorf("Fetch succeeded with another request")
+	}
+}
+
+const opensslRequestHex = "30430201013031300d060960864801650304020105000420853ff93762a06ddbf722c4eb" +
+	"e9ddd66d8f63ddaea97f521c3ecc20da7c97602002086f584f45087cf8390101ff"
+
+const opensslResponseHex = "308205df3003020100308205d606092a864886f70d010702a08205c7308205c302010331" +
+	"0f300d060960864801650304020105003081b2060b2a864886f70d0109100104a081a204" +
+	"819f30819c02010106042a0304013031300d060960864801650304020105000420853ff9" +
+	"3762a06ddbf722c4ebe9ddd66d8f63ddaea97f521c3ecc20da7c97602002010218133230" +
+	"3236313031363136333933382e3233375a300a020101800201f48101640101ff02086f58" +
+	"4f45087cf839a02da42b302931143012060355040a0c0b476f7068657220546573743111" +
+	"300f06035504030c085465737420545341a0820399308201d130820177a0030201020214" +
+	"74f65344182d2b2d4805081a084f4b77e977f4d2300a06082a8648ce3d040302302e3114" +
+	"3012060355040a0c0b476f7068657220546573743116301406035504030c0d5465737420" +
+	"54534120526f6f74301e170d3236313031363136333933385a170d333631303133313633" +
+	"3933385a302931143012060355040a0c0b476f7068657220546573743111300f06035504" +
+	"030c0854657374205453413059301306072a8648ce3d020106082a8648ce3d0301070342" +
+	"000436592ff77ac6daf55df645db4e90a91273a1a1fef08ae82bb6c3df897d531240822f" +
+	"077d0c397a04676fa6dfb31d991a2f18ac1141a7b0c0dcdffdcbc84b50baa3783076300c" +
+	"0603551d130101ff04023000300e0603551d0f0101ff04040302078030160603551d2501" +
+	"01ff040c300a06082b06010505070308301d0603551d0e041604141591453ce8e48ca3a0" +
+	"1d34bdd019f47efef93bfa301f0603551d230418301680146931749fe409ccba958cf03e" +
+	"f1003157537ba579300a06082a8648ce3d0403020348003045022100a50663c75424af0c" +
+	"300a25d96115c88921bc7014a8f2913c1d51754192a89f1c02201e071c84069d71a3f6c0" +
+	"0f851cff2000006048653597dd7561a50f3b8830fd33308201c030820167a00302010202" +
+	"146a3595f884f4989c1e01f601df4ce76a8f8865c2300a06082a8648ce3d040302302e31" +
+	"143012060355040a0c0b476f7068657220546573743116301406035504030c0d54657374" +
+	"2054534120526f6f74301e170d3236313031363136333933385a170d3436313031313136" +
+	"333933385a302e31143012060355040a0c0b476f70686572205465737431163014060355" +
+	"04030c0d546573742054534120526f6f743059301306072a8648ce3d020106082a8648ce" +
+	"3d03010703420004d2c0f9037aa2ea1e54a100ba0e9c2d12b4bd79d10a70862ee0df9fe5" +
+	"ec91cadefb3e8273a68f3e9e162cfd4af39eadf6bc6f670ff94cc0ff3f1f286dbcec2066" +
+	"a3633061301d0603551d0e041604146931749fe409ccba958cf03ef1003157537ba57930" +
+	"1f0603551d230418301680146931749fe409ccba958cf03ef1003157537ba579300f0603" +
+	"551d130101ff040530030101ff300e0603551d0f0101ff040403020204300a06082a8648" +
+	"ce3d0403020347003044022007f178b50e3cc1abe23f3af348fd2ce6b78adaf9404285f9" +
+	"ae0624f4df1ca36002200f2c1c072fab36d025f7c3376c407ef8622133f94f27b628e89e" +
+	"b0a1e1a8847831820159308201550201013046302e31143012060355040a0c0b476f7068" +
+	"657220546573743116301406035504030c0d546573742054534120526f6f74021474f653" +
+	"44182d2b2d4805081a084f4b77e977f4d2300d06096086480165030402010500a081a430" +
+	"1a06092a864886f70d010903310d060b2a864886f70d0109100104301c06092a864886f7" +
+	"0d010905310f170d3236313031363136333933385a302f06092a864886f70d0109043122" +
+	"04200f290c5d3af059c056004a73c2e5350523cbcc4329ebbe0114b4e5bdec2b9a593037" +
+	"060b2a864886f70d010910022f31283026302430220420439ec438ff4aa48c9d1420d50f" +
+	"f85743082aba138191c25eb78e81e542a83801300a06082a8648ce3d0403020446304402" +
+	"20658c90c75371b79c9bfd0fbf878e432222d9a43b1f9268bc6c2642ddaac64fa1022065" +
+	"6a8504aea43e84e0cd3824a7526ffe0db2cf8c8478d609a9a9cd17362c1566"
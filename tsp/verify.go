@@ -0,0 +1,406 @@
+// Copyright 2018 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package tsp
+
+import (
+	"bytes"
+	"crypto"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/asn1"
+	"errors"
+	"math/big"
+	"time"
+)
+
+// These are internal structures that reflect the ASN.1 structure of the CMS
+// SignedData that wraps a TSTInfo. See RFC 5652, Section 5.
+
+type contentInfo struct {
+	ContentType asn1.ObjectIdentifier
+	Content     asn1.RawValue `asn1:"explicit,tag:0"`
+}
+
+type signedData struct {
+	Version          int
+	DigestAlgorithms []pkix.AlgorithmIdentifier `asn1:"set"`
+	EncapContentInfo encapsulatedContentInfo
+	Certificates     asn1.RawValue `asn1:"optional,tag:0"`
+	CRLs             asn1.RawValue `asn1:"optional,tag:1"`
+	SignerInfos      []signerInfo  `asn1:"set"`
+}
+
+type encapsulatedContentInfo struct {
+	EContentType asn1.ObjectIdentifier
+	EContent     []byte `asn1:"explicit,optional,tag:0"`
+}
+
+type signerInfo struct {
+	Version            int
+	SID                asn1.RawValue
+	DigestAlgorithm    pkix.AlgorithmIdentifier
+	SignedAttrs        asn1.RawValue `asn1:"optional,tag:0"`
+	SignatureAlgorithm pkix.AlgorithmIdentifier
+	Signature          []byte
+	UnsignedAttrs      asn1.RawValue `asn1:"optional,tag:1"`
+}
+
+type issuerAndSerialNumber struct {
+	Issuer       asn1.RawValue
+	SerialNumber *big.Int
+}
+
+type attribute struct {
+	Type   asn1.ObjectIdentifier
+	Values []asn1.RawValue `asn1:"set"`
+}
+
+// These are the ESS attributes that identify the certificate of the signer.
+// See RFC 2634, Section 5.4 and RFC 5035, Section 3.
+
+type signingCertificate struct {
+	Certs []essCertID
+}
+
+type essCertID struct {
+	CertHash []byte
+}
+
+type signingCertificateV2 struct {
+	Certs []essCertIDv2
+}
+
+type essCertIDv2 struct {
+	HashAlgorithm pkix.AlgorithmIdentifier `asn1:"optional"`
+	CertHash      []byte
+}
+
+var (
+	oidAttributeContentType          = asn1.ObjectIdentifier{1, 2, 840, 113549, 1, 9, 3}
+	oidAttributeMessageDigest        = asn1.ObjectIdentifier{1, 2, 840, 113549, 1, 9, 4}
+	oidAttributeSigningCertificate   = asn1.ObjectIdentifier{1, 2, 840, 113549, 1, 9, 16, 2, 12}
+	oidAttributeSigningCertificateV2 = asn1.ObjectIdentifier{1, 2, 840, 113549, 1, 9, 16, 2, 47}
+	oidExtensionExtendedKeyUsage     = asn1.ObjectIdentifier{2, 5, 29, 37}
+	oidPublicKeyRSA                  = asn1.ObjectIdentifier{1, 2, 840, 113549, 1, 1, 1}
+	oidPublicKeyECDSA                = asn1.ObjectIdentifier{1, 2, 840, 10045, 2, 1}
+	oidSignatureSHA1WithRSA          = asn1.ObjectIdentifier{1, 2, 840, 113549, 1, 1, 5}
+	oidSignatureSHA256WithRSA        = asn1.ObjectIdentifier{1, 2, 840, 113549, 1, 1, 11}
+	oidSignatureSHA384WithRSA        = asn1.ObjectIdentifier{1, 2, 840, 113549, 1, 1, 12}
+	oidSignatureSHA512WithRSA        = asn1.ObjectIdentifier{1, 2, 840, 113549, 1, 1, 13}
+	oidSignatureECDSAWithSHA1        = asn1.ObjectIdentifier{1, 2, 840, 10045, 4, 1}
+	oidSignatureECDSAWithSHA256      = asn1.ObjectIdentifier{1, 2, 840, 10045, 4, 3, 2}
+	oidSignatureECDSAWithSHA384      = asn1.ObjectIdentifier{1, 2, 840, 10045, 4, 3, 3}
+	oidSignatureECDSAWithSHA512      = asn1.ObjectIdentifier{1, 2, 840, 10045, 4, 3, 4}
+)
+
+// signatureAlgorithmDetails lists the signature algorithms of SignerInfos.
+// CMS identifies them either by the public key algorithm alone, in which case
+// hash is zero and the digest algorithm of the SignerInfo is used, or by a
+// combined algorithm, whose hash must match the digest algorithm.
+var signatureAlgorithmDetails = []struct {
+	oid        asn1.ObjectIdentifier
+	pubKeyAlgo x509.PublicKeyAlgorithm
+	hash       crypto.Hash
+}{
+	{oidPublicKeyRSA, x509.RSA, crypto.Hash(0)},
+	{oidSignatureSHA1WithRSA, x509.RSA, crypto.SHA1},
+	{oidSignatureSHA256WithRSA, x509.RSA, crypto.SHA256},
+	{oidSignatureSHA384WithRSA, x509.RSA, crypto.SHA384},
+	{oidSignatureSHA512WithRSA, x509.RSA, crypto.SHA512},
+	{oidPublicKeyECDSA, x509.ECDSA, crypto.Hash(0)},
+	{oidSignatureECDSAWithSHA1, x509.ECDSA, crypto.SHA1},
+	{oidSignatureECDSAWithSHA256, x509.ECDSA, crypto.SHA256},
+	{oidSignatureECDSAWithSHA384, x509.ECDSA, crypto.SHA384},
+	{oidSignatureECDSAWithSHA512, x509.ECDSA, crypto.SHA512},
+}
+
+// x509SignatureAlgorithms maps a public key algorithm and a hash function to
+// the x509.SignatureAlgorithm with which to check a signature.
+var x509SignatureAlgorithms = map[x509.PublicKeyAlgorithm]map[crypto.Hash]x509.SignatureAlgorithm{
+	x509.RSA: {
+		crypto.SHA1:   x509.SHA1WithRSA,
+		crypto.SHA256: x509.SHA256WithRSA,
+		crypto.SHA384: x509.SHA384WithRSA,
+		crypto.SHA512: x509.SHA512WithRSA,
+	},
+	x509.ECDSA: {
+		crypto.SHA1:   x509.ECDSAWithSHA1,
+		crypto.SHA256: x509.ECDSAWithSHA256,
+		crypto.SHA384: x509.ECDSAWithSHA384,
+		crypto.SHA512: x509.ECDSAWithSHA512,
+	},
+}
+
+func parseSignedData(bytes []byte) (*signedData, error) {
+	var ci contentInfo
+	rest, err := asn1.Unmarshal(bytes, &ci)
+	if err != nil {
+		return nil, err
+	}
+	if len(rest) > 0 {
+		return nil, ParseError("tsp: trailing data in time-stamp token")
+	}
+	if !ci.ContentType.Equal(oidSignedData) {
+		return nil, ParseError("tsp: time-stamp token is not SignedData")
+	}
+	sd := new(signedData)
+	rest, err = asn1.Unmarshal(ci.Content.Bytes, sd)
+	if err != nil {
+		return nil, err
+	}
+	if len(rest) > 0 {
+		return nil, ParseError("tsp: trailing data in SignedData")
+	}
+	return sd, nil
+}
+
+// VerifyOptions contains options for verifying time-stamp tokens.
+type VerifyOptions struct {
+	// Roots is the set of trusted root certificates. If nil, the system
+	// roots are used.
+	Roots *x509.CertPool
+
+	// Intermediates are certificates that are not trusted, but may be
+	// used to build a chain to Roots, in addition to those included in
+	// the token. They may also include the certificate of the TSA, for
+	// tokens that do not.
+	Intermediates []*x509.Certificate
+
+	// CurrentTime is the time at which the certificate chain of the TSA is
+	// checked. If zero, the time of the token is used, so that tokens
+	// remain valid after the certificate of the TSA expires.
+	CurrentTime time.Time
+
+	// CheckSigner, if not nil, is called with the certificate of the TSA
+	// and its verified chains once all other checks have passed, for
+	// example to pin the TSA or to check that its certificate was not
+	// revoked. If it returns an error, Verify returns that error.
+	CheckSigner func(signer *x509.Certificate, chains [][]*x509.Certificate) error
+}
+
+// Verify checks the signature on t, and that it was made by a TSA whose
+// certificate chains up to opts.Roots. It returns the certificate of the TSA.
+//
+// Verify does not check what t time-stamps; see CheckRequest and
+// CheckMessage.
+func (t *Token) Verify(opts VerifyOptions) (*x509.Certificate, error) {
+	signer, err := t.findSigner(opts.Intermediates)
+	if err != nil {
+		return nil, err
+	}
+	if err := t.checkSignature(signer); err != nil {
+		return nil, err
+	}
+	if err := checkTimeStampingUsage(signer); err != nil {
+		return nil, err
+	}
+
+	intermediates := x509.NewCertPool()
+	for _, cert := range t.Certificates {
+		intermediates.AddCert(cert)
+	}
+	for _, cert := range opts.Intermediates {
+		intermediates.AddCert(cert)
+	}
+	currentTime := opts.CurrentTime
+	if currentTime.IsZero() {
+		currentTime = t.Time
+	}
+	chains, err := signer.Verify(x509.VerifyOptions{
+		Roots:         opts.Roots,
+		Intermediates: intermediates,
+		CurrentTime:   currentTime,
+		KeyUsages:     []x509.ExtKeyUsage{x509.ExtKeyUsageTimeStamping},
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	if opts.CheckSigner != nil {
+		if err := opts.CheckSigner(signer, chains); err != nil {
+			return nil, err
+		}
+	}
+	return signer, nil
+}
+
+// findSigner returns the certificate, among those of t and extra, that the
+// SignerInfo of t identifies.
+func (t *Token) findSigner(extra []*x509.Certificate) (*x509.Certificate, error) {
+	sid := t.signer.SID
+	match := func(cert *x509.Certificate) bool {
+		switch {
+		case sid.Class == asn1.ClassUniversal && sid.Tag == asn1.TagSequence:
+			var ias issuerAndSerialNumber
+			if rest, err := asn1.Unmarshal(sid.FullBytes, &ias); err != nil || len(rest) > 0 {
+				return false
+			}
+			return bytes.Equal(cert.RawIssuer, ias.Issuer.FullBytes) && cert.SerialNumber.Cmp(ias.SerialNumber) == 0
+		case sid.Class == asn1.ClassContextSpecific && sid.Tag == 0:
+			return len(cert.SubjectKeyId) > 0 && bytes.Equal(cert.SubjectKeyId, sid.Bytes)
+		}
+		return false
+	}
+	for _, cert := range t.Certificates {
+		if match(cert) {
+			return cert, nil
+		}
+	}
+	for _, cert := range extra {
+		if match(cert) {
+			return cert, nil
+		}
+	}
+	return nil, errors.New("tsp: certificate of the TSA not found")
+}
+
+// checkSignature checks that the SignerInfo of t is a valid signature by cert
+// over the TSTInfo, and that its signed attributes identify cert.
+func (t *Token) checkSignature(cert *x509.Certificate) error {
+	si := t.signer
+
+	hashFunc := getHashAlgorithmFromOID(si.DigestAlgorithm.Algorithm)
+	if hashFunc == crypto.Hash(0) || !hashFunc.Available() {
+		return x509.ErrUnsupportedAlgorithm
+	}
+	var sigAlgo x509.SignatureAlgorithm
+	for _, details := range signatureAlgorithmDetails {
+		if !si.SignatureAlgorithm.Algorithm.Equal(details.oid) {
+			continue
+		}
+		if details.pubKeyAlgo != cert.PublicKeyAlgorithm || details.hash != 0 && details.hash != hashFunc {
+			return errors.New("tsp: signature algorithm does not match the signer")
+		}
+		sigAlgo = x509SignatureAlgorithms[details.pubKeyAlgo][hashFunc]
+		break
+	}
+	if sigAlgo == x509.UnknownSignatureAlgorithm {
+		return x509.ErrUnsupportedAlgorithm
+	}
+
+	// RFC 3161 requires signed attributes, since they identify the
+	// certificate of the TSA. The signature covers their DER encoding as a
+	// SET OF, rather than with the implicit tag of the SignerInfo.
+	if len(si.SignedAttrs.FullBytes) == 0 {
+		return errors.New("tsp: token has no signed attributes")
+	}
+	signed := append([]byte(nil), si.SignedAttrs.FullBytes...)
+	signed[0] = 0x31 // SET OF, constructed
+	var attrs []attribute
+	if rest, err := asn1.UnmarshalWithParams(signed, &attrs, "set"); err != nil || len(rest) > 0 {
+		return ParseError("tsp: bad signed attributes")
+	}
+	if err := cert.CheckSignature(sigAlgo, signed, si.Signature); err != nil {
+		return err
+	}
+
+	var contentType asn1.ObjectIdentifier
+	if ok, err := unmarshalAttribute(attrs, oidAttributeContentType, &contentType); err != nil {
+		return err
+	} else if !ok {
+		return errors.New("tsp: token has no content type attribute")
+	}
+	if !contentType.Equal(oidTSTInfo) {
+		return errors.New("tsp: signed content type is not TSTInfo")
+	}
+
+	var digest []byte
+	if ok, err := unmarshalAttribute(attrs, oidAttributeMessageDigest, &digest); err != nil {
+		return err
+	} else if !ok {
+		return errors.New("tsp: token has no message digest attribute")
+	}
+	h := hashFunc.New()
+	h.Write(t.signedData.EncapContentInfo.EContent)
+	if !bytes.Equal(h.Sum(nil), digest) {
+		return errors.New("tsp: TSTInfo does not match its signed digest")
+	}
+
+	return checkSigningCertificate(attrs, cert)
+}
+
+// unmarshalAttribute parses the single value of the attribute of type oid
+// into out. It returns false if there is no such attribute.
+func unmarshalAttribute(attrs []attribute, oid asn1.ObjectIdentifier, out interface{}) (bool, error) {
+	for _, attr := range attrs {
+		if !attr.Type.Equal(oid) {
+			continue
+		}
+		if len(attr.Values) != 1 {
+			return true, ParseError("tsp: attribute " + oid.String() + " must have a single value")
+		}
+		if rest, err := asn1.Unmarshal(attr.Values[0].FullBytes, out); err != nil || len(rest) > 0 {
+			return true, ParseError("tsp: bad attribute " + oid.String())
+		}
+		return true, nil
+	}
+	return false, nil
+}
+
+// checkSigningCertificate checks that the ESS signing certificate attribute,
+// which RFC 3161 and RFC 5816 require, identifies cert.
+func checkSigningCertificate(attrs []attribute, cert *x509.Certificate) error {
+	var hashFunc crypto.Hash
+	var certHash []byte
+
+	// The first certificate identifier is that of the signer.
+	var v2 signingCertificateV2
+	ok, err := unmarshalAttribute(attrs, oidAttributeSigningCertificateV2, &v2)
+	if err != nil {
+		return err
+	}
+	if ok {
+		if len(v2.Certs) == 0 {
+			return ParseError("tsp: empty signing certificate attribute")
+		}
+		hashFunc = crypto.SHA256
+		if alg := v2.Certs[0].HashAlgorithm.Algorithm; len(alg) > 0 {
+			hashFunc = getHashAlgorithmFromOID(alg)
+		}
+		certHash = v2.Certs[0].CertHash
+	} else {
+		var v1 signingCertificate
+		ok, err := unmarshalAttribute(attrs, oidAttributeSigningCertificate, &v1)
+		if err != nil {
+			return err
+		}
+		if !ok {
+			return errors.New("tsp: token has no signing certificate attribute")
+		}
+		if len(v1.Certs) == 0 {
+			return ParseError("tsp: empty signing certificate attribute")
+		}
+		hashFunc = crypto.SHA1
+		certHash = v1.Certs[0].CertHash
+	}
+
+	if hashFunc == crypto.Hash(0) || !hashFunc.Available() {
+		return x509.ErrUnsupportedAlgorithm
+	}
+	h := hashFunc.New()
+	h.Write(cert.Raw)
+	if !bytes.Equal(h.Sum(nil), certHash) {
+		return errors.New("tsp: signing certificate attribute does not match the signer")
+	}
+	return nil
+}
+
+// checkTimeStampingUsage checks that cert is dedicated to time-stamping, as
+// RFC 3161, Section 2.3 requires: its extended key usage extension must be
+// critical and contain only id-kp-timeStamping.
+func checkTimeStampingUsage(cert *x509.Certificate) error {
+	if len(cert.ExtKeyUsage) != 1 || cert.ExtKeyUsage[0] != x509.ExtKeyUsageTimeStamping || len(cert.UnknownExtKeyUsage) != 0 {
+		return errors.New("tsp: certificate of the TSA is not only for time-stamping")
+	}
+	for _, ext := range cert.Extensions {
+		if ext.Id.Equal(oidExtensionExtendedKeyUsage) {
+			if !ext.Critical {
+				return errors.New("tsp: extended key usage of the TSA certificate is not critical")
+			}
+			return nil
+		}
+	}
+	return errors.New("tsp: certificate of the TSA is not only for time-stamping")
+}
@@ -12,7 +12,13 @@ package twofish // import "golang.org/x/crypto/twofish"
 // LibTomCrypt is free for all purposes under the public domain.
 // It was heavily inspired by the go blowfish package.
 
-import "strconv"
+import (
+	"strconv"
+
+	"golang.org/x/crypto/policy"
+)
+
+var algorithm = policy.Register("twofish", false)
 
 // BlockSize is the constant block size of Twofish.
 const BlockSize = 16
@@ -34,7 +40,11 @@ func (k KeySizeError) Error() string {
 
 // NewCipher creates and returns a Cipher.
 // The key argument should be the Twofish key, 16, 24 or 32 bytes.
+// It returns a *policy.DisabledError if Twofish is disabled.
 func NewCipher(key []byte) (*Cipher, error) {
+	if err := algorithm.Check(); err != nil {
+		return nil, err
+	}
 	keylen := len(key)
 
 	if keylen != 16 && keylen != 24 && keylen != 32 {
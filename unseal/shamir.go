@@ -0,0 +1,89 @@
+// Copyright 2018 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package unseal
+
+import "io"
+
+// Shamir's secret sharing over GF(2^8), with the reduction polynomial
+// x^8 + x^4 + x^3 + x + 1 of AES. Every byte of the secret is shared with its
+// own random polynomial, whose constant term is the byte, and share i holds
+// the values of the polynomials at x = i, for i in [1, 255].
+
+// gfMul returns a*b in GF(2^8), in constant time.
+func gfMul(a, b byte) byte {
+	var p byte
+	for i := 0; i < 8; i++ {
+		p ^= -(b & 1) & a
+		b >>= 1
+		// a *= x, reducing if the top bit was set.
+		a = a<<1 ^ -(a>>7)&0x1b
+	}
+	return p
+}
+
+// gfInv returns the inverse of a in GF(2^8), or 0 if a is 0, in constant
+// time, as a^254.
+func gfInv(a byte) byte {
+	// 254 = 0b11111110
+	r := a
+	for i := 0; i < 6; i++ {
+		r = gfMul(r, r)
+		r = gfMul(r, a)
+	}
+	return gfMul(r, r)
+}
+
+// split returns n shares of secret, any threshold of which recover it. The
+// share for x = i+1 is shares[i]. It requires 1 <= threshold <= n <= 255.
+func split(rand io.Reader, secret []byte, threshold, n int) ([][]byte, error) {
+	shares := make([][]byte, n)
+	for i := range shares {
+		shares[i] = make([]byte, len(secret))
+	}
+	coeffs := make([]byte, threshold-1)
+	for j, s := range secret {
+		if _, err := io.ReadFull(rand, coeffs); err != nil {
+			return nil, err
+		}
+		for i := range shares {
+			// Horner's method.
+			x := byte(i + 1)
+			var y byte
+			for k := len(coeffs) - 1; k >= 0; k-- {
+				y = gfMul(y, x) ^ coeffs[k]
+			}
+			shares[i][j] = gfMul(y, x) ^ s
+		}
+	}
+	for i := range coeffs {
+		coeffs[i] = 0
+	}
+	return shares, nil
+}
+
+// combine recovers the secret from shares, which maps distinct non-zero x
+// coordinates to shares of the same length, by Lagrange interpolation at 0.
+func combine(shares map[byte][]byte) []byte {
+	var secret []byte
+	for xi, share := range shares {
+		// The Lagrange basis polynomial of xi, at 0, is the product of
+		// xj / (xj - xi) over the other xj, and subtraction is addition.
+		var num, den byte = 1, 1
+		for xj := range shares {
+			if xj != xi {
+				num = gfMul(num, xj)
+				den = gfMul(den, xj^xi)
+			}
+		}
+		l := gfMul(num, gfInv(den))
+		if secret == nil {
+			secret = make([]byte, len(share))
+		}
+		for j, y := range share {
+			secret[j] ^= gfMul(l, y)
+		}
+	}
+	return secret
+}
@@ -0,0 +1,320 @@
+// Copyright 2018 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+// Package unseal protects a root key, such as the key encryption key of a
+// configuration store, by splitting it among n operators, any threshold of
+// whom can recover it together.
+//
+// Seal splits the key with Shamir's secret sharing, and encrypts the share of
+// each operator under a key derived from the operator's passphrase with
+// Argon2id. The result can be stored with the protected data. To recover the
+// key, for instance when a service starts, operators enter their passphrases
+// one by one into an Unsealer, which recovers and checks the key once enough
+// shares were decrypted.
+//
+// Fewer than threshold shares reveal nothing about the key, even to someone
+// who knows their passphrases, but the security of each share against an
+// offline attack only rests on its passphrase.
+package unseal // import "golang.org/x/crypto/unseal"
+
+import (
+	"crypto/cipher"
+	"crypto/hmac"
+	cryptorand "crypto/rand"
+	"crypto/sha256"
+	"encoding/json"
+	"errors"
+	"io"
+
+	"golang.org/x/crypto/argon2"
+	"golang.org/x/crypto/chacha20poly1305"
+)
+
+// Params holds the Argon2id parameters used to derive the keys of shares
+// from passphrases. They are recorded in the Sealed key.
+type Params struct {
+	Time    uint32
+	Memory  uint32 // in KiB
+	Threads uint8
+}
+
+// DefaultParams follows the recommendations in the documentation of
+// golang.org/x/crypto/argon2.
+var DefaultParams = Params{Time: 1, Memory: 64 * 1024, Threads: 4}
+
+const (
+	// maxTime and maxMemory bound the work that a sealed key from an
+	// untrusted source can make Submit perform, and the memory, in KiB, it
+	// can make it allocate.
+	maxTime   = 64
+	maxMemory = 4 * 1024 * 1024
+)
+
+// validate returns an error if p is not usable with argon2.IDKey, or exceeds
+// the bounds above.
+func (p *Params) validate() error {
+	if p.Time < 1 || p.Time > maxTime || p.Memory > maxMemory || p.Threads < 1 {
+		return errParams
+	}
+	return nil
+}
+
+// UnmarshalJSON decodes p from JSON, and rejects parameters that are out of
+// bounds.
+func (p *Params) UnmarshalJSON(b []byte) error {
+	type params Params
+	var v params
+	if err := json.Unmarshal(b, &v); err != nil {
+		return err
+	}
+	if err := (*Params)(&v).validate(); err != nil {
+		return err
+	}
+	*p = Params(v)
+	return nil
+}
+
+// A Sealed holds a root key split among operators. It contains no secrets
+// and can be stored in the clear, for instance encoded as JSON.
+type Sealed struct {
+	// ID identifies the sealed key, and binds the shares to it.
+	ID []byte
+	// Threshold is the number of shares needed to recover the key.
+	Threshold int
+	// Params are the Argon2id parameters of all the shares. Seal and Submit
+	// reject parameters with a Time above 64 or a Memory above 4 GiB, so
+	// that a modified sealed key cannot exhaust the resources of operators.
+	Params Params
+	// Shares holds the encrypted share of each operator.
+	Shares []Share
+	// Check is a MAC of the ID under the key, which detects incorrect
+	// recoveries.
+	Check []byte
+}
+
+// A Share is the share of an operator, encrypted under a key derived from
+// the passphrase of the operator.
+type Share struct {
+	Salt       []byte
+	Nonce      []byte
+	Ciphertext []byte
+}
+
+var (
+	// ErrWrongPassphrase is returned by Unsealer.Submit when a share cannot
+	// be decrypted with the given passphrase.
+	ErrWrongPassphrase = errors.New("unseal: wrong passphrase")
+	// ErrCorrupt is returned by Unsealer.Submit when the recovered key does
+	// not match the sealed key.
+	ErrCorrupt = errors.New("unseal: recovered key does not match")
+
+	errThreshold       = errors.New("unseal: threshold must be between 1 and the number of operators")
+	errTooManyShares   = errors.New("unseal: too many operators")
+	errEmptyKey        = errors.New("unseal: empty key")
+	errEmptyPassphrase = errors.New("unseal: empty passphrase")
+	errBadIndex        = errors.New("unseal: no such share")
+	errDuplicate       = errors.New("unseal: share already submitted")
+	errMalformed       = errors.New("unseal: malformed sealed key")
+	errParams          = errors.New("unseal: invalid or excessive Argon2 parameters")
+)
+
+const (
+	idSize   = 16
+	saltSize = 16
+)
+
+// Seal splits key among operators with the given passphrases, so that any
+// threshold of them can recover it. Share i is encrypted under passphrases[i].
+// If rand is nil, crypto/rand.Reader is used, and if params is nil,
+// DefaultParams is used.
+func Seal(rand io.Reader, key []byte, threshold int, passphrases [][]byte, params *Params) (*Sealed, error) {
+	if rand == nil {
+		rand = cryptorand.Reader
+	}
+	if params == nil {
+		params = &DefaultParams
+	}
+	if err := params.validate(); err != nil {
+		return nil, err
+	}
+	if len(key) == 0 {
+		return nil, errEmptyKey
+	}
+	if len(passphrases) > 255 {
+		return nil, errTooManyShares
+	}
+	if threshold < 1 || threshold > len(passphrases) {
+		return nil, errThreshold
+	}
+	for _, p := range passphrases {
+		if len(p) == 0 {
+			return nil, errEmptyPassphrase
+		}
+	}
+
+	s := &Sealed{
+		ID:        make([]byte, idSize),
+		Threshold: threshold,
+		Params:    *params,
+		Shares:    make([]Share, len(passphrases)),
+	}
+	if _, err := io.ReadFull(rand, s.ID); err != nil {
+		return nil, err
+	}
+	s.Check = check(s.ID, key)
+
+	shares, err := split(rand, key, threshold, len(passphrases))
+	if err != nil {
+		return nil, err
+	}
+	for i, p := range passphrases {
+		sh := &s.Shares[i]
+		sh.Salt = make([]byte, saltSize)
+		sh.Nonce = make([]byte, chacha20poly1305.NonceSizeX)
+		if _, err := io.ReadFull(rand, sh.Salt); err != nil {
+			return nil, err
+		}
+		if _, err := io.ReadFull(rand, sh.Nonce); err != nil {
+			return nil, err
+		}
+		aead, err := s.shareAEAD(sh, p)
+		if err != nil {
+			return nil, err
+		}
+		sh.Ciphertext = aead.Seal(nil, sh.Nonce, shares[i], s.additionalData(i))
+		wipe(shares[i])
+	}
+	return s, nil
+}
+
+func check(id, key []byte) []byte {
+	m := hmac.New(sha256.New, key)
+	m.Write([]byte("golang.org/x/crypto/unseal check"))
+	m.Write(id)
+	return m.Sum(nil)
+}
+
+func (s *Sealed) shareAEAD(sh *Share, passphrase []byte) (cipher.AEAD, error) {
+	if err := s.Params.validate(); err != nil {
+		return nil, err
+	}
+	k := argon2.IDKey(passphrase, sh.Salt, s.Params.Time, s.Params.Memory, s.Params.Threads, chacha20poly1305.KeySize)
+	defer wipe(k)
+	return chacha20poly1305.NewX(k)
+}
+
+// additionalData binds share i to the sealed key, to its index, and to the
+// threshold.
+func (s *Sealed) additionalData(i int) []byte {
+	ad := append([]byte("golang.org/x/crypto/unseal v1"), s.ID...)
+	return append(ad, byte(i), byte(s.Threshold), byte(len(s.Shares)))
+}
+
+func wipe(b []byte) {
+	for i := range b {
+		b[i] = 0
+	}
+}
+
+// An Unsealer recovers a sealed key from the shares submitted by operators.
+// It is not safe for concurrent use.
+type Unsealer struct {
+	s      *Sealed
+	shares map[byte][]byte
+	key    []byte
+}
+
+// NewUnsealer returns an Unsealer for s.
+func (s *Sealed) NewUnsealer() *Unsealer {
+	return &Unsealer{s: s, shares: make(map[byte][]byte)}
+}
+
+// Submit decrypts share i with passphrase. Once threshold shares were
+// decrypted, it recovers the key, which is then returned by Key, and reports
+// that the key is unsealed. If the recovered key does not match the sealed
+// key, which can only happen if s was modified, it returns ErrCorrupt and
+// resets u.
+//
+// A share that cannot be decrypted is not counted, and Submit returns
+// ErrWrongPassphrase, so that the operator can try again. Neither is a share
+// whose length differs from that of the shares already submitted, which can
+// only happen if s was modified.
+func (u *Unsealer) Submit(i int, passphrase []byte) (unsealed bool, err error) {
+	if u.key != nil {
+		return true, nil
+	}
+	if len(u.s.Shares) > 255 || u.s.Threshold < 1 || u.s.Threshold > len(u.s.Shares) {
+		return false, errMalformed
+	}
+	if i < 0 || i >= len(u.s.Shares) {
+		return false, errBadIndex
+	}
+	x := byte(i + 1)
+	if _, ok := u.shares[x]; ok {
+		return false, errDuplicate
+	}
+
+	sh := &u.s.Shares[i]
+	if len(sh.Nonce) != chacha20poly1305.NonceSizeX {
+		return false, errMalformed
+	}
+	aead, err := u.s.shareAEAD(sh, passphrase)
+	if err != nil {
+		return false, err
+	}
+	share, err := aead.Open(nil, sh.Nonce, sh.Ciphertext, u.s.additionalData(i))
+	if err != nil {
+		return false, ErrWrongPassphrase
+	}
+	// combine sizes the key after whichever share it sees first, so all
+	// shares must have the same, non-zero length.
+	for _, other := range u.shares {
+		if len(share) != len(other) {
+			wipe(share)
+			return false, errMalformed
+		}
+	}
+	if len(share) == 0 {
+		return false, errMalformed
+	}
+	u.shares[x] = share
+	if len(u.shares) < u.s.Threshold {
+		return false, nil
+	}
+
+	key := combine(u.shares)
+	if !hmac.Equal(check(u.s.ID, key), u.s.Check) {
+		wipe(key)
+		u.Reset()
+		return false, ErrCorrupt
+	}
+	u.Reset()
+	u.key = key
+	return true, nil
+}
+
+// Progress returns the number of shares submitted so far, and the number
+// needed to unseal the key.
+func (u *Unsealer) Progress() (submitted, threshold int) {
+	if u.key != nil {
+		return u.s.Threshold, u.s.Threshold
+	}
+	return len(u.shares), u.s.Threshold
+}
+
+// Key returns the recovered key, or nil if it is still sealed.
+func (u *Unsealer) Key() []byte {
+	return u.key
+}
+
+// Reset discards the submitted shares and the recovered key, if any, and
+// overwrites them in memory.
+func (u *Unsealer) Reset() {
+	for x, share := range u.shares {
+		wipe(share)
+		delete(u.shares, x)
+	}
+	wipe(u.key)
+	u.key = nil
+}
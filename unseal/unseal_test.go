@@ -0,0 +1,235 @@
+// Copyright 2018 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package unseal
+
+import (
+	"bytes"
+	"crypto/rand"
+	"encoding/json"
+	"testing"
+)
+
+// testParams keeps the tests fast. They are not safe for real use.
+var testParams = &Params{Time: 1, Memory: 64, Threads: 1}
+
+func TestGFInverse(t *testing.T) {
+	for a := 1; a < 256; a++ {
+		if p := gfMul(byte(a), gfInv(byte(a))); p != 1 {
+			t.Fatalf("%d * gfInv(%d) = %d", a, a, p)
+		}
+	}
+	if gfInv(0) != 0 {
+		t.Error("gfInv(0) != 0")
+	}
+}
+
+func TestSplitCombine(t *testing.T) {
+	secret := []byte("the root key of the configuration store")
+	shares, err := split(rand.Reader, secret, 3, 5)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, xs := range [][]byte{{1, 2, 3}, {5, 3, 1}, {2, 4, 5}, {1, 2, 3, 4, 5}} {
+		m := make(map[byte][]byte)
+		for _, x := range xs {
+			m[x] = shares[x-1]
+		}
+		if got := combine(m); !bytes.Equal(got, secret) {
+			t.Errorf("shares %v: got %q", xs, got)
+		}
+	}
+	m := map[byte][]byte{1: shares[0], 2: shares[1]}
+	if got := combine(m); bytes.Equal(got, secret) {
+		t.Error("two shares recovered the secret")
+	}
+}
+
+func passphrases(n int) [][]byte {
+	p := make([][]byte, n)
+	for i := range p {
+		p[i] = []byte{'p', 'w', byte('0' + i)}
+	}
+	return p
+}
+
+func TestUnseal(t *testing.T) {
+	key := []byte("0123456789abcdef0123456789abcdef")
+	p := passphrases(5)
+	s, err := Seal(nil, key, 3, p, testParams)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// Round-trip through JSON, as the sealed key would be stored.
+	enc, err := json.Marshal(s)
+	if err != nil {
+		t.Fatal(err)
+	}
+	s = new(Sealed)
+	if err := json.Unmarshal(enc, s); err != nil {
+		t.Fatal(err)
+	}
+
+	u := s.NewUnsealer()
+	if ok, err := u.Submit(4, p[4]); ok || err != nil {
+		t.Fatalf("Submit(4) = %v, %v", ok, err)
+	}
+	if _, err := u.Submit(1, p[2]); err != ErrWrongPassphrase {
+		t.Fatalf("Submit with wrong passphrase: got %v", err)
+	}
+	if _, err := u.Submit(4, p[4]); err == nil {
+		t.Fatal("duplicate share accepted")
+	}
+	if _, err := u.Submit(5, p[4]); err == nil {
+		t.Fatal("out of range share accepted")
+	}
+	if ok, err := u.Submit(1, p[1]); ok || err != nil {
+		t.Fatalf("Submit(1) = %v, %v", ok, err)
+	}
+	if n, k := u.Progress(); n != 2 || k != 3 {
+		t.Fatalf("Progress() = %d, %d; want 2, 3", n, k)
+	}
+	if u.Key() != nil {
+		t.Fatal("key available before threshold")
+	}
+	if ok, err := u.Submit(0, p[0]); !ok || err != nil {
+		t.Fatalf("Submit(0) = %v, %v", ok, err)
+	}
+	if !bytes.Equal(u.Key(), key) {
+		t.Fatalf("Key() = %q, want %q", u.Key(), key)
+	}
+	if n, k := u.Progress(); n != 3 || k != 3 {
+		t.Fatalf("Progress() = %d, %d; want 3, 3", n, k)
+	}
+
+	u.Reset()
+	if u.Key() != nil {
+		t.Fatal("key available after Reset")
+	}
+	if n, _ := u.Progress(); n != 0 {
+		t.Fatalf("Progress() = %d after Reset", n)
+	}
+}
+
+func TestUnsealTampered(t *testing.T) {
+	key := []byte("0123456789abcdef")
+	p := passphrases(3)
+	s, err := Seal(nil, key, 2, p, testParams)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// Swapping shares breaks the binding to their indexes.
+	s.Shares[0], s.Shares[1] = s.Shares[1], s.Shares[0]
+	if _, err := s.NewUnsealer().Submit(0, p[1]); err != ErrWrongPassphrase {
+		t.Errorf("swapped share: got %v", err)
+	}
+	s.Shares[0], s.Shares[1] = s.Shares[1], s.Shares[0]
+
+	// A different check value is detected once the shares are combined.
+	s.Check[0] ^= 1
+	u := s.NewUnsealer()
+	if _, err := u.Submit(0, p[0]); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := u.Submit(2, p[2]); err != ErrCorrupt {
+		t.Errorf("tampered check: got %v", err)
+	}
+	if n, _ := u.Progress(); n != 0 || u.Key() != nil {
+		t.Error("Unsealer not reset after ErrCorrupt")
+	}
+}
+
+func TestUnsealShareLength(t *testing.T) {
+	p := passphrases(3)
+	s, err := Seal(nil, []byte("0123456789abcdef"), 2, p, testParams)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// Replace share 2 with a longer one, correctly encrypted, as whoever
+	// wrote the sealed key could.
+	sh := &s.Shares[2]
+	aead, err := s.shareAEAD(sh, p[2])
+	if err != nil {
+		t.Fatal(err)
+	}
+	sh.Ciphertext = aead.Seal(nil, sh.Nonce, make([]byte, 32), s.additionalData(2))
+
+	u := s.NewUnsealer()
+	if _, err := u.Submit(0, p[0]); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := u.Submit(2, p[2]); err != errMalformed {
+		t.Errorf("share of the wrong length: got %v", err)
+	}
+	if n, _ := u.Progress(); n != 1 {
+		t.Errorf("share of the wrong length was counted, %d submitted", n)
+	}
+	if unsealed, err := u.Submit(1, p[1]); err != nil || !unsealed {
+		t.Errorf("Submit(1) = %v, %v", unsealed, err)
+	}
+}
+
+func TestSealErrors(t *testing.T) {
+	key := []byte("key")
+	for _, tt := range []struct {
+		key       []byte
+		threshold int
+		p         [][]byte
+	}{
+		{nil, 1, passphrases(1)},
+		{key, 0, passphrases(2)},
+		{key, 3, passphrases(2)},
+		{key, 1, [][]byte{[]byte("a"), nil}},
+		{key, 2, make([][]byte, 256)},
+	} {
+		if _, err := Seal(nil, tt.key, tt.threshold, tt.p, testParams); err == nil {
+			t.Errorf("Seal(%q, %d, %d passphrases) succeeded", tt.key, tt.threshold, len(tt.p))
+		}
+	}
+}
+
+func TestBadParams(t *testing.T) {
+	bad := []Params{
+		{},
+		{Time: 0, Memory: 64, Threads: 1},
+		{Time: 1, Memory: 64, Threads: 0},
+		{Time: maxTime + 1, Memory: 64, Threads: 1},
+		{Time: 1, Memory: maxMemory + 1, Threads: 1},
+	}
+	s, err := Seal(nil, []byte("key"), 1, passphrases(1), testParams)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, p := range bad {
+		p := p
+		if _, err := Seal(nil, []byte("key"), 1, passphrases(1), &p); err == nil {
+			t.Errorf("Seal with %+v succeeded", p)
+		}
+
+		s.Params = p
+		if _, err := s.NewUnsealer().Submit(0, passphrases(1)[0]); err == nil {
+			t.Errorf("Submit with %+v succeeded", p)
+		}
+
+		enc, err := json.Marshal(s)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if err := json.Unmarshal(enc, new(Sealed)); err == nil {
+			t.Errorf("json.Unmarshal accepted %+v", p)
+		}
+	}
+
+	// A sealed key without parameters must not make Submit panic.
+	s = new(Sealed)
+	if err := json.Unmarshal([]byte(`{"Threshold":1,"Shares":[{"Nonce":"AAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAA"}]}`), s); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := s.NewUnsealer().Submit(0, []byte("a")); err != errParams {
+		t.Errorf("Submit without parameters: %v, want errParams", err)
+	}
+}
@@ -8,7 +8,13 @@ package xtea // import "golang.org/x/crypto/xtea"
 
 // For details, see http://www.cix.co.uk/~klockstone/xtea.pdf
 
-import "strconv"
+import (
+	"strconv"
+
+	"golang.org/x/crypto/policy"
+)
+
+var algorithm = policy.Register("xtea", false)
 
 // The XTEA block size in bytes.
 const BlockSize = 8
@@ -28,7 +34,11 @@ func (k KeySizeError) Error() string {
 // NewCipher creates and returns a new Cipher.
 // The key argument should be the XTEA key.
 // XTEA only supports 128 bit (16 byte) keys.
+// It returns a *policy.DisabledError if XTEA is disabled.
 func NewCipher(key []byte) (*Cipher, error) {
+	if err := algorithm.Check(); err != nil {
+		return nil, err
+	}
 	k := len(key)
 	switch k {
 	default:
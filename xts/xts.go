@@ -25,8 +25,14 @@ import (
 	"crypto/cipher"
 	"encoding/binary"
 	"errors"
+
+	"golang.org/x/crypto/policy"
 )
 
+// XTS-AES is approved by SP 800-38E. XTS with other block ciphers is gated
+// by the policy of the block cipher.
+var algorithm = policy.Register("xts", true)
+
 // Cipher contains an expanded key structure. It doesn't contain mutable state
 // and therefore can be used concurrently.
 type Cipher struct {
@@ -39,8 +45,12 @@ const blockSize = 16
 
 // NewCipher creates a Cipher given a function for creating the underlying
 // block cipher (which must have a block size of 16 bytes). The key must be
-// twice the length of the underlying cipher's key.
+// twice the length of the underlying cipher's key. It returns a
+// *policy.DisabledError if XTS is disabled.
 func NewCipher(cipherFunc func([]byte) (cipher.Block, error), key []byte) (c *Cipher, err error) {
+	if err := algorithm.Check(); err != nil {
+		return nil, err
+	}
 	c = new(Cipher)
 	if c.k1, err = cipherFunc(key[:len(key)/2]); err != nil {
 		return